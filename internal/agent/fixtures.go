@@ -0,0 +1,362 @@
+package agent
+
+// fixtures.go implements the labelled capture corpus used to catch state
+// detection regressions: `ntm agents capture-fixture` saves a pane capture
+// with its expected state, and VerifyCorpus (driven by `ntm agents
+// verify-fixtures` and the corpus test) replays every fixture through the
+// parser, the gate detector and plugin patterns.
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// StateClass is the single label a pane state reduces to for fixture
+// verification.
+type StateClass string
+
+const (
+	ClassGate        StateClass = "gate"
+	ClassRateLimited StateClass = "rate_limited"
+	ClassError       StateClass = "error"
+	ClassWorking     StateClass = "working"
+	ClassIdle        StateClass = "idle"
+	ClassUnknown     StateClass = "unknown"
+)
+
+// StateClasses lists the classes in precedence order, which is also the row
+// and column order of a confusion matrix.
+var StateClasses = []StateClass{ClassGate, ClassRateLimited, ClassError, ClassWorking, ClassIdle, ClassUnknown}
+
+// ParseStateClass validates a class name.
+func ParseStateClass(s string) (StateClass, error) {
+	for _, c := range StateClasses {
+		if string(c) == s {
+			return c, nil
+		}
+	}
+	names := make([]string, len(StateClasses))
+	for i, c := range StateClasses {
+		names[i] = string(c)
+	}
+	return "", fmt.Errorf("unknown state class %q (want one of %s)", s, strings.Join(names, ", "))
+}
+
+// ClassifyState reduces a parsed state and gate verdict to one class. A gate
+// screen blocks everything else; the rest follows GetRecommendation's
+// precedence.
+func ClassifyState(st *AgentState, gate bool) StateClass {
+	switch {
+	case gate:
+		return ClassGate
+	case st.IsRateLimited:
+		return ClassRateLimited
+	case st.IsInError:
+		return ClassError
+	case st.IsWorking:
+		return ClassWorking
+	case st.IsIdle:
+		return ClassIdle
+	}
+	return ClassUnknown
+}
+
+// ExpectedState is the part of AgentState a fixture pins.
+type ExpectedState struct {
+	IsWorking     bool   `json:"is_working"`
+	IsIdle        bool   `json:"is_idle"`
+	IsRateLimited bool   `json:"rate_limited"`
+	IsContextLow  bool   `json:"context_low"`
+	IsInError     bool   `json:"is_in_error"`
+	Gate          bool   `json:"gate"`
+	GatePhrase    string `json:"gate_phrase,omitempty"`
+}
+
+// FixturePlugin snapshots a plugin agent's readiness patterns so the fixture
+// replays without the plugin installed.
+type FixturePlugin struct {
+	Idle    []string `json:"idle_patterns,omitempty"`
+	Working []string `json:"working_patterns,omitempty"`
+	Error   []string `json:"error_patterns,omitempty"`
+}
+
+// Fixture is one labelled capture. It is stored as <name>.json next to the
+// raw capture <name>.txt under <corpus>/<agent type>/, unless CaptureFile
+// points at a capture kept elsewhere.
+type Fixture struct {
+	Name       string         `json:"name"`
+	AgentType  AgentType      `json:"agent_type"`
+	Width      int            `json:"width,omitempty"`
+	Height     int            `json:"height,omitempty"`
+	Label      StateClass     `json:"label"`
+	Expected   ExpectedState  `json:"expected"`
+	Plugin     *FixturePlugin `json:"plugin,omitempty"`
+	Source     string         `json:"source,omitempty"`
+	Note       string         `json:"note,omitempty"`
+	CapturedAt time.Time      `json:"captured_at,omitzero"`
+	// CaptureFile is the capture's path relative to the sidecar, for a
+	// fixture that replays a sample another test already keeps.
+	CaptureFile string `json:"capture_file,omitempty"`
+
+	// Capture is the pane text; it lives in the .txt file.
+	Capture string `json:"-"`
+}
+
+// DefaultCorpusDir returns where captured fixtures are kept:
+// $XDG_DATA_HOME/ntm/agent-fixtures, else ~/.local/share/ntm/agent-fixtures.
+func DefaultCorpusDir() string {
+	if xdg := os.Getenv("XDG_DATA_HOME"); xdg != "" {
+		return filepath.Join(xdg, "ntm", "agent-fixtures")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return filepath.Join(os.TempDir(), fmt.Sprintf("ntm_agent_fixtures_%d", os.Getuid()))
+	}
+	return filepath.Join(home, ".local", "share", "ntm", "agent-fixtures")
+}
+
+var fixtureNameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// evaluate replays the fixture's capture.
+func (f *Fixture) evaluate() (*AgentState, string, bool) {
+	st, _ := NewParser().ParseWithHint(f.Capture, f.AgentType)
+	gate, found := DetectInteractiveGate(f.Capture, f.Width)
+	return st, gate, found
+}
+
+// NewFixture builds a fixture from a capture. Expected is the current
+// verdict when it agrees with label; otherwise it is what label implies, so
+// a capture taken to record a misclassification pins the correct answer.
+func NewFixture(name string, agentType AgentType, capture string, width, height int, label StateClass) (*Fixture, error) {
+	if !fixtureNameRe.MatchString(name) {
+		return nil, fmt.Errorf("invalid fixture name %q (letters, digits, '.', '_', '-')", name)
+	}
+	f := &Fixture{
+		Name:       name,
+		AgentType:  agentType.Canonical(),
+		Width:      width,
+		Height:     height,
+		Label:      label,
+		CapturedAt: time.Now().UTC(),
+		Capture:    capture,
+	}
+	if f.AgentType == "" {
+		f.AgentType = AgentTypeUnknown
+	}
+	if pp, ok := LookupPluginPatterns(f.AgentType); ok && pp.Declared() {
+		f.Plugin = &FixturePlugin{}
+		for _, re := range pp.Idle {
+			f.Plugin.Idle = append(f.Plugin.Idle, re.String())
+		}
+		for _, re := range pp.Working {
+			f.Plugin.Working = append(f.Plugin.Working, re.String())
+		}
+		for _, re := range pp.Error {
+			f.Plugin.Error = append(f.Plugin.Error, re.String())
+		}
+	}
+
+	st, gate, found := f.evaluate()
+	if ClassifyState(st, found) == label {
+		f.Expected = ExpectedState{
+			IsWorking:     st.IsWorking,
+			IsIdle:        st.IsIdle,
+			IsRateLimited: st.IsRateLimited,
+			IsContextLow:  st.IsContextLow,
+			IsInError:     st.IsInError,
+			Gate:          found,
+			GatePhrase:    gate,
+		}
+		return f, nil
+	}
+	switch label {
+	case ClassGate:
+		f.Expected.Gate = true
+	case ClassRateLimited:
+		f.Expected.IsRateLimited = true
+	case ClassError:
+		f.Expected.IsInError = true
+	case ClassWorking:
+		f.Expected.IsWorking = true
+	case ClassIdle:
+		f.Expected.IsIdle = true
+	}
+	return f, nil
+}
+
+// SaveFixture writes f into corpus, refusing to overwrite an existing
+// fixture unless overwrite is set. It returns the metadata path.
+func SaveFixture(corpus string, f *Fixture, overwrite bool) (string, error) {
+	dir := filepath.Join(corpus, string(f.AgentType))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	meta := filepath.Join(dir, f.Name+".json")
+	if !overwrite {
+		if _, err := os.Stat(meta); err == nil {
+			return "", fmt.Errorf("fixture %s/%s already exists", f.AgentType, f.Name)
+		}
+	}
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(dir, f.Name+".txt"), []byte(f.Capture), 0o644); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(meta, append(data, '\n'), 0o644); err != nil {
+		return "", err
+	}
+	return meta, nil
+}
+
+// LoadCorpus reads every fixture under corpus, sorted by agent type and
+// name. A missing corpus yields no fixtures.
+func LoadCorpus(corpus string) ([]*Fixture, error) {
+	metas, err := filepath.Glob(filepath.Join(corpus, "*", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var fixtures []*Fixture
+	for _, meta := range metas {
+		data, err := os.ReadFile(meta)
+		if err != nil {
+			return nil, err
+		}
+		var f Fixture
+		if err := json.Unmarshal(data, &f); err != nil {
+			return nil, fmt.Errorf("%s: %w", meta, err)
+		}
+		if _, err := ParseStateClass(string(f.Label)); err != nil {
+			return nil, fmt.Errorf("%s: %w", meta, err)
+		}
+		capturePath := strings.TrimSuffix(meta, ".json") + ".txt"
+		if f.CaptureFile != "" {
+			capturePath = filepath.Join(filepath.Dir(meta), filepath.FromSlash(f.CaptureFile))
+		}
+		capture, err := os.ReadFile(capturePath)
+		if err != nil {
+			return nil, fmt.Errorf("%s: capture: %w", meta, err)
+		}
+		f.Capture = string(capture)
+		fixtures = append(fixtures, &f)
+	}
+	sort.Slice(fixtures, func(i, j int) bool {
+		if fixtures[i].AgentType != fixtures[j].AgentType {
+			return fixtures[i].AgentType < fixtures[j].AgentType
+		}
+		return fixtures[i].Name < fixtures[j].Name
+	})
+	return fixtures, nil
+}
+
+// FixtureResult is the replay of one fixture.
+type FixtureResult struct {
+	Fixture   string     `json:"fixture"`
+	AgentType AgentType  `json:"agent_type"`
+	Label     StateClass `json:"label"`
+	Predicted StateClass `json:"predicted"`
+
+	// Mismatches names the expected fields the replay disagrees with.
+	Mismatches []string `json:"mismatches,omitempty"`
+}
+
+// Passed reports whether the replay reproduced the label and every pinned
+// field.
+func (r FixtureResult) Passed() bool {
+	return r.Label == r.Predicted && len(r.Mismatches) == 0
+}
+
+// ConfusionMatrix counts fixtures by labelled (row) and predicted (column)
+// class.
+type ConfusionMatrix map[StateClass]map[StateClass]int
+
+// CorpusReport is the outcome of replaying a corpus.
+type CorpusReport struct {
+	Corpus   string                        `json:"corpus"`
+	Total    int                           `json:"total"`
+	Passed   int                           `json:"passed"`
+	Results  []FixtureResult               `json:"results"`
+	Matrices map[AgentType]ConfusionMatrix `json:"confusion_matrices"`
+}
+
+// Failures returns the results that did not pass.
+func (r *CorpusReport) Failures() []FixtureResult {
+	var out []FixtureResult
+	for _, res := range r.Results {
+		if !res.Passed() {
+			out = append(out, res)
+		}
+	}
+	return out
+}
+
+// VerifyCorpus replays every fixture in corpus through ParseWithHint,
+// DetectInteractiveGate and, for plugin agent types, the plugin patterns.
+// A plugin type that is not registered is registered from the fixture's
+// snapshot; a registered one is replayed with its current patterns so
+// editing a plugin is verified against the corpus.
+func VerifyCorpus(corpus string) (*CorpusReport, error) {
+	fixtures, err := LoadCorpus(corpus)
+	if err != nil {
+		return nil, err
+	}
+	report := &CorpusReport{Corpus: corpus, Matrices: map[AgentType]ConfusionMatrix{}}
+	for _, f := range fixtures {
+		if f.Plugin != nil && !IsPluginType(f.AgentType) {
+			if err := RegisterPlugin(string(f.AgentType), f.Plugin.Idle, f.Plugin.Working, f.Plugin.Error); err != nil {
+				return nil, fmt.Errorf("fixture %s/%s: %w", f.AgentType, f.Name, err)
+			}
+		}
+		st, phrase, found := f.evaluate()
+		res := FixtureResult{
+			Fixture:   string(f.AgentType) + "/" + f.Name,
+			AgentType: f.AgentType,
+			Label:     f.Label,
+			Predicted: ClassifyState(st, found),
+		}
+		want := f.Expected
+		check := func(field string, got, expected bool) {
+			if got != expected {
+				res.Mismatches = append(res.Mismatches, fmt.Sprintf("%s=%t, want %t", field, got, expected))
+			}
+		}
+		check("is_working", st.IsWorking, want.IsWorking)
+		check("is_idle", st.IsIdle, want.IsIdle)
+		check("rate_limited", st.IsRateLimited, want.IsRateLimited)
+		check("context_low", st.IsContextLow, want.IsContextLow)
+		check("is_in_error", st.IsInError, want.IsInError)
+		check("gate", found, want.Gate)
+		if found && want.GatePhrase != "" && phrase != want.GatePhrase {
+			res.Mismatches = append(res.Mismatches, fmt.Sprintf("gate_phrase=%q, want %q", phrase, want.GatePhrase))
+		}
+
+		m := report.Matrices[f.AgentType]
+		if m == nil {
+			m = ConfusionMatrix{}
+			report.Matrices[f.AgentType] = m
+		}
+		if m[res.Label] == nil {
+			m[res.Label] = map[StateClass]int{}
+		}
+		m[res.Label][res.Predicted]++
+
+		report.Total++
+		if res.Passed() {
+			report.Passed++
+		}
+		report.Results = append(report.Results, res)
+	}
+	return report, nil
+}
+
+// ErrFixturesFailed is returned by callers that turn a failing corpus into
+// a non-zero exit.
+var ErrFixturesFailed = errors.New("fixtures misclassified")
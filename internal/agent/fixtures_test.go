@@ -0,0 +1,106 @@
+package agent
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestFixtureCorpus replays testdata/corpus, the labelled pane captures
+// maintained with `ntm agents capture-fixture`. A failure here means a
+// pattern or rule change altered how a real screen is classified.
+func TestFixtureCorpus(t *testing.T) {
+	UnregisterPlugins()
+	t.Cleanup(UnregisterPlugins)
+
+	report, err := VerifyCorpus(filepath.Join("testdata", "corpus"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Total == 0 {
+		t.Fatal("corpus is empty")
+	}
+	for _, res := range report.Results {
+		t.Run(res.Fixture, func(t *testing.T) {
+			if !res.Passed() {
+				t.Errorf("label %s, predicted %s; %s", res.Label, res.Predicted, strings.Join(res.Mismatches, "; "))
+			}
+		})
+	}
+	for _, at := range []AgentType{AgentTypeClaudeCode, AgentTypeCodex, AgentTypeGemini, "omp"} {
+		if report.Matrices[at] == nil {
+			t.Errorf("no fixtures for %s", at)
+		}
+	}
+}
+
+func TestSaveFixtureRoundTripAndMisclassification(t *testing.T) {
+	corpus := t.TempDir()
+
+	// A Codex idle prompt labelled working pins the label, not the
+	// (disagreeing) observed state, so replay reports the miss.
+	f, err := NewFixture("mislabelled", AgentTypeCodex, "codex>\n", 80, 24, ClassWorking)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !f.Expected.IsWorking || f.Expected.IsIdle {
+		t.Fatalf("expected = %+v, want the label's flags", f.Expected)
+	}
+	if _, err := SaveFixture(corpus, f, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SaveFixture(corpus, f, false); err == nil {
+		t.Fatal("overwrite without --force succeeded")
+	}
+
+	report, err := VerifyCorpus(corpus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Total != 1 || report.Passed != 0 {
+		t.Fatalf("total=%d passed=%d", report.Total, report.Passed)
+	}
+	if got := report.Matrices[AgentTypeCodex][ClassWorking][ClassIdle]; got != 1 {
+		t.Errorf("matrix[working][idle] = %d, want 1", got)
+	}
+	if fails := report.Failures(); len(fails) != 1 || fails[0].Fixture != "cod/mislabelled" {
+		t.Errorf("failures = %+v", fails)
+	}
+}
+
+func TestNewFixtureRejectsBadName(t *testing.T) {
+	if _, err := NewFixture("../escape", AgentTypeCodex, "", 0, 0, ClassIdle); err == nil {
+		t.Fatal("path-like fixture name accepted")
+	}
+}
+
+func TestLoadCorpusFollowsCaptureFile(t *testing.T) {
+	corpus := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(corpus, "cod"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(corpus, "shared.txt"), []byte("codex>\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	meta := `{"name":"idle","agent_type":"cod","label":"idle","expected":{"is_idle":true},"capture_file":"../shared.txt"}`
+	if err := os.WriteFile(filepath.Join(corpus, "cod", "idle.json"), []byte(meta), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	fixtures, err := LoadCorpus(corpus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fixtures) != 1 || fixtures[0].Capture != "codex>\n" {
+		t.Fatalf("fixtures = %+v, want the shared capture", fixtures)
+	}
+	data, err := json.Marshal(fixtures[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "captured_at") {
+		t.Errorf("unknown capture time serialized: %s", data)
+	}
+}
@@ -52,10 +52,10 @@ func (p *parserImpl) ParseWithHint(output string, hint AgentType) (*AgentState,
 	p.extractMetrics(cleanOutput, state)
 
 	// Step 3: Detect state flags
-	p.detectStateFlags(cleanOutput, state)
+	weight := p.detectStateFlags(cleanOutput, state)
 
-	// Step 4: Calculate confidence
-	state.Confidence = p.calculateConfidence(state)
+	// Step 4: Calculate confidence, scaled by the deciding rule's weight
+	state.Confidence = p.calculateConfidence(state) * weight
 
 	// Step 5: Keep sample for debugging (last N chars)
 	state.RawSample = util.SafeSliceFromEnd(cleanOutput, p.config.SampleLength)
@@ -212,398 +212,97 @@ func (p *parserImpl) extractMetrics(output string, state *AgentState) {
 	}
 }
 
-// detectStateFlags sets qualitative state flags based on output patterns.
-func (p *parserImpl) detectStateFlags(output string, state *AgentState) {
+// detectStateFlags sets the qualitative state flags from the agent type's
+// detection rules (see rules.go) and returns the weight of the rule that
+// decided the reported state, which scales the parse confidence.
+func (p *parserImpl) detectStateFlags(output string, state *AgentState) float64 {
+	rules := rulesFor(state.Type)
+
 	// Rate limit detection (highest priority - agent is blocked)
-	state.IsRateLimited = p.detectRateLimit(output, state.Type)
+	limit := rules.decide(RuleStateRateLimited, output, false)
+	state.IsRateLimited = limit.Detected
 	if state.IsRateLimited {
-		state.LimitIndicators = p.collectLimitIndicators(output, state.Type)
+		state.LimitIndicators = limit.Indicators
 		// If rate limited, we are effectively blocked, so clear other flags
 		state.IsWorking = false
 		state.IsIdle = false
-		state.IsInError = p.detectError(output, state.Type)
-		return
+		state.IsInError = rules.decide(RuleStateError, output, false).Detected
+		return limit.Weight
 	}
 
 	// Idle detection
 	// We check this BEFORE trusting IsWorking, because IsWorking patterns
 	// (like "testing", "running") might still be present in the scrollback
 	// even after the agent has finished and printed a prompt.
-	state.IsIdle = p.detectIdle(output, state.Type)
+	idle := rules.decide(RuleStateIdle, output, false)
+	state.IsIdle = idle.Detected
 
 	// Working detection
 	// We always run this to collect indicators for debugging/confidence
-	rawIsWorking := p.detectWorking(output, state.Type)
-	if rawIsWorking {
-		state.WorkIndicators = p.collectWorkIndicators(output, state.Type)
-	}
-
-	// For Claude Code the live-tail spinner is the AUTHORITATIVE working signal
-	// and must win over everything else. Claude pins its "❯ " input box to the
-	// bottom of the screen even while busy, so the live spinner (just above the
-	// box) is the only reliable in-flight marker. ClaudeActivelyWorking inspects
-	// the spinner-vs-turn-ended ordering in the live tail and is biased to
-	// false-WORKING, so trusting it can never mislabel a busy agent as idle.
-	if state.Type == AgentTypeClaudeCode {
-		if ClaudeActivelyWorking(output, 0) {
-			// Definitively working: a live spinner is the most-recent dynamic
-			// marker. Idle is impossible here regardless of the box being drawn.
-			state.IsWorking = true
-			state.IsIdle = false
-			if len(state.WorkIndicators) == 0 {
-				state.WorkIndicators = []string{"claude_live_spinner"}
-			}
-			state.IsInError = p.detectError(output, state.Type)
-			return
-		}
-		// No live spinner. A finished-turn idle prompt (detectIdle, already
-		// gated on !ClaudeActivelyWorking) wins over loose scrollback keyword
-		// matches that linger after a turn ends. Otherwise fall back to the
-		// substring working heuristics so mid-stream content (code blocks, file
-		// operations) with no spinner frame in the capture still reads as work.
-		if state.IsIdle {
-			state.IsWorking = false
-		} else {
-			state.IsWorking = rawIsWorking
-		}
-		state.IsInError = p.detectError(output, state.Type)
-		return
-	}
-
-	// Grok Build gets the same live-tail treatment as Claude Code: its
-	// bordered composer ("│ ❯ …") is permanent chrome drawn even while a turn
-	// is in flight, so the braille-spinner activity line / "Esc:cancel" footer
-	// hint is the authoritative working signal and must win over the
-	// idle-looking composer (GH#251 phase 2, verified against grok 1.0.5).
-	if state.Type == AgentTypeGrok {
-		if GrokActivelyWorking(output, 0) {
-			state.IsWorking = true
-			state.IsIdle = false
-			if len(state.WorkIndicators) == 0 {
-				state.WorkIndicators = []string{"grok_live_spinner"}
-			}
-			state.IsInError = p.detectError(output, state.Type)
-			return
-		}
-		if state.IsIdle {
-			state.IsWorking = false
-		} else {
-			state.IsWorking = rawIsWorking
-		}
-		state.IsInError = p.detectError(output, state.Type)
-		return
+	working := rules.decide(RuleStateWorking, output, false)
+	if working.Detected {
+		state.WorkIndicators = working.Indicators
 	}
 
 	// Conflict resolution: Prompt beats substring heuristics
 	// If we see a definitive prompt at the end (IsIdle), we are not working,
-	// regardless of what keywords appear in the scrollback.
-	if state.IsIdle {
+	// regardless of what keywords appear in the scrollback. The exception is
+	// an authoritative working rule (Claude's and Grok's live spinner): those
+	// agents draw their input box even while busy, so a live in-flight marker
+	// wins over the idle-looking chrome.
+	switch {
+	case working.Detected && working.Authoritative:
+		state.IsWorking = true
+		state.IsIdle = false
+	case state.IsIdle:
 		state.IsWorking = false
-	} else {
-		state.IsWorking = rawIsWorking
+	default:
+		state.IsWorking = working.Detected
 	}
 
 	// Error detection
-	state.IsInError = p.detectError(output, state.Type)
+	state.IsInError = rules.decide(RuleStateError, output, false).Detected
+
+	switch {
+	case state.IsWorking:
+		return working.Weight
+	case state.IsIdle:
+		return idle.Weight
+	}
+	return 1
 }
 
-// detectRateLimit checks if the agent hit an API usage limit.
-// We scan recent output (last 50 lines) to avoid stale errors triggering state.
+// detectRateLimit checks the recent output (last 50 lines in the built-in
+// packs) for usage limit messages.
 func (p *parserImpl) detectRateLimit(output string, agentType AgentType) bool {
-	recentOutput := util.GetLastNLines(output, 50)
-
-	switch agentType {
-	case AgentTypeClaudeCode:
-		return matchAny(recentOutput, ccRateLimitPatterns)
-	case AgentTypeCodex:
-		return matchAny(recentOutput, codRateLimitPatterns)
-	case AgentTypeGemini, AgentTypeAntigravity:
-		return matchAny(recentOutput, gmiRateLimitPatterns)
-	case AgentTypeCursor:
-		return matchAny(recentOutput, cursorRateLimitPatterns)
-	case AgentTypeWindsurf:
-		return matchAny(recentOutput, windsurfRateLimitPatterns)
-	case AgentTypeAider:
-		return matchAny(recentOutput, aiderRateLimitPatterns)
-	case AgentTypeOllama:
-		return matchAny(recentOutput, ollamaRateLimitPatterns)
-	case AgentTypeOpencode:
-		return matchAny(recentOutput, ocRateLimitPatterns)
-	case AgentTypeGrok:
-		return matchAny(recentOutput, grokRateLimitPatterns)
-	default:
-		// Check all patterns for unknown type
-		return matchAny(recentOutput, ccRateLimitPatterns) ||
-			matchAny(recentOutput, codRateLimitPatterns) ||
-			matchAny(recentOutput, gmiRateLimitPatterns) ||
-			matchAny(recentOutput, cursorRateLimitPatterns) ||
-			matchAny(recentOutput, windsurfRateLimitPatterns) ||
-			matchAny(recentOutput, aiderRateLimitPatterns) ||
-			matchAny(recentOutput, ollamaRateLimitPatterns)
-	}
+	return rulesFor(agentType).decide(RuleStateRateLimited, output, false).Detected
 }
 
 // detectWorking checks if the agent is actively producing output.
-// This focuses on recent output (last 20 lines) for accuracy.
+// The built-in packs focus on recent output (last 20 lines) for accuracy.
 func (p *parserImpl) detectWorking(output string, agentType AgentType) bool {
-	// Check recent output - recent activity is more relevant
-	recentOutput := util.GetLastNLines(output, 20)
-
-	switch agentType {
-	case AgentTypeClaudeCode:
-		return matchAny(recentOutput, ccWorkingPatterns)
-	case AgentTypeCodex:
-		return matchAny(recentOutput, codWorkingPatterns)
-	case AgentTypeGemini, AgentTypeAntigravity:
-		return matchAny(recentOutput, gmiWorkingPatterns)
-	case AgentTypeCursor:
-		return matchAny(recentOutput, cursorWorkingPatterns)
-	case AgentTypeWindsurf:
-		return matchAny(recentOutput, windsurfWorkingPatterns)
-	case AgentTypeAider:
-		return matchAny(recentOutput, aiderWorkingPatterns)
-	case AgentTypeOllama:
-		return matchAny(recentOutput, ollamaWorkingPatterns)
-	case AgentTypeGrok:
-		return GrokActivelyWorking(output, 0) || matchAny(recentOutput, grokWorkingPatterns)
-	case AgentTypeOpencode:
-		return OpencodeActivelyWorking(output, 0) || matchAny(recentOutput, ocWorkingPatterns)
-	default:
-		// A registered plugin with declared Working patterns is authoritative
-		// for its own type; the generic union below is for truly unknown types.
-		if pp, ok := LookupPluginPatterns(agentType); ok && len(pp.Working) > 0 {
-			return PluginActivelyWorking(output, agentType, 0)
-		}
-		// Check all patterns for unknown type
-		return matchAny(recentOutput, ccWorkingPatterns) ||
-			matchAny(recentOutput, codWorkingPatterns) ||
-			matchAny(recentOutput, gmiWorkingPatterns) ||
-			matchAny(recentOutput, cursorWorkingPatterns) ||
-			matchAny(recentOutput, windsurfWorkingPatterns) ||
-			matchAny(recentOutput, aiderWorkingPatterns) ||
-			matchAny(recentOutput, ollamaWorkingPatterns)
-	}
+	return rulesFor(agentType).decide(RuleStateWorking, output, false).Detected
 }
 
 // detectIdle checks if the agent is waiting for user input.
-// This examines the last few lines for prompt patterns.
+// The built-in packs examine the last few lines for prompt patterns.
 func (p *parserImpl) detectIdle(output string, agentType AgentType) bool {
-	// Check last lines for prompt indicators.
-	// Claude Code's TUI has a status bar (project path, bypass status, context %) that can be 5-8 lines below the prompt. Use a larger window for CC.
-	lineCount := 5
-	if agentType == AgentTypeClaudeCode {
-		lineCount = 12
-	}
-	lastLines := util.GetLastNLines(output, lineCount)
-
-	switch agentType {
-	case AgentTypeClaudeCode:
-		// Handle empty output with known agent type as idle
-		if strings.TrimSpace(lastLines) == "" {
-			return true
-		}
-		// A genuinely WORKING pane is NEVER idle. Claude pins its "❯ " input
-		// box to the bottom of the screen even while busy, so an empty-chevron
-		// match alone is not sufficient evidence of idleness — the live spinner
-		// renders just above the box. ClaudeActivelyWorking inspects the
-		// relative order of the spinner vs. turn-ended markers in the live tail
-		// (see patterns.go) and is biased to false-WORKING when ambiguous, so
-		// gating on it here can never produce a false-idle for a busy agent.
-		if ClaudeActivelyWorking(output, 0) {
-			return false
-		}
-		// Idle when a finished-turn prompt is showing. This covers both the
-		// empty chevron and the broader finished-turn footer: an input box that
-		// holds queued text or an "…" ellipsis, plus the post-turn
-		// "new task? /clear to save … tokens" hint. We pass the full `output`
-		// (not just `lastLines`) to the footer recognizer because the box and
-		// its decorative rules can exceed the bounded idle-line window.
-		if matchAnyRegex(lastLines, ccIdlePatterns) {
-			return true
-		}
-		if claudeFinishedTurnIdle(output) {
-			return true
-		}
-		return false
-	case AgentTypeCodex:
-		return matchAnyRegex(lastLines, codIdlePatterns)
-	case AgentTypeGemini, AgentTypeAntigravity:
-		// Gemini (and its successor Antigravity) are trickier - check for prompt
-		// or lack of working indicators
-		return matchAnyRegex(lastLines, gmiIdlePatterns)
-	case AgentTypeCursor:
-		return matchAnyRegex(lastLines, cursorIdlePatterns)
-	case AgentTypeWindsurf:
-		return matchAnyRegex(lastLines, windsurfIdlePatterns)
-	case AgentTypeAider:
-		return matchAnyRegex(lastLines, aiderIdlePatterns)
-	case AgentTypeOllama:
-		return matchAnyRegex(lastLines, ollamaIdlePatterns)
-	case AgentTypeGrok:
-		// Empty output with a known grok pane reads as idle (fresh spawn),
-		// mirroring the Claude arm. A live in-flight marker vetoes idle: the
-		// bordered composer is permanent chrome drawn during work.
-		if strings.TrimSpace(lastLines) == "" {
-			return true
-		}
-		if GrokActivelyWorking(output, 0) {
-			return false
-		}
-		return matchAnyRegex(lastLines, grokIdlePatterns)
-	case AgentTypeOpencode:
-		// Mirrors the grok arm: a fresh pane with no output reads as idle; the
-		// `esc interrupt` footer vetoes idle while a turn runs; otherwise the
-		// empty-composer hint text is the idle signal.
-		if strings.TrimSpace(lastLines) == "" {
-			return true
-		}
-		if OpencodeActivelyWorking(output, 0) {
-			return false
-		}
-		return matchAnyRegex(lastLines, ocIdlePatterns)
-	default:
-		// A registered plugin with declared readiness patterns: its Working
-		// patterns veto idle, its Idle patterns decide; with no Idle patterns
-		// declared fall through to the generic union (ntm#260).
-		if pp, ok := LookupPluginPatterns(agentType); ok && pp.Declared() {
-			if PluginActivelyWorking(output, agentType, 0) {
-				return false
-			}
-			if idle, declared := PluginIdlePromptShowing(lastLines, agentType); declared {
-				return idle
-			}
-		}
-		// Check all idle patterns for unknown type
-		return matchAnyRegex(lastLines, ccIdlePatterns) ||
-			matchAnyRegex(lastLines, codIdlePatterns) ||
-			matchAnyRegex(lastLines, gmiIdlePatterns) ||
-			matchAnyRegex(lastLines, cursorIdlePatterns) ||
-			matchAnyRegex(lastLines, windsurfIdlePatterns) ||
-			matchAnyRegex(lastLines, aiderIdlePatterns) ||
-			matchAnyRegex(lastLines, ollamaIdlePatterns)
-	}
+	return rulesFor(agentType).decide(RuleStateIdle, output, false).Detected
 }
 
 // detectError checks if the agent is in an error state.
 func (p *parserImpl) detectError(output string, agentType AgentType) bool {
-	// Check recent output for error patterns
-	recentOutput := util.GetLastNLines(output, 10)
-
-	switch agentType {
-	case AgentTypeClaudeCode:
-		switch DetectClaudeTurnState(output, 0) {
-		case ClaudeTurnWorking, ClaudeTurnEnded:
-			return false
-		case ClaudeTurnError:
-			return true
-		}
-		return matchAny(recentOutput, ccErrorPatterns)
-	case AgentTypeCodex:
-		return matchAny(recentOutput, codErrorPatterns)
-	case AgentTypeGemini, AgentTypeAntigravity:
-		return matchAny(recentOutput, gmiErrorPatterns)
-	case AgentTypeCursor:
-		return matchAny(recentOutput, cursorErrorPatterns)
-	case AgentTypeWindsurf:
-		return matchAny(recentOutput, windsurfErrorPatterns)
-	case AgentTypeAider:
-		return matchAny(recentOutput, aiderErrorPatterns)
-	case AgentTypeOllama:
-		return matchAny(recentOutput, ollamaErrorPatterns)
-	case AgentTypeGrok:
-		return matchAny(recentOutput, grokErrorPatterns)
-	case AgentTypeOpencode:
-		return matchAny(recentOutput, ocErrorPatterns)
-	default:
-		if pp, ok := LookupPluginPatterns(agentType); ok && len(pp.Error) > 0 {
-			return PluginErrorShowing(recentOutput, agentType)
-		}
-		// Check all patterns for unknown type
-		return matchAny(recentOutput, ccErrorPatterns) ||
-			matchAny(recentOutput, codErrorPatterns) ||
-			matchAny(recentOutput, gmiErrorPatterns) ||
-			matchAny(recentOutput, cursorErrorPatterns) ||
-			matchAny(recentOutput, windsurfErrorPatterns) ||
-			matchAny(recentOutput, aiderErrorPatterns) ||
-			matchAny(recentOutput, ollamaErrorPatterns)
-	}
+	return rulesFor(agentType).decide(RuleStateError, output, false).Detected
 }
 
 // collectLimitIndicators returns the specific patterns that matched for rate limiting.
 func (p *parserImpl) collectLimitIndicators(output string, agentType AgentType) []string {
-	// Focus on recent output to match detection logic
-	recentOutput := util.GetLastNLines(output, 50)
-
-	switch agentType {
-	case AgentTypeClaudeCode:
-		return collectMatches(recentOutput, ccRateLimitPatterns)
-	case AgentTypeCodex:
-		return collectMatches(recentOutput, codRateLimitPatterns)
-	case AgentTypeGemini, AgentTypeAntigravity:
-		return collectMatches(recentOutput, gmiRateLimitPatterns)
-	case AgentTypeCursor:
-		return collectMatches(recentOutput, cursorRateLimitPatterns)
-	case AgentTypeWindsurf:
-		return collectMatches(recentOutput, windsurfRateLimitPatterns)
-	case AgentTypeAider:
-		return collectMatches(recentOutput, aiderRateLimitPatterns)
-	case AgentTypeOllama:
-		return collectMatches(recentOutput, ollamaRateLimitPatterns)
-	case AgentTypeOpencode:
-		return collectMatches(recentOutput, ocRateLimitPatterns)
-	case AgentTypeGrok:
-		return collectMatches(recentOutput, grokRateLimitPatterns)
-	default:
-		// Collect from all for unknown type
-		matches := collectMatches(recentOutput, ccRateLimitPatterns)
-		matches = append(matches, collectMatches(recentOutput, codRateLimitPatterns)...)
-		matches = append(matches, collectMatches(recentOutput, gmiRateLimitPatterns)...)
-		matches = append(matches, collectMatches(recentOutput, cursorRateLimitPatterns)...)
-		matches = append(matches, collectMatches(recentOutput, windsurfRateLimitPatterns)...)
-		matches = append(matches, collectMatches(recentOutput, aiderRateLimitPatterns)...)
-		matches = append(matches, collectMatches(recentOutput, ollamaRateLimitPatterns)...)
-		return matches
-	}
+	return rulesFor(agentType).decide(RuleStateRateLimited, output, false).Indicators
 }
 
 // collectWorkIndicators returns the specific patterns that matched for working state.
 func (p *parserImpl) collectWorkIndicators(output string, agentType AgentType) []string {
-	// Focus on recent output
-	recentOutput := util.GetLastNLines(output, 20)
-
-	switch agentType {
-	case AgentTypeClaudeCode:
-		return collectMatches(recentOutput, ccWorkingPatterns)
-	case AgentTypeCodex:
-		return collectMatches(recentOutput, codWorkingPatterns)
-	case AgentTypeGemini, AgentTypeAntigravity:
-		return collectMatches(recentOutput, gmiWorkingPatterns)
-	case AgentTypeCursor:
-		return collectMatches(recentOutput, cursorWorkingPatterns)
-	case AgentTypeWindsurf:
-		return collectMatches(recentOutput, windsurfWorkingPatterns)
-	case AgentTypeAider:
-		return collectMatches(recentOutput, aiderWorkingPatterns)
-	case AgentTypeOllama:
-		return collectMatches(recentOutput, ollamaWorkingPatterns)
-	case AgentTypeOpencode:
-		return collectMatches(recentOutput, ocWorkingPatterns)
-	case AgentTypeGrok:
-		matches := collectMatches(recentOutput, grokWorkingPatterns)
-		if len(matches) == 0 && GrokActivelyWorking(output, 0) {
-			matches = []string{"grok_live_spinner"}
-		}
-		return matches
-	default:
-		matches := collectMatches(recentOutput, ccWorkingPatterns)
-		matches = append(matches, collectMatches(recentOutput, codWorkingPatterns)...)
-		matches = append(matches, collectMatches(recentOutput, gmiWorkingPatterns)...)
-		matches = append(matches, collectMatches(recentOutput, cursorWorkingPatterns)...)
-		matches = append(matches, collectMatches(recentOutput, windsurfWorkingPatterns)...)
-		matches = append(matches, collectMatches(recentOutput, aiderWorkingPatterns)...)
-		matches = append(matches, collectMatches(recentOutput, ollamaWorkingPatterns)...)
-		return matches
-	}
+	return rulesFor(agentType).decide(RuleStateWorking, output, false).Indicators
 }
 
 // calculateConfidence determines how confident we are in the parsed state.
@@ -9,24 +9,15 @@ import (
 )
 
 // Claude Code (cc) patterns for state detection.
+//
+// The rate-limit, working, idle and error lists of every agent below are
+// views of its embedded rule pack (rules/<agent>.toml), which is what the
+// parser actually evaluates; edit the pack, not these declarations.
 var (
 	// ccRateLimitPatterns indicates the agent hit an API usage limit.
 	// We use broad patterns here - false positives (waiting unnecessarily) are
 	// acceptable, but false negatives (interrupting a blocked agent) are costly.
-	ccRateLimitPatterns = []string{
-		"you've hit your limit",
-		"you.ve hit your limit",
-		"rate limit exceeded",
-		"rate limit",
-		"too many requests",
-		"please wait",
-		"try again later",
-		"usage limit",
-		"request limit",
-		"exceeded the limit",
-		"exceeded your limit",
-		"exceeded limit",
-	}
+	ccRateLimitPatterns = builtinRules.substrings("cc.rate_limited")
 
 	// ccContextWarnings indicates the agent is running low on context.
 	// Claude Code doesn't give explicit percentages, so we rely on warning messages.
@@ -46,41 +37,13 @@ var (
 
 	// ccWorkingPatterns indicates the agent is actively producing output.
 	// CRITICAL: When these patterns match, DO NOT INTERRUPT the agent.
-	ccWorkingPatterns = []string{
-		"```",         // Code block delimiter (most reliable indicator)
-		"writing to ", // File write operation
-		"created ",    // File creation
-		"modified ",   // File modification
-		"deleted ",    // File deletion
-		"reading ",    // File read operation
-		"searching ",  // Search in progress
-		"running ",    // Command execution
-		"executing ",  // Command execution
-		"installing ", // Package installation
-		"compiling",   // Compilation
-		"building",    // Build process
-		"testing",     // Test execution
-		"fetching",    // Network operation
-		"downloading", // Download operation
-		"uploading",   // Upload operation
-	}
+	ccWorkingPatterns = builtinRules.substrings("cc.working")
 
 	// ccIdlePatterns indicates the agent is waiting for user input.
 	// When these match at the end of output, it's safe to restart or send new work.
 	// NOTE: "bypass permissions on" was removed — it matches the permanent status bar,
 	// causing agents to always appear idle even while actively working.
-	ccIdlePatterns = []*regexp.Regexp{
-		regexp.MustCompile(`>\s*$`),      // Prompt waiting for input
-		regexp.MustCompile(`(?m)^>\s*$`), // Prompt line (empty prompt waiting for input)
-		regexp.MustCompile(`Human:\s*$`), // Conversation mode prompt
-		regexp.MustCompile(`waiting for input`),
-		regexp.MustCompile(`(?m)^.{0,40}\?\s*$`), // Short question prompt (max 40 chars to avoid matching reasoning output)
-		// Claude Code TUI patterns (welcome screen)
-		regexp.MustCompile(`(?i)claude\s+code\s+v[\d.]+`), // Version banner
-		regexp.MustCompile(`(?i)welcome\s+back`),          // Welcome message
-		regexp.MustCompile(`╰─>\s*$`),                     // Arrow prompt
-		regexp.MustCompile(`(?m)❯[\s\x{00a0}]*$`),         // Unicode heavy right-pointing angle prompt (multiline, NBSP-aware)
-	}
+	ccIdlePatterns = builtinRules.regexps("cc.idle")
 
 	// ccSpinnerActivePatterns detect Claude Code's randomized spinner verbs
 	// (e.g. "Bunning… (3s)", "Scurrying… (12s)", "Running…", "· thinking", "· thought for 5s").
@@ -157,19 +120,7 @@ var (
 	claudeTerminalErrorLineRe = regexp.MustCompile(`(?i)^\s*⎿[\s\x{00a0}]*error:`)
 
 	// ccErrorPatterns indicates an error condition.
-	ccErrorPatterns = []string{
-		"error:",
-		"failed:",
-		"exception:",
-		"panic:",
-		"fatal:",
-		"abort:",
-		"permission denied",
-		"access denied",
-		"connection refused",
-		"timed out",
-		"timeout error",
-	}
+	ccErrorPatterns = builtinRules.substrings("cc.error")
 
 	// ccHeaderPattern confirms output is from Claude Code.
 	ccHeaderPattern = regexp.MustCompile(`(?i)\b(opus|claude|sonnet|haiku)\b\s*\d*\.?\d*`)
@@ -187,40 +138,10 @@ var (
 	codTokenPattern = regexp.MustCompile(`Token usage:\s*total=(\d[\d,]*)`)
 
 	// codRateLimitPatterns indicates the agent hit usage limits.
-	codRateLimitPatterns = []string{
-		"you've reached your usage limit",
-		"you.ve reached your usage limit",
-		// Codex's current banner phrasing (bd-wtm0w): "You've hit your
-		// usage limit ... try again at <date>", plus plan-limit variants.
-		// Matching is substring containment, so the bare "hit your usage
-		// limit" covers both the "you've"/"you have" prefixes. This list
-		// cannot delegate to internal/ratelimit (import cycle: ratelimit
-		// imports agent); keep it in sync with ratelimit's canonical
-		// usageLimitBannerPatterns when provider copy changes.
-		"hit your usage limit",
-		"usage limit reached",
-		"plan limit reached",
-		"rate limit exceeded",
-		"rate limit",
-		"quota exceeded",
-		"capacity reached",
-		"maximum requests",
-		"too many requests",
-	}
+	codRateLimitPatterns = builtinRules.substrings("cod.rate_limited")
 
 	// codWorkingPatterns indicates active output production.
-	codWorkingPatterns = []string{
-		"```",       // Code block
-		"editing ",  // File edit
-		"creating ", // File creation
-		"writing ",  // File write
-		"reading ",  // File read
-		"running ",  // Command execution
-		"$ ",        // Shell command output indicator
-		"applying ", // Applying changes
-		"patching ", // Patch application
-		"deleting ", // File deletion
-	}
+	codWorkingPatterns = builtinRules.substrings("cod.working")
 
 	// Codex keeps its input chevron and context footer rendered while a turn is
 	// active, so those idle-looking elements cannot outrank a live spinner. Keep
@@ -231,21 +152,10 @@ var (
 	codexInterruptHintRe  = regexp.MustCompile(`(?i)esc\s+to\s+i(?:nterrupt\b|\S*…|\S*\.\.\.)`)
 
 	// codIdlePatterns indicates waiting for input.
-	codIdlePatterns = []*regexp.Regexp{
-		regexp.MustCompile(`>\s*$`),                // Standard prompt
-		regexp.MustCompile(`\?\s*for\s*shortcuts`), // Codex prompt line
-		regexp.MustCompile(`codex>\s*$`),           // Codex prompt
-		regexp.MustCompile(`(?m)^\s*›(?:\s.*)?$`),  // Codex chevron prompt, empty or with prefilled input
-	}
+	codIdlePatterns = builtinRules.regexps("cod.idle")
 
 	// codErrorPatterns indicates error conditions.
-	codErrorPatterns = []string{
-		"error:",
-		"failed:",
-		"exception:",
-		"could not",
-		"unable to",
-	}
+	codErrorPatterns = builtinRules.substrings("cod.error")
 
 	// codHeaderPattern confirms output is from Codex CLI.
 	codHeaderPattern = regexp.MustCompile(`(?i)\b(codex|openai|gpt-\d)\b`)
@@ -266,42 +176,16 @@ var (
 
 	// gmiRateLimitPatterns indicates rate limiting.
 	// Gemini is less explicit about limits, so we use broader heuristics.
-	gmiRateLimitPatterns = []string{
-		"quota exceeded",
-		"quota",
-		"limit reached",
-		"rate limit",
-		"try again",
-		"capacity",
-		"resource exhausted",
-	}
+	gmiRateLimitPatterns = builtinRules.substrings("gmi.rate_limited")
 
 	// gmiWorkingPatterns indicates active output.
-	gmiWorkingPatterns = []string{
-		"```",         // Code block
-		"creating ",   // File creation
-		"writing ",    // File write
-		"executing ",  // Command execution
-		"running ",    // Running command
-		"generating ", // Content generation
-		"analyzing ",  // Analysis
-		"processing",  // General processing
-		"thinking",    // Chain of thought
-	}
+	gmiWorkingPatterns = builtinRules.substrings("gmi.working")
 
 	// gmiIdlePatterns indicates waiting for input.
-	gmiIdlePatterns = []*regexp.Regexp{
-		regexp.MustCompile(`>\s*$`),       // Standard prompt
-		regexp.MustCompile(`gemini>\s*$`), // Gemini prompt
-	}
+	gmiIdlePatterns = builtinRules.regexps("gmi.idle")
 
 	// gmiErrorPatterns indicates error conditions.
-	gmiErrorPatterns = []string{
-		"error",
-		"failed",
-		"exception",
-		"invalid",
-	}
+	gmiErrorPatterns = builtinRules.substrings("gmi.error")
 
 	// gmiShellModePattern detects shell mode.
 	// GOTCHA: Shell mode is triggered by "!" prefix in prompts.
@@ -316,7 +200,7 @@ var (
 // Antigravity is the Gemini CLI's successor and shares its interactive TUI
 // behavior (memory display, YOLO-style auto-approve, prompt rendering), so its
 // working / idle / rate-limit / error / metric detection reuses the gmi*
-// pattern sets above (see the AgentTypeAntigravity arms in parser.go). The only
+// pattern sets above (rules/gmi.toml lists both agent types). The only
 // agy-specific signal we need is a header signature that distinguishes an
 // Antigravity pane from a legacy Gemini pane during unhinted type detection.
 var (
@@ -326,93 +210,39 @@ var (
 
 // Cursor (cursor) patterns.
 var (
-	cursorRateLimitPatterns = []string{
-		"rate limit",
-		"too many requests",
-		"quota exceeded",
-	}
+	cursorRateLimitPatterns = builtinRules.substrings("cursor.rate_limited")
 
-	cursorWorkingPatterns = []string{
-		"```",
-		"writing ",
-		"reading ",
-		"searching ",
-		"analyzing ",
-		"generating ",
-	}
+	cursorWorkingPatterns = builtinRules.substrings("cursor.working")
 
-	cursorIdlePatterns = []*regexp.Regexp{
-		regexp.MustCompile(`>\s*$`),
-		regexp.MustCompile(`cursor>\s*$`),
-	}
+	cursorIdlePatterns = builtinRules.regexps("cursor.idle")
 
-	cursorErrorPatterns = []string{
-		"error:",
-		"failed:",
-		"exception:",
-	}
+	cursorErrorPatterns = builtinRules.substrings("cursor.error")
 
 	cursorHeaderPattern = regexp.MustCompile(`(?i)(cursor|cursor\s+ai)`)
 )
 
 // Windsurf (windsurf) patterns.
 var (
-	windsurfRateLimitPatterns = []string{
-		"rate limit",
-		"too many requests",
-		"quota exceeded",
-	}
+	windsurfRateLimitPatterns = builtinRules.substrings("windsurf.rate_limited")
 
-	windsurfWorkingPatterns = []string{
-		"```",
-		"writing ",
-		"reading ",
-		"searching ",
-		"analyzing ",
-		"generating ",
-	}
+	windsurfWorkingPatterns = builtinRules.substrings("windsurf.working")
 
-	windsurfIdlePatterns = []*regexp.Regexp{
-		regexp.MustCompile(`>\s*$`),
-		regexp.MustCompile(`windsurf>\s*$`),
-	}
+	windsurfIdlePatterns = builtinRules.regexps("windsurf.idle")
 
-	windsurfErrorPatterns = []string{
-		"error:",
-		"failed:",
-		"exception:",
-	}
+	windsurfErrorPatterns = builtinRules.substrings("windsurf.error")
 
 	windsurfHeaderPattern = regexp.MustCompile(`(?i)(windsurf|windsurf\s+ide)`)
 )
 
 // Aider (aider) patterns.
 var (
-	aiderRateLimitPatterns = []string{
-		"rate limit",
-		"too many requests",
-		"quota exceeded",
-	}
+	aiderRateLimitPatterns = builtinRules.substrings("aider.rate_limited")
 
-	aiderWorkingPatterns = []string{
-		"```",
-		"applied edit",
-		"committing",
-		"repo-map",
-		"analyzing",
-		"searching",
-	}
+	aiderWorkingPatterns = builtinRules.substrings("aider.working")
 
-	aiderIdlePatterns = []*regexp.Regexp{
-		regexp.MustCompile(`>\s*$`),
-		regexp.MustCompile(`aider>\s*$`),
-	}
+	aiderIdlePatterns = builtinRules.regexps("aider.idle")
 
-	aiderErrorPatterns = []string{
-		"error:",
-		"failed:",
-		"exception:",
-	}
+	aiderErrorPatterns = builtinRules.substrings("aider.error")
 
 	aiderHeaderPattern = regexp.MustCompile(`(?i)(aider|aider\s+chat)`)
 )
@@ -441,22 +271,9 @@ var (
 // "❯" glyph alone is never idle evidence — GrokActivelyWorking must be
 // consulted first (see detectStateFlags).
 var (
-	grokRateLimitPatterns = []string{
-		"rate limit",
-		"too many requests",
-		"quota exceeded",
-		"usage limit",
-	}
+	grokRateLimitPatterns = builtinRules.substrings("grok.rate_limited")
 
-	grokWorkingPatterns = []string{
-		"```",
-		"writing ",
-		"reading ",
-		"running ",
-		"executing ",
-		"searching ",
-		"generating ",
-	}
+	grokWorkingPatterns = builtinRules.substrings("grok.working")
 
 	// grokActiveWorkLineRe matches the live activity line grok renders while a
 	// turn is in flight: a braille spinner frame followed by one of the three
@@ -472,18 +289,9 @@ var (
 	// grokIdlePatterns indicates waiting for input. The bordered composer line
 	// ("│ ❯ …") is permanent chrome; callers must gate on !GrokActivelyWorking
 	// (detectStateFlags does) before trusting it as idle evidence.
-	grokIdlePatterns = []*regexp.Regexp{
-		regexp.MustCompile(`(?m)^\s*│\s*❯`),               // Bordered composer line
-		regexp.MustCompile(`(?m)^\s*Worked\s+for\s+\d`),   // Turn-ended summary
-		regexp.MustCompile(`Turn cancelled by user`),      // Post-interrupt acknowledgement
-		regexp.MustCompile(`(?i)grok\s+build\s+\d+\.\d+`), // Welcome banner (fresh spawn)
-	}
+	grokIdlePatterns = builtinRules.regexps("grok.idle")
 
-	grokErrorPatterns = []string{
-		"error:",
-		"failed:",
-		"exception:",
-	}
+	grokErrorPatterns = builtinRules.substrings("grok.error")
 
 	// grokHeaderPattern confirms output is from the Grok Build TUI. The
 	// status-line chrome ("Grok 4.6 (high) · always-approve") and the welcome
@@ -514,17 +322,9 @@ func GrokActivelyWorking(output string, paneWidth int) bool {
 // spinner plus an `esc interrupt` (after one press: `esc again to
 // interrupt`) footer hint that disappears at idle.
 var (
-	ocRateLimitPatterns = []string{
-		"rate limit",
-		"too many requests",
-		"quota exceeded",
-		"usage limit",
-	}
+	ocRateLimitPatterns = builtinRules.substrings("oc.rate_limited")
 
-	ocWorkingPatterns = []string{
-		"esc interrupt",
-		"esc again to interrupt",
-	}
+	ocWorkingPatterns = builtinRules.substrings("oc.working")
 
 	// ocInterruptHintRe matches the footer hint OpenCode shows only while a
 	// turn is in flight.
@@ -532,16 +332,9 @@ var (
 
 	// ocIdlePatterns indicates the empty composer is waiting for input.
 	// Callers gate on !OpencodeActivelyWorking first (detectStateFlags does).
-	ocIdlePatterns = []*regexp.Regexp{
-		regexp.MustCompile(`(?i)\bAsk anything\b`),
-		regexp.MustCompile(`(?m)^\s*Interrupted\s*$`), // post-interrupt acknowledgement
-	}
+	ocIdlePatterns = builtinRules.regexps("oc.idle")
 
-	ocErrorPatterns = []string{
-		"error:",
-		"failed:",
-		"exception:",
-	}
+	ocErrorPatterns = builtinRules.substrings("oc.error")
 )
 
 // ocLiveTailLines bounds the live-tail window scanned for the OpenCode
@@ -559,32 +352,13 @@ func OpencodeActivelyWorking(output string, paneWidth int) bool {
 
 // Ollama (ollama) patterns.
 var (
-	ollamaRateLimitPatterns = []string{
-		"rate limit",
-		"too many requests",
-		"quota exceeded",
-	}
+	ollamaRateLimitPatterns = builtinRules.substrings("ollama.rate_limited")
 
-	ollamaWorkingPatterns = []string{
-		"```",
-		"pulling manifest",
-		"verifying sha256 digest",
-		"writing manifest",
-		"removing any unused layers",
-		"generating ",
-		"thinking",
-	}
+	ollamaWorkingPatterns = builtinRules.substrings("ollama.working")
 
-	ollamaIdlePatterns = []*regexp.Regexp{
-		regexp.MustCompile(`>\s*$`),
-		regexp.MustCompile(`ollama>\s*$`),
-	}
+	ollamaIdlePatterns = builtinRules.regexps("ollama.idle")
 
-	ollamaErrorPatterns = []string{
-		"error:",
-		"failed:",
-		"exception:",
-	}
+	ollamaErrorPatterns = builtinRules.substrings("ollama.error")
 
 	ollamaHeaderPattern = regexp.MustCompile(`(?im)(^ollama>\s*$|\bollama\s+(run|chat|serve|pull)\b|^\s*ollama\s+cli\b)`)
 )
@@ -0,0 +1,350 @@
+package agent
+
+// permission.go classifies the modal prompts an agent CLI raises before it
+// runs a tool — Claude's "Do you want to proceed?" box, Codex's "Would you
+// like to run the following command?" list, Gemini's "Allow execution?" —
+// and extracts what the prompt is asking for, so the gate auto-responder can
+// decide it by policy. They are deliberately NOT interactive gates: a pane
+// parked on one is waiting for a routine yes/no, not broken, and folding
+// them into DetectInteractiveGate would drive restart urgency downstream.
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/Dicklesworthstone/ntm/internal/util"
+)
+
+// GateKind names the family a blocking prompt belongs to. Auto-respond
+// policy rules select on it.
+type GateKind string
+
+const (
+	GateKindPermission GateKind = "permission" // tool/command permission prompt
+	GateKindTrust      GateKind = "trust"      // workspace/folder trust dialog
+	GateKindAuth       GateKind = "auth"       // login or OAuth gate
+	GateKindOnboarding GateKind = "onboarding" // first-run choice screen
+)
+
+// GateKinds lists every gate kind.
+var GateKinds = []GateKind{GateKindPermission, GateKindTrust, GateKindAuth, GateKindOnboarding}
+
+// ToolCategory coarsely classifies what a permission prompt would let the
+// agent do.
+type ToolCategory string
+
+const (
+	ToolRead  ToolCategory = "read"  // read a file, search, list
+	ToolEdit  ToolCategory = "edit"  // create or modify files
+	ToolBash  ToolCategory = "bash"  // run a shell command
+	ToolFetch ToolCategory = "fetch" // network fetch or web search
+	ToolMCP   ToolCategory = "mcp"   // MCP server tool
+	ToolOther ToolCategory = "other"
+)
+
+// ToolCategories lists every tool category.
+var ToolCategories = []ToolCategory{ToolRead, ToolEdit, ToolBash, ToolFetch, ToolMCP, ToolOther}
+
+// GatePrompt is a classified blocking prompt and how to answer it.
+type GatePrompt struct {
+	Kind GateKind `json:"kind"`
+	// Phrase is the text that identified the prompt.
+	Phrase string `json:"phrase"`
+	// Tool is the tool header as the agent printed it (e.g. "Bash command").
+	Tool     string       `json:"tool,omitempty"`
+	Category ToolCategory `json:"category,omitempty"`
+	// Command is what the prompt asks to run or touch: the shell command,
+	// file path or URL. Empty when the prompt does not show one.
+	Command string `json:"command,omitempty"`
+	// ApproveKeys and DenyKeys are tmux key names that pick the one-time
+	// accept option and the decline option. Either may be empty when the
+	// prompt cannot be answered that way from a keyboard.
+	ApproveKeys []string `json:"approve_keys,omitempty"`
+	DenyKeys    []string `json:"deny_keys,omitempty"`
+}
+
+// permissionTailBaseLines bounds the live tail scanned for a permission
+// prompt. The prompts render a tool box plus up to three options at the
+// bottom of the screen.
+const permissionTailBaseLines = 25
+
+// gateMarkerKinds maps each interactiveGateMarkers entry to its kind.
+var gateMarkerKinds = map[string]GateKind{
+	"do you trust the contents of this project": GateKindTrust,
+	"do you trust the files in this folder":     GateKindTrust,
+	"trust this workspace":                      GateKindTrust,
+	"trust this folder":                         GateKindTrust,
+	"browser didn't open? use the url below":    GateKindAuth,
+	"press enter to open browser":               GateKindAuth,
+	"select login method":                       GateKindAuth,
+	"please run /login":                         GateKindAuth,
+	"run claude login":                          GateKindAuth,
+	"paste the code from the browser":           GateKindAuth,
+	"choose the text style that looks best":     GateKindOnboarding,
+	"select your theme to get started":          GateKindOnboarding,
+}
+
+// permissionQuestion identifies a permission prompt for one agent family by
+// its question line.
+type permissionQuestion struct {
+	agent    AgentType
+	phrase   string
+	category ToolCategory // category implied by the question, if any
+}
+
+var permissionQuestions = []permissionQuestion{
+	{agent: AgentTypeClaudeCode, phrase: "do you want to proceed?"},
+	{agent: AgentTypeClaudeCode, phrase: "do you want to make this edit to", category: ToolEdit},
+	{agent: AgentTypeClaudeCode, phrase: "do you want to create", category: ToolEdit},
+	{agent: AgentTypeClaudeCode, phrase: "do you want to allow claude to fetch", category: ToolFetch},
+	{agent: AgentTypeCodex, phrase: "would you like to run the following command?", category: ToolBash},
+	{agent: AgentTypeCodex, phrase: "allow command?", category: ToolBash},
+	{agent: AgentTypeCodex, phrase: "would you like to make the following edits?", category: ToolEdit},
+	{agent: AgentTypeGemini, phrase: "allow execution", category: ToolBash},
+	{agent: AgentTypeGemini, phrase: "apply this change?", category: ToolEdit},
+}
+
+// permissionToolHeaders maps a lowercased tool header prefix to its
+// category. Longer prefixes come first.
+var permissionToolHeaders = []struct {
+	prefix   string
+	category ToolCategory
+}{
+	{"bash command", ToolBash},
+	{"shell", ToolBash},
+	{"read file", ToolRead},
+	{"readfile", ToolRead},
+	{"read", ToolRead},
+	{"search", ToolRead},
+	{"grep", ToolRead},
+	{"glob", ToolRead},
+	{"list", ToolRead},
+	{"edit file", ToolEdit},
+	{"create file", ToolEdit},
+	{"write", ToolEdit},
+	{"edit", ToolEdit},
+	{"update", ToolEdit},
+	{"web search", ToolFetch},
+	{"fetch", ToolFetch},
+	{"webfetch", ToolFetch},
+	{"tool use", ToolMCP},
+}
+
+var (
+	permissionOptionRe = regexp.MustCompile(`^\s*(?:[❯›>●]\s*)?(\d)\.\s+(.+?)\s*$`)
+	optionShortcutRe   = regexp.MustCompile(`\((esc|[a-z])\)\s*$`)
+	boxEdgeReplacer    = strings.NewReplacer("│", " ", "╭", " ", "╮", " ", "╰", " ", "╯", " ", "┃", " ")
+	geminiAllowRe      = regexp.MustCompile(`(?i)allow execution(?: of)?:?\s*'([^']+)'`)
+)
+
+// ClassifyGate reports the blocking prompt in the pane's live tail, if any:
+// a permission prompt for agentType, else one of the interactive gate
+// screens DetectInteractiveGate recognizes.
+func ClassifyGate(content string, agentType AgentType, paneWidth int) (GatePrompt, bool) {
+	if p, ok := DetectPermissionPrompt(content, agentType, paneWidth); ok {
+		return p, true
+	}
+	phrase, ok := DetectInteractiveGate(content, paneWidth)
+	if !ok {
+		return GatePrompt{}, false
+	}
+	g := GatePrompt{Kind: gateMarkerKinds[phrase], Phrase: phrase}
+	switch g.Kind {
+	case GateKindTrust:
+		// Trust dialogs preselect the accept option.
+		g.ApproveKeys = []string{"Enter"}
+		g.DenyKeys = []string{"Escape"}
+	case GateKindOnboarding:
+		g.ApproveKeys = []string{"Enter"}
+	}
+	// Auth gates need a browser round trip; no keystroke answers them.
+	return g, true
+}
+
+// DetectPermissionPrompt reports whether the pane's live tail shows a tool
+// permission prompt from agentType, with the requested tool and command.
+// Matching is agent-type-gated and requires the numbered option list the
+// prompt renders, so transcript text quoting the question does not match.
+func DetectPermissionPrompt(content string, agentType AgentType, paneWidth int) (GatePrompt, bool) {
+	agentType = agentType.Canonical()
+	if agentType == AgentTypeAntigravity {
+		agentType = AgentTypeGemini
+	}
+	clean := stripANSICodes(content)
+	tail := util.GetLastNLines(clean, util.WidthAdaptiveTailLines(paneWidth, permissionTailBaseLines))
+	lines := strings.Split(tail, "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSpace(boxEdgeReplacer.Replace(lines[i]))
+	}
+
+	question := -1
+	var q permissionQuestion
+	for i := len(lines) - 1; i >= 0 && question < 0; i-- {
+		lower := strings.ToLower(lines[i])
+		for _, cand := range permissionQuestions {
+			if cand.agent == agentType && strings.Contains(lower, cand.phrase) {
+				question, q = i, cand
+				break
+			}
+		}
+	}
+	if question < 0 {
+		return GatePrompt{}, false
+	}
+
+	approve, deny, ok := permissionOptionKeys(lines[question+1:])
+	if !ok {
+		return GatePrompt{}, false
+	}
+	p := GatePrompt{
+		Kind:        GateKindPermission,
+		Phrase:      q.phrase,
+		Category:    q.category,
+		ApproveKeys: approve,
+		DenyKeys:    deny,
+	}
+	p.Tool, p.Command = permissionSubject(agentType, lines, question)
+	if p.Category == "" {
+		p.Category = toolCategory(p.Tool)
+	}
+	return p, true
+}
+
+// permissionOptionKeys reads the option list below the question and returns
+// the keys for the first one-time accept option and the decline option.
+// ok is false when no accept option is listed.
+func permissionOptionKeys(lines []string) (approve, deny []string, ok bool) {
+	next := 1
+	for _, line := range lines {
+		m := permissionOptionRe.FindStringSubmatch(line)
+		if m == nil || m[1] != string(rune('0'+next)) {
+			continue
+		}
+		next++
+		label := strings.ToLower(m[2])
+		keys := []string{m[1], "Enter"}
+		if s := optionShortcutRe.FindStringSubmatch(label); s != nil {
+			if s[1] == "esc" {
+				keys = []string{"Escape"}
+			} else {
+				keys = []string{s[1]}
+			}
+		}
+		switch {
+		case approve == nil && strings.HasPrefix(label, "yes") && !strings.Contains(label, "don't ask") &&
+			!strings.Contains(label, "always") && !strings.Contains(label, "all edits"):
+			approve = keys
+		case deny == nil && strings.HasPrefix(label, "no"):
+			deny = keys
+		}
+	}
+	if approve == nil {
+		return nil, nil, false
+	}
+	if deny == nil {
+		deny = []string{"Escape"}
+	}
+	return approve, deny, true
+}
+
+// permissionSubjectLines bounds how far above the question the tool box is
+// searched when no box border is found.
+const permissionSubjectLines = 15
+
+// permissionTargetPhrases are questions that name their target inline
+// ("Do you want to make this edit to main.go?").
+var permissionTargetPhrases = []string{"do you want to make this edit to", "do you want to create"}
+
+// permissionSubject extracts the tool header and the requested command from
+// the prompt around the question line.
+func permissionSubject(agentType AgentType, lines []string, question int) (tool, command string) {
+	if agentType == AgentTypeCodex {
+		// Codex prints the command as "$ cmd" below the question (older
+		// builds above it).
+		for _, i := range append(rangeIndexes(question+1, len(lines)), reverseIndexes(0, question)...) {
+			if cmd, ok := strings.CutPrefix(lines[i], "$ "); ok {
+				return "command", strings.TrimSpace(cmd)
+			}
+		}
+		return "", ""
+	}
+
+	// Claude and Gemini draw a box (or a rule) around the prompt that
+	// opens with the tool header, followed by the command or path. Edit
+	// prompts nest a diff box inside, so the header is the nearest line
+	// under a rule that names a tool.
+	from := max(0, question-permissionSubjectLines)
+	header := -1
+	for i := question - 1; i >= from && header < 0; i-- {
+		if lines[i] == "" || isRuleLine(lines[i]) || toolCategory(strings.TrimLeft(lines[i], "?✓⊷ ")) == ToolOther {
+			continue
+		}
+		if i == from || isRuleLine(lines[i-1]) {
+			header = i
+		}
+	}
+	if header >= 0 {
+		tool = strings.TrimSpace(strings.TrimLeft(lines[header], "?✓⊷ "))
+		if agentType == AgentTypeGemini {
+			tool, _, _ = strings.Cut(tool, " ")
+		}
+		for _, line := range lines[header+1 : question] {
+			if line != "" && !isRuleLine(line) {
+				command = line
+				break
+			}
+		}
+	}
+	lower := strings.ToLower(lines[question])
+	for _, phrase := range permissionTargetPhrases {
+		if i := strings.Index(lower, phrase); i >= 0 && command == "" {
+			if target := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(lines[question][i+len(phrase):]), "?")); target != "" {
+				command = target
+			}
+		}
+	}
+	if command == "" && agentType == AgentTypeGemini {
+		if m := geminiAllowRe.FindStringSubmatch(lines[question]); m != nil {
+			command = m[1]
+		}
+	}
+	return tool, command
+}
+
+// isRuleLine reports whether line is a horizontal box border or rule.
+func isRuleLine(line string) bool {
+	if len(line) < 3 {
+		return false
+	}
+	return strings.Trim(line, "─━-═ ") == ""
+}
+
+func rangeIndexes(from, to int) []int {
+	var out []int
+	for i := from; i < to; i++ {
+		out = append(out, i)
+	}
+	return out
+}
+
+func reverseIndexes(from, to int) []int {
+	var out []int
+	for i := to - 1; i >= from; i-- {
+		out = append(out, i)
+	}
+	return out
+}
+
+// toolCategory maps a tool header to its category.
+func toolCategory(header string) ToolCategory {
+	lower := strings.ToLower(strings.TrimSpace(header))
+	for _, h := range permissionToolHeaders {
+		if strings.HasPrefix(lower, h.prefix) {
+			return h.category
+		}
+	}
+	if strings.HasSuffix(lower, "(mcp)") {
+		return ToolMCP
+	}
+	return ToolOther
+}
@@ -0,0 +1,152 @@
+package agent
+
+import (
+	"reflect"
+	"testing"
+)
+
+const ccBashPermission = `● I'll clean the build directory first.
+
+╭───────────────────────────────────────────────────────────────────╮
+│ Bash command                                                      │
+│                                                                   │
+│   rm -rf build/                                                   │
+│   Remove stale build output                                       │
+│                                                                   │
+│ Do you want to proceed?                                           │
+│ ❯ 1. Yes                                                          │
+│   2. Yes, and don't ask again for rm commands in /home/u/proj     │
+│   3. No, and tell Claude what to do differently (esc)             │
+╰───────────────────────────────────────────────────────────────────╯
+`
+
+const ccReadPermission = `╭───────────────────────────────────────────────╮
+│ Read file                                     │
+│                                               │
+│   /etc/hosts                                  │
+│                                               │
+│ Do you want to proceed?                       │
+│ ❯ 1. Yes                                      │
+│   2. Yes, and don't ask again this session    │
+│   3. No, and tell Claude what to do differently (esc) │
+╰───────────────────────────────────────────────╯
+`
+
+const ccEditPermission = `╭───────────────────────────────────────────────╮
+│ Edit file                                     │
+│ ╭───────────────────────────────────────────╮ │
+│ │ internal/agent/gate.go                    │ │
+│ │  12 -   old line                          │ │
+│ │  12 +   new line                          │ │
+│ ╰───────────────────────────────────────────╯ │
+│ Do you want to make this edit to gate.go?     │
+│ ❯ 1. Yes                                      │
+│   2. Yes, allow all edits during this session │
+│   3. No, and tell Claude what to do differently (esc) │
+╰───────────────────────────────────────────────╯
+`
+
+const codBashPermission = `• Running the test suite next.
+
+Would you like to run the following command?
+
+Reason: run the focused tests
+
+$ go test ./internal/agent/...
+
+› 1. Yes, proceed (y)
+  2. Yes, and don't ask again for this command (a)
+  3. No, and tell Codex what to do differently (esc)
+
+Press enter to confirm or esc to cancel
+`
+
+const gmiShellPermission = `╭──────────────────────────────────────────────╮
+│ ? Shell npm install (install dependencies)   │
+│                                              │
+│ npm install                                  │
+│                                              │
+│ Allow execution of: 'npm'?                   │
+│                                              │
+│ ● 1. Yes, allow once                         │
+│   2. Yes, allow always ...                   │
+│   3. No, suggest changes (esc)               │
+╰──────────────────────────────────────────────╯
+`
+
+func TestDetectPermissionPrompt(t *testing.T) {
+	cases := []struct {
+		name    string
+		content string
+		agent   AgentType
+		want    GatePrompt
+	}{
+		{
+			name: "claude bash", content: ccBashPermission, agent: AgentTypeClaudeCode,
+			want: GatePrompt{Kind: GateKindPermission, Phrase: "do you want to proceed?", Tool: "Bash command", Category: ToolBash,
+				Command: "rm -rf build/", ApproveKeys: []string{"1", "Enter"}, DenyKeys: []string{"Escape"}},
+		},
+		{
+			name: "claude read", content: ccReadPermission, agent: "claude",
+			want: GatePrompt{Kind: GateKindPermission, Phrase: "do you want to proceed?", Tool: "Read file", Category: ToolRead,
+				Command: "/etc/hosts", ApproveKeys: []string{"1", "Enter"}, DenyKeys: []string{"Escape"}},
+		},
+		{
+			name: "claude edit", content: ccEditPermission, agent: AgentTypeClaudeCode,
+			want: GatePrompt{Kind: GateKindPermission, Phrase: "do you want to make this edit to", Tool: "Edit file", Category: ToolEdit,
+				Command: "internal/agent/gate.go", ApproveKeys: []string{"1", "Enter"}, DenyKeys: []string{"Escape"}},
+		},
+		{
+			name: "codex command", content: codBashPermission, agent: AgentTypeCodex,
+			want: GatePrompt{Kind: GateKindPermission, Phrase: "would you like to run the following command?", Tool: "command", Category: ToolBash,
+				Command: "go test ./internal/agent/...", ApproveKeys: []string{"y"}, DenyKeys: []string{"Escape"}},
+		},
+		{
+			name: "gemini shell", content: gmiShellPermission, agent: AgentTypeGemini,
+			want: GatePrompt{Kind: GateKindPermission, Phrase: "allow execution", Tool: "Shell", Category: ToolBash,
+				Command: "npm install", ApproveKeys: []string{"1", "Enter"}, DenyKeys: []string{"Escape"}},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := DetectPermissionPrompt(tc.content, tc.agent, 80)
+			if !ok {
+				t.Fatal("prompt not detected")
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got  %+v\nwant %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDetectPermissionPromptIgnoresOtherAgentsAndTranscript(t *testing.T) {
+	if _, ok := DetectPermissionPrompt(ccBashPermission, AgentTypeCodex, 80); ok {
+		t.Error("claude prompt matched for a codex pane")
+	}
+	// Quoting the question without the option list is transcript, not a
+	// prompt.
+	quoted := "● The CLI asks \"Do you want to proceed?\" before running Bash.\n\n> "
+	if _, ok := DetectPermissionPrompt(quoted, AgentTypeClaudeCode, 80); ok {
+		t.Error("quoted question matched")
+	}
+}
+
+func TestClassifyGateFallsBackToInteractiveGates(t *testing.T) {
+	g, ok := ClassifyGate("Do you trust the files in this folder?\n\n❯ 1. Yes, proceed\n  2. No, exit\n", AgentTypeClaudeCode, 80)
+	if !ok || g.Kind != GateKindTrust {
+		t.Fatalf("gate = %+v, %v", g, ok)
+	}
+	if !reflect.DeepEqual(g.ApproveKeys, []string{"Enter"}) {
+		t.Errorf("approve keys = %v", g.ApproveKeys)
+	}
+	g, ok = ClassifyGate("Select login method:\n\n❯ 1. Claude account\n", AgentTypeClaudeCode, 80)
+	if !ok || g.Kind != GateKindAuth || g.ApproveKeys != nil {
+		t.Errorf("auth gate = %+v, %v", g, ok)
+	}
+	for _, marker := range interactiveGateMarkers {
+		if gateMarkerKinds[marker] == "" {
+			t.Errorf("gate marker %q has no kind", marker)
+		}
+	}
+}
@@ -84,6 +84,7 @@ func RegisterPlugin(name string, idle, working, errs []string) error {
 	pluginPatternsMu.Lock()
 	pluginPatterns[key] = pp
 	pluginPatternsMu.Unlock()
+	registerPluginRules(key, pp)
 	return nil
 }
 
@@ -92,6 +93,7 @@ func UnregisterPlugins() {
 	pluginPatternsMu.Lock()
 	pluginPatterns = map[AgentType]PluginPatterns{}
 	pluginPatternsMu.Unlock()
+	unregisterPluginRules()
 }
 
 // IsPluginType reports whether t names a registered plugin agent type.
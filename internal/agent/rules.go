@@ -0,0 +1,549 @@
+package agent
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	"github.com/Dicklesworthstone/ntm/internal/util"
+)
+
+// RuleSchemaVersion is the rule pack schema this build understands. Packs
+// declaring any other schema are rejected rather than half-applied.
+const RuleSchemaVersion = 1
+
+// RuleState names the agent state a detection rule votes on.
+type RuleState string
+
+const (
+	RuleStateRateLimited RuleState = "rate_limited"
+	RuleStateWorking     RuleState = "working"
+	RuleStateIdle        RuleState = "idle"
+	RuleStateError       RuleState = "error"
+)
+
+// RuleStates lists the states in the order the parser decides them.
+var RuleStates = []RuleState{RuleStateRateLimited, RuleStateIdle, RuleStateWorking, RuleStateError}
+
+// Rule effects and match kinds.
+const (
+	RuleEffectAssert = "assert"
+	RuleEffectVeto   = "veto"
+
+	RuleMatchRegex     = "regex"
+	RuleMatchSubstring = "substring"
+)
+
+// RulePack is a versioned set of state detection rules for one or more agent
+// types, loaded from TOML (built-in packs, <config>/agents/rules/*.toml) or
+// YAML (*.yaml, *.yml).
+//
+// Each state is decided by a decision list: the pack's rules for that state
+// are tried from highest to lowest priority (declaration order breaks ties)
+// and the first rule that fires decides it — true for an assert rule, false
+// for a veto. A state no rule fires for is false.
+type RulePack struct {
+	// Schema must equal RuleSchemaVersion.
+	Schema int `toml:"schema" yaml:"schema"`
+
+	// Version identifies this revision of the pack; it is reported by
+	// `ntm agents rules` so users can tell which pack a verdict came from.
+	Version string `toml:"version" yaml:"version"`
+
+	// Agents are the agent types the pack applies to (aliases such as
+	// "claude" are accepted). "*" targets panes of unknown type.
+	Agents []string `toml:"agents" yaml:"agents"`
+
+	// Replace discards the rules the pack is layered on instead of merging
+	// with them. Only meaningful for user packs.
+	Replace bool `toml:"replace" yaml:"replace"`
+
+	Rules []Rule `toml:"rule" yaml:"rules"`
+
+	// Source is the file the pack was read from.
+	Source string `toml:"-" yaml:"-"`
+
+	compiled []*compiledRule
+}
+
+// Rule is one declarative detection rule.
+type Rule struct {
+	// ID names the rule. A user pack rule with the ID of a rule it is
+	// layered on replaces that rule.
+	ID          string    `toml:"id" yaml:"id"`
+	State       RuleState `toml:"state" yaml:"state"`
+	Description string    `toml:"description" yaml:"description"`
+
+	// Effect is "assert" (default: the state is true when the rule fires)
+	// or "veto" (the state is false when the rule fires).
+	Effect string `toml:"effect" yaml:"effect"`
+
+	// Match selects how Patterns are applied: "regex" (default, Go RE2
+	// syntax) or "substring" (case-insensitive containment).
+	Match string `toml:"match" yaml:"match"`
+
+	// Patterns fire the rule when any of them matches the window.
+	Patterns []string `toml:"patterns" yaml:"patterns"`
+
+	// PatternsFrom appends the patterns of other rules (by ID) with the same
+	// match kind, so a pack can reuse a built-in list without copying it.
+	PatternsFrom []string `toml:"patterns_from" yaml:"patterns_from"`
+
+	// Sequence is a multi-line anchor: regexps that must match consecutive
+	// lines of the window, in order.
+	Sequence []string `toml:"sequence" yaml:"sequence"`
+
+	// Detector names a built-in recognizer for structure regexps cannot
+	// express (see RuleDetectors).
+	Detector string `toml:"detector" yaml:"detector"`
+
+	// LastLines limits the window to the trailing N lines of the capture;
+	// zero means the whole capture.
+	LastLines int `toml:"last_lines" yaml:"last_lines"`
+
+	// BottomLines further limits the window to its last N non-blank lines,
+	// the bottom region of the screen regardless of blank padding.
+	BottomLines int `toml:"bottom_lines" yaml:"bottom_lines"`
+
+	// PerLine applies regexps to each line separately so ^ and $ anchor a
+	// screen line without the (?m) flag.
+	PerLine bool `toml:"per_line" yaml:"per_line"`
+
+	// Negate fires the rule when its patterns do NOT match.
+	Negate bool `toml:"negate" yaml:"negate"`
+
+	Priority int `toml:"priority" yaml:"priority"`
+
+	// Weight in (0,1] is the confidence the rule carries when it decides a
+	// state; zero means 1. The parser scales its confidence by it.
+	Weight float64 `toml:"weight" yaml:"weight"`
+
+	// Authoritative marks a working rule that wins over an idle verdict,
+	// for agents whose prompt chrome stays drawn during a turn.
+	Authoritative bool `toml:"authoritative" yaml:"authoritative"`
+
+	// Disabled removes the rule with this ID from the rules being layered on.
+	Disabled bool `toml:"disabled" yaml:"disabled"`
+}
+
+type compiledRule struct {
+	Rule
+	source  string
+	subs    []string
+	res     []*regexp.Regexp
+	seq     []*regexp.Regexp
+	detect  func(string) bool
+	ordinal int
+}
+
+// RuleDetectors lists the built-in recognizers a rule may name in detector.
+var RuleDetectors = []string{
+	"blank",
+	"claude_live_spinner",
+	"claude_turn_error",
+	"claude_turn_settled",
+	"claude_finished_turn",
+	"codex_live_work",
+	"grok_live_spinner",
+	"opencode_live_footer",
+}
+
+// ruleDetector returns the recognizer for name. Detectors get the rule's
+// window when it sets last_lines, the whole capture otherwise; the live-tail
+// recognizers bound their own windows.
+func ruleDetector(name string) func(string) bool {
+	switch name {
+	case "blank":
+		return func(s string) bool { return strings.TrimSpace(s) == "" }
+	case "claude_live_spinner":
+		return func(s string) bool { return ClaudeActivelyWorking(s, 0) }
+	case "claude_turn_error":
+		return func(s string) bool { return DetectClaudeTurnState(s, 0) == ClaudeTurnError }
+	case "claude_turn_settled":
+		return func(s string) bool {
+			st := DetectClaudeTurnState(s, 0)
+			return st == ClaudeTurnWorking || st == ClaudeTurnEnded
+		}
+	case "claude_finished_turn":
+		return claudeFinishedTurnIdle
+	case "codex_live_work":
+		return func(s string) bool { return CodexActivelyWorking(s, 0) }
+	case "grok_live_spinner":
+		return func(s string) bool { return GrokActivelyWorking(s, 0) }
+	case "opencode_live_footer":
+		return func(s string) bool { return OpencodeActivelyWorking(s, 0) }
+	}
+	return nil
+}
+
+// ParseRulePack decodes a rule pack; format is "toml" or "yaml". Structural
+// problems are reported here, pattern problems by compilation.
+func ParseRulePack(data []byte, format, source string) (*RulePack, error) {
+	var pack RulePack
+	switch format {
+	case "toml":
+		md, err := toml.Decode(string(data), &pack)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", source, err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return nil, fmt.Errorf("%s: unknown key %q", source, undecoded[0].String())
+		}
+	case "yaml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(&pack); err != nil {
+			return nil, fmt.Errorf("%s: %w", source, err)
+		}
+	default:
+		return nil, fmt.Errorf("%s: unsupported rule pack format %q", source, format)
+	}
+	pack.Source = source
+	if pack.Schema != RuleSchemaVersion {
+		return nil, fmt.Errorf("%s: unsupported schema %d (this ntm understands %d)", source, pack.Schema, RuleSchemaVersion)
+	}
+	if len(pack.Agents) == 0 {
+		return nil, fmt.Errorf("%s: agents must list at least one agent type", source)
+	}
+	for i, a := range pack.Agents {
+		if a = strings.TrimSpace(a); a == "" {
+			return nil, fmt.Errorf("%s: empty agent type", source)
+		}
+		pack.Agents[i] = string(ruleAgentKey(AgentType(a)))
+	}
+	seen := make(map[string]bool, len(pack.Rules))
+	for i := range pack.Rules {
+		r := &pack.Rules[i]
+		if r.ID == "" {
+			return nil, fmt.Errorf("%s: rule %d has no id", source, i+1)
+		}
+		if seen[r.ID] {
+			return nil, fmt.Errorf("%s: duplicate rule id %q", source, r.ID)
+		}
+		seen[r.ID] = true
+		if err := r.validate(); err != nil {
+			return nil, fmt.Errorf("%s: rule %s: %w", source, r.ID, err)
+		}
+	}
+	return &pack, nil
+}
+
+func (r *Rule) validate() error {
+	if r.Disabled {
+		return nil
+	}
+	switch r.State {
+	case RuleStateRateLimited, RuleStateWorking, RuleStateIdle, RuleStateError:
+	default:
+		return fmt.Errorf("unknown state %q", r.State)
+	}
+	switch r.Effect {
+	case "":
+		r.Effect = RuleEffectAssert
+	case RuleEffectAssert, RuleEffectVeto:
+	default:
+		return fmt.Errorf("unknown effect %q", r.Effect)
+	}
+	switch r.Match {
+	case "":
+		r.Match = RuleMatchRegex
+	case RuleMatchRegex, RuleMatchSubstring:
+	default:
+		return fmt.Errorf("unknown match %q", r.Match)
+	}
+	sources := 0
+	if len(r.Patterns) > 0 || len(r.PatternsFrom) > 0 {
+		sources++
+	}
+	if len(r.Sequence) > 0 {
+		sources++
+	}
+	if r.Detector != "" {
+		if ruleDetector(r.Detector) == nil {
+			return fmt.Errorf("unknown detector %q (known: %s)", r.Detector, strings.Join(RuleDetectors, ", "))
+		}
+		sources++
+	}
+	if sources != 1 {
+		return fmt.Errorf("exactly one of patterns, sequence or detector is required")
+	}
+	if r.LastLines < 0 || r.BottomLines < 0 {
+		return fmt.Errorf("last_lines and bottom_lines must not be negative")
+	}
+	if r.Weight < 0 || r.Weight > 1 {
+		return fmt.Errorf("weight must be between 0 and 1")
+	}
+	if r.Authoritative && (r.State != RuleStateWorking || r.Effect != RuleEffectAssert) {
+		return fmt.Errorf("authoritative applies only to working assert rules")
+	}
+	return nil
+}
+
+// compile resolves patterns_from through lookup and compiles every rule.
+func (p *RulePack) compile(lookup func(id string) *Rule) error {
+	p.compiled = p.compiled[:0]
+	for i := range p.Rules {
+		r := p.Rules[i]
+		if !r.Disabled {
+			patterns := append([]string(nil), r.Patterns...)
+			for _, id := range r.PatternsFrom {
+				ref := lookup(id)
+				if ref == nil {
+					return fmt.Errorf("%s: rule %s: patterns_from references unknown rule %q", p.Source, r.ID, id)
+				}
+				if ref.Match != r.Match || len(ref.PatternsFrom) > 0 {
+					return fmt.Errorf("%s: rule %s: patterns_from rule %q must be a %s rule with its own patterns", p.Source, r.ID, id, r.Match)
+				}
+				patterns = append(patterns, ref.Patterns...)
+			}
+			r.Patterns = patterns
+			r.PatternsFrom = nil
+		}
+		cr, err := compileRule(r, p.label())
+		if err != nil {
+			return fmt.Errorf("%s: rule %s: %w", p.Source, r.ID, err)
+		}
+		cr.ordinal = i
+		p.compiled = append(p.compiled, cr)
+	}
+	return nil
+}
+
+func compileRule(r Rule, source string) (*compiledRule, error) {
+	cr := &compiledRule{Rule: r, source: source}
+	if r.Disabled {
+		return cr, nil
+	}
+	if r.Match == RuleMatchSubstring {
+		for _, s := range r.Patterns {
+			cr.subs = append(cr.subs, strings.ToLower(s))
+		}
+	} else {
+		for _, s := range r.Patterns {
+			re, err := regexp.Compile(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %w", s, err)
+			}
+			cr.res = append(cr.res, re)
+		}
+	}
+	for _, s := range r.Sequence {
+		re, err := regexp.Compile(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sequence pattern %q: %w", s, err)
+		}
+		cr.seq = append(cr.seq, re)
+	}
+	if r.Detector != "" {
+		cr.detect = ruleDetector(r.Detector)
+	}
+	return cr, nil
+}
+
+func (p *RulePack) label() string {
+	if p.Version == "" {
+		return p.Source
+	}
+	return p.Source + "@" + p.Version
+}
+
+// window returns the part of output the rule looks at.
+func (r *compiledRule) window(output string) string {
+	text := output
+	if r.LastLines > 0 {
+		text = util.GetLastNLines(text, r.LastLines)
+	}
+	if r.BottomLines > 0 {
+		lines := strings.Split(text, "\n")
+		var bottom []string
+		for i := len(lines) - 1; i >= 0 && len(bottom) < r.BottomLines; i-- {
+			if strings.TrimSpace(lines[i]) != "" {
+				bottom = append(bottom, lines[i])
+			}
+		}
+		for i, j := 0, len(bottom)-1; i < j; i, j = i+1, j-1 {
+			bottom[i], bottom[j] = bottom[j], bottom[i]
+		}
+		text = strings.Join(bottom, "\n")
+	}
+	return text
+}
+
+// match reports whether the rule's condition holds (before negation) and
+// the evidence for it: the patterns that matched, or the detector name.
+func (r *compiledRule) match(output string) (bool, []string) {
+	text := r.window(output)
+	switch {
+	case r.detect != nil:
+		if r.detect(text) {
+			return true, []string{r.Detector}
+		}
+		return false, nil
+	case len(r.seq) > 0:
+		lines := strings.Split(text, "\n")
+		for i := 0; i+len(r.seq) <= len(lines); i++ {
+			ok := true
+			for k, re := range r.seq {
+				if !re.MatchString(strings.TrimRight(lines[i+k], "\r")) {
+					ok = false
+					break
+				}
+			}
+			if ok {
+				return true, []string{strings.TrimSpace(lines[i])}
+			}
+		}
+		return false, nil
+	case r.Match == RuleMatchSubstring:
+		hits := collectMatches(text, r.subs)
+		return len(hits) > 0, hits
+	}
+	var hits []string
+	for _, re := range r.res {
+		if r.PerLine {
+			if matchAnyLine(text, []*regexp.Regexp{re}) {
+				hits = append(hits, re.String())
+			}
+		} else if re.MatchString(text) {
+			hits = append(hits, re.String())
+		}
+	}
+	return len(hits) > 0, hits
+}
+
+// RuleTrace records how one rule fared against a capture.
+type RuleTrace struct {
+	ID          string    `json:"id"`
+	State       RuleState `json:"state"`
+	Effect      string    `json:"effect"`
+	Priority    int       `json:"priority"`
+	Source      string    `json:"source"`
+	Description string    `json:"description,omitempty"`
+	Fired       bool      `json:"fired"`
+	Evidence    []string  `json:"evidence,omitempty"`
+	Decisive    bool      `json:"decisive"`
+}
+
+// StateVerdict is the outcome of one state's decision list.
+type StateVerdict struct {
+	State    RuleState `json:"state"`
+	Detected bool      `json:"detected"`
+
+	// Rule is the ID of the rule that decided the state, empty when none
+	// fired.
+	Rule          string  `json:"rule,omitempty"`
+	Weight        float64 `json:"weight,omitempty"`
+	Authoritative bool    `json:"authoritative,omitempty"`
+
+	// Indicators are the patterns matched by the state's firing pattern
+	// rules, or the deciding detector's name when no pattern matched.
+	Indicators []string `json:"indicators,omitempty"`
+
+	Trace []RuleTrace `json:"trace,omitempty"`
+}
+
+// agentRules is the resolved decision list for one agent type.
+type agentRules struct {
+	agent   AgentType
+	sources []string
+	rules   []*compiledRule
+}
+
+func newAgentRules(agent AgentType, sources []string, rules []*compiledRule) *agentRules {
+	ar := &agentRules{agent: agent, sources: sources}
+	for _, r := range rules {
+		if !r.Disabled {
+			ar.rules = append(ar.rules, r)
+		}
+	}
+	sort.SliceStable(ar.rules, func(i, j int) bool {
+		return ar.rules[i].Priority > ar.rules[j].Priority
+	})
+	return ar
+}
+
+// overlay layers a user pack's rules on ar: same-ID rules are replaced in
+// place, disabled ones removed, new ones appended.
+func (ar *agentRules) overlay(p *RulePack) *agentRules {
+	var rules []*compiledRule
+	if !p.Replace {
+		rules = append(rules, ar.rules...)
+	}
+	for _, ur := range p.compiled {
+		idx := -1
+		for i, r := range rules {
+			if r.ID == ur.ID {
+				idx = i
+				break
+			}
+		}
+		switch {
+		case ur.Disabled && idx >= 0:
+			rules = append(rules[:idx], rules[idx+1:]...)
+		case ur.Disabled:
+		case idx >= 0:
+			rules[idx] = ur
+		default:
+			rules = append(rules, ur)
+		}
+	}
+	return newAgentRules(ar.agent, append(append([]string(nil), ar.sources...), p.label()), rules)
+}
+
+// decide runs the decision list for state against output. With explain set
+// every rule is evaluated and traced; otherwise detector rules after the
+// decision are skipped since they contribute no indicators.
+func (ar *agentRules) decide(state RuleState, output string, explain bool) StateVerdict {
+	v := StateVerdict{State: state}
+	decided := false
+	var detector string
+	for _, r := range ar.rules {
+		if r.State != state {
+			continue
+		}
+		if decided && !explain && (r.detect != nil || r.Effect == RuleEffectVeto || r.Negate) {
+			continue
+		}
+		matched, evidence := r.match(output)
+		fired := matched != r.Negate
+		decisive := fired && !decided
+		if decisive {
+			decided = true
+			v.Rule = r.ID
+			v.Detected = r.Effect == RuleEffectAssert
+			v.Weight = r.Weight
+			if v.Weight == 0 {
+				v.Weight = 1
+			}
+			v.Authoritative = r.Authoritative
+			if r.detect != nil {
+				detector = r.Detector
+			}
+		}
+		if fired && r.Effect == RuleEffectAssert && !r.Negate && r.detect == nil {
+			v.Indicators = append(v.Indicators, evidence...)
+		}
+		if explain {
+			v.Trace = append(v.Trace, RuleTrace{
+				ID:          r.ID,
+				State:       r.State,
+				Effect:      r.Effect,
+				Priority:    r.Priority,
+				Source:      r.source,
+				Description: r.Description,
+				Fired:       fired,
+				Evidence:    evidence,
+				Decisive:    decisive,
+			})
+		}
+	}
+	if len(v.Indicators) == 0 && v.Detected && detector != "" {
+		v.Indicators = []string{detector}
+	}
+	return v
+}
@@ -0,0 +1,362 @@
+package agent
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Built-in agents ship their state detection rules as embedded packs; see
+// rules/*.toml. The per-agent pattern lists in patterns.go are views of them.
+//
+//go:embed rules/*.toml
+var builtinRuleFS embed.FS
+
+// genericRuleAgent is the pack key for panes of unknown type.
+const genericRuleAgent AgentType = "*"
+
+type builtinRuleIndex struct {
+	packs   []*RulePack
+	byAgent map[AgentType]*RulePack
+	byID    map[string]*compiledRule
+	raw     map[string]*Rule
+}
+
+var builtinRules = mustLoadBuiltinRules()
+
+func mustLoadBuiltinRules() *builtinRuleIndex {
+	idx := &builtinRuleIndex{
+		byAgent: map[AgentType]*RulePack{},
+		byID:    map[string]*compiledRule{},
+		raw:     map[string]*Rule{},
+	}
+	entries, err := builtinRuleFS.ReadDir("rules")
+	if err != nil {
+		panic(err)
+	}
+	for _, e := range entries {
+		data, err := builtinRuleFS.ReadFile(path.Join("rules", e.Name()))
+		if err != nil {
+			panic(err)
+		}
+		pack, err := ParseRulePack(data, "toml", "builtin:"+e.Name())
+		if err != nil {
+			panic(err)
+		}
+		for i := range pack.Rules {
+			if idx.raw[pack.Rules[i].ID] != nil {
+				panic(fmt.Sprintf("%s: rule id %q reused across built-in packs", pack.Source, pack.Rules[i].ID))
+			}
+			idx.raw[pack.Rules[i].ID] = &pack.Rules[i]
+		}
+		idx.packs = append(idx.packs, pack)
+	}
+	for _, pack := range idx.packs {
+		if err := pack.compile(func(id string) *Rule { return idx.raw[id] }); err != nil {
+			panic(err)
+		}
+		for _, cr := range pack.compiled {
+			idx.byID[cr.ID] = cr
+		}
+		for _, a := range pack.Agents {
+			idx.byAgent[AgentType(a)] = pack
+		}
+	}
+	return idx
+}
+
+// substrings returns the lowercased patterns of a built-in substring rule.
+func (idx *builtinRuleIndex) substrings(id string) []string {
+	r := idx.byID[id]
+	if r == nil || r.Match != RuleMatchSubstring {
+		panic(fmt.Sprintf("built-in rule %q is not a substring rule", id))
+	}
+	return r.subs
+}
+
+// regexps returns the compiled patterns of a built-in regex rule.
+func (idx *builtinRuleIndex) regexps(id string) []*regexp.Regexp {
+	r := idx.byID[id]
+	if r == nil || r.Match != RuleMatchRegex || len(r.res) == 0 {
+		panic(fmt.Sprintf("built-in rule %q is not a regex rule", id))
+	}
+	return r.res
+}
+
+var (
+	rulesMu       sync.RWMutex
+	userRulePacks []*RulePack
+	pluginRules   = map[AgentType]*agentRules{}
+	resolvedRules = map[AgentType]*agentRules{}
+)
+
+// ruleAgentKey normalizes an agent type to the key rule packs are indexed by.
+func ruleAgentKey(t AgentType) AgentType {
+	if t == genericRuleAgent {
+		return t
+	}
+	if c := t.Canonical(); c.IsValid() {
+		return c
+	}
+	return pluginKey(string(t))
+}
+
+// rulesFor returns the effective decision lists for agent type t: its
+// built-in pack, else its plugin's generated pack, else the generic pack,
+// with any user packs for it layered on top.
+func rulesFor(t AgentType) *agentRules {
+	key := ruleAgentKey(t)
+	rulesMu.RLock()
+	ar := resolvedRules[key]
+	rulesMu.RUnlock()
+	if ar != nil {
+		return ar
+	}
+
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	if ar = resolvedRules[key]; ar != nil {
+		return ar
+	}
+	base := key
+	if pack := builtinRules.byAgent[key]; pack != nil {
+		ar = newAgentRules(key, []string{pack.label()}, pack.compiled)
+	} else if pr := pluginRules[key]; pr != nil {
+		ar = pr
+	} else {
+		pack := builtinRules.byAgent[genericRuleAgent]
+		ar = newAgentRules(key, []string{pack.label()}, pack.compiled)
+		base = genericRuleAgent
+	}
+	for _, p := range userRulePacks {
+		for _, a := range p.Agents {
+			if AgentType(a) == key || AgentType(a) == base {
+				ar = ar.overlay(p)
+				break
+			}
+		}
+	}
+	resolvedRules[key] = ar
+	return ar
+}
+
+// LoadRulePacks reads every *.toml, *.yaml and *.yml rule pack in dir, in
+// name order. A missing directory yields no packs. Packs that fail to parse
+// or compile are skipped and reported together in the error, so one broken
+// file does not discard the rest.
+func LoadRulePacks(dir string) ([]*RulePack, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var packs []*RulePack
+	var errs []error
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		format := ""
+		switch strings.ToLower(filepath.Ext(e.Name())) {
+		case ".toml":
+			format = "toml"
+		case ".yaml", ".yml":
+			format = "yaml"
+		default:
+			continue
+		}
+		file := filepath.Join(dir, e.Name())
+		data, err := os.ReadFile(file)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		pack, err := ParseRulePack(data, format, file)
+		if err == nil {
+			err = pack.compile(func(id string) *Rule {
+				for i := range pack.Rules {
+					if pack.Rules[i].ID == id {
+						return &pack.Rules[i]
+					}
+				}
+				return builtinRules.raw[id]
+			})
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		packs = append(packs, pack)
+	}
+	return packs, errors.Join(errs...)
+}
+
+// SetUserRulePacks installs the packs layered over the built-in, plugin and
+// generic rules, in order; nil removes them.
+func SetUserRulePacks(packs []*RulePack) {
+	rulesMu.Lock()
+	userRulePacks = packs
+	resolvedRules = map[AgentType]*agentRules{}
+	rulesMu.Unlock()
+}
+
+// registerPluginRules generates the decision lists for a plugin agent type
+// from its flat readiness patterns: declared Working patterns decide working
+// and veto idle, declared Idle patterns decide idle, declared Error patterns
+// decide error, and every undeclared state falls back to the generic rules
+// (ntm#260). Patterns are matched per line, as plugins document.
+func registerPluginRules(key AgentType, pp PluginPatterns) {
+	generic := builtinRules.byAgent[genericRuleAgent]
+	var rules []*compiledRule
+	fallback := func(state RuleState) {
+		for _, r := range generic.compiled {
+			if r.State == state {
+				rules = append(rules, r)
+			}
+		}
+	}
+	source := "plugin:" + string(key)
+	plugin := func(id string, state RuleState, effect string, lastLines int, res []*regexp.Regexp) *compiledRule {
+		cr := &compiledRule{
+			Rule: Rule{
+				ID:        string(key) + "." + id,
+				State:     state,
+				Effect:    effect,
+				Match:     RuleMatchRegex,
+				LastLines: lastLines,
+				PerLine:   true,
+			},
+			source: source,
+			res:    res,
+		}
+		for _, re := range res {
+			cr.Patterns = append(cr.Patterns, re.String())
+		}
+		return cr
+	}
+
+	fallback(RuleStateRateLimited)
+	if len(pp.Working) > 0 {
+		rules = append(rules, plugin("working", RuleStateWorking, RuleEffectAssert, pluginLiveTailLines, pp.Working))
+	} else {
+		fallback(RuleStateWorking)
+	}
+	if pp.Declared() {
+		if len(pp.Working) > 0 {
+			veto := plugin("idle.working_veto", RuleStateIdle, RuleEffectVeto, pluginLiveTailLines, pp.Working)
+			veto.Priority = 90
+			rules = append(rules, veto)
+		}
+	}
+	if len(pp.Idle) > 0 {
+		rules = append(rules, plugin("idle", RuleStateIdle, RuleEffectAssert, 5, pp.Idle))
+	} else {
+		fallback(RuleStateIdle)
+	}
+	if len(pp.Error) > 0 {
+		rules = append(rules, plugin("error", RuleStateError, RuleEffectAssert, 10, pp.Error))
+	} else {
+		fallback(RuleStateError)
+	}
+
+	rulesMu.Lock()
+	pluginRules[key] = newAgentRules(key, []string{source, generic.label()}, rules)
+	resolvedRules = map[AgentType]*agentRules{}
+	rulesMu.Unlock()
+}
+
+func unregisterPluginRules() {
+	rulesMu.Lock()
+	pluginRules = map[AgentType]*agentRules{}
+	resolvedRules = map[AgentType]*agentRules{}
+	rulesMu.Unlock()
+}
+
+// RuleSummary describes one rule in effect for an agent type.
+type RuleSummary struct {
+	ID            string    `json:"id"`
+	State         RuleState `json:"state"`
+	Effect        string    `json:"effect"`
+	Priority      int       `json:"priority"`
+	Source        string    `json:"source"`
+	Description   string    `json:"description,omitempty"`
+	Detector      string    `json:"detector,omitempty"`
+	Patterns      int       `json:"patterns,omitempty"`
+	LastLines     int       `json:"last_lines,omitempty"`
+	Negate        bool      `json:"negate,omitempty"`
+	Authoritative bool      `json:"authoritative,omitempty"`
+}
+
+// EffectiveRules returns the rule packs and rules in effect for agent type t,
+// rules in decision order per state.
+func EffectiveRules(t AgentType) (packs []string, rules []RuleSummary) {
+	ar := rulesFor(t)
+	for _, state := range RuleStates {
+		for _, r := range ar.rules {
+			if r.State != state {
+				continue
+			}
+			rules = append(rules, RuleSummary{
+				ID:            r.ID,
+				State:         r.State,
+				Effect:        r.Effect,
+				Priority:      r.Priority,
+				Source:        r.source,
+				Description:   r.Description,
+				Detector:      r.Detector,
+				Patterns:      len(r.Patterns) + len(r.Sequence),
+				LastLines:     r.LastLines,
+				Negate:        r.Negate,
+				Authoritative: r.Authoritative,
+			})
+		}
+	}
+	return append([]string(nil), ar.sources...), rules
+}
+
+// RuleAgents lists the agent types with a built-in rule pack.
+func RuleAgents() []AgentType {
+	var out []AgentType
+	for a := range builtinRules.byAgent {
+		if a != genericRuleAgent {
+			out = append(out, a)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+// RuleExplanation is the result of running the detection rules against one
+// capture: the parsed state plus, per state, every rule tried and which one
+// decided it.
+type RuleExplanation struct {
+	AgentType AgentType      `json:"agent_type"`
+	Packs     []string       `json:"rule_packs"`
+	State     *AgentState    `json:"state"`
+	Verdicts  []StateVerdict `json:"verdicts"`
+}
+
+// ExplainRules parses output (with hint as the agent type when known) and
+// traces the rule decisions behind the result.
+func ExplainRules(output string, hint AgentType) (*RuleExplanation, error) {
+	st, err := NewParser().ParseWithHint(output, hint)
+	if err != nil {
+		return nil, err
+	}
+	clean := stripANSICodes(output)
+	ar := rulesFor(st.Type)
+	ex := &RuleExplanation{AgentType: st.Type, Packs: append([]string(nil), ar.sources...), State: st}
+	for _, state := range RuleStates {
+		ex.Verdicts = append(ex.Verdicts, ar.decide(state, clean, true))
+	}
+	return ex, nil
+}
@@ -0,0 +1,196 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuiltinRulePacksCoverEveryState(t *testing.T) {
+	for _, agent := range append(RuleAgents(), AgentTypeUnknown) {
+		ar := rulesFor(agent)
+		for _, state := range RuleStates {
+			found := false
+			for _, r := range ar.rules {
+				if r.State == state {
+					found = true
+					break
+				}
+			}
+			if !found {
+				t.Errorf("%s has no %s rule", agent, state)
+			}
+		}
+	}
+	if got, want := len(rulesFor(AgentTypeUnknown).rules[0].subs), len(ccRateLimitPatterns)+len(codRateLimitPatterns)+
+		len(gmiRateLimitPatterns)+len(cursorRateLimitPatterns)+len(windsurfRateLimitPatterns)+
+		len(aiderRateLimitPatterns)+len(ollamaRateLimitPatterns); got != want {
+		t.Errorf("generic rate limit patterns = %d, want the union %d", got, want)
+	}
+	if id := rulesFor(AgentTypeAntigravity).rules[0].ID; !strings.HasPrefix(id, "gmi.") {
+		t.Errorf("agy resolved to %s, want the gmi pack", id)
+	}
+	if rulesFor("claude").agent != AgentTypeClaudeCode {
+		t.Error("alias did not resolve to the cc pack")
+	}
+}
+
+func TestParseRulePackRejectsInvalidPacks(t *testing.T) {
+	tests := map[string]string{
+		"schema":        "schema = 2\nagents = [\"cc\"]",
+		"no agents":     "schema = 1",
+		"unknown key":   "schema = 1\nagents = [\"cc\"]\n[[rule]]\nid = \"x\"\nstate = \"idle\"\npatterns = [\"a\"]\nlast_line = 3",
+		"state":         "schema = 1\nagents = [\"cc\"]\n[[rule]]\nid = \"x\"\nstate = \"busy\"\npatterns = [\"a\"]",
+		"detector":      "schema = 1\nagents = [\"cc\"]\n[[rule]]\nid = \"x\"\nstate = \"idle\"\ndetector = \"nope\"",
+		"two sources":   "schema = 1\nagents = [\"cc\"]\n[[rule]]\nid = \"x\"\nstate = \"idle\"\ndetector = \"blank\"\npatterns = [\"a\"]",
+		"authoritative": "schema = 1\nagents = [\"cc\"]\n[[rule]]\nid = \"x\"\nstate = \"idle\"\npatterns = [\"a\"]\nauthoritative = true",
+		"weight":        "schema = 1\nagents = [\"cc\"]\n[[rule]]\nid = \"x\"\nstate = \"idle\"\npatterns = [\"a\"]\nweight = 2.0",
+		"duplicate":     "schema = 1\nagents = [\"cc\"]\n[[rule]]\nid = \"x\"\nstate = \"idle\"\npatterns = [\"a\"]\n[[rule]]\nid = \"x\"\nstate = \"idle\"\npatterns = [\"b\"]",
+	}
+	for name, src := range tests {
+		if _, err := ParseRulePack([]byte(src), "toml", name); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+
+	dir := t.TempDir()
+	writeRulePack(t, dir, "a-bad-regex.toml", "schema = 1\nagents = [\"cc\"]\n[[rule]]\nid = \"x\"\nstate = \"idle\"\npatterns = [\"(\"]")
+	writeRulePack(t, dir, "b-bad-ref.toml", "schema = 1\nagents = [\"cc\"]\n[[rule]]\nid = \"x\"\nstate = \"idle\"\npatterns_from = [\"cc.working\"]")
+	writeRulePack(t, dir, "c-good.yaml", "schema: 1\nagents: [cod]\nrules:\n  - id: cod.extra\n    state: error\n    patterns: ['boom']\n")
+	packs, err := LoadRulePacks(dir)
+	if err == nil || !strings.Contains(err.Error(), "a-bad-regex.toml") || !strings.Contains(err.Error(), "b-bad-ref.toml") {
+		t.Errorf("LoadRulePacks error = %v", err)
+	}
+	if len(packs) != 1 || packs[0].Agents[0] != "cod" {
+		t.Errorf("packs = %+v", packs)
+	}
+}
+
+func TestUserRulePacksLayerOverBuiltins(t *testing.T) {
+	t.Cleanup(func() { SetUserRulePacks(nil) })
+	dir := t.TempDir()
+	writeRulePack(t, dir, "claude.toml", `
+schema = 1
+version = "2026.10"
+agents = ["claude"]
+
+[[rule]]
+id = "cc.working"
+disabled = true
+
+# A multi-line anchor: a deploy banner directly above the progress bar.
+[[rule]]
+id = "my.deploying"
+state = "working"
+sequence = ['^Deploying\b', '^\[=+ *\]']
+priority = 10
+weight = 0.5
+
+[[rule]]
+id = "my.prompt_only_at_bottom"
+state = "idle"
+patterns = ['^READY>$']
+bottom_lines = 1
+priority = 60
+
+[[rule]]
+id = "my.no_footer_no_error"
+state = "error"
+effect = "veto"
+negate = true
+match = "substring"
+patterns = ["status:"]
+priority = 200
+`)
+	packs, err := LoadRulePacks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetUserRulePacks(packs)
+
+	p := NewParser()
+	// The disabled substring rule no longer reads scrollback as work.
+	st, _ := p.ParseWithHint("running tests\nsomething else", AgentTypeClaudeCode)
+	if st.IsWorking {
+		t.Errorf("disabled cc.working still fired: %+v", st)
+	}
+
+	base, _ := p.ParseWithHint("Deploying app\n[====    ]\nstatus: ok", AgentTypeClaudeCode)
+	if !base.IsWorking || len(base.WorkIndicators) == 0 {
+		t.Fatalf("sequence rule did not fire: %+v", base)
+	}
+	unweighted := p.(*parserImpl).calculateConfidence(base)
+	if base.Confidence != unweighted*0.5 {
+		t.Errorf("confidence = %v, want %v scaled by 0.5", base.Confidence, unweighted)
+	}
+	if st, _ := p.ParseWithHint("[====    ]\nDeploying app\nstatus: ok", AgentTypeClaudeCode); st.IsWorking {
+		t.Error("sequence matched out of order")
+	}
+
+	// bottom_lines ignores blank padding but not a later non-blank line.
+	if st, _ := p.ParseWithHint("some output\nREADY>\n\n\n", AgentTypeClaudeCode); !st.IsIdle {
+		t.Errorf("bottom prompt not idle: %+v", st)
+	}
+	if got := p.(*parserImpl).detectIdle("READY>\nmore output", AgentTypeClaudeCode); got {
+		t.Error("prompt above the bottom line read as idle")
+	}
+
+	// The negated veto clears error unless a status footer is present.
+	if p.(*parserImpl).detectError("error: boom", AgentTypeClaudeCode) {
+		t.Error("negated veto did not suppress error")
+	}
+	if !p.(*parserImpl).detectError("error: boom\nstatus: red", AgentTypeClaudeCode) {
+		t.Error("error not detected with footer present")
+	}
+
+	packsInEffect, _ := EffectiveRules(AgentTypeClaudeCode)
+	if len(packsInEffect) != 2 || !strings.HasSuffix(packsInEffect[1], "claude.toml@2026.10") {
+		t.Errorf("packs = %v", packsInEffect)
+	}
+	// Other agents keep their built-in rules.
+	if !p.(*parserImpl).detectWorking("running tests", AgentTypeCodex) {
+		t.Error("codex rules changed by a cc pack")
+	}
+}
+
+func TestExplainRulesNamesTheDecidingRule(t *testing.T) {
+	capture := "reading config\n✻ Whirlpooling… (ctrl+c to interrupt · 2m 44s)\n❯ \n"
+	ex, err := ExplainRules(capture, AgentTypeClaudeCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ex.State.IsWorking || ex.State.IsIdle {
+		t.Fatalf("state = %+v", ex.State)
+	}
+	verdicts := map[RuleState]StateVerdict{}
+	for _, v := range ex.Verdicts {
+		verdicts[v.State] = v
+	}
+	if v := verdicts[RuleStateWorking]; v.Rule != "cc.working.spinner" || !v.Authoritative {
+		t.Errorf("working verdict = %+v", v)
+	}
+	if v := verdicts[RuleStateIdle]; v.Rule != "cc.idle.spinner_veto" || v.Detected {
+		t.Errorf("idle verdict = %+v", v)
+	}
+	// The substring rule still fires and supplies the indicators.
+	if got := ex.State.WorkIndicators; len(got) != 1 || got[0] != "reading " {
+		t.Errorf("indicators = %q", got)
+	}
+	traced := 0
+	for _, tr := range verdicts[RuleStateIdle].Trace {
+		if tr.Decisive {
+			traced++
+		}
+	}
+	if len(verdicts[RuleStateIdle].Trace) != 4 || traced != 1 {
+		t.Errorf("idle trace = %+v", verdicts[RuleStateIdle].Trace)
+	}
+}
+
+func writeRulePack(t *testing.T, dir, name, body string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
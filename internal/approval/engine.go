@@ -1,6 +1,8 @@
 // Package approval provides a unified approval workflow engine for NTM.
 // It handles approval requests, SLB (two-person rule) enforcement, notifications,
 // and event emission for sensitive operations like force-releasing reservations.
+// A Policy adds quorum rules over named approver groups, time-based
+// escalation, and delegation, all enforced natively (see quorum.go).
 package approval
 
 import (
@@ -15,6 +17,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Dicklesworthstone/ntm/internal/audit"
 	"github.com/Dicklesworthstone/ntm/internal/events"
 	"github.com/Dicklesworthstone/ntm/internal/notify"
 	"github.com/Dicklesworthstone/ntm/internal/state"
@@ -42,6 +45,16 @@ var (
 	// ErrConcurrentDecision reports that the guarded SQL transition lost to a
 	// decision landed by a concurrent process.
 	ErrConcurrentDecision = errors.New("concurrently decided or expired")
+	// ErrNotEligible reports an approver outside every group a Policy rule
+	// draws its quorum from.
+	ErrNotEligible = errors.New("not an eligible approver")
+	// ErrNoDelegation reports a vote on behalf of an approver who has not
+	// delegated to the voter, or whose delegation has lapsed.
+	ErrNoDelegation = errors.New("no active delegation")
+	// ErrDuplicateVote reports a second vote by the same principal or the
+	// same voter: one person counts once toward a quorum, whoever they vote
+	// for.
+	ErrDuplicateVote = errors.New("already voted")
 )
 
 // Config holds configuration for the approval engine.
@@ -61,6 +74,10 @@ type Config struct {
 
 	// EnableSLB routes SLB-required approvals to the slb queue when available.
 	EnableSLB bool
+
+	// Policy adds quorum, escalation and delegation rules. Requests a rule
+	// matches are decided natively and never go to the slb queue.
+	Policy Policy
 }
 
 // DefaultConfig returns a default configuration.
@@ -138,8 +155,10 @@ func (e *Engine) Request(ctx context.Context, params RequestParams) (*state.Appr
 		Status:        state.ApprovalPending,
 	}
 
-	// If SLB is required and available, enqueue an SLB request first.
-	if params.RequiresSLB && e.config.EnableSLB {
+	// If SLB is required and available, enqueue an SLB request first, unless
+	// a Policy rule enforces the two-person rule natively.
+	rule := e.config.Policy.ruleFor(params.Action, params.RequiresSLB)
+	if params.RequiresSLB && e.config.EnableSLB && rule == nil {
 		slbID, err := e.enqueueSLBRequest(ctx, params)
 		if err != nil {
 			return nil, err
@@ -158,18 +177,18 @@ func (e *Engine) Request(ctx context.Context, params RequestParams) (*state.Appr
 	}
 
 	// Emit event
-	if e.eventBus != nil {
-		e.eventBus.Publish(events.BaseEvent{
-			Type:      "approval.requested",
-			Timestamp: now,
-		})
-	}
+	e.publish("approval.requested", now)
 
 	// Send notification
 	if e.notifier != nil && e.config.NotifyOnRequest {
 		e.notifyApprovalRequest(approval)
 	}
 
+	// Fire escalation steps due immediately (after = 0).
+	if err := e.escalate(approval, now); err != nil {
+		slog.Warn("approval escalation failed", "approval_id", approval.ID, "error", err)
+	}
+
 	return approval, nil
 }
 
@@ -226,9 +245,13 @@ func (e *Engine) Check(ctx context.Context, id string) (*state.Approval, error)
 		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
 	}
 
-	// Check if expired
-	if approval.Status == state.ApprovalPending && time.Now().After(approval.ExpiresAt) {
-		if err := e.expireApproval(approval, time.Now().UTC()); err != nil {
+	// Fire due escalation steps, then check if expired
+	now := time.Now().UTC()
+	if err := e.escalate(approval, now); err != nil {
+		return nil, err
+	}
+	if approval.Status == state.ApprovalPending && now.After(approval.ExpiresAt) {
+		if err := e.expireApproval(approval, now); err != nil {
 			return nil, err
 		}
 	}
@@ -236,102 +259,154 @@ func (e *Engine) Check(ctx context.Context, id string) (*state.Approval, error)
 	return approval, nil
 }
 
-// Approve grants an approval request.
+// Approve grants an approval request on behalf of approverID. Under a
+// Policy rule it casts one vote; see ApproveAs.
 func (e *Engine) Approve(ctx context.Context, id string, approverID string) error {
+	_, err := e.ApproveAs(ctx, id, Approver{ID: approverID})
+	return err
+}
+
+// ApproveAs records approver's approval. A request no Policy rule matches is
+// approved outright. Under a rule the approval is a vote toward the rule's
+// quorum, cast for the approver or for the absent approver it holds a
+// delegation from: the request is approved once every requirement is met and
+// stays pending, with the result listing what is still missing, until then.
+func (e *Engine) ApproveAs(ctx context.Context, id string, approver Approver) (*VoteResult, error) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
-	approval, err := e.store.GetApproval(id)
+	now := time.Now().UTC()
+	approval, err := e.pendingApproval(id, now)
 	if err != nil {
-		return fmt.Errorf("get approval: %w", err)
+		return nil, err
 	}
-	if approval == nil {
-		return fmt.Errorf("%w: %s", ErrNotFound, id)
+	if len(e.config.ApproverList) > 0 && !contains(e.config.ApproverList, approver.ID) {
+		return nil, fmt.Errorf("%s is not an authorized approver", approver.ID)
 	}
 
-	now := time.Now().UTC()
-	if err := e.ensurePendingApproval(approval); err != nil {
-		return err
+	rule := e.config.Policy.ruleFor(approval.Action, approval.RequiresSLB)
+	if rule == nil {
+		if approval.RequiresSLB && approver.ID == approval.RequestedBy {
+			return nil, ErrSLBSelfApproval
+		}
+		if err := e.decide(approval, state.ApprovalApproved, approver.ID, "", now); err != nil {
+			return nil, err
+		}
+		return &VoteResult{Approval: approval}, nil
 	}
-	expired, err := e.expireIfNeeded(approval, now)
+
+	principal, groups, err := e.config.Policy.principal(rule, approval, approver, now)
 	if err != nil {
-		return err
-	}
-	if expired {
-		return ErrExpired
+		return nil, err
 	}
-	if len(e.config.ApproverList) > 0 && !contains(e.config.ApproverList, approverID) {
-		return fmt.Errorf("%s is not an authorized approver", approverID)
+	vote := &state.ApprovalVote{
+		ApprovalID: id,
+		Principal:  principal,
+		Voter:      approver.ID,
+		OnBehalfOf: approver.OnBehalfOf,
+		Groups:     groups,
+		CreatedAt:  now,
 	}
-	if approval.RequiresSLB && approverID == approval.RequestedBy {
-		return ErrSLBSelfApproval
-	}
-
-	// Update approval. The pending->approved transition is guarded in SQL
-	// (like Consume's approved->consumed): a concurrent `ntm approve deny`
-	// runs in a separate process, so both may read status=pending; the
-	// conditional UPDATE ensures the first decision is terminal instead of
-	// letting this write silently flip a landed denial to approved.
-	approval.Status = state.ApprovalApproved
-	approval.ApprovedBy = approverID
-	approval.ApprovedAt = &now
+	added, err := e.store.AddApprovalVote(vote)
+	if err != nil {
+		return nil, fmt.Errorf("record vote: %w", err)
+	}
+	if !added {
+		return nil, fmt.Errorf("%w: %s already voted on %s", ErrDuplicateVote, approver.ID, id)
+	}
+	e.publish("approval.voted", now)
+	logAudit(audit.ActorUser, "approval.vote", map[string]interface{}{
+		"approval_id":  id,
+		"action":       approval.Action,
+		"resource":     approval.Resource,
+		"requested_by": approval.RequestedBy,
+		"voter":        approver.ID,
+		"on_behalf_of": approver.OnBehalfOf,
+		"groups":       groups,
+	})
 
-	ok, err := e.store.UpdateApprovalFrom(approval, state.ApprovalPending)
+	votes, err := e.store.ListApprovalVotes(id)
 	if err != nil {
-		return fmt.Errorf("update approval: %w", err)
+		return nil, fmt.Errorf("list votes: %w", err)
 	}
-	if !ok {
-		return fmt.Errorf("approval %s was %w; re-check its status before approving", id, ErrConcurrentDecision)
+	result := &VoteResult{Approval: approval, Votes: votes, Missing: rule.missing(votes)}
+	if len(result.Missing) > 0 {
+		return result, nil
 	}
-
-	// Emit event
-	if e.eventBus != nil {
-		e.eventBus.Publish(events.BaseEvent{
-			Type:      "approval.approved",
-			Timestamp: now,
-		})
+	if err := e.decide(approval, state.ApprovalApproved, principals(votes), "", now); err != nil {
+		return nil, err
 	}
-
-	// Notify
-	if e.notifier != nil && e.config.NotifyOnDecision {
-		e.notifyApprovalDecision(approval, "approved")
-	}
-
-	// Wake up waiters
-	e.notifyWaiters(id)
-
-	return nil
+	return result, nil
 }
 
 // Deny rejects an approval request.
 func (e *Engine) Deny(ctx context.Context, id string, approverID string, reason string) error {
+	return e.DenyAs(ctx, id, Approver{ID: approverID}, reason)
+}
+
+// DenyAs rejects an approval request. A single denial is final. Under a
+// Policy rule the denier must be eligible to approve (directly or through a
+// delegation), or be the requester withdrawing the request.
+func (e *Engine) DenyAs(ctx context.Context, id string, approver Approver, reason string) error {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
+	now := time.Now().UTC()
+	approval, err := e.pendingApproval(id, now)
+	if err != nil {
+		return err
+	}
+
+	decider := approver.ID
+	rule := e.config.Policy.ruleFor(approval.Action, approval.RequiresSLB)
+	withdrawn := approver.ID == approval.RequestedBy && approver.OnBehalfOf == ""
+	if rule != nil && !withdrawn {
+		principal, _, err := e.config.Policy.principal(rule, approval, approver, now)
+		if err != nil {
+			return err
+		}
+		decider = principal
+		if principal != approver.ID {
+			decider = approver.ID + " for " + principal
+		}
+	}
+	return e.decide(approval, state.ApprovalDenied, decider, reason, now)
+}
+
+// pendingApproval loads a record that can still be decided: it exists, its
+// escalation timeout has not resolved it, and it is pending and unexpired.
+func (e *Engine) pendingApproval(id string, now time.Time) (*state.Approval, error) {
 	approval, err := e.store.GetApproval(id)
 	if err != nil {
-		return fmt.Errorf("get approval: %w", err)
+		return nil, fmt.Errorf("get approval: %w", err)
 	}
 	if approval == nil {
-		return fmt.Errorf("%w: %s", ErrNotFound, id)
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	if err := e.escalate(approval, now); err != nil {
+		return nil, err
 	}
-
-	now := time.Now().UTC()
 	if err := e.ensurePendingApproval(approval); err != nil {
-		return err
+		return nil, err
 	}
 	expired, err := e.expireIfNeeded(approval, now)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if expired {
-		return ErrExpired
+		return nil, ErrExpired
 	}
+	return approval, nil
+}
 
-	// Update approval. Guarded like Approve: the first decision to land is
-	// terminal; a racing process's approve must not be overwritten blindly.
-	approval.Status = state.ApprovalDenied
-	approval.ApprovedBy = approverID // Record who denied
+// decide moves a pending record to approved or denied. The transition is
+// guarded in SQL (like Consume's approved->consumed): a concurrent `ntm
+// approve deny` runs in a separate process, so both may read status=pending;
+// the conditional UPDATE ensures the first decision is terminal instead of
+// letting this write silently flip a landed denial to approved.
+func (e *Engine) decide(approval *state.Approval, status state.ApprovalStatus, by, reason string, now time.Time) error {
+	approval.Status = status
+	approval.ApprovedBy = by // for a denial, who denied
 	approval.ApprovedAt = &now
 	approval.DeniedReason = reason
 
@@ -340,26 +415,29 @@ func (e *Engine) Deny(ctx context.Context, id string, approverID string, reason
 		return fmt.Errorf("update approval: %w", err)
 	}
 	if !ok {
-		return fmt.Errorf("approval %s was %w; re-check its status before denying", id, ErrConcurrentDecision)
+		verb := "approving"
+		if status == state.ApprovalDenied {
+			verb = "denying"
+		}
+		return fmt.Errorf("approval %s was %w; re-check its status before %s", approval.ID, ErrConcurrentDecision, verb)
 	}
 
-	// Emit event
+	e.publish("approval."+string(status), now)
+	if e.notifier != nil && e.config.NotifyOnDecision {
+		e.notifyApprovalDecision(approval, string(status))
+	}
+	e.notifyWaiters(approval.ID)
+	return nil
+}
+
+// publish emits an approval lifecycle event.
+func (e *Engine) publish(eventType string, at time.Time) {
 	if e.eventBus != nil {
 		e.eventBus.Publish(events.BaseEvent{
-			Type:      "approval.denied",
-			Timestamp: now,
+			Type:      eventType,
+			Timestamp: at,
 		})
 	}
-
-	// Notify
-	if e.notifier != nil && e.config.NotifyOnDecision {
-		e.notifyApprovalDecision(approval, "denied")
-	}
-
-	// Wake up waiters
-	e.notifyWaiters(id)
-
-	return nil
 }
 
 // Consume marks an approved record as spent (status=consumed). One approval
@@ -400,12 +478,7 @@ func (e *Engine) Consume(ctx context.Context, id string, consumerID string) erro
 		return fmt.Errorf("approval %s was already spent by a concurrent consumer", id)
 	}
 
-	if e.eventBus != nil {
-		e.eventBus.Publish(events.BaseEvent{
-			Type:      "approval.consumed",
-			Timestamp: time.Now().UTC(),
-		})
-	}
+	e.publish("approval.consumed", time.Now().UTC())
 	return nil
 }
 
@@ -422,8 +495,12 @@ func (e *Engine) LatestForCorrelation(ctx context.Context, correlationID string)
 		return nil, nil
 	}
 	latest := records[0]
-	if latest.Status == state.ApprovalPending && time.Now().After(latest.ExpiresAt) {
-		if err := e.expireApproval(&latest, time.Now().UTC()); err != nil {
+	now := time.Now().UTC()
+	if err := e.escalate(&latest, now); err != nil {
+		return nil, err
+	}
+	if latest.Status == state.ApprovalPending && now.After(latest.ExpiresAt) {
+		if err := e.expireApproval(&latest, now); err != nil {
 			return nil, err
 		}
 	}
@@ -437,10 +514,12 @@ func (e *Engine) ListPending(ctx context.Context) ([]state.Approval, error) {
 		return nil, fmt.Errorf("list pending approvals: %w", err)
 	}
 
-	// Filter out expired ones and update them
+	// Fire due escalation steps, then filter out expired ones and update them
 	var pending []state.Approval
 	now := time.Now()
 	for _, a := range approvals {
+		// Best-effort like expiry: a failed sweep retries on the next read.
+		_ = e.escalate(&a, now.UTC())
 		if a.Status == state.ApprovalPending && now.After(a.ExpiresAt) {
 			// Mark as expired (best-effort, continue even if update fails)
 			_ = e.expireApproval(&a, now.UTC())
@@ -464,6 +543,7 @@ func (e *Engine) History(ctx context.Context) ([]state.Approval, error) {
 
 	now := time.Now()
 	for i := range approvals {
+		_ = e.escalate(&approvals[i], now.UTC())
 		if approvals[i].Status == state.ApprovalPending && now.After(approvals[i].ExpiresAt) {
 			// Best-effort: expireApproval updates the row's status in place
 			// (or reloads the record if a concurrent decision won the race).
@@ -578,12 +658,7 @@ func (e *Engine) expireApproval(approval *state.Approval, now time.Time) error {
 		}
 		return nil
 	}
-	if e.eventBus != nil {
-		e.eventBus.Publish(events.BaseEvent{
-			Type:      "approval.expired",
-			Timestamp: now,
-		})
-	}
+	e.publish("approval.expired", now)
 	e.notifyWaiters(approval.ID)
 	return nil
 }
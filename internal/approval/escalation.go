@@ -0,0 +1,187 @@
+package approval
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/audit"
+	"github.com/Dicklesworthstone/ntm/internal/notify"
+	"github.com/Dicklesworthstone/ntm/internal/state"
+)
+
+// logAudit mirrors quorum votes, escalations and timeouts into the audit log
+// alongside the durable records in state.db. Tests replace it to observe
+// the trail.
+var logAudit = func(actor audit.Actor, target string, payload map[string]interface{}) {
+	_ = audit.LogEvent("", audit.EventTypeStateChange, actor, target, payload, nil)
+}
+
+// Escalate fires due escalation steps and timeouts for every pending
+// request. Reads (Check, ListPending, History) already do this lazily for
+// the records they return; long-running hosts such as `ntm serve` call
+// Escalate periodically so a request nobody looks at still escalates.
+func (e *Engine) Escalate(ctx context.Context) error {
+	if !e.config.Policy.Escalates() {
+		return nil
+	}
+	pending, err := e.store.ListPendingApprovals()
+	if err != nil {
+		return fmt.Errorf("list pending approvals: %w", err)
+	}
+	var errs []error
+	now := time.Now().UTC()
+	for i := range pending {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := e.escalate(&pending[i], now); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Tally returns a request with the votes cast on it and, while it is pending
+// under a Policy rule, the quorum requirements still unmet.
+func (e *Engine) Tally(ctx context.Context, id string) (*VoteResult, error) {
+	approval, err := e.Check(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	votes, err := e.store.ListApprovalVotes(id)
+	if err != nil {
+		return nil, fmt.Errorf("list votes: %w", err)
+	}
+	result := &VoteResult{Approval: approval, Votes: votes}
+	if rule := e.config.Policy.ruleFor(approval.Action, approval.RequiresSLB); rule != nil && approval.Status == state.ApprovalPending {
+		result.Missing = rule.missing(votes)
+	}
+	return result, nil
+}
+
+// Escalations lists the escalation steps fired for a request.
+func (e *Engine) Escalations(ctx context.Context, id string) ([]state.ApprovalEscalation, error) {
+	steps, err := e.store.ListApprovalEscalations(id)
+	if err != nil {
+		return nil, fmt.Errorf("list escalations: %w", err)
+	}
+	return steps, nil
+}
+
+// escalate fires the escalation steps of approval's rule that are due at now
+// and applies the rule's timeout. Each step is claimed in the store first,
+// so a group is notified once however many processes sweep the request.
+// Steps and timeouts falling after the record's own deadline never fire: the
+// request expires first.
+func (e *Engine) escalate(approval *state.Approval, now time.Time) error {
+	if approval.Status != state.ApprovalPending {
+		return nil
+	}
+	rule := e.config.Policy.ruleFor(approval.Action, approval.RequiresSLB)
+	if rule == nil {
+		return nil
+	}
+
+	for i, step := range rule.Escalation {
+		due := approval.CreatedAt.Add(step.After)
+		if now.Before(due) || due.After(approval.ExpiresAt) {
+			break
+		}
+		claimed, err := e.store.ClaimApprovalEscalation(&state.ApprovalEscalation{
+			ApprovalID: approval.ID,
+			Step:       i,
+			Notified:   step.Notify,
+			CreatedAt:  now,
+		})
+		if err != nil {
+			return fmt.Errorf("escalate approval %s: %w", approval.ID, err)
+		}
+		if claimed {
+			e.notifyEscalation(approval, i, step, now)
+		}
+	}
+
+	if rule.OnTimeout == "" {
+		return nil
+	}
+	deadline := approval.CreatedAt.Add(rule.Timeout)
+	if now.Before(deadline) || deadline.After(approval.ExpiresAt) {
+		return nil
+	}
+	status, reason := state.ApprovalDenied, fmt.Sprintf("no quorum within %s", rule.Timeout)
+	if rule.OnTimeout == TimeoutApprove {
+		status, reason = state.ApprovalApproved, ""
+	}
+	if err := e.decide(approval, status, TimeoutDecider, reason, now); err != nil {
+		if !errors.Is(err, ErrConcurrentDecision) {
+			return err
+		}
+		// Another process decided first; report its outcome.
+		fresh, gerr := e.store.GetApproval(approval.ID)
+		if gerr != nil {
+			return fmt.Errorf("reload concurrently updated approval: %w", gerr)
+		}
+		if fresh != nil {
+			*approval = *fresh
+		}
+		return nil
+	}
+	logAudit(audit.ActorSystem, "approval.timeout", map[string]interface{}{
+		"approval_id":  approval.ID,
+		"action":       approval.Action,
+		"resource":     approval.Resource,
+		"requested_by": approval.RequestedBy,
+		"decision":     string(status),
+		"timeout":      rule.Timeout.String(),
+	})
+	return nil
+}
+
+// notifyEscalation announces an escalation step to its group.
+func (e *Engine) notifyEscalation(approval *state.Approval, step int, s EscalationStep, now time.Time) {
+	members := ""
+	if g := e.config.Policy.group(s.Notify); g != nil {
+		members = g.describe()
+	}
+	e.publish("approval.escalated", now)
+	logAudit(audit.ActorSystem, "approval.escalate", map[string]interface{}{
+		"approval_id": approval.ID,
+		"action":      approval.Action,
+		"resource":    approval.Resource,
+		"step":        step,
+		"group":       s.Notify,
+		"members":     members,
+	})
+	if e.notifier == nil {
+		return
+	}
+	// Notification is best-effort; don't fail the operation if it fails
+	_ = e.notifier.Notify(notify.Event{
+		Type:    "approval.escalated",
+		Message: fmt.Sprintf("Approval needed from %s: %s on %s", s.Notify, approval.Action, approval.Resource),
+		Session: approval.CorrelationID,
+		Details: map[string]string{
+			"approval_id":  approval.ID,
+			"action":       approval.Action,
+			"resource":     approval.Resource,
+			"requested_by": approval.RequestedBy,
+			"group":        s.Notify,
+			"members":      members,
+			"step":         strconv.Itoa(step),
+			"expires_at":   approval.ExpiresAt.Format(time.RFC3339),
+		},
+	})
+}
+
+// Escalates reports whether any rule escalates or times out.
+func (p Policy) Escalates() bool {
+	for _, r := range p.Rules {
+		if len(r.Escalation) > 0 || r.OnTimeout != "" {
+			return true
+		}
+	}
+	return false
+}
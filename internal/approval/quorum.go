@@ -0,0 +1,314 @@
+package approval
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/state"
+)
+
+// Timeout outcomes for Rule.OnTimeout.
+const (
+	TimeoutDeny    = "deny"
+	TimeoutApprove = "approve"
+)
+
+// TimeoutDecider is recorded as the decider of a request that a rule's
+// timeout resolved.
+const TimeoutDecider = "escalation:timeout"
+
+// Policy configures native multi-party approval: who may approve which
+// actions, how many of them must agree, whom to chase when nobody does, and
+// who stands in for an absent approver. The zero Policy keeps the historical
+// single-approver behaviour.
+type Policy struct {
+	Groups      []Group      `json:"groups,omitempty"`
+	Rules       []Rule       `json:"rules,omitempty"`
+	Delegations []Delegation `json:"delegations,omitempty"`
+}
+
+// Group is a named set of approvers. An identity belongs to the group when it
+// is listed in Members or holds one of Roles (a serve RBAC role such as
+// "admin", or an OIDC group/role claim).
+type Group struct {
+	Name    string   `json:"name"`
+	Members []string `json:"members,omitempty"`
+	Roles   []string `json:"roles,omitempty"`
+}
+
+// Rule governs requests whose action matches Action. The first matching rule
+// applies; requests no rule matches keep single-approver behaviour.
+type Rule struct {
+	// Action is a path.Match glob over RequestParams.Action ("*" for all).
+	Action string `json:"action"`
+	// SLB limits the rule to requests that require the two-person rule.
+	SLB bool `json:"slb,omitempty"`
+	// Quorum lists the approvals needed, all of which must be met; one
+	// principal counts toward at most one requirement. Empty means one
+	// approval from anyone.
+	Quorum []Requirement `json:"quorum,omitempty"`
+	// Escalation notifies further groups while the request stays pending.
+	Escalation []EscalationStep `json:"escalation,omitempty"`
+	// Timeout, measured from the request's creation, resolves a request that
+	// is still pending with OnTimeout ("deny" or "approve").
+	Timeout   time.Duration `json:"timeout,omitempty"`
+	OnTimeout string        `json:"on_timeout,omitempty"`
+}
+
+// Requirement asks for Count approvals from members of Group.
+type Requirement struct {
+	Group string `json:"group"`
+	Count int    `json:"count"`
+}
+
+// EscalationStep notifies group Notify once the request has been pending for
+// After.
+type EscalationStep struct {
+	After  time.Duration `json:"after"`
+	Notify string        `json:"notify"`
+}
+
+// Delegation lets To vote on behalf of From until Until (zero: indefinitely).
+// A delegated vote counts with From's group memberships by name; roles are
+// only known for the identity presenting them.
+type Delegation struct {
+	From  string    `json:"from"`
+	To    string    `json:"to"`
+	Until time.Time `json:"until,omitempty"`
+}
+
+// Approver is the identity deciding a request.
+type Approver struct {
+	ID string
+	// Roles are the approver's RBAC roles and group claims, matched against
+	// Group.Roles.
+	Roles []string
+	// OnBehalfOf names an absent approver whose delegation the vote uses.
+	OnBehalfOf string
+}
+
+// VoteResult reports a recorded approve vote.
+type VoteResult struct {
+	Approval *state.Approval
+	Votes    []state.ApprovalVote
+	// Missing describes the quorum requirements still unmet ("1 more from
+	// sre"); it is empty once the request is approved.
+	Missing []string
+}
+
+// Validate checks that the policy's references resolve.
+func (p Policy) Validate() error {
+	groups := make(map[string]bool, len(p.Groups))
+	for i, g := range p.Groups {
+		if strings.TrimSpace(g.Name) == "" {
+			return fmt.Errorf("approvals.groups[%d]: name is required", i)
+		}
+		if groups[g.Name] {
+			return fmt.Errorf("approvals.groups[%d]: duplicate group %q", i, g.Name)
+		}
+		if len(g.Members) == 0 && len(g.Roles) == 0 {
+			return fmt.Errorf("approvals.groups[%d] (%s): needs members or roles", i, g.Name)
+		}
+		groups[g.Name] = true
+	}
+	for i, r := range p.Rules {
+		where := fmt.Sprintf("approvals.rules[%d]", i)
+		if r.Action == "" {
+			return fmt.Errorf("%s: action is required", where)
+		}
+		if _, err := path.Match(r.Action, ""); err != nil {
+			return fmt.Errorf("%s: invalid action pattern %q: %w", where, r.Action, err)
+		}
+		for _, q := range r.Quorum {
+			if !groups[q.Group] {
+				return fmt.Errorf("%s: quorum names unknown group %q", where, q.Group)
+			}
+			if q.Count < 1 {
+				return fmt.Errorf("%s: quorum count for %q must be at least 1", where, q.Group)
+			}
+		}
+		var prev time.Duration
+		for j, step := range r.Escalation {
+			if !groups[step.Notify] {
+				return fmt.Errorf("%s: escalation[%d] notifies unknown group %q", where, j, step.Notify)
+			}
+			if step.After < prev {
+				return fmt.Errorf("%s: escalation steps must be in increasing order of after", where)
+			}
+			prev = step.After
+		}
+		switch r.OnTimeout {
+		case "":
+		case TimeoutDeny, TimeoutApprove:
+			if r.Timeout <= 0 {
+				return fmt.Errorf("%s: on_timeout %q needs a positive timeout", where, r.OnTimeout)
+			}
+		default:
+			return fmt.Errorf("%s: on_timeout must be %q or %q, got %q", where, TimeoutDeny, TimeoutApprove, r.OnTimeout)
+		}
+	}
+	for i, d := range p.Delegations {
+		if d.From == "" || d.To == "" {
+			return fmt.Errorf("approvals.delegations[%d]: from and to are required", i)
+		}
+		if d.From == d.To {
+			return fmt.Errorf("approvals.delegations[%d]: %s cannot delegate to themselves", i, d.From)
+		}
+	}
+	return nil
+}
+
+// ruleFor returns the first rule governing a request, or nil.
+func (p Policy) ruleFor(action string, requiresSLB bool) *Rule {
+	for i := range p.Rules {
+		r := &p.Rules[i]
+		if r.SLB && !requiresSLB {
+			continue
+		}
+		if ok, _ := path.Match(r.Action, action); ok {
+			return r
+		}
+	}
+	return nil
+}
+
+func (p Policy) group(name string) *Group {
+	for i := range p.Groups {
+		if p.Groups[i].Name == name {
+			return &p.Groups[i]
+		}
+	}
+	return nil
+}
+
+// memberOf reports whether id, holding roles, belongs to group name.
+func (p Policy) memberOf(name, id string, roles []string) bool {
+	g := p.group(name)
+	if g == nil {
+		return false
+	}
+	if contains(g.Members, id) {
+		return true
+	}
+	for _, role := range roles {
+		if contains(g.Roles, role) {
+			return true
+		}
+	}
+	return false
+}
+
+// delegated reports whether to may act for from at now.
+func (p Policy) delegated(from, to string, now time.Time) bool {
+	for _, d := range p.Delegations {
+		if d.From == from && d.To == to && (d.Until.IsZero() || now.Before(d.Until)) {
+			return true
+		}
+	}
+	return false
+}
+
+// principal resolves the identity a decision by approver counts for under
+// rule r, and the quorum groups that identity is eligible for. A rule with no
+// quorum accepts anyone.
+func (p Policy) principal(r *Rule, approval *state.Approval, approver Approver, now time.Time) (string, []string, error) {
+	id, roles := approver.ID, approver.Roles
+	if approver.OnBehalfOf != "" {
+		if !p.delegated(approver.OnBehalfOf, approver.ID, now) {
+			return "", nil, fmt.Errorf("%w: %s holds no active delegation from %s", ErrNoDelegation, approver.ID, approver.OnBehalfOf)
+		}
+		id, roles = approver.OnBehalfOf, nil
+	}
+	if id == approval.RequestedBy || approver.ID == approval.RequestedBy {
+		return "", nil, ErrSLBSelfApproval
+	}
+	if len(r.Quorum) == 0 {
+		return id, nil, nil
+	}
+	var groups []string
+	for _, q := range r.Quorum {
+		if !contains(groups, q.Group) && p.memberOf(q.Group, id, roles) {
+			groups = append(groups, q.Group)
+		}
+	}
+	if len(groups) == 0 {
+		return "", nil, fmt.Errorf("%w: %s is not in any approver group for %s", ErrNotEligible, id, approval.Action)
+	}
+	return id, groups, nil
+}
+
+// missing lists the requirements of r that votes leave unmet. Each vote
+// fills at most one requirement slot; slots are assigned by augmenting
+// paths so a voter in several groups lands where they are needed.
+func (r *Rule) missing(votes []state.ApprovalVote) []string {
+	if len(r.Quorum) == 0 {
+		if len(votes) > 0 {
+			return nil
+		}
+		return []string{"1 more from any approver"}
+	}
+	var slots []string
+	for _, q := range r.Quorum {
+		for i := 0; i < q.Count; i++ {
+			slots = append(slots, q.Group)
+		}
+	}
+	holder := make([]int, len(slots)) // slot -> vote index, -1 when open
+	for i := range holder {
+		holder[i] = -1
+	}
+	var assign func(v int, seen []bool) bool
+	assign = func(v int, seen []bool) bool {
+		for s, group := range slots {
+			if seen[s] || !contains(votes[v].Groups, group) {
+				continue
+			}
+			seen[s] = true
+			if holder[s] < 0 || assign(holder[s], seen) {
+				holder[s] = v
+				return true
+			}
+		}
+		return false
+	}
+	for v := range votes {
+		assign(v, make([]bool, len(slots)))
+	}
+
+	open := make(map[string]int)
+	for s, group := range slots {
+		if holder[s] < 0 {
+			open[group]++
+		}
+	}
+	var out []string
+	for _, q := range r.Quorum {
+		if n := open[q.Group]; n > 0 {
+			out = append(out, fmt.Sprintf("%d more from %s", n, q.Group))
+			delete(open, q.Group)
+		}
+	}
+	return out
+}
+
+// describe lists a group's named members and roles for notifications.
+func (g *Group) describe() string {
+	parts := append([]string(nil), g.Members...)
+	for _, role := range g.Roles {
+		parts = append(parts, "role:"+role)
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
+
+// principals joins the identities behind votes, for ApprovedBy.
+func principals(votes []state.ApprovalVote) string {
+	ids := make([]string, 0, len(votes))
+	for _, v := range votes {
+		ids = append(ids, v.Principal)
+	}
+	return strings.Join(ids, ",")
+}
@@ -0,0 +1,277 @@
+package approval
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/audit"
+	"github.com/Dicklesworthstone/ntm/internal/state"
+)
+
+func quorumPolicy() Policy {
+	return Policy{
+		Groups: []Group{
+			{Name: "sre", Members: []string{"alice", "bob"}, Roles: []string{"admin"}},
+			{Name: "leads", Members: []string{"alice", "dana"}},
+		},
+		Rules: []Rule{{
+			Action: "force_*",
+			Quorum: []Requirement{{Group: "sre", Count: 1}, {Group: "leads", Count: 1}},
+		}},
+		Delegations: []Delegation{
+			{From: "dana", To: "carol", Until: time.Now().Add(time.Hour)},
+			{From: "bob", To: "carol", Until: time.Now().Add(-time.Hour)},
+		},
+	}
+}
+
+func requestForcePush(t *testing.T, engine *Engine) *state.Approval {
+	t.Helper()
+	appr, err := engine.Request(context.Background(), RequestParams{
+		Action:      "force_push",
+		Resource:    "main",
+		RequestedBy: "agent-1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return appr
+}
+
+func TestApproveAsQuorum(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Policy = quorumPolicy()
+	engine := New(setupTestStore(t), nil, nil, cfg)
+	ctx := context.Background()
+	appr := requestForcePush(t, engine)
+
+	for _, tc := range []struct {
+		approver Approver
+		want     error
+	}{
+		{Approver{ID: "agent-1"}, ErrSLBSelfApproval},
+		{Approver{ID: "mallory"}, ErrNotEligible},
+		{Approver{ID: "carol", OnBehalfOf: "bob"}, ErrNoDelegation}, // lapsed
+	} {
+		if _, err := engine.ApproveAs(ctx, appr.ID, tc.approver); !errors.Is(err, tc.want) {
+			t.Errorf("ApproveAs(%+v) = %v, want %v", tc.approver, err, tc.want)
+		}
+	}
+
+	// alice sits in both groups but counts once.
+	res, err := engine.ApproveAs(ctx, appr.ID, Approver{ID: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Approval.Status != state.ApprovalPending || len(res.Missing) != 1 {
+		t.Fatalf("after one vote: status %s, missing %v", res.Approval.Status, res.Missing)
+	}
+	if _, err := engine.ApproveAs(ctx, appr.ID, Approver{ID: "alice"}); !errors.Is(err, ErrDuplicateVote) {
+		t.Errorf("second vote by alice = %v, want ErrDuplicateVote", err)
+	}
+
+	// bob can only fill sre, so alice moves to leads and the quorum is met.
+	res, err = engine.ApproveAs(ctx, appr.ID, Approver{ID: "bob"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Approval.Status != state.ApprovalApproved || len(res.Missing) != 0 || res.Approval.ApprovedBy != "alice,bob" {
+		t.Fatalf("after quorum: %+v, missing %v", res.Approval, res.Missing)
+	}
+	if stored, _ := engine.Check(ctx, appr.ID); stored.Status != state.ApprovalApproved {
+		t.Errorf("stored status = %s, want approved", stored.Status)
+	}
+}
+
+func TestApproveAsRolesAndDelegation(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Policy = quorumPolicy()
+	engine := New(setupTestStore(t), nil, nil, cfg)
+	ctx := context.Background()
+	appr := requestForcePush(t, engine)
+
+	// eve is not listed but holds the admin role, which sre accepts.
+	if _, err := engine.ApproveAs(ctx, appr.ID, Approver{ID: "eve", Roles: []string{"admin"}}); err != nil {
+		t.Fatal(err)
+	}
+	// carol votes for dana while dana is away.
+	res, err := engine.ApproveAs(ctx, appr.ID, Approver{ID: "carol", OnBehalfOf: "dana"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Approval.Status != state.ApprovalApproved {
+		t.Fatalf("status = %s, missing %v", res.Approval.Status, res.Missing)
+	}
+	last := res.Votes[len(res.Votes)-1]
+	if last.Principal != "dana" || last.Voter != "carol" || !reflect.DeepEqual(last.Groups, []string{"leads"}) {
+		t.Errorf("delegated vote = %+v", last)
+	}
+
+	// Requests no rule matches keep single-approver behaviour.
+	other, err := engine.Request(ctx, RequestParams{Action: "kill_agent", Resource: "pane 2", RequestedBy: "agent-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.Approve(ctx, other.ID, "mallory"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestApproveAsOneVotePerVoter pins the two-person rule under delegation:
+// bob holds alice's delegation, but voting for himself and then for her
+// would let one person meet a 2-of-sre quorum alone.
+func TestApproveAsOneVotePerVoter(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Policy = Policy{
+		Groups: []Group{{Name: "sre", Members: []string{"alice", "bob", "eve"}}},
+		Rules:  []Rule{{Action: "force_*", Quorum: []Requirement{{Group: "sre", Count: 2}}}},
+		Delegations: []Delegation{
+			{From: "alice", To: "bob", Until: time.Now().Add(time.Hour)},
+		},
+	}
+	engine := New(setupTestStore(t), nil, nil, cfg)
+	ctx := context.Background()
+	appr := requestForcePush(t, engine)
+
+	if _, err := engine.ApproveAs(ctx, appr.ID, Approver{ID: "bob"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := engine.ApproveAs(ctx, appr.ID, Approver{ID: "bob", OnBehalfOf: "alice"}); !errors.Is(err, ErrDuplicateVote) {
+		t.Fatalf("second vote by bob for alice = %v, want ErrDuplicateVote", err)
+	}
+	res, err := engine.Tally(ctx, appr.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Approval.Status != state.ApprovalPending || len(res.Votes) != 1 {
+		t.Fatalf("status = %s, votes = %+v; want pending with one vote", res.Approval.Status, res.Votes)
+	}
+
+	res, err = engine.ApproveAs(ctx, appr.ID, Approver{ID: "eve"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Approval.Status != state.ApprovalApproved {
+		t.Fatalf("status after a second person = %s, missing %v", res.Approval.Status, res.Missing)
+	}
+}
+
+func TestDenyAsUnderRule(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Policy = quorumPolicy()
+	engine := New(setupTestStore(t), nil, nil, cfg)
+	ctx := context.Background()
+
+	appr := requestForcePush(t, engine)
+	if err := engine.DenyAs(ctx, appr.ID, Approver{ID: "mallory"}, "no"); !errors.Is(err, ErrNotEligible) {
+		t.Errorf("outsider deny = %v, want ErrNotEligible", err)
+	}
+	if err := engine.DenyAs(ctx, appr.ID, Approver{ID: "carol", OnBehalfOf: "dana"}, "not now"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := engine.Check(ctx, appr.ID); got.Status != state.ApprovalDenied || got.ApprovedBy != "carol for dana" {
+		t.Errorf("denied record = %+v", got)
+	}
+
+	// The requester may withdraw their own request.
+	appr = requestForcePush(t, engine)
+	if err := engine.Deny(ctx, appr.ID, "agent-1", "withdrawn"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEscalationAndTimeout(t *testing.T) {
+	var mu sync.Mutex
+	var trail []string
+	prev := logAudit
+	logAudit = func(_ audit.Actor, target string, _ map[string]interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		trail = append(trail, target)
+	}
+	t.Cleanup(func() { logAudit = prev })
+
+	for _, tc := range []struct {
+		onTimeout string
+		want      state.ApprovalStatus
+	}{
+		{TimeoutApprove, state.ApprovalApproved},
+		{TimeoutDeny, state.ApprovalDenied},
+	} {
+		trail = nil
+		cfg := DefaultConfig()
+		cfg.Policy = quorumPolicy()
+		cfg.Policy.Rules[0].Escalation = []EscalationStep{{Notify: "sre"}, {After: 30 * time.Millisecond, Notify: "leads"}}
+		cfg.Policy.Rules[0].Timeout = 80 * time.Millisecond
+		cfg.Policy.Rules[0].OnTimeout = tc.onTimeout
+		engine := New(setupTestStore(t), nil, nil, cfg)
+		ctx := context.Background()
+
+		appr := requestForcePush(t, engine)
+		steps, err := engine.Escalations(ctx, appr.ID)
+		if err != nil || len(steps) != 1 || steps[0].Notified != "sre" {
+			t.Fatalf("escalations at request time = %+v, %v", steps, err)
+		}
+
+		time.Sleep(50 * time.Millisecond)
+		if err := engine.Escalate(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if err := engine.Escalate(ctx); err != nil { // a second sweep notifies nobody again
+			t.Fatal(err)
+		}
+		if steps, _ := engine.Escalations(ctx, appr.ID); len(steps) != 2 || steps[1].Notified != "leads" {
+			t.Fatalf("escalations after 50ms = %+v", steps)
+		}
+
+		time.Sleep(50 * time.Millisecond)
+		got, err := engine.Check(ctx, appr.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Status != tc.want || got.ApprovedBy != TimeoutDecider {
+			t.Errorf("on_timeout=%s: record = %+v", tc.onTimeout, got)
+		}
+		if _, err := engine.ApproveAs(ctx, appr.ID, Approver{ID: "alice"}); !errors.Is(err, ErrNotPending) {
+			t.Errorf("vote after timeout = %v, want ErrNotPending", err)
+		}
+
+		mu.Lock()
+		want := []string{"approval.escalate", "approval.escalate", "approval.timeout"}
+		if !reflect.DeepEqual(trail, want) {
+			t.Errorf("audit trail = %v, want %v", trail, want)
+		}
+		mu.Unlock()
+	}
+}
+
+func TestPolicyValidate(t *testing.T) {
+	if err := quorumPolicy().Validate(); err != nil {
+		t.Fatalf("valid policy: %v", err)
+	}
+	for _, tc := range []struct {
+		mutate func(*Policy)
+		want   string
+	}{
+		{func(p *Policy) { p.Groups = append(p.Groups, Group{Name: "sre", Members: []string{"x"}}) }, "duplicate group"},
+		{func(p *Policy) { p.Rules[0].Quorum[0].Group = "ops" }, `unknown group "ops"`},
+		{func(p *Policy) { p.Rules[0].Quorum[0].Count = 0 }, "at least 1"},
+		{func(p *Policy) { p.Rules[0].OnTimeout = TimeoutDeny }, "positive timeout"},
+		{func(p *Policy) { p.Rules[0].OnTimeout = "maybe"; p.Rules[0].Timeout = time.Minute }, "on_timeout must be"},
+		{func(p *Policy) {
+			p.Rules[0].Escalation = []EscalationStep{{After: time.Minute, Notify: "sre"}, {Notify: "leads"}}
+		}, "increasing order"},
+		{func(p *Policy) { p.Delegations[0].To = "dana" }, "cannot delegate to themselves"},
+	} {
+		p := quorumPolicy()
+		tc.mutate(&p)
+		if err := p.Validate(); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("Validate() = %v, want %q", err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,402 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Checkpoint is a signed statement that an audit log's hash chain reached
+// Hash at SequenceNum. The chain alone only proves internal consistency:
+// anyone able to rewrite the file can recompute every checksum. Forging a
+// checkpoint needs the signing key, so checkpoints kept where the log writer
+// cannot rewrite them (a Mirror) pin the history that existed when they were
+// signed.
+type Checkpoint struct {
+	File        string    `json:"file"`
+	SessionID   string    `json:"session_id"`
+	SequenceNum uint64    `json:"sequence_num"`
+	Hash        string    `json:"hash"`
+	Timestamp   time.Time `json:"timestamp"`
+	PublicKey   string    `json:"public_key"`
+	Signature   string    `json:"signature"`
+}
+
+// checkpointDomain separates checkpoint signatures from anything else the
+// same key might sign.
+const checkpointDomain = "ntm-audit-checkpoint/v1"
+
+// signedData is the byte string a checkpoint signature covers.
+func (c Checkpoint) signedData() []byte {
+	return []byte(fmt.Sprintf("%s\n%s\n%s\n%d\n%s\n%s",
+		checkpointDomain, c.File, c.SessionID, c.SequenceNum, c.Hash,
+		c.Timestamp.UTC().Format(time.RFC3339Nano)))
+}
+
+// verifySignature checks c's signature under its embedded key and, when
+// trusted is non-empty, that the key is one of trusted.
+func (c Checkpoint) verifySignature(trusted []ed25519.PublicKey) error {
+	raw, err := base64.StdEncoding.DecodeString(c.PublicKey)
+	if err != nil || len(raw) != ed25519.PublicKeySize {
+		return fmt.Errorf("checkpoint at sequence %d: invalid public key", c.SequenceNum)
+	}
+	pub := ed25519.PublicKey(raw)
+	sig, err := base64.StdEncoding.DecodeString(c.Signature)
+	if err != nil || !ed25519.Verify(pub, c.signedData(), sig) {
+		return fmt.Errorf("checkpoint at sequence %d: invalid signature", c.SequenceNum)
+	}
+	if len(trusted) == 0 {
+		return nil
+	}
+	for _, key := range trusted {
+		if key.Equal(pub) {
+			return nil
+		}
+	}
+	return fmt.Errorf("checkpoint at sequence %d: signed by untrusted key %s", c.SequenceNum, KeyFingerprint(pub))
+}
+
+// KeyFingerprint returns a short, stable identifier for an ed25519 public
+// key, for comparing keys out of band.
+func KeyFingerprint(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:8])
+}
+
+// Mirror is a second, append-only home for checkpoints, out of reach of
+// whoever can rewrite the audit directory.
+type Mirror interface {
+	// Append records c under c.File.
+	Append(c Checkpoint) error
+	// Checkpoints returns the checkpoints recorded for a log file.
+	Checkpoints(file string) ([]Checkpoint, error)
+	String() string
+}
+
+// DirMirror appends checkpoints to per-log files in Dir, typically on another
+// filesystem or an append-only (chattr +a) directory.
+type DirMirror struct {
+	Dir string
+}
+
+// Append implements Mirror.
+func (m DirMirror) Append(c Checkpoint) error {
+	return appendCheckpoint(filepath.Join(m.Dir, c.File), c)
+}
+
+// Checkpoints implements Mirror.
+func (m DirMirror) Checkpoints(file string) ([]Checkpoint, error) {
+	return ReadCheckpoints(filepath.Join(m.Dir, file))
+}
+
+func (m DirMirror) String() string { return "dir:" + m.Dir }
+
+// GitNotesMirror appends checkpoints as git notes under Ref in Repo. Notes
+// attach to a blob naming the log file, so each log keeps one growing note;
+// pushing the notes ref elsewhere puts the checkpoints beyond local reach.
+type GitNotesMirror struct {
+	Repo string
+	Ref  string
+}
+
+// Append implements Mirror.
+func (m GitNotesMirror) Append(c Checkpoint) error {
+	line, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("marshal checkpoint: %w", err)
+	}
+	object, err := m.object(c.File, true)
+	if err != nil {
+		return err
+	}
+	if _, err := m.git(nil, "notes", "--ref", m.Ref, "append", "-m", string(line), object); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Checkpoints implements Mirror.
+func (m GitNotesMirror) Checkpoints(file string) ([]Checkpoint, error) {
+	object, err := m.object(file, false)
+	if err != nil {
+		return nil, err
+	}
+	out, err := m.git(nil, "notes", "--ref", m.Ref, "show", object)
+	if err != nil {
+		if strings.Contains(err.Error(), "no note found") {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return parseCheckpoints(bytes.NewReader(out), "git notes "+m.Ref)
+}
+
+func (m GitNotesMirror) String() string { return "git-notes:" + m.Repo + "@" + m.Ref }
+
+// object returns the blob the notes for file attach to, writing it when
+// write is set.
+func (m GitNotesMirror) object(file string, write bool) (string, error) {
+	args := []string{"hash-object", "--stdin"}
+	if write {
+		args = append(args, "-w")
+	}
+	out, err := m.git(strings.NewReader("ntm audit log "+file+"\n"), args...)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func (m GitNotesMirror) git(stdin io.Reader, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", m.Repo}, args...)...)
+	cmd.Stdin = stdin
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// Anchorer signs checkpoints of audit log heads and stores them next to the
+// logs and in every mirror.
+type Anchorer struct {
+	key      ed25519.PrivateKey
+	interval time.Duration
+	mirrors  []Mirror
+}
+
+// NewAnchorer returns an Anchorer signing with the ed25519 key derived from
+// seed (32 bytes, as resolved by the encryption key resolvers). Loggers sign
+// their head at most once per interval, and always when they close.
+func NewAnchorer(seed []byte, interval time.Duration, mirrors ...Mirror) (*Anchorer, error) {
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("audit signing key is %d bytes, expected a %d-byte ed25519 seed", len(seed), ed25519.SeedSize)
+	}
+	return &Anchorer{
+		key:      ed25519.NewKeyFromSeed(seed),
+		interval: interval,
+		mirrors:  mirrors,
+	}, nil
+}
+
+// PublicKey returns the key that verifies this Anchorer's checkpoints.
+func (a *Anchorer) PublicKey() ed25519.PublicKey {
+	return a.key.Public().(ed25519.PublicKey)
+}
+
+// Anchor signs the head of the log at logPath and appends the checkpoint
+// locally and to every mirror. A mirror failure is reported but does not
+// undo the local checkpoint.
+func (a *Anchorer) Anchor(logPath, sessionID string, seq uint64, hash string) (*Checkpoint, error) {
+	c := Checkpoint{
+		File:        filepath.Base(logPath),
+		SessionID:   sessionID,
+		SequenceNum: seq,
+		Hash:        hash,
+		Timestamp:   time.Now().UTC(),
+		PublicKey:   base64.StdEncoding.EncodeToString(a.PublicKey()),
+	}
+	c.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(a.key, c.signedData()))
+
+	if err := appendCheckpoint(AnchorPath(logPath), c); err != nil {
+		return nil, err
+	}
+	var errs []error
+	for _, m := range a.mirrors {
+		if err := m.Append(c); err != nil {
+			errs = append(errs, fmt.Errorf("mirror %s: %w", m, err))
+		}
+	}
+	return &c, errors.Join(errs...)
+}
+
+var (
+	anchorMu      sync.RWMutex
+	defaultAnchor *Anchorer
+)
+
+// SetAnchorer installs the Anchorer used by audit loggers opened afterwards;
+// nil disables checkpoint signing.
+func SetAnchorer(a *Anchorer) {
+	anchorMu.Lock()
+	defer anchorMu.Unlock()
+	defaultAnchor = a
+}
+
+// CurrentAnchorer returns the installed Anchorer, or nil.
+func CurrentAnchorer() *Anchorer {
+	anchorMu.RLock()
+	defer anchorMu.RUnlock()
+	return defaultAnchor
+}
+
+// AnchorPath returns where the local checkpoints of the log at logPath live:
+// an anchors/ directory beside the logs, so log globs never pick them up.
+func AnchorPath(logPath string) string {
+	return filepath.Join(filepath.Dir(logPath), "anchors", filepath.Base(logPath))
+}
+
+func appendCheckpoint(path string, c Checkpoint) error {
+	line, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("marshal checkpoint: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create checkpoint directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("open checkpoint file: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("sync checkpoint file: %w", err)
+	}
+	return f.Close()
+}
+
+// ReadCheckpoints reads a checkpoint file; a missing file has none.
+func ReadCheckpoints(path string) ([]Checkpoint, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("open checkpoint file: %w", err)
+	}
+	defer f.Close()
+	return parseCheckpoints(f, path)
+}
+
+func parseCheckpoints(r io.Reader, source string) ([]Checkpoint, error) {
+	var out []Checkpoint
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var c Checkpoint
+		if err := json.Unmarshal([]byte(text), &c); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid checkpoint: %w", source, line, err)
+		}
+		out = append(out, c)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read %s: %w", source, err)
+	}
+	return out, nil
+}
+
+// LoadCheckpoints gathers the checkpoints of the log at logPath from the
+// local anchors directory and every mirror, dropping duplicates. A mirror
+// keeps its checkpoints even if the local copy is deleted or rewritten.
+func LoadCheckpoints(logPath string, mirrors []Mirror) ([]Checkpoint, error) {
+	all, err := ReadCheckpoints(AnchorPath(logPath))
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range mirrors {
+		more, err := m.Checkpoints(filepath.Base(logPath))
+		if err != nil {
+			return nil, fmt.Errorf("mirror %s: %w", m, err)
+		}
+		all = append(all, more...)
+	}
+	seen := make(map[string]bool, len(all))
+	out := all[:0]
+	for _, c := range all {
+		if seen[c.Signature] {
+			continue
+		}
+		seen[c.Signature] = true
+		out = append(out, c)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].SequenceNum < out[j].SequenceNum })
+	return out, nil
+}
+
+// AnchorReport summarises a log checked against its checkpoints.
+type AnchorReport struct {
+	Entries         uint64 `json:"entries"`
+	Checkpoints     int    `json:"checkpoints"`
+	AnchoredThrough uint64 `json:"anchored_through"`
+	// Keys are the fingerprints of the keys that signed the checkpoints.
+	Keys []string `json:"keys,omitempty"`
+}
+
+// VerifyAnchored checks the hash chain of log file (read from r) and that
+// every checkpoint is validly signed, by a trusted key when trusted is
+// non-empty, and matches the entry it names. A rewritten chain fails the
+// hash comparison and a truncated one loses the entries checkpoints name.
+// Entries after the last checkpoint are reported, not rejected: they were
+// appended since.
+func VerifyAnchored(r io.Reader, file string, checkpoints []Checkpoint, trusted []ed25519.PublicKey) (*AnchorReport, error) {
+	report := &AnchorReport{Checkpoints: len(checkpoints)}
+	want := make(map[uint64]bool, len(checkpoints))
+	keys := make(map[string]bool)
+	for _, c := range checkpoints {
+		if c.File != file {
+			return report, fmt.Errorf("checkpoint at sequence %d names log %q, not %q", c.SequenceNum, c.File, file)
+		}
+		if err := c.verifySignature(trusted); err != nil {
+			return report, err
+		}
+		raw, _ := base64.StdEncoding.DecodeString(c.PublicKey)
+		keys[KeyFingerprint(raw)] = true
+		want[c.SequenceNum] = true
+	}
+	for k := range keys {
+		report.Keys = append(report.Keys, k)
+	}
+	sort.Strings(report.Keys)
+
+	hashes := make(map[uint64]string, len(want))
+	err := verifyChain(r, func(e AuditEntry) {
+		report.Entries = e.SequenceNum
+		if want[e.SequenceNum] {
+			hashes[e.SequenceNum] = e.Checksum
+		}
+	})
+	if err != nil {
+		return report, err
+	}
+	for _, c := range checkpoints {
+		got, ok := hashes[c.SequenceNum]
+		if !ok {
+			return report, fmt.Errorf("log truncated: checkpoint at sequence %d, log ends at %d", c.SequenceNum, report.Entries)
+		}
+		if got != c.Hash {
+			return report, fmt.Errorf("entry %d does not match its signed checkpoint (log rewritten)", c.SequenceNum)
+		}
+		if c.SequenceNum > report.AnchoredThrough {
+			report.AnchoredThrough = c.SequenceNum
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,245 @@
+package audit
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testSeed(b byte) []byte {
+	return bytes.Repeat([]byte{b}, ed25519.SeedSize)
+}
+
+// writeAnchoredLog logs n entries through a logger that signs every flush and
+// returns the log path.
+func writeAnchoredLog(t *testing.T, anchorer *Anchorer, n int) string {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	SetAnchorer(anchorer)
+	t.Cleanup(func() { SetAnchorer(nil) })
+
+	logger, err := NewAuditLogger(&LoggerConfig{SessionID: "anchored", BufferSize: 1, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		if err := logger.Log(AuditEntry{EventType: EventTypeCommand, Actor: ActorUser, Target: "t", Payload: map[string]interface{}{"i": i}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := logger.file.Name()
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// rechain rewrites the log at path through edit and recomputes every hash,
+// as someone with write access to the file could.
+func rechain(t *testing.T, path string, edit func([]AuditEntry) []AuditEntry) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries []AuditEntry
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var e AuditEntry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, e)
+	}
+	entries = edit(entries)
+	var out bytes.Buffer
+	prev := ""
+	for i, e := range entries {
+		e.SequenceNum = uint64(i + 1)
+		e.PrevHash = prev
+		e.Checksum = ""
+		raw, _ := json.Marshal(e)
+		sum := sha256.Sum256(raw)
+		e.Checksum = hex.EncodeToString(sum[:])
+		prev = e.Checksum
+		line, _ := json.Marshal(e)
+		out.Write(append(line, '\n'))
+	}
+	if err := os.WriteFile(path, out.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func verifyFile(t *testing.T, path string, mirrors []Mirror, trusted []ed25519.PublicKey) (*AnchorReport, error) {
+	t.Helper()
+	checkpoints, err := LoadCheckpoints(path, mirrors)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	return VerifyAnchored(f, filepath.Base(path), checkpoints, trusted)
+}
+
+func TestAnchorDetectsRewriteAndTruncation(t *testing.T) {
+	mirror := DirMirror{Dir: t.TempDir()}
+	anchorer, err := NewAnchorer(testSeed(1), 0, mirror)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := writeAnchoredLog(t, anchorer, 3)
+	trusted := []ed25519.PublicKey{anchorer.PublicKey()}
+
+	report, err := verifyFile(t, path, []Mirror{mirror}, trusted)
+	if err != nil {
+		t.Fatalf("intact log: %v", err)
+	}
+	if report.Entries != 3 || report.Checkpoints != 3 || report.AnchoredThrough != 3 {
+		t.Fatalf("report = %+v", report)
+	}
+
+	// Deleting the local checkpoints leaves the mirror's.
+	if err := os.RemoveAll(filepath.Dir(AnchorPath(path))); err != nil {
+		t.Fatal(err)
+	}
+	rechain(t, path, func(e []AuditEntry) []AuditEntry {
+		e[1].Target = "forged"
+		return e
+	})
+	if err := VerifyIntegrity(path); err != nil {
+		t.Fatalf("rechained log should pass the bare chain check: %v", err)
+	}
+	if _, err := verifyFile(t, path, []Mirror{mirror}, trusted); err == nil || !strings.Contains(err.Error(), "log rewritten") {
+		t.Fatalf("rewritten log = %v, want log rewritten", err)
+	}
+
+	rechain(t, path, func(e []AuditEntry) []AuditEntry { return e[:1] })
+	if _, err := verifyFile(t, path, []Mirror{mirror}, trusted); err == nil || !strings.Contains(err.Error(), "log truncated") {
+		t.Fatalf("truncated log = %v, want log truncated", err)
+	}
+}
+
+func TestBundleRoundTrip(t *testing.T) {
+	anchorer, err := NewAnchorer(testSeed(2), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := writeAnchoredLog(t, anchorer, 4)
+
+	bundle, err := NewBundle("anchored", []string{path}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The first flush signs at once; the rest wait for the interval, and
+	// closing signs the final head.
+	if got := bundle.Logs[0].Checkpoints; len(got) != 2 || got[1].SequenceNum != 4 {
+		t.Fatalf("checkpoints = %+v", got)
+	}
+
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Bundle
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	results, err := decoded.Verify(nil)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if len(results) != 1 || !results[0].Verified || results[0].Keys[0] != KeyFingerprint(anchorer.PublicKey()) {
+		t.Fatalf("results = %+v", results)
+	}
+
+	other, _ := NewAnchorer(testSeed(3), 0)
+	if _, err := decoded.Verify([]ed25519.PublicKey{other.PublicKey()}); err == nil || !strings.Contains(err.Error(), "untrusted key") {
+		t.Fatalf("Verify with another key = %v, want untrusted", err)
+	}
+
+	decoded.Logs[0].Entries = decoded.Logs[0].Entries[:3]
+	if _, err := decoded.Verify(nil); err == nil {
+		t.Fatal("Verify accepted a bundle missing its last entry")
+	}
+}
+
+func TestGitNotesMirror(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	repo := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"config", "user.name", "ntm"},
+		{"config", "user.email", "ntm@example.com"},
+	} {
+		if out, err := exec.Command("git", append([]string{"-C", repo}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	mirror := GitNotesMirror{Repo: repo, Ref: "ntm-audit"}
+
+	if got, err := mirror.Checkpoints("a.jsonl"); err != nil || got != nil {
+		t.Fatalf("empty mirror = %+v, %v", got, err)
+	}
+	for seq := uint64(1); seq <= 2; seq++ {
+		if err := mirror.Append(Checkpoint{File: "a.jsonl", SequenceNum: seq, Signature: "s"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := mirror.Checkpoints("a.jsonl")
+	if err != nil || len(got) != 2 || got[1].SequenceNum != 2 {
+		t.Fatalf("Checkpoints = %+v, %v", got, err)
+	}
+	if got, err := mirror.Checkpoints("b.jsonl"); err != nil || got != nil {
+		t.Fatalf("other log = %+v, %v", got, err)
+	}
+}
+
+func TestBundleUnanchoredLogs(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	logger, err := NewAuditLogger(&LoggerConfig{SessionID: "plain", BufferSize: 1, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := logger.Log(AuditEntry{EventType: EventTypeCommand, Actor: ActorUser, Target: "version"}); err != nil {
+		t.Fatal(err)
+	}
+	plain := logger.file.Name()
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	bundle, err := NewBundle("plain", []string{plain}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bundle.Verify(nil); err == nil || !strings.Contains(err.Error(), "no log in the bundle") {
+		t.Fatalf("Verify of an unanchored bundle = %v", err)
+	}
+
+	// Next to an anchored log, an unanchored one is reported, not rejected.
+	anchorer, _ := NewAnchorer(testSeed(4), 0)
+	anchoredPath := writeAnchoredLog(t, anchorer, 2)
+	bundle, err = NewBundle("mixed", []string{anchoredPath, plain}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := bundle.Verify(nil)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if !results[0].Anchored || results[1].Anchored || !results[1].Verified {
+		t.Fatalf("results = %+v", results)
+	}
+}
@@ -0,0 +1,167 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// BundleVersion is the verification bundle format version.
+const BundleVersion = 1
+
+// Bundle is a self-contained copy of a session's audit logs with their
+// signed checkpoints and the public keys that signed them, for verification
+// by a third party without access to the host.
+type Bundle struct {
+	Version    int         `json:"version"`
+	Session    string      `json:"session"`
+	CreatedAt  time.Time   `json:"created_at"`
+	PublicKeys []string    `json:"public_keys"`
+	Logs       []BundleLog `json:"logs"`
+}
+
+// BundleLog is one audit log file in a Bundle.
+type BundleLog struct {
+	File        string            `json:"file"`
+	Entries     []json.RawMessage `json:"entries"`
+	Checkpoints []Checkpoint      `json:"checkpoints"`
+}
+
+// BundleLogResult is the verification outcome for one BundleLog.
+type BundleLogResult struct {
+	File string `json:"file"`
+	AnchorReport
+	Verified bool `json:"verified"`
+	// Anchored is false for a log with an intact chain but no checkpoint,
+	// such as one written by a command that exits before anchoring is set up.
+	Anchored bool   `json:"anchored"`
+	Error    string `json:"error,omitempty"`
+}
+
+// NewBundle packages the audit logs at logPaths with their checkpoints from
+// the local anchors directory and mirrors.
+func NewBundle(session string, logPaths []string, mirrors []Mirror) (*Bundle, error) {
+	b := &Bundle{
+		Version:    BundleVersion,
+		Session:    session,
+		CreatedAt:  time.Now().UTC(),
+		PublicKeys: []string{},
+	}
+	keys := make(map[string]bool)
+	for _, logPath := range logPaths {
+		entries, err := readRawEntries(logPath)
+		if err != nil {
+			return nil, err
+		}
+		checkpoints, err := LoadCheckpoints(logPath, mirrors)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(logPath), err)
+		}
+		if checkpoints == nil {
+			checkpoints = []Checkpoint{}
+		}
+		for _, c := range checkpoints {
+			if !keys[c.PublicKey] {
+				keys[c.PublicKey] = true
+				b.PublicKeys = append(b.PublicKeys, c.PublicKey)
+			}
+		}
+		b.Logs = append(b.Logs, BundleLog{
+			File:        filepath.Base(logPath),
+			Entries:     entries,
+			Checkpoints: checkpoints,
+		})
+	}
+	sort.Strings(b.PublicKeys)
+	return b, nil
+}
+
+// readRawEntries returns the non-empty lines of an audit log unchanged.
+func readRawEntries(logPath string) ([]json.RawMessage, error) {
+	f, err := os.Open(logPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	entries := []json.RawMessage{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if !json.Valid(line) {
+			return nil, fmt.Errorf("%s: invalid JSON in audit log", filepath.Base(logPath))
+		}
+		entries = append(entries, json.RawMessage(append([]byte(nil), line...)))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return entries, nil
+}
+
+// Verify checks every log in the bundle against its checkpoints. Checkpoints
+// must be signed by one of trusted or, when trusted is empty, by one of the
+// bundle's own PublicKeys, whose fingerprints the verifier should then
+// compare with the operator's out of band. Logs without checkpoints only get
+// their hash chain checked and are reported unanchored; a bundle with no
+// checkpoints at all fails, since nothing outside it vouches for it.
+func (b *Bundle) Verify(trusted []ed25519.PublicKey) ([]BundleLogResult, error) {
+	if b.Version != BundleVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d", b.Version)
+	}
+	if len(trusted) == 0 {
+		for _, encoded := range b.PublicKeys {
+			raw, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil || len(raw) != ed25519.PublicKeySize {
+				return nil, fmt.Errorf("bundle public key %q is not a base64 ed25519 key", encoded)
+			}
+			trusted = append(trusted, ed25519.PublicKey(raw))
+		}
+	}
+
+	results := make([]BundleLogResult, 0, len(b.Logs))
+	var errs []error
+	anchored := false
+	for _, l := range b.Logs {
+		anchored = anchored || len(l.Checkpoints) > 0
+		result := BundleLogResult{File: l.File, Anchored: len(l.Checkpoints) > 0}
+		report, err := verifyBundleLog(l, trusted)
+		if report != nil {
+			result.AnchorReport = *report
+		}
+		if err != nil {
+			result.Error = err.Error()
+			errs = append(errs, fmt.Errorf("%s: %w", l.File, err))
+		} else {
+			result.Verified = true
+		}
+		results = append(results, result)
+	}
+	if !anchored {
+		errs = append(errs, errors.New("no log in the bundle has a signed checkpoint"))
+	}
+	return results, errors.Join(errs...)
+}
+
+func verifyBundleLog(l BundleLog, trusted []ed25519.PublicKey) (*AnchorReport, error) {
+	var buf bytes.Buffer
+	for _, entry := range l.Entries {
+		if err := json.Compact(&buf, entry); err != nil {
+			return nil, fmt.Errorf("invalid JSON in audit log: %w", err)
+		}
+		buf.WriteByte('\n')
+	}
+	return VerifyAnchored(&buf, l.File, l.Checkpoints, trusted)
+}
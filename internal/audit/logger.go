@@ -72,6 +72,11 @@ type AuditLogger struct {
 	// Buffering settings
 	entriesWritten int
 	lastFlush      time.Time
+
+	// Checkpoint signing (see anchor.go)
+	anchorer    *Anchorer
+	anchoredSeq uint64
+	lastAnchor  time.Time
 }
 
 // LoggerConfig holds configuration for the audit logger
@@ -356,6 +361,7 @@ func NewAuditLogger(config *LoggerConfig) (*AuditLogger, error) {
 		bufferSize:    config.BufferSize,
 		flushInterval: config.FlushInterval,
 		lastFlush:     time.Now(),
+		anchorer:      CurrentAnchorer(),
 	}
 
 	// Load the last hash from the file if it exists
@@ -363,6 +369,9 @@ func NewAuditLogger(config *LoggerConfig) (*AuditLogger, error) {
 		file.Close()
 		return nil, fmt.Errorf("failed to load last hash: %w", err)
 	}
+	// Only heads this logger writes get signed; the next one covers any
+	// unsigned tail left by a previous writer.
+	logger.anchoredSeq = logger.sequenceNum
 
 	// Start flush timer
 	logger.startFlushTimer()
@@ -561,9 +570,28 @@ func (al *AuditLogger) flushUnlocked() error {
 	}
 	al.entriesWritten = 0
 	al.lastFlush = time.Now()
+	al.anchorUnlocked(false)
 	return nil
 }
 
+// anchorUnlocked signs a checkpoint of the flushed chain head when entries
+// were written since the last one and the anchor interval has passed, or
+// unconditionally when force is set (caller must hold mutex). Failures are
+// logged: anchoring must never block audit writes.
+func (al *AuditLogger) anchorUnlocked(force bool) {
+	if al.anchorer == nil || al.sequenceNum == al.anchoredSeq {
+		return
+	}
+	if !force && time.Since(al.lastAnchor) < al.anchorer.interval {
+		return
+	}
+	if _, err := al.anchorer.Anchor(al.file.Name(), al.sessionID, al.sequenceNum, al.lastHash); err != nil {
+		log.Printf("audit: checkpoint failed: %v", err)
+	}
+	al.anchoredSeq = al.sequenceNum
+	al.lastAnchor = time.Now()
+}
+
 // Close flushes any remaining entries and closes the audit log
 func (al *AuditLogger) Close() error {
 	al.mutex.Lock()
@@ -586,6 +614,7 @@ func (al *AuditLogger) Close() error {
 		al.file.Close()
 		return fmt.Errorf("failed to flush before close: %w", err)
 	}
+	al.anchorUnlocked(true)
 
 	// Close file
 	if err := al.file.Close(); err != nil {
@@ -603,7 +632,13 @@ func VerifyIntegrity(logPath string) error {
 	}
 	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
+	return verifyChain(file, nil)
+}
+
+// verifyChain checks the hash chain read from r, calling visit (if non-nil)
+// with every entry that verifies.
+func verifyChain(r io.Reader, visit func(AuditEntry)) error {
+	scanner := bufio.NewScanner(r)
 	// Set max line size for large audit payloads (10MB), start with 64KB
 	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
 
@@ -656,6 +691,9 @@ func VerifyIntegrity(logPath string) error {
 		}
 
 		prevHash = entry.Checksum
+		if visit != nil {
+			visit(entry)
+		}
 	}
 
 	return scanner.Err()
@@ -0,0 +1,380 @@
+// Package autorespond answers the blocking prompts agents raise — tool
+// permission prompts, trust dialogs — by policy, so an unattended swarm does
+// not sit blocked overnight on a routine yes/no.
+//
+// Each scan classifies every pane with agent.ClassifyGate and asks the
+// policy's auto_respond rules (policy.DecideGate) what to do. Approvals and
+// denials send the prompt's keys through tmux; anything the rules do not
+// cover, or that the command policy requires approval for, is escalated as
+// an approval.Engine request and answered once a human decides it. Every
+// decision is written to the session's audit log.
+package autorespond
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/agent"
+	"github.com/Dicklesworthstone/ntm/internal/approval"
+	"github.com/Dicklesworthstone/ntm/internal/audit"
+	"github.com/Dicklesworthstone/ntm/internal/policy"
+	"github.com/Dicklesworthstone/ntm/internal/state"
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+)
+
+// approvalAction is the action recorded on escalated prompt requests.
+const approvalAction = "gate_response"
+
+// captureLines is how much scrollback each scan captures per pane.
+const captureLines = 80
+
+// answerCooldown keeps a scan from answering the same prompt twice while
+// the agent redraws after the first answer.
+const answerCooldown = 10 * time.Second
+
+// Panes is the tmux surface the responder drives. *tmux.Client satisfies it.
+type Panes interface {
+	GetPanes(session string) ([]tmux.Pane, error)
+	CapturePaneOutput(target string, lines int) (string, error)
+	SendKeyName(target, keyName string) error
+}
+
+// AuditFunc records one audit event; audit.LogEvent by default.
+type AuditFunc func(session string, eventType audit.EventType, actor audit.Actor, target string, payload, metadata map[string]interface{}) error
+
+// Config configures a Responder.
+type Config struct {
+	Session string
+	Policy  *policy.Policy
+	// Approvals receives escalations. Nil leaves escalated prompts
+	// unanswered (they are still audited).
+	Approvals *approval.Engine
+	// Panes defaults to tmux.DefaultClient.
+	Panes Panes
+	// DryRun decides and audits without sending keys or filing requests.
+	DryRun bool
+	// KeyDelay spaces multi-key answers; defaults to 150ms.
+	KeyDelay time.Duration
+	Audit    AuditFunc
+}
+
+// Outcome is what a scan did with one prompt.
+type Outcome string
+
+const (
+	OutcomeApproved  Outcome = "approved"  // accept keys sent by rule
+	OutcomeDenied    Outcome = "denied"    // decline keys sent by rule or policy
+	OutcomeEscalated Outcome = "escalated" // approval request filed
+	OutcomePending   Outcome = "pending"   // waiting on an open approval request
+	OutcomeGranted   Outcome = "granted"   // accept keys sent after a human approved
+	OutcomeRefused   Outcome = "refused"   // decline keys sent after a human denied
+	OutcomeSkipped   Outcome = "skipped"   // decided, but nothing could be sent
+)
+
+// Decision is one prompt the responder acted on.
+type Decision struct {
+	Time       time.Time           `json:"time"`
+	Pane       string              `json:"pane"`
+	PaneID     string              `json:"pane_id"`
+	AgentType  string              `json:"agent_type"`
+	Prompt     agent.GatePrompt    `json:"prompt"`
+	Verdict    policy.GateDecision `json:"verdict"`
+	Outcome    Outcome             `json:"outcome"`
+	ApprovalID string              `json:"approval_id,omitempty"`
+	KeysSent   []string            `json:"keys_sent,omitempty"`
+	Error      string              `json:"error,omitempty"`
+}
+
+// escalation tracks an open approval request for one pane's prompt.
+type escalation struct {
+	fingerprint string
+	approvalID  string
+}
+
+// Responder answers prompts in one session.
+type Responder struct {
+	cfg Config
+
+	mu       sync.Mutex
+	pending  map[string]escalation // pane ID -> open request
+	answered map[string]time.Time  // fingerprint -> when keys were sent
+}
+
+// New creates a Responder.
+func New(cfg Config) *Responder {
+	if cfg.Panes == nil {
+		cfg.Panes = tmux.DefaultClient
+	}
+	if cfg.KeyDelay <= 0 {
+		cfg.KeyDelay = 150 * time.Millisecond
+	}
+	if cfg.Audit == nil {
+		cfg.Audit = audit.LogEvent
+	}
+	if cfg.Policy == nil {
+		cfg.Policy = &policy.Policy{}
+	}
+	return &Responder{
+		cfg:      cfg,
+		pending:  make(map[string]escalation),
+		answered: make(map[string]time.Time),
+	}
+}
+
+// Run scans every interval until ctx is done.
+func (r *Responder) Run(ctx context.Context, interval time.Duration, onDecision func(Decision)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		decisions, err := r.Scan(ctx)
+		if err != nil {
+			slog.Warn("gate auto-responder scan failed", "session", r.cfg.Session, "error", err)
+		}
+		if onDecision != nil {
+			for _, d := range decisions {
+				onDecision(d)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Scan checks every pane once and acts on the prompts it finds.
+func (r *Responder) Scan(ctx context.Context) ([]Decision, error) {
+	panes, err := r.cfg.Panes.GetPanes(r.cfg.Session)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for fp, at := range r.answered {
+		if now.Sub(at) > answerCooldown {
+			delete(r.answered, fp)
+		}
+	}
+
+	var decisions []Decision
+	var errs error
+	for _, pane := range panes {
+		agentType := agent.AgentType(pane.Type).Canonical()
+		if agentType == "" || agentType == agent.AgentTypeUser || agentType == agent.AgentTypeUnknown {
+			continue
+		}
+		content, err := r.cfg.Panes.CapturePaneOutput(pane.ID, captureLines)
+		if err != nil {
+			errs = errors.Join(errs, fmt.Errorf("capture %s: %w", pane.ID, err))
+			continue
+		}
+		prompt, ok := agent.ClassifyGate(content, agentType, pane.Width)
+		if !ok {
+			// The prompt is gone (answered by hand, or it timed out); an
+			// open request for it no longer applies.
+			delete(r.pending, pane.ID)
+			continue
+		}
+		fp := fingerprint(pane.ID, prompt)
+		if _, recent := r.answered[fp]; recent {
+			continue
+		}
+		if d, ok := r.handle(ctx, pane, agentType, prompt, fp); ok {
+			decisions = append(decisions, d)
+		}
+	}
+	return decisions, errs
+}
+
+func (r *Responder) handle(ctx context.Context, pane tmux.Pane, agentType agent.AgentType, prompt agent.GatePrompt, fp string) (Decision, bool) {
+	d := Decision{
+		Time:      time.Now().UTC(),
+		Pane:      paneLabel(pane),
+		PaneID:    pane.ID,
+		AgentType: string(agentType),
+		Prompt:    prompt,
+	}
+
+	if esc, ok := r.pending[pane.ID]; ok && esc.fingerprint == fp {
+		return r.followUp(ctx, d, esc)
+	}
+	delete(r.pending, pane.ID)
+
+	d.Verdict = r.cfg.Policy.DecideGate(policy.GateRequest{
+		AgentType: string(agentType),
+		Gate:      string(prompt.Kind),
+		Tool:      string(prompt.Category),
+		Command:   prompt.Command,
+		Context:   policy.Context{Session: r.cfg.Session},
+	})
+	switch d.Verdict.Action {
+	case policy.RespondApprove:
+		r.answer(d.Pane, &d, prompt.ApproveKeys, OutcomeApproved, fp)
+	case policy.RespondDeny:
+		r.answer(d.Pane, &d, prompt.DenyKeys, OutcomeDenied, fp)
+	default:
+		r.escalate(ctx, &d, fp)
+	}
+	r.record(d)
+	return d, true
+}
+
+// followUp re-checks the open request for a prompt escalated by an earlier
+// scan. Pending requests are reported without a new audit entry.
+func (r *Responder) followUp(ctx context.Context, d Decision, esc escalation) (Decision, bool) {
+	d.ApprovalID = esc.approvalID
+	d.Verdict = policy.GateDecision{Action: policy.RespondEscalate, Rule: -1, Reason: "awaiting approval " + esc.approvalID}
+	if r.cfg.Approvals == nil || esc.approvalID == "" {
+		return d, false
+	}
+	record, err := r.cfg.Approvals.Check(ctx, esc.approvalID)
+	if err != nil {
+		d.Outcome, d.Error = OutcomeSkipped, err.Error()
+		delete(r.pending, d.PaneID)
+		r.record(d)
+		return d, true
+	}
+	switch record.Status {
+	case state.ApprovalPending:
+		d.Outcome = OutcomePending
+		return d, false
+	case state.ApprovalApproved:
+		delete(r.pending, d.PaneID)
+		if err := r.cfg.Approvals.Consume(ctx, record.ID, Requester(r.cfg.Session)); err != nil {
+			d.Outcome, d.Error = OutcomeSkipped, err.Error()
+			break
+		}
+		d.Verdict.Reason = "approved by " + record.ApprovedBy
+		r.answer(d.Pane, &d, d.Prompt.ApproveKeys, OutcomeGranted, fingerprint(d.PaneID, d.Prompt))
+	case state.ApprovalDenied:
+		delete(r.pending, d.PaneID)
+		d.Verdict.Reason = "denied by " + record.ApprovedBy
+		if record.DeniedReason != "" {
+			d.Verdict.Reason += ": " + record.DeniedReason
+		}
+		r.answer(d.Pane, &d, d.Prompt.DenyKeys, OutcomeRefused, fingerprint(d.PaneID, d.Prompt))
+	default:
+		// Expired or consumed elsewhere: the next scan escalates afresh.
+		delete(r.pending, d.PaneID)
+		d.Outcome = OutcomeSkipped
+		d.Error = fmt.Sprintf("approval %s is %s", record.ID, record.Status)
+	}
+	r.record(d)
+	return d, true
+}
+
+func (r *Responder) escalate(ctx context.Context, d *Decision, fp string) {
+	d.Outcome = OutcomeEscalated
+	if r.cfg.DryRun || r.cfg.Approvals == nil {
+		// Nothing to wait on; remember the prompt so later scans do not
+		// re-audit it until it changes.
+		r.pending[d.PaneID] = escalation{fingerprint: fp}
+		return
+	}
+	resource := d.Pane + " " + string(d.Prompt.Kind)
+	if d.Prompt.Command != "" {
+		resource += ": " + d.Prompt.Command
+	}
+	record, err := r.cfg.Approvals.Request(ctx, approval.RequestParams{
+		Action:        approvalAction,
+		Resource:      resource,
+		Reason:        d.Verdict.Reason,
+		RequestedBy:   Requester(r.cfg.Session),
+		CorrelationID: "autorespond:" + fp,
+		RequiresSLB:   d.Verdict.SLB,
+	})
+	if err != nil {
+		d.Outcome, d.Error = OutcomeSkipped, err.Error()
+		return
+	}
+	d.ApprovalID = record.ID
+	r.pending[d.PaneID] = escalation{fingerprint: fp, approvalID: record.ID}
+}
+
+// answer sends keys to the pane, one tmux key name at a time.
+func (r *Responder) answer(target string, d *Decision, keys []string, outcome Outcome, fp string) {
+	if len(keys) == 0 {
+		d.Outcome = OutcomeSkipped
+		d.Error = fmt.Sprintf("%s prompt has no keys to %s it", d.Prompt.Kind, d.Verdict.Action)
+		return
+	}
+	d.Outcome = outcome
+	if r.cfg.DryRun {
+		r.answered[fp] = time.Now()
+		return
+	}
+	for i, key := range keys {
+		if i > 0 {
+			time.Sleep(r.cfg.KeyDelay)
+		}
+		if err := r.cfg.Panes.SendKeyName(d.PaneID, key); err != nil {
+			d.Outcome = OutcomeSkipped
+			d.Error = fmt.Sprintf("send key %q to %s: %v", key, target, err)
+			return
+		}
+		d.KeysSent = append(d.KeysSent, key)
+	}
+	r.answered[fp] = time.Now()
+}
+
+func (r *Responder) record(d Decision) {
+	payload := map[string]interface{}{
+		"pane":       d.Pane,
+		"pane_id":    d.PaneID,
+		"agent_type": d.AgentType,
+		"gate":       string(d.Prompt.Kind),
+		"tool":       d.Prompt.Tool,
+		"category":   string(d.Prompt.Category),
+		"command":    d.Prompt.Command,
+		"action":     string(d.Verdict.Action),
+		"outcome":    string(d.Outcome),
+		"reason":     d.Verdict.Reason,
+		"rule":       d.Verdict.Rule,
+		"dry_run":    r.cfg.DryRun,
+	}
+	if d.Verdict.Policy != nil {
+		payload["policy_action"] = string(d.Verdict.Policy.Action)
+		payload["policy_pattern"] = d.Verdict.Policy.Pattern
+	}
+	if d.ApprovalID != "" {
+		payload["approval_id"] = d.ApprovalID
+	}
+	if len(d.KeysSent) > 0 {
+		payload["keys_sent"] = strings.Join(d.KeysSent, " ")
+	}
+	if d.Error != "" {
+		payload["error"] = d.Error
+	}
+	if err := r.cfg.Audit(r.cfg.Session, audit.EventTypeCommand, audit.ActorSystem, "gate.auto_respond", payload, nil); err != nil {
+		slog.Warn("gate auto-responder audit failed", "session", r.cfg.Session, "error", err)
+	}
+}
+
+// Requester is the identity escalated prompts are requested by, so they can
+// be told apart in `ntm approve list`.
+func Requester(session string) string {
+	return "autorespond:" + session
+}
+
+// fingerprint identifies one prompt on one pane.
+func fingerprint(paneID string, p agent.GatePrompt) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{paneID, string(p.Kind), p.Tool, p.Command}, "\x00")))
+	return hex.EncodeToString(sum[:8])
+}
+
+func paneLabel(p tmux.Pane) string {
+	if p.Title != "" {
+		return p.Title
+	}
+	return p.ID
+}
@@ -0,0 +1,213 @@
+package autorespond
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Dicklesworthstone/ntm/internal/approval"
+	"github.com/Dicklesworthstone/ntm/internal/audit"
+	"github.com/Dicklesworthstone/ntm/internal/policy"
+	"github.com/Dicklesworthstone/ntm/internal/state"
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+)
+
+type fakePanes struct {
+	panes   []tmux.Pane
+	content map[string]string
+	sent    map[string][]string
+}
+
+func (f *fakePanes) GetPanes(string) ([]tmux.Pane, error) { return f.panes, nil }
+
+func (f *fakePanes) CapturePaneOutput(target string, _ int) (string, error) {
+	return f.content[target], nil
+}
+
+func (f *fakePanes) SendKeyName(target, key string) error {
+	if f.sent == nil {
+		f.sent = map[string][]string{}
+	}
+	f.sent[target] = append(f.sent[target], key)
+	return nil
+}
+
+func prompt(tool, command string) string {
+	return "╭──────────────╮\n│ " + tool + " │\n│\n│   " + command + "\n│\n│ Do you want to proceed?\n│ ❯ 1. Yes\n│   2. Yes, and don't ask again\n│   3. No, and tell Claude what to do differently (esc)\n╰──────────────╯\n"
+}
+
+const testPolicy = `version: 1
+blocked:
+  - pattern: 'git\s+reset\s+--hard'
+    reason: "Hard reset loses uncommitted changes"
+auto_respond:
+  - gates: [permission]
+    pattern: '(^|\s)rm\s'
+    action: escalate
+    reason: "never auto-approve rm"
+  - agents: [cc]
+    tools: [read]
+    action: approve
+  - agents: [cc]
+    tools: [bash]
+    pattern: '^git\s'
+    action: approve
+`
+
+func newTestResponder(t *testing.T, panes *fakePanes) (*Responder, *approval.Engine, *[]map[string]interface{}) {
+	t.Helper()
+	p, err := policy.DecodeYAML([]byte(testPolicy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	store, err := state.Open(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	if err := store.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	engine := approval.New(store, nil, nil, approval.Config{})
+
+	var events []map[string]interface{}
+	r := New(Config{
+		Session:   "proj",
+		Policy:    p,
+		Approvals: engine,
+		Panes:     panes,
+		KeyDelay:  1,
+		Audit: func(_ string, _ audit.EventType, _ audit.Actor, target string, payload, _ map[string]interface{}) error {
+			if target != "gate.auto_respond" {
+				t.Errorf("audit target = %q", target)
+			}
+			events = append(events, payload)
+			return nil
+		},
+	})
+	return r, engine, &events
+}
+
+func TestScanAnswersByPolicy(t *testing.T) {
+	panes := &fakePanes{
+		panes: []tmux.Pane{
+			{ID: "%1", Title: "proj__cc_1", Type: tmux.AgentClaude},
+			{ID: "%2", Title: "proj__cc_2", Type: tmux.AgentClaude},
+			{ID: "%3", Title: "proj__cc_3", Type: tmux.AgentClaude},
+			{ID: "%4", Title: "proj__user_1", Type: tmux.AgentUser},
+		},
+		content: map[string]string{
+			"%1": prompt("Read file", "/etc/hosts"),
+			"%2": prompt("Bash command", "git reset --hard HEAD~1"),
+			"%3": prompt("Bash command", "git status"),
+			"%4": prompt("Bash command", "git status"),
+		},
+	}
+	r, _, events := newTestResponder(t, panes)
+
+	decisions, err := r.Scan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decisions) != 3 {
+		t.Fatalf("decisions = %+v", decisions)
+	}
+	want := map[string]Outcome{"%1": OutcomeApproved, "%2": OutcomeDenied, "%3": OutcomeApproved}
+	for _, d := range decisions {
+		if d.Outcome != want[d.PaneID] {
+			t.Errorf("%s: outcome %s, want %s (%s)", d.PaneID, d.Outcome, want[d.PaneID], d.Verdict.Reason)
+		}
+	}
+	if got := panes.sent["%1"]; !reflect.DeepEqual(got, []string{"1", "Enter"}) {
+		t.Errorf("keys to %%1 = %v", got)
+	}
+	if got := panes.sent["%2"]; !reflect.DeepEqual(got, []string{"Escape"}) {
+		t.Errorf("keys to %%2 = %v", got)
+	}
+	if _, ok := panes.sent["%4"]; ok {
+		t.Error("user pane answered")
+	}
+	if len(*events) != 3 {
+		t.Errorf("audit events = %d, want 3", len(*events))
+	}
+
+	// The same prompts still on screen right after answering are not
+	// answered twice.
+	if decisions, _ := r.Scan(context.Background()); len(decisions) != 0 {
+		t.Errorf("second scan decisions = %+v", decisions)
+	}
+}
+
+func TestScanEscalatesAndAnswersOnceDecided(t *testing.T) {
+	panes := &fakePanes{
+		panes:   []tmux.Pane{{ID: "%1", Title: "proj__cc_1", Type: tmux.AgentClaude}},
+		content: map[string]string{"%1": prompt("Bash command", "rm build.log")},
+	}
+	r, engine, events := newTestResponder(t, panes)
+	ctx := context.Background()
+
+	decisions, err := r.Scan(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decisions) != 1 || decisions[0].Outcome != OutcomeEscalated || decisions[0].ApprovalID == "" {
+		t.Fatalf("decisions = %+v", decisions)
+	}
+	id := decisions[0].ApprovalID
+	if len(panes.sent) != 0 {
+		t.Fatalf("keys sent before a decision: %v", panes.sent)
+	}
+
+	// Still pending: nothing new to report or audit.
+	if decisions, _ := r.Scan(ctx); len(decisions) != 0 {
+		t.Errorf("pending scan decisions = %+v", decisions)
+	}
+
+	if err := engine.Approve(ctx, id, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	decisions, _ = r.Scan(ctx)
+	if len(decisions) != 1 || decisions[0].Outcome != OutcomeGranted {
+		t.Fatalf("decisions after approval = %+v", decisions)
+	}
+	if got := panes.sent["%1"]; !reflect.DeepEqual(got, []string{"1", "Enter"}) {
+		t.Errorf("keys = %v", got)
+	}
+	record, err := engine.Check(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.Status != state.ApprovalConsumed {
+		t.Errorf("approval status = %s, want consumed", record.Status)
+	}
+	if len(*events) != 2 {
+		t.Errorf("audit events = %d, want escalation and grant", len(*events))
+	}
+}
+
+func TestScanDryRunSendsNothing(t *testing.T) {
+	panes := &fakePanes{
+		panes:   []tmux.Pane{{ID: "%1", Title: "proj__cc_1", Type: tmux.AgentClaude}},
+		content: map[string]string{"%1": prompt("Read file", "go.mod")},
+	}
+	r, _, events := newTestResponder(t, panes)
+	r.cfg.DryRun = true
+
+	decisions, err := r.Scan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decisions) != 1 || decisions[0].Outcome != OutcomeApproved {
+		t.Fatalf("decisions = %+v", decisions)
+	}
+	if len(panes.sent) != 0 {
+		t.Errorf("dry run sent keys: %v", panes.sent)
+	}
+	if len(*events) != 1 || (*events)[0]["dry_run"] != true {
+		t.Errorf("audit = %+v", *events)
+	}
+}
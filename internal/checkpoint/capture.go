@@ -16,6 +16,7 @@ import (
 
 	"github.com/Dicklesworthstone/ntm/internal/assignment"
 	"github.com/Dicklesworthstone/ntm/internal/bv"
+	layoutpkg "github.com/Dicklesworthstone/ntm/internal/layout"
 	"github.com/Dicklesworthstone/ntm/internal/privacy"
 	"github.com/Dicklesworthstone/ntm/internal/tmux"
 )
@@ -185,10 +186,18 @@ func (c *Capturer) captureSessionState(sessionName string) (SessionState, error)
 		}
 	}
 
+	var layoutSpec *layoutpkg.Spec
+	if spec, ok, err := layoutpkg.Active(context.Background(), tmux.DefaultClient, sessionName); err != nil {
+		slog.Warn("failed to capture session layout spec", "session", sessionName, "error", err)
+	} else if ok {
+		layoutSpec = &spec
+	}
+
 	return SessionState{
 		Panes:           paneStates,
 		Layout:          layout,
 		WindowLayouts:   windowLayouts,
+		LayoutSpec:      layoutSpec,
 		ActivePaneIndex: activeIndex,
 	}, nil
 }
@@ -1,6 +1,7 @@
 package checkpoint
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
@@ -11,6 +12,7 @@ import (
 	"time"
 
 	"github.com/Dicklesworthstone/ntm/internal/agent"
+	layoutpkg "github.com/Dicklesworthstone/ntm/internal/layout"
 	"github.com/Dicklesworthstone/ntm/internal/tmux"
 )
 
@@ -299,6 +301,21 @@ func (r *Restorer) restoreLayout(cp *Checkpoint, workDir string) (int, error) {
 	}
 
 	// Apply captured layouts after panes exist.
+	if spec := cp.Session.LayoutSpec; spec != nil {
+		// The window layout strings below restore the exact geometry;
+		// without them the layout is re-planned over the new panes.
+		ctx := context.Background()
+		if len(cp.Session.WindowLayouts) > 0 {
+			if err := layoutpkg.Record(ctx, tmux.DefaultClient, cp.SessionName, *spec); err != nil {
+				return panesCreated, err
+			}
+		} else {
+			if _, err := layoutpkg.Apply(ctx, tmux.DefaultClient, cp.SessionName, *spec); err != nil {
+				return panesCreated, fmt.Errorf("applying layout %q: %w", spec.Name, err)
+			}
+			return panesCreated, nil
+		}
+	}
 	if len(cp.Session.WindowLayouts) > 0 {
 		if err := r.applyWindowLayouts(cp.SessionName, cp.Session.WindowLayouts); err != nil {
 			// Non-fatal - layout is a best-effort feature
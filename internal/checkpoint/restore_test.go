@@ -1,6 +1,7 @@
 package checkpoint
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -11,6 +12,7 @@ import (
 	"testing"
 	"time"
 
+	layoutpkg "github.com/Dicklesworthstone/ntm/internal/layout"
 	"github.com/Dicklesworthstone/ntm/internal/tmux"
 	"github.com/Dicklesworthstone/ntm/tests/testutil"
 )
@@ -1187,6 +1189,11 @@ func TestRestorer_RestoreFromCheckpoint_PreservesPerWindowLayouts(t *testing.T)
 		t.Fatalf("select-layout second template window failed: %v", err)
 	}
 
+	spec := layoutpkg.Spec{Name: "paged", Strategy: layoutpkg.StrategyGrid, PerWindow: 2}
+	if err := layoutpkg.Record(context.Background(), tmux.DefaultClient, templateSession, spec); err != nil {
+		t.Fatalf("Record(template layout) failed: %v", err)
+	}
+
 	capturer := NewCapturerWithStorage(NewStorageWithDir(t.TempDir()))
 	sessionState, err := capturer.captureSessionState(templateSession)
 	if err != nil {
@@ -1195,6 +1202,9 @@ func TestRestorer_RestoreFromCheckpoint_PreservesPerWindowLayouts(t *testing.T)
 	if len(sessionState.WindowLayouts) != 2 {
 		t.Fatalf("len(sessionState.WindowLayouts) = %d, want 2", len(sessionState.WindowLayouts))
 	}
+	if sessionState.LayoutSpec == nil || *sessionState.LayoutSpec != spec {
+		t.Fatalf("captured LayoutSpec = %+v, want %+v", sessionState.LayoutSpec, spec)
+	}
 
 	r := NewRestorerWithStorage(NewStorageWithDir(t.TempDir()))
 	cp := &Checkpoint{
@@ -1219,6 +1229,9 @@ func TestRestorer_RestoreFromCheckpoint_PreservesPerWindowLayouts(t *testing.T)
 	if !windowLayoutsEqual(normalizeWindowLayouts(restoredLayouts), normalizeWindowLayouts(sessionState.WindowLayouts)) {
 		t.Fatalf("restored layouts = %#v, want %#v", restoredLayouts, sessionState.WindowLayouts)
 	}
+	if active, ok, err := layoutpkg.Active(context.Background(), tmux.DefaultClient, restoreSession); err != nil || !ok || active != spec {
+		t.Fatalf("restored active layout = %+v, %v, %v; want %+v", active, ok, err, spec)
+	}
 }
 
 var paneLayoutIDPattern = regexp.MustCompile(`(\d+x\d+,\d+,\d+),\d+`)
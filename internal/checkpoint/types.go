@@ -7,6 +7,7 @@ import (
 	"sort"
 	"time"
 
+	layoutpkg "github.com/Dicklesworthstone/ntm/internal/layout"
 	"github.com/Dicklesworthstone/ntm/internal/tmux"
 )
 
@@ -86,6 +87,9 @@ type SessionState struct {
 	Layout string `json:"layout,omitempty"`
 	// WindowLayouts captures per-window tmux layout strings for restoration.
 	WindowLayouts []WindowLayoutState `json:"window_layouts,omitempty"`
+	// LayoutSpec is the named layout applied with ntm layout apply, so a
+	// restored session keeps re-tiling the same way.
+	LayoutSpec *layoutpkg.Spec `json:"layout_spec,omitempty"`
 	// ActivePaneIndex is the currently selected pane
 	ActivePaneIndex int `json:"active_pane_index"`
 }
@@ -22,6 +22,7 @@ import (
 	"github.com/Dicklesworthstone/ntm/internal/gemini"
 	"github.com/Dicklesworthstone/ntm/internal/hooks"
 	"github.com/Dicklesworthstone/ntm/internal/integrations/dcg"
+	"github.com/Dicklesworthstone/ntm/internal/layout"
 	"github.com/Dicklesworthstone/ntm/internal/output"
 	"github.com/Dicklesworthstone/ntm/internal/persona"
 	"github.com/Dicklesworthstone/ntm/internal/plugins"
@@ -977,6 +978,14 @@ func executeAdd(ctx context.Context, opts AddOptions, emitResult bool) error {
 		})
 	}
 
+	// Each split tiled the first window; put the session back in its active
+	// layout now that every new pane carries its title.
+	if len(newPanes) > 0 {
+		if err := layout.Retile(ctx, session); err != nil && !IsJSONOutput() {
+			output.PrintWarningf("Failed to re-tile layout: %v", err)
+		}
+	}
+
 	// Register the newly added agents with Agent Mail so panes added to a live
 	// session get identities and inboxes just like spawned ones (#240). The
 	// helper self-guards on a disabled config or an unreachable server, and it
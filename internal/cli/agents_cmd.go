@@ -31,6 +31,9 @@ Subcommands:
   show      Show details of a specific agent profile
   stats     Show performance statistics for agents
   recommend Recommend the best agent for a task
+  rules     Inspect and test state detection rules
+  capture-fixture  Save a labelled pane capture for regression replay
+  verify-fixtures  Replay the fixture corpus and report misclassifications
 
 Examples:
   ntm agents list                           # List all profiles
@@ -43,6 +46,9 @@ Examples:
 	cmd.AddCommand(newAgentsShowCmd())
 	cmd.AddCommand(newAgentsStatsCmd())
 	cmd.AddCommand(newAgentsRecommendCmd())
+	cmd.AddCommand(newAgentsRulesCmd())
+	cmd.AddCommand(newAgentsCaptureFixtureCmd())
+	cmd.AddCommand(newAgentsVerifyFixturesCmd())
 
 	return cmd
 }
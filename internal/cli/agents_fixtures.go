@@ -0,0 +1,260 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	agentpkg "github.com/Dicklesworthstone/ntm/internal/agent"
+	"github.com/Dicklesworthstone/ntm/internal/output"
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+)
+
+// AgentFixtureCaptureResult is the output of `ntm agents capture-fixture`.
+type AgentFixtureCaptureResult struct {
+	Path      string                 `json:"path"`
+	Fixture   string                 `json:"fixture"`
+	Label     agentpkg.StateClass    `json:"label"`
+	Predicted agentpkg.StateClass    `json:"predicted"`
+	Expected  agentpkg.ExpectedState `json:"expected"`
+}
+
+func (r *AgentFixtureCaptureResult) Text(w io.Writer) error {
+	fmt.Fprintf(w, "Saved %s (%s) to %s\n", r.Fixture, r.Label, r.Path)
+	if r.Predicted != r.Label {
+		fmt.Fprintf(w, "Note: currently detected as %s; verify-fixtures will report it until detection is fixed\n", r.Predicted)
+	}
+	return nil
+}
+
+func (r *AgentFixtureCaptureResult) JSON() interface{} {
+	return r
+}
+
+func newAgentsCaptureFixtureCmd() *cobra.Command {
+	var (
+		label     string
+		name      string
+		corpus    string
+		note      string
+		file      string
+		agentType string
+		lines     int
+		force     bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "capture-fixture <session> <pane> | <session:pane> | --file capture.txt",
+		Short: "Save a labelled pane capture into the detection fixture corpus",
+		Long: `Capture a pane (or import a saved capture with --file) together with its
+width, agent type and the state it is really in, so verify-fixtures can
+replay it after every detection change.
+
+--label is the true state: working, idle, rate_limited, error, gate or
+unknown. When the current detection disagrees, the label wins and the
+fixture records the misclassification until it is fixed.
+
+Fixtures are stored as <corpus>/<agent>/<name>.txt with a <name>.json
+sidecar. The default corpus is $XDG_DATA_HOME/ntm/agent-fixtures.
+
+Examples:
+  ntm agents capture-fixture myproject 2 --label working
+  ntm agents capture-fixture myproject:cc_1 --label gate --name trust_dialog
+  ntm agents capture-fixture --file pane.txt --agent cod --label idle`,
+		Args: cobra.RangeArgs(0, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			class, err := agentpkg.ParseStateClass(label)
+			if err != nil {
+				return err
+			}
+			if corpus == "" {
+				corpus = agentpkg.DefaultCorpusDir()
+			}
+
+			hint := agentpkg.AgentType(agentType).Canonical()
+			var text, source string
+			var width, height int
+			switch {
+			case file != "":
+				if len(args) > 0 {
+					return fmt.Errorf("--file takes no pane arguments")
+				}
+				data, err := os.ReadFile(file)
+				if err != nil {
+					return err
+				}
+				text, source = string(data), file
+				if name == "" {
+					name = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+				}
+			case len(args) == 0:
+				return fmt.Errorf("specify a pane (session and pane) or --file")
+			default:
+				sessionArg, paneArg := args[0], ""
+				if len(args) == 2 {
+					paneArg = args[1]
+				} else if i := strings.LastIndex(args[0], ":"); i > 0 {
+					sessionArg, paneArg = args[0][:i], args[0][i+1:]
+				} else {
+					return fmt.Errorf("specify the pane as <session> <pane> or <session:pane>")
+				}
+				session, err := useFleetSession(sessionArg)
+				if err != nil {
+					return err
+				}
+				pane, err := resolvePane(session, paneArg)
+				if err != nil {
+					return err
+				}
+				if text, err = tmux.CapturePaneOutput(pane.ID, lines); err != nil {
+					return err
+				}
+				width, height = pane.Width, pane.Height
+				source = session + ":" + pane.Title
+				if hint == "" {
+					hint = agentpkg.AgentType(pane.Type).Canonical()
+				}
+				if name == "" {
+					name = fmt.Sprintf("%s_%s", class, time.Now().UTC().Format("20060102T150405"))
+				}
+			}
+			if hint == "" {
+				hint = agentpkg.NewParser().DetectAgentType(text)
+			}
+
+			f, err := agentpkg.NewFixture(name, hint, text, width, height, class)
+			if err != nil {
+				return err
+			}
+			f.Source = source
+			f.Note = note
+			path, err := agentpkg.SaveFixture(corpus, f, force)
+			if err != nil {
+				return err
+			}
+			st, _ := agentpkg.NewParser().ParseWithHint(text, f.AgentType)
+			_, gate := agentpkg.DetectInteractiveGate(text, width)
+			return output.New(output.WithJSON(jsonOutput)).Output(&AgentFixtureCaptureResult{
+				Path:      path,
+				Fixture:   string(f.AgentType) + "/" + f.Name,
+				Label:     f.Label,
+				Predicted: agentpkg.ClassifyState(st, gate),
+				Expected:  f.Expected,
+			})
+		},
+	}
+	cmd.Flags().StringVar(&label, "label", "", "True state of the pane: working, idle, rate_limited, error, gate, unknown")
+	cmd.Flags().StringVar(&name, "name", "", "Fixture name (default: <label>_<timestamp>, or the --file base name)")
+	cmd.Flags().StringVar(&corpus, "corpus", "", "Fixture corpus directory (default: $XDG_DATA_HOME/ntm/agent-fixtures)")
+	cmd.Flags().StringVar(&note, "note", "", "Free-form note stored with the fixture")
+	cmd.Flags().StringVar(&file, "file", "", "Import a saved capture instead of a live pane")
+	cmd.Flags().StringVar(&agentType, "agent", "", "Agent type of the capture (default: pane type, else detected)")
+	cmd.Flags().IntVarP(&lines, "lines", "n", 200, "Lines to capture from a live pane")
+	cmd.Flags().BoolVar(&force, "force", false, "Overwrite an existing fixture with the same name")
+	_ = cmd.MarkFlagRequired("label")
+	return cmd
+}
+
+// AgentFixtureVerifyResult is the output of `ntm agents verify-fixtures`.
+type AgentFixtureVerifyResult struct {
+	*agentpkg.CorpusReport
+	Failed []agentpkg.FixtureResult `json:"failures"`
+}
+
+func (r *AgentFixtureVerifyResult) Text(w io.Writer) error {
+	if r.Total == 0 {
+		fmt.Fprintf(w, "No fixtures in %s\n", r.Corpus)
+		return nil
+	}
+	types := make([]string, 0, len(r.Matrices))
+	for at := range r.Matrices {
+		types = append(types, string(at))
+	}
+	sort.Strings(types)
+
+	for _, at := range types {
+		m := r.Matrices[agentpkg.AgentType(at)]
+		// Only classes that occur as a label or a prediction get a row or
+		// column, keeping the matrix readable.
+		var classes []agentpkg.StateClass
+		for _, c := range agentpkg.StateClasses {
+			used := len(m[c]) > 0
+			for _, row := range m {
+				used = used || row[c] > 0
+			}
+			if used {
+				classes = append(classes, c)
+			}
+		}
+		fmt.Fprintf(w, "%s (rows: label, columns: detected)\n", at)
+		fmt.Fprintf(w, "  %-13s", "")
+		for _, c := range classes {
+			fmt.Fprintf(w, " %12s", c)
+		}
+		fmt.Fprintln(w)
+		for _, label := range classes {
+			fmt.Fprintf(w, "  %-13s", label)
+			for _, c := range classes {
+				fmt.Fprintf(w, " %12d", m[label][c])
+			}
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintln(w)
+	}
+
+	for _, res := range r.Failed {
+		fmt.Fprintf(w, "FAIL %s: label %s, detected %s", res.Fixture, res.Label, res.Predicted)
+		if len(res.Mismatches) > 0 {
+			fmt.Fprintf(w, " (%s)", strings.Join(res.Mismatches, "; "))
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "%d/%d fixtures pass\n", r.Passed, r.Total)
+	return nil
+}
+
+func (r *AgentFixtureVerifyResult) JSON() interface{} {
+	return r
+}
+
+func newAgentsVerifyFixturesCmd() *cobra.Command {
+	var corpus string
+
+	cmd := &cobra.Command{
+		Use:   "verify-fixtures",
+		Short: "Replay the fixture corpus and report a confusion matrix per agent type",
+		Long: `Replay every fixture in the corpus through the state parser, the
+interactive gate detector and plugin readiness patterns, then print a
+confusion matrix (labelled vs detected state) for each agent type and list
+every misclassified fixture. Exits non-zero when any fixture fails.
+
+Plugin fixtures replay with the installed plugin's current patterns, or with
+the patterns snapshotted at capture time when the plugin is not installed.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if corpus == "" {
+				corpus = agentpkg.DefaultCorpusDir()
+			}
+			report, err := agentpkg.VerifyCorpus(corpus)
+			if err != nil {
+				return err
+			}
+			res := &AgentFixtureVerifyResult{CorpusReport: report, Failed: report.Failures()}
+			if err := output.New(output.WithJSON(jsonOutput)).Output(res); err != nil {
+				return err
+			}
+			if len(res.Failed) > 0 {
+				return fmt.Errorf("%d of %d %w", len(res.Failed), report.Total, agentpkg.ErrFixturesFailed)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&corpus, "corpus", "", "Fixture corpus directory (default: $XDG_DATA_HOME/ntm/agent-fixtures)")
+	return cmd
+}
@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	agentpkg "github.com/Dicklesworthstone/ntm/internal/agent"
+)
+
+func TestAgentsCaptureAndVerifyFixtures(t *testing.T) {
+	corpus := t.TempDir()
+	capture := filepath.Join(t.TempDir(), "idle.txt")
+	if err := os.WriteFile(capture, []byte("codex>\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	capCmd := newAgentsCaptureFixtureCmd()
+	capCmd.SetArgs([]string{"--file", capture, "--agent", "codex", "--label", "idle", "--corpus", corpus})
+	capCmd.SetOut(&bytes.Buffer{})
+	if err := capCmd.Execute(); err != nil {
+		t.Fatalf("capture-fixture: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(corpus, "cod", "idle.json")); err != nil {
+		t.Fatalf("fixture sidecar: %v", err)
+	}
+
+	verify := newAgentsVerifyFixturesCmd()
+	verify.SetArgs([]string{"--corpus", corpus})
+	if err := verify.Execute(); err != nil {
+		t.Fatalf("verify-fixtures: %v", err)
+	}
+
+	// A mislabelled capture fails verification and shows up off the
+	// matrix diagonal.
+	f, err := agentpkg.NewFixture("wrong", agentpkg.AgentTypeCodex, "codex>\n", 0, 0, agentpkg.ClassWorking)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := agentpkg.SaveFixture(corpus, f, false); err != nil {
+		t.Fatal(err)
+	}
+	verify = newAgentsVerifyFixturesCmd()
+	verify.SetArgs([]string{"--corpus", corpus})
+	if err := verify.Execute(); !errors.Is(err, agentpkg.ErrFixturesFailed) {
+		t.Fatalf("verify-fixtures err = %v, want ErrFixturesFailed", err)
+	}
+
+	report, err := agentpkg.VerifyCorpus(corpus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := (&AgentFixtureVerifyResult{CorpusReport: report, Failed: report.Failures()}).Text(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{"cod (rows: label, columns: detected)", "FAIL cod/wrong: label working, detected idle", "1/2 fixtures pass"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
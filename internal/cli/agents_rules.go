@@ -0,0 +1,225 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	agentpkg "github.com/Dicklesworthstone/ntm/internal/agent"
+	"github.com/Dicklesworthstone/ntm/internal/output"
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+)
+
+// agentRulesDirForArgs is where user state detection rule packs live:
+// <config>/agents/rules, next to the agent plugins.
+func agentRulesDirForArgs(args []string) string {
+	return filepath.Join(pluginAgentsDirForArgs(args), "rules")
+}
+
+// registerAgentRulePacks layers the user rule packs in dir over the built-in
+// detection rules. Broken packs are skipped with a warning so a typo in one
+// file never changes how the other agents are classified.
+func registerAgentRulePacks(dir string) []*agentpkg.RulePack {
+	packs, err := agentpkg.LoadRulePacks(dir)
+	if err != nil {
+		slog.Warn("agent rule packs skipped; affected agents keep their built-in rules", "dir", dir, "error", err)
+	}
+	agentpkg.SetUserRulePacks(packs)
+	return packs
+}
+
+func newAgentsRulesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rules",
+		Short: "Inspect and test agent state detection rules",
+		Long: `Agent states (working, idle, rate limited, error) are detected by
+declarative rule packs. Built-in agents ship embedded packs; files in
+<config>/agents/rules/*.toml (or .yaml) are layered on top: a rule with the
+same id replaces the built-in one, disabled = true removes it, new ids are
+added, and replace = true drops the built-in rules for the pack's agents.
+
+Each state is decided by the highest-priority rule that fires: an assert rule
+sets it, a veto rule clears it.
+
+Examples:
+  ntm agents rules list cc                     # Rules in effect for Claude Code
+  ntm agents rules test capture.txt --agent cc # Explain a saved capture
+  ntm agents rules test myproject cc_1         # Explain a live pane`,
+	}
+	cmd.AddCommand(newAgentsRulesListCmd())
+	cmd.AddCommand(newAgentsRulesTestCmd())
+	return cmd
+}
+
+// AgentRulesListResult is the output of `ntm agents rules list`.
+type AgentRulesListResult struct {
+	AgentType string                 `json:"agent_type"`
+	Packs     []string               `json:"rule_packs"`
+	Rules     []agentpkg.RuleSummary `json:"rules"`
+	Agents    []agentpkg.AgentType   `json:"agents,omitempty"`
+}
+
+func (r *AgentRulesListResult) Text(w io.Writer) error {
+	fmt.Fprintf(w, "Rules for %s (%s)\n", r.AgentType, strings.Join(r.Packs, " + "))
+	var state agentpkg.RuleState
+	for _, rule := range r.Rules {
+		if rule.State != state {
+			state = rule.State
+			fmt.Fprintf(w, "\n%s:\n", state)
+		}
+		what := rule.Detector
+		if what == "" {
+			what = fmt.Sprintf("%d pattern(s)", rule.Patterns)
+		}
+		if rule.LastLines > 0 {
+			what += fmt.Sprintf(", last %d lines", rule.LastLines)
+		}
+		flags := rule.Effect
+		if rule.Negate {
+			flags += ",negate"
+		}
+		if rule.Authoritative {
+			flags += ",authoritative"
+		}
+		fmt.Fprintf(w, "  %4d  %-26s %-20s %s\n", rule.Priority, rule.ID, flags, what)
+	}
+	if len(r.Agents) > 0 {
+		names := make([]string, len(r.Agents))
+		for i, a := range r.Agents {
+			names[i] = string(a)
+		}
+		fmt.Fprintf(w, "\nBuilt-in packs: %s\n", strings.Join(names, ", "))
+	}
+	return nil
+}
+
+func (r *AgentRulesListResult) JSON() interface{} {
+	return r
+}
+
+func newAgentsRulesListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list [agent-type]",
+		Short: "List the detection rules in effect for an agent type",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			agentType := agentpkg.AgentTypeUnknown
+			if len(args) == 1 {
+				agentType = agentpkg.AgentType(args[0]).Canonical()
+			}
+			packs, rules := agentpkg.EffectiveRules(agentType)
+			return output.New(output.WithJSON(jsonOutput)).Output(&AgentRulesListResult{
+				AgentType: string(agentType),
+				Packs:     packs,
+				Rules:     rules,
+				Agents:    agentpkg.RuleAgents(),
+			})
+		},
+	}
+}
+
+// AgentRulesTestResult is the output of `ntm agents rules test`.
+type AgentRulesTestResult struct {
+	Input string `json:"input"`
+	*agentpkg.RuleExplanation
+}
+
+func (r *AgentRulesTestResult) Text(w io.Writer) error {
+	st := r.State
+	fmt.Fprintf(w, "%s: %s (%s)\n", r.Input, r.AgentType, strings.Join(r.Packs, " + "))
+	fmt.Fprintf(w, "State: working=%t idle=%t rate_limited=%t error=%t confidence=%.2f\n",
+		st.IsWorking, st.IsIdle, st.IsRateLimited, st.IsInError, st.Confidence)
+	for _, v := range r.Verdicts {
+		decision := "no rule fired"
+		if v.Rule != "" {
+			decision = "decided by " + v.Rule
+		}
+		fmt.Fprintf(w, "\n%s = %t (%s)\n", v.State, v.Detected, decision)
+		for _, t := range v.Trace {
+			mark := " "
+			switch {
+			case t.Decisive:
+				mark = "*"
+			case t.Fired:
+				mark = "+"
+			}
+			line := fmt.Sprintf("  %s %4d  %-26s %s", mark, t.Priority, t.ID, t.Effect)
+			if t.Fired && len(t.Evidence) > 0 {
+				line += "  " + truncate(strings.Join(t.Evidence, ", "), 60)
+			}
+			fmt.Fprintln(w, line)
+		}
+	}
+	fmt.Fprintln(w, "\n* decided the state, + fired after the decision")
+	return nil
+}
+
+func (r *AgentRulesTestResult) JSON() interface{} {
+	return r
+}
+
+func newAgentsRulesTestCmd() *cobra.Command {
+	var (
+		agentType string
+		lines     int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "test <fixture-file | session pane>",
+		Short: "Explain which rules fire for a saved capture or a live pane",
+		Long: `Run the detection rules against a saved pane capture (one argument, "-"
+for stdin) or a live pane (session and pane index, title or id) and show, for
+every state, each rule tried and the one that decided it.
+
+The agent type comes from --agent, else from the pane title, else it is
+detected from the output.`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			hint := agentpkg.AgentType(agentType).Canonical()
+			var input, text string
+			if len(args) == 1 {
+				input = args[0]
+				var data []byte
+				var err error
+				if input == "-" {
+					data, err = io.ReadAll(cmd.InOrStdin())
+				} else {
+					data, err = os.ReadFile(input)
+				}
+				if err != nil {
+					return err
+				}
+				text = string(data)
+			} else {
+				session, err := useFleetSession(args[0])
+				if err != nil {
+					return err
+				}
+				pane, err := resolvePane(session, args[1])
+				if err != nil {
+					return err
+				}
+				if text, err = tmux.CapturePaneOutput(pane.ID, lines); err != nil {
+					return err
+				}
+				input = session + ":" + pane.Title
+				if agentType == "" {
+					hint = agentpkg.AgentType(pane.Type).Canonical()
+				}
+			}
+			ex, err := agentpkg.ExplainRules(text, hint)
+			if err != nil {
+				return err
+			}
+			return output.New(output.WithJSON(jsonOutput)).Output(&AgentRulesTestResult{Input: input, RuleExplanation: ex})
+		},
+	}
+	cmd.Flags().StringVar(&agentType, "agent", "", "Agent type of the capture (e.g. cc, cod, grok)")
+	cmd.Flags().IntVarP(&lines, "lines", "n", 200, "Lines to capture from a live pane")
+	return cmd
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	agentpkg "github.com/Dicklesworthstone/ntm/internal/agent"
+)
+
+func TestRegisterAgentRulePacksLayersOverPlugins(t *testing.T) {
+	agentpkg.UnregisterPlugins()
+	t.Cleanup(agentpkg.UnregisterPlugins)
+	t.Cleanup(func() { agentpkg.SetUserRulePacks(nil) })
+
+	agents := filepath.Join(t.TempDir(), "agents")
+	writePluginTOML(t, agents, ompPluginTOML)
+	registerAgentPluginTypes(agents)
+
+	rules := filepath.Join(agents, "rules")
+	if err := os.MkdirAll(rules, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	pack := "schema = 1\nversion = \"3\"\nagents = [\"omp\"]\n\n[[rule]]\nid = \"omp.error\"\nstate = \"error\"\nmatch = \"substring\"\npatterns = [\"omp crashed\"]\n"
+	if err := os.WriteFile(filepath.Join(rules, "omp.toml"), []byte(pack), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(rules, "broken.toml"), []byte("schema = 7"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := registerAgentRulePacks(rules); len(got) != 1 {
+		t.Fatalf("registered %d packs, want the valid one", len(got))
+	}
+
+	ex, err := agentpkg.ExplainRules("working ⟨esc⟩\nomp crashed", "omp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ex.State.IsWorking || !ex.State.IsInError {
+		t.Fatalf("state = %+v", ex.State)
+	}
+	if len(ex.Packs) != 3 || ex.Packs[0] != "plugin:omp" {
+		t.Errorf("packs = %v", ex.Packs)
+	}
+
+	var buf bytes.Buffer
+	if err := (&AgentRulesTestResult{Input: "capture.txt", RuleExplanation: ex}).Text(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "error = true (decided by omp.error)") {
+		t.Errorf("explanation:\n%s", buf.String())
+	}
+}
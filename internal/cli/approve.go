@@ -5,18 +5,21 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/Dicklesworthstone/ntm/internal/approval"
 	"github.com/Dicklesworthstone/ntm/internal/audit"
+	"github.com/Dicklesworthstone/ntm/internal/config"
+	"github.com/Dicklesworthstone/ntm/internal/notify"
 	"github.com/Dicklesworthstone/ntm/internal/robot"
 	"github.com/Dicklesworthstone/ntm/internal/state"
 )
 
 func newApproveCmd() *cobra.Command {
-	var reason string
+	var reason, onBehalfOf string
 
 	cmd := &cobra.Command{
 		Use:   "approve [token]",
@@ -39,6 +42,12 @@ Examples:
   ntm approve deny abc123 --reason "Too risky"
   ntm approve show abc123             # Show approval details
 
+Quorum rules: when an [approvals] rule in config.toml matches the request's
+action, approving casts one vote toward the rule's quorum (for example two
+approvers from group "sre") and the request stays pending until the quorum is
+met. Use --on-behalf-of to vote for an approver who has delegated to you.
+Escalation steps and timeouts from the rule fire whenever approvals are read.
+
 Identity note: the approver identity recorded on decisions is taken from the
 NTM_USER (or USER) environment variable. It is asserted, not authenticated —
 any process or person with shell access to this machine can approve or deny
@@ -49,9 +58,10 @@ operators on a trusted host, not as an authentication boundary.`,
 			if len(args) == 0 {
 				return cmd.Help()
 			}
-			return runApprove(args[0], IsJSONOutput())
+			return runApprove(args[0], onBehalfOf, IsJSONOutput())
 		},
 	}
+	cmd.Flags().StringVar(&onBehalfOf, "on-behalf-of", "", "Vote for an approver who has delegated to you")
 
 	// list - list pending approvals
 	var listLimit, listOffset int
@@ -71,10 +81,11 @@ operators on a trusted host, not as an authentication boundary.`,
 		Short: "Deny a pending request",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runApproveDeny(args[0], reason, IsJSONOutput())
+			return runApproveDeny(args[0], reason, onBehalfOf, IsJSONOutput())
 		},
 	}
 	denyCmd.Flags().StringVar(&reason, "reason", "", "Reason for denial")
+	denyCmd.Flags().StringVar(&onBehalfOf, "on-behalf-of", "", "Deny for an approver who has delegated to you")
 
 	// show <token> - show details
 	showCmd := &cobra.Command{
@@ -106,7 +117,9 @@ type ApprovalResult struct {
 	Action   string `json:"action"`
 	Resource string `json:"resource"`
 	Status   string `json:"status"`
-	Error    string `json:"error,omitempty"`
+	// Missing lists the quorum requirements still unmet after a vote.
+	Missing []string `json:"missing,omitempty"`
+	Error   string   `json:"error,omitempty"`
 }
 
 func getApprovalEngine() (*approval.Engine, *state.Store, error) {
@@ -121,11 +134,62 @@ func getApprovalEngine() (*approval.Engine, *state.Store, error) {
 		return nil, nil, fmt.Errorf("apply migrations: %w", err)
 	}
 
-	engine := approval.New(store, nil, nil, approval.DefaultConfig())
+	engineCfg, err := approvalEngineConfig()
+	if err != nil {
+		store.Close()
+		return nil, nil, err
+	}
+	engine := approval.New(store, approvalNotifier(), nil, engineCfg)
 	return engine, store, nil
 }
 
-func runApprove(token string, jsonOutput bool) error {
+// approvalEngineConfig returns the engine configuration carrying the
+// [approvals] groups, rules and delegations from config.toml.
+func approvalEngineConfig() (approval.Config, error) {
+	engineCfg := approval.DefaultConfig()
+	if cfg == nil {
+		return engineCfg, nil
+	}
+	engineCfg.Policy = approvalPolicy(cfg.Approvals)
+	if err := engineCfg.Policy.Validate(); err != nil {
+		return engineCfg, fmt.Errorf("config: %w", err)
+	}
+	return engineCfg, nil
+}
+
+// approvalPolicy converts the [approvals] config mirror to the engine's
+// policy.
+func approvalPolicy(c config.ApprovalsConfig) approval.Policy {
+	var p approval.Policy
+	for _, g := range c.Groups {
+		p.Groups = append(p.Groups, approval.Group{Name: g.Name, Members: g.Members, Roles: g.Roles})
+	}
+	for _, r := range c.Rules {
+		rule := approval.Rule{Action: r.Action, SLB: r.SLB, Timeout: r.Timeout, OnTimeout: r.OnTimeout}
+		for _, q := range r.Quorum {
+			rule.Quorum = append(rule.Quorum, approval.Requirement{Group: q.Group, Count: q.Count})
+		}
+		for _, step := range r.Escalation {
+			rule.Escalation = append(rule.Escalation, approval.EscalationStep{After: step.After, Notify: step.Notify})
+		}
+		p.Rules = append(p.Rules, rule)
+	}
+	for _, d := range c.Delegations {
+		p.Delegations = append(p.Delegations, approval.Delegation{From: d.From, To: d.To, Until: d.Until})
+	}
+	return p
+}
+
+// approvalNotifier delivers approval and escalation notifications through
+// the [notifications] channels, or returns nil when they are disabled.
+func approvalNotifier() *notify.Notifier {
+	if cfg == nil || !cfg.Notifications.Enabled {
+		return nil
+	}
+	return notify.New(cfg.Notifications)
+}
+
+func runApprove(token, onBehalfOf string, jsonOutput bool) error {
 	engine, store, err := getApprovalEngine()
 	if err != nil {
 		return outputError(err, jsonOutput)
@@ -135,14 +199,11 @@ func runApprove(token string, jsonOutput bool) error {
 	ctx := context.Background()
 	currentUser := getCurrentApprover()
 
-	if err := engine.Approve(ctx, token, currentUser); err != nil {
-		return outputError(err, jsonOutput)
-	}
-
-	appr, err := engine.Check(ctx, token)
+	vote, err := engine.ApproveAs(ctx, token, approval.Approver{ID: currentUser, OnBehalfOf: onBehalfOf})
 	if err != nil {
 		return outputError(err, jsonOutput)
 	}
+	appr := vote.Approval
 
 	// Durable state.db record is the primary trail; mirror the decision into
 	// the audit log so approvals appear alongside other audited operations
@@ -153,6 +214,8 @@ func runApprove(token string, jsonOutput bool) error {
 		"resource":     appr.Resource,
 		"requested_by": appr.RequestedBy,
 		"approved_by":  currentUser,
+		"on_behalf_of": onBehalfOf,
+		"status":       string(appr.Status),
 		"requires_slb": appr.RequiresSLB,
 	}, nil)
 
@@ -162,12 +225,22 @@ func runApprove(token string, jsonOutput bool) error {
 		Action:   appr.Action,
 		Resource: appr.Resource,
 		Status:   string(appr.Status),
+		Missing:  vote.Missing,
 	}
 
 	if jsonOutput {
 		return json.NewEncoder(os.Stdout).Encode(result)
 	}
 
+	if appr.Status == state.ApprovalPending {
+		fmt.Printf("✓ Vote recorded: %s\n", token)
+		fmt.Printf("  Action:   %s\n", appr.Action)
+		fmt.Printf("  Resource: %s\n", appr.Resource)
+		fmt.Printf("  Votes:    %s\n", approvalVoters(vote.Votes))
+		fmt.Printf("  Needs:    %s\n", strings.Join(vote.Missing, ", "))
+		return nil
+	}
+
 	fmt.Printf("✓ Approved: %s\n", token)
 	fmt.Printf("  Action:   %s\n", appr.Action)
 	fmt.Printf("  Resource: %s\n", appr.Resource)
@@ -249,7 +322,7 @@ func runApproveList(jsonOutput bool, limit, offset int) error {
 	return nil
 }
 
-func runApproveDeny(token, reason string, jsonOutput bool) error {
+func runApproveDeny(token, reason, onBehalfOf string, jsonOutput bool) error {
 	engine, store, err := getApprovalEngine()
 	if err != nil {
 		return outputError(err, jsonOutput)
@@ -259,7 +332,7 @@ func runApproveDeny(token, reason string, jsonOutput bool) error {
 	ctx := context.Background()
 	currentUser := getCurrentApprover()
 
-	if err := engine.Deny(ctx, token, currentUser, reason); err != nil {
+	if err := engine.DenyAs(ctx, token, approval.Approver{ID: currentUser, OnBehalfOf: onBehalfOf}, reason); err != nil {
 		return outputError(err, jsonOutput)
 	}
 
@@ -274,6 +347,7 @@ func runApproveDeny(token, reason string, jsonOutput bool) error {
 		"resource":     appr.Resource,
 		"requested_by": appr.RequestedBy,
 		"denied_by":    currentUser,
+		"on_behalf_of": onBehalfOf,
 		"reason":       reason,
 		"requires_slb": appr.RequiresSLB,
 	}, nil)
@@ -307,15 +381,30 @@ func runApproveShow(token string, jsonOutput bool) error {
 	defer store.Close()
 
 	ctx := context.Background()
-	appr, err := engine.Check(ctx, token)
+	tally, err := engine.Tally(ctx, token)
+	if err != nil {
+		return outputError(err, jsonOutput)
+	}
+	appr := tally.Approval
+	escalations, err := engine.Escalations(ctx, token)
 	if err != nil {
 		return outputError(err, jsonOutput)
 	}
 
 	if jsonOutput {
+		votes := tally.Votes
+		if votes == nil {
+			votes = []state.ApprovalVote{}
+		}
+		if escalations == nil {
+			escalations = []state.ApprovalEscalation{}
+		}
 		return json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
-			"success":  true,
-			"approval": appr,
+			"success":     true,
+			"approval":    appr,
+			"votes":       votes,
+			"missing":     tally.Missing,
+			"escalations": escalations,
 		})
 	}
 
@@ -345,10 +434,32 @@ func runApproveShow(token string, jsonOutput bool) error {
 	if appr.CorrelationID != "" {
 		fmt.Printf("  Correlation:  %s\n", appr.CorrelationID)
 	}
+	if len(tally.Votes) > 0 {
+		fmt.Printf("  Votes:        %s\n", approvalVoters(tally.Votes))
+	}
+	if len(tally.Missing) > 0 {
+		fmt.Printf("  Needs:        %s\n", strings.Join(tally.Missing, ", "))
+	}
+	for _, esc := range escalations {
+		fmt.Printf("  Escalated:    to %s at %s\n", esc.Notified, esc.CreatedAt.Format(time.RFC3339))
+	}
 
 	return nil
 }
 
+// approvalVoters renders quorum votes as "alice, carol for dana".
+func approvalVoters(votes []state.ApprovalVote) string {
+	names := make([]string, 0, len(votes))
+	for _, v := range votes {
+		name := v.Voter
+		if v.OnBehalfOf != "" {
+			name += " for " + v.OnBehalfOf
+		}
+		names = append(names, name)
+	}
+	return strings.Join(names, ", ")
+}
+
 func runApproveHistory(jsonOutput bool) error {
 	engine, store, err := getApprovalEngine()
 	if err != nil {
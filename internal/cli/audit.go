@@ -1,6 +1,8 @@
 package cli
 
 import (
+	"crypto/ed25519"
+	"encoding/base64"
 	"encoding/csv"
 	"encoding/json"
 	"errors"
@@ -15,6 +17,9 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/Dicklesworthstone/ntm/internal/audit"
+	"github.com/Dicklesworthstone/ntm/internal/config"
+	"github.com/Dicklesworthstone/ntm/internal/encryption"
+	"github.com/Dicklesworthstone/ntm/internal/policy"
 	"github.com/Dicklesworthstone/ntm/internal/robot"
 	"github.com/Dicklesworthstone/ntm/internal/tui/theme"
 )
@@ -32,7 +37,10 @@ Examples:
   ntm audit search "spawn"                  # Search all logs
   ntm audit search --type=error --days=7    # Errors in last week
   ntm audit verify myproject                # Verify log integrity
-  ntm audit export myproject --format=json  # Export session log`,
+  ntm audit export myproject --format=json  # Export session log
+  ntm audit export myproject --bundle -o myproject.bundle.json
+  ntm audit pubkey > operator.pub           # Share the checkpoint signing key
+  ntm audit verify --bundle myproject.bundle.json --key operator.pub`,
 	}
 
 	cmd.AddCommand(
@@ -41,6 +49,7 @@ Examples:
 		newAuditVerifyCmd(),
 		newAuditExportCmd(),
 		newAuditListCmd(),
+		newAuditPubkeyCmd(),
 	)
 
 	return cmd
@@ -111,20 +120,52 @@ Examples:
 }
 
 func newAuditVerifyCmd() *cobra.Command {
+	var (
+		bundle  string
+		keyFile string
+	)
+
 	cmd := &cobra.Command{
-		Use:   "verify <session>",
+		Use:   "verify [session]",
 		Short: "Verify audit log integrity",
 		Long: `Verify the hash chain and sequence numbers of an audit log.
 
 Checks for:
 - Broken hash chains (indicating tampering)
 - Sequence number gaps (indicating deletion)
-- Checksum mismatches (indicating modification)`,
-		Args: cobra.ExactArgs(1),
+- Checksum mismatches (indicating modification)
+
+When [audit.anchor] has signed checkpoints of a log (locally or in a
+configured mirror), the log must also still contain each checkpointed entry
+unchanged. That catches a chain rewritten from scratch, which the hash chain
+alone cannot.
+
+--bundle verifies a bundle written by 'ntm audit export --bundle' without
+access to the original host. Pass --key with the operator's public keys
+(base64, one per line) to pin the signer; otherwise the keys the bundle
+carries are used and their fingerprints printed for comparison.`,
+		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runAuditVerify(args[0])
+			trusted, err := loadAuditTrustedKeys(keyFile)
+			if err != nil {
+				return err
+			}
+			if bundle != "" {
+				if len(args) > 0 {
+					return fmt.Errorf("--bundle takes no session argument")
+				}
+				return runAuditVerifyBundleTo(os.Stdout, bundle, trusted, keyFile != "")
+			}
+			if len(args) == 0 {
+				return fmt.Errorf("session name required (or --bundle)")
+			}
+			return runAuditVerifyWithKeys(os.Stdout, args[0], trusted)
 		},
 	}
+
+	cmd.Flags().StringVar(&bundle, "bundle", "", "Verify a verification bundle file instead of local logs")
+	cmd.Flags().StringVar(&keyFile, "key", "", "File of trusted base64 ed25519 public keys, one per line")
+
 	return cmd
 }
 
@@ -132,23 +173,64 @@ func newAuditExportCmd() *cobra.Command {
 	var (
 		format string
 		output string
+		bundle bool
 	)
 
 	cmd := &cobra.Command{
 		Use:   "export <session>",
 		Short: "Export audit log to file",
-		Args:  cobra.ExactArgs(1),
+		Long: `Export a session's audit log as JSON or CSV.
+
+--bundle writes a self-contained verification bundle instead: the raw log
+entries, every signed checkpoint (local and mirrored) and the signing public
+keys. Anyone can check it with 'ntm audit verify --bundle'.`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if bundle {
+				return runAuditExportBundle(args[0], output)
+			}
 			return runAuditExport(args[0], format, output)
 		},
 	}
 
 	cmd.Flags().StringVar(&format, "format", "json", "Export format (json, csv)")
 	cmd.Flags().StringVarP(&output, "output", "o", "", "Output file path (default: stdout)")
+	cmd.Flags().BoolVar(&bundle, "bundle", false, "Write a verification bundle with signed checkpoints and public keys")
 
 	return cmd
 }
 
+func newAuditPubkeyCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "pubkey",
+		Short: "Print the public key that verifies audit checkpoints",
+		Long: `Print the base64 ed25519 public key matching the [audit.anchor] signing key.
+
+Give it to whoever verifies your bundles, for 'ntm audit verify --key'.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAuditPubkey(os.Stdout)
+		},
+	}
+}
+
+func runAuditPubkey(w io.Writer) error {
+	a := audit.CurrentAnchorer()
+	if a == nil {
+		return fmt.Errorf("audit checkpoint signing is not enabled: set [audit.anchor] enabled = true")
+	}
+	pub := a.PublicKey()
+	encoded := base64.StdEncoding.EncodeToString(pub)
+	if jsonOutput {
+		return json.NewEncoder(w).Encode(map[string]string{
+			"public_key":  encoded,
+			"fingerprint": audit.KeyFingerprint(pub),
+		})
+	}
+	_, err := fmt.Fprintln(w, encoded)
+	return err
+}
+
 func newAuditListCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "list",
@@ -317,6 +399,10 @@ type auditVerifyFileResult struct {
 	Status   string `json:"status"`
 	Verified bool   `json:"verified"`
 	Error    string `json:"error,omitempty"`
+	// Checkpoints and AnchoredThrough report signed [audit.anchor]
+	// checkpoints the log was checked against.
+	Checkpoints     int    `json:"checkpoints,omitempty"`
+	AnchoredThrough uint64 `json:"anchored_through,omitempty"`
 }
 
 type auditVerifyResult struct {
@@ -328,6 +414,19 @@ type auditVerifyResult struct {
 }
 
 func runAuditVerifyTo(w io.Writer, session string) error {
+	return runAuditVerifyWithKeys(w, session, nil)
+}
+
+// runAuditVerifyWithKeys verifies a session's logs, checking signed
+// checkpoints against trusted (or, when empty, the configured signing key).
+func runAuditVerifyWithKeys(w io.Writer, session string, trusted []ed25519.PublicKey) error {
+	if len(trusted) == 0 {
+		if a := audit.CurrentAnchorer(); a != nil {
+			trusted = []ed25519.PublicKey{a.PublicKey()}
+		}
+	}
+	mirrors := auditAnchorMirrors()
+
 	searcher, err := newAuditSearcherFunc()
 	if err != nil {
 		return fmt.Errorf("failed to create searcher: %w", err)
@@ -354,7 +453,8 @@ func runAuditVerifyTo(w io.Writer, session string) error {
 
 	for _, logPath := range matches {
 		fname := filepath.Base(logPath)
-		if err := audit.VerifyIntegrity(logPath); err != nil {
+		report, err := verifyAuditLogFile(logPath, mirrors, trusted)
+		if err != nil {
 			result.Success = false
 			result.Verified = false
 			result.Files = append(result.Files, auditVerifyFileResult{
@@ -365,11 +465,16 @@ func runAuditVerifyTo(w io.Writer, session string) error {
 				fmt.Printf("%s✗%s %s: FAIL - %v\n", colorize(t.Error), "\033[0m", fname, err)
 			}
 		} else {
-			result.Files = append(result.Files, auditVerifyFileResult{
-				File: fname, Status: "PASS", Verified: true,
-			})
+			fileResult := auditVerifyFileResult{File: fname, Status: "PASS", Verified: true}
+			anchored := ""
+			if report != nil && report.Checkpoints > 0 {
+				fileResult.Checkpoints = report.Checkpoints
+				fileResult.AnchoredThrough = report.AnchoredThrough
+				anchored = fmt.Sprintf(" (anchored through %d/%d, checkpoints: %d)", report.AnchoredThrough, report.Entries, report.Checkpoints)
+			}
+			result.Files = append(result.Files, fileResult)
 			if !jsonOutput {
-				fmt.Printf("%s✓%s %s: PASS\n", colorize(t.Success), "\033[0m", fname)
+				fmt.Printf("%s✓%s %s: PASS%s\n", colorize(t.Success), "\033[0m", fname, anchored)
 			}
 		}
 	}
@@ -388,6 +493,180 @@ func runAuditVerifyTo(w io.Writer, session string) error {
 	return nil
 }
 
+// verifyAuditLogFile checks one log's hash chain and, when it has signed
+// checkpoints, that it still matches them. The report is nil for a log
+// without checkpoints.
+func verifyAuditLogFile(logPath string, mirrors []audit.Mirror, trusted []ed25519.PublicKey) (*audit.AnchorReport, error) {
+	checkpoints, err := audit.LoadCheckpoints(logPath, mirrors)
+	if err != nil {
+		return nil, err
+	}
+	if len(checkpoints) == 0 {
+		return nil, audit.VerifyIntegrity(logPath)
+	}
+	f, err := os.Open(logPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+	return audit.VerifyAnchored(f, filepath.Base(logPath), checkpoints, trusted)
+}
+
+type auditVerifyBundleResult struct {
+	Success  bool                    `json:"success"`
+	Bundle   string                  `json:"bundle"`
+	Session  string                  `json:"session"`
+	Verified bool                    `json:"verified"`
+	Pinned   bool                    `json:"pinned"`
+	Keys     []string                `json:"keys"`
+	Logs     []audit.BundleLogResult `json:"logs"`
+	Error    string                  `json:"error,omitempty"`
+}
+
+// runAuditVerifyBundleTo verifies a bundle written by runAuditExportBundle.
+// pinned reports whether trusted came from --key rather than the bundle.
+func runAuditVerifyBundleTo(w io.Writer, path string, trusted []ed25519.PublicKey, pinned bool) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read bundle: %w", err)
+	}
+	var bundle audit.Bundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return fmt.Errorf("invalid bundle %s: %w", path, err)
+	}
+
+	result := auditVerifyBundleResult{Bundle: path, Session: bundle.Session, Pinned: pinned, Keys: []string{}}
+	logs, verifyErr := bundle.Verify(trusted)
+	result.Logs = logs
+	if result.Logs == nil {
+		result.Logs = []audit.BundleLogResult{}
+	}
+	keys := map[string]bool{}
+	for _, l := range logs {
+		for _, k := range l.Keys {
+			if !keys[k] {
+				keys[k] = true
+				result.Keys = append(result.Keys, k)
+			}
+		}
+	}
+	result.Success = verifyErr == nil
+	result.Verified = result.Success
+
+	if !jsonOutput {
+		t := theme.Current()
+		for _, l := range logs {
+			switch {
+			case l.Verified && l.Anchored:
+				fmt.Fprintf(w, "%s✓%s %s: PASS (anchored through %d/%d, checkpoints: %d)\n",
+					colorize(t.Success), "\033[0m", l.File, l.AnchoredThrough, l.Entries, l.Checkpoints)
+			case l.Verified:
+				fmt.Fprintf(w, "%s!%s %s: UNANCHORED (hash chain intact, %d entries, no signed checkpoint)\n",
+					colorize(t.Warning), "\033[0m", l.File, l.Entries)
+			default:
+				fmt.Fprintf(w, "%s✗%s %s: FAIL - %s\n", colorize(t.Error), "\033[0m", l.File, l.Error)
+			}
+		}
+		if len(result.Keys) > 0 {
+			fmt.Fprintf(w, "Signed by key %s\n", strings.Join(result.Keys, ", "))
+			if !pinned {
+				fmt.Fprintln(w, "Key taken from the bundle: compare the fingerprint with the operator's, or pass --key.")
+			}
+		}
+	}
+
+	if verifyErr != nil {
+		result.Error = "bundle verification failed"
+		if jsonOutput {
+			return emitJSONFailureEnvelopeToWithCause(w, result, verifyErr)
+		}
+		return fmt.Errorf("%s: %w", result.Error, verifyErr)
+	}
+	if jsonOutput {
+		return json.NewEncoder(w).Encode(result)
+	}
+	return nil
+}
+
+// runAuditExportBundle writes a verification bundle for a session.
+func runAuditExportBundle(session, outputPath string) error {
+	searcher, err := newAuditSearcherFunc()
+	if err != nil {
+		return fmt.Errorf("failed to create searcher: %w", err)
+	}
+	matches, err := filepath.Glob(filepath.Join(searcher.AuditDir(), session+"-*.jsonl"))
+	if err != nil {
+		return fmt.Errorf("failed to find log files: %w", err)
+	}
+	if len(matches) == 0 {
+		return fmt.Errorf("no audit logs found for session %q", session)
+	}
+	bundle, err := audit.NewBundle(session, matches, auditAnchorMirrors())
+	if err != nil {
+		return fmt.Errorf("failed to build bundle: %w", err)
+	}
+
+	w := os.Stdout
+	if outputPath != "" {
+		w, err = os.Create(outputPath)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer w.Close()
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(bundle)
+}
+
+// loadAuditTrustedKeys reads --key: base64 ed25519 public keys, one per line.
+func loadAuditTrustedKeys(path string) ([]ed25519.PublicKey, error) {
+	if path == "" {
+		return nil, nil
+	}
+	keys, err := policy.LoadTrustedKeys(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%s lists no public keys", path)
+	}
+	return keys, nil
+}
+
+// auditAnchorMirrors returns the checkpoint mirrors configured in
+// [audit.anchor]. Verification reads them whether or not this host signs.
+func auditAnchorMirrors() []audit.Mirror {
+	if cfg == nil {
+		return nil
+	}
+	a := cfg.Audit.Anchor
+	var mirrors []audit.Mirror
+	if a.MirrorDir != "" {
+		mirrors = append(mirrors, audit.DirMirror{Dir: a.MirrorDir})
+	}
+	if a.GitNotesRepo != "" {
+		mirrors = append(mirrors, audit.GitNotesMirror{Repo: a.GitNotesRepo, Ref: a.GitNotesRef})
+	}
+	return mirrors
+}
+
+// newAuditAnchorer resolves the [audit.anchor] signing key through the
+// encryption key resolvers.
+func newAuditAnchorer(a config.AuditAnchorConfig) (*audit.Anchorer, error) {
+	seed, err := encryption.ResolveKey(encryption.KeyConfig{
+		KeySource:  a.KeySource,
+		KeyEnv:     a.KeyEnv,
+		KeyFile:    a.KeyFile,
+		KeyCommand: a.KeyCommand,
+		KeyFormat:  a.KeyFormat,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return audit.NewAnchorer(seed, time.Duration(a.IntervalSeconds)*time.Second, auditAnchorMirrors()...)
+}
+
 func runAuditExport(session, format, outputPath string) error {
 	format = strings.ToLower(strings.TrimSpace(format))
 	if IsJSONOutput() {
@@ -2,7 +2,9 @@ package cli
 
 import (
 	"bytes"
+	"crypto/ed25519"
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
@@ -518,3 +520,74 @@ func writeTestAuditLog(t *testing.T, dir, session string, count int) {
 		fmt.Fprintln(f, string(line))
 	}
 }
+
+func TestRunAuditBundleExportAndVerify(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTestAuditLog(t, tmpDir, "bundled", 3)
+	withTestSearcher(t, tmpDir)
+	logPath := filepath.Join(tmpDir, fmt.Sprintf("bundled-%s.jsonl", time.Now().Format("2006-01-02")))
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	var last audit.AuditEntry
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &last); err != nil {
+		t.Fatal(err)
+	}
+	anchorer, err := audit.NewAnchorer(bytes.Repeat([]byte{7}, 32), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := anchorer.Anchor(logPath, "bundled", last.SequenceNum, last.Checksum); err != nil {
+		t.Fatal(err)
+	}
+
+	originalJSON := jsonOutput
+	jsonOutput = true
+	t.Cleanup(func() { jsonOutput = originalJSON })
+
+	var buf bytes.Buffer
+	if err := runAuditVerifyWithKeys(&buf, "bundled", []ed25519.PublicKey{anchorer.PublicKey()}); err != nil {
+		t.Fatalf("verify anchored session: %v", err)
+	}
+	var local auditVerifyResult
+	if err := json.Unmarshal(buf.Bytes(), &local); err != nil {
+		t.Fatal(err)
+	}
+	if f := local.Files[0]; f.Checkpoints != 1 || f.AnchoredThrough != 3 {
+		t.Fatalf("local verification = %+v", f)
+	}
+
+	bundlePath := filepath.Join(t.TempDir(), "bundle.json")
+	if err := runAuditExportBundle("bundled", bundlePath); err != nil {
+		t.Fatalf("export bundle: %v", err)
+	}
+	buf.Reset()
+	if err := runAuditVerifyBundleTo(&buf, bundlePath, nil, false); err != nil {
+		t.Fatalf("verify bundle: %v", err)
+	}
+	var result auditVerifyBundleResult
+	if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if !result.Verified || result.Pinned || len(result.Keys) != 1 || result.Keys[0] != audit.KeyFingerprint(anchorer.PublicKey()) {
+		t.Fatalf("bundle result = %+v", result)
+	}
+
+	// Pinning a different operator key rejects the bundle.
+	other, _ := audit.NewAnchorer(bytes.Repeat([]byte{8}, 32), 0)
+	keyFile := filepath.Join(t.TempDir(), "operator.pub")
+	if err := os.WriteFile(keyFile, []byte(base64.StdEncoding.EncodeToString(other.PublicKey())+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	trusted, err := loadAuditTrustedKeys(keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	if err := runAuditVerifyBundleTo(&buf, bundlePath, trusted, true); !errors.Is(err, errJSONFailure) {
+		t.Fatalf("verify with foreign key = %v, want JSON failure", err)
+	}
+}
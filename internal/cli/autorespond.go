@@ -0,0 +1,168 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/ntm/internal/approval"
+	"github.com/Dicklesworthstone/ntm/internal/autorespond"
+	"github.com/Dicklesworthstone/ntm/internal/output"
+	"github.com/Dicklesworthstone/ntm/internal/policy"
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+)
+
+func newAutoRespondCmd() *cobra.Command {
+	var (
+		once     bool
+		dryRun   bool
+		interval time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "autorespond <session>",
+		Short: "Answer agent permission prompts and gates by policy",
+		Long: `Watch a session's agent panes for blocking prompts — tool permission
+prompts ("Do you want to proceed?"), trust dialogs, onboarding screens — and
+answer them according to the auto_respond rules in ~/.ntm/policy.yaml.
+
+Rules select prompts by agent type, gate kind, tool category and a regexp on
+the requested command or path; the first match decides approve, deny or
+escalate. Approved commands are still checked against the blocked and
+approval_required patterns. Prompts no rule covers are escalated as approval
+requests (see 'ntm approve') and answered once someone decides them.
+Every decision is written to the session's audit log.
+
+Examples:
+  ntm autorespond myproject               # Run until Ctrl-C
+  ntm autorespond myproject --dry-run     # Show what would be answered
+  ntm autorespond myproject --once --json # Single scan, JSON decisions`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			session, err := useFleetSession(args[0])
+			if err != nil {
+				return err
+			}
+			if err := tmux.ValidateSessionName(session); err != nil {
+				return err
+			}
+			if interval <= 0 {
+				return fmt.Errorf("--interval must be positive")
+			}
+
+			p, err := policy.LoadForSession(session)
+			if err != nil {
+				return fmt.Errorf("loading policy: %w", err)
+			}
+
+			var engine *approval.Engine
+			if !dryRun {
+				e, store, err := getApprovalEngine()
+				if err != nil {
+					return err
+				}
+				defer store.Close()
+				engine = e
+			}
+
+			r := autorespond.New(autorespond.Config{
+				Session:   session,
+				Policy:    p,
+				Approvals: engine,
+				DryRun:    dryRun,
+			})
+
+			if once {
+				decisions, err := r.Scan(cmd.Context())
+				if err != nil {
+					return err
+				}
+				return output.New(output.WithJSON(jsonOutput)).Output(&AutoRespondResult{
+					Session:   session,
+					DryRun:    dryRun,
+					Decisions: decisions,
+				})
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			w := cmd.OutOrStdout()
+			if !jsonOutput {
+				mode := ""
+				if dryRun {
+					mode = " (dry run)"
+				}
+				fmt.Fprintf(cmd.ErrOrStderr(), "Answering prompts in %s every %s%s (Ctrl-C to stop)\n", session, interval, mode)
+			}
+			enc := json.NewEncoder(w)
+			return r.Run(ctx, interval, func(d autorespond.Decision) {
+				if jsonOutput {
+					_ = enc.Encode(d)
+					return
+				}
+				writeAutoRespondDecision(w, d)
+			})
+		},
+	}
+	cmd.Flags().BoolVar(&once, "once", false, "Scan once and exit")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Decide and audit without sending keys or filing approval requests")
+	cmd.Flags().DurationVar(&interval, "interval", 2*time.Second, "Time between scans")
+	return cmd
+}
+
+// AutoRespondResult is the output of `ntm autorespond --once`.
+type AutoRespondResult struct {
+	Session   string                 `json:"session"`
+	DryRun    bool                   `json:"dry_run,omitempty"`
+	Decisions []autorespond.Decision `json:"decisions"`
+}
+
+func (r *AutoRespondResult) Text(w io.Writer) error {
+	if len(r.Decisions) == 0 {
+		fmt.Fprintf(w, "No prompts waiting in %s.\n", r.Session)
+		return nil
+	}
+	for _, d := range r.Decisions {
+		writeAutoRespondDecision(w, d)
+	}
+	return nil
+}
+
+func (r *AutoRespondResult) JSON() interface{} {
+	if r.Decisions == nil {
+		r.Decisions = []autorespond.Decision{}
+	}
+	return r
+}
+
+func writeAutoRespondDecision(w io.Writer, d autorespond.Decision) {
+	subject := string(d.Prompt.Kind)
+	if d.Prompt.Tool != "" {
+		subject = d.Prompt.Tool
+	}
+	if d.Prompt.Command != "" {
+		subject += ": " + d.Prompt.Command
+	}
+	line := fmt.Sprintf("%s  %-10s %-9s %s", d.Time.Format("15:04:05"), d.Pane, d.Outcome, subject)
+	var notes []string
+	if d.Verdict.Reason != "" {
+		notes = append(notes, d.Verdict.Reason)
+	}
+	if d.ApprovalID != "" {
+		notes = append(notes, "approval "+d.ApprovalID)
+	}
+	if d.Error != "" {
+		notes = append(notes, "error: "+d.Error)
+	}
+	if len(notes) > 0 {
+		line += " (" + strings.Join(notes, "; ") + ")"
+	}
+	fmt.Fprintln(w, line)
+}
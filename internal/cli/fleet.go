@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Dicklesworthstone/ntm/internal/config"
+	"github.com/Dicklesworthstone/ntm/internal/fleet"
+	"github.com/Dicklesworthstone/ntm/internal/output"
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+)
+
+// loadFleet builds the host fleet declared by [[hosts]] in the active config.
+func loadFleet() (*fleet.Fleet, error) {
+	c := cfg
+	if c == nil {
+		c = config.Default()
+	}
+	return fleet.FromConfig(c)
+}
+
+// useFleetSession points tmux.DefaultClient at the host named by a
+// host-qualified session argument ("build1:myproject") and returns the bare
+// session name, the same way --ssh retargets the default client. Arguments
+// that do not name a configured host are returned unchanged.
+func useFleetSession(session string) (string, error) {
+	if cfg == nil || len(cfg.Hosts) == 0 || !strings.Contains(session, ":") {
+		return session, nil
+	}
+	f, err := loadFleet()
+	if err != nil {
+		return "", err
+	}
+	h, name, err := f.ResolveSession(session)
+	if err != nil {
+		return "", err
+	}
+	if !h.IsLocal() {
+		tmux.DefaultClient = h.Client
+	}
+	return name, nil
+}
+
+// fleetStatusResponse is the JSON output of `ntm status --fleet`.
+type fleetStatusResponse struct {
+	output.TimestampedResponse
+	fleet.Status
+}
+
+// runFleetStatus prints every session on every configured host.
+func runFleetStatus(ctx context.Context, w io.Writer) error {
+	f, err := loadFleet()
+	if err != nil {
+		if IsJSONOutput() {
+			return emitJSONFailureEnvelopeWithCause(output.NewError(err.Error()), err)
+		}
+		return err
+	}
+	defer f.Close()
+
+	resp := fleetStatusResponse{
+		TimestampedResponse: output.NewTimestamped(),
+		Status:              f.Status(ctx, ""),
+	}
+	if IsJSONOutput() {
+		return output.PrintJSON(resp)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "HOST\tTARGET\tSTATE\tSESSIONS")
+	for _, h := range resp.Hosts {
+		target := h.SSH
+		if target == "" {
+			target = "(this machine)"
+		}
+		if h.Socket != "" {
+			target += " -L " + h.Socket
+		}
+		state := "ok"
+		if !h.Reachable {
+			state = "unreachable"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\n", h.Name, strings.TrimSpace(target), state, h.Sessions)
+	}
+	tw.Flush()
+
+	if len(resp.Sessions) > 0 {
+		fmt.Fprintln(w)
+		tw = tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(tw, "SESSION\tWINDOWS\tPANES\tSTATE\tAGENTS")
+		for _, s := range resp.Sessions {
+			attached := "detached"
+			if s.Attached {
+				attached = "attached"
+			}
+			fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%d\n", s.ID, s.Windows, s.PaneCount, attached, s.AgentCount())
+		}
+		tw.Flush()
+	}
+
+	for _, h := range resp.Hosts {
+		if h.Error != "" {
+			fmt.Fprintf(w, "\n%s: %s\n", h.Name, h.Error)
+		}
+	}
+	return nil
+}
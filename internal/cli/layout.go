@@ -0,0 +1,222 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/ntm/internal/layout"
+	"github.com/Dicklesworthstone/ntm/internal/output"
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+)
+
+func newLayoutCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "layout",
+		Short: "Arrange session panes with named layouts",
+		Long: `Arrange a session's panes into windows with a named layout instead of
+splitting one window into ever thinner slivers.
+
+Built-in layouts:
+  grid           Every pane tiled in one window
+  main-vertical  User pane on the left, agents stacked on the right
+  by-agent       One tiled window per agent type
+  paged          Tiled windows of at most 6 panes
+
+Define more with [[layouts]] in config.toml (name, strategy, per_window, main,
+main_size) or a recipe's layout field. The applied layout is remembered on the
+session: ntm scale, ntm add and pane kills re-tile with it, and checkpoints
+restore it.
+
+Examples:
+  ntm layout list
+  ntm layout apply by-agent myproject
+  ntm layout apply paged                  # Current tmux session
+  ntm layout show myproject`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runLayoutList()
+		},
+	}
+	cmd.AddCommand(newLayoutListCmd())
+	cmd.AddCommand(newLayoutApplyCmd())
+	cmd.AddCommand(newLayoutShowCmd())
+	return cmd
+}
+
+// customLayouts returns the [[layouts]] definitions from config.
+func customLayouts() []layout.Spec {
+	if cfg == nil {
+		return nil
+	}
+	return cfg.Layouts
+}
+
+// applySpawnLayout arranges a freshly spawned session with opts.Layout or,
+// failing that, the configured tmux.layout. Sessions with neither keep the
+// tiled layout the panes were split into, as do sessions on a pane backend
+// without windows when no layout was asked for explicitly.
+func applySpawnLayout(ctx context.Context, opts SpawnOptions) error {
+	spec := opts.Layout
+	if spec == nil {
+		if cfg == nil || cfg.Tmux.Layout == "" || tmux.DefaultClient.Backend() != nil {
+			return nil
+		}
+		s, err := layout.Lookup(cfg.Tmux.Layout, cfg.Layouts)
+		if err != nil {
+			return err
+		}
+		spec = &s
+	}
+	_, err := layout.Apply(ctx, tmux.DefaultClient, opts.Session, *spec)
+	return err
+}
+
+// layoutSession resolves an optional session argument, defaulting to the
+// current tmux session.
+func layoutSession(args []string) (string, error) {
+	session := ""
+	if len(args) > 0 {
+		session = args[0]
+	} else {
+		session = strings.TrimSpace(tmux.GetCurrentSession())
+		if session == "" {
+			return "", fmt.Errorf("session is required outside tmux")
+		}
+	}
+	session, err := useFleetSession(session)
+	if err != nil {
+		return "", err
+	}
+	if err := tmux.ValidateSessionName(session); err != nil {
+		return "", err
+	}
+	return session, nil
+}
+
+// LayoutListResult is the output of `ntm layout list`.
+type LayoutListResult struct {
+	Layouts []layout.Spec `json:"layouts"`
+}
+
+func (r *LayoutListResult) Text(w io.Writer) error {
+	for _, l := range r.Layouts {
+		detail := string(l.Strategy)
+		if l.PerWindow > 0 {
+			detail += fmt.Sprintf(", %d per window", l.PerWindow)
+		}
+		fmt.Fprintf(w, "  %-16s %-32s %s\n", l.Name, detail, l.Description)
+	}
+	return nil
+}
+
+func (r *LayoutListResult) JSON() interface{} { return r }
+
+func newLayoutListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List built-in and configured layouts",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runLayoutList()
+		},
+	}
+}
+
+func runLayoutList() error {
+	return output.New(output.WithJSON(jsonOutput)).Output(&LayoutListResult{Layouts: layout.All(customLayouts())})
+}
+
+// LayoutResult is the output of `ntm layout apply` and `ntm layout show`.
+type LayoutResult struct {
+	Session string          `json:"session"`
+	Layout  string          `json:"layout,omitempty"`
+	Spec    *layout.Spec    `json:"spec,omitempty"`
+	Windows []layout.Window `json:"windows"`
+	Applied bool            `json:"applied"`
+}
+
+func (r *LayoutResult) Text(w io.Writer) error {
+	if r.Layout == "" {
+		fmt.Fprintf(w, "%s has no layout applied (panes are tiled).\n", r.Session)
+		return nil
+	}
+	verb := "uses"
+	if r.Applied {
+		verb = "arranged with"
+	}
+	fmt.Fprintf(w, "%s %s layout %q (%s)\n", r.Session, verb, r.Layout, r.Spec.Strategy)
+	for _, win := range r.Windows {
+		fmt.Fprintf(w, "  %-12s %-14s %s\n", win.Name, win.Arrangement, strings.Join(win.Panes, " "))
+	}
+	return nil
+}
+
+func (r *LayoutResult) JSON() interface{} {
+	if r.Windows == nil {
+		r.Windows = []layout.Window{}
+	}
+	return r
+}
+
+func newLayoutApplyCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "apply <name> [session]",
+		Short: "Arrange a session's panes with a named layout",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			spec, err := layout.Lookup(args[0], customLayouts())
+			if err != nil {
+				return err
+			}
+			session, err := layoutSession(args[1:])
+			if err != nil {
+				return err
+			}
+			plan, err := layout.Apply(cmd.Context(), tmux.DefaultClient, session, spec)
+			if err != nil {
+				return fmt.Errorf("apply layout %q: %w", spec.Name, err)
+			}
+			return output.New(output.WithJSON(jsonOutput)).Output(&LayoutResult{
+				Session: session,
+				Layout:  spec.Name,
+				Spec:    &spec,
+				Windows: plan.Windows,
+				Applied: true,
+			})
+		},
+	}
+}
+
+func newLayoutShowCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show [session]",
+		Short: "Show a session's active layout and its window plan",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			session, err := layoutSession(args)
+			if err != nil {
+				return err
+			}
+			ctx := cmd.Context()
+			result := &LayoutResult{Session: session}
+			spec, ok, err := layout.Active(ctx, tmux.DefaultClient, session)
+			if err != nil {
+				return err
+			}
+			if ok {
+				panes, err := tmux.DefaultClient.GetPanesContext(ctx, session)
+				if err != nil {
+					return err
+				}
+				result.Layout = spec.Name
+				result.Spec = &spec
+				result.Windows = spec.Plan(panes).Windows
+			}
+			return output.New(output.WithJSON(jsonOutput)).Output(result)
+		},
+	}
+}
@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Dicklesworthstone/ntm/internal/config"
+	"github.com/Dicklesworthstone/ntm/internal/layout"
+)
+
+func TestLayoutListIncludesConfiguredLayouts(t *testing.T) {
+	oldCfg := cfg
+	t.Cleanup(func() { cfg = oldCfg })
+	cfg = config.Default()
+	cfg.Layouts = []layout.Spec{{Name: "wide", Strategy: layout.StrategyMainVertical, PerWindow: 8, Description: "Wide main pane"}}
+
+	var buf bytes.Buffer
+	if err := (&LayoutListResult{Layouts: layout.All(customLayouts())}).Text(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{"grid", "by-agent", "paged", "wide", "main-vertical, 8 per window", "Wide main pane"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("layout list missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestApplySpawnLayoutWithoutLayoutIsNoop(t *testing.T) {
+	oldCfg := cfg
+	t.Cleanup(func() { cfg = oldCfg })
+	cfg = config.Default()
+
+	if err := applySpawnLayout(context.Background(), SpawnOptions{Session: "no-such-session"}); err != nil {
+		t.Errorf("applySpawnLayout without a layout = %v", err)
+	}
+	cfg.Tmux.Layout = "missing"
+	if err := applySpawnLayout(context.Background(), SpawnOptions{Session: "no-such-session"}); err == nil {
+		t.Error("unknown tmux.layout accepted")
+	}
+}
+
+func TestLayoutResultText(t *testing.T) {
+	var buf bytes.Buffer
+	_ = (&LayoutResult{Session: "proj"}).Text(&buf)
+	if !strings.Contains(buf.String(), "no layout applied") {
+		t.Errorf("text = %q", buf.String())
+	}
+
+	spec := layout.Builtins()[2]
+	buf.Reset()
+	_ = (&LayoutResult{
+		Session: "proj",
+		Layout:  spec.Name,
+		Spec:    &spec,
+		Windows: []layout.Window{{Name: "cc", Panes: []string{"%1", "%2"}, Arrangement: "tiled"}},
+		Applied: true,
+	}).Text(&buf)
+	if !strings.Contains(buf.String(), `arranged with layout "by-agent"`) || !strings.Contains(buf.String(), "%1 %2") {
+		t.Errorf("text = %q", buf.String())
+	}
+}
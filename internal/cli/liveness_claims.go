@@ -6,6 +6,7 @@ import (
 	"github.com/Dicklesworthstone/ntm/internal/encryption"
 	"github.com/Dicklesworthstone/ntm/internal/hooks"
 	"github.com/Dicklesworthstone/ntm/internal/redaction"
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
 )
 
 // WS0-G2 config-key liveness claims for keys consumed by internal/cli
@@ -47,6 +48,22 @@ func init() {
 	config.RegisterReader("tmux.default_panes", runCreate)
 	config.RegisterReader("tmux.history_limit", runCreate)
 	config.RegisterReader("tmux.pane_init_delay_ms", executeAdd)
+	// Control-mode transport opt-in (root.go).
+	config.RegisterReader("tmux.control_mode", (*tmux.Client).SetControlMode)
+	// Named pane layouts (layout.go, spawn.go).
+	config.RegisterReader("tmux.layout", applySpawnLayout)
+	// Pane backend selection (pty.go, root.go).
+	config.RegisterReader("tmux.backend", applyPaneBackend)
+	for _, key := range []string{
+		"layouts.name",
+		"layouts.description",
+		"layouts.strategy",
+		"layouts.per_window",
+		"layouts.main",
+		"layouts.main_size",
+	} {
+		config.RegisterReader(key, customLayouts)
+	}
 
 	// Top-level knobs.
 	config.RegisterReader("palette_file", paletteWatchPaths)
@@ -260,4 +277,42 @@ func init() {
 	config.RegisterReader("ensemble.budget.total", applyRobotEnsembleConfigDefaults)
 	config.RegisterReader("ensemble.budget.per_agent", applyRobotEnsembleConfigDefaults)
 	config.RegisterReader("ensemble.cache.enabled", applyRobotEnsembleConfigDefaults)
+
+	// Native approval quorums (approve.go, serve.go): approvalPolicy converts
+	// the [approvals] tables into the approval engine's Policy.
+	for _, key := range []string{
+		"approvals.groups.name",
+		"approvals.groups.members",
+		"approvals.groups.roles",
+		"approvals.rules.action",
+		"approvals.rules.slb",
+		"approvals.rules.quorum.group",
+		"approvals.rules.quorum.count",
+		"approvals.rules.escalation.after",
+		"approvals.rules.escalation.notify",
+		"approvals.rules.timeout",
+		"approvals.rules.on_timeout",
+		"approvals.delegations.from",
+		"approvals.delegations.to",
+		"approvals.delegations.until",
+	} {
+		config.RegisterReader(key, approvalPolicy)
+	}
+
+	// Audit checkpoint signing (root.go PersistentPreRunE → newAuditAnchorer)
+	// and the mirrors verification also reads (audit.go).
+	for _, key := range []string{
+		"audit.anchor.enabled",
+		"audit.anchor.interval_seconds",
+		"audit.anchor.key_source",
+		"audit.anchor.key_env",
+		"audit.anchor.key_file",
+		"audit.anchor.key_command",
+		"audit.anchor.key_format",
+	} {
+		config.RegisterReader(key, newAuditAnchorer)
+	}
+	config.RegisterReader("audit.anchor.mirror_dir", auditAnchorMirrors)
+	config.RegisterReader("audit.anchor.git_notes_repo", auditAnchorMirrors)
+	config.RegisterReader("audit.anchor.git_notes_ref", auditAnchorMirrors)
 }
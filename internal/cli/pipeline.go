@@ -17,6 +17,7 @@ import (
 
 	"github.com/Dicklesworthstone/ntm/internal/output"
 	"github.com/Dicklesworthstone/ntm/internal/pipeline"
+	"github.com/Dicklesworthstone/ntm/internal/pressure"
 	"github.com/Dicklesworthstone/ntm/internal/tmux"
 )
 
@@ -37,6 +38,9 @@ Subcommands:
   list     List all tracked pipelines
   cancel   Cancel a running pipeline
   cleanup  Remove old pipeline state files
+  artifacts List or download files archived by a run
+  graph    Export a workflow or run as a DOT, Mermaid or JSON graph
+  schedule Start workflows on cron, interval or event triggers
 
 Quick ad-hoc pipeline:
   ntm pipeline exec <session> --stage "cc: prompt" --stage "cod: prompt"
@@ -75,6 +79,9 @@ Examples:
 		newPipelineCancelCmd(),
 		newPipelineResumeCmd(),
 		newPipelineCleanupCmd(),
+		newPipelineArtifactsCmd(),
+		newPipelineGraphCmd(),
+		newPipelineScheduleCmd(),
 		newPipelineExecCmd(), // Backward-compatible stage-based execution
 	)
 
@@ -241,6 +248,7 @@ func newPipelineRunCmd() *cobra.Command {
 		background    bool
 		startFromStep string
 		fromState     string
+		noCache       bool
 	)
 
 	cmd := &cobra.Command{
@@ -262,7 +270,10 @@ Examples:
   ntm pipeline run workflow.yaml --session proj --dry-run
 
   # Run in background
-  ntm pipeline run workflow.yaml --session proj --background`,
+  ntm pipeline run workflow.yaml --session proj --background
+
+  # Re-run steps marked cache: instead of reusing earlier results
+  ntm pipeline run workflow.yaml --session proj --no-cache`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			workflowFile := args[0]
@@ -307,6 +318,7 @@ Examples:
 					Background:    background,
 					StartFromStep: startFromStep,
 					FromState:     fromState,
+					NoCache:       noCache,
 				}
 				exitCode := pipeline.PrintPipelineRun(opts)
 				if exitCode != 0 {
@@ -376,8 +388,10 @@ Examples:
 			// Create executor
 			execCfg := pipeline.DefaultExecutorConfig(session)
 			execCfg.DryRun = dryRun
+			execCfg.NoCache = noCache
 			execCfg.ProjectDir = projectDir
 			execCfg.WorkflowFile = workflowPath
+			execCfg.Pressure = pressure.NewSystemGovernor(pressure.ModeEnforce)
 			if startFromStep != "" {
 				execCfg.StartFromStep = startFromStep
 				if fromState != "" {
@@ -464,6 +478,7 @@ Examples:
 	cmd.Flags().BoolVarP(&background, "background", "b", false, "Run in background")
 	cmd.Flags().StringVar(&startFromStep, "start-from", "", "Begin execution at the given step ID; transitive dependencies are marked Skipped")
 	cmd.Flags().StringVar(&fromState, "from-state", "", "Run ID whose persisted outputs should be reused for steps skipped by --start-from")
+	cmd.Flags().BoolVar(&noCache, "no-cache", false, "Re-run cache-enabled steps instead of reusing cached results")
 
 	return cmd
 }
@@ -752,6 +767,7 @@ Examples:
 			execCfg.ProjectDir = projectDir
 			execCfg.WorkflowFile = workflowFile
 			execCfg.ResumeOptions = resumeOpts
+			execCfg.Pressure = pressure.NewSystemGovernor(pressure.ModeEnforce)
 			executor := pipeline.NewExecutor(execCfg)
 
 			state.WorkflowFile = workflowFile
@@ -1119,6 +1135,8 @@ func printProgressEvent(event pipeline.ProgressEvent) {
 		fmt.Printf("  ↻ [%s] %s\n", event.StepID, event.Message)
 	case "parallel_start":
 		fmt.Printf("  ⫘ [%s] %s\n", event.StepID, event.Message)
+	case "approval_waiting":
+		fmt.Printf("  ⏸ [%s] %s\n", event.StepID, event.Message)
 	default:
 		if event.StepID != "" {
 			fmt.Printf("  • [%s] %s\n", event.StepID, event.Message)
@@ -1174,10 +1192,15 @@ func showPipelineStatus(runID string) error {
 				status = "✗ failed"
 			case "running":
 				status = "▶ running"
+			case "waiting_approval":
+				status = "⏸ waiting for approval"
 			case "skipped":
 				status = "⊘ skipped"
 			}
 			line := fmt.Sprintf("  [%s] %s", id, status)
+			if step.Cache.IsHit() {
+				line += " (cached from " + step.Cache.SourceRunID + ")"
+			}
 			if step.SkipKind != "" {
 				line += fmt.Sprintf(" (%s)", step.SkipKind)
 			}
@@ -1218,6 +1241,8 @@ func showPipelineList() error {
 			status = "✗ failed"
 		case "running":
 			status = "▶ running"
+		case "waiting_approval":
+			status = "⏸ waiting for approval"
 		case "cancelled":
 			status = "⊘ cancelled"
 		}
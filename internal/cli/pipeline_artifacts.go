@@ -0,0 +1,167 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/ntm/internal/output"
+	"github.com/Dicklesworthstone/ntm/internal/pipeline"
+)
+
+// newPipelineArtifactsCmd creates the "pipeline artifacts" subcommand.
+func newPipelineArtifactsCmd() *cobra.Command {
+	var file string
+	var outputDir string
+
+	cmd := &cobra.Command{
+		Use:   "artifacts <run-id> [step/name]",
+		Short: "List or download files archived by a pipeline run",
+		Long: `List the artifacts a pipeline run archived, or copy them out of the
+run's artifact store.
+
+Steps declare artifacts with an artifacts: list of paths or globs. When the
+step completes the matching files are copied to
+.ntm/pipelines/<run-id>/artifacts/<step>/<name>/, where they survive later
+changes to the working tree.
+
+Examples:
+  # List everything a run archived
+  ntm pipeline artifacts run-20241230-123456-abcd
+
+  # Copy one artifact's files into ./review-out
+  ntm pipeline artifacts run-20241230-123456-abcd review/patches -o review-out
+
+  # Copy a single file of a multi-file artifact
+  ntm pipeline artifacts run-20241230-123456-abcd review/patches --file out/1.patch`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			selector := ""
+			if len(args) == 2 {
+				selector = args[1]
+			}
+			return runPipelineArtifacts(args[0], selector, file, outputDir, IsJSONOutput())
+		},
+	}
+
+	cmd.Flags().StringVar(&file, "file", "", "Only the archived file with this path (with step/name)")
+	cmd.Flags().StringVarP(&outputDir, "output", "o", ".", "Directory to copy artifacts into (with step/name)")
+
+	return cmd
+}
+
+func runPipelineArtifacts(runID, selector, file, outputDir string, jsonOutput bool) error {
+	projectDir := GetProjectRoot()
+	if projectDir == "" {
+		return outputError(fmt.Errorf("getting project root failed"), jsonOutput)
+	}
+	artifacts, err := pipeline.ListRunArtifacts(projectDir, runID)
+	if err != nil {
+		return outputError(err, jsonOutput)
+	}
+
+	if selector == "" {
+		if file != "" {
+			return outputError(fmt.Errorf("--file requires a step/name argument"), jsonOutput)
+		}
+		return printPipelineArtifacts(runID, artifacts, jsonOutput)
+	}
+
+	stepID, name, ok := strings.Cut(selector, "/")
+	if !ok || stepID == "" || name == "" {
+		return outputError(fmt.Errorf("invalid artifact %q: expected step/name", selector), jsonOutput)
+	}
+	var selected []pipeline.RunArtifact
+	for _, a := range artifacts {
+		if a.Step == stepID && a.Name == name && (file == "" || a.File == file) {
+			selected = append(selected, a)
+		}
+	}
+	if len(selected) == 0 {
+		return outputError(fmt.Errorf("%w: %s in run %s", pipeline.ErrArtifactNotFound, selector, runID), jsonOutput)
+	}
+
+	var written []string
+	for _, a := range selected {
+		_, src, err := pipeline.ResolveRunArtifact(projectDir, runID, a.Step, a.Name, a.File)
+		if err != nil {
+			return outputError(err, jsonOutput)
+		}
+		rel := filepath.FromSlash(a.File)
+		if !filepath.IsLocal(rel) {
+			return outputError(fmt.Errorf("artifact file %q escapes the output directory", a.File), jsonOutput)
+		}
+		dst := filepath.Join(outputDir, rel)
+		if err := copyArtifactFile(src, dst); err != nil {
+			return outputError(err, jsonOutput)
+		}
+		written = append(written, dst)
+	}
+
+	if jsonOutput {
+		return json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+			"success": true,
+			"run_id":  runID,
+			"files":   written,
+		})
+	}
+	for _, path := range written {
+		fmt.Println(path)
+	}
+	output.SuccessCheck(fmt.Sprintf("Copied %d file(s) from %s", len(written), selector))
+	return nil
+}
+
+func printPipelineArtifacts(runID string, artifacts []pipeline.RunArtifact, jsonOutput bool) error {
+	if jsonOutput {
+		if artifacts == nil {
+			artifacts = []pipeline.RunArtifact{}
+		}
+		return json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+			"success":   true,
+			"run_id":    runID,
+			"artifacts": artifacts,
+			"count":     len(artifacts),
+		})
+	}
+
+	fmt.Printf("Artifacts for %s:\n", runID)
+	if len(artifacts) == 0 {
+		fmt.Println("  (none; steps archive files with an artifacts: list)")
+		return nil
+	}
+	current := ""
+	for _, a := range artifacts {
+		if key := a.Step + "/" + a.Name; key != current {
+			current = key
+			fmt.Printf("\n  %s\n", key)
+		}
+		fmt.Printf("    %-40s %s\n", a.File, formatBytes(a.Size))
+	}
+	return nil
+}
+
+func copyArtifactFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("open archived artifact: %w", err)
+	}
+	defer in.Close()
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("create output dir: %w", err)
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", dst, err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return fmt.Errorf("copy %s: %w", dst, err)
+	}
+	return out.Close()
+}
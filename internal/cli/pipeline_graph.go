@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/ntm/internal/output"
+	"github.com/Dicklesworthstone/ntm/internal/pipeline"
+)
+
+// newPipelineGraphCmd creates the "pipeline graph" subcommand.
+func newPipelineGraphCmd() *cobra.Command {
+	var format string
+	var outputFile string
+
+	cmd := &cobra.Command{
+		Use:   "graph <workflow-file|run-id>",
+		Short: "Export a workflow or run as a DOT, Mermaid or JSON graph",
+		Long: `Render the step graph of a workflow file, or of a pipeline run with each
+step coloured by the status it reached.
+
+Parallel groups, loops, foreach and matrix steps are drawn as clusters. For a
+run they contain the iterations that actually executed; for a workflow file
+they contain the body definition. Branch steps link to each branch body,
+labelled with its key.
+
+Formats:
+  dot      Graphviz DOT (default)
+  mermaid  Mermaid flowchart
+  json     Nodes, edges and concurrency levels
+
+Examples:
+  # Render a workflow with Graphviz
+  ntm pipeline graph review.yaml | dot -Tsvg > review.svg
+
+  # Mermaid graph of a run, with step status
+  ntm pipeline graph run-20241230-123456-abcd --format mermaid
+
+  # JSON graph written to a file
+  ntm pipeline graph review.yaml --format json -o review-graph.json`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if IsJSONOutput() && !cmd.Flags().Changed("format") {
+				format = pipeline.GraphFormatJSON
+			}
+			return runPipelineGraph(args[0], format, outputFile, IsJSONOutput())
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", pipeline.GraphFormatDOT, "Output format: dot, mermaid, json")
+	cmd.Flags().StringVarP(&outputFile, "output", "o", "", "Write the graph to a file instead of stdout")
+
+	return cmd
+}
+
+func runPipelineGraph(target, format, outputFile string, jsonOutput bool) error {
+	graph, err := loadPipelineGraph(target)
+	if err != nil {
+		return outputError(err, jsonOutput)
+	}
+	rendered, err := graph.Render(format)
+	if err != nil {
+		return outputError(err, jsonOutput)
+	}
+
+	if outputFile == "" {
+		fmt.Print(rendered)
+		return nil
+	}
+	if err := os.WriteFile(outputFile, []byte(rendered), 0644); err != nil {
+		return outputError(fmt.Errorf("write graph: %w", err), jsonOutput)
+	}
+	if !jsonOutput {
+		output.SuccessCheck(fmt.Sprintf("Wrote %s graph (%d steps) to %s", strings.ToLower(format), len(graph.Nodes), outputFile))
+	}
+	return nil
+}
+
+// loadPipelineGraph treats target as a workflow file when it names an
+// existing file or has a workflow extension, and as a run ID otherwise.
+func loadPipelineGraph(target string) (*pipeline.RunGraph, error) {
+	ext := strings.ToLower(filepath.Ext(target))
+	if info, err := os.Stat(target); (err == nil && !info.IsDir()) || ext == ".yaml" || ext == ".yml" || ext == ".toml" {
+		workflow, _, err := pipeline.LoadAndValidate(target)
+		if err != nil {
+			return nil, err
+		}
+		return pipeline.BuildRunGraph(workflow, nil), nil
+	}
+
+	projectDir := GetProjectRoot()
+	if projectDir == "" {
+		return nil, fmt.Errorf("getting project root failed")
+	}
+	return pipeline.LoadRunGraph(projectDir, target)
+}
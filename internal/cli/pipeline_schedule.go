@@ -0,0 +1,360 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/ntm/internal/events"
+	"github.com/Dicklesworthstone/ntm/internal/scheduler"
+	"github.com/Dicklesworthstone/ntm/internal/state"
+)
+
+func newPipelineScheduleCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schedule",
+		Short: "Start workflows on cron, interval or event triggers",
+		Long: `Manage persisted pipeline schedules.
+
+A schedule starts a workflow file against a session when its trigger fires:
+  --cron   a five-field cron expression or @daily/@hourly/... macro
+  --every  a fixed interval (minimum 1m), e.g. 30m, 6h, 1d
+  --on     an event-bus event type, e.g. agent_error, bead.closed
+
+Schedules are stored in the state DB and fired by the scheduler hosted in
+` + "`ntm serve`" + `, or by ` + "`ntm pipeline schedule daemon`" + ` when no server is running.
+Changes apply on the scheduler's next tick; no restart is needed.
+
+When a schedule fires while its previous run is still active, --overlap
+decides: skip (default) drops the firing, queue runs it once the previous run
+ends, cancel_previous cancels the active run and starts a new one.
+--jitter delays each firing by a random amount up to the given duration.
+
+Runs receive ${vars.schedule.name} and ${vars.schedule.trigger}; event
+triggers also receive ${vars.event.type} and ${vars.event.session}.
+
+Examples:
+  ntm pipeline schedule add nightly-audit audit.yaml --session proj --cron "0 2 * * *" --jitter 10m
+  ntm pipeline schedule add triage triage.yaml --session proj --every 6h --overlap queue
+  ntm pipeline schedule add on-error recover.yaml --session proj --on agent_error --event-session proj
+  ntm pipeline schedule list
+  ntm pipeline schedule pause nightly-audit
+  ntm pipeline schedule remove nightly-audit`,
+	}
+	cmd.AddCommand(
+		newPipelineScheduleAddCmd(),
+		newPipelineScheduleListCmd(),
+		newPipelineSchedulePauseCmd(true),
+		newPipelineSchedulePauseCmd(false),
+		newPipelineScheduleRemoveCmd(),
+		newPipelineScheduleDaemonCmd(),
+	)
+	return cmd
+}
+
+func newPipelineScheduleAddCmd() *cobra.Command {
+	var (
+		sched         state.PipelineSchedule
+		every, jitter string
+		varsFlag      []string
+		varsFile      string
+	)
+	cmd := &cobra.Command{
+		Use:   "add <name> <workflow-file>",
+		Short: "Create a schedule",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			jsonOutput := IsJSONOutput()
+			sched.Name = args[0]
+			workflowPath, err := filepath.Abs(args[1])
+			if err != nil {
+				return outputError(fmt.Errorf("resolve workflow file: %w", err), jsonOutput)
+			}
+			if _, err := os.Stat(workflowPath); err != nil {
+				return outputError(fmt.Errorf("workflow file: %w", err), jsonOutput)
+			}
+			sched.WorkflowFile = workflowPath
+			if sched.ProjectDir, err = os.Getwd(); err != nil {
+				return outputError(fmt.Errorf("resolve project directory: %w", err), jsonOutput)
+			}
+			if sched.IntervalSeconds, err = scheduler.DurationSeconds(every); err != nil {
+				return outputError(fmt.Errorf("invalid --every %q: %w", every, err), jsonOutput)
+			}
+			if sched.JitterSeconds, err = scheduler.DurationSeconds(jitter); err != nil {
+				return outputError(fmt.Errorf("invalid --jitter %q: %w", jitter, err), jsonOutput)
+			}
+			vars, err := parsePipelineRunVariables(varsFile, varsFlag)
+			if err != nil {
+				return outputError(err, jsonOutput)
+			}
+			if len(vars) > 0 {
+				sched.Variables = vars
+			}
+			return runPipelineScheduleAdd(&sched, jsonOutput)
+		},
+	}
+	cmd.Flags().StringVarP(&sched.Session, "session", "s", "", "Tmux session the workflow runs against (required)")
+	cmd.Flags().StringVar(&sched.CronExpr, "cron", "", "Cron expression, e.g. \"0 2 * * *\" or @daily")
+	cmd.Flags().StringVar(&sched.Timezone, "tz", "", "IANA timezone for --cron (default: local)")
+	cmd.Flags().StringVar(&every, "every", "", "Fixed interval, e.g. 30m, 6h, 1d")
+	cmd.Flags().StringVar(&sched.EventType, "on", "", "Event type that triggers a run, e.g. agent_error")
+	cmd.Flags().StringVar(&sched.EventSession, "event-session", "", "Only trigger on events from this session")
+	cmd.Flags().StringVar(&sched.OverlapPolicy, "overlap", scheduler.OverlapSkip, "When the previous run is still active: skip|queue|cancel_previous")
+	cmd.Flags().StringVar(&jitter, "jitter", "", "Random delay added to each firing, up to this duration")
+	cmd.Flags().BoolVar(&sched.Paused, "paused", false, "Create the schedule paused")
+	cmd.Flags().StringArrayVar(&varsFlag, "var", nil, "Variable in key=value format")
+	cmd.Flags().StringVar(&varsFile, "var-file", "", "JSON file with variables")
+	return cmd
+}
+
+func runPipelineScheduleAdd(sched *state.PipelineSchedule, jsonOutput bool) error {
+	store, err := openMigratedStateStore()
+	if err != nil {
+		return outputError(err, jsonOutput)
+	}
+	defer store.Close()
+
+	if err := scheduler.Create(store, sched); err != nil {
+		return outputError(err, jsonOutput)
+	}
+	if jsonOutput {
+		return json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+			"success":  true,
+			"schedule": sched,
+		})
+	}
+	fmt.Printf("✓ Created schedule %s (%s)\n", sched.Name, sched.ID)
+	printPipelineSchedule(sched)
+	if next, err := scheduler.NextFire(sched, time.Now()); err == nil && !next.IsZero() {
+		fmt.Printf("    Next run:  %s\n", next.Format(time.RFC3339))
+	}
+	return nil
+}
+
+func newPipelineScheduleListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List schedules",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPipelineScheduleList(IsJSONOutput())
+		},
+	}
+}
+
+func runPipelineScheduleList(jsonOutput bool) error {
+	store, err := openMigratedStateStore()
+	if err != nil {
+		return outputError(err, jsonOutput)
+	}
+	defer store.Close()
+
+	schedules, err := store.ListPipelineSchedules()
+	if err != nil {
+		return outputError(err, jsonOutput)
+	}
+	if jsonOutput {
+		if schedules == nil {
+			schedules = []state.PipelineSchedule{}
+		}
+		return json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+			"success":   true,
+			"schedules": schedules,
+			"count":     len(schedules),
+		})
+	}
+
+	fmt.Println("Pipeline Schedules:")
+	if len(schedules) == 0 {
+		fmt.Println("  (none; create one with `ntm pipeline schedule add`)")
+		return nil
+	}
+	for i := range schedules {
+		sched := &schedules[i]
+		status := "active"
+		if sched.Paused {
+			status = "paused"
+		}
+		fmt.Printf("\n  %s [%s] %s\n", sched.Name, status, sched.ID)
+		printPipelineSchedule(sched)
+		if sched.NextRunAt != nil && !sched.Paused {
+			fmt.Printf("    Next run:  %s\n", sched.NextRunAt.Format(time.RFC3339))
+		}
+		if sched.LastFiredAt != nil {
+			fmt.Printf("    Last run:  %s %s\n", sched.LastFiredAt.Format(time.RFC3339), sched.LastRunID)
+		}
+		if sched.Queued > 0 {
+			fmt.Printf("    Queued:    %d\n", sched.Queued)
+		}
+		if sched.LastError != "" {
+			fmt.Printf("    Last error: %s\n", sched.LastError)
+		}
+	}
+	return nil
+}
+
+func printPipelineSchedule(sched *state.PipelineSchedule) {
+	fmt.Printf("    Workflow:  %s\n", sched.WorkflowFile)
+	fmt.Printf("    Session:   %s\n", sched.Session)
+	switch sched.TriggerKind {
+	case scheduler.TriggerCron:
+		trigger := "cron " + sched.CronExpr
+		if sched.Timezone != "" {
+			trigger += " (" + sched.Timezone + ")"
+		}
+		fmt.Printf("    Trigger:   %s\n", trigger)
+	case scheduler.TriggerInterval:
+		fmt.Printf("    Trigger:   every %s\n", time.Duration(sched.IntervalSeconds)*time.Second)
+	case scheduler.TriggerEvent:
+		trigger := "on " + sched.EventType
+		if sched.EventSession != "" {
+			trigger += " from " + sched.EventSession
+		}
+		fmt.Printf("    Trigger:   %s\n", trigger)
+	}
+	policy := "overlap " + sched.OverlapPolicy
+	if sched.JitterSeconds > 0 {
+		policy += fmt.Sprintf(", jitter %s", time.Duration(sched.JitterSeconds)*time.Second)
+	}
+	fmt.Printf("    Policy:    %s\n", policy)
+}
+
+func newPipelineSchedulePauseCmd(pause bool) *cobra.Command {
+	use, short, verb := "pause <name|id>", "Pause a schedule", "Paused"
+	if !pause {
+		use, short, verb = "resume <name|id>", "Resume a paused schedule", "Resumed"
+	}
+	return &cobra.Command{
+		Use:   use,
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			jsonOutput := IsJSONOutput()
+			store, err := openMigratedStateStore()
+			if err != nil {
+				return outputError(err, jsonOutput)
+			}
+			defer store.Close()
+
+			sched, err := lookupPipelineSchedule(store, args[0])
+			if err != nil {
+				return outputError(err, jsonOutput)
+			}
+			if err := store.SetPipelineSchedulePaused(sched.ID, pause, time.Now().UTC()); err != nil {
+				return outputError(err, jsonOutput)
+			}
+			if jsonOutput {
+				return json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+					"success": true,
+					"id":      sched.ID,
+					"name":    sched.Name,
+					"paused":  pause,
+				})
+			}
+			fmt.Printf("✓ %s: %s\n", verb, sched.Name)
+			return nil
+		},
+	}
+}
+
+func newPipelineScheduleRemoveCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "remove <name|id>",
+		Aliases: []string{"rm"},
+		Short:   "Delete a schedule",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			jsonOutput := IsJSONOutput()
+			store, err := openMigratedStateStore()
+			if err != nil {
+				return outputError(err, jsonOutput)
+			}
+			defer store.Close()
+
+			sched, err := lookupPipelineSchedule(store, args[0])
+			if err != nil {
+				return outputError(err, jsonOutput)
+			}
+			if err := store.DeletePipelineSchedule(sched.ID); err != nil {
+				return outputError(err, jsonOutput)
+			}
+			if jsonOutput {
+				return json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+					"success": true,
+					"id":      sched.ID,
+					"name":    sched.Name,
+					"deleted": true,
+				})
+			}
+			fmt.Printf("✓ Removed: %s\n", sched.Name)
+			return nil
+		},
+	}
+}
+
+func newPipelineScheduleDaemonCmd() *cobra.Command {
+	var tick time.Duration
+	cmd := &cobra.Command{
+		Use:   "daemon",
+		Short: "Run the scheduler in the foreground without ntm serve",
+		Long: `Run the pipeline scheduler in the foreground until interrupted.
+
+Use this under a process supervisor when ` + "`ntm serve`" + ` is not running. Do not
+run it alongside a server that shares the same state DB, or schedules fire
+twice. Event triggers only see events published inside this process; host
+event-driven schedules in ` + "`ntm serve`" + `.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openMigratedStateStore()
+			if err != nil {
+				return err
+			}
+			defer store.Close()
+
+			projectDir, err := os.Getwd()
+			if err != nil {
+				return fmt.Errorf("resolve project directory: %w", err)
+			}
+			sched := scheduler.New(scheduler.Config{
+				Store:  store,
+				Runner: scheduler.PipelineRunner{ProjectDir: projectDir},
+				Tick:   tick,
+			})
+			unsubscribe := events.SubscribeAll(sched.HandleEvent)
+			defer unsubscribe()
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			fmt.Fprintf(cmd.ErrOrStderr(), "pipeline scheduler running (tick %s); Ctrl+C to stop\n", durationOrDefault(tick, scheduler.DefaultTick))
+			sched.Run(ctx)
+			return nil
+		},
+	}
+	cmd.Flags().DurationVar(&tick, "tick", scheduler.DefaultTick, "How often schedules are re-read and evaluated")
+	return cmd
+}
+
+func lookupPipelineSchedule(store *state.Store, ref string) (*state.PipelineSchedule, error) {
+	sched, err := store.GetPipelineSchedule(strings.TrimSpace(ref))
+	if err != nil {
+		return nil, err
+	}
+	if sched == nil {
+		return nil, fmt.Errorf("schedule %q not found", ref)
+	}
+	return sched, nil
+}
+
+func durationOrDefault(d, def time.Duration) time.Duration {
+	if d <= 0 {
+		return def
+	}
+	return d
+}
@@ -29,7 +29,9 @@ The policy system controls:
 Use 'ntm policy show' to see the current policy.
 Use 'ntm policy validate' to check policy file syntax.
 Use 'ntm policy reset' to reset to defaults.
-Use 'ntm policy edit' to open in your editor.`,
+Use 'ntm policy edit' to open in your editor.
+Use 'ntm policy explain <command>' to see why each rule did or did not apply.
+Use 'ntm policy keygen' and 'ntm policy sign' to sign the org baseline.`,
 	}
 
 	cmd.AddCommand(
@@ -38,23 +40,42 @@ Use 'ntm policy edit' to open in your editor.`,
 		newPolicyResetCmd(),
 		newPolicyEditCmd(),
 		newPolicyAutomationCmd(),
+		newPolicyExplainCmd(),
+		newPolicyKeygenCmd(),
+		newPolicySignCmd(),
 	)
 
 	return cmd
 }
 
 func newPolicyShowCmd() *cobra.Command {
-	var showAll bool
+	var showAll, effective bool
+	var session string
 
 	cmd := &cobra.Command{
 		Use:   "show",
 		Short: "Display current policy configuration",
+		Long: `Display the effective policy: the merge of every policy layer that exists.
+
+Layers, most general first:
+  system   /etc/ntm/policy.yaml (org baseline, optionally signed)
+  user     ~/.ntm/policy.yaml
+  project  .ntm/policy.yaml
+  session  .ntm/policies/<session>.yaml
+
+Blocked and approval-required rules from every layer apply. An allowed rule
+only lifts restrictive rules from its own layer, or from a more general layer
+when that rule is marked overridable.
+
+Use --effective to list every rule with the layer and file it came from.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runPolicyShow(showAll)
+			return runPolicyShow(showAll, effective, session)
 		},
 	}
 
 	cmd.Flags().BoolVarP(&showAll, "all", "a", false, "Show all rules including patterns")
+	cmd.Flags().BoolVar(&effective, "effective", false, "Show all rules with the layer and file each came from")
+	cmd.Flags().StringVar(&session, "session", "", "Include the session layer for this session (default: $NTM_SESSION)")
 
 	return cmd
 }
@@ -65,6 +86,7 @@ type PolicyShowResponse struct {
 	Version    int                     `json:"version"`
 	PolicyPath string                  `json:"policy_path,omitempty"`
 	IsDefault  bool                    `json:"is_default"`
+	Layers     []policy.Layer          `json:"layers,omitempty"`
 	Stats      PolicyStats             `json:"stats"`
 	Automation policy.AutomationConfig `json:"automation"`
 	Rules      *PolicyRulesDetail      `json:"rules,omitempty"`
@@ -72,10 +94,11 @@ type PolicyShowResponse struct {
 
 // PolicyStats contains rule counts.
 type PolicyStats struct {
-	Blocked  int `json:"blocked"`
-	Approval int `json:"approval"`
-	Allowed  int `json:"allowed"`
-	SLBRules int `json:"slb_rules"`
+	Blocked     int `json:"blocked"`
+	Approval    int `json:"approval"`
+	Allowed     int `json:"allowed"`
+	SLBRules    int `json:"slb_rules"`
+	AutoRespond int `json:"auto_respond"`
 }
 
 // PolicyRulesDetail contains detailed rule information.
@@ -87,24 +110,32 @@ type PolicyRulesDetail struct {
 
 // RuleSummary is a simplified rule representation.
 type RuleSummary struct {
-	Pattern string `json:"pattern"`
-	Reason  string `json:"reason,omitempty"`
-	SLB     bool   `json:"slb,omitempty"`
+	Pattern     string `json:"pattern"`
+	When        string `json:"when,omitempty"`
+	Reason      string `json:"reason,omitempty"`
+	SLB         bool   `json:"slb,omitempty"`
+	Overridable bool   `json:"overridable,omitempty"`
+	// Source is set by policy show --effective.
+	Source *policy.Source `json:"source,omitempty"`
 }
 
-func runPolicyShow(showAll bool) error {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return fmt.Errorf("getting home directory: %w", err)
+func runPolicyShow(showAll, effective bool, session string) error {
+	if session == "" {
+		session = strings.TrimSpace(os.Getenv("NTM_SESSION"))
 	}
-	policyPath := filepath.Join(home, ".ntm", "policy.yaml")
-
-	p, err := policy.LoadOrDefault()
+	p, err := policy.LoadForSession(session)
 	if err != nil {
 		return fmt.Errorf("loading policy: %w", err)
 	}
+	// Writers such as 'ntm policy automation' update this file.
+	policyPath, _, err := policy.ResolveEffectivePath()
+	if err != nil {
+		return err
+	}
 
-	isDefault := !fileExists(policyPath)
+	layers := p.Layers()
+	isDefault := len(layers) == 0
+	showAll = showAll || effective
 	blocked, approval, allowed := p.Stats()
 
 	// Count SLB rules
@@ -120,12 +151,15 @@ func runPolicyShow(showAll bool) error {
 			TimestampedResponse: output.NewTimestamped(),
 			Version:             p.Version,
 			IsDefault:           isDefault,
+			Layers:              layers,
 			Automation:          p.Automation,
 			Stats: PolicyStats{
 				Blocked:  blocked,
 				Approval: approval,
 				Allowed:  allowed,
 				SLBRules: slbCount,
+
+				AutoRespond: len(p.AutoRespond),
 			},
 		}
 		if !isDefault {
@@ -138,6 +172,11 @@ func runPolicyShow(showAll bool) error {
 				ApprovalRequired: toRuleSummaries(p.ApprovalRequired),
 				Allowed:          toRuleSummaries(p.Allowed),
 			}
+			if effective {
+				addRuleSources(resp.Rules.Blocked, p.Blocked)
+				addRuleSources(resp.Rules.ApprovalRequired, p.ApprovalRequired)
+				addRuleSources(resp.Rules.Allowed, p.Allowed)
+			}
 		}
 
 		return output.PrintJSON(resp)
@@ -160,7 +199,14 @@ func runPolicyShow(showAll bool) error {
 	if isDefault {
 		fmt.Printf("  %s %s\n", labelStyle.Render("Source:"), mutedStyle.Render("Default policy (no custom file)"))
 	} else {
-		fmt.Printf("  %s %s\n", labelStyle.Render("Source:"), valueStyle.Render(policyPath))
+		fmt.Println(labelStyle.Render("  Layers:"))
+		for _, l := range layers {
+			signed := ""
+			if l.Signed {
+				signed = okStyle.Render(" (signed)")
+			}
+			fmt.Printf("    %-8s %s%s\n", l.Name, valueStyle.Render(l.Path), signed)
+		}
 	}
 	fmt.Printf("  %s %s\n", labelStyle.Render("Version:"), valueStyle.Render(fmt.Sprintf("%d", p.Version)))
 	fmt.Println()
@@ -174,6 +220,7 @@ func runPolicyShow(showAll bool) error {
 	}
 	fmt.Println()
 	fmt.Printf("    %s explicitly allowed patterns\n", okStyle.Render(fmt.Sprintf("%d", allowed)))
+	fmt.Printf("    %s auto-respond rules\n", valueStyle.Render(fmt.Sprintf("%d", len(p.AutoRespond))))
 	fmt.Println()
 
 	// Automation settings
@@ -185,9 +232,9 @@ func runPolicyShow(showAll bool) error {
 
 	// Show detailed rules if requested
 	if showAll {
-		printRuleSection("Blocked", p.Blocked, errorStyle, mutedStyle)
-		printRuleSection("Approval Required", p.ApprovalRequired, warnStyle, mutedStyle)
-		printRuleSection("Allowed", p.Allowed, okStyle, mutedStyle)
+		printRuleSection("Blocked", p.Blocked, effective, errorStyle, mutedStyle)
+		printRuleSection("Approval Required", p.ApprovalRequired, effective, warnStyle, mutedStyle)
+		printRuleSection("Allowed", p.Allowed, effective, okStyle, mutedStyle)
 	} else {
 		fmt.Printf("  %s\n", mutedStyle.Render("Use --all to see detailed rules"))
 	}
@@ -200,28 +247,48 @@ func toRuleSummaries(rules []policy.Rule) []RuleSummary {
 	result := make([]RuleSummary, len(rules))
 	for i, r := range rules {
 		result[i] = RuleSummary{
-			Pattern: r.Pattern,
+			Pattern: r.Describe(),
+			When:    r.Scope(),
 			Reason:  r.Reason,
 			SLB:     r.SLB,
+
+			Overridable: r.Overridable,
 		}
 	}
 	return result
 }
 
-func printRuleSection(title string, rules []policy.Rule, titleStyle, mutedStyle lipgloss.Style) {
+// addRuleSources records where each rule came from, for policy show --effective.
+func addRuleSources(summaries []RuleSummary, rules []policy.Rule) {
+	for i := range summaries {
+		source := rules[i].Source()
+		summaries[i].Source = &source
+	}
+}
+
+func printRuleSection(title string, rules []policy.Rule, withSource bool, titleStyle, mutedStyle lipgloss.Style) {
 	if len(rules) == 0 {
 		return
 	}
 
 	fmt.Printf("  %s:\n", titleStyle.Render(title))
 	for _, r := range rules {
-		fmt.Printf("    • %s\n", r.Pattern)
+		fmt.Printf("    • %s\n", r.Describe())
+		if scope := r.Scope(); scope != "" {
+			fmt.Printf("      %s\n", mutedStyle.Render("when "+scope))
+		}
 		if r.Reason != "" {
 			fmt.Printf("      %s\n", mutedStyle.Render(r.Reason))
 		}
 		if r.SLB {
 			fmt.Printf("      %s\n", mutedStyle.Render("[Requires SLB two-person approval]"))
 		}
+		if r.Overridable {
+			fmt.Printf("      %s\n", mutedStyle.Render("[Overridable by more specific layers]"))
+		}
+		if withSource {
+			fmt.Printf("      %s\n", mutedStyle.Render("from "+r.Source().String()))
+		}
 	}
 	fmt.Println()
 }
@@ -488,9 +555,169 @@ approval_required:
   - pattern: 'force_release'
     reason: "Force release another agent's reservation"
     slb: true  # Requires two-person approval
+
+# Any rule can be scoped with when: conditions on agents, sessions, projects,
+# labels, paths, branches, hours, and days ("!" negates a glob). Use
+# 'ntm policy explain <command>' to see how rules apply in a context.
+#  - command: git
+#    arg: push
+#    when:
+#      agents: cod
+#      branches: "!agent/*"
+#    reason: "Codex pushes only agent/* branches"
+
+# Auto-respond rules answer agent permission prompts and gates (ntm autorespond).
+# First matching rule wins; prompts no rule covers are escalated for approval.
+# Approved commands are still checked against blocked/approval_required above.
+# auto_respond:
+#   - agents: [cc, cod]
+#     tools: [read]
+#     action: approve
+#     reason: "Read-only tools are safe"
+#   - tools: [bash]
+#     pattern: '^(go|git)\s+(test|status|diff|log)\b'
+#     action: approve
+#   - gates: [trust]
+#     action: approve
 `
 }
 
+func newPolicyExplainCmd() *cobra.Command {
+	var contextFlags policyContextFlags
+	var showAll bool
+
+	cmd := &cobra.Command{
+		Use:   "explain <command>",
+		Short: "Explain how each policy rule applies to a command",
+		Long: `Evaluate a command against the policy and report, rule by rule, whether
+its when: conditions held in the given context, whether it matched, and which
+rule decided the verdict.
+
+The context defaults to what ntm detects from the environment (see
+'ntm safety check'); override it with --agent, --session, --dir, --branch, and --at.
+
+Examples:
+  ntm policy explain --agent cod --branch main -- git push origin main
+  ntm policy explain --session myproj--release "npm publish"
+  ntm policy explain --at 2026-03-02T10:00:00Z "psql -c 'DROP TABLE users'"`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			pctx, err := contextFlags.resolve()
+			if err != nil {
+				return err
+			}
+			return runPolicyExplain(strings.Join(args, " "), pctx, showAll)
+		},
+	}
+	contextFlags.register(cmd)
+	cmd.Flags().BoolVarP(&showAll, "all", "a", false, "List every rule, not only those that matched or are scoped")
+
+	return cmd
+}
+
+// PolicyExplainResponse is the JSON output for policy explain.
+type PolicyExplainResponse struct {
+	output.TimestampedResponse
+	policy.Explanation
+	Action string `json:"action"` // allow, block, approve
+}
+
+func runPolicyExplain(command string, pctx policy.Context, showAll bool) error {
+	p, err := policy.LoadForSession(pctx.Session)
+	if err != nil {
+		return fmt.Errorf("loading policy: %w", err)
+	}
+	exp := p.Explain(command, pctx)
+	action := string(policy.ActionAllow)
+	if exp.Verdict != nil {
+		action = string(exp.Verdict.Action)
+	}
+
+	if IsJSONOutput() {
+		return output.PrintJSON(PolicyExplainResponse{
+			TimestampedResponse: output.NewTimestamped(),
+			Explanation:         exp,
+			Action:              action,
+		})
+	}
+
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("99"))
+	labelStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("111"))
+	mutedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	okStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	warnStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+
+	unknown := mutedStyle.Render("(unknown)")
+	orUnknown := func(v string) string {
+		if v == "" {
+			return unknown
+		}
+		return v
+	}
+
+	fmt.Println()
+	fmt.Println(titleStyle.Render("Policy Explanation"))
+	fmt.Println()
+	fmt.Printf("  %s %s\n", labelStyle.Render("Command:"), exp.Command)
+	fmt.Printf("  %s agent=%s session=%s branch=%s\n", labelStyle.Render("Context:"),
+		orUnknown(exp.Context.AgentType), orUnknown(exp.Context.Session), orUnknown(exp.Context.Branch))
+	fmt.Printf("           dir=%s time=%s\n", orUnknown(exp.Context.Dir), exp.Context.Time.Format("2006-01-02 15:04 MST"))
+
+	switch action {
+	case string(policy.ActionBlock):
+		fmt.Printf("  %s %s\n", labelStyle.Render("Verdict:"), errorStyle.Render("BLOCKED"))
+	case string(policy.ActionApprove):
+		fmt.Printf("  %s %s\n", labelStyle.Render("Verdict:"), warnStyle.Render("Requires approval"))
+	default:
+		fmt.Printf("  %s %s\n", labelStyle.Render("Verdict:"), okStyle.Render("Allowed"))
+	}
+	if exp.Verdict != nil && exp.Verdict.Reason != "" {
+		fmt.Printf("    %s\n", mutedStyle.Render(exp.Verdict.Reason))
+	}
+	fmt.Println()
+
+	hidden := 0
+	for _, re := range exp.Rules {
+		if !showAll && !re.Matched && re.Scope == "" {
+			hidden++
+			continue
+		}
+		marker := mutedStyle.Render("·")
+		switch {
+		case re.Decisive:
+			marker = labelStyle.Render("→")
+		case re.InScope && re.Matched:
+			marker = okStyle.Render("✓")
+		case !re.InScope:
+			marker = mutedStyle.Render("✗")
+		}
+		fmt.Printf("  %s %s[%d] %s\n", marker, re.List, re.Index, re.Rule)
+		if re.Source.Layer != "" {
+			fmt.Printf("      %s\n", mutedStyle.Render("from "+re.Source.String()))
+		}
+		if re.Scope != "" {
+			fmt.Printf("      %s\n", mutedStyle.Render("when "+re.Scope))
+		}
+		for _, c := range re.Conditions {
+			got := c.Got
+			if got == "" {
+				got = "unknown"
+			}
+			fmt.Printf("      %s\n", mutedStyle.Render(fmt.Sprintf("%s: %s (want %s) %s", c.Condition, got, c.Want, c.Outcome)))
+		}
+		if re.SubCommand != "" && re.SubCommand != exp.Command {
+			fmt.Printf("      %s\n", mutedStyle.Render("matched: "+re.SubCommand))
+		}
+		fmt.Printf("      %s\n", re.Why)
+	}
+	if hidden > 0 {
+		fmt.Printf("  %s\n", mutedStyle.Render(fmt.Sprintf("%d unscoped rules did not match; use --all to list them", hidden)))
+	}
+	fmt.Println()
+	return nil
+}
+
 func newPolicyEditCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "edit",
@@ -794,7 +1021,7 @@ func generatePolicyYAML(p *policy.Policy) string {
 	if len(p.Allowed) > 0 {
 		sb.WriteString("allowed:\n")
 		for _, r := range p.Allowed {
-			sb.WriteString(fmt.Sprintf("  - pattern: '%s'\n", escapeYAMLSingleQuote(r.Pattern)))
+			writeRuleMatchersYAML(&sb, r)
 			if r.Reason != "" {
 				sb.WriteString(fmt.Sprintf("    reason: \"%s\"\n", escapeYAMLDoubleQuote(r.Reason)))
 			}
@@ -805,10 +1032,13 @@ func generatePolicyYAML(p *policy.Policy) string {
 	if len(p.Blocked) > 0 {
 		sb.WriteString("blocked:\n")
 		for _, r := range p.Blocked {
-			sb.WriteString(fmt.Sprintf("  - pattern: '%s'\n", escapeYAMLSingleQuote(r.Pattern)))
+			writeRuleMatchersYAML(&sb, r)
 			if r.Reason != "" {
 				sb.WriteString(fmt.Sprintf("    reason: \"%s\"\n", escapeYAMLDoubleQuote(r.Reason)))
 			}
+			if r.Overridable {
+				sb.WriteString("    overridable: true\n")
+			}
 		}
 		sb.WriteString("\n")
 	}
@@ -816,7 +1046,38 @@ func generatePolicyYAML(p *policy.Policy) string {
 	if len(p.ApprovalRequired) > 0 {
 		sb.WriteString("approval_required:\n")
 		for _, r := range p.ApprovalRequired {
-			sb.WriteString(fmt.Sprintf("  - pattern: '%s'\n", escapeYAMLSingleQuote(r.Pattern)))
+			writeRuleMatchersYAML(&sb, r)
+			if r.Reason != "" {
+				sb.WriteString(fmt.Sprintf("    reason: \"%s\"\n", escapeYAMLDoubleQuote(r.Reason)))
+			}
+			if r.SLB {
+				sb.WriteString("    slb: true\n")
+			}
+			if r.Overridable {
+				sb.WriteString("    overridable: true\n")
+			}
+		}
+	}
+
+	if len(p.AutoRespond) > 0 {
+		if len(p.ApprovalRequired) > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("auto_respond:\n")
+		for _, r := range p.AutoRespond {
+			sb.WriteString(fmt.Sprintf("  - action: %s\n", r.Action))
+			if len(r.Agents) > 0 {
+				sb.WriteString(fmt.Sprintf("    agents: [%s]\n", strings.Join(r.Agents, ", ")))
+			}
+			if len(r.Gates) > 0 {
+				sb.WriteString(fmt.Sprintf("    gates: [%s]\n", strings.Join(r.Gates, ", ")))
+			}
+			if len(r.Tools) > 0 {
+				sb.WriteString(fmt.Sprintf("    tools: [%s]\n", strings.Join(r.Tools, ", ")))
+			}
+			if r.Pattern != "" {
+				sb.WriteString(fmt.Sprintf("    pattern: '%s'\n", escapeYAMLSingleQuote(r.Pattern)))
+			}
 			if r.Reason != "" {
 				sb.WriteString(fmt.Sprintf("    reason: \"%s\"\n", escapeYAMLDoubleQuote(r.Reason)))
 			}
@@ -829,6 +1090,58 @@ func generatePolicyYAML(p *policy.Policy) string {
 	return sb.String()
 }
 
+// writeRuleMatchersYAML writes the opening lines of a rule list item: its
+// pattern, any argv matchers, and its when: conditions.
+func writeRuleMatchersYAML(sb *strings.Builder, r policy.Rule) {
+	lead := "  - "
+	if r.Pattern != "" || (r.Command == "" && len(r.Flag) == 0 && len(r.Arg) == 0) {
+		sb.WriteString(fmt.Sprintf("%spattern: '%s'\n", lead, escapeYAMLSingleQuote(r.Pattern)))
+		lead = "    "
+	}
+	if r.Command != "" {
+		sb.WriteString(fmt.Sprintf("%scommand: '%s'\n", lead, escapeYAMLSingleQuote(r.Command)))
+		lead = "    "
+	}
+	for _, m := range []struct {
+		key    string
+		values []string
+	}{{"flag", r.Flag}, {"arg", r.Arg}} {
+		if len(m.values) == 0 {
+			continue
+		}
+		quoted := make([]string, len(m.values))
+		for i, v := range m.values {
+			quoted[i] = "'" + escapeYAMLSingleQuote(v) + "'"
+		}
+		sb.WriteString(fmt.Sprintf("%s%s: [%s]\n", lead, m.key, strings.Join(quoted, ", ")))
+		lead = "    "
+	}
+	if r.When == nil {
+		return
+	}
+	sb.WriteString("    when:\n")
+	for _, c := range []struct {
+		key    string
+		values []string
+	}{
+		{"agents", r.When.Agents}, {"sessions", r.When.Sessions}, {"projects", r.When.Projects},
+		{"labels", r.When.Labels}, {"paths", r.When.Paths}, {"branches", r.When.Branches},
+		{"hours", r.When.Hours}, {"days", r.When.Days},
+	} {
+		if len(c.values) == 0 {
+			continue
+		}
+		quoted := make([]string, len(c.values))
+		for i, v := range c.values {
+			quoted[i] = "'" + escapeYAMLSingleQuote(v) + "'"
+		}
+		sb.WriteString(fmt.Sprintf("      %s: [%s]\n", c.key, strings.Join(quoted, ", ")))
+	}
+	if r.When.Timezone != "" {
+		sb.WriteString(fmt.Sprintf("      timezone: '%s'\n", escapeYAMLSingleQuote(r.When.Timezone)))
+	}
+}
+
 // escapeYAMLSingleQuote escapes single quotes for YAML single-quoted strings.
 // In YAML single-quoted strings, single quotes are escaped by doubling them.
 func escapeYAMLSingleQuote(s string) string {
@@ -7,6 +7,8 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/Dicklesworthstone/ntm/internal/policy"
 )
 
 func TestPolicyValidateJSONFailuresAreTerminal(t *testing.T) {
@@ -109,7 +111,7 @@ func TestPolicyCmd(t *testing.T) {
 	cmd := newPolicyCmd()
 
 	// Test that the command has expected subcommands
-	expectedSubs := []string{"show", "validate", "reset", "edit", "automation"}
+	expectedSubs := []string{"show", "validate", "reset", "edit", "automation", "explain", "keygen", "sign"}
 	for _, sub := range expectedSubs {
 		found := false
 		for _, c := range cmd.Commands() {
@@ -205,6 +207,222 @@ func TestGenerateDefaultPolicyYAML(t *testing.T) {
 	}
 }
 
+func TestGeneratePolicyYAMLKeepsAutoRespond(t *testing.T) {
+	src := `version: 1
+auto_respond:
+  - agents: [cc, cod]
+    tools: [bash]
+    pattern: '^git\s+(status|diff)'
+    action: approve
+    reason: "Read-only \"git\""
+  - gates: [trust]
+    action: escalate
+    slb: true
+`
+	p, err := policy.DecodeYAML([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	round, err := policy.DecodeYAML([]byte(generatePolicyYAML(p)))
+	if err != nil {
+		t.Fatalf("regenerated YAML does not parse: %v", err)
+	}
+	if err := round.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if len(round.AutoRespond) != 2 {
+		t.Fatalf("auto_respond rules = %+v", round.AutoRespond)
+	}
+	got, want := round.AutoRespond[0], p.AutoRespond[0]
+	if got.Pattern != want.Pattern || got.Reason != want.Reason || got.Action != want.Action ||
+		strings.Join(got.Agents, ",") != "cc,cod" || strings.Join(got.Tools, ",") != "bash" {
+		t.Errorf("rule 1 = %+v, want %+v", got, want)
+	}
+	if !round.AutoRespond[1].SLB || strings.Join(round.AutoRespond[1].Gates, ",") != "trust" {
+		t.Errorf("rule 2 = %+v", round.AutoRespond[1])
+	}
+}
+
+func TestGeneratePolicyYAMLKeepsArgvMatchers(t *testing.T) {
+	src := `version: 1
+blocked:
+  - command: rm
+    flag: [-r, -f]
+    arg: ["/", "~"]
+    reason: critical delete
+  - pattern: 'git\s+reset'
+    command: git
+`
+	p, err := policy.DecodeYAML([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	round, err := policy.DecodeYAML([]byte(generatePolicyYAML(p)))
+	if err != nil {
+		t.Fatalf("regenerated YAML does not parse: %v", err)
+	}
+	if err := round.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	for i := range p.Blocked {
+		if got, want := round.Blocked[i].Describe(), p.Blocked[i].Describe(); got != want {
+			t.Errorf("blocked[%d] = %q, want %q", i, got, want)
+		}
+	}
+	if m := round.Check("sudo rm -rf /"); m == nil || m.Action != policy.ActionBlock {
+		t.Errorf("regenerated policy does not block: %+v", m)
+	}
+}
+
+func TestGeneratePolicyYAMLKeepsConditions(t *testing.T) {
+	src := `version: 1
+blocked:
+  - command: git
+    arg: push
+    when:
+      agents: cod
+      branches: ["!agent/*"]
+      paths: ~/work
+    reason: codex pushes only agent branches
+approval_required:
+  - pattern: 'DROP'
+    when:
+      hours: "09:00-18:00"
+      days: [mon-fri]
+      timezone: Europe/Berlin
+`
+	p, err := policy.DecodeYAML([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	round, err := policy.DecodeYAML([]byte(generatePolicyYAML(p)))
+	if err != nil {
+		t.Fatalf("regenerated YAML does not parse: %v", err)
+	}
+	if err := round.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := round.Blocked[0].Scope(), "agents=cod paths=~/work branches=!agent/*"; got != want {
+		t.Errorf("blocked scope = %q, want %q", got, want)
+	}
+	if got, want := round.ApprovalRequired[0].Scope(), "hours=09:00-18:00 days=mon-fri timezone=Europe/Berlin"; got != want {
+		t.Errorf("approval scope = %q, want %q", got, want)
+	}
+}
+
+func TestPolicyExplainJSONReportsRuleScopes(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.MkdirAll(filepath.Join(home, ".ntm"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := `version: 1
+blocked:
+  - command: npm
+    arg: publish
+    when:
+      sessions: "!*--release"
+    reason: publish from the release session
+`
+	if err := os.WriteFile(filepath.Join(home, ".ntm", "policy.yaml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	originalJSON := jsonOutput
+	jsonOutput = true
+	t.Cleanup(func() { jsonOutput = originalJSON })
+
+	for _, tc := range []struct {
+		session, action string
+		inScope         bool
+	}{
+		{"proj--dev", "block", true},
+		{"proj--release", "allow", false},
+	} {
+		stdout, err := captureStdout(t, func() error {
+			return runPolicyExplain("npm publish", policy.Context{Session: tc.session}, false)
+		})
+		if err != nil {
+			t.Fatalf("runPolicyExplain: %v", err)
+		}
+		document := decodeSingleTerminalJSONMap(t, stdout)
+		if document["action"] != tc.action {
+			t.Errorf("%s: action = %v, want %s", tc.session, document["action"], tc.action)
+		}
+		rules, ok := document["rules"].([]interface{})
+		if !ok || len(rules) != 1 {
+			t.Fatalf("%s: rules = %#v", tc.session, document["rules"])
+		}
+		rule := rules[0].(map[string]interface{})
+		if rule["in_scope"] != tc.inScope || rule["matched"] != true || rule["why"] == "" {
+			t.Errorf("%s: rule = %#v", tc.session, rule)
+		}
+	}
+}
+
+func TestPolicySignAndShowEffective(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Chdir(t.TempDir())
+	prevDir := policy.SystemPolicyDir
+	policy.SystemPolicyDir = t.TempDir()
+	t.Cleanup(func() { policy.SystemPolicyDir = prevDir })
+	originalJSON := jsonOutput
+	jsonOutput = true
+	t.Cleanup(func() { jsonOutput = originalJSON })
+
+	baseline := filepath.Join(policy.SystemPolicyDir, "policy.yaml")
+	if err := os.WriteFile(baseline, []byte("version: 1\nblocked:\n  - pattern: 'curl .* \\| sh'\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	keyName := filepath.Join(t.TempDir(), "org")
+	if _, err := captureStdout(t, func() error { return runPolicyKeygen(keyName, false) }); err != nil {
+		t.Fatalf("runPolicyKeygen: %v", err)
+	}
+	if err := runPolicyKeygen(keyName, false); err == nil {
+		t.Error("keygen overwrote an existing key without --force")
+	}
+	pub, err := os.ReadFile(keyName + ".pub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(policy.SystemPolicyDir, policy.TrustedKeysFile), pub, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, err := captureStdout(t, func() error { return runPolicySign(baseline, keyName+".key") })
+	if err != nil {
+		t.Fatalf("runPolicySign: %v", err)
+	}
+	if document := decodeSingleTerminalJSONMap(t, stdout); document["trusted"] != true {
+		t.Errorf("sign response = %#v, want trusted", document)
+	}
+
+	if err := os.MkdirAll(filepath.Join(home, ".ntm"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, ".ntm", "policy.yaml"), []byte("version: 1\nblocked:\n  - pattern: 'rm -rf'\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stdout, err = captureStdout(t, func() error { return runPolicyShow(false, true, "") })
+	if err != nil {
+		t.Fatalf("runPolicyShow: %v", err)
+	}
+	document := decodeSingleTerminalJSONMap(t, stdout)
+	layers, _ := document["layers"].([]interface{})
+	if len(layers) != 2 || layers[0].(map[string]interface{})["signed"] != true {
+		t.Fatalf("layers = %#v, want a signed baseline and the user layer", document["layers"])
+	}
+	blocked := document["rules"].(map[string]interface{})["blocked"].([]interface{})
+	var sources []string
+	for _, r := range blocked {
+		source := r.(map[string]interface{})["source"].(map[string]interface{})
+		sources = append(sources, source["layer"].(string))
+	}
+	if strings.Join(sources, ",") != "system,user" {
+		t.Errorf("blocked rule sources = %v, want system,user", sources)
+	}
+}
+
 func TestUpdateAutomationInYAML(t *testing.T) {
 	input := `version: 1
 
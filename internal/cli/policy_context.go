@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/ntm/internal/policy"
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+)
+
+// policyContextFlags describe where a checked command runs, for policy rules
+// scoped by `when:` conditions. Unset flags are detected from the caller's
+// environment so the safety wrappers and hooks need not pass them.
+type policyContextFlags struct {
+	agent   string
+	session string
+	dir     string
+	branch  string
+	at      string
+}
+
+func (f *policyContextFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.agent, "agent", "", "Agent type running the command (default: $NTM_AGENT_TYPE, else the current pane's agent)")
+	cmd.Flags().StringVar(&f.session, "session", "", "Session the command runs in (default: $NTM_SESSION, else the current tmux session)")
+	cmd.Flags().StringVar(&f.dir, "dir", "", "Working directory (default: current directory)")
+	cmd.Flags().StringVar(&f.branch, "branch", "", "Git branch (default: the branch checked out in the working directory)")
+	cmd.Flags().StringVar(&f.at, "at", "", "Evaluate time windows at this RFC3339 time (default: now)")
+}
+
+// resolve builds the policy context from the flags and the environment.
+func (f policyContextFlags) resolve() (policy.Context, error) {
+	dir := f.dir
+	if dir == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return policy.Context{}, fmt.Errorf("getting working directory: %w", err)
+		}
+		dir = wd
+	}
+	pctx := policy.DirContext(dir)
+	if f.branch != "" {
+		pctx.Branch = f.branch
+	}
+	if f.at != "" {
+		at, err := time.Parse(time.RFC3339, f.at)
+		if err != nil {
+			return policy.Context{}, fmt.Errorf("invalid --at %q: expected RFC3339 (2026-01-02T15:04:05Z)", f.at)
+		}
+		pctx.Time = at
+	}
+
+	pctx.Session = f.session
+	if pctx.Session == "" {
+		pctx.Session = strings.TrimSpace(os.Getenv("NTM_SESSION"))
+	}
+	if pctx.Session == "" && tmux.InTmux() {
+		pctx.Session = tmux.GetCurrentSession()
+	}
+	pctx.AgentType = f.agent
+	if pctx.AgentType == "" {
+		pctx.AgentType = strings.TrimSpace(os.Getenv("NTM_AGENT_TYPE"))
+	}
+	if pctx.AgentType == "" {
+		pctx.AgentType = currentPaneAgentType(pctx.Session)
+	}
+	return pctx, nil
+}
+
+// currentPaneAgentType is the agent type of the tmux pane this process runs
+// in, or "" outside an ntm pane.
+func currentPaneAgentType(session string) string {
+	paneID := strings.TrimSpace(os.Getenv("TMUX_PANE"))
+	if paneID == "" || session == "" {
+		return ""
+	}
+	panes, err := tmux.GetPanes(session)
+	if err != nil {
+		return ""
+	}
+	for _, pane := range panes {
+		if pane.ID == paneID && pane.Type != "" {
+			return string(pane.Type)
+		}
+	}
+	return ""
+}
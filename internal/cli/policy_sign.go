@@ -0,0 +1,172 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/ntm/internal/output"
+	"github.com/Dicklesworthstone/ntm/internal/policy"
+)
+
+func newPolicyKeygenCmd() *cobra.Command {
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "keygen <name>",
+		Short: "Generate an ed25519 key pair for signing the org policy baseline",
+		Long: `Generate an ed25519 key pair for signing the org policy baseline.
+
+Writes <name>.key (private, mode 0600) and <name>.pub. Install the public key
+as /etc/ntm/policy.pub to require a valid signature on /etc/ntm/policy.yaml;
+keep the private key with whoever maintains the baseline.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPolicyKeygen(args[0], force)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "Overwrite existing key files")
+
+	return cmd
+}
+
+// PolicyKeygenResponse is the JSON output for policy keygen.
+type PolicyKeygenResponse struct {
+	output.TimestampedResponse
+	PublicKey      string `json:"public_key"`
+	PublicKeyPath  string `json:"public_key_path"`
+	PrivateKeyPath string `json:"private_key_path"`
+}
+
+func runPolicyKeygen(name string, force bool) error {
+	pubPath, keyPath := name+".pub", name+".key"
+	if !force {
+		for _, path := range []string{pubPath, keyPath} {
+			if fileExists(path) {
+				return fmt.Errorf("%s already exists (use --force to overwrite)", path)
+			}
+		}
+	}
+
+	pub, priv, err := policy.GenerateSigningKey()
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(keyPath); dir != "." {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return fmt.Errorf("creating key directory: %w", err)
+		}
+	}
+	if err := os.WriteFile(keyPath, []byte(priv+"\n"), 0o600); err != nil {
+		return fmt.Errorf("writing private key: %w", err)
+	}
+	if err := os.WriteFile(pubPath, []byte(pub+"\n"), 0o644); err != nil {
+		return fmt.Errorf("writing public key: %w", err)
+	}
+
+	if IsJSONOutput() {
+		return output.PrintJSON(PolicyKeygenResponse{
+			TimestampedResponse: output.NewTimestamped(),
+			PublicKey:           pub,
+			PublicKeyPath:       pubPath,
+			PrivateKeyPath:      keyPath,
+		})
+	}
+
+	okStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	mutedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	fmt.Printf("  %s Private key: %s\n", okStyle.Render("✓"), keyPath)
+	fmt.Printf("  %s Public key:  %s\n", okStyle.Render("✓"), pubPath)
+	fmt.Printf("  %s\n", mutedStyle.Render("Install the public key as "+filepath.Join(policy.SystemPolicyDir, policy.TrustedKeysFile)+" to require a signed baseline"))
+	return nil
+}
+
+func newPolicySignCmd() *cobra.Command {
+	var keyFile string
+
+	cmd := &cobra.Command{
+		Use:   "sign [file]",
+		Short: "Sign a policy file with an ed25519 key",
+		Long: `Validate a policy file and write its detached signature to <file>.sig.
+
+The file defaults to the org baseline, /etc/ntm/policy.yaml. Re-sign the
+baseline after every edit: once /etc/ntm/policy.pub lists a key, ntm refuses
+to load a baseline whose signature does not match.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			policyFile := filepath.Join(policy.SystemPolicyDir, "policy.yaml")
+			if len(args) > 0 {
+				policyFile = args[0]
+			}
+			return runPolicySign(policyFile, keyFile)
+		},
+	}
+
+	cmd.Flags().StringVar(&keyFile, "key", "", "Private key file written by 'ntm policy keygen' (required)")
+	_ = cmd.MarkFlagRequired("key")
+
+	return cmd
+}
+
+// PolicySignResponse is the JSON output for policy sign.
+type PolicySignResponse struct {
+	output.TimestampedResponse
+	PolicyPath    string `json:"policy_path"`
+	SignaturePath string `json:"signature_path"`
+	// Trusted reports whether the installed policy.pub accepts the signature.
+	Trusted bool `json:"trusted"`
+}
+
+func runPolicySign(policyFile, keyFile string) error {
+	data, err := os.ReadFile(policyFile)
+	if err != nil {
+		return fmt.Errorf("reading policy file: %w", err)
+	}
+	p, err := policy.DecodeYAML(data)
+	if err != nil {
+		return err
+	}
+	if err := p.Validate(); err != nil {
+		return fmt.Errorf("refusing to sign an invalid policy: %w", err)
+	}
+	key, err := os.ReadFile(keyFile)
+	if err != nil {
+		return fmt.Errorf("reading signing key: %w", err)
+	}
+	sig, err := policy.Sign(data, string(key))
+	if err != nil {
+		return err
+	}
+	sigPath := policyFile + policy.SignatureSuffix
+	if err := os.WriteFile(sigPath, []byte(sig+"\n"), 0o644); err != nil {
+		return fmt.Errorf("writing signature: %w", err)
+	}
+
+	keys, err := policy.LoadTrustedKeys(filepath.Join(policy.SystemPolicyDir, policy.TrustedKeysFile))
+	if err != nil {
+		return err
+	}
+	trusted := policy.Verify(data, sig, keys)
+
+	if IsJSONOutput() {
+		return output.PrintJSON(PolicySignResponse{
+			TimestampedResponse: output.NewTimestamped(),
+			PolicyPath:          policyFile,
+			SignaturePath:       sigPath,
+			Trusted:             trusted,
+		})
+	}
+
+	okStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	warnStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	fmt.Printf("  %s Signed %s\n", okStyle.Render("✓"), policyFile)
+	fmt.Printf("    Signature: %s\n", sigPath)
+	if !trusted {
+		fmt.Printf("  %s %s\n", warnStyle.Render("⚠"), "The signing key is not listed in "+filepath.Join(policy.SystemPolicyDir, policy.TrustedKeysFile))
+	}
+	return nil
+}
@@ -0,0 +1,280 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/ntm/internal/config"
+	"github.com/Dicklesworthstone/ntm/internal/output"
+	"github.com/Dicklesworthstone/ntm/internal/ptyd"
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+)
+
+// backendEnv overrides tmux.backend for one invocation.
+const backendEnv = "NTM_BACKEND"
+
+// resolvePaneBackend picks the backend for name ("auto", "tmux" or "pty"):
+// auto means tmux when it is installed and the PTY daemon otherwise.
+func resolvePaneBackend(name string, tmuxInstalled bool) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if err := config.ValidatePaneBackend(name); err != nil {
+		return "", err
+	}
+	switch name {
+	case "", "auto":
+		if tmuxInstalled {
+			return "tmux", nil
+		}
+		return ptyd.BackendName, nil
+	}
+	return name, nil
+}
+
+// applyPaneBackend routes tmux.DefaultClient to the selected pane backend.
+// name (spawn --backend) wins over NTM_BACKEND, which wins over
+// [tmux] backend. The choice is exported as NTM_BACKEND so monitors and
+// hooks started by this invocation drive the same panes.
+func applyPaneBackend(name string) error {
+	name = configuredPaneBackend(name)
+	client := tmux.DefaultClient
+	if client.Remote != "" {
+		// --ssh always drives the remote tmux server.
+		if strings.EqualFold(strings.TrimSpace(name), ptyd.BackendName) {
+			return errors.New("the pty backend cannot be combined with --ssh")
+		}
+		return nil
+	}
+	tmuxInstalled := client.Backend() == nil && client.IsInstalled()
+	backend, err := resolvePaneBackend(name, tmuxInstalled)
+	if err != nil {
+		return err
+	}
+	if backend != ptyd.BackendName {
+		client.SetBackend(nil)
+	} else if client.BackendName() != ptyd.BackendName {
+		pc, err := ptyd.NewClient("")
+		if err != nil {
+			return err
+		}
+		client.SetBackend(pc)
+	}
+	return os.Setenv(backendEnv, backend)
+}
+
+// configuredPaneBackend returns name, else NTM_BACKEND, else tmux.backend.
+func configuredPaneBackend(name string) string {
+	if name == "" {
+		name = os.Getenv(backendEnv)
+	}
+	if name == "" && cfg != nil {
+		name = cfg.Tmux.Backend
+	}
+	return name
+}
+
+// ptyFollowUpHint returns a reminder for spawn --backend=pty when later
+// commands would otherwise look for the panes on the tmux server. Call it
+// before applyPaneBackend exports the choice.
+func ptyFollowUpHint(requested string) string {
+	tmuxInstalled := tmux.NewClient("").IsInstalled()
+	want, err := resolvePaneBackend(requested, tmuxInstalled)
+	if err != nil || want != ptyd.BackendName {
+		return ""
+	}
+	if ambient, err := resolvePaneBackend(configuredPaneBackend(""), tmuxInstalled); err == nil && ambient == ptyd.BackendName {
+		return ""
+	}
+	return fmt.Sprintf("Panes run in the PTY daemon; export %s=pty so other ntm commands find them", backendEnv)
+}
+
+func newPtyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pty",
+		Short: "Manage the headless PTY pane backend",
+		Long: `Run agents without a tmux server. The PTY backend keeps each pane's shell
+in a pseudo-terminal owned by a small ntm daemon, with a scrollback buffer,
+tmux-style pane IDs (%0, %1, ...) and pane titles, so spawn, send, status and
+pipelines work unchanged.
+
+Select it with spawn --backend=pty, NTM_BACKEND=pty, or [tmux] backend = "pty"
+in config.toml ("auto" uses it only when tmux is not installed). The daemon
+starts on the first new session, listens on ~/.ntm/ptyd.sock (NTM_PTY_SOCKET
+overrides), and exits when its last session ends.
+
+Examples:
+  ntm spawn myproject --cc=2 --backend=pty
+  NTM_BACKEND=pty ntm send myproject --all "run the tests"
+  ntm pty status
+  ntm pty attach myproject:0.1          # Ctrl-] detaches
+  ntm pty stop`,
+	}
+	cmd.AddCommand(newPtyDaemonCmd())
+	cmd.AddCommand(newPtyStatusCmd())
+	cmd.AddCommand(newPtyAttachCmd())
+	cmd.AddCommand(newPtyStopCmd())
+	return cmd
+}
+
+func newPtyDaemonCmd() *cobra.Command {
+	var socket string
+	var keep bool
+	cmd := &cobra.Command{
+		Use:   "daemon",
+		Short: "Run the PTY daemon in the foreground",
+		Long: `Run the PTY daemon in the foreground. ntm starts it on demand, so running it
+by hand is only needed to keep it under a supervisor or to serve a custom
+socket.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if socket == "" {
+				var err error
+				if socket, err = ptyd.SocketPath(); err != nil {
+					return err
+				}
+			}
+			srv := ptyd.NewServer(socket)
+			srv.ExitWhenEmpty = !keep
+
+			sigs := make(chan os.Signal, 1)
+			signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+			defer signal.Stop(sigs)
+			go func() {
+				select {
+				case <-sigs:
+					srv.Close()
+				case <-srv.Done():
+				}
+			}()
+			return srv.Serve()
+		},
+	}
+	cmd.Flags().StringVar(&socket, "socket", "", "unix socket to listen on (default: $NTM_PTY_SOCKET or ~/.ntm/ptyd.sock)")
+	cmd.Flags().BoolVar(&keep, "keep", false, "keep running after the last session ends")
+	return cmd
+}
+
+// PtyStatusResult is the output of `ntm pty status`.
+type PtyStatusResult struct {
+	Socket   string            `json:"socket"`
+	Running  bool              `json:"running"`
+	Backend  string            `json:"backend"`
+	Sessions []PtySessionPanes `json:"sessions"`
+}
+
+// PtySessionPanes is one daemon session with its panes.
+type PtySessionPanes struct {
+	ptyd.SessionInfo
+	PaneList []ptyd.PaneInfo `json:"pane_list"`
+}
+
+func (r *PtyStatusResult) Text(w io.Writer) error {
+	if !r.Running {
+		fmt.Fprintf(w, "PTY daemon not running (%s)\n", r.Socket)
+		fmt.Fprintf(w, "Active backend: %s\n", r.Backend)
+		return nil
+	}
+	fmt.Fprintf(w, "PTY daemon on %s, %d session(s); active backend: %s\n", r.Socket, len(r.Sessions), r.Backend)
+	for _, s := range r.Sessions {
+		attached := ""
+		if s.Attached {
+			attached = " (attached)"
+		}
+		fmt.Fprintf(w, "  %s: %d pane(s), created %s%s\n", s.Name, s.Panes, s.Created.Format(time.DateTime), attached)
+		for _, p := range s.PaneList {
+			fmt.Fprintf(w, "    %-4s %-28s %-12s pid %d\n", p.ID, p.Title, p.Command, p.PID)
+		}
+	}
+	return nil
+}
+
+func (r *PtyStatusResult) JSON() interface{} {
+	if r.Sessions == nil {
+		r.Sessions = []PtySessionPanes{}
+	}
+	return r
+}
+
+func newPtyStatusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "status",
+		Aliases: []string{"list", "ls"},
+		Short:   "Show the PTY daemon's sessions and panes",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			pc, err := ptyd.NewClient("")
+			if err != nil {
+				return err
+			}
+			ctx, cancel := context.WithTimeout(cmd.Context(), tmux.DefaultCommandTimeout)
+			defer cancel()
+			result := &PtyStatusResult{
+				Socket:  pc.Socket,
+				Running: pc.Running(ctx),
+				Backend: tmux.DefaultClient.BackendName(),
+			}
+			if result.Running {
+				sessions, err := pc.Sessions(ctx)
+				if err != nil {
+					return err
+				}
+				for _, s := range sessions {
+					panes, err := pc.Panes(ctx, s.Name)
+					if err != nil {
+						return err
+					}
+					result.Sessions = append(result.Sessions, PtySessionPanes{SessionInfo: s, PaneList: panes})
+				}
+			}
+			return output.New(output.WithJSON(jsonOutput)).Output(result)
+		},
+	}
+}
+
+func newPtyAttachCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "attach <session|session:0.N|%pane>",
+		Short: "Attach the terminal to a PTY pane (Ctrl-] detaches)",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			pc, err := ptyd.NewClient("")
+			if err != nil {
+				return err
+			}
+			return pc.Attach(args[0])
+		},
+	}
+}
+
+func newPtyStopCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "stop",
+		Short: "Stop the PTY daemon and every pane it owns",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			pc, err := ptyd.NewClient("")
+			if err != nil {
+				return err
+			}
+			ctx, cancel := context.WithTimeout(cmd.Context(), tmux.DefaultCommandTimeout)
+			defer cancel()
+			if !pc.Running(ctx) {
+				fmt.Printf("PTY daemon not running (%s)\n", pc.Socket)
+				return nil
+			}
+			if err := pc.Shutdown(ctx); err != nil {
+				return err
+			}
+			fmt.Printf("Stopped PTY daemon (%s)\n", pc.Socket)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Dicklesworthstone/ntm/internal/config"
+	"github.com/Dicklesworthstone/ntm/internal/ptyd"
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+)
+
+func TestResolvePaneBackend(t *testing.T) {
+	tests := []struct {
+		name          string
+		tmuxInstalled bool
+		want          string
+	}{
+		{"", true, "tmux"},
+		{"auto", true, "tmux"},
+		{"", false, "pty"},
+		{" Auto ", false, "pty"},
+		{"tmux", false, "tmux"},
+		{"PTY", true, "pty"},
+	}
+	for _, tt := range tests {
+		got, err := resolvePaneBackend(tt.name, tt.tmuxInstalled)
+		if err != nil || got != tt.want {
+			t.Errorf("resolvePaneBackend(%q, %v) = %q, %v; want %q", tt.name, tt.tmuxInstalled, got, err, tt.want)
+		}
+	}
+	if _, err := resolvePaneBackend("screen", true); err == nil {
+		t.Error("unknown backend accepted")
+	}
+}
+
+func TestApplyPaneBackendPrecedence(t *testing.T) {
+	oldCfg, oldClient := cfg, tmux.DefaultClient
+	t.Cleanup(func() { cfg, tmux.DefaultClient = oldCfg, oldClient })
+	tmux.DefaultClient = tmux.NewClient("")
+	t.Setenv(ptyd.SocketEnv, filepath.Join(t.TempDir(), "p.sock"))
+	t.Setenv(backendEnv, "")
+	cfg = config.Default()
+	cfg.Tmux.Backend = "pty"
+
+	if err := applyPaneBackend(""); err != nil {
+		t.Fatal(err)
+	}
+	if got := tmux.DefaultClient.BackendName(); got != "pty" {
+		t.Fatalf("tmux.backend = pty selected %q", got)
+	}
+	if got := configuredPaneBackend(""); got != "pty" {
+		t.Errorf("NTM_BACKEND not exported for child processes: %q", got)
+	}
+
+	t.Setenv(backendEnv, "tmux")
+	if err := applyPaneBackend(""); err != nil {
+		t.Fatal(err)
+	}
+	if got := tmux.DefaultClient.BackendName(); got != "tmux" {
+		t.Errorf("NTM_BACKEND=tmux selected %q", got)
+	}
+
+	if err := applyPaneBackend("pty"); err != nil {
+		t.Fatal(err)
+	}
+	if got := tmux.DefaultClient.BackendName(); got != "pty" {
+		t.Errorf("--backend=pty selected %q", got)
+	}
+
+	if err := applyPaneBackend("screen"); err == nil {
+		t.Error("unknown backend accepted")
+	}
+
+	tmux.DefaultClient = tmux.NewClient("user@host")
+	if err := applyPaneBackend("pty"); err == nil {
+		t.Error("pty backend accepted with --ssh")
+	}
+}
+
+func TestPtyStatusResultText(t *testing.T) {
+	var buf bytes.Buffer
+	_ = (&PtyStatusResult{Socket: "/tmp/p.sock", Backend: "tmux"}).Text(&buf)
+	if !strings.Contains(buf.String(), "not running (/tmp/p.sock)") {
+		t.Errorf("text = %q", buf.String())
+	}
+
+	buf.Reset()
+	_ = (&PtyStatusResult{
+		Socket:  "/tmp/p.sock",
+		Running: true,
+		Backend: "pty",
+		Sessions: []PtySessionPanes{{
+			SessionInfo: ptyd.SessionInfo{Name: "proj", Panes: 1},
+			PaneList:    []ptyd.PaneInfo{{ID: "%0", Title: "proj__cc_1", Command: "claude", PID: 42}},
+		}},
+	}).Text(&buf)
+	for _, want := range []string{"1 session(s)", "proj: 1 pane(s)", "%0", "proj__cc_1", "pid 42"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("status text missing %q:\n%s", want, buf.String())
+		}
+	}
+}
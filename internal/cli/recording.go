@@ -0,0 +1,347 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/ntm/internal/config"
+	"github.com/Dicklesworthstone/ntm/internal/output"
+	"github.com/Dicklesworthstone/ntm/internal/recording"
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+	"github.com/Dicklesworthstone/ntm/internal/tui/theme"
+)
+
+func newRecordingCmd() *cobra.Command {
+	var dir string
+
+	cmd := &cobra.Command{
+		Use:   "recording",
+		Short: "Record pane output to asciicast files and play it back",
+		Long: `Record what every pane of a session prints, with ANSI colours and timing,
+into asciicast v2 files (one per pane, rotated by size, secrets redacted),
+then play, export or serve them for post-mortems.
+
+Playback markers come from the session timeline (agent state changes) and
+prompt history (prompts sent to the pane), so you can jump straight to
+"when cc_1 went idle" or "the prompt about the migration".
+
+Examples:
+  ntm recording start myproject            # Record until Ctrl-C or the session ends
+  ntm recording list                       # List recordings
+  ntm recording play myproject/20261017T021400Z-0.1 --speed 4
+  ntm recording play <id> --markers        # List seek markers
+  ntm recording play <id> --seek 3         # Start at marker 3
+  ntm recording play <id> --seek "migration"
+  ntm recording export <id> -o agent3.cast # asciinema-compatible file with markers`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRecordingList(recordingsDir(dir), "")
+		},
+	}
+	cmd.PersistentFlags().StringVar(&dir, "dir", "", "Recordings directory (default: ~/.local/share/ntm/recordings)")
+
+	cmd.AddCommand(newRecordingStartCmd(&dir))
+	cmd.AddCommand(newRecordingListCmd(&dir))
+	cmd.AddCommand(newRecordingPlayCmd(&dir))
+	cmd.AddCommand(newRecordingExportCmd(&dir))
+	return cmd
+}
+
+func recordingsDir(dir string) string {
+	if dir != "" {
+		return dir
+	}
+	return recording.DefaultDir()
+}
+
+// RecordingStartResult is the output of `ntm recording start` once recording
+// stops.
+type RecordingStartResult struct {
+	Session string   `json:"session"`
+	Dir     string   `json:"dir"`
+	Files   []string `json:"files"`
+}
+
+func (r *RecordingStartResult) Text(w io.Writer) error {
+	if len(r.Files) == 0 {
+		fmt.Fprintln(w, "Nothing recorded.")
+		return nil
+	}
+	fmt.Fprintf(w, "Recorded %d file(s):\n", len(r.Files))
+	for _, f := range r.Files {
+		fmt.Fprintf(w, "  %s\n", f)
+	}
+	return nil
+}
+
+func (r *RecordingStartResult) JSON() interface{} {
+	return r
+}
+
+func newRecordingStartCmd(dir *string) *cobra.Command {
+	var maxSizeMB int
+
+	cmd := &cobra.Command{
+		Use:   "start <session>",
+		Short: "Record a session's panes until interrupted or the session ends",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			session, err := useFleetSession(args[0])
+			if err != nil {
+				return err
+			}
+			if err := tmux.ValidateSessionName(session); err != nil {
+				return err
+			}
+			if maxSizeMB <= 0 {
+				return fmt.Errorf("--max-size must be positive")
+			}
+
+			c := cfg
+			if c == nil {
+				c = config.Default()
+			}
+			redactCfg := c.Redaction.ToRedactionLibConfig()
+			root := recordingsDir(*dir)
+			rec := recording.NewRecorder(session, recording.Options{
+				Dir:       root,
+				MaxBytes:  int64(maxSizeMB) << 20,
+				Redaction: &redactCfg,
+				Client:    tmux.DefaultClient,
+			})
+
+			// The pane streamers log attach/detach chatter meant for ntm serve.
+			prevLog := log.Writer()
+			log.SetOutput(io.Discard)
+			defer log.SetOutput(prevLog)
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			if !jsonOutput {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Recording %s to %s (Ctrl-C to stop)\n", session, root)
+			}
+			if err := rec.Run(ctx); err != nil {
+				return err
+			}
+
+			files := rec.Files()
+			if files == nil {
+				files = []string{}
+			}
+			return output.New(output.WithJSON(jsonOutput)).Output(&RecordingStartResult{
+				Session: session,
+				Dir:     root,
+				Files:   files,
+			})
+		},
+	}
+	cmd.Flags().IntVar(&maxSizeMB, "max-size", int(recording.DefaultMaxBytes>>20), "Rotate a pane's recording after this many MB")
+	return cmd
+}
+
+func newRecordingListCmd(dir *string) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list [session]",
+		Short: "List recordings",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			session := ""
+			if len(args) == 1 {
+				session = args[0]
+			}
+			return runRecordingList(recordingsDir(*dir), session)
+		},
+	}
+}
+
+// RecordingListResult is the output of `ntm recording list`.
+type RecordingListResult struct {
+	Dir        string           `json:"dir"`
+	Recordings []recording.Info `json:"recordings"`
+}
+
+func (r *RecordingListResult) Text(w io.Writer) error {
+	t := theme.Current()
+	if len(r.Recordings) == 0 {
+		fmt.Fprintf(w, "%sNo recordings in %s%s\n", colorize(t.Warning), r.Dir, colorize(t.Text))
+		return nil
+	}
+	fmt.Fprintf(w, " %sID%s                                        %sAGENT%s   %sSTARTED%s      %sLENGTH%s    %sSIZE%s\n",
+		colorize(t.Surface1), colorize(t.Text),
+		colorize(t.Surface1), colorize(t.Text),
+		colorize(t.Surface1), colorize(t.Text),
+		colorize(t.Surface1), colorize(t.Text),
+		colorize(t.Surface1), colorize(t.Text))
+	for _, rec := range r.Recordings {
+		agent := rec.AgentID
+		if agent == "" {
+			agent = "-"
+		}
+		length := time.Duration(rec.Duration * float64(time.Second)).Round(time.Second)
+		fmt.Fprintf(w, " %-41s %-7s %-12s %8s  %7s\n",
+			truncate(rec.ID, 41),
+			truncate(agent, 7),
+			rec.Started.Local().Format("01-02 15:04"),
+			length,
+			formatBytes(rec.Size))
+	}
+	return nil
+}
+
+func (r *RecordingListResult) JSON() interface{} {
+	return r
+}
+
+func runRecordingList(dir, session string) error {
+	infos, err := recording.List(dir, session)
+	if err != nil {
+		return fmt.Errorf("list recordings: %w", err)
+	}
+	formatter := output.New(output.WithJSON(jsonOutput))
+	return formatter.Output(&RecordingListResult{Dir: dir, Recordings: infos})
+}
+
+// loadRecording reads a cast by ID with its timeline markers merged in.
+func loadRecording(dir, id string, markers bool) (*recording.Cast, error) {
+	c, err := recording.Load(recordingsDir(dir), id)
+	if errors.Is(err, recording.ErrNotFound) {
+		return nil, fmt.Errorf("%w (see 'ntm recording list')", err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if markers {
+		c = c.WithMarkers(recording.TimelineMarkers(c))
+	}
+	return c, nil
+}
+
+// RecordingMarkersResult lists the seek markers of one recording.
+type RecordingMarkersResult struct {
+	ID      string             `json:"id"`
+	Markers []recording.Marker `json:"markers"`
+}
+
+func (r *RecordingMarkersResult) Text(w io.Writer) error {
+	if len(r.Markers) == 0 {
+		fmt.Fprintf(w, "No markers for %s\n", r.ID)
+		return nil
+	}
+	for i, m := range r.Markers {
+		at := time.Duration(m.Time * float64(time.Second)).Round(time.Second)
+		fmt.Fprintf(w, "%3d  %8s  %s\n", i+1, at, m.Label)
+	}
+	return nil
+}
+
+func (r *RecordingMarkersResult) JSON() interface{} {
+	return r
+}
+
+func newRecordingPlayCmd(dir *string) *cobra.Command {
+	var (
+		speed       float64
+		idleLimit   time.Duration
+		seek        string
+		listMarkers bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "play <id>",
+		Short: "Play a recording in the terminal",
+		Long: `Play a recording in the terminal with its original timing.
+
+--seek jumps to a marker number (see --markers), a duration into the
+recording ("90s", "2m"), or the first marker whose label contains the text.
+Output before the seek point is drawn at once so the screen is current.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := loadRecording(*dir, args[0], true)
+			if err != nil {
+				return err
+			}
+			if listMarkers {
+				return output.New(output.WithJSON(jsonOutput)).Output(&RecordingMarkersResult{
+					ID:      args[0],
+					Markers: c.Markers(),
+				})
+			}
+			if speed <= 0 {
+				return fmt.Errorf("--speed must be positive")
+			}
+			opts := recording.PlayOptions{Speed: speed, IdleLimit: idleLimit}
+			if seek != "" {
+				if opts.From, err = recording.Seek(c, seek); err != nil {
+					return err
+				}
+			}
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			if err := recording.Play(ctx, cmd.OutOrStdout(), c, opts); err != nil && !errors.Is(err, ctx.Err()) {
+				return err
+			}
+			return nil
+		},
+	}
+	cmd.Flags().Float64Var(&speed, "speed", 1, "Playback speed multiplier")
+	cmd.Flags().DurationVar(&idleLimit, "idle-limit", 0, "Cap pauses between output at this duration (e.g. 2s)")
+	cmd.Flags().StringVar(&seek, "seek", "", "Start at a marker number, duration, or marker label text")
+	cmd.Flags().BoolVar(&listMarkers, "markers", false, "List seek markers instead of playing")
+	return cmd
+}
+
+func newRecordingExportCmd(dir *string) *cobra.Command {
+	var (
+		outPath   string
+		noMarkers bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "export <id>",
+		Short: "Export a recording as an asciicast v2 file with markers",
+		Long: `Export a recording as an asciicast v2 file, playable with asciinema or
+embeddable with asciinema-player. Timeline markers are merged in as marker
+events unless --no-markers is given.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := loadRecording(*dir, args[0], !noMarkers)
+			if err != nil {
+				return err
+			}
+			if outPath == "" || outPath == "-" {
+				return c.Write(cmd.OutOrStdout())
+			}
+			f, err := os.OpenFile(outPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+			if err != nil {
+				return err
+			}
+			if err := c.Write(f); err != nil {
+				f.Close()
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+			if !jsonOutput {
+				fmt.Fprintf(cmd.OutOrStdout(), "Exported %s to %s (%d markers)\n", args[0], outPath, len(c.Markers()))
+				return nil
+			}
+			return output.PrintJSON(map[string]interface{}{
+				"id":      args[0],
+				"path":    outPath,
+				"markers": len(c.Markers()),
+			})
+		},
+	}
+	cmd.Flags().StringVarP(&outPath, "output", "o", "", "Output file (default: stdout)")
+	cmd.Flags().BoolVar(&noMarkers, "no-markers", false, "Do not merge timeline markers")
+	return cmd
+}
@@ -184,7 +184,10 @@ Examples:
   ntm replay 1 --dry-run          # Preview without sending
   ntm replay 1 --yes              # Send without an interactive confirmation
   ntm replay 1 --session=other    # Send to different session
-  ntm replay 1 --cc               # Send to Claude agents only`,
+  ntm replay 1 --cc               # Send to Claude agents only
+
+To watch what panes printed rather than re-send prompts, record them with
+'ntm recording start' and play them back with 'ntm recording play'.`,
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			jsonMode := IsJSONOutput()
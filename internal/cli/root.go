@@ -41,6 +41,7 @@ import (
 	"github.com/Dicklesworthstone/ntm/internal/state"
 	"github.com/Dicklesworthstone/ntm/internal/tmux"
 	"github.com/Dicklesworthstone/ntm/internal/tui/theme"
+	"github.com/Dicklesworthstone/ntm/internal/turns"
 	"github.com/Dicklesworthstone/ntm/internal/util"
 )
 
@@ -658,6 +659,7 @@ Shell Integration:
 		// surfaces, send targeting, deps), not only at spawn/add (ntm#260).
 		// Cheap: a directory scan of a handful of TOML files, nil when absent.
 		registerAgentPluginTypes(pluginAgentsDirForArgs(os.Args[1:]))
+		registerAgentRulePacks(agentRulesDirForArgs(os.Args[1:]))
 
 		// Handle --no-color flag by setting environment variable
 		// This integrates with the existing theme.NoColorEnabled() system
@@ -759,6 +761,12 @@ Shell Integration:
 				// ([integrations.bv] timeout_seconds; NTM_BV_TIMEOUT wins, GH#253).
 				bv.ConfigureCommandTimeout(cfg.Integrations.BV.TimeoutSeconds)
 
+				// [tmux] control_mode opts into the persistent tmux -C
+				// transport; NTM_TMUX_CONTROL_MODE enables it regardless.
+				if cfg.Tmux.ControlMode {
+					tmux.DefaultClient.SetControlMode(true)
+				}
+
 				privacy.SetDefaultManager(privacy.New(cfg.Privacy))
 
 				redactCfg := cfg.Redaction.ToRedactionLibConfig()
@@ -812,6 +820,21 @@ Shell Integration:
 				})
 			}
 
+			// Sign checkpoints of the audit log hash chains. Like encryption, a
+			// key that does not resolve is fatal: the operator asked for
+			// tamper evidence, and unsigned logs would silently lack it.
+			if cfg != nil && cfg.Audit.Anchor.Enabled {
+				anchorer, err := newAuditAnchorer(cfg.Audit.Anchor)
+				if err != nil {
+					return encryptionStartupError(
+						cmd,
+						fmt.Errorf("audit signing key resolution failed: %w", err),
+						"Configure a valid [audit.anchor] key source, or set [audit.anchor] enabled = false",
+					)
+				}
+				audit.SetAnchorer(anchorer)
+			}
+
 			// Run automatic temp file cleanup if enabled
 			MaybeRunStartupCleanup(
 				cfg.Cleanup.AutoCleanOnStartup,
@@ -820,6 +843,12 @@ Shell Integration:
 			)
 		}
 
+		// [tmux] backend and NTM_BACKEND can move panes off the tmux server
+		// onto the headless PTY daemon.
+		if err := applyPaneBackend(""); err != nil {
+			return err
+		}
+
 		if shouldInitializeRobotPersistence(cmd) {
 			if err := initializeRobotPersistence(cmd.Context()); err != nil {
 				return err
@@ -2485,6 +2514,7 @@ Shell Integration:
 			{"robot-kill-pane", robotKillPane},
 			{"robot-dialogs", robotDialogs},
 			{"robot-answer-dialog", robotAnswerDialog},
+			{"robot-turns", robotTurns},
 		} {
 			if lifecycleFlag.value == "" && cmd.Flags().Changed(lifecycleFlag.name) {
 				failRobotCommand(fmt.Errorf("--%s requires a session name", lifecycleFlag.name), robot.ErrCodeInvalidFlag, fmt.Sprintf("Use --%s=SESSION; 'ntm list' shows available sessions", lifecycleFlag.name), lifecycleFlag.name)
@@ -2519,6 +2549,36 @@ Shell Integration:
 			}
 			return
 		}
+		if robotTurns != "" {
+			session, err := resolveRobotLiveSession(cmd.Context(), robotTurns)
+			if err != nil {
+				failRobotCommand(err, robot.ErrCodeSessionNotFound, "Use 'ntm list' to see available sessions", "robot-turns")
+				return
+			}
+			kinds, err := turns.ParseKinds(robotTurnKinds)
+			if err != nil {
+				failRobotCommand(err, robot.ErrCodeInvalidFlag, "Use --turn-kinds=diff,error (user_prompt, assistant, tool_call, tool_result, code, diff, error)", "robot-turns")
+				return
+			}
+			var paneFilter []string
+			if robotPanes != "" {
+				paneFilter = strings.Split(robotPanes, ",")
+			}
+			lines := robotLines
+			if !cmd.Flags().Changed("lines") {
+				lines = 500
+			}
+			if err := robot.PrintTurns(cmd.Context(), robot.TurnsOptions{
+				Session: session,
+				Panes:   paneFilter,
+				Lines:   lines,
+				Kinds:   kinds,
+				Limit:   robotTurnLimit,
+			}); err != nil {
+				recordRobotProcessExit(err)
+			}
+			return
+		}
 		if robotKillPane != "" {
 			session, err := resolveRobotLiveSession(cmd.Context(), robotKillPane)
 			if err != nil {
@@ -3994,6 +4054,9 @@ var (
 	robotDialogs           string // session for per-pane dialog classification
 	robotAnswerDialog      string // session for policy-gated dialog answering
 	robotDialogChoice      string // choice for --robot-answer-dialog
+	robotTurns             string // session for per-pane turn segmentation
+	robotTurnKinds         string // segment kinds kept by --robot-turns
+	robotTurnLimit         int    // last N turns per pane for --robot-turns
 	robotIncidentNote      string // optional resolution note recorded with --robot-incident-resolve
 	robotInspectSession    string // session name for projection-backed session inspection
 	robotInspectAgent      string // runtime agent id for projection-backed agent inspection
@@ -4297,7 +4360,7 @@ func init() {
 	rootCmd.Flags().StringVar(&robotWatchBead, "robot-watch-bead", "", "Capture bead mentions across panes plus current bead status (JSON snapshot). Required: SESSION")
 	rootCmd.Flags().StringVar(&robotWatchBeadID, "bead", "", "Bead ID for --robot-watch-bead. Example: --bead=bd-abc123")
 	rootCmd.Flags().StringVar(&robotErrors, "robot-errors", "", "Filter pane output to show only errors. Required: SESSION. Example: ntm --robot-errors=myproject --lines=100")
-	rootCmd.Flags().IntVar(&robotLines, "lines", 20, "Lines to capture per pane. Optional with --robot-tail, --robot-errors, --robot-watch-bead, --robot-is-working, --robot-agent-health, --robot-smart-restart, and --robot-monitor. Example: --lines=100")
+	rootCmd.Flags().IntVar(&robotLines, "lines", 20, "Lines to capture per pane. Optional with --robot-tail, --robot-turns, --robot-errors, --robot-watch-bead, --robot-is-working, --robot-agent-health, --robot-smart-restart, and --robot-monitor. Example: --lines=100")
 	rootCmd.Flags().StringVar(&robotPanes, "panes", "", "Filter with comma-separated N, W.P, or %N pane selectors. Optional with --robot-tail, --robot-watch-bead, --robot-errors, --robot-send, --robot-ack, --robot-interrupt, --robot-wait, --robot-is-working, --robot-agent-health, --robot-smart-restart, --robot-restart-pane, and --robot-monitor. Example: --panes=1,2.0,%7")
 	rootCmd.Flags().StringVar(&robotIsWorking, "robot-is-working", "", "Check if agents are working. Returns work state with recommendations. Required: SESSION. Example: ntm --robot-is-working=myproject --panes=2,3")
 	rootCmd.Flags().BoolVar(&robotIsWorkingVerbose, "is-working-verbose", false, "Include raw sample output in --robot-is-working response. Example: --is-working-verbose")
@@ -4706,6 +4769,9 @@ func init() {
 	rootCmd.Flags().StringVar(&robotDialogs, "robot-dialogs", "", "Classify in-pane interactive dialogs (trust prompt, rate-limit options, usage overlay, paste limbo, destructive confirm) with extracted options. Required: SESSION. Optional: --panes. Example: ntm --robot-dialogs=myproject")
 	rootCmd.Flags().StringVar(&robotAnswerDialog, "robot-answer-dialog", "", "Answer a classified dialog by label with policy gating (accept-side refused on destructive confirms). Required: SESSION, --panes (one pane), --choice. Example: ntm --robot-answer-dialog=myproject --panes=1 --choice=decline")
 	rootCmd.Flags().StringVar(&robotDialogChoice, "choice", "", "Dialog answer for --robot-answer-dialog: decline, extra-usage, dismiss, or option-K")
+	rootCmd.Flags().StringVar(&robotTurns, "robot-turns", "", "Segment pane scrollback into turns: user prompts, assistant text, tool calls/results, code blocks, diffs and errors. Required: SESSION. Optional: --panes, --lines, --turn-kinds, --turn-limit. Example: ntm --robot-turns=myproject --panes=2 --turn-kinds=diff")
+	rootCmd.Flags().StringVar(&robotTurnKinds, "turn-kinds", "", "Comma-separated segment kinds for --robot-turns: user_prompt, assistant, tool_call, tool_result, code, diff, error")
+	rootCmd.Flags().IntVar(&robotTurnLimit, "turn-limit", 0, "Keep only the last N turns per pane for --robot-turns (0 = all)")
 	rootCmd.Flags().StringVar(&robotIncidentNote, "incident-note", "", "Optional resolution note for --robot-incident-resolve")
 
 	rootCmd.Flags().StringVar(&robotMetrics, "robot-metrics", "", "Session metrics export. Optional SESSION. Example: ntm --robot-metrics=myproject --metrics-period=24h")
@@ -5161,6 +5227,7 @@ func init() {
 		newHandoffCmd(),
 		newResumeCmd(),
 		newTimelineCmd(),
+		newRecordingCmd(),
 
 		// Utilities
 		newOverlayCmd(),
@@ -5187,6 +5254,7 @@ func init() {
 		newOpenAPICmd(),
 		newGuardsCmd(),
 		newApproveCmd(),
+		newAutoRespondCmd(),
 		newServeCmd(),
 		newWebCmd(),
 		newSetupCmd(),
@@ -5245,6 +5313,8 @@ func init() {
 		newTemplateCmd(),
 		newSessionTemplatesCmd(),
 		newSessionProfileCmd(), // bd-29kr: session profiles
+		newLayoutCmd(),
+		newPtyCmd(),
 	)
 
 	// Load command plugins
@@ -6480,7 +6550,7 @@ func needsConfigLoading(cmdName string) bool {
 		// Most other robot flags need full config
 		if robotStatus || robotPlan || robotSnapshot || robotTail != "" || robotWatchBead != "" ||
 			robotSend != "" || robotAck != "" || robotSpawn != "" ||
-			robotInterrupt != "" || robotRestartPane != "" || robotExitCLI != "" || robotKillAgent != "" || robotKillPane != "" || robotDialogs != "" || robotAnswerDialog != "" || robotIncidentResolve != "" || robotProbe != "" || robotGraph || robotMail || robotHealth != "" ||
+			robotInterrupt != "" || robotRestartPane != "" || robotExitCLI != "" || robotKillAgent != "" || robotKillPane != "" || robotDialogs != "" || robotAnswerDialog != "" || robotTurns != "" || robotIncidentResolve != "" || robotProbe != "" || robotGraph || robotMail || robotHealth != "" ||
 			robotHealthOAuth != "" || robotHealthRestartStuck != "" || robotLogs != "" || robotDiagnose != "" || robotTerse || robotMarkdown || robotSave != "" || robotRestore != "" ||
 			robotContext != "" || robotEnsemble != "" || robotEnsembleSpawn != "" || robotEnsembleSuggest != "" || robotEnsembleStop != "" || robotAlerts || robotIsWorking != "" || robotAgentHealth != "" ||
 			robotSmartRestart != "" || robotMonitor != "" || robotEnv != "" || robotSupportBundle != "" ||
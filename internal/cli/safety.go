@@ -254,14 +254,27 @@ func runSafetyBlocked(hours int) error {
 }
 
 func newSafetyCheckCmd() *cobra.Command {
-	return &cobra.Command{
+	var contextFlags policyContextFlags
+
+	cmd := &cobra.Command{
 		Use:   "check <command>",
 		Short: "Check a command against safety policy",
-		Args:  cobra.MinimumNArgs(1),
+		Long: `Check a command against the safety policy.
+
+Rules scoped with when: conditions are evaluated for the agent type, session,
+working directory, branch, and time the command runs in. Each defaults to
+what ntm can detect from the environment; use the flags to override.`,
+		Args: cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runSafetyCheck(strings.Join(args, " "))
+			pctx, err := contextFlags.resolve()
+			if err != nil {
+				return err
+			}
+			return runSafetyCheck(strings.Join(args, " "), pctx)
 		},
 	}
+	contextFlags.register(cmd)
+	return cmd
 }
 
 func newSafetySimulateCmd() *cobra.Command {
@@ -306,16 +319,23 @@ type CheckResponse struct {
 	Action  string `json:"action"` // allow, block, approve
 	Pattern string `json:"pattern,omitempty"`
 	Reason  string `json:"reason,omitempty"`
+	// SubCommand is the resolved sub-command the policy matched.
+	SubCommand string `json:"sub_command,omitempty"`
 
 	Policy *CheckPolicyVerdict `json:"policy,omitempty"`
 	DCG    *CheckDCGVerdict    `json:"dcg,omitempty"`
 }
 
 type CheckPolicyVerdict struct {
-	Action  string `json:"action"` // allow, block, approve
-	Pattern string `json:"pattern,omitempty"`
-	Reason  string `json:"reason,omitempty"`
-	SLB     bool   `json:"slb,omitempty"` // Requires SLB two-person approval
+	Action     string `json:"action"` // allow, block, approve
+	Pattern    string `json:"pattern,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+	SubCommand string `json:"sub_command,omitempty"`
+	// Scope lists the matched rule's when: conditions.
+	Scope string `json:"scope,omitempty"`
+	// Source is the policy file and layer the matched rule came from.
+	Source policy.Source `json:"source"`
+	SLB    bool          `json:"slb,omitempty"` // Requires SLB two-person approval
 }
 
 type CheckDCGVerdict struct {
@@ -326,8 +346,8 @@ type CheckDCGVerdict struct {
 	Error     string `json:"error,omitempty"`
 }
 
-func runSafetyCheck(command string) error {
-	resp, exitCode, err := evaluateSafetyCheck(command)
+func runSafetyCheck(command string, pctx policy.Context) error {
+	resp, exitCode, err := evaluateSafetyCheck(command, pctx)
 	if err != nil {
 		return err
 	}
@@ -364,6 +384,13 @@ func runSafetyCheck(command string) error {
 			if resp.Pattern != "" {
 				fmt.Printf("    %s\n", mutedStyle.Render("Pattern: "+resp.Pattern))
 			}
+			if resp.SubCommand != "" && resp.SubCommand != command {
+				fmt.Printf("    %s\n", mutedStyle.Render("Matched: "+resp.SubCommand))
+			}
+			if resp.Policy.Scope != "" {
+				fmt.Printf("    %s\n", mutedStyle.Render("When: "+resp.Policy.Scope))
+			}
+			fmt.Printf("    %s\n", mutedStyle.Render("Rule from: "+resp.Policy.Source.String()))
 			if resp.DCG != nil && resp.DCG.Checked {
 				if resp.DCG.Blocked {
 					fmt.Printf("    %s\n", mutedStyle.Render("DCG: BLOCKED"))
@@ -457,6 +484,9 @@ func renderSafetySimulation(report policy.SimulationReport) {
 			if step.Policy.Pattern != "" {
 				fmt.Printf("     %s %s\n", labelStyle.Render("Pattern:"), mutedStyle.Render(step.Policy.Pattern))
 			}
+			if step.Policy.SubCommand != "" && step.Policy.SubCommand != step.Command {
+				fmt.Printf("     %s %s\n", labelStyle.Render("Matched:"), step.Policy.SubCommand)
+			}
 			if step.RequiresSLB {
 				fmt.Printf("     %s %s\n", labelStyle.Render("Approval:"), warnStyle.Render("SLB required"))
 			}
@@ -474,13 +504,13 @@ func renderSafetySimulation(report policy.SimulationReport) {
 	fmt.Println()
 }
 
-func evaluateSafetyCheck(command string) (CheckResponse, int, error) {
-	p, err := policy.LoadOrDefault()
+func evaluateSafetyCheck(command string, pctx policy.Context) (CheckResponse, int, error) {
+	p, err := policy.LoadForSession(pctx.Session)
 	if err != nil {
 		return CheckResponse{}, 0, fmt.Errorf("loading policy: %w", err)
 	}
 
-	match := p.Check(command)
+	match := p.CheckContext(command, pctx)
 
 	resp := CheckResponse{
 		TimestampedResponse: output.NewTimestamped(),
@@ -491,11 +521,15 @@ func evaluateSafetyCheck(command string) (CheckResponse, int, error) {
 		resp.Action = string(match.Action)
 		resp.Pattern = match.Pattern
 		resp.Reason = match.Reason
+		resp.SubCommand = match.SubCommand
 		resp.Policy = &CheckPolicyVerdict{
-			Action:  string(match.Action),
-			Pattern: match.Pattern,
-			Reason:  match.Reason,
-			SLB:     match.SLB,
+			Action:     string(match.Action),
+			Pattern:    match.Pattern,
+			Reason:     match.Reason,
+			SubCommand: match.SubCommand,
+			Scope:      match.Scope,
+			Source:     match.Source,
+			SLB:        match.SLB,
 		}
 	}
 
@@ -878,9 +912,11 @@ HOOK_INPUT="$(cat)"
 if [ -n "$HOOK_INPUT" ] && command -v jq >/dev/null 2>&1; then
     TOOL_NAME="$(printf '%s' "$HOOK_INPUT" | jq -r '.tool_name // empty' 2>/dev/null)"
     COMMAND="$(printf '%s' "$HOOK_INPUT" | jq -r '.tool_input.command // empty' 2>/dev/null)"
+    HOOK_CWD="$(printf '%s' "$HOOK_INPUT" | jq -r '.cwd // empty' 2>/dev/null)"
 else
     TOOL_NAME=""
     COMMAND=""
+    HOOK_CWD=""
 fi
 
 # Fall back to legacy env vars if a caller still provides them directly.
@@ -900,8 +936,12 @@ if [ -z "$COMMAND" ]; then
     exit 0
 fi
 
-# Check against policy
-check_result=$(ntm safety check "$COMMAND" --json 2>&1)
+# Check against policy as Claude Code, in the tool call's working directory
+context_args=(--agent cc)
+if [ -n "$HOOK_CWD" ]; then
+    context_args+=(--dir "$HOOK_CWD")
+fi
+check_result=$(ntm safety check "$COMMAND" "${context_args[@]}" --json 2>&1)
 exit_code=$?
 
 # ntm safety check exits 0 for allow, 1 for block/approve
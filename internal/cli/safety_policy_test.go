@@ -81,7 +81,7 @@ func TestEvaluateSafetyCheck_DCGMissing_PreservesApprovalRequired(t *testing.T)
 	t.Setenv("HOME", t.TempDir())
 	t.Setenv("PATH", t.TempDir())
 
-	resp, exitCode, err := evaluateSafetyCheck("git commit --amend")
+	resp, exitCode, err := evaluateSafetyCheck("git commit --amend", policy.Context{})
 	if err != nil {
 		t.Fatalf("evaluateSafetyCheck returned error: %v", err)
 	}
@@ -137,7 +137,7 @@ exit 0
 
 	t.Setenv("PATH", dir+":"+os.Getenv("PATH"))
 
-	resp, exitCode, err := evaluateSafetyCheck("git commit --amend")
+	resp, exitCode, err := evaluateSafetyCheck("git commit --amend", policy.Context{})
 	if err != nil {
 		t.Fatalf("evaluateSafetyCheck returned error: %v", err)
 	}
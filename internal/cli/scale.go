@@ -11,6 +11,7 @@ import (
 	"github.com/charmbracelet/huh"
 	"github.com/spf13/cobra"
 
+	"github.com/Dicklesworthstone/ntm/internal/layout"
 	"github.com/Dicklesworthstone/ntm/internal/output"
 	"github.com/Dicklesworthstone/ntm/internal/robot"
 	"github.com/Dicklesworthstone/ntm/internal/tmux"
@@ -438,8 +439,8 @@ func runScale(ctx context.Context, session string, targets []scaleTarget, dryRun
 
 	// Re-tile layout after changes
 	if cancellationErr == nil {
-		if err := tmux.ApplyTiledLayoutContext(ctx, session); err != nil {
-			recordOperationError("apply tiled layout", err)
+		if err := layout.Retile(ctx, session); err != nil {
+			recordOperationError("re-tile layout", err)
 		}
 	}
 
@@ -36,7 +36,9 @@ import (
 	"github.com/Dicklesworthstone/ntm/internal/hooks"
 	"github.com/Dicklesworthstone/ntm/internal/integrations/dcg"
 	"github.com/Dicklesworthstone/ntm/internal/kernel"
+	"github.com/Dicklesworthstone/ntm/internal/layout"
 	"github.com/Dicklesworthstone/ntm/internal/output"
+	"github.com/Dicklesworthstone/ntm/internal/policy"
 	"github.com/Dicklesworthstone/ntm/internal/process"
 	"github.com/Dicklesworthstone/ntm/internal/prompt"
 	"github.com/Dicklesworthstone/ntm/internal/redaction"
@@ -72,7 +74,9 @@ type SendResult struct {
 	// CASSInjection reports send-time CASS context injection (--with-cass),
 	// using the same envelope block contract as --robot-send.
 	CASSInjection *robot.CASSInjectionInfo `json:"cass_injection,omitempty"`
-	Error         string                   `json:"error,omitempty"`
+	// Verification is the per-pane keystroke-level delivery check.
+	Verification []robot.SendVerification `json:"verification,omitempty"`
+	Error        string                   `json:"error,omitempty"`
 }
 
 const (
@@ -2178,6 +2182,7 @@ func runSendInternal(opts SendOptions) (err error) {
 	}
 	delivered = dispatchResult.Delivered
 	failed = dispatchResult.Failed
+	verification := sendVerificationsFromReceipts(dispatchResult.Receipts)
 	var firstDeliveryErr error
 	var firstFailedPane string
 	for _, receipt := range dispatchResult.Receipts {
@@ -2219,6 +2224,7 @@ func runSendInternal(opts SendOptions) (err error) {
 				RoutedTo:             opts.routingResult,
 				DispatchPacing:       dispatchPacing,
 				CASSInjection:        cassInjectionInfo,
+				Verification:         verification,
 				ErrorCode:            errorCode,
 				Error:                firstDeliveryErr.Error(),
 			}
@@ -2243,6 +2249,7 @@ func runSendInternal(opts SendOptions) (err error) {
 			RoutedTo:             opts.routingResult,
 			DispatchPacing:       dispatchPacing,
 			CASSInjection:        cassInjectionInfo,
+			Verification:         verification,
 		}
 		if jsonOutput || opts.executionPolicy == sendExecutionCollect {
 			return finishSendResult(opts, result, nil)
@@ -2304,6 +2311,7 @@ func runSendInternal(opts SendOptions) (err error) {
 		RoutedTo:             opts.routingResult,
 		DispatchPacing:       dispatchPacing,
 		CASSInjection:        cassInjectionInfo,
+		Verification:         verification,
 	}
 	if !result.Success {
 		result.ErrorCode = sendErrorCodeFailed
@@ -2342,6 +2350,18 @@ func runSendInternal(opts SendOptions) (err error) {
 	return nil
 }
 
+// sendVerificationsFromReceipts collects the keystroke-level delivery checks
+// recorded on dispatch receipts.
+func sendVerificationsFromReceipts(receipts []dispatchsvc.Receipt) []robot.SendVerification {
+	var out []robot.SendVerification
+	for _, receipt := range receipts {
+		if receipt.Verification != nil {
+			out = append(out, robot.SendVerification{Pane: receipt.Target.Address, Verification: *receipt.Verification})
+		}
+	}
+	return out
+}
+
 func saveDeliveredPrompt(delivered int, entry sessionPkg.PromptEntry) error {
 	if delivered <= 0 {
 		return nil
@@ -2909,6 +2929,9 @@ func runKill(ctx context.Context, w io.Writer, session string, force bool, tags
 				return fmt.Errorf("killing pane %s: %w", p.ID, err)
 			}
 		}
+		if err := layout.Retile(ctx, session); err != nil {
+			output.PrintWarningf("Failed to re-tile layout: %v", err)
+		}
 		addTimelineStopMarkers(session, toKill)
 		auditKilled = true
 		auditKilledPanes = len(toKill)
@@ -3327,6 +3350,9 @@ func buildKillResponse(ctx context.Context, session string, force bool, tags []s
 				},
 			))
 		}
+		if err := layout.Retile(ctx, session); err != nil {
+			slog.Default().Debug("re-tile after tag kill failed", "session", session, "error", err)
+		}
 		addTimelineStopMarkers(session, toKill)
 		auditKilled = true
 		auditKilledPanes = len(toKill)
@@ -3766,6 +3792,22 @@ func shellFinalMessageRedactor(redactCfg redaction.Config) dispatchsvc.FinalMess
 	})
 }
 
+// shellDispatchPolicyGuard checks each final message against the command
+// policy in the target's context: its agent type, the session, and the
+// session's project directory and branch. Like the serve endpoints, sends
+// proceed unguarded when the policy cannot be loaded.
+func shellDispatchPolicyGuard(session string) dispatchsvc.FinalMessageGuard {
+	p, err := policy.LoadForSession(session)
+	if err != nil {
+		return nil
+	}
+	dir := ""
+	if cfg != nil {
+		dir = cfg.GetProjectDir(session)
+	}
+	return dispatchsvc.PolicyGuard{Policy: p, Base: policy.DirContext(dir)}
+}
+
 func newShellDispatchService(session string, selected []tmux.Pane, redactCfg redaction.Config) (*dispatchsvc.Service, error) {
 	return newShellDispatchServiceWithGate(session, selected, redactCfg, nil)
 }
@@ -3781,6 +3823,7 @@ func newShellDispatchServiceWithGate(
 			return stampMarchingOrders(input.BaseMessage, session, input.Target.Pane.WindowIndex, input.Target.Pane.Index), nil
 		}),
 		Redactor:  shellFinalMessageRedactor(redactCfg),
+		Guard:     shellDispatchPolicyGuard(session),
 		Orderer:   shellDispatchOrderer(selected),
 		Protocols: shellDispatchProtocolPlanner{},
 		Deliverer: dispatchsvc.DelivererFunc(func(ctx context.Context, delivery dispatchsvc.Delivery) error {
@@ -47,6 +47,7 @@ Examples:
   ntm serve                              # Start on default port
   ntm serve --port 8080                  # Start on custom port
   ntm serve --host 0.0.0.0 --auth-mode api_key --api-key $KEY
+  ntm serve --host 0.0.0.0 --auth-mode api_key   # keys from 'ntm serve keys'
   ntm serve --auth-mode oidc --oidc-issuer https://issuer --oidc-jwks-url https://issuer/.well-known/jwks.json`,
 		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -57,7 +58,7 @@ Examples:
 	cmd.Flags().StringVar(&opts.Host, "host", opts.Host, "HTTP bind host (default 127.0.0.1)")
 	cmd.Flags().IntVar(&opts.Port, "port", opts.Port, "HTTP server port")
 	cmd.Flags().StringVar(&opts.AuthMode, "auth-mode", opts.AuthMode, "Auth mode: local|api_key|oidc|mtls")
-	cmd.Flags().StringVar(&opts.APIKey, "api-key", "", "API key for api_key auth mode")
+	cmd.Flags().StringVar(&opts.APIKey, "api-key", "", "Shared API key for api_key auth mode (optional when keys are issued with `ntm serve keys`)")
 	cmd.Flags().StringVar(&opts.OIDCIssuer, "oidc-issuer", "", "OIDC issuer URL for oidc auth mode")
 	cmd.Flags().StringVar(&opts.OIDCAudience, "oidc-audience", "", "OIDC audience for oidc auth mode")
 	cmd.Flags().StringVar(&opts.OIDCJWKSURL, "oidc-jwks-url", "", "JWKS URL for oidc auth mode")
@@ -68,6 +69,7 @@ Examples:
 	cmd.Flags().StringVar(&opts.PublicBaseURL, "public-base-url", "", "Public base URL for external clients (optional)")
 	cmd.Flags().BoolVar(&opts.Web, "web", false, "Also serve the embedded web dashboard at / (same as `ntm web`)")
 
+	cmd.AddCommand(newServeKeysCmd())
 	return cmd
 }
 
@@ -214,6 +216,18 @@ func runServe(opts serveOptions) error {
 			},
 		},
 	}
+	if cfg != nil {
+		serverCfg.Approvals = approvalPolicy(cfg.Approvals)
+		serverCfg.ApprovalNotifier = approvalNotifier()
+	}
+	if cfg != nil && len(cfg.Hosts) > 0 {
+		hosts, err := loadFleet()
+		if err != nil {
+			return err
+		}
+		defer hosts.Close()
+		serverCfg.Fleet = hosts
+	}
 	if opts.Web {
 		ui, err := webui.FS()
 		if err != nil {
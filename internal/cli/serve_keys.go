@@ -0,0 +1,266 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Dicklesworthstone/ntm/internal/serve"
+	"github.com/Dicklesworthstone/ntm/internal/state"
+	"github.com/Dicklesworthstone/ntm/internal/util"
+)
+
+func newServeKeysCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "keys",
+		Short: "Manage scoped API keys for `ntm serve --auth-mode api_key`",
+		Long: `Manage the API key registry used by ntm serve in api_key auth mode.
+
+Each key carries its own role (viewer, operator, admin), an optional expiry,
+and an optional scope restricting it to named sessions and/or projects.
+A session-scoped key must address one of its sessions; collection endpoints
+that span all sessions (session, job and schedule lists) are refused.
+Secrets are shown once at creation or rotation; only a hash is stored.
+Revocation and expiry take effect on the next request — no restart needed.
+
+The shared --api-key flag keeps working alongside registry keys.
+
+Examples:
+  ntm serve keys create --name ci --role operator --expires 30d
+  ntm serve keys create --name dash --role viewer --session myproj
+  ntm serve keys list
+  ntm serve keys revoke key_0123abcd
+  ntm serve keys rotate key_0123abcd`,
+	}
+
+	var (
+		name     string
+		role     string
+		sessions []string
+		projects []string
+		expires  string
+	)
+	createCmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a new API key and print its secret once",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runServeKeysCreate(serve.IssueAPIKeyOptions{
+				Name:     name,
+				Role:     serve.Role(role),
+				Sessions: sessions,
+				Projects: projects,
+			}, expires, IsJSONOutput())
+		},
+	}
+	createCmd.Flags().StringVar(&name, "name", "", "Human-readable label for the key")
+	createCmd.Flags().StringVar(&role, "role", string(serve.RoleViewer), "Role: viewer|operator|admin")
+	createCmd.Flags().StringArrayVar(&sessions, "session", nil, "Restrict the key to a session (repeatable)")
+	createCmd.Flags().StringArrayVar(&projects, "project", nil, "Restrict the key to a project path or directory name (repeatable)")
+	createCmd.Flags().StringVar(&expires, "expires", "", "Key lifetime, e.g. 12h, 30d, 1w (default: never expires)")
+
+	var all bool
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List API keys",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runServeKeysList(all, IsJSONOutput())
+		},
+	}
+	listCmd.Flags().BoolVar(&all, "all", false, "Include revoked keys")
+
+	revokeCmd := &cobra.Command{
+		Use:   "revoke <key-id>",
+		Short: "Revoke an API key immediately",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runServeKeysRevoke(args[0], IsJSONOutput())
+		},
+	}
+
+	var rotateExpires string
+	rotateCmd := &cobra.Command{
+		Use:   "rotate <key-id>",
+		Short: "Replace an API key with a new secret and revoke the old one",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runServeKeysRotate(args[0], rotateExpires, IsJSONOutput())
+		},
+	}
+	rotateCmd.Flags().StringVar(&rotateExpires, "expires", "", "Lifetime of the new key (default: same lifetime as the old key)")
+
+	cmd.AddCommand(createCmd, listCmd, revokeCmd, rotateCmd)
+	return cmd
+}
+
+func openMigratedStateStore() (*state.Store, error) {
+	store, err := state.Open("")
+	if err != nil {
+		return nil, fmt.Errorf("open state store: %w", err)
+	}
+	if err := store.Migrate(); err != nil {
+		store.Close()
+		return nil, fmt.Errorf("apply migrations: %w", err)
+	}
+	return store, nil
+}
+
+func parseKeyTTL(raw string) (time.Duration, error) {
+	if strings.TrimSpace(raw) == "" {
+		return 0, nil
+	}
+	ttl, err := util.ParseDuration(strings.TrimSpace(raw))
+	if err != nil {
+		return 0, fmt.Errorf("invalid --expires %q: %w", raw, err)
+	}
+	if ttl <= 0 {
+		return 0, fmt.Errorf("--expires must be positive")
+	}
+	return ttl, nil
+}
+
+func runServeKeysCreate(opts serve.IssueAPIKeyOptions, expires string, jsonOutput bool) error {
+	ttl, err := parseKeyTTL(expires)
+	if err != nil {
+		return outputError(err, jsonOutput)
+	}
+	opts.TTL = ttl
+
+	store, err := openMigratedStateStore()
+	if err != nil {
+		return outputError(err, jsonOutput)
+	}
+	defer store.Close()
+
+	issued, err := serve.IssueAPIKey(store, opts)
+	if err != nil {
+		return outputError(err, jsonOutput)
+	}
+	return printIssuedKey("Created", issued, jsonOutput)
+}
+
+func runServeKeysRotate(id, expires string, jsonOutput bool) error {
+	ttl, err := parseKeyTTL(expires)
+	if err != nil {
+		return outputError(err, jsonOutput)
+	}
+
+	store, err := openMigratedStateStore()
+	if err != nil {
+		return outputError(err, jsonOutput)
+	}
+	defer store.Close()
+
+	issued, err := serve.RotateAPIKey(store, id, ttl)
+	if err != nil {
+		return outputError(err, jsonOutput)
+	}
+	return printIssuedKey("Rotated "+id+" →", issued, jsonOutput)
+}
+
+func printIssuedKey(verb string, issued *serve.IssuedAPIKey, jsonOutput bool) error {
+	if jsonOutput {
+		return json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+			"success": true,
+			"key":     issued,
+		})
+	}
+	fmt.Printf("✓ %s %s (%s)\n", verb, issued.ID, issued.Role)
+	printKeyScope(&issued.ServeAPIKey)
+	fmt.Printf("\n  Secret: %s\n\n", issued.Secret)
+	fmt.Println("  Store this secret now; it cannot be shown again.")
+	return nil
+}
+
+func runServeKeysList(includeRevoked, jsonOutput bool) error {
+	store, err := openMigratedStateStore()
+	if err != nil {
+		return outputError(err, jsonOutput)
+	}
+	defer store.Close()
+
+	keys, err := store.ListServeAPIKeys(includeRevoked)
+	if err != nil {
+		return outputError(err, jsonOutput)
+	}
+
+	if jsonOutput {
+		if keys == nil {
+			keys = []state.ServeAPIKey{}
+		}
+		return json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+			"success": true,
+			"keys":    keys,
+			"count":   len(keys),
+		})
+	}
+
+	fmt.Println("API Keys:")
+	if len(keys) == 0 {
+		fmt.Println("  (no keys; create one with `ntm serve keys create`)")
+		return nil
+	}
+	now := time.Now()
+	for i := range keys {
+		key := &keys[i]
+		status := "active"
+		switch {
+		case key.RevokedAt != nil:
+			status = "revoked"
+		case !key.Active(now):
+			status = "expired"
+		}
+		fmt.Printf("\n  %s [%s] %s…\n", key.ID, status, key.KeyPrefix)
+		if key.Name != "" {
+			fmt.Printf("    Name:      %s\n", key.Name)
+		}
+		fmt.Printf("    Role:      %s\n", key.Role)
+		printKeyScope(key)
+		fmt.Printf("    Created:   %s\n", key.CreatedAt.Format(time.RFC3339))
+		if key.LastUsedAt != nil {
+			fmt.Printf("    Last used: %s\n", key.LastUsedAt.Format(time.RFC3339))
+		}
+		if key.RotatedFrom != "" {
+			fmt.Printf("    Rotated from: %s\n", key.RotatedFrom)
+		}
+	}
+	return nil
+}
+
+func printKeyScope(key *state.ServeAPIKey) {
+	if len(key.Sessions) > 0 {
+		fmt.Printf("    Sessions:  %s\n", strings.Join(key.Sessions, ", "))
+	}
+	if len(key.Projects) > 0 {
+		fmt.Printf("    Projects:  %s\n", strings.Join(key.Projects, ", "))
+	}
+	if key.ExpiresAt != nil {
+		fmt.Printf("    Expires:   %s\n", key.ExpiresAt.Format(time.RFC3339))
+	}
+}
+
+func runServeKeysRevoke(id string, jsonOutput bool) error {
+	store, err := openMigratedStateStore()
+	if err != nil {
+		return outputError(err, jsonOutput)
+	}
+	defer store.Close()
+
+	if err := serve.RevokeAPIKey(store, id); err != nil {
+		return outputError(err, jsonOutput)
+	}
+	if jsonOutput {
+		return json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+			"success": true,
+			"id":      id,
+			"status":  "revoked",
+		})
+	}
+	fmt.Printf("✓ Revoked: %s\n", id)
+	return nil
+}
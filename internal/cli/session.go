@@ -929,6 +929,7 @@ func newStatusCmd() *cobra.Command {
 	var showSummary bool
 	var watch bool
 	var interval int
+	var fleetView bool
 	cmd := &cobra.Command{
 		Use:   "status <session-name>",
 		Short: "Show detailed status of a session",
@@ -952,9 +953,25 @@ Examples:
   ntm status myproject --assignments --agent=claude
   ntm status myproject --assignments --status=failed --agent=codex
   ntm status myproject --assignments --summary
-  ntm status myproject --watch`,
-		Args: cobra.ExactArgs(1),
+  ntm status myproject --watch
+
+Fleet ([[hosts]] in config):
+  ntm status --fleet                 # Sessions on every configured host
+  ntm status build1:myproject        # Session on host build1`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if fleetView {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if fleetView {
+				return runFleetStatus(cmd.Context(), cmd.OutOrStdout())
+			}
+			session, err := useFleetSession(args[0])
+			if err != nil {
+				return err
+			}
 			opts := statusOptions{
 				tags:            tags,
 				showAssignments: showAssignments,
@@ -965,7 +982,7 @@ Examples:
 				watchMode:       watch,
 				interval:        time.Duration(interval) * time.Millisecond,
 			}
-			return runStatus(cmd.Context(), cmd.OutOrStdout(), args[0], opts)
+			return runStatus(cmd.Context(), cmd.OutOrStdout(), session, opts)
 		},
 	}
 	cmd.Flags().StringSliceVar(&tags, "tag", nil, "filter panes by tag")
@@ -976,6 +993,7 @@ Examples:
 	cmd.Flags().BoolVar(&showSummary, "summary", false, "show assignment summary statistics only")
 	cmd.Flags().BoolVarP(&watch, "watch", "w", false, "auto-refresh display")
 	cmd.Flags().IntVar(&interval, "interval", 2000, "refresh interval in milliseconds (with --watch)")
+	cmd.Flags().BoolVar(&fleetView, "fleet", false, "show sessions on every host declared in [[hosts]]")
 	cmd.ValidArgsFunction = completeSessionArgs
 	return cmd
 }
@@ -34,6 +34,7 @@ import (
 	"github.com/Dicklesworthstone/ntm/internal/handoff"
 	"github.com/Dicklesworthstone/ntm/internal/hooks"
 	"github.com/Dicklesworthstone/ntm/internal/integrations/dcg"
+	"github.com/Dicklesworthstone/ntm/internal/layout"
 	"github.com/Dicklesworthstone/ntm/internal/models"
 	"github.com/Dicklesworthstone/ntm/internal/output"
 	"github.com/Dicklesworthstone/ntm/internal/persona"
@@ -1204,6 +1205,7 @@ type SpawnOptions struct {
 	UserPane           bool
 	AutoRestart        bool
 	RecipeName         string
+	Layout             *layout.Spec // Pane layout applied after launch; nil uses tmux.layout, then plain tiling
 	PersonaMap         map[string]*persona.Persona
 	PluginMap          map[string]plugins.AgentPlugin
 
@@ -1267,6 +1269,8 @@ type SpawnOptions struct {
 	// A non-nil admission means policy and the full actionable set were
 	// verified before spawn, including the valid no-work case of an empty set.
 	assignAdmission *spawnAssignmentAdmission
+	// backendHint is printed after a text-mode spawn (see ptyFollowUpHint).
+	backendHint string
 
 	// CreateDir opts into creating a missing project directory without an
 	// interactive confirm. In non-TTY contexts a missing directory is a
@@ -1582,6 +1586,8 @@ type RecoveryCMRule struct {
 func newSpawnCmd() *cobra.Command {
 	var noUserPane bool
 	var recipeName string
+	var layoutName string
+	var backendName string
 	var templateName string
 	var agentSpecs AgentSpecs
 	var personaSpecs PersonaSpecs
@@ -1770,6 +1776,14 @@ Examples:
 				return err
 			}
 
+			var backendHint string
+			if backendName != "" {
+				backendHint = ptyFollowUpHint(backendName)
+				if err := applyPaneBackend(backendName); err != nil {
+					return err
+				}
+			}
+
 			// Apply goal label to session name (bd-1933u)
 			if label != "" {
 				if err := config.ValidateLabel(label); err != nil {
@@ -1836,6 +1850,16 @@ Examples:
 				}
 			}
 
+			// Layout precedence: --layout, the recipe's layout, tmux.layout.
+			var spawnLayout *layout.Spec
+			if layoutName != "" {
+				spec, err := layout.Lookup(layoutName, customLayouts())
+				if err != nil {
+					return err
+				}
+				spawnLayout = &spec
+			}
+
 			// Handle recipe
 			if recipeName != "" {
 				loader := recipe.NewLoader()
@@ -1851,6 +1875,15 @@ Examples:
 				if err := appendMissingRecipeAgentSpecs(&agentSpecs, personaMap, r.Name, dir, r.Agents); err != nil {
 					return err
 				}
+				if spawnLayout == nil {
+					spec, ok, err := r.ResolveLayout(customLayouts())
+					if err != nil {
+						return fmt.Errorf("recipe %q layout: %w", recipeName, err)
+					}
+					if ok {
+						spawnLayout = &spec
+					}
+				}
 				if !IsJSONOutput() {
 					fmt.Printf("Using recipe '%s': %s\n", r.Name, r.Description)
 				}
@@ -1990,6 +2023,8 @@ Examples:
 				UserPane:                !noUserPane,
 				AutoRestart:             autoRestart,
 				RecipeName:              recipeName,
+				Layout:                  spawnLayout,
+				backendHint:             backendHint,
 				PersonaMap:              personaMap,
 				PluginMap:               pluginMap,
 				CassContextQuery:        contextQuery,
@@ -2063,6 +2098,8 @@ Examples:
 	cmd.Flags().Var(&personaSpecs, "persona", "Persona-defined agents (name or name:count)")
 	cmd.Flags().BoolVar(&noUserPane, "no-user", false, "don't reserve a pane for the user")
 	cmd.Flags().StringVarP(&recipeName, "recipe", "r", "", "use a recipe for agent configuration")
+	cmd.Flags().StringVar(&layoutName, "layout", "", "arrange panes with a named layout (see 'ntm layout list'; default: tmux.layout)")
+	cmd.Flags().StringVar(&backendName, "backend", "", "pane backend: tmux, pty (headless ntm pty daemon), or auto (default: $NTM_BACKEND or tmux.backend)")
 	cmd.Flags().StringVarP(&templateName, "template", "t", "", "size the session from a workflow template's agent counts (counts only; run the coordination with 'ntm workflow run')")
 	cmd.Flags().BoolVar(&autoRestart, "auto-restart", false, "monitor and auto-restart crashed agents")
 
@@ -3394,6 +3431,10 @@ func spawnSessionLogicContextWithOutput(ctx context.Context, opts SpawnOptions,
 		steps.Done()
 	}
 
+	if err := applySpawnLayout(ctx, opts); err != nil && !IsJSONOutput() {
+		output.PrintWarningf("Could not apply layout: %v", err)
+	}
+
 	// Save initial spawn state for dashboard display
 	if spawnState != nil {
 		if err := spawnState.Save(dir); err != nil && !IsJSONOutput() {
@@ -3800,6 +3841,10 @@ func spawnSessionLogicContextWithOutput(ctx context.Context, opts SpawnOptions,
 		}
 	}
 
+	if opts.backendHint != "" && !IsJSONOutput() {
+		output.PrintInfof("%s", opts.backendHint)
+	}
+
 	// Print "What's next?" only after every requested terminal phase succeeds.
 	output.SuccessFooter(output.SpawnSuggestions(opts.Session)...)
 
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestApprovalsTOMLRoundTrip pins the [approvals] schema documented in the
+// generated config: groups, rules with inline quorum/escalation tables and
+// duration strings, and delegations with a TOML datetime.
+func TestApprovalsTOMLRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(`[[approvals.groups]]
+name = "sre"
+members = ["alice", "bob"]
+roles = ["admin"]
+
+[[approvals.groups]]
+name = "leads"
+members = ["dana"]
+
+[[approvals.rules]]
+action = "force_*"
+quorum = [{ group = "sre", count = 2 }]
+escalation = [{ after = "0s", notify = "sre" }, { after = "10m", notify = "leads" }]
+timeout = "30m"
+on_timeout = "deny"
+
+[[approvals.delegations]]
+from = "alice"
+to = "carol"
+until = 2026-12-01T00:00:00Z
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() rejected approvals section: %v", err)
+	}
+	a := cfg.Approvals
+	if len(a.Groups) != 2 || a.Groups[0].Roles[0] != "admin" {
+		t.Errorf("Groups = %+v", a.Groups)
+	}
+	if len(a.Rules) != 1 {
+		t.Fatalf("Rules = %+v", a.Rules)
+	}
+	r := a.Rules[0]
+	if r.Quorum[0].Count != 2 || r.Escalation[1].After != 10*time.Minute || r.Escalation[1].Notify != "leads" || r.Timeout != 30*time.Minute || r.OnTimeout != "deny" {
+		t.Errorf("Rule = %+v", r)
+	}
+	if len(a.Delegations) != 1 || !a.Delegations[0].Until.Equal(time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Delegations = %+v", a.Delegations)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"regexp"
 	"runtime"
 	"sort"
 	"strconv"
@@ -17,6 +18,7 @@ import (
 	"github.com/BurntSushi/toml"
 
 	"github.com/Dicklesworthstone/ntm/internal/agent"
+	"github.com/Dicklesworthstone/ntm/internal/layout"
 	"github.com/Dicklesworthstone/ntm/internal/models"
 	"github.com/Dicklesworthstone/ntm/internal/notify"
 	"github.com/Dicklesworthstone/ntm/internal/persona"
@@ -83,6 +85,8 @@ type Config struct {
 	Palette         []PaletteCmd          `toml:"palette"`
 	PaletteState    PaletteState          `toml:"palette_state"`
 	Tmux            TmuxConfig            `toml:"tmux"`
+	Hosts           []HostConfig          `toml:"hosts"`   // Fleet hosts driven over ssh (ntm status --fleet)
+	Layouts         []layout.Spec         `toml:"layouts"` // Named pane layouts (ntm layout apply)
 	Robot           RobotConfig           `toml:"robot"`
 	CommandHooks    []CommandHookConfig   `toml:"command_hooks"`
 	AgentMail       AgentMailConfig       `toml:"agent_mail"`
@@ -108,10 +112,12 @@ type Config struct {
 	Swarm           SwarmConfig           `toml:"swarm"`            // Weighted multi-project agent swarm
 	SpawnPacing     SpawnPacingConfig     `toml:"spawn_pacing"`     // Spawn scheduler pacing configuration
 	Safety          SafetyConfig          `toml:"safety"`           // Safety profile selection + defaults
+	Approvals       ApprovalsConfig       `toml:"approvals"`        // Approver groups, quorum, escalation, delegation
 	Preflight       PreflightConfig       `toml:"preflight"`        // Prompt preflight/lint configuration
 	Redaction       RedactionConfig       `toml:"redaction"`        // Secrets/PII redaction configuration
 	Privacy         PrivacyConfig         `toml:"privacy"`          // Privacy mode configuration
 	Encryption      EncryptionConfig      `toml:"encryption"`       // Encryption at rest for artifacts
+	Audit           AuditConfig           `toml:"audit"`            // Audit log checkpoint signing and mirroring
 	Send            SendConfig            `toml:"send"`             // Send command defaults
 	Prompts         PromptsConfig         `toml:"prompts"`          // Per-agent-type default prompts
 	Retry           RetryConfig           `toml:"retry"`            // Unified retry policy configuration
@@ -1161,6 +1167,90 @@ type TmuxConfig struct {
 	DefaultPanes    int `toml:"default_panes"`
 	PaneInitDelayMs int `toml:"pane_init_delay_ms"` // Delay before sending keys to new panes
 	HistoryLimit    int `toml:"history_limit"`      // Scrollback buffer lines per pane (default 50000)
+	// ControlMode sends tmux commands over one persistent `tmux -C`
+	// connection and streams pane output from %output notifications.
+	// NTM_TMUX_CONTROL_MODE=1 enables it without a config change.
+	ControlMode bool `toml:"control_mode"`
+	// Layout names the layout spawn applies to new sessions (see [[layouts]]
+	// and ntm layout list). Empty keeps the plain tiled layout.
+	Layout string `toml:"layout"`
+	// Backend selects where panes run: "tmux", "pty" (the headless ntm pty
+	// daemon) or "auto"/empty (tmux when installed, else pty). NTM_BACKEND
+	// overrides it.
+	Backend string `toml:"backend"`
+}
+
+// ValidatePaneBackend checks a tmux.backend value.
+func ValidatePaneBackend(name string) error {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "auto", "tmux", "pty":
+		return nil
+	}
+	return fmt.Errorf("tmux.backend: unknown backend %q (want auto, tmux or pty)", name)
+}
+
+// ValidateLayouts checks [[layouts]] definitions and that tmux.layout names
+// a known layout.
+func ValidateLayouts(layouts []layout.Spec, defaultLayout string) error {
+	seen := make(map[string]bool, len(layouts))
+	for i, l := range layouts {
+		if err := l.Validate(); err != nil {
+			return fmt.Errorf("layouts[%d]: %w", i, err)
+		}
+		key := strings.ToLower(l.Name)
+		if seen[key] {
+			return fmt.Errorf("layouts[%d]: duplicate name %q", i, l.Name)
+		}
+		seen[key] = true
+	}
+	if defaultLayout != "" {
+		if _, err := layout.Lookup(defaultLayout, layouts); err != nil {
+			return fmt.Errorf("tmux.layout: %w", err)
+		}
+	}
+	return nil
+}
+
+// LocalHostName is the fleet name of the tmux server ntm runs against by
+// default. It is implicit and cannot be declared in [[hosts]].
+const LocalHostName = "local"
+
+// HostConfig declares one machine in the fleet. Sessions on it are addressed
+// as "<name>:<session>".
+type HostConfig struct {
+	Name         string   `toml:"name"`          // Short host name used in host:session references
+	SSH          string   `toml:"ssh"`           // ssh target ("user@host" or an ssh_config alias); empty for this machine
+	Socket       string   `toml:"socket"`        // tmux -L socket name on the host (default server when empty)
+	ProjectsBase string   `toml:"projects_base"` // Project directory on the host (defaults to projects_base)
+	Labels       []string `toml:"labels"`        // Free-form labels for filtering, e.g. ["gpu", "linux"]
+}
+
+var hostNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// ValidateHosts checks that host names are unique, usable in host:session
+// references, and that every host points somewhere other than the default
+// local server.
+func ValidateHosts(hosts []HostConfig) error {
+	seen := make(map[string]bool, len(hosts))
+	for i, h := range hosts {
+		if !hostNamePattern.MatchString(h.Name) {
+			return fmt.Errorf("hosts[%d]: invalid name %q (letters, digits, '-' and '_' only)", i, h.Name)
+		}
+		if h.Name == LocalHostName {
+			return fmt.Errorf("hosts[%d]: name %q is reserved for this machine's default tmux server", i, h.Name)
+		}
+		if seen[h.Name] {
+			return fmt.Errorf("hosts[%d]: duplicate name %q", i, h.Name)
+		}
+		seen[h.Name] = true
+		if h.SSH == "" && h.Socket == "" {
+			return fmt.Errorf("hosts[%d] (%s): ssh or socket is required", i, h.Name)
+		}
+		if strings.HasPrefix(h.SSH, "-") {
+			return fmt.Errorf("hosts[%d] (%s): ssh target cannot start with '-'", i, h.Name)
+		}
+	}
+	return nil
 }
 
 // AgentMailConfig holds Agent Mail server settings
@@ -2026,6 +2116,55 @@ func ValidateSafetyConfig(cfg *SafetyConfig) error {
 	}
 }
 
+// ApprovalsConfig configures native multi-party approval for `ntm approve`
+// and the serve approval API: approver groups, quorum and escalation rules
+// per action, and delegations. Mirrors internal/approval.Policy for TOML
+// deserialization without import cycles; the approval engine validates it.
+type ApprovalsConfig struct {
+	Groups      []ApprovalGroupConfig      `toml:"groups"`
+	Rules       []ApprovalRuleConfig       `toml:"rules"`
+	Delegations []ApprovalDelegationConfig `toml:"delegations"`
+}
+
+// ApprovalGroupConfig names a set of approvers by identity or by serve
+// RBAC role / OIDC group claim.
+type ApprovalGroupConfig struct {
+	Name    string   `toml:"name"`
+	Members []string `toml:"members"` // Approver identities (NTM_USER, OIDC sub/email)
+	Roles   []string `toml:"roles"`   // Serve roles or OIDC group claims that confer membership
+}
+
+// ApprovalRuleConfig governs requests whose action matches Action.
+type ApprovalRuleConfig struct {
+	Action     string                     `toml:"action"` // Glob over approval actions, e.g. "force_*"
+	SLB        bool                       `toml:"slb"`    // Only requests that require the two-person rule
+	Quorum     []ApprovalQuorumConfig     `toml:"quorum"`
+	Escalation []ApprovalEscalationConfig `toml:"escalation"`
+	Timeout    time.Duration              `toml:"timeout"`    // From request creation, e.g. "30m"
+	OnTimeout  string                     `toml:"on_timeout"` // deny | approve (empty: expire normally)
+}
+
+// ApprovalQuorumConfig asks for Count approvals from Group.
+type ApprovalQuorumConfig struct {
+	Group string `toml:"group"`
+	Count int    `toml:"count"`
+}
+
+// ApprovalEscalationConfig notifies Notify once a request has been pending
+// for After.
+type ApprovalEscalationConfig struct {
+	After  time.Duration `toml:"after"`
+	Notify string        `toml:"notify"`
+}
+
+// ApprovalDelegationConfig lets To vote for From until Until (unset: until
+// removed).
+type ApprovalDelegationConfig struct {
+	From  string    `toml:"from"`
+	To    string    `toml:"to"`
+	Until time.Time `toml:"until"`
+}
+
 // PreflightConfig controls prompt preflight (lint/validation) defaults.
 // The preflight.enabled key was deprecated in v1.28.0 (bd-6otuk): no send
 // path ever consulted it (it was surfaced in doctor output only).
@@ -2249,6 +2388,82 @@ func ValidateEncryptionConfig(cfg *EncryptionConfig) error {
 	return nil
 }
 
+// AuditConfig controls the tamper-evident audit log.
+type AuditConfig struct {
+	Anchor AuditAnchorConfig `toml:"anchor"`
+}
+
+// AuditAnchorConfig controls signed checkpoints of each audit log's hash
+// chain head. The signing key is an ed25519 seed (32 bytes) resolved like the
+// [encryption] key; checkpoints land next to the logs and, optionally, in a
+// second append-only location the log writer cannot rewrite.
+type AuditAnchorConfig struct {
+	// Enabled turns on periodic checkpoint signing (default false).
+	Enabled bool `toml:"enabled"`
+	// IntervalSeconds is the minimum time between checkpoints of one log; the
+	// final head is always signed when the log closes.
+	IntervalSeconds int `toml:"interval_seconds"`
+	// KeySource selects how the signing key is provided: env, file, or command.
+	KeySource string `toml:"key_source"`
+	// KeyEnv is the environment variable name holding the key (for key_source=env).
+	KeyEnv string `toml:"key_env"`
+	// KeyFile is the path to a file containing the key (for key_source=file).
+	KeyFile string `toml:"key_file"`
+	// KeyCommand is a shell command that prints the key to stdout (for key_source=command).
+	KeyCommand string `toml:"key_command"`
+	// KeyFormat is the encoding of the key material: hex or base64.
+	KeyFormat string `toml:"key_format"`
+	// MirrorDir receives a copy of every checkpoint (optional).
+	MirrorDir string `toml:"mirror_dir"`
+	// GitNotesRepo mirrors checkpoints as git notes in this repository (optional).
+	GitNotesRepo string `toml:"git_notes_repo"`
+	// GitNotesRef is the notes ref used with GitNotesRepo.
+	GitNotesRef string `toml:"git_notes_ref"`
+}
+
+// DefaultAuditConfig returns sensible audit defaults (anchoring disabled).
+func DefaultAuditConfig() AuditConfig {
+	return AuditConfig{
+		Anchor: AuditAnchorConfig{
+			Enabled:         false,
+			IntervalSeconds: 300,
+			KeySource:       "env",
+			KeyEnv:          "NTM_AUDIT_SIGNING_KEY",
+			KeyFormat:       "hex",
+			GitNotesRef:     "ntm-audit",
+		},
+	}
+}
+
+// ValidateAuditConfig validates the audit configuration.
+func ValidateAuditConfig(cfg *AuditConfig) error {
+	a := &cfg.Anchor
+	if !a.Enabled {
+		return nil
+	}
+	if a.IntervalSeconds < 0 {
+		return fmt.Errorf("anchor.interval_seconds must be non-negative, got %d", a.IntervalSeconds)
+	}
+	switch a.KeySource {
+	case "env", "file", "command":
+		// valid
+	case "":
+		return fmt.Errorf("anchor.key_source is required when anchoring is enabled")
+	default:
+		return fmt.Errorf("invalid anchor.key_source %q: must be env, file, or command", a.KeySource)
+	}
+	switch a.KeyFormat {
+	case "hex", "base64", "":
+		// valid (empty defaults to hex)
+	default:
+		return fmt.Errorf("invalid anchor.key_format %q: must be hex or base64", a.KeyFormat)
+	}
+	if a.GitNotesRepo != "" && strings.TrimSpace(a.GitNotesRef) == "" {
+		return fmt.Errorf("anchor.git_notes_ref is required when anchor.git_notes_repo is set")
+	}
+	return nil
+}
+
 // SendConfig holds defaults for the send command.
 type SendConfig struct {
 	BasePrompt     string `toml:"base_prompt"`      // Text prepended to all prompts
@@ -2383,6 +2598,7 @@ func Default() *Config {
 		Redaction:       DefaultRedactionConfig(),
 		Privacy:         DefaultPrivacyConfig(),
 		Encryption:      DefaultEncryptionConfig(),
+		Audit:           DefaultAuditConfig(),
 		SpawnPacing:     DefaultSpawnPacingConfig(),
 		Retry:           DefaultRetryConfig(),
 		Routing:         DefaultRoutingConfig(),
@@ -3624,6 +3840,9 @@ func Print(cfg *Config, w io.Writer) error {
 	fmt.Fprintf(w, "default_panes = %d\n", cfg.Tmux.DefaultPanes)
 	fmt.Fprintf(w, "pane_init_delay_ms = %d  # Delay before send-keys to new panes\n", cfg.Tmux.PaneInitDelayMs)
 	fmt.Fprintf(w, "history_limit = %d       # Scrollback buffer lines per pane\n", cfg.Tmux.HistoryLimit)
+	fmt.Fprintf(w, "control_mode = %t        # Persistent tmux -C connection instead of one process per command\n", cfg.Tmux.ControlMode)
+	fmt.Fprintf(w, "layout = %q                 # Layout applied on spawn (grid, main-vertical, by-agent, paged, or a [[layouts]] name)\n", cfg.Tmux.Layout)
+	fmt.Fprintf(w, "backend = %q                # Pane backend: auto, tmux, or pty (headless ntm pty daemon)\n", cfg.Tmux.Backend)
 	fmt.Fprintln(w)
 
 	fmt.Fprintln(w, "[robot]")
@@ -3751,6 +3970,23 @@ func Print(cfg *Config, w io.Writer) error {
 	fmt.Fprintf(w, "profile = %q\n", cfg.Safety.Profile)
 	fmt.Fprintln(w)
 
+	fmt.Fprintln(w, "# [approvals] — quorum, escalation and delegation for ntm approve")
+	fmt.Fprintln(w, "# [[approvals.groups]]")
+	fmt.Fprintln(w, "# name = \"sre\"")
+	fmt.Fprintln(w, "# members = [\"alice\", \"bob\"]")
+	fmt.Fprintln(w, "# roles = [\"admin\"]            # serve RBAC roles or OIDC group claims")
+	fmt.Fprintln(w, "# [[approvals.rules]]")
+	fmt.Fprintln(w, "# action = \"force_*\"")
+	fmt.Fprintln(w, "# quorum = [{ group = \"sre\", count = 2 }]")
+	fmt.Fprintln(w, "# escalation = [{ after = \"0s\", notify = \"sre\" }, { after = \"10m\", notify = \"leads\" }]")
+	fmt.Fprintln(w, "# timeout = \"30m\"")
+	fmt.Fprintln(w, "# on_timeout = \"deny\"           # deny|approve")
+	fmt.Fprintln(w, "# [[approvals.delegations]]")
+	fmt.Fprintln(w, "# from = \"alice\"")
+	fmt.Fprintln(w, "# to = \"carol\"")
+	fmt.Fprintln(w, "# until = 2026-12-01T00:00:00Z")
+	fmt.Fprintln(w)
+
 	fmt.Fprintln(w, "[preflight]")
 	fmt.Fprintln(w, "# Prompt preflight (lint/validation) defaults")
 	fmt.Fprintf(w, "strict = %t\n", cfg.Preflight.Strict)
@@ -3815,6 +4051,37 @@ func Print(cfg *Config, w io.Writer) error {
 	}
 	fmt.Fprintln(w)
 
+	anchor := cfg.Audit.Anchor
+	fmt.Fprintln(w, "[audit.anchor]")
+	fmt.Fprintln(w, "# Signed checkpoints of the audit log hash chain (ed25519 seed, e.g. openssl rand -hex 32)")
+	fmt.Fprintf(w, "enabled = %t\n", anchor.Enabled)
+	fmt.Fprintf(w, "interval_seconds = %d\n", anchor.IntervalSeconds)
+	fmt.Fprintf(w, "key_source = %q\n", anchor.KeySource)
+	fmt.Fprintf(w, "key_env = %q\n", anchor.KeyEnv)
+	if anchor.KeyFile != "" {
+		fmt.Fprintf(w, "key_file = %q\n", anchor.KeyFile)
+	} else {
+		fmt.Fprintln(w, "# key_file = \"\"")
+	}
+	if anchor.KeyCommand != "" {
+		fmt.Fprintf(w, "key_command = %q\n", anchor.KeyCommand)
+	} else {
+		fmt.Fprintln(w, "# key_command = \"\"")
+	}
+	fmt.Fprintf(w, "key_format = %q\n", anchor.KeyFormat)
+	if anchor.MirrorDir != "" {
+		fmt.Fprintf(w, "mirror_dir = %q\n", anchor.MirrorDir)
+	} else {
+		fmt.Fprintln(w, "# mirror_dir = \"/var/lib/ntm-audit-anchors\"")
+	}
+	if anchor.GitNotesRepo != "" {
+		fmt.Fprintf(w, "git_notes_repo = %q\n", anchor.GitNotesRepo)
+	} else {
+		fmt.Fprintln(w, "# git_notes_repo = \"/srv/audit-anchors.git\"")
+	}
+	fmt.Fprintf(w, "git_notes_ref = %q\n", anchor.GitNotesRef)
+	fmt.Fprintln(w)
+
 	fmt.Fprintln(w, "[send]")
 	fmt.Fprintln(w, "# Defaults prepended to outbound send/broadcast prompts")
 	if cfg.Send.BasePrompt != "" {
@@ -4560,6 +4827,12 @@ func GetValue(cfg *Config, path string) (interface{}, error) {
 			return cfg.Tmux.PaneInitDelayMs, nil
 		case "history_limit":
 			return cfg.Tmux.HistoryLimit, nil
+		case "control_mode":
+			return cfg.Tmux.ControlMode, nil
+		case "layout":
+			return cfg.Tmux.Layout, nil
+		case "backend":
+			return cfg.Tmux.Backend, nil
 		}
 	case "robot":
 		if len(parts) < 2 {
@@ -5419,6 +5692,9 @@ func Diff(cfg *Config) []ConfigDiff {
 	addDiff("tmux.default_panes", defaults.Tmux.DefaultPanes, cfg.Tmux.DefaultPanes)
 	addDiff("tmux.pane_init_delay_ms", defaults.Tmux.PaneInitDelayMs, cfg.Tmux.PaneInitDelayMs)
 	addDiff("tmux.history_limit", defaults.Tmux.HistoryLimit, cfg.Tmux.HistoryLimit)
+	addDiff("tmux.control_mode", defaults.Tmux.ControlMode, cfg.Tmux.ControlMode)
+	addDiff("tmux.layout", defaults.Tmux.Layout, cfg.Tmux.Layout)
+	addDiff("tmux.backend", defaults.Tmux.Backend, cfg.Tmux.Backend)
 
 	// Robot
 	addDiff("robot.verbosity", defaults.Robot.Verbosity, cfg.Robot.Verbosity)
@@ -5765,6 +6041,18 @@ func Validate(cfg *Config) []error {
 		errs = append(errs, fmt.Errorf("command_hooks: %w", err))
 	}
 
+	if err := ValidateHosts(cfg.Hosts); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := ValidateLayouts(cfg.Layouts, cfg.Tmux.Layout); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := ValidatePaneBackend(cfg.Tmux.Backend); err != nil {
+		errs = append(errs, err)
+	}
+
 	// Validate safety profile and preflight configuration
 	if err := ValidateSafetyConfig(&cfg.Safety); err != nil {
 		errs = append(errs, fmt.Errorf("safety: %w", err))
@@ -5788,6 +6076,11 @@ func Validate(cfg *Config) []error {
 		errs = append(errs, fmt.Errorf("encryption: %w", err))
 	}
 
+	// Validate audit anchoring configuration
+	if err := ValidateAuditConfig(&cfg.Audit); err != nil {
+		errs = append(errs, fmt.Errorf("audit: %w", err))
+	}
+
 	// Validate spawn pacing config
 	if err := ValidateSpawnPacingConfig(&cfg.SpawnPacing); err != nil {
 		errs = append(errs, fmt.Errorf("spawn_pacing: %w", err))
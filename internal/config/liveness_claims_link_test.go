@@ -13,6 +13,7 @@ import (
 	_ "github.com/Dicklesworthstone/ntm/internal/cli"             // cli-consumed keys (liveness_claims.go) + memory.send_* + cass.*
 	_ "github.com/Dicklesworthstone/ntm/internal/context"         // context_rotation.*
 	_ "github.com/Dicklesworthstone/ntm/internal/coordinator"     // integrations.caam.* + rotation.thresholds.restart_*
+	_ "github.com/Dicklesworthstone/ntm/internal/fleet"           // hosts.*
 	_ "github.com/Dicklesworthstone/ntm/internal/integrations/pt" // integrations.process_triage.*
 	_ "github.com/Dicklesworthstone/ntm/internal/privacy"         // privacy.*
 	_ "github.com/Dicklesworthstone/ntm/internal/quota"           // rotation.thresholds.{warning,critical}_percent
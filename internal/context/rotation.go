@@ -3,6 +3,7 @@
 package context
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
@@ -13,6 +14,7 @@ import (
 	"github.com/Dicklesworthstone/ntm/internal/agent"
 	"github.com/Dicklesworthstone/ntm/internal/alerts"
 	"github.com/Dicklesworthstone/ntm/internal/config"
+	"github.com/Dicklesworthstone/ntm/internal/layout"
 	"github.com/Dicklesworthstone/ntm/internal/tmux"
 )
 
@@ -196,17 +198,27 @@ func (s *DefaultPaneSpawner) SpawnAgent(session, agentType string, index int, va
 		return "", fmt.Errorf("launching agent: %w", err)
 	}
 
-	// Apply tiled layout (best-effort)
-	if err := tmux.ApplyTiledLayout(session); err != nil {
-		slog.Warn("failed to apply tiled layout after spawn", "session", session, "error", err)
+	// Re-tile with the session's active layout (best-effort)
+	if err := layout.Retile(context.Background(), session); err != nil {
+		slog.Warn("failed to re-tile layout after spawn", "session", session, "error", err)
 	}
 
 	return paneID, nil
 }
 
-// KillPane terminates a pane.
+// KillPane terminates a pane and re-tiles the session it leaves.
 func (s *DefaultPaneSpawner) KillPane(paneID string) error {
-	return tmux.KillPane(paneID)
+	ctx := context.Background()
+	session, _ := tmux.DefaultClient.RunContext(ctx, "display-message", "-p", "-t", tmux.ExactTarget(paneID), "#{session_name}")
+	if err := tmux.KillPane(paneID); err != nil {
+		return err
+	}
+	if session = strings.TrimSpace(session); session != "" {
+		if err := layout.Retile(ctx, session); err != nil {
+			slog.Warn("failed to re-tile layout after kill", "session", session, "error", err)
+		}
+	}
+	return nil
 }
 
 // SendKeys sends text to a pane.
@@ -15,6 +15,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/Dicklesworthstone/ntm/internal/policy"
 	"github.com/Dicklesworthstone/ntm/internal/tmux"
 )
 
@@ -29,6 +30,8 @@ const (
 	ErrMessageBuild              ErrorCode = "message_build_failed"
 	ErrRedaction                 ErrorCode = "redaction_failed"
 	ErrRedactionBlocked          ErrorCode = "redaction_blocked"
+	ErrGuard                     ErrorCode = "guard_failed"
+	ErrPolicyBlocked             ErrorCode = "policy_blocked"
 	ErrPromptDeliveryUnsupported ErrorCode = "prompt_delivery_unsupported"
 	ErrProtocol                  ErrorCode = "protocol_failed"
 	ErrLifecycle                 ErrorCode = "lifecycle_failed"
@@ -180,6 +183,9 @@ type Receipt struct {
 	Redaction        RedactionReceipt `json:"redaction"`
 	Warnings         []string         `json:"warnings,omitempty"`
 	Error            string           `json:"error,omitempty"`
+	// Verification is the keystroke-level delivery check, present when the
+	// deliverer implements VerifyingDeliverer and typed into the pane.
+	Verification *Verification `json:"verification,omitempty"`
 }
 
 // Result is the complete safe receipt envelope for a dispatch.
@@ -261,6 +267,55 @@ func (AllowAllRedactor) RedactFinalMessage(_ context.Context, _ Target, message
 	return RedactionResult{Message: message, Mode: "off"}, nil
 }
 
+// GuardInput is one redacted final message about to be delivered to Target.
+type GuardInput struct {
+	Session string
+	Target  Target
+	Message string
+}
+
+// GuardVerdict is a guard's decision on one final message.
+type GuardVerdict struct {
+	Blocked bool
+	Reason  string
+}
+
+// FinalMessageGuard vets each redacted final message before delivery. It is
+// optional; without one every message that survives redaction is delivered.
+type FinalMessageGuard interface {
+	GuardFinalMessage(context.Context, GuardInput) (GuardVerdict, error)
+}
+
+// FinalMessageGuardFunc adapts a function to FinalMessageGuard.
+type FinalMessageGuardFunc func(context.Context, GuardInput) (GuardVerdict, error)
+
+func (f FinalMessageGuardFunc) GuardFinalMessage(ctx context.Context, in GuardInput) (GuardVerdict, error) {
+	return f(ctx, in)
+}
+
+// PolicyGuard blocks final messages the command policy blocks when checked
+// in the target's context: Base (working directory, branch, time) with the
+// dispatch session and the target's agent type filled in.
+type PolicyGuard struct {
+	Policy *policy.Policy
+	Base   policy.Context
+}
+
+func (g PolicyGuard) GuardFinalMessage(_ context.Context, in GuardInput) (GuardVerdict, error) {
+	if g.Policy == nil {
+		return GuardVerdict{}, nil
+	}
+	pctx := g.Base
+	pctx.Session = in.Session
+	pctx.Project, pctx.Label = "", ""
+	pctx.AgentType = string(in.Target.AgentType)
+	match := g.Policy.CheckContext(in.Message, pctx)
+	if match == nil || match.Action != policy.ActionBlock {
+		return GuardVerdict{}, nil
+	}
+	return GuardVerdict{Blocked: true, Reason: match.Reason}, nil
+}
+
 // ProtocolPlanner chooses the submission protocol for one target.
 type ProtocolPlanner interface {
 	PlanDelivery(context.Context, Target, bool) (ProtocolPlan, error)
@@ -319,12 +374,25 @@ func RefuseDeadAgentPane(pane tmux.Pane) error {
 		pane.Ref().Physical(), pane.Title, pane.Command)
 }
 
-func (TMUXDeliverer) Deliver(ctx context.Context, delivery Delivery) error {
+func (d TMUXDeliverer) Deliver(ctx context.Context, delivery Delivery) error {
+	_, err := d.DeliverVerified(ctx, delivery)
+	return err
+}
+
+// DeliverVerified delivers like Deliver and reports keystroke-level
+// verification: the composer region is diffed before and after typing, and
+// lost, partial, or stranded prompts are retried with escalating strategies
+// (see deliverVerified). The Verification is nil when delivery was refused
+// before any keystroke was sent.
+func (TMUXDeliverer) DeliverVerified(ctx context.Context, delivery Delivery) (*Verification, error) {
 	if err := contextError(ctx); err != nil {
-		return err
+		return nil, err
 	}
 	if err := RefuseDeadAgentPane(delivery.Target.Pane); err != nil {
-		return err
+		return nil, err
+	}
+	if err := validateDeliveryProtocol(delivery); err != nil {
+		return nil, err
 	}
 	target := delivery.Target.Ref.ID
 	if target == "" {
@@ -337,27 +405,12 @@ func (TMUXDeliverer) Deliver(ctx context.Context, delivery Delivery) error {
 	// unknown agent types, so it can only refuse when the screen positively
 	// shows neither a composer nor working-state chrome.
 	if ready, reason := tmux.ComposerReadyForDelivery(ctx, target, delivery.Target.AgentType, delivery.Target.Pane.Width); !ready {
-		return fmt.Errorf("pane %s not ready for delivery: %s", target, reason)
+		return nil, fmt.Errorf("pane %s not ready for delivery: %s", target, reason)
 	}
 	if err := ClearComposerForDelivery(ctx, target, delivery); err != nil {
-		return err
-	}
-	switch delivery.Protocol {
-	case ProtocolStageOnly:
-		return tmux.SendKeysForAgentWithDelayContext(ctx, target, delivery.Message, false, 0, delivery.Target.AgentType)
-	case ProtocolSingleEnter:
-		return tmux.SendKeysForAgentWithDelayContext(ctx, target, delivery.Message, true, delivery.EnterDelay, delivery.Target.AgentType)
-	case ProtocolDoubleEnter:
-		if delivery.EnterDelay != tmux.DoubleEnterFirstDelay || delivery.SecondEnterDelay != tmux.DoubleEnterSecondDelay {
-			return fmt.Errorf("tmux double-enter protocol requires delays %s and %s", tmux.DoubleEnterFirstDelay, tmux.DoubleEnterSecondDelay)
-		}
-		if err := tmux.SendKeysForAgentDoubleEnterContext(ctx, target, delivery.Message, delivery.Target.AgentType); err != nil {
-			return err
-		}
-		return VerifyAgentSubmission(ctx, target, delivery.Message, delivery.Target.AgentType, delivery.Target.Pane.Width)
-	default:
-		return fmt.Errorf("unsupported delivery protocol %q", delivery.Protocol)
+		return nil, err
 	}
+	return deliverVerified(ctx, target, delivery)
 }
 
 // ClearComposerForDelivery runs the pre-send composer clear when the
@@ -478,6 +531,7 @@ func (TimerPacer) Wait(ctx context.Context, pace Pace) error {
 type Ports struct {
 	Builder   FinalMessageBuilder
 	Redactor  FinalMessageRedactor
+	Guard     FinalMessageGuard
 	Orderer   TargetOrderer
 	Protocols ProtocolPlanner
 	Deliverer Deliverer
@@ -489,6 +543,7 @@ type Ports struct {
 type Service struct {
 	builder   FinalMessageBuilder
 	redactor  FinalMessageRedactor
+	guard     FinalMessageGuard
 	orderer   TargetOrderer
 	protocols ProtocolPlanner
 	deliverer Deliverer
@@ -522,6 +577,7 @@ func NewService(ports Ports) (*Service, error) {
 	return &Service{
 		builder:   ports.Builder,
 		redactor:  ports.Redactor,
+		guard:     ports.Guard,
 		orderer:   ports.Orderer,
 		protocols: ports.Protocols,
 		deliverer: ports.Deliverer,
@@ -691,6 +747,19 @@ func (s *Service) Prepare(ctx context.Context, req Request) (prepared *Prepared,
 		if strings.TrimSpace(redacted.Message) == "" && !req.AllowEmptyMessage {
 			return rejectPrepared(prepared, i, ErrRedaction, errors.New("redactor returned an empty final message"), ReceiptFailed)
 		}
+		if s.guard != nil {
+			verdict, err := s.guard.GuardFinalMessage(ctx, GuardInput{
+				Session: req.Session,
+				Target:  cloneTarget(target),
+				Message: redacted.Message,
+			})
+			if err != nil {
+				return rejectPrepared(prepared, i, ErrGuard, err, ReceiptFailed)
+			}
+			if verdict.Blocked {
+				return rejectPrepared(prepared, i, ErrPolicyBlocked, fmt.Errorf("final message blocked by safety policy: %s", verdict.Reason), ReceiptBlocked)
+			}
+		}
 
 		plan, err := s.protocols.PlanDelivery(ctx, cloneTarget(target), req.Submit)
 		if err != nil {
@@ -788,7 +857,7 @@ func (s *Service) Dispatch(ctx context.Context, prepared *Prepared) (result Resu
 		}
 
 		entry := &prepared.entries[i]
-		if err := s.deliverer.Deliver(ctx, cloneDelivery(entry.delivery)); err != nil {
+		if err := s.deliver(ctx, entry); err != nil {
 			entry.receipt.Status = ReceiptFailed
 			entry.receipt.Error = err.Error()
 			if firstErr == nil {
@@ -812,6 +881,18 @@ func (s *Service) Dispatch(ctx context.Context, prepared *Prepared) (result Resu
 	return result, firstErr
 }
 
+// deliver actuates one entry, recording keystroke verification on its
+// receipt when the deliverer provides it.
+func (s *Service) deliver(ctx context.Context, entry *preparedDelivery) error {
+	verifying, ok := s.deliverer.(VerifyingDeliverer)
+	if !ok {
+		return s.deliverer.Deliver(ctx, cloneDelivery(entry.delivery))
+	}
+	verification, err := verifying.DeliverVerified(ctx, cloneDelivery(entry.delivery))
+	entry.receipt.Verification = verification
+	return err
+}
+
 func (s *Service) notifyReceipt(ctx context.Context, delivery Delivery, receipt Receipt) {
 	if s.lifecycle.AfterReceipt != nil {
 		s.lifecycle.AfterReceipt(ctx, cloneDelivery(delivery), cloneReceipt(receipt))
@@ -1191,6 +1272,7 @@ func deliveriesFromEntries(entries []preparedDelivery) []Delivery {
 func cloneReceipt(receipt Receipt) Receipt {
 	receipt.Target = cloneTarget(receipt.Target)
 	receipt.Warnings = append([]string(nil), receipt.Warnings...)
+	receipt.Verification = cloneVerification(receipt.Verification)
 	receipt.Redaction = safeRedactionReceipt(RedactionResult{
 		Mode:       receipt.Redaction.Mode,
 		Findings:   receipt.Redaction.Findings,
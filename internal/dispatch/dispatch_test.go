@@ -10,6 +10,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Dicklesworthstone/ntm/internal/policy"
 	"github.com/Dicklesworthstone/ntm/internal/tmux"
 )
 
@@ -531,6 +532,58 @@ func TestPrepareRedactionBlockIsAtomicAndHasPerPaneReceipts(t *testing.T) {
 	}
 }
 
+func TestPolicyGuardChecksEachTargetInItsContext(t *testing.T) {
+	t.Parallel()
+	p, err := policy.DecodeYAML([]byte(`version: 1
+blocked:
+  - command: git
+    arg: push
+    when:
+      agents: cod
+      branches: "!agent/*"
+    reason: codex pushes only agent branches
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	panes := []tmux.Pane{
+		testPane("%1", 0, 0, tmux.AgentClaude, ""),
+		testPane("%2", 0, 1, tmux.AgentCodex, ""),
+	}
+	newService := func(branch string) *Service {
+		service, err := NewService(Ports{
+			Redactor:  AllowAllRedactor{},
+			Guard:     PolicyGuard{Policy: p, Base: policy.Context{Branch: branch}},
+			Deliverer: DelivererFunc(func(context.Context, Delivery) error { return nil }),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return service
+	}
+	request := Request{Session: "proj", Panes: panes, Message: "git push origin HEAD", DryRun: true}
+
+	prepared, err := newService("main").Prepare(context.Background(), request)
+	dispatchErr := requireCode(t, err, ErrPolicyBlocked)
+	if dispatchErr.Target == nil || dispatchErr.Target.Address != "1" {
+		t.Fatalf("blocked target = %+v, want the codex pane at address 1", dispatchErr.Target)
+	}
+	result := prepared.PreflightResult()
+	if got := []ReceiptStatus{result.Receipts[0].Status, result.Receipts[1].Status}; !reflect.DeepEqual(got, []ReceiptStatus{ReceiptSkipped, ReceiptBlocked}) {
+		t.Fatalf("statuses = %v", got)
+	}
+	if !strings.Contains(result.Receipts[1].Error, "codex pushes only agent branches") {
+		t.Fatalf("blocked receipt error = %q", result.Receipts[1].Error)
+	}
+
+	if _, err := newService("agent/fix-1").Prepare(context.Background(), request); err != nil {
+		t.Fatalf("push from agent branch should pass the guard: %v", err)
+	}
+}
+
 func TestPrepareFailuresAreAtomic(t *testing.T) {
 	t.Parallel()
 	panes := []tmux.Pane{
@@ -676,6 +729,52 @@ func TestDispatchContinuesAfterDeliveryFailureAndPacesEveryAttempt(t *testing.T)
 	}
 }
 
+// verifyingStub reports a canned verification per target address.
+type verifyingStub map[string]*Verification
+
+func (v verifyingStub) Deliver(ctx context.Context, delivery Delivery) error {
+	_, err := v.DeliverVerified(ctx, delivery)
+	return err
+}
+
+func (v verifyingStub) DeliverVerified(_ context.Context, delivery Delivery) (*Verification, error) {
+	verification := v[delivery.Target.Address]
+	if verification != nil && verification.Status == VerificationFailed {
+		return verification, errors.New("DELIVERY_UNVERIFIED")
+	}
+	return verification, nil
+}
+
+func TestDispatchRecordsVerificationOnReceipts(t *testing.T) {
+	t.Parallel()
+	panes := []tmux.Pane{
+		testPane("%1", 0, 0, tmux.AgentClaude, ""),
+		testPane("%2", 0, 1, tmux.AgentCodex, ""),
+	}
+	service, err := NewService(Ports{
+		Redactor: AllowAllRedactor{},
+		Deliverer: verifyingStub{
+			"0": {Status: VerificationRecovered, Outcome: tmux.DeliverySubmitted, Retries: 1, PartialPaste: true, Attempts: []VerificationAttempt{
+				{Strategy: StrategyPlanned, Outcome: tmux.DeliveryPartial},
+				{Strategy: StrategyClearComposer, Outcome: tmux.DeliverySubmitted},
+			}},
+			"1": {Status: VerificationFailed, Outcome: tmux.DeliveryLost, Retries: 3},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := service.Execute(context.Background(), Request{Session: "proj", Panes: panes, Message: "work", Submit: true})
+	requireCode(t, err, ErrDelivery)
+	first, second := result.Receipts[0], result.Receipts[1]
+	if first.Status != ReceiptDelivered || first.Verification == nil || first.Verification.Status != VerificationRecovered || len(first.Verification.Attempts) != 2 {
+		t.Fatalf("recovered receipt = %+v", first)
+	}
+	if second.Status != ReceiptFailed || second.Verification == nil || second.Verification.Outcome != tmux.DeliveryLost {
+		t.Fatalf("failed receipt = %+v", second)
+	}
+}
+
 func TestDispatchStopOnFailureSkipsRemainingTargets(t *testing.T) {
 	t.Parallel()
 	panes := []tmux.Pane{
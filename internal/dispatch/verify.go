@@ -0,0 +1,308 @@
+package dispatch
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+)
+
+// RetryStrategy names how a prompt was typed into a pane. Retries escalate
+// through the strategies in order, each one trading speed for robustness
+// against a different way TUIs lose input.
+type RetryStrategy string
+
+const (
+	// StrategyPlanned is the first attempt with the planned protocol.
+	StrategyPlanned RetryStrategy = "planned"
+	// StrategyClearComposer clears leftover composer text, then resends with
+	// the planned protocol.
+	StrategyClearComposer RetryStrategy = "clear_composer"
+	// StrategyChunkedKeys clears, then types the prompt with send-keys in
+	// small paced chunks so a redraw cannot swallow one large burst.
+	StrategyChunkedKeys RetryStrategy = "chunked_keys"
+	// StrategyPasteBuffer clears, then delivers the prompt atomically through
+	// tmux load-buffer/paste-buffer.
+	StrategyPasteBuffer RetryStrategy = "paste_buffer"
+)
+
+// retryStrategies is the escalation order after the planned attempt.
+var retryStrategies = []RetryStrategy{StrategyClearComposer, StrategyChunkedKeys, StrategyPasteBuffer}
+
+// VerificationStatus summarizes keystroke-level verification of a delivery.
+type VerificationStatus string
+
+const (
+	// VerificationConfirmed: the first attempt landed (and submitted, when
+	// the protocol submits).
+	VerificationConfirmed VerificationStatus = "confirmed"
+	// VerificationRecovered: a retry strategy landed the prompt after an
+	// earlier attempt was lost, partial, or stranded.
+	VerificationRecovered VerificationStatus = "recovered"
+	// VerificationFailed: every strategy was tried and the composer still
+	// shows the prompt missing, partial, or unsubmitted.
+	VerificationFailed VerificationStatus = "failed"
+	// VerificationUnverified: the pane has no composer to diff (shell and
+	// unknown panes, TUIs without a known marker, capture failures).
+	VerificationUnverified VerificationStatus = "unverified"
+)
+
+// VerificationAttempt records one typing attempt and what the composer diff
+// showed afterwards.
+type VerificationAttempt struct {
+	Strategy RetryStrategy        `json:"strategy"`
+	Outcome  tmux.DeliveryOutcome `json:"outcome"`
+	Error    string               `json:"error,omitempty"`
+}
+
+// Verification is the per-pane keystroke verification receipt. It records
+// outcomes only, never pane contents or the prompt.
+type Verification struct {
+	Status       VerificationStatus    `json:"status"`
+	Outcome      tmux.DeliveryOutcome  `json:"outcome"`
+	Retries      int                   `json:"retries"`
+	PartialPaste bool                  `json:"partial_paste,omitempty"`
+	Attempts     []VerificationAttempt `json:"attempts"`
+}
+
+// VerifyingDeliverer is a Deliverer that also reports keystroke-level
+// verification. Service records the returned Verification on the receipt
+// whether or not delivery failed.
+type VerifyingDeliverer interface {
+	Deliverer
+	DeliverVerified(context.Context, Delivery) (*Verification, error)
+}
+
+const (
+	// deliverySettleDelay is the pause between the last keystroke of an
+	// attempt and the capture diffed against the baseline.
+	deliverySettleDelay = 400 * time.Millisecond
+	// chunkedKeysRunes and chunkedKeysGap pace StrategyChunkedKeys.
+	chunkedKeysRunes = 64
+	chunkedKeysGap   = 40 * time.Millisecond
+)
+
+// retryableOutcome reports whether an outcome positively shows the prompt
+// did not arrive intact, so a resend cannot duplicate it.
+func retryableOutcome(outcome tmux.DeliveryOutcome) bool {
+	switch outcome {
+	case tmux.DeliveryLost, tmux.DeliveryPartial, tmux.DeliveryStranded:
+		return true
+	}
+	return false
+}
+
+// verifiableTarget reports whether a delivery's pane has a composer worth
+// diffing. Shell and unknown panes are never retried: resending into a shell
+// could run a command twice.
+func verifiableTarget(delivery Delivery) bool {
+	canonical := delivery.Target.AgentType.Canonical()
+	if canonical == tmux.AgentUser || canonical == tmux.AgentUnknown || !canonical.IsValid() {
+		return false
+	}
+	return tmux.HasComposerMarker(delivery.Target.AgentType)
+}
+
+// deliverVerified types the prompt, diffs the composer region against a
+// pre-send baseline, and escalates through retryStrategies while the diff
+// positively shows a lost, partial, or stranded prompt. A delivery whose
+// retries are exhausted fails with DELIVERY_UNVERIFIED so receipts stop
+// reporting eaten prompts as delivered.
+func deliverVerified(ctx context.Context, target string, delivery Delivery) (*Verification, error) {
+	if !verifiableTarget(delivery) {
+		return &Verification{Status: VerificationUnverified, Outcome: tmux.DeliveryUnverifiable, Attempts: []VerificationAttempt{}},
+			sendPlanned(ctx, target, delivery)
+	}
+	before, err := tmux.CapturePaneVisibleContext(ctx, target)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return &Verification{Status: VerificationUnverified, Outcome: tmux.DeliveryUnverifiable, Attempts: []VerificationAttempt{}},
+			sendPlanned(ctx, target, delivery)
+	}
+
+	verification := &Verification{Attempts: []VerificationAttempt{}}
+	strategies := append([]RetryStrategy{StrategyPlanned}, retryStrategies...)
+	for i, strategy := range strategies {
+		if i > 0 {
+			verification.Retries++
+			// Every retry starts from an empty composer: leftover partial or
+			// stranded text would otherwise be concatenated with the resend.
+			if _, _, err := tmux.ClearComposerContext(ctx, target, delivery.Target.AgentType); err != nil {
+				return verification, fmt.Errorf("COMPOSER_CLEAR_FAILED: pane %s: %w", delivery.Target.Ref.Physical(), err)
+			}
+			if before, err = tmux.CapturePaneVisibleContext(ctx, target); err != nil {
+				return verification, fmt.Errorf("capture pane %s before retry: %w", target, err)
+			}
+		}
+
+		attempt := VerificationAttempt{Strategy: strategy}
+		sendErr := sendWithStrategy(ctx, target, delivery, strategy)
+		if sendErr != nil {
+			if ctx.Err() != nil {
+				return verification, ctx.Err()
+			}
+			attempt.Error = sendErr.Error()
+		}
+		if err := waitSettle(ctx); err != nil {
+			return verification, err
+		}
+		attempt.Outcome = classifyAfter(ctx, target, before, delivery)
+		if attempt.Outcome == tmux.DeliveryLost {
+			// A slow redraw looks exactly like a lost prompt; look once more
+			// before resending so a retry cannot duplicate a taken prompt.
+			if err := waitSettle(ctx); err != nil {
+				return verification, err
+			}
+			attempt.Outcome = classifyAfter(ctx, target, before, delivery)
+		}
+		verification.Attempts = append(verification.Attempts, attempt)
+		verification.Outcome = attempt.Outcome
+		if attempt.Outcome == tmux.DeliveryPartial {
+			verification.PartialPaste = true
+		}
+
+		if !retryableOutcome(attempt.Outcome) {
+			if sendErr != nil {
+				// A send error is only retried when the screen positively
+				// shows the prompt did not arrive intact.
+				verification.Status = VerificationFailed
+				return verification, sendErr
+			}
+			switch {
+			case attempt.Outcome == tmux.DeliveryUnverifiable:
+				verification.Status = VerificationUnverified
+			case verification.Retries > 0:
+				verification.Status = VerificationRecovered
+				slog.Info("prompt delivery recovered by retry", "target", target, "strategy", strategy, "retries", verification.Retries)
+			default:
+				verification.Status = VerificationConfirmed
+			}
+			return verification, nil
+		}
+		slog.Warn("prompt delivery not confirmed", "target", target, "strategy", strategy, "outcome", attempt.Outcome)
+	}
+	verification.Status = VerificationFailed
+	return verification, fmt.Errorf("DELIVERY_UNVERIFIED: pane %s: prompt still %s after %d retries (last strategy %s); inspect with --robot-tail before resending",
+		delivery.Target.Ref.Physical(), verification.Outcome, verification.Retries, strategies[len(strategies)-1])
+}
+
+// validateDeliveryProtocol rejects plans the tmux primitives cannot honor
+// before any keystroke is sent.
+func validateDeliveryProtocol(delivery Delivery) error {
+	switch delivery.Protocol {
+	case ProtocolStageOnly, ProtocolSingleEnter:
+		return nil
+	case ProtocolDoubleEnter:
+		if delivery.EnterDelay != tmux.DoubleEnterFirstDelay || delivery.SecondEnterDelay != tmux.DoubleEnterSecondDelay {
+			return fmt.Errorf("tmux double-enter protocol requires delays %s and %s", tmux.DoubleEnterFirstDelay, tmux.DoubleEnterSecondDelay)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported delivery protocol %q", delivery.Protocol)
+	}
+}
+
+// classifyAfter captures the pane and classifies it against before.
+func classifyAfter(ctx context.Context, target, before string, delivery Delivery) tmux.DeliveryOutcome {
+	after, err := tmux.CapturePaneVisibleContext(ctx, target)
+	if err != nil {
+		return tmux.DeliveryUnverifiable
+	}
+	return tmux.ClassifyDelivery(before, after, delivery.Message, delivery.Target.AgentType,
+		delivery.Protocol != ProtocolStageOnly, delivery.Target.Pane.Width)
+}
+
+func waitSettle(ctx context.Context) error {
+	timer := time.NewTimer(deliverySettleDelay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// sendWithStrategy types the prompt with one strategy and submits it with
+// the planned protocol.
+func sendWithStrategy(ctx context.Context, target string, delivery Delivery, strategy RetryStrategy) error {
+	switch strategy {
+	case StrategyPlanned, StrategyClearComposer:
+		return sendPlanned(ctx, target, delivery)
+	case StrategyChunkedKeys:
+		if err := tmux.SendKeysChunkedContext(ctx, target, delivery.Message, chunkedKeysRunes, chunkedKeysGap); err != nil {
+			return err
+		}
+	case StrategyPasteBuffer:
+		if err := tmux.SendBufferWithDelayContext(ctx, target, delivery.Message, false, 0); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported retry strategy %q", strategy)
+	}
+	return submitPlanned(ctx, target, delivery)
+}
+
+// sendPlanned maps the neutral delivery protocol to NTM's tmux primitives.
+func sendPlanned(ctx context.Context, target string, delivery Delivery) error {
+	switch delivery.Protocol {
+	case ProtocolStageOnly:
+		return tmux.SendKeysForAgentWithDelayContext(ctx, target, delivery.Message, false, 0, delivery.Target.AgentType)
+	case ProtocolSingleEnter:
+		return tmux.SendKeysForAgentWithDelayContext(ctx, target, delivery.Message, true, delivery.EnterDelay, delivery.Target.AgentType)
+	case ProtocolDoubleEnter:
+		if err := tmux.SendKeysForAgentDoubleEnterContext(ctx, target, delivery.Message, delivery.Target.AgentType); err != nil {
+			return err
+		}
+		return VerifyAgentSubmission(ctx, target, delivery.Message, delivery.Target.AgentType, delivery.Target.Pane.Width)
+	default:
+		return fmt.Errorf("unsupported delivery protocol %q", delivery.Protocol)
+	}
+}
+
+// submitPlanned presses the planned protocol's Enter keys after text was
+// typed by a retry strategy.
+func submitPlanned(ctx context.Context, target string, delivery Delivery) error {
+	var delays []time.Duration
+	switch delivery.Protocol {
+	case ProtocolStageOnly:
+		return nil
+	case ProtocolSingleEnter:
+		delays = []time.Duration{delivery.EnterDelay}
+	case ProtocolDoubleEnter:
+		delays = []time.Duration{delivery.EnterDelay, delivery.SecondEnterDelay}
+	default:
+		return fmt.Errorf("unsupported delivery protocol %q", delivery.Protocol)
+	}
+	for _, delay := range delays {
+		if delay > 0 {
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+		if err := tmux.SendKeyNameContext(ctx, target, "Enter"); err != nil {
+			return err
+		}
+	}
+	if delivery.Protocol == ProtocolDoubleEnter {
+		return VerifyAgentSubmission(ctx, target, delivery.Message, delivery.Target.AgentType, delivery.Target.Pane.Width)
+	}
+	return nil
+}
+
+func cloneVerification(v *Verification) *Verification {
+	if v == nil {
+		return nil
+	}
+	out := *v
+	out.Attempts = append([]VerificationAttempt{}, v.Attempts...)
+	return &out
+}
@@ -537,6 +537,44 @@ func NewFileConflictEvent(session, path string, agents []string) FileConflictEve
 	}
 }
 
+// ----------------------------------------------------------------
+// Turn Events
+// ----------------------------------------------------------------
+
+// TurnSegmentEvent is emitted when a new segment (prompt, assistant text,
+// tool call or result, code block, diff or error) appears in a pane's
+// output.
+type TurnSegmentEvent struct {
+	BaseEvent
+	Pane      string `json:"pane"`
+	AgentType string `json:"agent_type,omitempty"`
+	Turn      int    `json:"turn"`
+	Kind      string `json:"kind"`
+	Tool      string `json:"tool,omitempty"`
+	File      string `json:"file,omitempty"`
+	Language  string `json:"language,omitempty"`
+	Text      string `json:"text"`
+}
+
+// NewTurnSegmentEvent creates a new turn segment event.
+func NewTurnSegmentEvent(session, pane, agentType string, turn int, kind, tool, file, language, text string) TurnSegmentEvent {
+	return TurnSegmentEvent{
+		BaseEvent: BaseEvent{
+			Type:      "turn_segment",
+			Timestamp: time.Now().UTC(),
+			Session:   session,
+		},
+		Pane:      pane,
+		AgentType: agentType,
+		Turn:      turn,
+		Kind:      kind,
+		Tool:      tool,
+		File:      file,
+		Language:  language,
+		Text:      text,
+	}
+}
+
 func cloneStringSlice(values []string) []string {
 	if values == nil {
 		return nil
@@ -441,6 +441,21 @@ func TestNewFileConflictEvent(t *testing.T) {
 	}
 }
 
+func TestNewTurnSegmentEvent(t *testing.T) {
+	t.Parallel()
+
+	event := NewTurnSegmentEvent("proj", "%3", "cc", 2, "diff", "Update", "main.go", "", "3 +  a := 2")
+	if event.EventType() != "turn_segment" {
+		t.Errorf("EventType() = %q, want %q", event.EventType(), "turn_segment")
+	}
+	if event.EventSession() != "proj" {
+		t.Errorf("EventSession() = %q, want %q", event.EventSession(), "proj")
+	}
+	if event.Pane != "%3" || event.Turn != 2 || event.Kind != "diff" || event.File != "main.go" {
+		t.Errorf("event = %+v", event)
+	}
+}
+
 func TestConflictEvents_ImplementBusEvent(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,352 @@
+// Package fleet drives several tmux servers from one ntm. Each server is a
+// host declared in [[hosts]] (plus the implicit "local" server), sessions are
+// addressed as "host:session", and listing, capture and send fan out across
+// hosts concurrently so one slow or unreachable box does not stall the rest.
+package fleet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/config"
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+)
+
+// DefaultHostTimeout bounds each per-host call during a fan-out.
+const DefaultHostTimeout = 10 * time.Second
+
+// Host is one tmux server in the fleet.
+type Host struct {
+	Name         string
+	SSH          string // ssh target, empty for this machine
+	Socket       string // tmux -L socket name, empty for the default server
+	ProjectsBase string
+	Labels       []string
+	Client       *tmux.Client
+}
+
+// IsLocal reports whether h is the implicit default local server.
+func (h *Host) IsLocal() bool {
+	return h.Name == config.LocalHostName
+}
+
+// HasLabel reports whether h carries label.
+func (h *Host) HasLabel(label string) bool {
+	for _, l := range h.Labels {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
+
+// SessionRef is a host-qualified session name.
+type SessionRef struct {
+	Host    string
+	Session string
+}
+
+// String renders the reference as "host:session".
+func (r SessionRef) String() string {
+	return r.Host + ":" + r.Session
+}
+
+// ParseSessionRef parses "host:session". tmux session names cannot contain
+// ':', so the first colon always separates the host.
+func ParseSessionRef(s string) (SessionRef, error) {
+	host, session, ok := strings.Cut(s, ":")
+	if !ok || host == "" || session == "" {
+		return SessionRef{}, fmt.Errorf("invalid session reference %q: want host:session", s)
+	}
+	if strings.Contains(session, ":") {
+		return SessionRef{}, fmt.Errorf("invalid session reference %q: session names cannot contain ':'", s)
+	}
+	return SessionRef{Host: host, Session: session}, nil
+}
+
+// Fleet is the set of tmux servers ntm operates on.
+type Fleet struct {
+	// HostTimeout bounds each per-host call in a fan-out. Zero means
+	// DefaultHostTimeout.
+	HostTimeout time.Duration
+
+	hosts  []*Host
+	byName map[string]*Host
+}
+
+// New builds a fleet of the local server, driven by local, followed by hosts
+// in declaration order. projectsBase is the default for hosts that do not set
+// their own. Hosts get control-mode clients when controlMode is set.
+func New(local *tmux.Client, projectsBase string, hosts []config.HostConfig, controlMode bool) (*Fleet, error) {
+	if local == nil {
+		local = tmux.DefaultClient
+	}
+	if err := config.ValidateHosts(hosts); err != nil {
+		return nil, err
+	}
+	f := &Fleet{byName: make(map[string]*Host, len(hosts)+1)}
+	f.add(&Host{Name: config.LocalHostName, SSH: local.Remote, Socket: local.Socket, ProjectsBase: projectsBase, Client: local})
+	for _, hc := range hosts {
+		client := tmux.NewClient(hc.SSH)
+		client.Socket = hc.Socket
+		if controlMode {
+			client.SetControlMode(true)
+		}
+		base := hc.ProjectsBase
+		if base == "" {
+			base = projectsBase
+		}
+		f.add(&Host{
+			Name:         hc.Name,
+			SSH:          hc.SSH,
+			Socket:       hc.Socket,
+			ProjectsBase: base,
+			Labels:       append([]string(nil), hc.Labels...),
+			Client:       client,
+		})
+	}
+	return f, nil
+}
+
+// FromConfig builds the fleet declared by cfg around tmux.DefaultClient.
+func FromConfig(cfg *config.Config) (*Fleet, error) {
+	if cfg == nil {
+		cfg = config.Default()
+	}
+	return New(tmux.DefaultClient, cfg.ProjectsBase, cfg.Hosts, cfg.Tmux.ControlMode)
+}
+
+func (f *Fleet) add(h *Host) {
+	f.hosts = append(f.hosts, h)
+	f.byName[h.Name] = h
+}
+
+// Hosts returns every host, local first.
+func (f *Fleet) Hosts() []*Host {
+	return append([]*Host(nil), f.hosts...)
+}
+
+// Host looks up a host by name.
+func (f *Fleet) Host(name string) (*Host, bool) {
+	h, ok := f.byName[name]
+	return h, ok
+}
+
+// HasRemoteHosts reports whether any host besides the local server is
+// declared.
+func (f *Fleet) HasRemoteHosts() bool {
+	return len(f.hosts) > 1
+}
+
+// Close releases the hosts' control-mode connections. The local client is
+// shared with the rest of the process and left open.
+func (f *Fleet) Close() {
+	for _, h := range f.hosts {
+		if !h.IsLocal() {
+			h.Client.CloseControl()
+		}
+	}
+}
+
+// Resolve routes a possibly host-qualified tmux target. When the text before
+// the first ':' names a host, the rest is a target on that host; anything
+// else (including plain "session:1.2" targets) is a target on the local
+// server. A host name therefore shadows a local session of the same name.
+func (f *Fleet) Resolve(target string) (*Host, string) {
+	if prefix, rest, ok := strings.Cut(target, ":"); ok && rest != "" {
+		if h, ok := f.byName[prefix]; ok {
+			return h, rest
+		}
+	}
+	return f.byName[config.LocalHostName], target
+}
+
+// ResolveSession resolves a session argument that may be host-qualified.
+func (f *Fleet) ResolveSession(name string) (*Host, string, error) {
+	h, session := f.Resolve(name)
+	if session == "" || strings.Contains(session, ":") {
+		return nil, "", fmt.Errorf("invalid session %q", name)
+	}
+	return h, session, nil
+}
+
+// HostResult is one host's share of a fan-out.
+type HostResult[T any] struct {
+	Host  *Host
+	Value T
+	Err   error
+}
+
+// FanOut runs fn once per host concurrently, each under the per-host
+// timeout, and returns the results in host order.
+func FanOut[T any](ctx context.Context, f *Fleet, fn func(context.Context, *Host) (T, error)) []HostResult[T] {
+	return fanOutHosts(ctx, f, f.hosts, fn)
+}
+
+func fanOutHosts[T any](ctx context.Context, f *Fleet, hosts []*Host, fn func(context.Context, *Host) (T, error)) []HostResult[T] {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	timeout := f.HostTimeout
+	if timeout <= 0 {
+		timeout = DefaultHostTimeout
+	}
+	results := make([]HostResult[T], len(hosts))
+	var wg sync.WaitGroup
+	for i, h := range hosts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			hostCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			v, err := fn(hostCtx, h)
+			if err != nil {
+				err = fmt.Errorf("host %s: %w", h.Name, err)
+			}
+			results[i] = HostResult[T]{Host: h, Value: v, Err: err}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// ListSessions lists every host's sessions.
+func (f *Fleet) ListSessions(ctx context.Context) []HostResult[[]tmux.Session] {
+	return FanOut(ctx, f, func(ctx context.Context, h *Host) ([]tmux.Session, error) {
+		return h.Client.ListSessionsContext(ctx)
+	})
+}
+
+// GetAllPanes lists every host's panes, keyed by session name.
+func (f *Fleet) GetAllPanes(ctx context.Context) []HostResult[map[string][]tmux.Pane] {
+	return FanOut(ctx, f, func(ctx context.Context, h *Host) (map[string][]tmux.Pane, error) {
+		return h.Client.GetAllPanesContext(ctx)
+	})
+}
+
+// Capture captures the last lines of a possibly host-qualified pane target.
+func (f *Fleet) Capture(ctx context.Context, target string, lines int) (string, error) {
+	h, t := f.Resolve(target)
+	return h.Client.CapturePaneOutputContext(ctx, t, lines)
+}
+
+// Send types keys into a possibly host-qualified pane target.
+func (f *Fleet) Send(ctx context.Context, target, keys string, enter bool) error {
+	h, t := f.Resolve(target)
+	return h.Client.SendKeysContext(ctx, t, keys, enter)
+}
+
+// TargetResult is the outcome of a capture or send for one target.
+type TargetResult struct {
+	Target string
+	Output string
+	Err    error
+}
+
+// CaptureTargets captures every target, fanning out across hosts. Targets on
+// the same host are captured in order; results keep the input order.
+func (f *Fleet) CaptureTargets(ctx context.Context, targets []string, lines int) []TargetResult {
+	return f.eachTarget(ctx, targets, func(ctx context.Context, h *Host, t string) (string, error) {
+		return h.Client.CapturePaneOutputContext(ctx, t, lines)
+	})
+}
+
+// SendTargets sends keys to every target, fanning out across hosts. Targets
+// on the same host are sent to in order; results keep the input order.
+func (f *Fleet) SendTargets(ctx context.Context, targets []string, keys string, enter bool) []TargetResult {
+	return f.eachTarget(ctx, targets, func(ctx context.Context, h *Host, t string) (string, error) {
+		return "", h.Client.SendKeysContext(ctx, t, keys, enter)
+	})
+}
+
+func (f *Fleet) eachTarget(ctx context.Context, targets []string, fn func(context.Context, *Host, string) (string, error)) []TargetResult {
+	results := make([]TargetResult, len(targets))
+	byHost := make(map[*Host][]int)
+	var hosts []*Host
+	for i, target := range targets {
+		h, _ := f.Resolve(target)
+		if _, ok := byHost[h]; !ok {
+			hosts = append(hosts, h)
+		}
+		byHost[h] = append(byHost[h], i)
+		results[i].Target = target
+	}
+	fanOutHosts(ctx, f, hosts, func(ctx context.Context, h *Host) (struct{}, error) {
+		indexes := byHost[h]
+		for k, i := range indexes {
+			if err := ctx.Err(); err != nil {
+				// The host timed out or the caller gave up; fail the rest
+				// of its targets without dialling again.
+				for _, j := range indexes[k:] {
+					results[j].Err = fmt.Errorf("host %s: %w", h.Name, err)
+				}
+				break
+			}
+			_, t := f.Resolve(targets[i])
+			results[i].Output, results[i].Err = fn(ctx, h, t)
+		}
+		return struct{}{}, nil
+	})
+	return results
+}
+
+// SessionOverview is one session in a fleet overview, with its panes.
+type SessionOverview struct {
+	Ref     SessionRef
+	Session tmux.Session
+}
+
+// HostOverview is one host's sessions, or the error that kept them from
+// being listed.
+type HostOverview struct {
+	Host     *Host
+	Sessions []SessionOverview
+	Err      error
+}
+
+// Overview lists every host's sessions together with their panes. It is the
+// shared input of the fleet-wide status, snapshot and API views.
+func (f *Fleet) Overview(ctx context.Context) []HostOverview {
+	results := FanOut(ctx, f, func(ctx context.Context, h *Host) ([]SessionOverview, error) {
+		sessions, err := h.Client.ListSessionsContext(ctx)
+		if err != nil || len(sessions) == 0 {
+			return nil, err
+		}
+		panes, err := h.Client.GetAllPanesContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		out := make([]SessionOverview, 0, len(sessions))
+		for _, s := range sessions {
+			s.Panes = panes[s.Name]
+			out = append(out, SessionOverview{Ref: SessionRef{Host: h.Name, Session: s.Name}, Session: s})
+		}
+		return out, nil
+	})
+	overview := make([]HostOverview, len(results))
+	for i, r := range results {
+		overview[i] = HostOverview{Host: r.Host, Sessions: r.Value, Err: r.Err}
+	}
+	return overview
+}
+
+// ErrorSummary shortens a per-host error for display: a failed tmux or ssh
+// invocation is reduced to what the command printed on stderr instead of the
+// full (and, over ssh, quoted) argv.
+func ErrorSummary(err error) string {
+	if err == nil {
+		return ""
+	}
+	var cmdErr *tmux.CommandError
+	if errors.As(err, &cmdErr) {
+		if stderr := strings.TrimSpace(cmdErr.Stderr); stderr != "" {
+			return stderr
+		}
+		return cmdErr.Err.Error()
+	}
+	return err.Error()
+}
@@ -0,0 +1,189 @@
+package fleet
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/config"
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+)
+
+func TestParseSessionRef(t *testing.T) {
+	ref, err := ParseSessionRef("build1:myproject")
+	if err != nil || ref != (SessionRef{Host: "build1", Session: "myproject"}) {
+		t.Fatalf("ParseSessionRef = %+v, %v", ref, err)
+	}
+	if ref.String() != "build1:myproject" {
+		t.Errorf("String() = %q", ref.String())
+	}
+	for _, bad := range []string{"", "myproject", ":myproject", "build1:", "build1:proj:0.1"} {
+		if _, err := ParseSessionRef(bad); err == nil {
+			t.Errorf("ParseSessionRef(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestNewValidatesHosts(t *testing.T) {
+	cases := map[string][]config.HostConfig{
+		"reserved":  {{Name: "local", SSH: "box"}},
+		"duplicate": {{Name: "a", SSH: "x"}, {Name: "a", SSH: "y"}},
+		"colon":     {{Name: "a:b", SSH: "x"}},
+		"no target": {{Name: "a"}},
+		"ssh flag":  {{Name: "a", SSH: "-oProxyCommand=x"}},
+	}
+	for name, hosts := range cases {
+		if _, err := New(tmux.NewClient(""), "", hosts, false); err == nil {
+			t.Errorf("%s: New succeeded, want error", name)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	f, err := New(tmux.NewClient(""), "/base", []config.HostConfig{
+		{Name: "build1", SSH: "ci@build1", Labels: []string{"gpu"}},
+		{Name: "build2", SSH: "ci@build2", ProjectsBase: "/srv"},
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		target, host, rest string
+	}{
+		{"build1:proj", "build1", "proj"},
+		{"build1:proj:0.1", "build1", "proj:0.1"},
+		{"proj:0.1", "local", "proj:0.1"},
+		{"proj", "local", "proj"},
+		{"local:proj", "local", "proj"},
+		{"%3", "local", "%3"},
+	}
+	for _, tt := range tests {
+		h, rest := f.Resolve(tt.target)
+		if h.Name != tt.host || rest != tt.rest {
+			t.Errorf("Resolve(%q) = %s, %q; want %s, %q", tt.target, h.Name, rest, tt.host, tt.rest)
+		}
+	}
+
+	b1, _ := f.Host("build1")
+	b2, _ := f.Host("build2")
+	if b1.ProjectsBase != "/base" || b2.ProjectsBase != "/srv" {
+		t.Errorf("projects base = %q, %q", b1.ProjectsBase, b2.ProjectsBase)
+	}
+	if !b1.HasLabel("gpu") || b2.HasLabel("gpu") {
+		t.Error("labels not carried over")
+	}
+	if b1.Client.Remote != "ci@build1" {
+		t.Errorf("client remote = %q", b1.Client.Remote)
+	}
+	if !f.HasRemoteHosts() || len(f.Hosts()) != 3 || !f.Hosts()[0].IsLocal() {
+		t.Errorf("hosts = %v", f.Hosts())
+	}
+}
+
+// TestFleetAcrossSockets stands two private tmux servers (-L sockets) in for
+// remote hosts.
+func TestFleetAcrossSockets(t *testing.T) {
+	if !tmux.IsInstalled() {
+		t.Skip("tmux not installed")
+	}
+	sockA := fmt.Sprintf("ntm-fleet-a-%d", os.Getpid())
+	sockB := fmt.Sprintf("ntm-fleet-b-%d", os.Getpid())
+	f, err := New(tmux.NewClient(""), "", []config.HostConfig{
+		{Name: "alpha", Socket: sockA},
+		{Name: "beta", Socket: sockB},
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alpha, _ := f.Host("alpha")
+	beta, _ := f.Host("beta")
+	t.Cleanup(func() {
+		_ = alpha.Client.RunSilent("kill-server")
+		_ = beta.Client.RunSilent("kill-server")
+		f.Close()
+	})
+
+	dir := t.TempDir()
+	if err := alpha.Client.CreateSession("work", dir); err != nil {
+		t.Skipf("create session on %s: %v", sockA, err)
+	}
+	if err := beta.Client.CreateSession("other", dir); err != nil {
+		t.Skipf("create session on %s: %v", sockB, err)
+	}
+
+	ctx := context.Background()
+	sessions := map[string][]string{}
+	for _, r := range f.ListSessions(ctx) {
+		if r.Err != nil && !r.Host.IsLocal() {
+			t.Fatalf("ListSessions %s: %v", r.Host.Name, r.Err)
+		}
+		for _, s := range r.Value {
+			sessions[r.Host.Name] = append(sessions[r.Host.Name], s.Name)
+		}
+	}
+	if strings.Join(sessions["alpha"], ",") != "work" || strings.Join(sessions["beta"], ",") != "other" {
+		t.Fatalf("sessions = %v", sessions)
+	}
+
+	for _, o := range f.Overview(ctx) {
+		if o.Host.IsLocal() {
+			continue
+		}
+		if o.Err != nil || len(o.Sessions) != 1 || len(o.Sessions[0].Session.Panes) != 1 {
+			t.Fatalf("overview %s = %+v, %v", o.Host.Name, o.Sessions, o.Err)
+		}
+		if want := map[string]string{"alpha": "alpha:work", "beta": "beta:other"}[o.Host.Name]; o.Sessions[0].Ref.String() != want {
+			t.Errorf("overview ref = %s, want %s", o.Sessions[0].Ref, want)
+		}
+	}
+
+	var targets []string
+	for _, r := range f.GetAllPanes(ctx) {
+		want := map[string]string{"alpha": "work", "beta": "other"}[r.Host.Name]
+		if want == "" {
+			continue
+		}
+		if len(r.Value[want]) != 1 || len(r.Value) != 1 {
+			t.Fatalf("%s panes = %v", r.Host.Name, r.Value)
+		}
+		// cat echoes input as soon as it runs, unlike a login shell that
+		// may still be starting.
+		paneID := r.Value[want][0].ID
+		if err := r.Host.Client.RunSilent("respawn-pane", "-k", "-t", paneID, "cat"); err != nil {
+			t.Fatalf("respawn %s: %v", paneID, err)
+		}
+		targets = append(targets, r.Host.Name+":"+paneID)
+	}
+
+	results := f.SendTargets(ctx, targets, "fleet-marker", true)
+	for _, r := range results {
+		if r.Err != nil {
+			t.Fatalf("send %s: %v", r.Target, r.Err)
+		}
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		captures := f.CaptureTargets(ctx, targets, 20)
+		ok := true
+		for _, c := range captures {
+			if c.Err != nil {
+				t.Fatalf("capture %s: %v", c.Target, c.Err)
+			}
+			ok = ok && strings.Contains(c.Output, "fleet-marker")
+		}
+		if ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("marker not captured: %+v", captures)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	if _, err := f.Capture(ctx, "alpha:other", 5); err == nil {
+		t.Error("capture of a session on the wrong host succeeded")
+	}
+}
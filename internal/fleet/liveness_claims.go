@@ -0,0 +1,19 @@
+package fleet
+
+import (
+	"github.com/Dicklesworthstone/ntm/internal/config"
+)
+
+// WS0-G2 config-key liveness claims for keys consumed by internal/fleet.
+// See internal/config/liveness.go.
+func init() {
+	for _, key := range []string{
+		"hosts.name",
+		"hosts.ssh",
+		"hosts.socket",
+		"hosts.projects_base",
+		"hosts.labels",
+	} {
+		config.RegisterReader(key, New)
+	}
+}
@@ -0,0 +1,83 @@
+package fleet
+
+import (
+	"context"
+
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+)
+
+// Status is the fleet-wide session listing shared by `ntm status --fleet`
+// and the serve API.
+type Status struct {
+	Hosts    []HostStatus    `json:"hosts"`
+	Sessions []SessionStatus `json:"sessions"`
+}
+
+// HostStatus summarises one host.
+type HostStatus struct {
+	Name      string   `json:"name"`
+	SSH       string   `json:"ssh,omitempty"`
+	Socket    string   `json:"socket,omitempty"`
+	Labels    []string `json:"labels,omitempty"`
+	Reachable bool     `json:"reachable"`
+	Error     string   `json:"error,omitempty"`
+	Sessions  int      `json:"sessions"`
+}
+
+// SessionStatus is one host-qualified session.
+type SessionStatus struct {
+	ID        string         `json:"id"` // host:session
+	Host      string         `json:"host"`
+	Session   string         `json:"session"`
+	Windows   int            `json:"windows"`
+	PaneCount int            `json:"pane_count"`
+	Attached  bool           `json:"attached"`
+	Agents    map[string]int `json:"agents"` // pane count by agent type (cc, cod, gmi, user, ...)
+}
+
+// AgentCount returns the number of non-user panes.
+func (s SessionStatus) AgentCount() int {
+	n := 0
+	for t, c := range s.Agents {
+		if t != string(tmux.AgentUser) {
+			n += c
+		}
+	}
+	return n
+}
+
+// Status lists every host's sessions. hostFilter, when non-empty, limits the
+// listing to that host.
+func (f *Fleet) Status(ctx context.Context, hostFilter string) Status {
+	st := Status{Hosts: []HostStatus{}, Sessions: []SessionStatus{}}
+	for _, o := range f.Overview(ctx) {
+		if hostFilter != "" && o.Host.Name != hostFilter {
+			continue
+		}
+		st.Hosts = append(st.Hosts, HostStatus{
+			Name:      o.Host.Name,
+			SSH:       o.Host.SSH,
+			Socket:    o.Host.Socket,
+			Labels:    o.Host.Labels,
+			Reachable: o.Err == nil,
+			Error:     ErrorSummary(o.Err),
+			Sessions:  len(o.Sessions),
+		})
+		for _, s := range o.Sessions {
+			agents := make(map[string]int)
+			for _, p := range s.Session.Panes {
+				agents[string(p.Type.Canonical())]++
+			}
+			st.Sessions = append(st.Sessions, SessionStatus{
+				ID:        s.Ref.String(),
+				Host:      s.Ref.Host,
+				Session:   s.Ref.Session,
+				Windows:   s.Session.Windows,
+				PaneCount: len(s.Session.Panes),
+				Attached:  s.Session.Attached,
+				Agents:    agents,
+			})
+		}
+	}
+	return st
+}
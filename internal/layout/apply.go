@@ -0,0 +1,253 @@
+package layout
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+)
+
+// sessionOption is the tmux session user option holding the applied Spec as
+// JSON.
+const sessionOption = "@ntm_layout"
+
+// Runner is the tmux surface layouts need. *tmux.Client satisfies it.
+type Runner interface {
+	RunContext(ctx context.Context, args ...string) (string, error)
+	GetPanesContext(ctx context.Context, session string) ([]tmux.Pane, error)
+}
+
+// Tiler is a Runner that can fall back to tiling every window. *tmux.Client
+// satisfies it.
+type Tiler interface {
+	Runner
+	ApplyTiledLayoutContext(ctx context.Context, session string) error
+}
+
+// Apply plans spec over the session's current panes, rearranges tmux to
+// match and records spec on the session for Retile.
+func Apply(ctx context.Context, r Runner, session string, spec Spec) (Plan, error) {
+	if err := spec.Validate(); err != nil {
+		return Plan{}, err
+	}
+	panes, err := r.GetPanesContext(ctx, session)
+	if err != nil {
+		return Plan{}, err
+	}
+	plan := spec.Plan(panes)
+	if err := arrange(ctx, r, session, spec, plan); err != nil {
+		return plan, err
+	}
+	return plan, Record(ctx, r, session, spec)
+}
+
+// Record marks spec as the session's active layout without rearranging
+// panes, for sessions whose geometry was restored some other way.
+func Record(ctx context.Context, r Runner, session string, spec Spec) error {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return err
+	}
+	if _, err := r.RunContext(ctx, "set-option", "-t", tmux.SessionOptionTarget(session), sessionOption, string(data)); err != nil {
+		return fmt.Errorf("record layout on session: %w", err)
+	}
+	return nil
+}
+
+// Active returns the layout last applied to a session, if any.
+func Active(ctx context.Context, r Runner, session string) (Spec, bool, error) {
+	out, err := r.RunContext(ctx, "show-options", "-q", "-v", "-t", tmux.SessionOptionTarget(session), sessionOption)
+	if err != nil {
+		return Spec{}, false, err
+	}
+	out = strings.TrimSpace(out)
+	if out == "" {
+		return Spec{}, false, nil
+	}
+	var spec Spec
+	if err := json.Unmarshal([]byte(out), &spec); err != nil {
+		return Spec{}, false, fmt.Errorf("decode %s: %w", sessionOption, err)
+	}
+	return spec, true, nil
+}
+
+// Retile re-applies the session's active layout after panes were added or
+// removed, or tiles every window when no layout was applied.
+func Retile(ctx context.Context, session string) error {
+	return RetileWith(ctx, tmux.DefaultClient, session)
+}
+
+// RetileWith is Retile against an explicit client.
+func RetileWith(ctx context.Context, c Tiler, session string) error {
+	spec, ok, err := Active(ctx, c, session)
+	if err != nil || !ok {
+		return c.ApplyTiledLayoutContext(ctx, session)
+	}
+	_, err = Apply(ctx, c, session, spec)
+	return err
+}
+
+// arrange moves panes into the plan's windows, orders them, names the
+// windows and applies each window's tmux layout.
+func arrange(ctx context.Context, r Runner, session string, spec Spec, plan Plan) error {
+	loc, err := paneWindows(ctx, r, session)
+	if err != nil {
+		return err
+	}
+	want := make(map[string]int) // pane ID -> plan window
+	for k, w := range plan.Windows {
+		for _, id := range w.Panes {
+			want[id] = k
+		}
+	}
+
+	windowIDs := make([]string, len(plan.Windows))
+	for k, w := range plan.Windows {
+		anchor := w.Panes[0]
+		if k > 0 && sharesWindow(loc, want, anchor, k) {
+			out, err := r.RunContext(ctx, "break-pane", "-d", "-s", anchor, "-P", "-F", "#{window_id}")
+			if err != nil {
+				return fmt.Errorf("move %s to a new window: %w", anchor, err)
+			}
+			loc[anchor] = strings.TrimSpace(out)
+		}
+		windowIDs[k] = loc[anchor]
+		for _, id := range w.Panes[1:] {
+			if loc[id] == windowIDs[k] {
+				continue
+			}
+			// Re-tile before each join so the target window has room to
+			// split however many panes it already holds.
+			if _, err := r.RunContext(ctx, "select-layout", "-t", windowIDs[k], "tiled"); err != nil {
+				return fmt.Errorf("tile window %s: %w", windowIDs[k], err)
+			}
+			if _, err := r.RunContext(ctx, "join-pane", "-d", "-s", id, "-t", anchor); err != nil {
+				return fmt.Errorf("move %s into window %s: %w", id, windowIDs[k], err)
+			}
+			loc[id] = windowIDs[k]
+		}
+	}
+
+	for k, w := range plan.Windows {
+		win := windowIDs[k]
+		if err := orderPanes(ctx, r, win, w.Panes); err != nil {
+			return err
+		}
+		if _, err := r.RunContext(ctx, "rename-window", "-t", win, w.Name); err != nil {
+			return fmt.Errorf("rename window %s: %w", win, err)
+		}
+		zoomed, err := r.RunContext(ctx, "display-message", "-p", "-t", win, "#{window_zoomed_flag}")
+		if err == nil && strings.TrimSpace(zoomed) == "1" {
+			if _, err := r.RunContext(ctx, "resize-pane", "-Z", "-t", win); err != nil {
+				return fmt.Errorf("unzoom window %s: %w", win, err)
+			}
+		}
+		if w.Arrangement == "main-vertical" && spec.MainSize != "" {
+			if _, err := r.RunContext(ctx, "set-window-option", "-t", win, "main-pane-width", spec.MainSize); err != nil {
+				return fmt.Errorf("set main pane width on %s: %w", win, err)
+			}
+		}
+		if _, err := r.RunContext(ctx, "select-layout", "-t", win, w.Arrangement); err != nil {
+			return fmt.Errorf("apply %s to window %s: %w", w.Arrangement, win, err)
+		}
+	}
+	return orderWindows(ctx, r, session, windowIDs)
+}
+
+// sharesWindow reports whether pane's window also holds panes planned for a
+// window other than k.
+func sharesWindow(loc map[string]string, want map[string]int, pane string, k int) bool {
+	for id, win := range loc {
+		if win == loc[pane] && id != pane {
+			if w, ok := want[id]; !ok || w != k {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// paneWindows maps every pane ID in the session to its window ID.
+func paneWindows(ctx context.Context, r Runner, session string) (map[string]string, error) {
+	out, err := r.RunContext(ctx, "list-panes", "-s", "-t", tmux.TargetSession(session), "-F", "#{pane_id}\t#{window_id}")
+	if err != nil {
+		return nil, err
+	}
+	loc := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		id, win, ok := strings.Cut(strings.TrimSpace(line), "\t")
+		if ok {
+			loc[id] = win
+		}
+	}
+	return loc, nil
+}
+
+// orderPanes swaps panes within a window into the planned order.
+func orderPanes(ctx context.Context, r Runner, win string, order []string) error {
+	out, err := r.RunContext(ctx, "list-panes", "-t", win, "-F", "#{pane_id}")
+	if err != nil {
+		return err
+	}
+	cur := strings.Fields(out)
+	for i, id := range order {
+		if i >= len(cur) || cur[i] == id {
+			continue
+		}
+		if _, err := r.RunContext(ctx, "swap-pane", "-d", "-s", id, "-t", cur[i]); err != nil {
+			return fmt.Errorf("reorder %s in window %s: %w", id, win, err)
+		}
+		for j := i + 1; j < len(cur); j++ {
+			if cur[j] == id {
+				cur[j] = cur[i]
+				break
+			}
+		}
+		cur[i] = id
+	}
+	return nil
+}
+
+// orderWindows swaps the planned windows so their indexes follow plan order.
+func orderWindows(ctx context.Context, r Runner, session string, windowIDs []string) error {
+	out, err := r.RunContext(ctx, "list-windows", "-t", tmux.TargetSession(session), "-F", "#{window_id}\t#{window_index}")
+	if err != nil {
+		return err
+	}
+	index := make(map[string]int)
+	for _, line := range strings.Split(out, "\n") {
+		id, idx, ok := strings.Cut(strings.TrimSpace(line), "\t")
+		if !ok {
+			continue
+		}
+		if n, err := strconv.Atoi(idx); err == nil {
+			index[id] = n
+		}
+	}
+	slots := make([]int, 0, len(windowIDs))
+	for _, id := range windowIDs {
+		slots = append(slots, index[id])
+	}
+	sort.Ints(slots)
+	for k, id := range windowIDs {
+		if index[id] == slots[k] {
+			continue
+		}
+		var other string
+		for oid, idx := range index {
+			if idx == slots[k] {
+				other = oid
+				break
+			}
+		}
+		if _, err := r.RunContext(ctx, "swap-window", "-d", "-s", id, "-t", other); err != nil {
+			return fmt.Errorf("reorder window %s: %w", id, err)
+		}
+		index[other], index[id] = index[id], slots[k]
+	}
+	return nil
+}
@@ -0,0 +1,337 @@
+// Package layout arranges a session's panes into windows by a named layout.
+//
+// A Spec describes the arrangement (a tiled grid, a main pane beside a stack,
+// one window per agent type) and how many panes a window may hold before the
+// rest overflow into further windows. Plan turns a Spec and the session's
+// panes into a deterministic window assignment; Apply moves panes with
+// break-pane/join-pane until tmux matches it. The applied Spec is stored on
+// the session, so Retile can re-run it after panes are added or removed.
+package layout
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+)
+
+// Strategy is how a layout groups and arranges panes.
+type Strategy string
+
+const (
+	// StrategyGrid tiles every pane evenly.
+	StrategyGrid Strategy = "grid"
+	// StrategyMainVertical puts the main pane (the user/coordinator pane by
+	// default) on the left and stacks the agents on the right.
+	StrategyMainVertical Strategy = "main-vertical"
+	// StrategyByAgent gives each agent type its own tiled window.
+	StrategyByAgent Strategy = "by-agent"
+)
+
+// Strategies lists the valid strategies.
+var Strategies = []Strategy{StrategyGrid, StrategyMainVertical, StrategyByAgent}
+
+// Spec is a named layout. It is decoded from [[layouts]] in config.toml and
+// from a recipe's layout table, and stored as JSON on sessions it is applied
+// to.
+type Spec struct {
+	Name        string   `toml:"name" json:"name"`
+	Description string   `toml:"description,omitempty" json:"description,omitempty"`
+	Strategy    Strategy `toml:"strategy" json:"strategy"`
+	// PerWindow caps the panes in one window; the rest overflow into
+	// further windows. Zero means no cap.
+	PerWindow int `toml:"per_window,omitempty" json:"per_window,omitempty"`
+	// Main selects the main pane for main-vertical by agent type ("user",
+	// "cc", ...) or exact pane title. Defaults to "user".
+	Main string `toml:"main,omitempty" json:"main,omitempty"`
+	// MainSize is the main pane width for main-vertical: columns ("120") or
+	// a percentage ("50%").
+	MainSize string `toml:"main_size,omitempty" json:"main_size,omitempty"`
+}
+
+// Builtins are the layouts available without configuration.
+func Builtins() []Spec {
+	return []Spec{
+		{Name: "grid", Description: "Every pane tiled in one window", Strategy: StrategyGrid},
+		{Name: "main-vertical", Description: "User pane on the left, agents stacked on the right", Strategy: StrategyMainVertical, Main: "user", MainSize: "50%"},
+		{Name: "by-agent", Description: "One tiled window per agent type", Strategy: StrategyByAgent},
+		{Name: "paged", Description: "Tiled windows of at most 6 panes", Strategy: StrategyGrid, PerWindow: 6},
+	}
+}
+
+// Validate reports whether the spec can be planned.
+func (s Spec) Validate() error {
+	if strings.TrimSpace(s.Name) == "" {
+		return fmt.Errorf("layout name is required")
+	}
+	switch s.Strategy {
+	case StrategyGrid, StrategyMainVertical, StrategyByAgent:
+	default:
+		return fmt.Errorf("layout %q: unknown strategy %q (must be grid, main-vertical, or by-agent)", s.Name, s.Strategy)
+	}
+	if s.PerWindow < 0 {
+		return fmt.Errorf("layout %q: per_window must not be negative", s.Name)
+	}
+	if s.PerWindow == 1 && s.Strategy == StrategyMainVertical {
+		return fmt.Errorf("layout %q: main-vertical needs per_window of at least 2", s.Name)
+	}
+	if s.MainSize != "" {
+		n, err := strconv.Atoi(strings.TrimSuffix(s.MainSize, "%"))
+		if err != nil || n <= 0 || (strings.HasSuffix(s.MainSize, "%") && n >= 100) {
+			return fmt.Errorf("layout %q: invalid main_size %q (columns or 1-99%%)", s.Name, s.MainSize)
+		}
+	}
+	return nil
+}
+
+// Lookup finds a layout by name, preferring custom definitions over
+// builtins.
+func Lookup(name string, custom []Spec) (Spec, error) {
+	for _, s := range custom {
+		if strings.EqualFold(s.Name, name) {
+			return s, s.Validate()
+		}
+	}
+	for _, s := range Builtins() {
+		if strings.EqualFold(s.Name, name) {
+			return s, nil
+		}
+	}
+	return Spec{}, fmt.Errorf("unknown layout %q (available: %s)", name, strings.Join(Names(custom), ", "))
+}
+
+// All returns the builtins followed by custom layouts, with custom layouts
+// replacing builtins of the same name.
+func All(custom []Spec) []Spec {
+	var out []Spec
+	for _, b := range Builtins() {
+		overridden := false
+		for _, c := range custom {
+			if strings.EqualFold(c.Name, b.Name) {
+				overridden = true
+				break
+			}
+		}
+		if !overridden {
+			out = append(out, b)
+		}
+	}
+	return append(out, custom...)
+}
+
+// Names returns the names of All(custom).
+func Names(custom []Spec) []string {
+	all := All(custom)
+	names := make([]string, len(all))
+	for i, s := range all {
+		names[i] = s.Name
+	}
+	return names
+}
+
+// Window is one window of a plan.
+type Window struct {
+	Name string `json:"name"`
+	// Panes are tmux pane IDs in display order.
+	Panes []string `json:"panes"`
+	// Arrangement is the tmux layout applied to the window.
+	Arrangement string `json:"arrangement"`
+}
+
+// Plan is the window assignment a layout produces for a set of panes.
+type Plan struct {
+	Layout  string   `json:"layout"`
+	Windows []Window `json:"windows"`
+}
+
+// Plan assigns panes to windows. The result depends only on the spec and
+// the panes' types, NTM indexes and IDs, never on where the panes currently
+// sit, so re-planning after a scale is stable.
+func (s Spec) Plan(panes []tmux.Pane) Plan {
+	ordered := append([]tmux.Pane(nil), panes...)
+	sortPanes(ordered)
+	plan := Plan{Layout: s.Name}
+
+	switch s.Strategy {
+	case StrategyByAgent:
+		var groups [][]tmux.Pane
+		for _, p := range ordered {
+			if n := len(groups); n > 0 && groups[n-1][0].Type == p.Type {
+				groups[n-1] = append(groups[n-1], p)
+				continue
+			}
+			groups = append(groups, []tmux.Pane{p})
+		}
+		for _, g := range groups {
+			plan.Windows = append(plan.Windows, s.paginate(typeName(g[0].Type), g, "tiled")...)
+		}
+	case StrategyMainVertical:
+		main := s.mainPane(ordered)
+		if main < 0 {
+			plan.Windows = s.paginate("agents", ordered, "tiled")
+			break
+		}
+		rest := append(append([]tmux.Pane(nil), ordered[:main]...), ordered[main+1:]...)
+		first := len(rest)
+		if s.PerWindow > 0 && first > s.PerWindow-1 {
+			first = s.PerWindow - 1
+		}
+		plan.Windows = append(plan.Windows, Window{
+			Name:        "agents",
+			Panes:       paneIDs(append([]tmux.Pane{ordered[main]}, rest[:first]...)),
+			Arrangement: "main-vertical",
+		})
+		for i, w := range s.paginate("agents", rest[first:], "tiled") {
+			w.Name = fmt.Sprintf("agents-%d", i+2)
+			plan.Windows = append(plan.Windows, w)
+		}
+	default:
+		plan.Windows = s.paginate("agents", ordered, "tiled")
+	}
+	return plan
+}
+
+func (s Spec) paginate(name string, panes []tmux.Pane, arrangement string) []Window {
+	var out []Window
+	size := s.PerWindow
+	if size <= 0 {
+		size = len(panes)
+	}
+	for start := 0; start < len(panes); start += size {
+		end := start + size
+		if end > len(panes) {
+			end = len(panes)
+		}
+		w := Window{Name: name, Panes: paneIDs(panes[start:end]), Arrangement: arrangement}
+		if start > 0 {
+			w.Name = fmt.Sprintf("%s-%d", name, start/size+1)
+		}
+		out = append(out, w)
+	}
+	return out
+}
+
+// mainPane returns the index of the main pane in ordered panes, or -1.
+func (s Spec) mainPane(ordered []tmux.Pane) int {
+	sel := s.Main
+	if sel == "" {
+		sel = string(tmux.AgentUser)
+	}
+	for i, p := range ordered {
+		if p.Title == sel || strings.EqualFold(string(p.Type), sel) {
+			return i
+		}
+	}
+	return -1
+}
+
+// sortPanes orders panes user first, then by agent type, NTM index and
+// pane ID.
+func sortPanes(panes []tmux.Pane) {
+	sort.SliceStable(panes, func(i, j int) bool {
+		a, b := panes[i], panes[j]
+		if ra, rb := typeRank(a.Type), typeRank(b.Type); ra != rb {
+			return ra < rb
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		if a.NTMIndex != b.NTMIndex {
+			return a.NTMIndex < b.NTMIndex
+		}
+		return paneNumber(a.ID) < paneNumber(b.ID)
+	})
+}
+
+func typeRank(t tmux.AgentType) int {
+	switch t {
+	case tmux.AgentUser:
+		return 0
+	case "", tmux.AgentUnknown:
+		return 2
+	default:
+		return 1
+	}
+}
+
+func typeName(t tmux.AgentType) string {
+	if t == "" || t == tmux.AgentUnknown {
+		return "other"
+	}
+	return string(t)
+}
+
+func paneNumber(id string) int {
+	n, err := strconv.Atoi(strings.TrimPrefix(id, "%"))
+	if err != nil {
+		return int(^uint(0) >> 1)
+	}
+	return n
+}
+
+func paneIDs(panes []tmux.Pane) []string {
+	ids := make([]string, len(panes))
+	for i, p := range panes {
+		ids[i] = p.ID
+	}
+	return ids
+}
+
+// UnmarshalTOML lets a recipe name a layout (layout = "by-agent") or define
+// one inline as a table.
+func (s *Spec) UnmarshalTOML(v interface{}) error {
+	switch v := v.(type) {
+	case string:
+		*s = Spec{Name: v}
+		return nil
+	case map[string]interface{}:
+		*s = Spec{}
+		for key, val := range v {
+			var err error
+			switch key {
+			case "name":
+				s.Name, err = tomlString(key, val)
+			case "description":
+				s.Description, err = tomlString(key, val)
+			case "strategy":
+				var str string
+				str, err = tomlString(key, val)
+				s.Strategy = Strategy(str)
+			case "per_window":
+				n, ok := val.(int64)
+				if !ok {
+					return fmt.Errorf("layout per_window must be an integer")
+				}
+				s.PerWindow = int(n)
+			case "main":
+				s.Main, err = tomlString(key, val)
+			case "main_size":
+				s.MainSize, err = tomlString(key, val)
+			default:
+				return fmt.Errorf("unknown layout field %q", key)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("layout must be a name or a table, got %T", v)
+	}
+}
+
+// IsReference reports whether the spec only names a layout defined
+// elsewhere.
+func (s Spec) IsReference() bool {
+	return s.Strategy == "" && s.PerWindow == 0 && s.Main == "" && s.MainSize == ""
+}
+
+func tomlString(key string, v interface{}) (string, error) {
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("layout %s must be a string", key)
+	}
+	return s, nil
+}
@@ -0,0 +1,424 @@
+package layout
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+)
+
+func swarm() []tmux.Pane {
+	return []tmux.Pane{
+		{ID: "%0", Title: "proj__user", Type: tmux.AgentUser},
+		{ID: "%1", Title: "proj__cc_1", Type: tmux.AgentClaude, NTMIndex: 1},
+		{ID: "%2", Title: "proj__cc_2", Type: tmux.AgentClaude, NTMIndex: 2},
+		{ID: "%3", Title: "proj__cc_3", Type: tmux.AgentClaude, NTMIndex: 3},
+		{ID: "%4", Title: "proj__cod_1", Type: tmux.AgentCodex, NTMIndex: 1},
+		{ID: "%5", Title: "proj__cod_2", Type: tmux.AgentCodex, NTMIndex: 2},
+		{ID: "%6", Title: "proj__gmi_1", Type: tmux.AgentGemini, NTMIndex: 1},
+	}
+}
+
+func windowsOf(p Plan) []string {
+	var out []string
+	for _, w := range p.Windows {
+		out = append(out, fmt.Sprintf("%s/%s:%s", w.Name, w.Arrangement, strings.Join(w.Panes, ",")))
+	}
+	return out
+}
+
+func TestPlan(t *testing.T) {
+	cases := []struct {
+		spec Spec
+		want []string
+	}{
+		{Spec{Name: "grid", Strategy: StrategyGrid},
+			[]string{"agents/tiled:%0,%1,%2,%3,%4,%5,%6"}},
+		{Spec{Name: "paged", Strategy: StrategyGrid, PerWindow: 3},
+			[]string{"agents/tiled:%0,%1,%2", "agents-2/tiled:%3,%4,%5", "agents-3/tiled:%6"}},
+		{Spec{Name: "by-agent", Strategy: StrategyByAgent, PerWindow: 2},
+			[]string{"user/tiled:%0", "cc/tiled:%1,%2", "cc-2/tiled:%3", "cod/tiled:%4,%5", "gmi/tiled:%6"}},
+		{Spec{Name: "main", Strategy: StrategyMainVertical, PerWindow: 4},
+			[]string{"agents/main-vertical:%0,%1,%2,%3", "agents-2/tiled:%4,%5,%6"}},
+		{Spec{Name: "main-cod", Strategy: StrategyMainVertical, Main: "proj__cod_2"},
+			[]string{"agents/main-vertical:%5,%0,%1,%2,%3,%4,%6"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.spec.Name, func(t *testing.T) {
+			if got := windowsOf(tc.spec.Plan(swarm())); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("plan = %v\nwant   %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPlanIgnoresCurrentOrder(t *testing.T) {
+	spec := Spec{Name: "by-agent", Strategy: StrategyByAgent, PerWindow: 2}
+	want := spec.Plan(swarm())
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 20; i++ {
+		panes := swarm()
+		rng.Shuffle(len(panes), func(a, b int) { panes[a], panes[b] = panes[b], panes[a] })
+		for j := range panes {
+			panes[j].WindowIndex = rng.Intn(3)
+		}
+		if got := spec.Plan(panes); !reflect.DeepEqual(got, want) {
+			t.Fatalf("shuffled plan = %v, want %v", windowsOf(got), windowsOf(want))
+		}
+	}
+}
+
+func TestApplyArrangesWindowsAndRetiles(t *testing.T) {
+	ft := newFakeTmux(swarm())
+	ctx := context.Background()
+	spec := Spec{Name: "by-agent", Strategy: StrategyByAgent, PerWindow: 2}
+
+	plan, err := Apply(ctx, ft, "proj", spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ft.assertMatches(t, plan)
+
+	// Scale up: a new cc pane split into the last window lands in the cc
+	// overflow window on re-tile.
+	ft.addPane(tmux.Pane{ID: "%7", Title: "proj__cc_4", Type: tmux.AgentClaude, NTMIndex: 4}, ft.windows[len(ft.windows)-1])
+	active, ok, err := Active(ctx, ft, "proj")
+	if err != nil || !ok || !reflect.DeepEqual(active, spec) {
+		t.Fatalf("active = %+v, %v, %v", active, ok, err)
+	}
+	plan, err = Apply(ctx, ft, "proj", active)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := windowsOf(plan)[2]; got != "cc-2/tiled:%3,%7" {
+		t.Errorf("cc overflow window = %s", got)
+	}
+	ft.assertMatches(t, plan)
+
+	// Scale down: removing panes collapses windows.
+	ft.removePane("%3")
+	ft.removePane("%7")
+	plan, err = Apply(ctx, ft, "proj", active)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Windows) != 4 {
+		t.Errorf("windows after scale down = %v", windowsOf(plan))
+	}
+	ft.assertMatches(t, plan)
+}
+
+func TestApplyMainVerticalSetsMainWidth(t *testing.T) {
+	ft := newFakeTmux(swarm())
+	spec := Spec{Name: "mv", Strategy: StrategyMainVertical, MainSize: "40%"}
+	plan, err := Apply(context.Background(), ft, "proj", spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ft.assertMatches(t, plan)
+	win := ft.win["%0"]
+	if ft.layouts[win] != "main-vertical" || ft.mainWidth[win] != "40%" {
+		t.Errorf("window %s layout=%q main width=%q", win, ft.layouts[win], ft.mainWidth[win])
+	}
+}
+
+func TestRetileKeepsActiveLayoutAfterAddAndRemove(t *testing.T) {
+	ft := newFakeTmux(swarm())
+	ctx := context.Background()
+	spec := Spec{Name: "mv", Strategy: StrategyMainVertical}
+	if _, err := Apply(ctx, ft, "proj", spec); err != nil {
+		t.Fatal(err)
+	}
+
+	// ntm add splits the first window and tiles it.
+	first := ft.windows[0]
+	ft.addPane(tmux.Pane{ID: "%7", Title: "proj__cc_4", Type: tmux.AgentClaude, NTMIndex: 4}, first)
+	ft.layouts[first] = "tiled"
+	if err := RetileWith(ctx, ft, "proj"); err != nil {
+		t.Fatal(err)
+	}
+	panes, _ := ft.GetPanesContext(ctx, "proj")
+	ft.assertMatches(t, spec.Plan(panes))
+
+	ft.removePane("%2")
+	if err := RetileWith(ctx, ft, "proj"); err != nil {
+		t.Fatal(err)
+	}
+	panes, _ = ft.GetPanesContext(ctx, "proj")
+	ft.assertMatches(t, spec.Plan(panes))
+	if ft.tiled {
+		t.Error("session with an active layout fell back to tiling")
+	}
+
+	bare := newFakeTmux(swarm())
+	if err := RetileWith(ctx, bare, "proj"); err != nil || !bare.tiled {
+		t.Errorf("session without a layout: tiled=%v err=%v", bare.tiled, err)
+	}
+}
+
+func TestSpecTOMLAndValidate(t *testing.T) {
+	var doc struct {
+		Named  *Spec  `toml:"named"`
+		Inline *Spec  `toml:"inline"`
+		List   []Spec `toml:"layouts"`
+	}
+	src := `named = "by-agent"
+inline = { strategy = "grid", per_window = 4 }
+
+[[layouts]]
+name = "wide"
+strategy = "main-vertical"
+main_size = "60%"
+`
+	md, err := toml.Decode(src, &doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		t.Errorf("undecoded keys: %v", undecoded)
+	}
+	if !doc.Named.IsReference() || doc.Named.Name != "by-agent" {
+		t.Errorf("named = %+v", doc.Named)
+	}
+	if doc.Inline.IsReference() || doc.Inline.PerWindow != 4 {
+		t.Errorf("inline = %+v", doc.Inline)
+	}
+	if len(doc.List) != 1 || doc.List[0].Validate() != nil {
+		t.Fatalf("layouts = %+v", doc.List)
+	}
+	got, err := Lookup("WIDE", doc.List)
+	if err != nil || got.MainSize != "60%" {
+		t.Errorf("lookup = %+v, %v", got, err)
+	}
+	if _, err := toml.Decode(`named = { strategy = "grid", colour = "red" }`, &doc); err == nil {
+		t.Error("unknown layout field accepted")
+	}
+
+	for _, bad := range []Spec{
+		{Strategy: StrategyGrid},
+		{Name: "x", Strategy: "spiral"},
+		{Name: "x", Strategy: StrategyGrid, PerWindow: -1},
+		{Name: "x", Strategy: StrategyMainVertical, PerWindow: 1},
+		{Name: "x", Strategy: StrategyMainVertical, MainSize: "120%"},
+	} {
+		if bad.Validate() == nil {
+			t.Errorf("%+v validated", bad)
+		}
+	}
+	for _, b := range Builtins() {
+		if err := b.Validate(); err != nil {
+			t.Errorf("builtin %s: %v", b.Name, err)
+		}
+	}
+}
+
+// fakeTmux models the windows, pane order and session options Apply
+// manipulates.
+type fakeTmux struct {
+	panes     map[string]tmux.Pane
+	win       map[string]string   // pane -> window
+	order     map[string][]string // window -> panes in order
+	windows   []string            // window IDs by index
+	names     map[string]string
+	layouts   map[string]string
+	mainWidth map[string]string
+	option    string
+	nextWin   int
+	tiled     bool
+}
+
+func newFakeTmux(panes []tmux.Pane) *fakeTmux {
+	ft := &fakeTmux{
+		panes:     map[string]tmux.Pane{},
+		win:       map[string]string{},
+		order:     map[string][]string{},
+		names:     map[string]string{},
+		layouts:   map[string]string{},
+		mainWidth: map[string]string{},
+	}
+	w := ft.newWindow()
+	for _, p := range panes {
+		ft.addPane(p, w)
+	}
+	return ft
+}
+
+func (f *fakeTmux) newWindow() string {
+	id := fmt.Sprintf("@%d", f.nextWin)
+	f.nextWin++
+	f.windows = append(f.windows, id)
+	return id
+}
+
+func (f *fakeTmux) addPane(p tmux.Pane, win string) {
+	f.panes[p.ID] = p
+	f.win[p.ID] = win
+	f.order[win] = append(f.order[win], p.ID)
+}
+
+func (f *fakeTmux) removePane(id string) {
+	f.detach(id)
+	delete(f.panes, id)
+	delete(f.win, id)
+}
+
+// detach takes a pane out of its window, closing the window if it empties.
+func (f *fakeTmux) detach(id string) {
+	w := f.win[id]
+	var rest []string
+	for _, p := range f.order[w] {
+		if p != id {
+			rest = append(rest, p)
+		}
+	}
+	f.order[w] = rest
+	if len(rest) == 0 {
+		delete(f.order, w)
+		for i, x := range f.windows {
+			if x == w {
+				f.windows = append(f.windows[:i], f.windows[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+func (f *fakeTmux) GetPanesContext(context.Context, string) ([]tmux.Pane, error) {
+	var out []tmux.Pane
+	for _, p := range f.panes {
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
+func (f *fakeTmux) ApplyTiledLayoutContext(context.Context, string) error {
+	f.tiled = true
+	return nil
+}
+
+func (f *fakeTmux) RunContext(_ context.Context, args ...string) (string, error) {
+	flag := func(name string) string {
+		for i := 0; i+1 < len(args); i++ {
+			if args[i] == name {
+				return args[i+1]
+			}
+		}
+		return ""
+	}
+	switch args[0] {
+	case "list-panes":
+		var lines []string
+		if len(args) > 1 && args[1] == "-s" {
+			for _, w := range f.windows {
+				for _, p := range f.order[w] {
+					lines = append(lines, p+"\t"+w)
+				}
+			}
+		} else {
+			lines = f.order[flag("-t")]
+		}
+		return strings.Join(lines, "\n"), nil
+	case "list-windows":
+		var lines []string
+		for i, w := range f.windows {
+			lines = append(lines, fmt.Sprintf("%s\t%d", w, i))
+		}
+		return strings.Join(lines, "\n"), nil
+	case "break-pane":
+		id := flag("-s")
+		f.detach(id)
+		w := f.newWindow()
+		f.win[id] = w
+		f.order[w] = []string{id}
+		return w, nil
+	case "join-pane":
+		id, target := flag("-s"), flag("-t")
+		f.detach(id)
+		w := f.win[target]
+		var out []string
+		for _, p := range f.order[w] {
+			out = append(out, p)
+			if p == target {
+				out = append(out, id)
+			}
+		}
+		f.order[w] = out
+		f.win[id] = w
+		return "", nil
+	case "swap-pane":
+		a, b := flag("-s"), flag("-t")
+		wa, wb := f.win[a], f.win[b]
+		wins := []string{wa}
+		if wb != wa {
+			wins = append(wins, wb)
+		}
+		for _, w := range wins {
+			for i, p := range f.order[w] {
+				switch p {
+				case a:
+					f.order[w][i] = b
+				case b:
+					f.order[w][i] = a
+				}
+			}
+		}
+		f.win[a], f.win[b] = wb, wa
+		return "", nil
+	case "swap-window":
+		a, b := flag("-s"), flag("-t")
+		var ia, ib int
+		for i, w := range f.windows {
+			if w == a {
+				ia = i
+			}
+			if w == b {
+				ib = i
+			}
+		}
+		f.windows[ia], f.windows[ib] = f.windows[ib], f.windows[ia]
+		return "", nil
+	case "rename-window":
+		f.names[flag("-t")] = args[len(args)-1]
+		return "", nil
+	case "select-layout":
+		f.layouts[flag("-t")] = args[len(args)-1]
+		return "", nil
+	case "set-window-option":
+		f.mainWidth[flag("-t")] = args[len(args)-1]
+		return "", nil
+	case "display-message":
+		return "0", nil
+	case "set-option":
+		f.option = args[len(args)-1]
+		return "", nil
+	case "show-options":
+		return f.option, nil
+	}
+	return "", fmt.Errorf("unexpected tmux command %v", args)
+}
+
+// assertMatches checks the modelled session is exactly the plan: one window
+// per plan window, in order, holding the planned panes in order.
+func (f *fakeTmux) assertMatches(t *testing.T, plan Plan) {
+	t.Helper()
+	if len(f.windows) != len(plan.Windows) {
+		t.Fatalf("session has %d windows, plan %d (%v)", len(f.windows), len(plan.Windows), windowsOf(plan))
+	}
+	for i, w := range plan.Windows {
+		id := f.windows[i]
+		if !reflect.DeepEqual(f.order[id], w.Panes) {
+			t.Errorf("window %d panes = %v, want %v", i, f.order[id], w.Panes)
+		}
+		if f.names[id] != w.Name || f.layouts[id] != w.Arrangement {
+			t.Errorf("window %d = %q/%q, want %q/%q", i, f.names[id], f.layouts[id], w.Name, w.Arrangement)
+		}
+	}
+}
@@ -0,0 +1,422 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/approval"
+	"github.com/Dicklesworthstone/ntm/internal/state"
+)
+
+// ApprovalGateStep pauses a run until a human decides an approval request
+// (`approval:` or its alias `gate:`). The request is filed with the approval
+// engine using the run ID as its correlation ID, so it shows up in
+// `ntm approve list` and is decided with `ntm approve <id>` or
+// `ntm approve deny <id>`. While it waits the run reports
+// StatusWaitingApproval.
+//
+// The short form `gate: "Merge to main?"` sets only the message.
+type ApprovalGateStep struct {
+	Message string `yaml:"message,omitempty" toml:"message,omitempty" json:"message,omitempty"`
+	// Approvers restricts who may approve. An approval granted by anyone
+	// else is ignored and a fresh request is filed; denials from anyone
+	// still fail the gate.
+	Approvers StringOrList `yaml:"approvers,omitempty" toml:"approvers,omitempty" json:"approvers,omitempty"`
+	// SLB applies the two-person rule: the identity that started the run
+	// (NTM_USER, else USER) cannot approve its own gate.
+	SLB bool `yaml:"slb,omitempty" toml:"slb,omitempty" json:"slb,omitempty"`
+	// Timeout is how long the request stays open before the gate fails.
+	// Defaults to the step timeout, then to the time left before the run's
+	// global timeout.
+	Timeout Duration `yaml:"timeout,omitempty" toml:"timeout,omitempty" json:"timeout,omitempty"`
+}
+
+// UnmarshalYAML accepts a bare message or the structured form.
+func (g *ApprovalGateStep) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var message string
+	if err := unmarshal(&message); err == nil {
+		*g = ApprovalGateStep{Message: message}
+		return nil
+	}
+	type raw ApprovalGateStep
+	var obj raw
+	if err := unmarshal(&obj); err != nil {
+		return fmt.Errorf("approval: must be a message or {message, approvers, slb, timeout}: %w", err)
+	}
+	*g = ApprovalGateStep(obj)
+	return nil
+}
+
+// UnmarshalJSON accepts a bare message or the structured form.
+func (g *ApprovalGateStep) UnmarshalJSON(data []byte) error {
+	if isJSONNull(data) {
+		*g = ApprovalGateStep{}
+		return nil
+	}
+	var message string
+	if err := json.Unmarshal(data, &message); err == nil {
+		*g = ApprovalGateStep{Message: message}
+		return nil
+	}
+	type raw ApprovalGateStep
+	var obj raw
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return fmt.Errorf("approval: must be a message or {message, approvers, slb, timeout}: %w", err)
+	}
+	*g = ApprovalGateStep(obj)
+	return nil
+}
+
+// UnmarshalTOML accepts a bare message or the structured table.
+func (g *ApprovalGateStep) UnmarshalTOML(data any) error {
+	if message, ok := data.(string); ok {
+		*g = ApprovalGateStep{Message: message}
+		return nil
+	}
+	type raw ApprovalGateStep
+	var obj raw
+	if err := decodeTOMLValue(data, &obj); err != nil {
+		return fmt.Errorf("approval: must be a message or {message, approvers, slb, timeout}: %w", err)
+	}
+	*g = ApprovalGateStep(obj)
+	return nil
+}
+
+// ApprovalGateState is the persisted request behind an approval gate. A
+// resumed run finds it here and keeps waiting on the same request instead of
+// filing a duplicate.
+type ApprovalGateState struct {
+	StepID      string               `json:"step_id"`
+	ApprovalID  string               `json:"approval_id"`
+	Status      state.ApprovalStatus `json:"status"`
+	RequestedAt time.Time            `json:"requested_at"`
+	ExpiresAt   time.Time            `json:"expires_at,omitempty"`
+	DecidedBy   string               `json:"decided_by,omitempty"`
+	DecidedAt   time.Time            `json:"decided_at,omitempty"`
+}
+
+// defaultApprovalPollInterval is how often a waiting gate re-reads its
+// request when ExecutorConfig.ApprovalPollInterval is unset.
+const defaultApprovalPollInterval = 2 * time.Second
+
+// approvalGateAction is the action recorded on gate approval requests.
+const approvalGateAction = "pipeline_gate"
+
+func (e *Executor) executeApprovalGate(ctx context.Context, step *Step, workflow *Workflow) StepResult {
+	result := StepResult{
+		StepID:    step.ID,
+		Status:    StatusRunning,
+		StartedAt: time.Now(),
+		AgentType: "approval",
+	}
+	fail := func(errType, reason, hint string, err error) StepResult {
+		details := ""
+		if err != nil {
+			details = err.Error()
+		}
+		result.Status = StatusFailed
+		result.Error = stepRuntimeError(step, "approval", errType, reason, hint, details)
+		result.FinishedAt = time.Now()
+		return result
+	}
+
+	gate := step.Approval
+	message, err := e.substituteVariablesStrictCtx(ctx, gate.Message)
+	if err != nil {
+		return fail("approval", fmt.Sprintf("variable substitution failed in approval message: %v", err),
+			"reference declared workflow vars or provide a | fallback for optional values", err)
+	}
+	message = strings.TrimSpace(message)
+	if message == "" {
+		message = fmt.Sprintf("Approve step %s of workflow %s", step.ID, workflow.Name)
+	}
+	approvers := approvalGateApprovers(gate.Approvers)
+
+	if e.config.DryRun {
+		result.Status = StatusCompleted
+		result.Output = dryRunOutput(step, "Would request approval: "+SanitizeDescriptionForTerminal(message))
+		result.FinishedAt = time.Now()
+		return result
+	}
+
+	engine, release, err := e.approvalEngine()
+	if err != nil {
+		return fail("approval", fmt.Sprintf("approval engine unavailable: %v", err),
+			"approval gates need the ntm state database", err)
+	}
+	defer release()
+
+	e.stateMu.RLock()
+	runID := e.state.RunID
+	prior, hasPrior := e.state.ApprovalGates[step.ID]
+	e.stateMu.RUnlock()
+
+	request := func() (*state.Approval, error) {
+		expiry := gate.Timeout.Duration
+		if expiry <= 0 {
+			expiry = step.Timeout.Duration
+		}
+		if deadline, ok := ctx.Deadline(); ok {
+			remaining := time.Until(deadline)
+			if expiry <= 0 {
+				expiry = remaining
+			} else if expiry > remaining {
+				slog.Warn("approval gate timeout outlives the run's global timeout",
+					"run_id", runID, "step_id", step.ID,
+					"timeout", expiry, "remaining", remaining.Round(time.Second))
+			}
+		}
+		reason := message
+		if len(approvers) > 0 {
+			reason += " (approvers: " + strings.Join(approvers, ", ") + ")"
+		}
+		record, err := engine.Request(ctx, approval.RequestParams{
+			Action:        approvalGateAction,
+			Resource:      workflow.Name + "/" + step.ID,
+			Reason:        reason,
+			RequestedBy:   approvalGateRequester(),
+			CorrelationID: runID,
+			RequiresSLB:   gate.SLB,
+			ExpiresIn:     expiry,
+		})
+		if err != nil {
+			return nil, err
+		}
+		e.recordApprovalGate(ApprovalGateState{
+			StepID:      step.ID,
+			ApprovalID:  record.ID,
+			Status:      record.Status,
+			RequestedAt: record.CreatedAt,
+			ExpiresAt:   record.ExpiresAt,
+		})
+		e.persistState()
+		slog.Info("approval gate requested",
+			"run_id", runID,
+			"workflow", workflow.Name,
+			"step_id", step.ID,
+			"approval_id", record.ID,
+			"expires_at", record.ExpiresAt,
+		)
+		return record, nil
+	}
+
+	// Re-attach to the request a previous attempt filed unless this gate
+	// already settled it: re-running a denied or expired gate (resume of a
+	// failed run, on_error retry) or a gate inside a loop asks again.
+	var record *state.Approval
+	if hasPrior && prior.ApprovalID != "" && (prior.Status == state.ApprovalPending || prior.Status == state.ApprovalApproved) {
+		record, err = engine.Check(ctx, prior.ApprovalID)
+		if err != nil && !errors.Is(err, approval.ErrNotFound) {
+			return fail("approval", fmt.Sprintf("failed to read approval %s: %v", prior.ApprovalID, err),
+				"check the ntm state database", err)
+		}
+	}
+
+	for {
+		if record == nil {
+			if record, err = request(); err != nil {
+				return fail("approval", fmt.Sprintf("failed to request approval: %v", err),
+					"check the ntm state database and slb configuration", err)
+			}
+		}
+
+		record, err = e.awaitApprovalDecision(ctx, engine, step.ID, message, record)
+		if err != nil {
+			if ctx.Err() != nil {
+				// The request stays pending; a resumed run waits on it again.
+				result.Status = StatusCancelled
+				result.SkipReason = "cancelled while waiting for approval"
+				result.SkipKind = SkipKindCancelled
+				result.FinishedAt = time.Now()
+				return result
+			}
+			return fail("approval", fmt.Sprintf("failed to read approval decision: %v", err),
+				"check the ntm state database", err)
+		}
+		e.recordApprovalDecision(step.ID, record)
+
+		switch record.Status {
+		case state.ApprovalApproved:
+			if len(approvers) > 0 && !stringSliceContains(approvers, record.ApprovedBy) {
+				slog.Warn("approval gate ignored approval from a non-listed approver",
+					"run_id", runID, "step_id", step.ID,
+					"approval_id", record.ID, "approved_by", record.ApprovedBy)
+				record = nil
+				continue
+			}
+			if err := engine.Consume(ctx, record.ID, "pipeline:"+runID); err != nil {
+				return fail("approval", fmt.Sprintf("failed to consume approval %s: %v", record.ID, err),
+					"another consumer may have spent the approval; re-run the step to ask again", err)
+			}
+			record.Status = state.ApprovalConsumed
+			e.recordApprovalDecision(step.ID, record)
+		case state.ApprovalConsumed:
+			// Consumed by this gate before a crash: the decision stands.
+		case state.ApprovalDenied:
+			reason := fmt.Sprintf("approval %s denied by %s", record.ID, record.ApprovedBy)
+			if record.DeniedReason != "" {
+				reason += ": " + record.DeniedReason
+			}
+			return fail("approval_denied", reason, "resume the run to request approval again", nil)
+		case state.ApprovalExpired:
+			return fail("approval_timeout", fmt.Sprintf("approval %s expired at %s without a decision", record.ID, record.ExpiresAt.Format(time.RFC3339)),
+				"raise approval.timeout or resume the run to request approval again", nil)
+		default:
+			return fail("approval", fmt.Sprintf("approval %s has unexpected status %q", record.ID, record.Status), "", nil)
+		}
+		break
+	}
+
+	data := map[string]interface{}{
+		"approval_id": record.ID,
+		"approved_by": record.ApprovedBy,
+		"status":      string(state.ApprovalApproved),
+	}
+	if record.ApprovedAt != nil {
+		data["decided_at"] = record.ApprovedAt.UTC().Format(time.RFC3339)
+	}
+	result.Output = fmt.Sprintf("approved by %s (%s)", record.ApprovedBy, record.ID)
+	result.ParsedData = data
+	result.Status = StatusCompleted
+	result.FinishedAt = time.Now()
+
+	slog.Info("approval gate passed",
+		"run_id", runID,
+		"workflow", workflow.Name,
+		"step_id", step.ID,
+		"approval_id", record.ID,
+		"approved_by", record.ApprovedBy,
+	)
+	return result
+}
+
+// awaitApprovalDecision polls a pending request until it is decided or
+// expires, holding the run in StatusWaitingApproval meanwhile.
+func (e *Executor) awaitApprovalDecision(ctx context.Context, engine *approval.Engine, stepID, message string, record *state.Approval) (*state.Approval, error) {
+	if record.Status != state.ApprovalPending {
+		return record, nil
+	}
+
+	e.beginApprovalWait()
+	defer e.endApprovalWait()
+	e.emitProgress("approval_waiting", stepID,
+		fmt.Sprintf("Waiting for approval %s: %s (ntm approve %s)", record.ID, SanitizeDescriptionForTerminal(message), record.ID),
+		e.calculateProgress())
+
+	interval := e.config.ApprovalPollInterval
+	if interval <= 0 {
+		interval = defaultApprovalPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+		current, err := engine.Check(ctx, record.ID)
+		if err != nil {
+			return nil, err
+		}
+		if current.Status != state.ApprovalPending {
+			return current, nil
+		}
+	}
+}
+
+func (e *Executor) beginApprovalWait() {
+	e.stateMu.Lock()
+	e.approvalWaits++
+	if e.state != nil && e.state.Status == StatusRunning {
+		e.state.Status = StatusWaitingApproval
+	}
+	e.stateMu.Unlock()
+	e.persistState()
+}
+
+func (e *Executor) endApprovalWait() {
+	e.stateMu.Lock()
+	e.approvalWaits--
+	if e.approvalWaits == 0 && e.state != nil && e.state.Status == StatusWaitingApproval {
+		e.state.Status = StatusRunning
+	}
+	e.stateMu.Unlock()
+}
+
+func (e *Executor) recordApprovalGate(gate ApprovalGateState) {
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+	if e.state == nil {
+		return
+	}
+	if e.state.ApprovalGates == nil {
+		e.state.ApprovalGates = make(map[string]ApprovalGateState)
+	}
+	e.state.ApprovalGates[gate.StepID] = gate
+}
+
+func (e *Executor) recordApprovalDecision(stepID string, record *state.Approval) {
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+	if e.state == nil {
+		return
+	}
+	gate, ok := e.state.ApprovalGates[stepID]
+	if !ok || gate.ApprovalID != record.ID {
+		return
+	}
+	gate.Status = record.Status
+	gate.DecidedBy = record.ApprovedBy
+	if record.ApprovedAt != nil {
+		gate.DecidedAt = *record.ApprovedAt
+	}
+	e.state.ApprovalGates[stepID] = gate
+}
+
+// approvalEngine returns the configured engine, or one over the default
+// state store that the returned release func closes.
+func (e *Executor) approvalEngine() (*approval.Engine, func(), error) {
+	if e.config.Approvals != nil {
+		return e.config.Approvals, func() {}, nil
+	}
+	store, err := state.Open("")
+	if err != nil {
+		return nil, nil, fmt.Errorf("open state store: %w", err)
+	}
+	if err := store.Migrate(); err != nil {
+		store.Close()
+		return nil, nil, fmt.Errorf("apply migrations: %w", err)
+	}
+	return approval.New(store, nil, nil, approval.DefaultConfig()), func() { _ = store.Close() }, nil
+}
+
+// approvalGateRequester is the identity recorded as the requester, which the
+// two-person rule keeps from approving its own gate. It matches the identity
+// `ntm approve` records for the approver.
+func approvalGateRequester() string {
+	if user := os.Getenv("NTM_USER"); user != "" {
+		return user
+	}
+	if user := os.Getenv("USER"); user != "" {
+		return user
+	}
+	return "ntm-pipeline"
+}
+
+func approvalGateApprovers(list StringOrList) []string {
+	var out []string
+	for _, approver := range list {
+		if approver = strings.TrimSpace(approver); approver != "" {
+			out = append(out, approver)
+		}
+	}
+	return out
+}
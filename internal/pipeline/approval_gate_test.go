@@ -0,0 +1,278 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/approval"
+	"github.com/Dicklesworthstone/ntm/internal/state"
+)
+
+func newGateEngine(t *testing.T) *approval.Engine {
+	t.Helper()
+	store, err := state.Open(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("open state store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	if err := store.Migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	cfg := approval.DefaultConfig()
+	cfg.EnableSLB = false
+	return approval.New(store, nil, nil, cfg)
+}
+
+type gateRun struct {
+	exec  *Executor
+	state *ExecutionState
+	err   error
+	done  chan struct{}
+}
+
+func startGateRun(t *testing.T, ctx context.Context, path string, engine *approval.Engine, prior *ExecutionState) *gateRun {
+	t.Helper()
+	workflow, validation, err := LoadAndValidate(path)
+	if err != nil || !validation.Valid {
+		t.Fatalf("LoadAndValidate(%s) = %v, %+v", path, err, validation.Errors)
+	}
+	cfg := DefaultExecutorConfig("gate-session")
+	cfg.ProjectDir = filepath.Dir(path)
+	cfg.WorkflowFile = path
+	cfg.DefaultTimeout = 5 * time.Second
+	cfg.Approvals = engine
+	cfg.ApprovalPollInterval = 10 * time.Millisecond
+	run := &gateRun{exec: NewExecutor(cfg), done: make(chan struct{})}
+	go func() {
+		defer close(run.done)
+		if prior != nil {
+			run.state, run.err = run.exec.Resume(ctx, workflow, prior, nil)
+		} else {
+			run.state, run.err = run.exec.Run(ctx, workflow, nil, nil)
+		}
+	}()
+	return run
+}
+
+func (r *gateRun) wait(t *testing.T) {
+	t.Helper()
+	select {
+	case <-r.done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("pipeline did not finish")
+	}
+}
+
+// waitForPendingGate waits until the run is parked on a pending approval and
+// returns that request.
+func waitForPendingGate(t *testing.T, engine *approval.Engine, run *gateRun) state.Approval {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		pending, err := engine.ListPending(context.Background())
+		if err != nil {
+			t.Fatalf("ListPending: %v", err)
+		}
+		if st := run.exec.GetState(); len(pending) == 1 && st != nil && st.Status == StatusWaitingApproval {
+			return pending[0]
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("run never reached waiting_approval")
+	return state.Approval{}
+}
+
+const gatedDeployWorkflow = `
+schema_version: "2.0"
+name: gated-deploy
+steps:
+  - id: confirm
+    approval:
+      message: "Deploy ${vars.target}?"
+      slb: true
+  - id: deploy
+    depends_on: [confirm]
+    command: echo deployed
+vars:
+  target: {type: string, default: prod}
+`
+
+func TestApprovalGateContinuesAfterApproval(t *testing.T) {
+	t.Setenv("NTM_USER", "carol")
+	dir := t.TempDir()
+	path := writeWorkflowFile(t, dir, "deploy.yaml", gatedDeployWorkflow)
+	engine := newGateEngine(t)
+
+	run := startGateRun(t, context.Background(), path, engine, nil)
+	req := waitForPendingGate(t, engine, run)
+
+	if req.CorrelationID != run.exec.GetState().RunID || req.Action != approvalGateAction || !strings.Contains(req.Reason, "Deploy prod?") {
+		t.Fatalf("approval request = %+v", req)
+	}
+	onDisk, err := LoadState(dir, req.CorrelationID)
+	if err != nil || onDisk.Status != StatusWaitingApproval || onDisk.ApprovalGates["confirm"].ApprovalID != req.ID {
+		t.Fatalf("persisted state = %+v, %v", onDisk, err)
+	}
+	if err := engine.Approve(context.Background(), req.ID, "carol"); !errors.Is(err, approval.ErrSLBSelfApproval) {
+		t.Fatalf("self-approval under slb = %v, want ErrSLBSelfApproval", err)
+	}
+	if err := engine.Approve(context.Background(), req.ID, "dave"); err != nil {
+		t.Fatalf("approve: %v", err)
+	}
+	run.wait(t)
+
+	if run.err != nil || run.state.Status != StatusCompleted {
+		t.Fatalf("run = %v, %v", run.state.Status, run.err)
+	}
+	gate := run.state.Steps["confirm"]
+	data, _ := gate.ParsedData.(map[string]interface{})
+	if data["approved_by"] != "dave" || data["approval_id"] != req.ID {
+		t.Fatalf("gate result = %+v", gate)
+	}
+	if run.state.Steps["deploy"].Status != StatusCompleted {
+		t.Fatalf("deploy step = %+v", run.state.Steps["deploy"])
+	}
+	if rec, _ := engine.Check(context.Background(), req.ID); rec.Status != state.ApprovalConsumed {
+		t.Fatalf("approval status after gate = %s, want consumed", rec.Status)
+	}
+}
+
+func TestApprovalGateDenialFailsRun(t *testing.T) {
+	dir := t.TempDir()
+	path := writeWorkflowFile(t, dir, "deploy.yaml", gatedDeployWorkflow)
+	engine := newGateEngine(t)
+
+	run := startGateRun(t, context.Background(), path, engine, nil)
+	req := waitForPendingGate(t, engine, run)
+	if err := engine.Deny(context.Background(), req.ID, "dave", "freeze in effect"); err != nil {
+		t.Fatalf("deny: %v", err)
+	}
+	run.wait(t)
+
+	if run.state.Status != StatusFailed {
+		t.Fatalf("status = %s, want failed", run.state.Status)
+	}
+	gate := run.state.Steps["confirm"]
+	if gate.Error == nil || gate.Error.Type != "approval_denied" || !strings.Contains(gate.Error.Message, "freeze in effect") {
+		t.Fatalf("gate error = %+v", gate.Error)
+	}
+	if run.state.Steps["deploy"].Status == StatusCompleted {
+		t.Fatal("gated step ran after a denial")
+	}
+}
+
+func TestApprovalGateTimeoutFailsRun(t *testing.T) {
+	dir := t.TempDir()
+	path := writeWorkflowFile(t, dir, "deploy.yaml", `
+schema_version: "2.0"
+name: gated-deploy
+steps:
+  - id: confirm
+    gate: {message: "Ship it?", timeout: 50ms}
+`)
+	run := startGateRun(t, context.Background(), path, newGateEngine(t), nil)
+	run.wait(t)
+	gate := run.state.Steps["confirm"]
+	if run.state.Status != StatusFailed || gate.Error == nil || gate.Error.Type != "approval_timeout" {
+		t.Fatalf("status %s, gate %+v", run.state.Status, gate.Error)
+	}
+}
+
+func TestApprovalGateResumeWaitsOnSameRequest(t *testing.T) {
+	dir := t.TempDir()
+	path := writeWorkflowFile(t, dir, "deploy.yaml", gatedDeployWorkflow)
+	engine := newGateEngine(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := startGateRun(t, ctx, path, engine, nil)
+	req := waitForPendingGate(t, engine, first)
+	cancel()
+	first.wait(t)
+
+	prior, err := LoadState(dir, req.CorrelationID)
+	if err != nil {
+		t.Fatalf("LoadState: %v", err)
+	}
+	if err := engine.Approve(context.Background(), req.ID, "dave"); err != nil {
+		t.Fatalf("approve: %v", err)
+	}
+
+	resumed := startGateRun(t, context.Background(), path, engine, prior)
+	resumed.wait(t)
+	if resumed.err != nil || resumed.state.Status != StatusCompleted {
+		t.Fatalf("resumed run = %v, %v", resumed.state.Status, resumed.err)
+	}
+	history, _ := engine.History(context.Background())
+	if len(history) != 1 || history[0].Status != state.ApprovalConsumed {
+		t.Fatalf("resume filed a new request or left it unspent: %+v", history)
+	}
+}
+
+func TestApprovalGateIgnoresUnlistedApprover(t *testing.T) {
+	dir := t.TempDir()
+	path := writeWorkflowFile(t, dir, "merge.yaml", `
+schema_version: "2.0"
+name: gated-merge
+steps:
+  - id: confirm
+    approval:
+      message: Merge to main?
+      approvers: [alice]
+`)
+	engine := newGateEngine(t)
+	run := startGateRun(t, context.Background(), path, engine, nil)
+
+	first := waitForPendingGate(t, engine, run)
+	if err := engine.Approve(context.Background(), first.ID, "mallory"); err != nil {
+		t.Fatalf("approve: %v", err)
+	}
+	var second state.Approval
+	deadline := time.Now().Add(5 * time.Second)
+	for second.ID == "" || second.ID == first.ID {
+		if time.Now().After(deadline) {
+			t.Fatal("gate did not re-request after an unlisted approval")
+		}
+		second = waitForPendingGate(t, engine, run)
+	}
+	if err := engine.Approve(context.Background(), second.ID, "alice"); err != nil {
+		t.Fatalf("approve: %v", err)
+	}
+	run.wait(t)
+	if run.state.Status != StatusCompleted || !strings.Contains(run.state.Steps["confirm"].Output, "alice") {
+		t.Fatalf("run = %s, gate output %q", run.state.Status, run.state.Steps["confirm"].Output)
+	}
+}
+
+func TestValidateApprovalGateStep(t *testing.T) {
+	for name, tc := range map[string]struct {
+		step string
+		want string
+	}{
+		"with command": {"approval: ok?\n    command: echo hi", "cannot combine approval"},
+		"both aliases": {"approval: ok?\n    gate: ok?", "both approval and gate"},
+	} {
+		wf, err := ParseString("schema_version: \"2.0\"\nname: v\nsteps:\n  - id: s\n    "+tc.step+"\n", "yaml")
+		if err != nil {
+			t.Fatalf("%s: parse: %v", name, err)
+		}
+		result := Validate(wf)
+		if result.Valid || !strings.Contains(result.Errors[0].Message, tc.want) {
+			t.Errorf("%s: errors = %+v, want %q", name, result.Errors, tc.want)
+		}
+	}
+
+	wf, err := ParseString("schema_version: \"2.0\"\nname: v\nsteps:\n  - id: s\n    gate: Deploy?\n", "yaml")
+	if err != nil {
+		t.Fatalf("parse shorthand: %v", err)
+	}
+	if result := Validate(wf); !result.Valid {
+		t.Fatalf("shorthand gate invalid: %+v", result.Errors)
+	}
+	if wf.Steps[0].Approval == nil || wf.Steps[0].Approval.Message != "Deploy?" || wf.Steps[0].Gate != nil {
+		t.Fatalf("gate alias not normalized: %+v", wf.Steps[0])
+	}
+}
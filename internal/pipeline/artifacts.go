@@ -0,0 +1,479 @@
+package pipeline
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ArtifactSpec declares files a step leaves in the working directory (the
+// project directory commands run in). When the step completes, every file
+// matching Path is copied into the run's artifact store under
+// .ntm/pipelines/<run-id>/artifacts/<step>/<name>/, so later steps, the REST
+// API and `ntm pipeline artifacts` can read them after the workdir changes.
+//
+//	artifacts:
+//	  - name: patch
+//	    path: "out/*.patch"
+//	  - report.json          # name defaults to "report"
+//
+// Path is a filepath.Glob pattern; a matched directory is archived
+// recursively. Symlinks are not followed, and a pattern that reaches outside
+// the working directory through a symlinked directory fails the step.
+type ArtifactSpec struct {
+	Name string `yaml:"name,omitempty" toml:"name,omitempty" json:"name,omitempty"`
+	Path string `yaml:"path" toml:"path" json:"path"`
+	// Optional lets the step succeed when nothing matches Path. A required
+	// artifact that matches nothing fails the step.
+	Optional bool `yaml:"optional,omitempty" toml:"optional,omitempty" json:"optional,omitempty"`
+}
+
+// UnmarshalYAML accepts a bare path or the structured form.
+func (a *ArtifactSpec) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var path string
+	if err := unmarshal(&path); err == nil {
+		*a = artifactSpecFromPath(path)
+		return nil
+	}
+	type raw ArtifactSpec
+	var obj raw
+	if err := unmarshal(&obj); err != nil {
+		return fmt.Errorf("artifacts: entry must be a path or {name, path, optional}: %w", err)
+	}
+	*a = ArtifactSpec(obj)
+	return nil
+}
+
+// UnmarshalJSON accepts a bare path or the structured form.
+func (a *ArtifactSpec) UnmarshalJSON(data []byte) error {
+	var path string
+	if err := json.Unmarshal(data, &path); err == nil {
+		*a = artifactSpecFromPath(path)
+		return nil
+	}
+	type raw ArtifactSpec
+	var obj raw
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return fmt.Errorf("artifacts: entry must be a path or {name, path, optional}: %w", err)
+	}
+	*a = ArtifactSpec(obj)
+	return nil
+}
+
+// UnmarshalTOML accepts a bare path or the structured table.
+func (a *ArtifactSpec) UnmarshalTOML(data any) error {
+	if path, ok := data.(string); ok {
+		*a = artifactSpecFromPath(path)
+		return nil
+	}
+	type raw ArtifactSpec
+	var obj raw
+	if err := decodeTOMLValue(data, &obj); err != nil {
+		return fmt.Errorf("artifacts: entry must be a path or {name, path, optional}: %w", err)
+	}
+	*a = ArtifactSpec(obj)
+	return nil
+}
+
+// artifactSpecFromPath names a shorthand entry after its file name without
+// the extension. Glob patterns get no name; validation asks for one.
+func artifactSpecFromPath(path string) ArtifactSpec {
+	spec := ArtifactSpec{Path: path}
+	base := filepath.Base(filepath.FromSlash(path))
+	if !strings.ContainsAny(base, "*?[${") {
+		spec.Name = strings.TrimSuffix(base, filepath.Ext(base))
+	}
+	return spec
+}
+
+// ArtifactRecord is what a completed step archived for one ArtifactSpec.
+type ArtifactRecord struct {
+	Name string `json:"name"`
+	// Dir is the absolute directory holding the archived copies.
+	Dir   string         `json:"dir"`
+	Files []ArtifactFile `json:"files,omitempty"`
+}
+
+// ArtifactFile is a single archived file. Path is slash-separated and
+// relative to both the working directory it came from and the record's Dir.
+type ArtifactFile struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Location is the value ${artifacts.<step>.<name>} resolves to: the archived
+// file when exactly one matched, otherwise the directory holding them all.
+func (r ArtifactRecord) Location() string {
+	if len(r.Files) == 1 {
+		return filepath.Join(r.Dir, filepath.FromSlash(r.Files[0].Path))
+	}
+	return r.Dir
+}
+
+// RunArtifact is one archived file of a run, as listed by
+// `ntm pipeline artifacts` and GET /api/v1/pipelines/{id}/artifacts.
+type RunArtifact struct {
+	Step   string `json:"step"`
+	Name   string `json:"name"`
+	File   string `json:"file"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// ErrArtifactNotFound is returned when a run has no archived artifact
+// matching the requested step, name and file.
+var ErrArtifactNotFound = errors.New("artifact not found")
+
+const (
+	artifactStoreDirName = "artifacts"
+
+	// maxArtifactBytes caps the total size archived for one ArtifactSpec so
+	// a stray glob cannot copy a build tree into .ntm.
+	maxArtifactBytes int64 = 256 << 20
+)
+
+// ArtifactStoreDir returns the directory holding a run's archived artifacts.
+func ArtifactStoreDir(projectDir, runID string) string {
+	return filepath.Join(pipelineStateDir(projectDir), runID, artifactStoreDirName)
+}
+
+// ListRunArtifacts returns every file archived by a persisted run, ordered by
+// step, artifact name and file path.
+func ListRunArtifacts(projectDir, runID string) ([]RunArtifact, error) {
+	state, err := LoadState(projectDir, runID)
+	if err != nil {
+		return nil, err
+	}
+	var out []RunArtifact
+	for stepID, result := range state.Steps {
+		for _, rec := range result.Artifacts {
+			for _, f := range rec.Files {
+				out = append(out, RunArtifact{Step: stepID, Name: rec.Name, File: f.Path, Size: f.Size, SHA256: f.SHA256})
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Step != out[j].Step {
+			return out[i].Step < out[j].Step
+		}
+		if out[i].Name != out[j].Name {
+			return out[i].Name < out[j].Name
+		}
+		return out[i].File < out[j].File
+	})
+	return out, nil
+}
+
+// ResolveRunArtifact finds one archived file of a persisted run and returns
+// its absolute path. file may be empty when the artifact holds exactly one
+// file. Only paths recorded in the run state are ever returned, so the
+// arguments cannot be used to reach outside the artifact store.
+func ResolveRunArtifact(projectDir, runID, stepID, name, file string) (RunArtifact, string, error) {
+	artifacts, err := ListRunArtifacts(projectDir, runID)
+	if err != nil {
+		return RunArtifact{}, "", err
+	}
+	var matches []RunArtifact
+	for _, a := range artifacts {
+		if a.Step == stepID && a.Name == name && (file == "" || a.File == file) {
+			matches = append(matches, a)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return RunArtifact{}, "", fmt.Errorf("%w: %s/%s %s", ErrArtifactNotFound, stepID, name, file)
+	case 1:
+		a := matches[0]
+		path := filepath.Join(ArtifactStoreDir(projectDir, runID), a.Step, a.Name, filepath.FromSlash(a.File))
+		return a, path, nil
+	default:
+		return RunArtifact{}, "", fmt.Errorf("artifact %s/%s holds %d files; specify which file", stepID, name, len(matches))
+	}
+}
+
+// validateArtifactSpecs checks a step's artifact declarations.
+func validateArtifactSpecs(step *Step, stepField string, result *ValidationResult) {
+	seen := make(map[string]bool, len(step.Artifacts))
+	for i, spec := range step.Artifacts {
+		field := fmt.Sprintf("%s.artifacts[%d]", stepField, i)
+		switch {
+		case spec.Name == "":
+			result.addError(ParseError{
+				Field:   field + ".name",
+				Message: "artifact name is required",
+				Hint:    "Name the artifact so later steps can use ${artifacts.<step>.<name>}",
+			})
+		case !isValidID(spec.Name):
+			result.addError(ParseError{
+				Field:   field + ".name",
+				Message: fmt.Sprintf("invalid artifact name: %s", spec.Name),
+				Hint:    "Use alphanumeric characters, underscores, and hyphens only",
+			})
+		case seen[spec.Name]:
+			result.addError(ParseError{
+				Field:   field + ".name",
+				Message: fmt.Sprintf("duplicate artifact name: %s", spec.Name),
+				Hint:    "Each artifact of a step must have a unique name",
+			})
+		}
+		seen[spec.Name] = true
+
+		if strings.TrimSpace(spec.Path) == "" {
+			result.addError(ParseError{
+				Field:   field + ".path",
+				Message: "artifact path is required",
+				Hint:    "Set path to a file or glob relative to the project directory",
+			})
+		} else if err := checkArtifactPattern(spec.Path); err != nil {
+			result.addError(ParseError{
+				Field:   field + ".path",
+				Message: err.Error(),
+				Hint:    "Artifact paths are relative to the project directory and must stay inside it",
+			})
+		}
+	}
+}
+
+// checkArtifactPattern rejects patterns that could match outside the
+// working directory.
+func checkArtifactPattern(pattern string) error {
+	native := filepath.FromSlash(pattern)
+	if filepath.IsAbs(native) || strings.HasPrefix(pattern, "/") {
+		return fmt.Errorf("artifact path %q must be relative", pattern)
+	}
+	if _, err := filepath.Match(native, ""); err != nil {
+		return fmt.Errorf("artifact path %q is not a valid glob: %w", pattern, err)
+	}
+	for _, part := range strings.FieldsFunc(pattern, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if part == ".." {
+			return fmt.Errorf("artifact path %q must not contain ..", pattern)
+		}
+	}
+	return nil
+}
+
+// workDir is the directory commands run in and artifacts are collected from.
+func (e *Executor) workDir() (string, error) {
+	if e.config.ProjectDir != "" {
+		return e.config.ProjectDir, nil
+	}
+	return os.Getwd()
+}
+
+// collectArtifacts archives a completed step's declared artifacts and
+// records them on the result. A missing required artifact, or one that
+// cannot be copied, fails the step so retries and on_error apply as usual.
+func (e *Executor) collectArtifacts(step *Step, result StepResult) StepResult {
+	if len(step.Artifacts) == 0 || e.config.DryRun {
+		return result
+	}
+	fail := func(format string, args ...interface{}) StepResult {
+		result.Status = StatusFailed
+		result.Error = &StepError{
+			Type:      "artifact",
+			Message:   fmt.Sprintf(format, args...),
+			Timestamp: time.Now(),
+		}
+		return result
+	}
+
+	workDir, err := e.workDir()
+	if err != nil {
+		return fail("resolve working directory for artifacts: %v", err)
+	}
+	if strings.ContainsAny(step.ID, "/\\") {
+		return fail("step id %q cannot name an artifact directory", step.ID)
+	}
+	runID := e.runIDForLog()
+	store := ArtifactStoreDir(workDir, runID)
+
+	records := make([]ArtifactRecord, 0, len(step.Artifacts))
+	for _, spec := range step.Artifacts {
+		pattern, err := e.substituteVariablesStrict(spec.Path)
+		if err != nil {
+			return fail("artifact %s: %v", spec.Name, err)
+		}
+		if err := checkArtifactPattern(pattern); err != nil {
+			return fail("artifact %s: %v", spec.Name, err)
+		}
+		files, err := matchArtifactFiles(workDir, pattern)
+		if err != nil {
+			return fail("artifact %s: %v", spec.Name, err)
+		}
+		if len(files) == 0 {
+			if spec.Optional {
+				continue
+			}
+			return fail("artifact %s: no files match %q", spec.Name, pattern)
+		}
+
+		rec := ArtifactRecord{Name: spec.Name, Dir: filepath.Join(store, step.ID, spec.Name)}
+		if err := os.RemoveAll(rec.Dir); err != nil {
+			return fail("artifact %s: clear previous copy: %v", spec.Name, err)
+		}
+		var total int64
+		for _, rel := range files {
+			f, err := archiveArtifactFile(filepath.Join(workDir, rel), filepath.Join(rec.Dir, rel))
+			if err != nil {
+				return fail("artifact %s: %v", spec.Name, err)
+			}
+			total += f.Size
+			if total > maxArtifactBytes {
+				_ = os.RemoveAll(rec.Dir)
+				return fail("artifact %s: exceeds %d MiB", spec.Name, maxArtifactBytes>>20)
+			}
+			f.Path = filepath.ToSlash(rel)
+			rec.Files = append(rec.Files, f)
+		}
+		records = append(records, rec)
+		slog.Info("pipeline.artifact.archived",
+			"run_id", runID,
+			"step_id", step.ID,
+			"name", spec.Name,
+			"files", len(rec.Files),
+			"bytes", total,
+		)
+	}
+	result.Artifacts = records
+	return result
+}
+
+// matchArtifactFiles expands pattern under workDir into regular files,
+// descending into matched directories, and returns paths relative to
+// workDir in lexical order. filepath.Glob follows symlinked directories in
+// the middle of a pattern, so each match's parent is resolved and a match
+// whose real location is outside workDir is rejected.
+func matchArtifactFiles(workDir, pattern string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(workDir, filepath.FromSlash(pattern)))
+	if err != nil {
+		return nil, err
+	}
+	realWorkDir, err := filepath.EvalSymlinks(workDir)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var files []string
+	add := func(path string, mode fs.FileMode) error {
+		if !mode.IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(workDir, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("%s is outside the working directory", path)
+		}
+		if !seen[rel] {
+			seen[rel] = true
+			files = append(files, rel)
+		}
+		return nil
+	}
+	for _, match := range matches {
+		realParent, err := filepath.EvalSymlinks(filepath.Dir(match))
+		if err != nil {
+			return nil, err
+		}
+		if rel, err := filepath.Rel(realWorkDir, realParent); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("%s resolves outside the working directory", match)
+		}
+		info, err := os.Lstat(match)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			if err := add(match, info.Mode()); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		err = filepath.WalkDir(match, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if path == pipelineStateDir(workDir) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			return add(path, d.Type())
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// archiveArtifactFile copies src to dst and returns its size and digest.
+func archiveArtifactFile(src, dst string) (ArtifactFile, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return ArtifactFile{}, fmt.Errorf("open %s: %w", src, err)
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return ArtifactFile{}, fmt.Errorf("create artifact dir: %w", err)
+	}
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return ArtifactFile{}, fmt.Errorf("create %s: %w", dst, err)
+	}
+	hash := sha256.New()
+	n, err := io.Copy(io.MultiWriter(out, hash), io.LimitReader(in, maxArtifactBytes+1))
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return ArtifactFile{}, fmt.Errorf("copy %s: %w", src, err)
+	}
+	return ArtifactFile{Size: n, SHA256: hex.EncodeToString(hash.Sum(nil))}, nil
+}
+
+// resolveArtifacts handles artifacts.<step>.<name>[.dir|.files].
+func (s *Substitutor) resolveArtifacts(parts []string) (interface{}, error) {
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("artifacts requires step ID and artifact name")
+	}
+	if s.state == nil || s.state.Steps == nil {
+		return nil, fmt.Errorf("no execution state")
+	}
+	result, ok := s.state.Steps[parts[0]]
+	if !ok {
+		return nil, fmt.Errorf("step not found: %s", parts[0])
+	}
+	for _, rec := range result.Artifacts {
+		if rec.Name != parts[1] {
+			continue
+		}
+		if len(parts) == 2 {
+			return rec.Location(), nil
+		}
+		switch parts[2] {
+		case "dir":
+			return rec.Dir, nil
+		case "files":
+			files := make([]interface{}, len(rec.Files))
+			for i, f := range rec.Files {
+				files[i] = filepath.Join(rec.Dir, filepath.FromSlash(f.Path))
+			}
+			return files, nil
+		default:
+			return nil, fmt.Errorf("unknown artifact field: %s (use dir or files)", parts[2])
+		}
+	}
+	return nil, fmt.Errorf("step %s has no artifact %s", parts[0], parts[1])
+}
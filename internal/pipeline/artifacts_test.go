@@ -0,0 +1,163 @@
+package pipeline
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestArtifactsArchivedAndReferencedDownstream(t *testing.T) {
+	dir := t.TempDir()
+	path := writeWorkflowFile(t, dir, "review.yaml", `
+schema_version: "2.0"
+name: review
+steps:
+  - id: review
+    command: mkdir -p out && echo '{"ok":true}' > report.json && echo a > out/1.patch && echo b > out/2.patch
+    artifacts:
+      - report.json
+      - {name: patches, path: "out/*.patch"}
+      - {name: notes, path: "NOTES.md", optional: true}
+  - id: consume
+    depends_on: [review]
+    command: rm report.json && cat ${artifacts.review.report} && cat ${artifacts.review.patches}/out/1.patch
+`)
+	state, err := runWorkflowFile(t, path, false)
+	if err != nil || state.Status != StatusCompleted {
+		t.Fatalf("run = %v, %v (%+v)", state.Status, err, state.Steps)
+	}
+	if out := state.Steps["consume"].Output; !strings.Contains(out, `{"ok":true}`) || !strings.HasSuffix(out, "a") {
+		t.Fatalf("downstream output = %q", out)
+	}
+
+	records := state.Steps["review"].Artifacts
+	if len(records) != 2 || records[0].Name != "report" || records[1].Name != "patches" || len(records[1].Files) != 2 {
+		t.Fatalf("records = %+v", records)
+	}
+
+	listed, err := ListRunArtifacts(dir, state.RunID)
+	if err != nil {
+		t.Fatalf("ListRunArtifacts: %v", err)
+	}
+	var files []string
+	for _, a := range listed {
+		files = append(files, a.Name+":"+a.File)
+	}
+	if got := strings.Join(files, ","); got != "patches:out/1.patch,patches:out/2.patch,report:report.json" {
+		t.Fatalf("listed = %s", got)
+	}
+
+	if _, _, err := ResolveRunArtifact(dir, state.RunID, "review", "patches", ""); err == nil || !strings.Contains(err.Error(), "specify which file") {
+		t.Fatalf("ambiguous resolve = %v", err)
+	}
+	if _, _, err := ResolveRunArtifact(dir, state.RunID, "review", "patches", "../report.json"); !errors.Is(err, ErrArtifactNotFound) {
+		t.Fatalf("resolve outside the record = %v, want ErrArtifactNotFound", err)
+	}
+	a, file, err := ResolveRunArtifact(dir, state.RunID, "review", "patches", "out/2.patch")
+	if err != nil {
+		t.Fatalf("ResolveRunArtifact: %v", err)
+	}
+	if data, _ := os.ReadFile(file); string(data) != "b\n" || a.Size != 2 || !strings.HasPrefix(file, ArtifactStoreDir(dir, state.RunID)) {
+		t.Fatalf("resolved %+v at %s = %q", a, file, data)
+	}
+}
+
+func TestArtifactsMissingRequiredFailsStep(t *testing.T) {
+	dir := t.TempDir()
+	path := writeWorkflowFile(t, dir, "build.yaml", `
+schema_version: "2.0"
+name: build
+steps:
+  - id: build
+    command: "true"
+    artifacts: [{name: binary, path: "bin/*"}]
+`)
+	state, _ := runWorkflowFile(t, path, false)
+	result := state.Steps["build"]
+	if state.Status != StatusFailed || result.Error == nil || result.Error.Type != "artifact" || !strings.Contains(result.Error.Message, `no files match "bin/*"`) {
+		t.Fatalf("status %s, step %+v", state.Status, result.Error)
+	}
+}
+
+func TestCleanupStatesRemovesArtifacts(t *testing.T) {
+	dir := t.TempDir()
+	path := writeWorkflowFile(t, dir, "a.yaml", `
+schema_version: "2.0"
+name: a
+steps:
+  - id: s
+    command: echo x > x.txt
+    artifacts: [x.txt]
+`)
+	state, err := runWorkflowFile(t, path, false)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	store := ArtifactStoreDir(dir, state.RunID)
+	if _, err := os.Stat(filepath.Join(store, "s", "x", "x.txt")); err != nil {
+		t.Fatalf("archived copy missing: %v", err)
+	}
+	old := pipelineStatePath(dir, state.RunID)
+	past := state.StartedAt.AddDate(0, 0, -30)
+	if err := os.Chtimes(old, past, past); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	if n, err := CleanupStates(dir, 24*time.Hour); err != nil || n != 1 {
+		t.Fatalf("CleanupStates = %d, %v", n, err)
+	}
+	if _, err := os.Stat(filepath.Dir(store)); !os.IsNotExist(err) {
+		t.Fatalf("artifact dir survived cleanup: %v", err)
+	}
+}
+
+func TestValidateArtifactSpecs(t *testing.T) {
+	for name, tc := range map[string]struct {
+		artifacts string
+		want      string
+	}{
+		"glob without name": {`["out/*.patch"]`, "artifact name is required"},
+		"absolute":          {`[{name: a, path: /etc/passwd}]`, "must be relative"},
+		"parent":            {`[{name: a, path: ../secrets}]`, "must not contain .."},
+		"duplicate":         {`[a.txt, {name: a, path: b.txt}]`, "duplicate artifact name"},
+		"bad name":          {`[{name: "a.b", path: x}]`, "invalid artifact name"},
+	} {
+		wf, err := ParseString("schema_version: \"2.0\"\nname: v\nsteps:\n  - id: s\n    command: 'true'\n    artifacts: "+tc.artifacts+"\n", "yaml")
+		if err != nil {
+			t.Fatalf("%s: parse: %v", name, err)
+		}
+		result := Validate(wf)
+		if result.Valid || !strings.Contains(result.Errors[0].Message, tc.want) {
+			t.Errorf("%s: errors = %+v, want %q", name, result.Errors, tc.want)
+		}
+	}
+}
+
+func TestMatchArtifactFilesRejectsSymlinkedDirOutsideWorkDir(t *testing.T) {
+	workDir, outside := t.TempDir(), t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("s"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(workDir, "build"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(workDir, "build", "a.txt"), []byte("a"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(workDir, "out")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(workDir, "build"), filepath.Join(workDir, "alias")); err != nil {
+		t.Fatal(err)
+	}
+
+	if files, err := matchArtifactFiles(workDir, "out/*.txt"); err == nil || !strings.Contains(err.Error(), "outside the working directory") {
+		t.Fatalf("out/*.txt through a link to %s = %v, %v; want rejection", outside, files, err)
+	}
+	files, err := matchArtifactFiles(workDir, "alias/*.txt")
+	if err != nil || len(files) != 1 || files[0] != filepath.Join("alias", "a.txt") {
+		t.Fatalf("link within the work dir = %v, %v", files, err)
+	}
+}
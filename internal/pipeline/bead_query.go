@@ -75,6 +75,34 @@ func (e *Executor) executeBeadQuery(ctx context.Context, step *Step, workflow *W
 		return result
 	}
 
+	if step.Cache.enabled() {
+		info, cached, err := e.lookupStepCache(step, stepCacheMaterial{Kind: "bead_query", Args: args})
+		if err != nil {
+			result.Status = StatusFailed
+			result.Error = stepRuntimeError(step, "bead_query", "cache",
+				fmt.Sprintf("failed to compute cache key: %v", err),
+				"check the cache key expression and that every cache input path is readable",
+				err.Error())
+			result.FinishedAt = time.Now()
+			return result
+		}
+		result.Cache = info
+		if cached != nil {
+			var records []BeadRecord
+			if err := json.Unmarshal([]byte(cached.Output), &records); err == nil {
+				result.Output = cached.Output
+				result.ParsedData = records
+				result.Artifacts = cached.Artifacts
+				result.Status = StatusCompleted
+				result.FinishedAt = time.Now()
+				return result
+			}
+			// An unreadable cached payload falls through to a fresh query,
+			// whose result replaces the entry.
+			result.Cache = &StepCacheInfo{Key: info.Key}
+		}
+	}
+
 	slog.Info("bead_query step starting",
 		"run_id", e.state.RunID,
 		"workflow", workflow.Name,
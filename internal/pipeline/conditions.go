@@ -9,7 +9,8 @@ import (
 )
 
 // ConditionEvaluator evaluates conditional expressions for workflow steps.
-// It supports boolean, equality, comparison, contains, and logical operators.
+// It supports boolean, equality, comparison, contains, and logical operators
+// on substituted text, and typed ${{ ... }} expressions (see expr.go).
 type ConditionEvaluator struct {
 	substitutor *Substitutor
 }
@@ -36,6 +37,16 @@ func (e *ConditionEvaluator) Evaluate(condition string) (ConditionResult, error)
 		return ConditionResult{Value: true, Skip: false, Reason: "no condition"}, nil
 	}
 
+	// ${{ ... }} conditions are typed expressions: references resolve to
+	// structured values instead of being substituted as text.
+	if body, ok := typedConditionBody(condition); ok {
+		value, err := evalTypedCondition(body, &exprScope{sub: e.substitutor})
+		if err != nil {
+			return ConditionResult{}, err
+		}
+		return conditionResult(condition, value), nil
+	}
+
 	// Substitute variables first
 	substituted, err := e.substitutor.Substitute(condition)
 	if err != nil {
@@ -49,6 +60,10 @@ func (e *ConditionEvaluator) Evaluate(condition string) (ConditionResult, error)
 		return ConditionResult{}, err
 	}
 
+	return conditionResult(condition, value), nil
+}
+
+func conditionResult(condition string, value bool) ConditionResult {
 	result := ConditionResult{
 		Value: value,
 		Skip:  !value,
@@ -60,7 +75,7 @@ func (e *ConditionEvaluator) Evaluate(condition string) (ConditionResult, error)
 		result.Reason = fmt.Sprintf("condition '%s' evaluated to false", condition)
 	}
 
-	return result, nil
+	return result
 }
 
 // evaluateExpr evaluates a (substituted) expression recursively.
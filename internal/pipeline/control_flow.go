@@ -21,6 +21,18 @@ import (
 // the round overlay from withRoundOverrides instead of falling back to
 // the (post-bd-2ubxp.20 unpopulated) state.Variables["round"].
 func (e *Executor) resolveBranch(ctx context.Context, step *Step) (string, error) {
+	if body, ok := typedConditionBody(step.Branch); ok {
+		value, err := e.evaluateTypedExprCtx(withStepExprLocals(ctx, step), body)
+		if err != nil {
+			return "", err
+		}
+		switch value.(type) {
+		case []interface{}, map[string]interface{}:
+			return "", fmt.Errorf("branch expression must produce a string, number or boolean, got %s", exprTypeName(value))
+		}
+		return formatValue(value), nil
+	}
+
 	expr := e.substituteVariablesCtx(ctx, step.Branch)
 
 	if strings.HasPrefix(expr, "$(") && strings.HasSuffix(expr, ")") {
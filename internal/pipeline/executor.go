@@ -22,6 +22,8 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/Dicklesworthstone/ntm/internal/approval"
+	"github.com/Dicklesworthstone/ntm/internal/pressure"
 	"github.com/Dicklesworthstone/ntm/internal/robot"
 	"github.com/Dicklesworthstone/ntm/internal/status"
 	"github.com/Dicklesworthstone/ntm/internal/util"
@@ -48,6 +50,7 @@ type ExecutorConfig struct {
 	GlobalTimeout    time.Duration // Maximum workflow runtime (default: 30m)
 	ProgressInterval time.Duration // Interval for progress updates (default: 1s)
 	DryRun           bool          // If true, validate but don't execute
+	NoCache          bool          // If true, ignore cached step results (fresh results are still stored)
 	Verbose          bool          // Enable verbose logging
 	RunID            string        // Optional: pre-generated run ID (if empty, one is generated)
 	BeadQueryRunBr   func(ctx context.Context, args []string) ([]byte, error)
@@ -65,6 +68,20 @@ type ExecutorConfig struct {
 
 	// ResumeOptions controls how Resume interprets prior persisted state.
 	ResumeOptions ResumeOptions
+
+	// Approvals files and decides approval: gate requests. When nil, the
+	// gate opens the default state store for the duration of the wait.
+	Approvals *approval.Engine
+	// ApprovalPollInterval is how often a waiting gate re-checks its
+	// request (default: 2s). Decisions usually land from another process
+	// (`ntm approve`), so the gate polls the store rather than waiting on
+	// an in-process signal.
+	ApprovalPollInterval time.Duration
+
+	// Pressure, when set, gates parallel foreach and matrix fan-out on the
+	// governor's ActionPipelineFanout budget. When nil, fan-out is bounded
+	// only by limits.max_concurrent_foreach.
+	Pressure *pressure.Governor
 }
 
 // MinProgressInterval is the minimum allowed progress interval to prevent ticker panics.
@@ -102,6 +119,7 @@ type Executor struct {
 	paneMeta *PaneMetadataLoader
 	paneMu   sync.Mutex
 	graph    *DependencyGraph
+	workflow *Workflow // guarded by stateMu; read by Workflow()
 	progress chan<- ProgressEvent
 	cancelFn context.CancelFunc
 
@@ -135,6 +153,15 @@ type Executor struct {
 	paneLocks  map[string]chan struct{}
 
 	mailClients agentMailClientCache
+
+	// resuming is set by Resume so workflow: steps resume their callee's
+	// persisted run instead of starting it over.
+	resuming bool
+
+	// approvalWaits counts approval gates currently blocked on a decision;
+	// the run reports StatusWaitingApproval while it is non-zero. Guarded
+	// by stateMu.
+	approvalWaits int
 }
 
 // acquirePaneLock serializes the full dispatch window against a tmux pane.
@@ -225,6 +252,8 @@ func (e *Executor) Run(ctx context.Context, workflow *Workflow, vars map[string]
 		e.backgroundCommandWG.Wait()
 	}()
 
+	e.resuming = false
+
 	// Initialize execution state
 	runID := e.config.RunID
 	if runID == "" {
@@ -281,6 +310,9 @@ func (e *Executor) Run(ctx context.Context, workflow *Workflow, vars map[string]
 	e.persistState()
 
 	// Build dependency graph
+	e.stateMu.Lock()
+	e.workflow = workflow
+	e.stateMu.Unlock()
 	e.graph = NewDependencyGraph(workflow)
 	if errors := e.graph.Validate(); len(errors) > 0 {
 		e.stateMu.Lock()
@@ -391,6 +423,8 @@ func (e *Executor) Resume(ctx context.Context, workflow *Workflow, prior *Execut
 		e.backgroundCommandWG.Wait()
 	}()
 
+	e.resuming = true
+
 	e.stateMu.Lock()
 	e.state = prior
 	if e.state.Steps == nil {
@@ -439,6 +473,9 @@ func (e *Executor) Resume(ctx context.Context, workflow *Workflow, prior *Execut
 	e.limits = workflow.Settings.Limits.EffectiveLimits()
 
 	// Build dependency graph
+	e.stateMu.Lock()
+	e.workflow = workflow
+	e.stateMu.Unlock()
 	e.graph = NewDependencyGraph(workflow)
 	if errors := e.graph.Validate(); len(errors) > 0 {
 		e.stateMu.Lock()
@@ -923,7 +960,7 @@ func (e *Executor) executeStep(ctx context.Context, step *Step, workflow *Workfl
 	// loop body steps nested inside a max_rounds foreach body, where
 	// loops.go calls executor.executeStep with the round-overlay ctx.
 	if step.When != "" {
-		skip, err := e.evaluateConditionCtx(ctx, step.When)
+		skip, err := e.evaluateConditionCtx(withStepExprLocals(ctx, step), step.When)
 		if err != nil {
 			result.Status = StatusFailed
 			result.Error = &StepError{
@@ -969,13 +1006,21 @@ func (e *Executor) executeStep(ctx context.Context, step *Step, workflow *Workfl
 
 		// Execute the step
 		stepResult := e.executeStepOnce(ctx, step, workflow)
+		if stepResult.Status == StatusCompleted && !stepResult.Cache.IsHit() {
+			stepResult = e.collectArtifacts(step, stepResult)
+			e.storeStepCache(step, stepResult)
+		}
 
 		if stepResult.Status == StatusCompleted {
 			result = stepResult
 			result.Attempts = attempt
 			result.FinishedAt = time.Now()
+			detail := ""
+			if result.Cache.IsHit() {
+				detail = "cached from " + result.Cache.SourceRunID
+			}
 			e.emitProgress("step_complete", step.ID,
-				stepProgressMessage("Step completed", step, ""), e.calculateProgress())
+				stepProgressMessage("Step completed", step, detail), e.calculateProgress())
 			// bd-w6nth.7: run OnSuccess steps after a successful parent.
 			// Failures here are logged but do NOT flip the parent's
 			// Status (matching post_pipeline_steps semantics). Depth is
@@ -1075,6 +1120,10 @@ func (e *Executor) executeStepOnce(ctx context.Context, step *Step, workflow *Wo
 
 	// Keep composite steps inside executeStep's retry/failure tail.
 	// Branch, foreach, bead-query, and mail steps already follow this path.
+	if step.Matrix != nil {
+		return e.executeMatrix(ctx, step, workflow)
+	}
+
 	if len(step.Parallel.Steps) > 0 {
 		return e.executeParallel(ctx, step, workflow)
 	}
@@ -1107,6 +1156,16 @@ func (e *Executor) executeStepOnce(ctx context.Context, step *Step, workflow *Wo
 		return e.executeForeach(ctx, step, workflow)
 	}
 
+	// Dispatch sub-workflow calls (workflow: / call:).
+	if step.Workflow != nil {
+		return e.executeSubWorkflow(ctx, step, workflow)
+	}
+
+	// Dispatch human approval gates (approval: / gate:).
+	if step.Approval != nil {
+		return e.executeApprovalGate(ctx, step, workflow)
+	}
+
 	// Agent Mail step kinds (mail_send, file_reservation_paths,
 	// mail_inbox_check, file_reservation_release) execute via MCP Agent Mail
 	// rather than tmux pane dispatch.
@@ -1278,7 +1337,7 @@ func (e *Executor) executeStepOnce(ctx context.Context, step *Step, workflow *Wo
 
 	// Parse output if configured
 	if step.OutputVar != "" && step.OutputParse.Type != "" && step.OutputParse.Type != "none" {
-		parsed, err := e.parseOutput(result.Output, step.OutputParse)
+		parsed, err := e.parseOutput(result.Output, step.OutputParse.forAgent(agentType))
 		if err != nil {
 			// Non-fatal - just warn
 			e.stateMu.Lock()
@@ -1422,6 +1481,33 @@ func (e *Executor) executeCommand(ctx context.Context, step *Step, workflow *Wor
 		return result
 	}
 
+	if step.Cache.enabled() {
+		info, cached, err := e.lookupStepCache(step, stepCacheMaterial{
+			Kind:    "command",
+			Command: expandedCmd,
+			Stdin:   expandedStdin,
+			Args:    expandedArgs,
+		})
+		if err != nil {
+			result.Status = StatusFailed
+			result.Error = stepRuntimeError(step, "command", "cache",
+				fmt.Sprintf("failed to compute cache key: %v", err),
+				"check the cache key expression and that every cache input path is readable",
+				err.Error())
+			result.FinishedAt = time.Now()
+			return result
+		}
+		result.Cache = info
+		if cached != nil {
+			result.Output = cached.Output
+			result.ParsedData = e.parseCommandOutput(step, cached.Output)
+			result.Artifacts = cached.Artifacts
+			result.Status = StatusCompleted
+			result.FinishedAt = time.Now()
+			return result
+		}
+	}
+
 	slog.Info("command step starting",
 		"run_id", e.state.RunID,
 		"workflow", workflow.Name,
@@ -1657,22 +1743,7 @@ func (e *Executor) executeCommand(ctx context.Context, step *Step, workflow *Wor
 		return result
 	}
 
-	if step.OutputVar != "" && step.OutputParse.Type != "" && step.OutputParse.Type != "none" {
-		parsed, err := e.parseOutput(output, step.OutputParse)
-		if err != nil {
-			e.stateMu.Lock()
-			e.state.Errors = append(e.state.Errors, ExecutionError{
-				StepID:    step.ID,
-				Type:      "parse",
-				Message:   fmt.Sprintf("failed to parse output: %v", err),
-				Timestamp: time.Now(),
-				Fatal:     false,
-			})
-			e.stateMu.Unlock()
-		} else {
-			result.ParsedData = parsed
-		}
-	}
+	result.ParsedData = e.parseCommandOutput(step, output)
 
 	result.Status = StatusCompleted
 	result.FinishedAt = time.Now()
@@ -1683,6 +1754,28 @@ func (e *Executor) executeCommand(ctx context.Context, step *Step, workflow *Wor
 	return result
 }
 
+// parseCommandOutput applies a command step's output_parse when it feeds an
+// output_var. Parse failures are recorded as non-fatal execution errors.
+func (e *Executor) parseCommandOutput(step *Step, output string) interface{} {
+	if step.OutputVar == "" || step.OutputParse.Type == "" || step.OutputParse.Type == "none" {
+		return nil
+	}
+	parsed, err := e.parseOutput(output, step.OutputParse)
+	if err != nil {
+		e.stateMu.Lock()
+		e.state.Errors = append(e.state.Errors, ExecutionError{
+			StepID:    step.ID,
+			Type:      "parse",
+			Message:   fmt.Sprintf("failed to parse output: %v", err),
+			Timestamp: time.Now(),
+			Fatal:     false,
+		})
+		e.stateMu.Unlock()
+		return nil
+	}
+	return parsed
+}
+
 // executeTemplate reads a template file, substitutes <KEY> placeholders with
 // step Params/Args, validates declared placeholders, and dispatches the
 // rendered text to a pane. Wait/timeout behavior mirrors the prompt path.
@@ -1891,7 +1984,7 @@ func (e *Executor) executeTemplate(ctx context.Context, step *Step, workflow *Wo
 	result.Output = util.ExtractNewOutput(beforeOutput, afterOutput)
 
 	if step.OutputVar != "" && step.OutputParse.Type != "" && step.OutputParse.Type != "none" {
-		parsed, err := e.parseOutput(result.Output, step.OutputParse)
+		parsed, err := e.parseOutput(result.Output, step.OutputParse.forAgent(agentType))
 		if err != nil {
 			e.stateMu.Lock()
 			e.state.Errors = append(e.state.Errors, ExecutionError{
@@ -2385,7 +2478,7 @@ func (e *Executor) executeParallelStep(ctx context.Context, step *Step, workflow
 	// Evaluate condition if present. bd-s2edh: ctx-aware so foreach
 	// max_rounds overlays reach parallel sub-step When conditions.
 	if step.When != "" {
-		skip, err := e.evaluateConditionCtx(ctx, step.When)
+		skip, err := e.evaluateConditionCtx(withStepExprLocals(ctx, step), step.When)
 		if err != nil {
 			result.Status = StatusFailed
 			result.Error = &StepError{
@@ -2596,7 +2689,7 @@ func (e *Executor) executeParallelStep(ctx context.Context, step *Step, workflow
 
 		// Parse output if configured
 		if step.OutputParse.Type != "" && step.OutputParse.Type != "none" {
-			parsed, err := e.parseOutput(result.Output, step.OutputParse)
+			parsed, err := e.parseOutput(result.Output, step.OutputParse.forAgent(agentType))
 			if err != nil {
 				e.emitProgress("step_warning", step.ID,
 					fmt.Sprintf("output parse warning: %v", err),
@@ -3088,6 +3181,7 @@ func (e *Executor) substituteCommandArgsStrictCtx(ctx context.Context, args map[
 // - Contains operator (contains)
 // - Logical operators (AND, OR, NOT)
 // - Type coercion for numeric comparisons
+// ${{ ... }} conditions are evaluated as typed expressions instead.
 // Thread-safe: acquires read locks on Variables and Steps for concurrent access during parallel execution.
 // Round
 // overrides from ctx are passed to the Substitutor so a body step's
@@ -3107,10 +3201,30 @@ func (e *Executor) evaluateConditionCtx(ctx context.Context, condition string) (
 	if overrides := roundOverridesFromCtx(ctx); overrides != nil {
 		sub.SetLocalOverrides(overrides)
 	}
+	if body, ok := typedConditionBody(condition); ok {
+		value, err := evalTypedCondition(body, &exprScope{sub: sub, runtime: e.runtimeVariable})
+		return !value, err
+	}
 	condition = e.substituteRuntimeVariables(condition)
 	return EvaluateCondition(condition, sub)
 }
 
+// evaluateTypedExprCtx evaluates a ${{ ... }} expression body to its value
+// under the same locks and overrides as evaluateConditionCtx.
+func (e *Executor) evaluateTypedExprCtx(ctx context.Context, body string) (interface{}, error) {
+	e.stateMu.RLock()
+	defer e.stateMu.RUnlock()
+	e.varMu.RLock()
+	defer e.varMu.RUnlock()
+	sub := NewSubstitutor(e.state, e.config.Session, e.state.WorkflowID)
+	sub.SetDefaults(e.defaults)
+	sub.SetMaxDepth(e.limits.MaxSubstitutionDepth)
+	if overrides := roundOverridesFromCtx(ctx); overrides != nil {
+		sub.SetLocalOverrides(overrides)
+	}
+	return evalTypedExpr(body, &exprScope{sub: sub, runtime: e.runtimeVariable})
+}
+
 // parseOutput parses step output according to the parse configuration.
 // Uses the OutputParser for full parsing support including:
 // - JSON parsing with embedded JSON extraction
@@ -3615,6 +3729,7 @@ func (e *Executor) snapshotState() *ExecutionState {
 			snapshot.InFlightSteps[key] = value
 		}
 	}
+	snapshot.ApprovalGates = maps.Clone(e.state.ApprovalGates)
 	e.stateMu.RUnlock()
 
 	if e.state.Variables != nil {
@@ -3715,6 +3830,14 @@ func (e *Executor) markFireAndForgetCancelled(stepID, workflowName string) {
 	}
 }
 
+// Workflow returns the workflow the executor is running, or nil before Run
+// or Resume starts it.
+func (e *Executor) Workflow() *Workflow {
+	e.stateMu.RLock()
+	defer e.stateMu.RUnlock()
+	return e.workflow
+}
+
 // GetState returns a snapshot of the current execution state (for monitoring).
 // Returns a copy to avoid racing with concurrent writers.
 func (e *Executor) GetState() *ExecutionState {
@@ -3967,6 +4090,7 @@ func (e *Executor) applyStartFrom(workflow *Workflow) error {
 			if priorResult, ok := prior.Steps[id]; ok {
 				result.Output = priorResult.Output
 				result.ParsedData = priorResult.ParsedData
+				result.Artifacts = priorResult.Artifacts
 				result.PaneUsed = priorResult.PaneUsed
 				result.AgentType = priorResult.AgentType
 			}
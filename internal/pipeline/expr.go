@@ -0,0 +1,419 @@
+// expr.go implements the typed expression language used by when:, loop
+// while: and branch: values written as ${{ ... }}.
+//
+// Unlike legacy conditions, which are substituted as text and then compared
+// as strings, a typed expression resolves references such as
+// steps.review.json.issues[0].severity to their structured values and
+// evaluates them with real types:
+//
+//	when: ${{ len(steps.review.json.issues) > 0 && vars.env == "prod" }}
+//	while: ${{ !any(steps.poll.json.jobs, it.state == "running") }}
+//	branch: ${{ steps.triage.json.severity }}
+//
+// Literals are numbers, "strings" or 'strings', true, false, null and
+// [lists]. Operators are ! && || == != < <= > >= and in, plus parentheses,
+// .field and [index] access. Functions are listed in exprFunctions.
+package pipeline
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// typedConditionBody returns the expression inside a ${{ ... }} value.
+func typedConditionBody(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "${{") || !strings.HasSuffix(s, "}}") || len(s) < 5 {
+		return "", false
+	}
+	return strings.TrimSpace(s[3 : len(s)-2]), true
+}
+
+// IsTypedExpression reports whether a condition uses the ${{ ... }} typed
+// expression syntax rather than legacy text substitution.
+func IsTypedExpression(s string) bool {
+	_, ok := typedConditionBody(s)
+	return ok
+}
+
+type exprTokenKind int
+
+const (
+	exprTokEOF exprTokenKind = iota
+	exprTokIdent
+	exprTokNumber
+	exprTokString
+	exprTokOp
+)
+
+type exprToken struct {
+	kind  exprTokenKind
+	text  string
+	value interface{}
+	pos   int
+}
+
+// exprOperators is ordered longest first so the lexer matches greedily.
+var exprOperators = []string{"&&", "||", "==", "!=", "<=", ">=", "(", ")", "[", "]", ".", ",", "!", "<", ">"}
+
+func lexExpr(src string) ([]exprToken, error) {
+	var toks []exprToken
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"' || c == '\'':
+			end := i + 1
+			for end < len(src) && src[end] != c {
+				if src[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(src) {
+				return nil, fmt.Errorf("col %d: unterminated string", i+1)
+			}
+			raw := src[i : end+1]
+			if c == '\'' {
+				raw = `"` + strings.ReplaceAll(strings.ReplaceAll(raw[1:len(raw)-1], `\'`, `'`), `"`, `\"`) + `"`
+			}
+			s, err := strconv.Unquote(raw)
+			if err != nil {
+				return nil, fmt.Errorf("col %d: invalid string %s", i+1, src[i:end+1])
+			}
+			toks = append(toks, exprToken{kind: exprTokString, text: src[i : end+1], value: s, pos: i})
+			i = end + 1
+		case isExprDigit(c) || (c == '-' && i+1 < len(src) && isExprDigit(src[i+1])):
+			end := i + 1
+			// After a member dot (items.1.name) only the index digits
+			// belong to the number.
+			member := len(toks) > 0 && toks[len(toks)-1].kind == exprTokOp && toks[len(toks)-1].text == "."
+			for end < len(src) && (isExprDigit(src[end]) || (!member && (src[end] == '.' || src[end] == 'e' || src[end] == 'E'))) {
+				end++
+			}
+			n, err := strconv.ParseFloat(src[i:end], 64)
+			if err != nil {
+				return nil, fmt.Errorf("col %d: invalid number %q", i+1, src[i:end])
+			}
+			toks = append(toks, exprToken{kind: exprTokNumber, text: src[i:end], value: n, pos: i})
+			i = end
+		case isExprIdentStart(c):
+			end := i + 1
+			// Step and variable IDs may contain dashes; there is no
+			// subtraction operator to confuse them with.
+			for end < len(src) && (isExprIdentStart(src[end]) || isExprDigit(src[end]) || src[end] == '-') {
+				end++
+			}
+			toks = append(toks, exprToken{kind: exprTokIdent, text: src[i:end], pos: i})
+			i = end
+		default:
+			matched := false
+			for _, op := range exprOperators {
+				if strings.HasPrefix(src[i:], op) {
+					toks = append(toks, exprToken{kind: exprTokOp, text: op, pos: i})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("col %d: unexpected character %q", i+1, c)
+			}
+		}
+	}
+	return append(toks, exprToken{kind: exprTokEOF, pos: len(src)}), nil
+}
+
+func isExprDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func isExprIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+type exprNodeKind int
+
+const (
+	exprLiteral exprNodeKind = iota
+	exprIdent
+	exprMember
+	exprIndex
+	exprCall
+	exprUnary
+	exprBinary
+	exprList
+)
+
+// exprNode is one node of a parsed expression. name holds the identifier,
+// member, function or operator; args holds operands in source order.
+type exprNode struct {
+	kind  exprNodeKind
+	pos   int
+	name  string
+	value interface{}
+	args  []*exprNode
+}
+
+type exprParser struct {
+	toks []exprToken
+	i    int
+}
+
+// parseExpr parses a typed expression body (without the ${{ }} wrapper).
+func parseExpr(src string) (*exprNode, error) {
+	toks, err := lexExpr(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{toks: toks}
+	if p.peek().kind == exprTokEOF {
+		return nil, fmt.Errorf("empty expression")
+	}
+	n, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != exprTokEOF {
+		return nil, fmt.Errorf("col %d: unexpected %q", t.pos+1, t.text)
+	}
+	return n, nil
+}
+
+func (p *exprParser) peek() exprToken { return p.toks[p.i] }
+
+func (p *exprParser) next() exprToken {
+	t := p.toks[p.i]
+	if t.kind != exprTokEOF {
+		p.i++
+	}
+	return t
+}
+
+func (p *exprParser) acceptOp(op string) bool {
+	if t := p.peek(); t.kind == exprTokOp && t.text == op {
+		p.i++
+		return true
+	}
+	return false
+}
+
+func (p *exprParser) expectOp(op string) error {
+	if p.acceptOp(op) {
+		return nil
+	}
+	t := p.peek()
+	if t.kind == exprTokEOF {
+		return fmt.Errorf("col %d: expected %q before end of expression", t.pos+1, op)
+	}
+	return fmt.Errorf("col %d: expected %q, found %q", t.pos+1, op, t.text)
+}
+
+func (p *exprParser) parseOr() (*exprNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == exprTokOp && p.peek().text == "||" {
+		pos := p.next().pos
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &exprNode{kind: exprBinary, pos: pos, name: "||", args: []*exprNode{left, right}}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAnd() (*exprNode, error) {
+	left, err := p.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == exprTokOp && p.peek().text == "&&" {
+		pos := p.next().pos
+		right, err := p.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+		left = &exprNode{kind: exprBinary, pos: pos, name: "&&", args: []*exprNode{left, right}}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseComparison() (*exprNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	t := p.peek()
+	op := ""
+	switch {
+	case t.kind == exprTokOp && (t.text == "==" || t.text == "!=" || t.text == "<" || t.text == "<=" || t.text == ">" || t.text == ">="):
+		op = t.text
+	case t.kind == exprTokIdent && t.text == "in":
+		op = "in"
+	default:
+		return left, nil
+	}
+	p.next()
+	right, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	if nt := p.peek(); (nt.kind == exprTokOp && strings.ContainsAny(nt.text, "=<>") && nt.text != "") || (nt.kind == exprTokIdent && nt.text == "in") {
+		return nil, fmt.Errorf("col %d: comparisons cannot be chained; use && between them", nt.pos+1)
+	}
+	return &exprNode{kind: exprBinary, pos: t.pos, name: op, args: []*exprNode{left, right}}, nil
+}
+
+func (p *exprParser) parseUnary() (*exprNode, error) {
+	if t := p.peek(); t.kind == exprTokOp && t.text == "!" {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &exprNode{kind: exprUnary, pos: t.pos, name: "!", args: []*exprNode{operand}}, nil
+	}
+	return p.parsePostfix()
+}
+
+func (p *exprParser) parsePostfix() (*exprNode, error) {
+	n, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		switch {
+		case t.kind == exprTokOp && t.text == ".":
+			p.next()
+			field := p.next()
+			switch field.kind {
+			case exprTokIdent:
+			case exprTokNumber:
+				// a.0 is accepted as a.[0] for parity with ${vars.x.0}.
+				if strings.ContainsAny(field.text, ".eE-") {
+					return nil, fmt.Errorf("col %d: invalid field %q", field.pos+1, field.text)
+				}
+			default:
+				return nil, fmt.Errorf("col %d: expected field name after '.'", field.pos+1)
+			}
+			n = &exprNode{kind: exprMember, pos: field.pos, name: field.text, args: []*exprNode{n}}
+		case t.kind == exprTokOp && t.text == "[":
+			p.next()
+			index, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectOp("]"); err != nil {
+				return nil, err
+			}
+			n = &exprNode{kind: exprIndex, pos: t.pos, args: []*exprNode{n, index}}
+		default:
+			return n, nil
+		}
+	}
+}
+
+func (p *exprParser) parsePrimary() (*exprNode, error) {
+	t := p.next()
+	switch t.kind {
+	case exprTokNumber, exprTokString:
+		return &exprNode{kind: exprLiteral, pos: t.pos, value: t.value}, nil
+	case exprTokIdent:
+		switch t.text {
+		case "true":
+			return &exprNode{kind: exprLiteral, pos: t.pos, value: true}, nil
+		case "false":
+			return &exprNode{kind: exprLiteral, pos: t.pos, value: false}, nil
+		case "null":
+			return &exprNode{kind: exprLiteral, pos: t.pos, value: nil}, nil
+		case "in":
+			return nil, fmt.Errorf("col %d: unexpected 'in'", t.pos+1)
+		}
+		if p.acceptOp("(") {
+			args, err := p.parseArgs(")")
+			if err != nil {
+				return nil, err
+			}
+			return &exprNode{kind: exprCall, pos: t.pos, name: t.text, args: args}, nil
+		}
+		return &exprNode{kind: exprIdent, pos: t.pos, name: t.text}, nil
+	case exprTokOp:
+		switch t.text {
+		case "(":
+			n, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectOp(")"); err != nil {
+				return nil, err
+			}
+			return n, nil
+		case "[":
+			elems, err := p.parseArgs("]")
+			if err != nil {
+				return nil, err
+			}
+			return &exprNode{kind: exprList, pos: t.pos, args: elems}, nil
+		}
+		return nil, fmt.Errorf("col %d: unexpected %q", t.pos+1, t.text)
+	default:
+		return nil, fmt.Errorf("col %d: unexpected end of expression", t.pos+1)
+	}
+}
+
+func (p *exprParser) parseArgs(closer string) ([]*exprNode, error) {
+	var args []*exprNode
+	if p.acceptOp(closer) {
+		return args, nil
+	}
+	for {
+		arg, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		if p.acceptOp(closer) {
+			return args, nil
+		}
+		if err := p.expectOp(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// staticPath flattens an identifier followed by .field and constant [index]
+// accesses into a reference path, e.g. steps.a.json.items[0] into
+// [steps a json items 0]. ok is false when any index is computed.
+func (n *exprNode) staticPath() ([]string, bool) {
+	switch n.kind {
+	case exprIdent:
+		return []string{n.name}, true
+	case exprMember:
+		base, ok := n.args[0].staticPath()
+		if !ok {
+			return nil, false
+		}
+		return append(base, n.name), true
+	case exprIndex:
+		base, ok := n.args[0].staticPath()
+		if !ok || n.args[1].kind != exprLiteral {
+			return nil, false
+		}
+		switch v := n.args[1].value.(type) {
+		case string:
+			return append(base, v), true
+		case float64:
+			if v == float64(int(v)) {
+				return append(base, strconv.Itoa(int(v))), true
+			}
+		}
+		return nil, false
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,457 @@
+package pipeline
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// exprType is the static type of a typed-expression node. exprTypeAny
+// covers values only known at run time, such as parsed step output.
+type exprType int
+
+const (
+	exprTypeAny exprType = iota
+	exprTypeNull
+	exprTypeBool
+	exprTypeNumber
+	exprTypeString
+	exprTypeList
+	exprTypeMap
+)
+
+func (t exprType) String() string {
+	switch t {
+	case exprTypeNull:
+		return "null"
+	case exprTypeBool:
+		return "boolean"
+	case exprTypeNumber:
+		return "number"
+	case exprTypeString:
+		return "string"
+	case exprTypeList:
+		return "list"
+	case exprTypeMap:
+		return "map"
+	default:
+		return "any"
+	}
+}
+
+// concrete reports whether the type is statically known and not null.
+func (t exprType) concrete() bool {
+	return t != exprTypeAny && t != exprTypeNull
+}
+
+// exprCheckEnv is the static context typed expressions are checked in.
+type exprCheckEnv struct {
+	vars  map[string]exprType // declared workflow variables
+	steps map[string]bool     // every step ID in the workflow
+	roots map[string]bool     // foreach `as` bindings and matrix
+}
+
+// exprIssue is a problem found while checking an expression.
+type exprIssue struct {
+	pos     int
+	message string
+	hint    string
+	warning bool
+}
+
+func (i exprIssue) String() string {
+	return fmt.Sprintf("col %d: %s", i.pos+1, i.message)
+}
+
+type exprChecker struct {
+	env    *exprCheckEnv
+	locals map[string]bool
+	issues []exprIssue
+}
+
+// checkTypedExpr parses and type-checks a typed expression body.
+func checkTypedExpr(src string, env *exprCheckEnv) (exprType, []exprIssue) {
+	n, err := parseExpr(src)
+	if err != nil {
+		return exprTypeAny, []exprIssue{{message: "syntax error: " + err.Error(), hint: "See Typed Expressions in docs/WORKFLOW_SCHEMA.md"}}
+	}
+	c := &exprChecker{env: env, locals: map[string]bool{}}
+	return c.typeOf(n), c.issues
+}
+
+func (c *exprChecker) errorf(n *exprNode, hint, format string, args ...interface{}) {
+	c.issues = append(c.issues, exprIssue{pos: n.pos, message: fmt.Sprintf(format, args...), hint: hint})
+}
+
+func (c *exprChecker) typeOf(n *exprNode) exprType {
+	switch n.kind {
+	case exprLiteral:
+		switch n.value.(type) {
+		case nil:
+			return exprTypeNull
+		case bool:
+			return exprTypeBool
+		case float64:
+			return exprTypeNumber
+		default:
+			return exprTypeString
+		}
+	case exprList:
+		for _, a := range n.args {
+			c.typeOf(a)
+		}
+		return exprTypeList
+	case exprIdent, exprMember, exprIndex:
+		if path, ok := n.staticPath(); ok {
+			return c.refType(n, path)
+		}
+		base := c.typeOf(n.args[0])
+		if n.kind == exprIndex {
+			index := c.typeOf(n.args[1])
+			switch {
+			case base == exprTypeList && index.concrete() && index != exprTypeNumber:
+				c.errorf(n, "", "list index must be a number, got %s", index)
+			case base == exprTypeMap && index.concrete() && index != exprTypeString:
+				c.errorf(n, "", "map key must be a string, got %s", index)
+			}
+		}
+		if base.concrete() && base != exprTypeList && base != exprTypeMap {
+			c.errorf(n, "", "cannot index into %s", base)
+		}
+		return exprTypeAny
+	case exprUnary:
+		c.wantBool(n.args[0], "!")
+		return exprTypeBool
+	case exprBinary:
+		return c.binaryType(n)
+	case exprCall:
+		return c.callType(n)
+	}
+	return exprTypeAny
+}
+
+func (c *exprChecker) wantBool(n *exprNode, op string) {
+	if t := c.typeOf(n); t.concrete() && t != exprTypeBool {
+		c.errorf(n, "Compare the value explicitly, e.g. len(x) > 0 or x != \"\"", "%s expects a boolean, got %s", op, t)
+	}
+}
+
+func (c *exprChecker) binaryType(n *exprNode) exprType {
+	if n.name == "&&" || n.name == "||" {
+		c.wantBool(n.args[0], n.name)
+		c.wantBool(n.args[1], n.name)
+		return exprTypeBool
+	}
+	left, right := c.typeOf(n.args[0]), c.typeOf(n.args[1])
+	switch n.name {
+	case "==", "!=":
+		if left.concrete() && right.concrete() && left != right {
+			c.errorf(n, "Convert one side with number(...) or string(...)", "comparing %s with %s is always %v", left, right, n.name == "!=")
+		}
+	case "in":
+		switch {
+		case right.concrete() && right != exprTypeList && right != exprTypeMap && right != exprTypeString:
+			c.errorf(n, "", "in needs a list, map or string on the right, got %s", right)
+		case (right == exprTypeString || right == exprTypeMap) && left.concrete() && left != exprTypeString:
+			c.errorf(n, "", "in %s needs a string on the left, got %s", right, left)
+		}
+	default:
+		for _, t := range []exprType{left, right} {
+			if t.concrete() && t != exprTypeNumber && t != exprTypeString {
+				c.errorf(n, "", "%s cannot order %s values", n.name, t)
+				return exprTypeBool
+			}
+		}
+		if left.concrete() && right.concrete() && left != right {
+			c.errorf(n, "Convert one side with number(...) or string(...)", "cannot compare %s with %s using %s", left, right, n.name)
+		}
+	}
+	return exprTypeBool
+}
+
+func (c *exprChecker) callType(n *exprNode) exprType {
+	arity, ok := exprFunctions[n.name]
+	if !ok {
+		c.errorf(n, "Available functions: "+exprFunctionNames(), "unknown function %q", n.name)
+		return exprTypeAny
+	}
+	if len(n.args) < arity[0] || len(n.args) > arity[1] {
+		c.errorf(n, "", "%s() takes %s, got %d", n.name, exprArityText(arity), len(n.args))
+		return exprTypeAny
+	}
+
+	switch n.name {
+	case "any", "all", "filter":
+		c.wantArg(n, 0, exprTypeList)
+		if len(n.args) == 2 {
+			saved := c.locals["it"]
+			c.locals["it"] = true
+			c.wantBool(n.args[1], n.name+"() predicate")
+			c.locals["it"] = saved
+		}
+		if n.name == "filter" {
+			return exprTypeList
+		}
+		return exprTypeBool
+	}
+
+	types := make([]exprType, len(n.args))
+	for i, a := range n.args {
+		types[i] = c.typeOf(a)
+	}
+	want := func(i int, allowed ...exprType) {
+		if !types[i].concrete() {
+			return
+		}
+		for _, t := range allowed {
+			if types[i] == t {
+				return
+			}
+		}
+		names := make([]string, len(allowed))
+		for j, t := range allowed {
+			names[j] = t.String()
+		}
+		c.errorf(n.args[i], "", "%s() argument %d must be %s, got %s", n.name, i+1, strings.Join(names, " or "), types[i])
+	}
+
+	switch n.name {
+	case "len":
+		want(0, exprTypeString, exprTypeList, exprTypeMap)
+		return exprTypeNumber
+	case "matches":
+		want(0, exprTypeString)
+		want(1, exprTypeString)
+		if lit := n.args[1]; lit.kind == exprLiteral {
+			if pattern, ok := lit.value.(string); ok {
+				if _, err := regexp.Compile(pattern); err != nil {
+					c.errorf(lit, "", "invalid regular expression: %v", err)
+				}
+			}
+		}
+		return exprTypeBool
+	case "startsWith", "endsWith":
+		want(0, exprTypeString)
+		want(1, exprTypeString)
+		return exprTypeBool
+	case "contains":
+		want(0, exprTypeString, exprTypeList)
+		if types[0] == exprTypeString {
+			want(1, exprTypeString)
+		}
+		return exprTypeBool
+	case "lower", "upper", "trim":
+		want(0, exprTypeString)
+		return exprTypeString
+	case "number":
+		want(0, exprTypeString, exprTypeNumber)
+		return exprTypeNumber
+	case "string":
+		return exprTypeString
+	}
+	return exprTypeAny
+}
+
+func (c *exprChecker) wantArg(n *exprNode, i int, want exprType) {
+	if t := c.typeOf(n.args[i]); t.concrete() && t != want {
+		c.errorf(n.args[i], "", "%s() argument %d must be %s, got %s", n.name, i+1, want, t)
+	}
+}
+
+// refType types a reference path such as steps.build.output.
+func (c *exprChecker) refType(n *exprNode, path []string) exprType {
+	root, rest := path[0], path[1:]
+	scalar := func(t exprType, fields int) exprType {
+		if len(rest) > fields {
+			c.errorf(n, "", "cannot access %s on %s", rest[fields], t)
+		}
+		return t
+	}
+
+	if c.locals[root] || c.env.roots[root] {
+		return exprTypeAny
+	}
+	switch root {
+	case "steps":
+		if len(rest) < 2 {
+			c.errorf(n, "Use steps.<id>.output, steps.<id>.json or steps.<id>.status", "incomplete step reference %s", strings.Join(path, "."))
+			return exprTypeAny
+		}
+		if c.env.steps != nil && !c.env.steps[rest[0]] {
+			c.issues = append(c.issues, exprIssue{pos: n.pos, warning: true,
+				message: fmt.Sprintf("reference to unknown step %q", rest[0]),
+				hint:    "Steps produced by foreach or matrix expansion are only known at run time"})
+		}
+		switch rest[1] {
+		case "output":
+			if len(rest) > 2 {
+				return exprTypeAny // steps.X.output.field reads parsed data
+			}
+			return exprTypeString
+		case "status", "agent", "pane", "duration":
+			rest = rest[1:]
+			return scalar(exprTypeString, 1)
+		case "json", "data", "parsed_data":
+			return exprTypeAny
+		}
+		c.errorf(n, "Step fields: output, json, data, status, agent, pane, duration", "unknown step field %q", rest[1])
+		return exprTypeAny
+	case "vars":
+		if len(rest) == 0 {
+			c.errorf(n, "Use vars.<name>", "incomplete variable reference")
+			return exprTypeAny
+		}
+		t, ok := c.env.vars[rest[0]]
+		if !ok {
+			return exprTypeAny
+		}
+		rest = rest[1:]
+		if t == exprTypeList && len(rest) > 0 {
+			return exprTypeAny
+		}
+		return scalar(t, 0)
+	case "env":
+		if len(rest) == 0 {
+			c.errorf(n, "Use env.<NAME>", "incomplete env reference")
+			return exprTypeAny
+		}
+		rest = rest[1:]
+		return scalar(exprTypeString, 0)
+	case "loop":
+		if len(rest) == 0 {
+			c.errorf(n, "Use loop.item, loop.index, loop.count, loop.first or loop.last", "incomplete loop reference")
+			return exprTypeAny
+		}
+		field := rest[0]
+		rest = rest[1:]
+		switch field {
+		case "index", "count", "round", "rounds_remaining":
+			return scalar(exprTypeNumber, 0)
+		case "first", "last":
+			return scalar(exprTypeBool, 0)
+		}
+		return exprTypeAny
+	case "session", "run_id", "timestamp", "workflow":
+		return scalar(exprTypeString, 0)
+	case "round", "rounds_remaining":
+		return scalar(exprTypeNumber, 0)
+	}
+	if exprKnownRoots[root] {
+		return exprTypeAny
+	}
+	c.errorf(n, "References start with steps, vars, env, loop, item, a foreach as: name, or matrix", "unknown name %q", root)
+	return exprTypeAny
+}
+
+func exprFunctionNames() string {
+	names := make([]string, 0, len(exprFunctions))
+	for name := range exprFunctions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// validateTypedExpressions type-checks every ${{ ... }} when, branch and
+// loop.while expression so mistakes surface at lint time instead of
+// mid-run.
+func validateTypedExpressions(w *Workflow, result *ValidationResult) {
+	env := &exprCheckEnv{
+		vars:  make(map[string]exprType),
+		steps: make(map[string]bool),
+		roots: map[string]bool{"item": true},
+	}
+	for name, def := range w.Vars {
+		switch def.Type {
+		case VarTypeString:
+			env.vars[name] = exprTypeString
+		case VarTypeNumber:
+			env.vars[name] = exprTypeNumber
+		case VarTypeBoolean:
+			env.vars[name] = exprTypeBool
+		case VarTypeArray:
+			env.vars[name] = exprTypeList
+		}
+	}
+
+	var collect func(steps []Step)
+	collect = func(steps []Step) {
+		for _, step := range steps {
+			env.steps[step.ID] = true
+			if step.Matrix != nil {
+				env.roots["matrix"] = true
+			}
+			if step.Loop != nil {
+				if step.Loop.As != "" {
+					env.roots[step.Loop.As] = true
+				}
+				collect(step.Loop.Steps)
+			}
+			for _, fc := range []*ForeachConfig{step.Foreach, step.ForeachPane} {
+				if fc == nil {
+					continue
+				}
+				if fc.As != "" {
+					env.roots[fc.As] = true
+				}
+				collect(fc.Steps)
+			}
+			collect(step.Parallel.Steps)
+		}
+	}
+	collect(w.Steps)
+	collect(w.Settings.OnCancel)
+
+	check := func(expr, field string, branch bool) {
+		body, ok := typedConditionBody(expr)
+		if !ok {
+			return
+		}
+		t, issues := checkTypedExpr(body, env)
+		for _, issue := range issues {
+			pe := ParseError{Field: field, Message: issue.String(), Hint: issue.hint}
+			if issue.warning {
+				result.addWarning(pe)
+			} else {
+				result.addError(pe)
+			}
+		}
+		switch {
+		case branch && (t == exprTypeList || t == exprTypeMap):
+			result.addError(ParseError{
+				Field:   field,
+				Message: fmt.Sprintf("branch expression produces a %s", t),
+				Hint:    "A branch must select a key of branches: with a string, number or boolean",
+			})
+		case !branch && t.concrete() && t != exprTypeBool:
+			result.addError(ParseError{
+				Field:   field,
+				Message: fmt.Sprintf("condition produces a %s, not a boolean", t),
+				Hint:    "Compare the value explicitly, e.g. len(x) > 0 or x != \"\"",
+			})
+		}
+	}
+
+	var walk func(steps []Step, prefix string)
+	walk = func(steps []Step, prefix string) {
+		for i, step := range steps {
+			stepField := fmt.Sprintf("%s[%d]", prefix, i)
+			check(step.When, stepField+".when", false)
+			check(step.Branch, stepField+".branch", true)
+			if step.Loop != nil {
+				check(step.Loop.While, stepField+".loop.while", false)
+				walk(step.Loop.Steps, stepField+".loop.steps")
+			}
+			if step.Foreach != nil {
+				walk(step.Foreach.Steps, stepField+".foreach.steps")
+			}
+			if step.ForeachPane != nil {
+				walk(step.ForeachPane.Steps, stepField+".foreach_pane.steps")
+			}
+			walk(step.Parallel.Steps, stepField+".parallel")
+		}
+	}
+	walk(w.Steps, "steps")
+	walk(w.Settings.OnCancel, "settings.on_cancel")
+}
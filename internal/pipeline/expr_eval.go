@@ -0,0 +1,658 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// exprFunctions lists the built-in functions of typed expressions with
+// their minimum and maximum argument counts.
+var exprFunctions = map[string][2]int{
+	"len":        {1, 1}, // length of a string, list or map
+	"matches":    {2, 2}, // regular expression match
+	"startsWith": {2, 2},
+	"endsWith":   {2, 2},
+	"contains":   {2, 2}, // substring or list membership
+	"lower":      {1, 1},
+	"upper":      {1, 1},
+	"trim":       {1, 1},
+	"number":     {1, 1}, // parse a numeric string
+	"string":     {1, 1}, // format any value as a string
+	"any":        {1, 2}, // any(list) or any(list, predicate using it)
+	"all":        {1, 2},
+	"filter":     {2, 2}, // filter(list, predicate using it)
+}
+
+// exprKnownRoots are the reference namespaces a typed expression can start
+// with, in addition to foreach bindings and the `it` predicate variable.
+var exprKnownRoots = map[string]bool{
+	"steps": true, "vars": true, "env": true, "loop": true, "pane": true,
+	"defaults": true, "artifacts": true, "runtime": true, "item": true,
+	"session": true, "run_id": true, "timestamp": true, "workflow": true,
+	"round": true, "rounds_remaining": true,
+}
+
+// exprScope resolves references for one evaluation. Missing values in a
+// known namespace evaluate to null rather than failing, so conditions can
+// test optional fields with `!= null`.
+type exprScope struct {
+	sub     *Substitutor
+	runtime func(key string) (interface{}, bool)
+	locals  map[string]interface{}
+}
+
+// evalTypedExpr parses and evaluates a typed expression body.
+func evalTypedExpr(src string, scope *exprScope) (interface{}, error) {
+	n, err := parseExpr(src)
+	if err != nil {
+		return nil, fmt.Errorf("parse expression: %w", err)
+	}
+	return scope.eval(n)
+}
+
+// evalTypedCondition evaluates a typed expression that must produce a
+// boolean; null counts as false.
+func evalTypedCondition(src string, scope *exprScope) (bool, error) {
+	v, err := evalTypedExpr(src, scope)
+	if err != nil {
+		return false, err
+	}
+	switch b := v.(type) {
+	case bool:
+		return b, nil
+	case nil:
+		return false, nil
+	default:
+		return false, fmt.Errorf("condition must evaluate to a boolean, got %s", exprTypeName(v))
+	}
+}
+
+func (s *exprScope) with(name string, value interface{}) *exprScope {
+	locals := make(map[string]interface{}, len(s.locals)+1)
+	for k, v := range s.locals {
+		locals[k] = v
+	}
+	locals[name] = value
+	return &exprScope{sub: s.sub, runtime: s.runtime, locals: locals}
+}
+
+func (s *exprScope) resolve(path []string) (interface{}, error) {
+	root := path[0]
+	if v, ok := s.locals[root]; ok {
+		return exprNavigate(exprNormalize(v), path[1:])
+	}
+
+	prefix := 1
+	switch root {
+	case "steps":
+		prefix = 3
+	case "vars", "env", "loop", "pane", "defaults", "runtime":
+		prefix = 2
+	case "artifacts":
+		prefix = max(len(path), 3)
+	}
+	if len(path) < prefix {
+		return nil, fmt.Errorf("%s is incomplete", strings.Join(path, "."))
+	}
+
+	if root == "runtime" {
+		if s.runtime == nil {
+			return nil, nil
+		}
+		v, ok := s.runtime(path[1])
+		if !ok {
+			return nil, nil
+		}
+		return exprNavigate(exprNormalize(v), path[2:])
+	}
+
+	if s.sub == nil {
+		return nil, fmt.Errorf("no variables context for %s", root)
+	}
+	v, err := s.sub.resolveVar(strings.Join(path[:prefix], "."))
+	if err != nil {
+		if exprKnownRoots[root] {
+			return nil, nil
+		}
+		// foreach `as` bindings and matrix cells live in state.Variables.
+		if s.sub.state != nil {
+			if bound, ok := s.sub.state.Variables[root]; ok {
+				return exprNavigate(exprNormalize(bound), path[1:])
+			}
+		}
+		return nil, fmt.Errorf("unknown name %q", root)
+	}
+	return exprNavigate(exprNormalize(v), path[prefix:])
+}
+
+func (s *exprScope) eval(n *exprNode) (interface{}, error) {
+	switch n.kind {
+	case exprLiteral:
+		return n.value, nil
+	case exprList:
+		out := make([]interface{}, 0, len(n.args))
+		for _, a := range n.args {
+			v, err := s.eval(a)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, v)
+		}
+		return out, nil
+	case exprIdent, exprMember, exprIndex:
+		if path, ok := n.staticPath(); ok {
+			return s.resolve(path)
+		}
+		base, err := s.eval(n.args[0])
+		if err != nil {
+			return nil, err
+		}
+		if n.kind == exprMember {
+			return exprIndexValue(base, n.name)
+		}
+		index, err := s.eval(n.args[1])
+		if err != nil {
+			return nil, err
+		}
+		return exprIndexValue(base, index)
+	case exprUnary:
+		v, err := s.eval(n.args[0])
+		if err != nil {
+			return nil, err
+		}
+		b, err := exprBool(v, "!")
+		return !b, err
+	case exprBinary:
+		return s.evalBinary(n)
+	case exprCall:
+		return s.evalCall(n)
+	}
+	return nil, fmt.Errorf("col %d: unsupported expression", n.pos+1)
+}
+
+func (s *exprScope) evalBinary(n *exprNode) (interface{}, error) {
+	left, err := s.eval(n.args[0])
+	if err != nil {
+		return nil, err
+	}
+	if n.name == "&&" || n.name == "||" {
+		lb, err := exprBool(left, n.name)
+		if err != nil {
+			return nil, err
+		}
+		if (n.name == "&&" && !lb) || (n.name == "||" && lb) {
+			return lb, nil
+		}
+		right, err := s.eval(n.args[1])
+		if err != nil {
+			return nil, err
+		}
+		return exprBool(right, n.name)
+	}
+
+	right, err := s.eval(n.args[1])
+	if err != nil {
+		return nil, err
+	}
+	switch n.name {
+	case "==":
+		return reflect.DeepEqual(left, right), nil
+	case "!=":
+		return !reflect.DeepEqual(left, right), nil
+	case "in":
+		return exprContains(right, left, "in")
+	}
+
+	switch l := left.(type) {
+	case float64:
+		if r, ok := right.(float64); ok {
+			return exprOrdered(n.name, l < r, l == r), nil
+		}
+	case string:
+		if r, ok := right.(string); ok {
+			return exprOrdered(n.name, l < r, l == r), nil
+		}
+	}
+	return nil, fmt.Errorf("col %d: cannot compare %s with %s using %s", n.pos+1, exprTypeName(left), exprTypeName(right), n.name)
+}
+
+func exprOrdered(op string, less, equal bool) bool {
+	switch op {
+	case "<":
+		return less
+	case "<=":
+		return less || equal
+	case ">":
+		return !less && !equal
+	default: // >=
+		return !less
+	}
+}
+
+func (s *exprScope) evalCall(n *exprNode) (interface{}, error) {
+	arity, ok := exprFunctions[n.name]
+	if !ok {
+		return nil, fmt.Errorf("col %d: unknown function %q", n.pos+1, n.name)
+	}
+	if len(n.args) < arity[0] || len(n.args) > arity[1] {
+		return nil, fmt.Errorf("col %d: %s() takes %s", n.pos+1, n.name, exprArityText(arity))
+	}
+
+	switch n.name {
+	case "any", "all", "filter":
+		return s.evalPredicate(n)
+	}
+
+	args := make([]interface{}, len(n.args))
+	for i, a := range n.args {
+		v, err := s.eval(a)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	str := func(i int) (string, error) {
+		if v, ok := args[i].(string); ok {
+			return v, nil
+		}
+		return "", fmt.Errorf("col %d: %s() argument %d must be a string, got %s", n.pos+1, n.name, i+1, exprTypeName(args[i]))
+	}
+
+	switch n.name {
+	case "len":
+		switch v := args[0].(type) {
+		case nil:
+			return float64(0), nil
+		case string:
+			return float64(utf8.RuneCountInString(v)), nil
+		case []interface{}:
+			return float64(len(v)), nil
+		case map[string]interface{}:
+			return float64(len(v)), nil
+		}
+		return nil, fmt.Errorf("col %d: len() of %s", n.pos+1, exprTypeName(args[0]))
+	case "matches":
+		subject, err := str(0)
+		if err != nil {
+			return nil, err
+		}
+		pattern, err := str(1)
+		if err != nil {
+			return nil, err
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("col %d: matches(): %w", n.pos+1, err)
+		}
+		return re.MatchString(subject), nil
+	case "startsWith", "endsWith":
+		subject, err := str(0)
+		if err != nil {
+			return nil, err
+		}
+		affix, err := str(1)
+		if err != nil {
+			return nil, err
+		}
+		if n.name == "startsWith" {
+			return strings.HasPrefix(subject, affix), nil
+		}
+		return strings.HasSuffix(subject, affix), nil
+	case "contains":
+		return exprContains(args[0], args[1], "contains()")
+	case "lower", "upper", "trim":
+		v, err := str(0)
+		if err != nil {
+			return nil, err
+		}
+		switch n.name {
+		case "lower":
+			return strings.ToLower(v), nil
+		case "upper":
+			return strings.ToUpper(v), nil
+		}
+		return strings.TrimSpace(v), nil
+	case "number":
+		switch v := args[0].(type) {
+		case float64:
+			return v, nil
+		case string:
+			f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return nil, fmt.Errorf("col %d: number(): %q is not a number", n.pos+1, v)
+			}
+			return f, nil
+		}
+		return nil, fmt.Errorf("col %d: number() of %s", n.pos+1, exprTypeName(args[0]))
+	case "string":
+		return formatValue(args[0]), nil
+	}
+	return nil, fmt.Errorf("col %d: unknown function %q", n.pos+1, n.name)
+}
+
+// evalPredicate implements any, all and filter. The optional second
+// argument is evaluated once per element with the element bound to `it`.
+func (s *exprScope) evalPredicate(n *exprNode) (interface{}, error) {
+	v, err := s.eval(n.args[0])
+	if err != nil {
+		return nil, err
+	}
+	var items []interface{}
+	switch list := v.(type) {
+	case nil:
+	case []interface{}:
+		items = list
+	default:
+		return nil, fmt.Errorf("col %d: %s() needs a list, got %s", n.pos+1, n.name, exprTypeName(v))
+	}
+
+	var kept []interface{}
+	for _, item := range items {
+		cond := item
+		if len(n.args) == 2 {
+			if cond, err = s.with("it", item).eval(n.args[1]); err != nil {
+				return nil, err
+			}
+		}
+		ok, err := exprBool(cond, n.name+"()")
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case n.name == "any" && ok:
+			return true, nil
+		case n.name == "all" && !ok:
+			return false, nil
+		case n.name == "filter" && ok:
+			kept = append(kept, item)
+		}
+	}
+	switch n.name {
+	case "any":
+		return false, nil
+	case "all":
+		return true, nil
+	}
+	if kept == nil {
+		kept = []interface{}{}
+	}
+	return kept, nil
+}
+
+func exprBool(v interface{}, op string) (bool, error) {
+	switch b := v.(type) {
+	case bool:
+		return b, nil
+	case nil:
+		return false, nil
+	}
+	return false, fmt.Errorf("%s expects a boolean, got %s", op, exprTypeName(v))
+}
+
+func exprContains(container, needle interface{}, op string) (bool, error) {
+	switch c := container.(type) {
+	case nil:
+		return false, nil
+	case []interface{}:
+		for _, el := range c {
+			if reflect.DeepEqual(el, needle) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case map[string]interface{}:
+		key, ok := needle.(string)
+		if !ok {
+			return false, fmt.Errorf("%s: map keys are strings, got %s", op, exprTypeName(needle))
+		}
+		_, found := c[key]
+		return found, nil
+	case string:
+		sub, ok := needle.(string)
+		if !ok {
+			return false, fmt.Errorf("%s: cannot look for %s in a string", op, exprTypeName(needle))
+		}
+		return strings.Contains(c, sub), nil
+	}
+	return false, fmt.Errorf("%s: cannot search in %s", op, exprTypeName(container))
+}
+
+// exprIndexValue applies one .field or [index] step. Missing keys and
+// out-of-range indexes yield null; indexing a scalar is an error.
+func exprIndexValue(base, key interface{}) (interface{}, error) {
+	switch b := base.(type) {
+	case nil:
+		return nil, nil
+	case map[string]interface{}:
+		k, ok := key.(string)
+		if !ok {
+			return nil, fmt.Errorf("map index must be a string, got %s", exprTypeName(key))
+		}
+		return b[k], nil
+	case []interface{}:
+		var idx int
+		switch k := key.(type) {
+		case float64:
+			if k != float64(int(k)) {
+				return nil, fmt.Errorf("list index must be an integer, got %v", k)
+			}
+			idx = int(k)
+		case string:
+			n, err := strconv.Atoi(k)
+			if err != nil {
+				return nil, fmt.Errorf("list index must be a number, got %q", k)
+			}
+			idx = n
+		default:
+			return nil, fmt.Errorf("list index must be a number, got %s", exprTypeName(key))
+		}
+		if idx < 0 {
+			idx += len(b)
+		}
+		if idx < 0 || idx >= len(b) {
+			return nil, nil
+		}
+		return b[idx], nil
+	}
+	return nil, fmt.Errorf("cannot access %v on %s", key, exprTypeName(base))
+}
+
+func exprNavigate(v interface{}, path []string) (interface{}, error) {
+	for _, part := range path {
+		var err error
+		if v, err = exprIndexValue(v, part); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// exprNormalize converts resolved Go values into the expression value
+// domain: nil, bool, float64, string, []interface{} and
+// map[string]interface{}.
+func exprNormalize(v interface{}) interface{} {
+	switch x := v.(type) {
+	case nil, bool, string, float64:
+		return x
+	case int:
+		return float64(x)
+	case int8:
+		return float64(x)
+	case int16:
+		return float64(x)
+	case int32:
+		return float64(x)
+	case int64:
+		return float64(x)
+	case uint:
+		return float64(x)
+	case uint8:
+		return float64(x)
+	case uint16:
+		return float64(x)
+	case uint32:
+		return float64(x)
+	case uint64:
+		return float64(x)
+	case float32:
+		return float64(x)
+	case json.Number:
+		f, _ := x.Float64()
+		return f
+	case time.Duration:
+		return x.String()
+	case []interface{}:
+		out := make([]interface{}, len(x))
+		for i, el := range x {
+			out[i] = exprNormalize(el)
+		}
+		return out
+	case []string:
+		out := make([]interface{}, len(x))
+		for i, el := range x {
+			out[i] = el
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(x))
+		for k, el := range x {
+			out[k] = exprNormalize(el)
+		}
+		return out
+	case map[string]string:
+		out := make(map[string]interface{}, len(x))
+		for k, el := range x {
+			out[k] = el
+		}
+		return out
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(x))
+		for k, el := range x {
+			out[fmt.Sprint(k)] = exprNormalize(el)
+		}
+		return out
+	}
+	// Structs and typed slices (e.g. []BeadRecord) go through JSON so
+	// their fields are addressable by their JSON names.
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	var out interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return string(data)
+	}
+	return exprNormalize(out)
+}
+
+func exprTypeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "list"
+	case map[string]interface{}:
+		return "map"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func exprArityText(arity [2]int) string {
+	switch {
+	case arity[0] == arity[1] && arity[0] == 1:
+		return "1 argument"
+	case arity[0] == arity[1]:
+		return fmt.Sprintf("%d arguments", arity[0])
+	default:
+		return fmt.Sprintf("%d or %d arguments", arity[0], arity[1])
+	}
+}
+
+// bindTypedExprLocals records the current foreach bindings on a
+// materialized step and its nested steps. Legacy conditions get the same
+// values baked in as text by substituteForeachStepFields; typed
+// expressions keep their structure, so the values travel with the step
+// and are layered in by withStepExprLocals when the condition runs.
+// Nested foreach bodies rebind their own alias and loop.*, so those roots
+// are left for the inner materialization.
+func (e *Executor) bindTypedExprLocals(step *Step, varName string) {
+	e.varMu.RLock()
+	bindings := make(map[string]interface{})
+	for _, key := range append(loopScopeKeys(varName), varName, paneVariableKey) {
+		if v, ok := e.state.Variables[key]; ok {
+			bindings[key] = v
+		}
+	}
+	e.varMu.RUnlock()
+	if item, ok := bindings["loop.item"]; ok {
+		bindings["item"] = item
+	}
+	bindTypedExprLocalsProtected(step, bindings, nil)
+}
+
+func bindTypedExprLocalsProtected(step *Step, bindings map[string]interface{}, protected map[string]struct{}) {
+	typed := IsTypedExpression(step.When) || IsTypedExpression(step.Branch) ||
+		(step.Loop != nil && IsTypedExpression(step.Loop.While))
+	if typed {
+		locals := cloneInterfaceMap(step.exprLocals)
+		if locals == nil {
+			locals = make(map[string]interface{}, len(bindings))
+		}
+		for key, v := range bindings {
+			if _, skip := protected[strings.SplitN(key, ".", 2)[0]]; !skip {
+				locals[key] = v
+			}
+		}
+		step.exprLocals = locals
+	}
+
+	for i := range step.Parallel.Steps {
+		bindTypedExprLocalsProtected(&step.Parallel.Steps[i], bindings, protected)
+	}
+	for i := range step.OnSuccess {
+		bindTypedExprLocalsProtected(&step.OnSuccess[i], bindings, protected)
+	}
+	if step.Loop != nil {
+		for i := range step.Loop.Steps {
+			bindTypedExprLocalsProtected(&step.Loop.Steps[i], bindings, protected)
+		}
+	}
+	for _, config := range []*ForeachConfig{step.Foreach, step.ForeachPane} {
+		if config == nil {
+			continue
+		}
+		inner := cloneProtectedRoots(protected)
+		for _, root := range []string{foreachVarName(config), "loop", "item", paneVariableKey} {
+			inner[root] = struct{}{}
+		}
+		for i := range config.Steps {
+			bindTypedExprLocalsProtected(&config.Steps[i], bindings, inner)
+		}
+	}
+}
+
+// withStepExprLocals layers a step's captured foreach bindings under any
+// round overlay already on ctx.
+func withStepExprLocals(ctx context.Context, step *Step) context.Context {
+	if step == nil || len(step.exprLocals) == 0 {
+		return ctx
+	}
+	merged := cloneInterfaceMap(step.exprLocals)
+	for key, v := range roundOverridesFromCtx(ctx) {
+		merged[key] = v
+	}
+	return withRoundOverrides(ctx, merged)
+}
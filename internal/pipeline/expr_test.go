@@ -0,0 +1,262 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTypedConditionBody(t *testing.T) {
+	for in, want := range map[string]string{
+		"${{ a == 1 }}":    "a == 1",
+		"  ${{x}}  ":       "x",
+		"${vars.x} == 1":   "",
+		"${{ unclosed }":   "",
+		"$(echo ${{ }})":   "",
+		"${{ a }} && ${b}": "",
+	} {
+		got, ok := typedConditionBody(in)
+		if ok != (want != "") || got != want {
+			t.Errorf("typedConditionBody(%q) = %q, %v; want %q", in, got, ok, want)
+		}
+	}
+}
+
+func TestEvalTypedExpr(t *testing.T) {
+	state := &ExecutionState{
+		Steps: map[string]StepResult{
+			"scan": {
+				StepID:     "scan",
+				Status:     StatusCompleted,
+				Output:     `{"count": 3, "findings": [{"sev": "high"}, {"sev": "low"}], "tag": "v1.2"}`,
+				ParsedData: map[string]interface{}{"count": 3, "findings": []interface{}{map[string]interface{}{"sev": "high"}, map[string]interface{}{"sev": "low"}}, "tag": "v1.2"},
+			},
+			"raw": {StepID: "raw", Status: StatusCompleted, Output: `{"ok": true}`},
+		},
+		Variables: map[string]interface{}{"limit": 2, "mode": "fast", "files": []interface{}{"a.go", "b.md"}},
+	}
+	scope := &exprScope{sub: NewSubstitutor(state, "s", "wf")}
+
+	for src, want := range map[string]interface{}{
+		`steps.scan.json.count > vars.limit`:                            true,
+		`steps.scan.json.findings[0].sev == "high"`:                     true,
+		`steps.scan.json.findings.1.sev`:                                "low",
+		`len(steps.scan.json.findings)`:                                 float64(2),
+		`any(steps.scan.json.findings, it.sev == "high")`:               true,
+		`all(steps.scan.json.findings, it.sev == "high")`:               false,
+		`len(filter(vars.files, endsWith(it, ".go")))`:                  float64(1),
+		`matches(steps.scan.json.tag, "^v[0-9]+\\.[0-9]+$")`:            true,
+		`steps.raw.json.ok && steps.raw.status == "completed"`:          true,
+		`steps.scan.json.missing == null`:                               true,
+		`vars.mode in ["fast", "cheap"] && !(vars.limit >= 5)`:          true,
+		`startsWith(lower("NTM-1"), "ntm") || steps.nope.output == "x"`: true,
+		`number("4") + 0`: nil, // parse error checked below
+		`string(vars.limit) == "2" && contains(vars.files, "b.md")`: true,
+		`vars.files[-1]`:       "b.md",
+		`'single' == "single"`: true,
+	} {
+		got, err := evalTypedExpr(src, scope)
+		if want == nil {
+			if err == nil {
+				t.Errorf("evalTypedExpr(%q) = %v, want error", src, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("evalTypedExpr(%q) error = %v", src, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("evalTypedExpr(%q) = %#v, want %#v", src, got, want)
+		}
+	}
+
+	for src, want := range map[string]string{
+		`vars.mode > 1`:           "cannot compare",
+		`vars.mode && true`:       "expects a boolean",
+		`len(vars.limit)`:         "len()",
+		`nosuch.value == 1`:       "unknown name",
+		`matches(vars.mode, "(")`: "matches()",
+	} {
+		if _, err := evalTypedCondition(src, scope); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("evalTypedCondition(%q) error = %v, want %q", src, err, want)
+		}
+	}
+
+	if ok, err := evalTypedCondition(`steps.scan.json.missing`, scope); err != nil || ok {
+		t.Errorf("null condition = %v, %v; want false", ok, err)
+	}
+	if _, err := evalTypedCondition(`steps.scan.json.count`, scope); err == nil {
+		t.Error("number condition should be an error")
+	}
+}
+
+func TestCheckTypedExpr(t *testing.T) {
+	env := &exprCheckEnv{
+		vars:  map[string]exprType{"limit": exprTypeNumber, "mode": exprTypeString, "files": exprTypeList},
+		steps: map[string]bool{"scan": true},
+		roots: map[string]bool{"item": true, "h": true},
+	}
+	for src, want := range map[string]string{
+		`vars.limit > 1 && vars.mode == "fast"`:           "",
+		`any(steps.scan.json.items, it.ok) && h.id != ""`: "",
+		`len(vars.files) > 0 || item.x`:                   "",
+		`vars.limit == "2"`:                               "comparing number with string",
+		`vars.mode > 3`:                                   "cannot compare string with number",
+		`vars.mode && true`:                               "&& expects a boolean",
+		`len(vars.limit)`:                                 "len() argument 1 must be",
+		`startsWith(vars.mode)`:                           "takes 2 arguments",
+		`nope(1)`:                                         "unknown function",
+		`matches(vars.mode, "[")`:                         "invalid regular expression",
+		`steps.scan.outptu == ""`:                         `unknown step field "outptu"`,
+		`steps.scan.status.x == ""`:                       "cannot access x on string",
+		`loop == 1`:                                       "incomplete loop reference",
+		`vars.mode == `:                                   "syntax error",
+		`stepz.scan.output`:                               `unknown name "stepz"`,
+		`1 in vars.limit`:                                 "in needs a list",
+	} {
+		_, issues := checkTypedExpr(src, env)
+		var errs []string
+		for _, issue := range issues {
+			if !issue.warning {
+				errs = append(errs, issue.String())
+			}
+		}
+		got := strings.Join(errs, "; ")
+		if (want == "") != (got == "") || !strings.Contains(got, want) {
+			t.Errorf("checkTypedExpr(%q) = %q, want %q", src, got, want)
+		}
+	}
+
+	_, issues := checkTypedExpr(`steps.later.output == "x"`, env)
+	if len(issues) != 1 || !issues[0].warning {
+		t.Errorf("unknown step issues = %+v, want one warning", issues)
+	}
+}
+
+func TestValidateTypedExpressions(t *testing.T) {
+	wf, err := ParseString(`
+schema_version: "2.0"
+name: typed
+vars:
+  retries: {type: number, default: 1}
+steps:
+  - id: a
+    command: "true"
+    when: "${{ vars.retries == \"1\" }}"
+  - id: b
+    command: "true"
+    when: "${{ len(steps.a.output) }}"
+  - id: c
+    branch: "${{ [1, 2] }}"
+    branches:
+      default: {id: c_default, command: "true"}
+  - id: d
+    loop:
+      while: "${{ steps.a.status == \"completed\" }}"
+      max_iterations: 2
+      steps:
+        - id: d1
+          command: "true"
+          when: "${{ loop.index >= 1 && steps.ghost.status == \"failed\" }}"
+`, "yaml")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	result := Validate(wf)
+	if result.Valid {
+		t.Fatal("Validate() = valid, want type errors")
+	}
+	var msgs []string
+	for _, e := range result.Errors {
+		msgs = append(msgs, e.Field+": "+e.Message)
+	}
+	all := strings.Join(msgs, "\n")
+	for _, want := range []string{
+		"steps[0].when: col 14: comparing number with string",
+		"steps[1].when: condition produces a number, not a boolean",
+		"steps[2].branch: branch expression produces a list",
+	} {
+		if !strings.Contains(all, want) {
+			t.Errorf("errors missing %q:\n%s", want, all)
+		}
+	}
+	if len(result.Errors) != 3 {
+		t.Errorf("got %d errors, want 3:\n%s", len(result.Errors), all)
+	}
+	warned := false
+	for _, w := range result.Warnings {
+		warned = warned || (w.Field == "steps[3].loop.steps[0].when" && strings.Contains(w.Message, `unknown step "ghost"`))
+	}
+	if !warned {
+		t.Errorf("warnings = %+v, want unknown step ghost", result.Warnings)
+	}
+}
+
+func TestTypedConditionsDriveExecution(t *testing.T) {
+	dir := t.TempDir()
+	path := writeWorkflowFile(t, dir, "typed.yaml", `
+schema_version: "2.0"
+name: typed
+vars:
+  threshold: {type: number, default: 2}
+  issues: {type: array, default: [{sev: high}, {sev: low}, {sev: low}]}
+steps:
+  - id: scan
+    command: "printf '{\"issues\": [{\"sev\": \"high\"}, {\"sev\": \"low\"}, {\"sev\": \"low\"}]}'"
+    output_parse: json
+  - id: gate
+    depends_on: [scan]
+    when: "${{ len(steps.scan.json.issues) > vars.threshold && any(steps.scan.json.issues, it.sev == 'high') }}"
+    command: "echo gated"
+  - id: quiet
+    depends_on: [scan]
+    when: "${{ all(steps.scan.json.issues, it.sev == 'low') }}"
+    command: "echo never"
+  - id: route
+    depends_on: [scan]
+    branch: "${{ steps.scan.json.issues[0].sev }}"
+    branches:
+      high: {id: page, command: "echo paging"}
+      default: {id: log, command: "echo logging"}
+  - id: per_issue
+    depends_on: [scan]
+    foreach:
+      items: "${vars.issues}"
+      as: issue
+      steps:
+        - id: fix
+          when: "${{ issue.sev == 'low' && loop.index > 0 }}"
+          command: "echo fixing ${issue.sev} >> fixed.txt"
+  - id: count
+    depends_on: [scan]
+    loop:
+      while: "${{ steps.count_iter1_tick.status == null }}"
+      max_iterations: 10
+      steps:
+        - id: tick
+          command: "echo x >> ticks.txt"
+`)
+
+	state, err := runWorkflowFile(t, path, false)
+	if err != nil || state.Status != StatusCompleted {
+		t.Fatalf("run = %v, %v", state.Status, err)
+	}
+	if r := state.Steps["gate"]; r.Status != StatusCompleted || r.Output != "gated" {
+		t.Errorf("gate = %s %q, want completed", r.Status, r.Output)
+	}
+	if r := state.Steps["quiet"]; r.Status != StatusSkipped {
+		t.Errorf("quiet = %s, want skipped", r.Status)
+	}
+	if out := state.Steps["route"].Output; !strings.Contains(out, "paging") || strings.Contains(out, "logging") {
+		t.Errorf("route output = %q, want the high branch", out)
+	}
+	fixed, err := os.ReadFile(filepath.Join(dir, "fixed.txt"))
+	if err != nil || string(fixed) != "fixing low\nfixing low\n" {
+		t.Errorf("fixed.txt = %q, %v; want two low fixes", fixed, err)
+	}
+	if n := countLinesIn(t, filepath.Join(dir, "ticks.txt")); n != 2 {
+		t.Errorf("while loop ran %d times, want 2", n)
+	}
+}
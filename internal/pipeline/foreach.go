@@ -10,6 +10,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/Dicklesworthstone/ntm/internal/pressure"
 	"github.com/Dicklesworthstone/ntm/internal/tmux"
 )
 
@@ -74,6 +75,31 @@ func (e *Executor) executeForeach(ctx context.Context, step *Step, workflow *Wor
 	if err != nil {
 		return finishForeachFailure(result, "foreach_source", err.Error())
 	}
+	plans, iterations, ok := e.runForeachItems(ctx, step, workflow, config, kind, body, items, &result)
+	if !ok {
+		return result
+	}
+
+	result.ParsedData = iterations
+	total, dispatched, skipped, failed := countForeachIterations(iterations)
+	result.Output = fmt.Sprintf("Foreach completed: %d/%d dispatched, %d skipped, %d failed", dispatched, total, skipped, failed)
+
+	// bd-dg38m: enforce ForeachConfig.OutputVarMode / Step.OutputVarMode now
+	// that all iterations have completed. Per-iteration writes through
+	// storeForeachNestedResult are last-writer-wins, which silently loses
+	// N-1 outputs for aggregate (the default) and collect modes. Replace
+	// parent.OutputVar with the right shape — []string in iteration order
+	// for aggregate, map[string]string keyed by item identity for collect.
+	// Last mode keeps the per-iteration last-writer behavior (declared
+	// non-deterministic for parallel foreach by validateForeachOutputVarMode).
+	e.storeForeachOutputVars(step, config, plans, iterations)
+	return e.finishForeachIterations(ctx, step, workflow, result, iterations)
+}
+
+// runForeachItems plans and dispatches one iteration of body per item. It
+// is the fan-out core shared by foreach and matrix steps. When it returns
+// false, result has already been finished as a failure.
+func (e *Executor) runForeachItems(ctx context.Context, step *Step, workflow *Workflow, config *ForeachConfig, kind string, body []Step, items []interface{}, result *StepResult) ([]foreachIterationPlan, []foreachIterationResult, bool) {
 	if len(items) > e.limits.MaxForeachIterations {
 		result.Status = StatusFailed
 		result.Error = foreachStructuredError(step.ID, "limit",
@@ -81,24 +107,25 @@ func (e *Executor) executeForeach(ctx context.Context, step *Step, workflow *Wor
 			"raise pipeline limits.max_foreach_iterations or shrink the iteration source")
 		result.SkipKind = SkipKindLimit
 		result.FinishedAt = time.Now()
-		return result
+		return nil, nil, false
 	}
 
-	slog.Info("foreach step starting",
+	slog.Info(result.AgentType+" step starting",
 		"run_id", e.state.RunID,
 		"workflow", workflow.Name,
 		"step_id", step.ID,
-		"agent_type", "foreach",
+		"agent_type", result.AgentType,
 		"iterations", len(items),
 		"parallel", config.Parallel,
 	)
-	e.emitProgress("foreach_start", step.ID,
+	e.emitProgress(result.AgentType+"_start", step.ID,
 		fmt.Sprintf("Starting %s with %d iterations", kind, len(items)),
 		e.calculateProgress())
 
 	plans, err := e.prepareForeachIterations(ctx, step, config, kind, body, items)
 	if err != nil {
-		return finishForeachFailure(result, "foreach", err.Error())
+		*result = finishForeachFailure(*result, "foreach", err.Error())
+		return nil, nil, false
 	}
 
 	// bd-gstw3: items-fingerprint drift detection (parity with bd-3awat for
@@ -114,7 +141,8 @@ func (e *Executor) executeForeach(ctx context.Context, step *Step, workflow *Wor
 	}
 	itemsFingerprint := computeForeachItemsFingerprint(itemsForFingerprint)
 	if err := e.verifyForeachItemsFingerprint(step.ID, itemsFingerprint); err != nil {
-		return finishForeachFailure(result, "foreach", err.Error())
+		*result = finishForeachFailure(*result, "foreach", err.Error())
+		return nil, nil, false
 	}
 
 	// bd-qeatk: register foreach state so iterations that completed in a
@@ -126,27 +154,25 @@ func (e *Executor) executeForeach(ctx context.Context, step *Step, workflow *Wor
 	completedIters := e.foreachCompletedIterationIDs(step.ID)
 
 	onError := resolveErrorAction(step.OnError, workflow.Settings.OnError)
-	var iterations []foreachIterationResult
-	if config.Parallel {
-		iterations = e.executeForeachIterationsParallel(ctx, step, workflow, plans, onError, foreachMaxConcurrent(config, e.limits), completedIters)
-	} else {
-		iterations = e.executeForeachIterationsSequential(ctx, step, workflow, plans, onError, completedIters)
+	if !config.Parallel {
+		return plans, e.executeForeachIterationsSequential(ctx, step, workflow, plans, onError, completedIters), true
+	}
+	maxConcurrent, err := e.admitForeachFanout(ctx, step, min(foreachMaxConcurrent(config, e.limits), len(plans)))
+	if err != nil {
+		result.Status = StatusFailed
+		result.Error = foreachStructuredError(step.ID, "pressure", err.Error(), "wait for host pressure to drop, or run the fan-out sequentially")
+		result.FinishedAt = time.Now()
+		return nil, nil, false
 	}
+	return plans, e.executeForeachIterationsParallel(ctx, step, workflow, plans, onError, maxConcurrent, completedIters), true
+}
 
-	result.ParsedData = iterations
-	total, dispatched, skipped, failed := countForeachIterations(iterations)
-	result.Output = fmt.Sprintf("Foreach completed: %d/%d dispatched, %d skipped, %d failed", dispatched, total, skipped, failed)
+// finishForeachIterations settles a fan-out step's status from its
+// iteration results once output vars have been stored.
+func (e *Executor) finishForeachIterations(ctx context.Context, step *Step, workflow *Workflow, result StepResult, iterations []foreachIterationResult) StepResult {
 	result.FinishedAt = time.Now()
-
-	// bd-dg38m: enforce ForeachConfig.OutputVarMode / Step.OutputVarMode now
-	// that all iterations have completed. Per-iteration writes through
-	// storeForeachNestedResult are last-writer-wins, which silently loses
-	// N-1 outputs for aggregate (the default) and collect modes. Replace
-	// parent.OutputVar with the right shape — []string in iteration order
-	// for aggregate, map[string]string keyed by item identity for collect.
-	// Last mode keeps the per-iteration last-writer behavior (declared
-	// non-deterministic for parallel foreach by validateForeachOutputVarMode).
-	e.storeForeachOutputVars(step, config, plans, iterations)
+	total, dispatched, skipped, failed := countForeachIterations(iterations)
+	onError := resolveErrorAction(step.OnError, workflow.Settings.OnError)
 	if failed > 0 {
 		result.Error = aggregateForeachErrors(iterations, step.ID, total)
 		if onError != ErrorActionContinue {
@@ -161,12 +187,12 @@ func (e *Executor) executeForeach(ctx context.Context, step *Step, workflow *Wor
 		return result
 	}
 	result.Status = StatusCompleted
-	e.emitProgress("foreach_complete", step.ID, result.Output, e.calculateProgress())
-	slog.Info("foreach step completed",
+	e.emitProgress(result.AgentType+"_complete", step.ID, result.Output, e.calculateProgress())
+	slog.Info(result.AgentType+" step completed",
 		"run_id", e.state.RunID,
 		"workflow", workflow.Name,
 		"step_id", step.ID,
-		"agent_type", "foreach",
+		"agent_type", result.AgentType,
 		"iterations", total,
 		"dispatched", dispatched,
 		"skipped", skipped,
@@ -344,6 +370,7 @@ func (e *Executor) materializeForeachSteps(parent *Step, config *ForeachConfig,
 			materialized.Pane.Index = plan.PaneIndex
 		}
 		e.substituteForeachStepFields(&materialized)
+		e.bindTypedExprLocals(&materialized, varName)
 		steps = append(steps, materialized)
 	}
 	return steps, nil
@@ -801,7 +828,7 @@ func (e *Executor) foreachLoopControlDecision(ctx context.Context, step Step) (L
 	if step.When == "" {
 		return control, true, nil
 	}
-	skip, err := e.evaluateConditionCtx(ctx, step.When)
+	skip, err := e.evaluateConditionCtx(withStepExprLocals(ctx, &step), step.When)
 	if err != nil || skip {
 		return LoopControlNone, false, err
 	}
@@ -831,7 +858,10 @@ func foreachControlOnlyStep(step Step) bool {
 		step.Loop == nil &&
 		step.Foreach == nil &&
 		step.ForeachPane == nil &&
+		step.Matrix == nil &&
 		step.BeadQuery == nil &&
+		step.Workflow == nil &&
+		step.Approval == nil &&
 		len(step.mailStepKindNames()) == 0
 }
 
@@ -875,7 +905,7 @@ func (e *Executor) logForeachLoopControl(control LoopControl, workflow *Workflow
 
 func (e *Executor) executeForeachNestedStep(ctx context.Context, step *Step, workflow *Workflow) StepResult {
 	if step.When != "" {
-		skip, err := e.evaluateConditionCtx(ctx, step.When)
+		skip, err := e.evaluateConditionCtx(withStepExprLocals(ctx, step), step.When)
 		if err != nil {
 			return StepResult{
 				StepID:     step.ID,
@@ -1167,9 +1197,10 @@ func (e *Executor) storeForeachNestedResult(step *Step, result StepResult) {
 
 // stepOwnsForeachOutputVar reports whether a step is a foreach container, whose
 // OutputVar is written by storeForeachOutputVars with the shape declared by
-// output_var_mode rather than by the generic step-result path.
+// output_var_mode rather than by the generic step-result path. Matrix steps
+// likewise own theirs: it holds the result table.
 func stepOwnsForeachOutputVar(step *Step) bool {
-	return step != nil && (step.Foreach != nil || step.ForeachPane != nil)
+	return step != nil && (step.Foreach != nil || step.ForeachPane != nil || step.Matrix != nil)
 }
 
 func (e *Executor) substituteForeachStepFields(step *Step) {
@@ -1185,8 +1216,12 @@ func (e *Executor) substituteForeachStepFieldsProtected(step *Step, protected ma
 	step.Command = e.substituteForeachString(step.Command, protected)
 	step.Template = e.substituteForeachString(step.Template, protected)
 	step.Wait = WaitCondition(e.substituteForeachString(string(step.Wait), protected))
-	step.When = e.substituteForeachString(step.When, protected)
-	step.Branch = e.substituteForeachString(step.Branch, protected)
+	if !IsTypedExpression(step.When) {
+		step.When = e.substituteForeachString(step.When, protected)
+	}
+	if !IsTypedExpression(step.Branch) {
+		step.Branch = e.substituteForeachString(step.Branch, protected)
+	}
 	step.OutputVar = e.substituteForeachString(step.OutputVar, protected)
 	step.Args = substituteForeachInterfaceMap(e, step.Args, protected)
 	step.Params = substituteForeachInterfaceMap(e, step.Params, protected)
@@ -1362,7 +1397,7 @@ func (e *Executor) resolveForeachAlias(varPath string) (interface{}, bool, error
 
 func knownSubstitutionNamespace(root string) bool {
 	switch root {
-	case "vars", "steps", "env", "loop", "defaults", "item", "pane", "session", "run_id", "timestamp", "workflow", "runtime":
+	case "vars", "steps", "artifacts", "env", "loop", "defaults", "item", "pane", "session", "run_id", "timestamp", "workflow", "runtime":
 		return true
 	default:
 		return false
@@ -1390,6 +1425,37 @@ func foreachMaxConcurrent(config *ForeachConfig, limits LimitsConfig) int {
 	return maxConcurrent
 }
 
+// admitForeachFanout asks the pressure governor, when the executor has one,
+// how many iterations of a parallel fan-out may run at once. High pressure
+// serializes the fan-out; an enforcing governor refuses it outright at
+// critical pressure (the ActionPipelineFanout budget).
+func (e *Executor) admitForeachFanout(ctx context.Context, step *Step, maxConcurrent int) (int, error) {
+	gov := e.config.Pressure
+	if gov == nil {
+		return maxConcurrent, nil
+	}
+	refreshCtx, cancel := context.WithTimeout(ctx, 250*time.Millisecond)
+	gov.Refresh(refreshCtx)
+	cancel()
+
+	admission := gov.AdmitFanout(e.config.Session, maxConcurrent)
+	if admission.Decision == pressure.DecisionDeny && admission.Enforcing {
+		return 0, fmt.Errorf("pressure governor refused a fan-out of %d at %s pressure: %s",
+			maxConcurrent, admission.PressureLevel, admission.Hint)
+	}
+	if admission.Allowed < maxConcurrent {
+		slog.Info("foreach fan-out throttled",
+			"run_id", e.state.RunID,
+			"step_id", step.ID,
+			"requested", maxConcurrent,
+			"allowed", admission.Allowed,
+			"reason", admission.Reason,
+		)
+		return admission.Allowed, nil
+	}
+	return maxConcurrent, nil
+}
+
 func countForeachIterations(results []foreachIterationResult) (total, dispatched, skipped, failed int) {
 	total = len(results)
 	for _, result := range results {
@@ -0,0 +1,421 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Graph formats accepted by RunGraph.Render.
+const (
+	GraphFormatDOT     = "dot"
+	GraphFormatMermaid = "mermaid"
+	GraphFormatJSON    = "json"
+)
+
+// Graph edge kinds.
+const (
+	GraphEdgeDependsOn = "depends_on"
+	GraphEdgeBranch    = "branch"
+)
+
+// RunGraph is a renderable view of a workflow's step graph. Built with a
+// run's state, every node also carries the status that step reached.
+//
+// Containers (parallel, loop, foreach, matrix and branch steps) own their
+// body steps through GraphNode.Parent. For a run, foreach, loop and matrix
+// containers list the iteration steps that actually ran
+// (`<step>_iter<N>_<child>`); without a run they list the body definition,
+// marked Template.
+type RunGraph struct {
+	Workflow string          `json:"workflow"`
+	RunID    string          `json:"run_id,omitempty"`
+	Status   ExecutionStatus `json:"status,omitempty"`
+	Nodes    []GraphNode     `json:"nodes"`
+	Edges    []GraphEdge     `json:"edges"`
+	// Levels groups top-level steps that can run concurrently, in order.
+	Levels [][]string `json:"levels,omitempty"`
+}
+
+// GraphNode is one step in a RunGraph.
+type GraphNode struct {
+	ID       string          `json:"id"`
+	Label    string          `json:"label"`
+	Kind     string          `json:"kind"`
+	Parent   string          `json:"parent,omitempty"`
+	Status   ExecutionStatus `json:"status,omitempty"`
+	Template bool            `json:"template,omitempty"`
+}
+
+// GraphEdge connects two nodes. Branch edges run from a branch step to the
+// first step of each branch body, labelled with the branch key.
+type GraphEdge struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Kind  string `json:"kind"`
+	Label string `json:"label,omitempty"`
+}
+
+// BuildRunGraph builds the step graph of a workflow. state may be nil for
+// a static graph of the definition.
+func BuildRunGraph(workflow *Workflow, state *ExecutionState) *RunGraph {
+	g := &RunGraph{Workflow: workflow.Name, Nodes: []GraphNode{}, Edges: []GraphEdge{}}
+	b := &graphBuilder{graph: g, state: state, seen: make(map[string]bool)}
+	if state != nil {
+		g.RunID = state.RunID
+		g.Status = state.Status
+	}
+
+	b.addSteps(workflow.Steps, "", false)
+	if plan := NewDependencyGraph(workflow).Resolve(); plan.Valid {
+		for _, level := range plan.Levels {
+			var top []string
+			for _, id := range level {
+				if b.parent[id] == "" {
+					top = append(top, id)
+				}
+			}
+			if len(top) > 0 {
+				g.Levels = append(g.Levels, top)
+			}
+		}
+	}
+	return g
+}
+
+type graphBuilder struct {
+	graph  *RunGraph
+	state  *ExecutionState
+	seen   map[string]bool
+	parent map[string]string
+}
+
+func (b *graphBuilder) addNode(step *Step, id, label, parent string, template bool) {
+	if b.seen[id] {
+		return
+	}
+	b.seen[id] = true
+	if b.parent == nil {
+		b.parent = make(map[string]string)
+	}
+	b.parent[id] = parent
+	if label == "" {
+		label = id
+		if step.Name != "" {
+			label = step.Name
+		}
+	}
+	b.graph.Nodes = append(b.graph.Nodes, GraphNode{
+		ID:       id,
+		Label:    label,
+		Kind:     stepKind(step),
+		Parent:   parent,
+		Status:   b.status(id),
+		Template: template,
+	})
+}
+
+func (b *graphBuilder) status(id string) ExecutionStatus {
+	if b.state == nil {
+		return ""
+	}
+	if result, ok := b.state.Steps[id]; ok && result.Status != "" {
+		return result.Status
+	}
+	if _, ok := b.state.InFlightSteps[id]; ok || b.state.CurrentStep == id {
+		return StatusRunning
+	}
+	return StatusPending
+}
+
+func (b *graphBuilder) addSteps(steps []Step, parent string, template bool) {
+	for i := range steps {
+		step := &steps[i]
+		b.addNode(step, step.ID, "", parent, template)
+		for _, dep := range step.DependsOn {
+			b.graph.Edges = append(b.graph.Edges, GraphEdge{From: dep, To: step.ID, Kind: GraphEdgeDependsOn})
+		}
+		b.addChildren(step, template)
+	}
+}
+
+func (b *graphBuilder) addChildren(step *Step, template bool) {
+	b.addSteps(step.Parallel.Steps, step.ID, template)
+
+	var body []Step
+	switch {
+	case step.Matrix != nil:
+		body = []Step{{ID: "cell", Prompt: step.Prompt, Command: step.Command, Template: step.Template}}
+	case step.Foreach != nil:
+		body = step.Foreach.Steps
+	case step.ForeachPane != nil:
+		body = step.ForeachPane.Steps
+	case step.Loop != nil:
+		body = step.Loop.Steps
+	}
+	if body != nil {
+		if template || !b.addIterations(step, body) {
+			b.addSteps(body, step.ID, true)
+		}
+	}
+
+	if len(step.Branches) > 0 {
+		b.addBranches(step, template)
+	}
+}
+
+// addIterations adds the `<step>_iter<N>_<child>` steps a run recorded for
+// a container, grouped by iteration. It reports false when the run has
+// none, so the caller falls back to the body definition.
+func (b *graphBuilder) addIterations(step *Step, body []Step) bool {
+	if b.state == nil {
+		return false
+	}
+	defs := make(map[string]*Step, len(body))
+	for i := range body {
+		defs[body[i].ID] = &body[i]
+	}
+	type iteration struct {
+		index int
+		child string
+		id    string
+	}
+	prefix := step.ID + "_iter"
+	var found []iteration
+	for id := range b.state.Steps {
+		rest, ok := strings.CutPrefix(id, prefix)
+		if !ok {
+			continue
+		}
+		n, child, ok := strings.Cut(rest, "_")
+		index, err := strconv.Atoi(n)
+		if !ok || err != nil {
+			continue
+		}
+		found = append(found, iteration{index: index, child: child, id: id})
+	}
+	if len(found) == 0 {
+		return false
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].index != found[j].index {
+			return found[i].index < found[j].index
+		}
+		return found[i].id < found[j].id
+	})
+	for _, it := range found {
+		def := defs[it.child]
+		if def == nil {
+			def = &Step{ID: it.child}
+		}
+		b.addNode(def, it.id, fmt.Sprintf("[%d] %s", it.index, it.child), step.ID, false)
+	}
+	return true
+}
+
+func (b *graphBuilder) addBranches(step *Step, template bool) {
+	keys := make([]string, 0, len(step.Branches))
+	for key := range step.Branches {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		body, err := parseBranchSteps(step.Branches[key], step.ID, key)
+		if err != nil || len(body) == 0 {
+			continue
+		}
+		for i := range body {
+			body[i].ID = scopedChildStepID(step.ID, body[i].ID, i+1)
+		}
+		b.addSteps(body, step.ID, template)
+		b.graph.Edges = append(b.graph.Edges, GraphEdge{From: step.ID, To: body[0].ID, Kind: GraphEdgeBranch, Label: key})
+	}
+}
+
+// LoadRunGraph builds the graph of a run, preferring the live executor of a
+// run tracked by this process and falling back to the state persisted
+// under projectDir. The workflow definition comes from the live executor
+// or from the workflow file the run recorded.
+func LoadRunGraph(projectDir, runID string) (*RunGraph, error) {
+	pipelineMu.RLock()
+	exec := pipelineRegistry[runID]
+	pipelineMu.RUnlock()
+	if exec != nil && exec.executor != nil {
+		if workflow := exec.executor.Workflow(); workflow != nil {
+			return BuildRunGraph(workflow, exec.executor.GetState()), nil
+		}
+	}
+
+	state, err := LoadState(projectDir, runID)
+	if err != nil {
+		return nil, err
+	}
+	if state.WorkflowFile == "" {
+		return nil, fmt.Errorf("run %s did not record its workflow file", runID)
+	}
+	workflowFile := state.WorkflowFile
+	if !filepath.IsAbs(workflowFile) {
+		workflowFile = filepath.Join(projectDir, workflowFile)
+	}
+	workflow, _, err := LoadAndValidate(workflowFile)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("workflow file %s for run %s no longer exists", state.WorkflowFile, runID)
+		}
+		return nil, fmt.Errorf("load workflow for run %s: %w", runID, err)
+	}
+	return BuildRunGraph(workflow, state), nil
+}
+
+// Render formats the graph as Graphviz DOT, Mermaid or indented JSON.
+func (g *RunGraph) Render(format string) (string, error) {
+	switch strings.ToLower(format) {
+	case GraphFormatDOT:
+		return g.DOT(), nil
+	case GraphFormatMermaid:
+		return g.Mermaid(), nil
+	case GraphFormatJSON:
+		data, err := json.MarshalIndent(g, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(data) + "\n", nil
+	}
+	return "", fmt.Errorf("unknown graph format %q (want dot, mermaid or json)", format)
+}
+
+// graphStatusColors are the fill colours used for each step status.
+var graphStatusColors = map[ExecutionStatus]string{
+	StatusPending:         "#ffffff",
+	StatusRunning:         "#fff3b0",
+	StatusPaused:          "#e0e0e0",
+	StatusWaitingApproval: "#ffd8a8",
+	StatusCompleted:       "#b7e4c7",
+	StatusFailed:          "#ffadad",
+	StatusCancelled:       "#ffc9a0",
+	StatusSkipped:         "#dee2e6",
+}
+
+func (g *RunGraph) children() map[string][]GraphNode {
+	children := make(map[string][]GraphNode)
+	for _, n := range g.Nodes {
+		children[n.Parent] = append(children[n.Parent], n)
+	}
+	return children
+}
+
+func (n GraphNode) caption() string {
+	label := n.Label
+	if n.Kind != "" && n.Kind != StepKindUnknown {
+		label += " (" + n.Kind + ")"
+	}
+	if n.Status != "" {
+		label += "\n" + string(n.Status)
+	}
+	return label
+}
+
+// DOT renders the graph in Graphviz DOT. Containers become clusters; a
+// container's own node sits inside its cluster so edges can still reach it.
+func (g *RunGraph) DOT() string {
+	var sb strings.Builder
+	children := g.children()
+	fmt.Fprintf(&sb, "digraph %s {\n", strconv.Quote(g.Workflow))
+	sb.WriteString("  rankdir=LR;\n  compound=true;\n  node [shape=box, style=\"rounded,filled\", fillcolor=\"#ffffff\", fontname=\"Helvetica\"];\n")
+
+	var write func(nodes []GraphNode, indent string)
+	write = func(nodes []GraphNode, indent string) {
+		for _, n := range nodes {
+			attrs := []string{"label=" + strconv.Quote(n.caption())}
+			if color, ok := graphStatusColors[n.Status]; ok {
+				attrs = append(attrs, "fillcolor="+strconv.Quote(color))
+			}
+			if n.Template {
+				attrs = append(attrs, `style="rounded,filled,dashed"`)
+			}
+			if kids := children[n.ID]; len(kids) > 0 {
+				fmt.Fprintf(&sb, "%ssubgraph %s {\n", indent, strconv.Quote("cluster_"+n.ID))
+				fmt.Fprintf(&sb, "%s  label=%s;\n%s  style=dashed;\n", indent, strconv.Quote(n.Label), indent)
+				fmt.Fprintf(&sb, "%s  %s [%s];\n", indent, strconv.Quote(n.ID), strings.Join(attrs, ", "))
+				write(kids, indent+"  ")
+				fmt.Fprintf(&sb, "%s}\n", indent)
+				continue
+			}
+			fmt.Fprintf(&sb, "%s%s [%s];\n", indent, strconv.Quote(n.ID), strings.Join(attrs, ", "))
+		}
+	}
+	write(children[""], "  ")
+
+	for _, e := range g.Edges {
+		attrs := ""
+		if e.Kind == GraphEdgeBranch {
+			attrs = fmt.Sprintf(" [label=%s, style=dashed]", strconv.Quote(e.Label))
+		}
+		fmt.Fprintf(&sb, "  %s -> %s%s;\n", strconv.Quote(e.From), strconv.Quote(e.To), attrs)
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// Mermaid renders the graph as a Mermaid flowchart with one class per
+// status.
+func (g *RunGraph) Mermaid() string {
+	var sb strings.Builder
+	children := g.children()
+	ids := make(map[string]string, len(g.Nodes))
+	for i, n := range g.Nodes {
+		ids[n.ID] = fmt.Sprintf("n%d", i)
+	}
+	sb.WriteString("flowchart LR\n")
+
+	node := func(n GraphNode, indent string) {
+		label := strings.ReplaceAll(n.caption(), "\n", "<br/>")
+		label = strings.ReplaceAll(label, `"`, "#quot;")
+		fmt.Fprintf(&sb, "%s%s[\"%s\"]\n", indent, ids[n.ID], label)
+		if n.Status != "" {
+			fmt.Fprintf(&sb, "%sclass %s %s\n", indent, ids[n.ID], n.Status)
+		}
+	}
+	var write func(nodes []GraphNode, indent string)
+	write = func(nodes []GraphNode, indent string) {
+		for _, n := range nodes {
+			kids := children[n.ID]
+			if len(kids) == 0 {
+				node(n, indent)
+				continue
+			}
+			fmt.Fprintf(&sb, "%ssubgraph %s_group[\"%s\"]\n", indent, ids[n.ID], strings.ReplaceAll(n.Label, `"`, "#quot;"))
+			node(n, indent+"  ")
+			write(kids, indent+"  ")
+			fmt.Fprintf(&sb, "%send\n", indent)
+		}
+	}
+	write(children[""], "  ")
+
+	for _, e := range g.Edges {
+		from, to := ids[e.From], ids[e.To]
+		if from == "" || to == "" {
+			continue
+		}
+		if e.Kind == GraphEdgeBranch {
+			fmt.Fprintf(&sb, "  %s -. %s .-> %s\n", from, strings.ReplaceAll(e.Label, `"`, "#quot;"), to)
+			continue
+		}
+		fmt.Fprintf(&sb, "  %s --> %s\n", from, to)
+	}
+
+	statuses := make([]string, 0, len(graphStatusColors))
+	for status := range graphStatusColors {
+		statuses = append(statuses, string(status))
+	}
+	sort.Strings(statuses)
+	for _, status := range statuses {
+		fmt.Fprintf(&sb, "  classDef %s fill:%s\n", status, graphStatusColors[ExecutionStatus(status)])
+	}
+	return sb.String()
+}
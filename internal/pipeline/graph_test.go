@@ -0,0 +1,151 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const graphTestWorkflow = `
+schema_version: "2.0"
+name: graph-demo
+vars:
+  files: {type: array, default: [a, b]}
+steps:
+  - id: plan
+    command: "echo plan"
+  - id: checks
+    depends_on: [plan]
+    parallel:
+      - id: lint
+        command: "echo lint"
+      - id: vet
+        command: "echo vet"
+  - id: each
+    depends_on: [plan]
+    foreach:
+      items: "${vars.files}"
+      steps:
+        - id: touch
+          command: "echo ${item}"
+  - id: route
+    depends_on: [checks]
+    branch: "fast"
+    branches:
+      fast: {id: quick, command: "echo quick"}
+      slow: {id: thorough, command: "exit 1"}
+`
+
+func graphNodes(g *RunGraph) map[string]GraphNode {
+	nodes := make(map[string]GraphNode, len(g.Nodes))
+	for _, n := range g.Nodes {
+		nodes[n.ID] = n
+	}
+	return nodes
+}
+
+func TestBuildRunGraphStatic(t *testing.T) {
+	wf, err := ParseString(graphTestWorkflow, "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := BuildRunGraph(wf, nil)
+	nodes := graphNodes(g)
+
+	for id, want := range map[string]struct{ kind, parent string }{
+		"plan":           {StepKindCommand, ""},
+		"checks":         {StepKindParallel, ""},
+		"lint":           {StepKindCommand, "checks"},
+		"each":           {StepKindForeach, ""},
+		"touch":          {StepKindCommand, "each"},
+		"route":          {StepKindBranch, ""},
+		"route_quick":    {StepKindCommand, "route"},
+		"route_thorough": {StepKindCommand, "route"},
+	} {
+		n, ok := nodes[id]
+		if !ok || n.Kind != want.kind || n.Parent != want.parent || n.Status != "" {
+			t.Errorf("node %s = %+v, want kind %s parent %q", id, n, want.kind, want.parent)
+		}
+	}
+	if !nodes["touch"].Template || nodes["lint"].Template {
+		t.Errorf("template flags: touch=%v lint=%v", nodes["touch"].Template, nodes["lint"].Template)
+	}
+
+	var branchEdges []string
+	deps := 0
+	for _, e := range g.Edges {
+		switch e.Kind {
+		case GraphEdgeBranch:
+			branchEdges = append(branchEdges, e.Label+":"+e.To)
+		case GraphEdgeDependsOn:
+			deps++
+		}
+	}
+	if strings.Join(branchEdges, ",") != "fast:route_quick,slow:route_thorough" || deps != 3 {
+		t.Errorf("branch edges = %v, depends_on edges = %d", branchEdges, deps)
+	}
+	if got, _ := json.Marshal(g.Levels); string(got) != `[["plan"],["checks","each"],["route"]]` {
+		t.Errorf("levels = %s", got)
+	}
+}
+
+func TestBuildRunGraphFromRun(t *testing.T) {
+	dir := t.TempDir()
+	// Parallel command steps need tmux panes, so the run uses a plain step.
+	workflow := strings.Replace(graphTestWorkflow, `    parallel:
+      - id: lint
+        command: "echo lint"
+      - id: vet
+        command: "echo vet"`, `    command: "echo checks"`, 1)
+	path := writeWorkflowFile(t, dir, "graph.yaml", workflow)
+	state, err := runWorkflowFile(t, path, false)
+	if err != nil || state.Status != StatusCompleted {
+		t.Fatalf("run = %v, %v", state.Status, err)
+	}
+
+	g, err := LoadRunGraph(dir, state.RunID)
+	if err != nil {
+		t.Fatalf("LoadRunGraph: %v", err)
+	}
+	if g.RunID != state.RunID || g.Status != StatusCompleted {
+		t.Fatalf("graph run = %s %s", g.RunID, g.Status)
+	}
+	nodes := graphNodes(g)
+	if _, ok := nodes["touch"]; ok {
+		t.Error("foreach body definition should be replaced by its iterations")
+	}
+	for id, want := range map[string]ExecutionStatus{
+		"plan":             StatusCompleted,
+		"checks":           StatusCompleted,
+		"each_iter0_touch": StatusCompleted,
+		"each_iter1_touch": StatusCompleted,
+		"route_quick":      StatusCompleted,
+		"route_thorough":   StatusPending,
+	} {
+		if n := nodes[id]; n.Status != want {
+			t.Errorf("node %s status = %q, want %q", id, n.Status, want)
+		}
+	}
+	if n := nodes["each_iter1_touch"]; n.Parent != "each" || n.Label != "[1] touch" {
+		t.Errorf("iteration node = %+v", n)
+	}
+
+	dot, _ := g.Render("dot")
+	for _, want := range []string{`digraph "graph-demo"`, `subgraph "cluster_each"`, `"plan" -> "checks";`, `[label="fast", style=dashed]`, `fillcolor="#b7e4c7"`} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("DOT missing %q:\n%s", want, dot)
+		}
+	}
+	mermaid, _ := g.Render("mermaid")
+	for _, want := range []string{"flowchart LR", "subgraph", "-. fast .->", "class n0 completed", "classDef failed fill:#ffadad"} {
+		if !strings.Contains(mermaid, want) {
+			t.Errorf("Mermaid missing %q:\n%s", want, mermaid)
+		}
+	}
+	if _, err := g.Render("svg"); err == nil {
+		t.Error("Render(svg) should fail")
+	}
+	if _, err := LoadRunGraph(dir, "run-missing"); err == nil {
+		t.Error("LoadRunGraph for an unknown run should fail")
+	}
+}
@@ -70,6 +70,9 @@ const (
 	StepKindForeach     = "foreach"
 	StepKindForeachPane = "foreach_pane"
 	StepKindBranch      = "branch"
+	StepKindWorkflow    = "workflow"
+	StepKindApproval    = "approval"
+	StepKindMatrix      = "matrix"
 )
 
 // Logger returns a slog logger with the current pipeline run identity attached.
@@ -120,6 +123,8 @@ func stepKind(step *Step) string {
 	switch {
 	case step == nil:
 		return StepKindUnknown
+	case step.Matrix != nil:
+		return StepKindMatrix
 	case step.Command != "":
 		return StepKindCommand
 	case step.Template != "":
@@ -130,6 +135,10 @@ func stepKind(step *Step) string {
 		return StepKindForeachPane
 	case step.Branch != "" || len(step.Branches) > 0:
 		return StepKindBranch
+	case step.Workflow != nil:
+		return StepKindWorkflow
+	case step.Approval != nil:
+		return StepKindApproval
 	case step.Loop != nil:
 		return StepKindLoop
 	case step.Parallel.Flag || len(step.Parallel.Steps) > 0:
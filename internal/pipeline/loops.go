@@ -289,7 +289,7 @@ func (le *LoopExecutor) executeWhile(ctx context.Context, step *Step, loop *Loop
 		// Evaluate while condition. bd-ypo73: ctx-aware so a `loop.while`
 		// expression nested inside a foreach max_rounds body sees the
 		// round overlay from withRoundOverrides.
-		shouldSkip, err := le.executor.evaluateConditionCtx(ctx, loop.While)
+		shouldSkip, err := le.executor.evaluateConditionCtx(withStepExprLocals(ctx, step), loop.While)
 		if err != nil {
 			result.Status = StatusFailed
 			result.Error = &StepError{
@@ -357,7 +357,7 @@ func (le *LoopExecutor) executeWhile(ctx context.Context, step *Step, loop *Loop
 	if result.Iterations >= maxIterations {
 		// Evaluate condition one more time to see if it's still true.
 		// bd-ypo73: ctx-aware for the same max_rounds overlay reasoning.
-		shouldSkip, _ := le.executor.evaluateConditionCtx(ctx, loop.While)
+		shouldSkip, _ := le.executor.evaluateConditionCtx(withStepExprLocals(ctx, step), loop.While)
 		if !shouldSkip {
 			// Condition is still true, we hit the limit
 			result.Error = &StepError{
@@ -707,7 +707,7 @@ func loopControlAppliesForStep(ctx context.Context, step Step, e *Executor) (Loo
 	if step.When == "" {
 		return control, true, nil
 	}
-	skip, err := e.evaluateConditionCtx(ctx, step.When)
+	skip, err := e.evaluateConditionCtx(withStepExprLocals(ctx, &step), step.When)
 	if err != nil {
 		return LoopControlNone, false, err
 	}
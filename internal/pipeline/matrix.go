@@ -0,0 +1,399 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// MatrixConfig fans a step out over the cartesian product of several axes.
+// Every key other than the reserved include/exclude/parallel/max_concurrent
+// names an axis and lists its values:
+//
+//	matrix:
+//	  agent: [cc, cod, agy]
+//	  model: [fast, smart]
+//	  exclude:
+//	    - {agent: agy, model: fast}
+//	  include:
+//	    - {agent: cc, model: smart, budget: high}
+//	  parallel: true
+//
+// Axes are combined in name order. Exclude entries drop every combination
+// whose values match all of the entry's keys. An include entry whose axis
+// values match existing combinations adds its remaining keys to them;
+// otherwise it is appended as a combination of its own.
+//
+// Each combination runs the step body once as a scoped step
+// `<step>_iter<N>_cell` with the combination bound to ${matrix.<axis>}.
+type MatrixConfig struct {
+	Axes          map[string][]interface{}
+	Include       []map[string]interface{}
+	Exclude       []map[string]interface{}
+	Parallel      bool
+	MaxConcurrent int
+}
+
+// matrixControl holds the reserved, non-axis keys of a matrix block.
+type matrixControl struct {
+	Include       []map[string]interface{} `json:"include,omitempty"`
+	Exclude       []map[string]interface{} `json:"exclude,omitempty"`
+	Parallel      bool                     `json:"parallel,omitempty"`
+	MaxConcurrent int                      `json:"max_concurrent,omitempty"`
+}
+
+func isMatrixControlKey(key string) bool {
+	switch key {
+	case "include", "exclude", "parallel", "max_concurrent":
+		return true
+	default:
+		return false
+	}
+}
+
+// UnmarshalYAML reads the flat axes-plus-control-keys form.
+func (m *MatrixConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var raw map[string]interface{}
+	if err := unmarshal(&raw); err != nil {
+		return fmt.Errorf("matrix: must be a map of axes: %w", err)
+	}
+	return m.fromMap(raw)
+}
+
+// UnmarshalJSON reads the flat axes-plus-control-keys form.
+func (m *MatrixConfig) UnmarshalJSON(data []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("matrix: must be a map of axes: %w", err)
+	}
+	return m.fromMap(raw)
+}
+
+// UnmarshalTOML reads the flat axes-plus-control-keys table.
+func (m *MatrixConfig) UnmarshalTOML(data any) error {
+	raw, ok := tomlMap(data)
+	if !ok {
+		return fmt.Errorf("matrix: must be a table of axes")
+	}
+	return m.fromMap(raw)
+}
+
+// MarshalJSON emits the same flat form the unmarshalers accept.
+func (m MatrixConfig) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.toMap())
+}
+
+// MarshalYAML emits the same flat form the unmarshalers accept.
+func (m MatrixConfig) MarshalYAML() (interface{}, error) {
+	return m.toMap(), nil
+}
+
+func (m *MatrixConfig) fromMap(raw map[string]interface{}) error {
+	var control matrixControl
+	controlRaw := make(map[string]interface{})
+	axes := make(map[string][]interface{})
+	for key, value := range raw {
+		if isMatrixControlKey(key) {
+			controlRaw[key] = value
+			continue
+		}
+		values, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("matrix: axis %q must be a list of values", key)
+		}
+		axes[key] = values
+	}
+	if err := decodeTOMLValue(controlRaw, &control); err != nil {
+		return fmt.Errorf("matrix: %w", err)
+	}
+	*m = MatrixConfig{
+		Axes:          axes,
+		Include:       control.Include,
+		Exclude:       control.Exclude,
+		Parallel:      control.Parallel,
+		MaxConcurrent: control.MaxConcurrent,
+	}
+	return nil
+}
+
+func (m MatrixConfig) toMap() map[string]interface{} {
+	out := make(map[string]interface{}, len(m.Axes)+4)
+	for name, values := range m.Axes {
+		out[name] = values
+	}
+	if len(m.Include) > 0 {
+		out["include"] = m.Include
+	}
+	if len(m.Exclude) > 0 {
+		out["exclude"] = m.Exclude
+	}
+	if m.Parallel {
+		out["parallel"] = true
+	}
+	if m.MaxConcurrent > 0 {
+		out["max_concurrent"] = m.MaxConcurrent
+	}
+	return out
+}
+
+// AxisNames returns the axis names in the order they are combined.
+func (m *MatrixConfig) AxisNames() []string {
+	names := make([]string, 0, len(m.Axes))
+	for name := range m.Axes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Combinations expands the axes, applies exclude and then include, and
+// returns one map of axis (and include-only) values per cell.
+func (m *MatrixConfig) Combinations() []map[string]interface{} {
+	names := m.AxisNames()
+	var combos []map[string]interface{}
+	if len(names) > 0 {
+		combos = []map[string]interface{}{{}}
+		for _, name := range names {
+			next := make([]map[string]interface{}, 0, len(combos)*len(m.Axes[name]))
+			for _, combo := range combos {
+				for _, value := range m.Axes[name] {
+					cell := make(map[string]interface{}, len(combo)+1)
+					for k, v := range combo {
+						cell[k] = v
+					}
+					cell[name] = value
+					next = append(next, cell)
+				}
+			}
+			combos = next
+		}
+	}
+
+	kept := combos[:0]
+	for _, combo := range combos {
+		excluded := false
+		for _, rule := range m.Exclude {
+			if len(rule) > 0 && matrixValuesMatch(combo, rule) {
+				excluded = true
+				break
+			}
+		}
+		if !excluded {
+			kept = append(kept, combo)
+		}
+	}
+	combos = kept
+
+	original := len(combos)
+	for _, inc := range m.Include {
+		axisKeys := make(map[string]interface{})
+		for key, value := range inc {
+			if _, isAxis := m.Axes[key]; isAxis {
+				axisKeys[key] = value
+			}
+		}
+		matched := false
+		for i := 0; i < original; i++ {
+			if !matrixValuesMatch(combos[i], axisKeys) {
+				continue
+			}
+			matched = true
+			for key, value := range inc {
+				if _, isAxis := m.Axes[key]; !isAxis {
+					combos[i][key] = value
+				}
+			}
+		}
+		if !matched {
+			combos = append(combos, cloneInterfaceMap(inc))
+		}
+	}
+	return combos
+}
+
+// matrixValuesMatch reports whether combo agrees with every key of rule.
+// Values compare by their printed form so 4 (YAML int) matches 4.0 (JSON).
+func matrixValuesMatch(combo, rule map[string]interface{}) bool {
+	for key, want := range rule {
+		got, ok := combo[key]
+		if !ok || fmt.Sprint(got) != fmt.Sprint(want) {
+			return false
+		}
+	}
+	return true
+}
+
+// validateMatrix checks a matrix step's axes, include/exclude rules and body.
+func validateMatrix(step *Step, stepField string, result *ValidationResult) {
+	m := step.Matrix
+	field := stepField + ".matrix"
+	if step.Prompt == "" && step.PromptFile == "" && step.Command == "" && step.Template == "" {
+		result.addError(ParseError{
+			Field:   field,
+			Message: "matrix step must have prompt, prompt_file, command, or template",
+			Hint:    "The step body runs once per matrix combination",
+		})
+	}
+	for _, name := range m.AxisNames() {
+		if !isValidID(name) {
+			result.addError(ParseError{
+				Field:   field + "." + name,
+				Message: fmt.Sprintf("invalid matrix axis name %q", name),
+				Hint:    "Axis names are referenced as ${matrix.<axis>}; use alphanumerics, underscores and dashes",
+			})
+		}
+		if len(m.Axes[name]) == 0 {
+			result.addError(ParseError{
+				Field:   field + "." + name,
+				Message: fmt.Sprintf("matrix axis %q has no values", name),
+				Hint:    "List at least one value or remove the axis",
+			})
+		}
+	}
+	for i, rule := range m.Exclude {
+		for key := range rule {
+			if _, ok := m.Axes[key]; !ok {
+				result.addError(ParseError{
+					Field:   fmt.Sprintf("%s.exclude[%d].%s", field, i, key),
+					Message: fmt.Sprintf("exclude references unknown matrix axis %q", key),
+					Hint:    "exclude entries can only match declared axes",
+				})
+			}
+		}
+	}
+	for i, inc := range m.Include {
+		if len(inc) == 0 {
+			result.addError(ParseError{
+				Field:   fmt.Sprintf("%s.include[%d]", field, i),
+				Message: "include entry is empty",
+			})
+		}
+	}
+	if m.MaxConcurrent < 0 {
+		result.addError(ParseError{
+			Field:   field + ".max_concurrent",
+			Message: "max_concurrent cannot be negative",
+		})
+	}
+	if len(m.Combinations()) == 0 {
+		result.addError(ParseError{
+			Field:   field,
+			Message: "matrix expands to no combinations",
+			Hint:    "Declare at least one axis or include entry, and check that exclude does not remove every combination",
+		})
+	}
+}
+
+// matrixCellStep is the body each combination runs: the matrix step itself
+// minus the container-level fields the parent keeps (dependencies, when,
+// output_var, artifacts, retries and success/failure hooks). Retrying the
+// parent re-runs only the cells that have not completed, since completed
+// iterations are recorded in the foreach state.
+func matrixCellStep(step *Step) Step {
+	cell := cloneStep(*step)
+	cell.ID = "cell"
+	cell.Matrix = nil
+	cell.DependsOn = nil
+	cell.After = nil
+	cell.When = ""
+	cell.OutputVar = ""
+	cell.OutputVarMode = ""
+	cell.Artifacts = nil
+	cell.OnSuccess = nil
+	cell.OnFailure = OnFailureSpec{}
+	cell.RetryCount = 0
+	cell.RetryDelay = Duration{}
+	cell.RetryBackoff = ""
+	return cell
+}
+
+func (e *Executor) executeMatrix(ctx context.Context, step *Step, workflow *Workflow) StepResult {
+	result := StepResult{
+		StepID:    step.ID,
+		Status:    StatusRunning,
+		StartedAt: time.Now(),
+		AgentType: "matrix",
+	}
+	if e.state == nil {
+		return finishForeachFailure(result, "state", "execution state is not initialized")
+	}
+
+	combos := step.Matrix.Combinations()
+	items := make([]interface{}, len(combos))
+	for i, combo := range combos {
+		items[i] = combo
+	}
+	config := &ForeachConfig{
+		As:            "matrix",
+		Parallel:      step.Matrix.Parallel,
+		MaxConcurrent: step.Matrix.MaxConcurrent,
+	}
+	body := []Step{matrixCellStep(step)}
+
+	plans, iterations, ok := e.runForeachItems(ctx, step, workflow, config, "matrix", body, items, &result)
+	if !ok {
+		return result
+	}
+
+	table := matrixResultTable(plans, iterations)
+	result.ParsedData = table
+	total, dispatched, skipped, failed := countForeachIterations(iterations)
+	result.Output = fmt.Sprintf("Matrix completed: %d/%d dispatched, %d skipped, %d failed", dispatched, total, skipped, failed)
+	if step.OutputVar != "" {
+		e.varMu.Lock()
+		e.state.Variables[step.OutputVar] = table
+		e.varMu.Unlock()
+	}
+	return e.finishForeachIterations(ctx, step, workflow, result, iterations)
+}
+
+// matrixResultTable builds the structured table a matrix step stores in its
+// output_var and ParsedData: one row per combination, in expansion order,
+// with the columns index, matrix (the combination), step_id, status,
+// output, data (parsed output), duration_ms and error.
+func matrixResultTable(plans []foreachIterationPlan, iterations []foreachIterationResult) []interface{} {
+	rows := make([]interface{}, 0, len(iterations))
+	for i, iter := range iterations {
+		row := map[string]interface{}{
+			"index":  iter.Index,
+			"matrix": iter.Item,
+		}
+		if i < len(plans) && len(plans[i].Steps) > 0 {
+			row["step_id"] = plans[i].Steps[0].ID
+		}
+		var cell *StepResult
+		for j := len(iter.Results) - 1; j >= 0; j-- {
+			if iter.Results[j].StepID == row["step_id"] {
+				cell = &iter.Results[j]
+				break
+			}
+		}
+		switch {
+		case cell != nil:
+			row["status"] = string(cell.Status)
+			row["output"] = cell.Output
+			if cell.ParsedData != nil {
+				row["data"] = cell.ParsedData
+			}
+			if !cell.FinishedAt.IsZero() {
+				row["duration_ms"] = cell.FinishedAt.Sub(cell.StartedAt).Milliseconds()
+			}
+			if cell.Error != nil {
+				row["error"] = cell.Error.Message
+			}
+		case iter.Skipped:
+			row["status"] = string(StatusSkipped)
+		case foreachIterationCancelled(iter):
+			row["status"] = string(StatusCancelled)
+		default:
+			row["status"] = string(StatusFailed)
+		}
+		if cell == nil && iter.Error != "" {
+			row["error"] = iter.Error
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
@@ -0,0 +1,252 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/pressure"
+)
+
+func TestMatrixCombinations(t *testing.T) {
+	wf, err := ParseString(`
+schema_version: "2.0"
+name: m
+steps:
+  - id: bench
+    command: "true"
+    matrix:
+      agent: [cc, cod, agy]
+      model: [fast, smart]
+      exclude:
+        - {agent: agy, model: fast}
+      include:
+        - {agent: cc, model: smart, budget: high}
+        - {agent: gmi, model: pro}
+`, "yaml")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	var got []string
+	for _, combo := range wf.Steps[0].Matrix.Combinations() {
+		cell := fmt.Sprintf("%v/%v", combo["agent"], combo["model"])
+		if budget, ok := combo["budget"]; ok {
+			cell += fmt.Sprintf("+%v", budget)
+		}
+		got = append(got, cell)
+	}
+	want := "cc/fast,cc/smart+high,cod/fast,cod/smart,agy/smart,gmi/pro"
+	if strings.Join(got, ",") != want {
+		t.Fatalf("combinations = %s, want %s", strings.Join(got, ","), want)
+	}
+}
+
+func TestMatrixStepRunsEachCombinationIntoTable(t *testing.T) {
+	dir := t.TempDir()
+	path := writeWorkflowFile(t, dir, "bench.yaml", `
+schema_version: "2.0"
+name: bench
+steps:
+  - id: bench
+    command: "echo ${matrix.agent}-${matrix.variant.name}"
+    output_var: results
+    matrix:
+      agent: [cc, cod]
+      variant: [{name: terse}, {name: verbose}]
+      parallel: true
+      max_concurrent: 2
+  - id: report
+    depends_on: [bench]
+    command: "echo ${vars.results.3.output} ${steps.bench_iter0_cell.output}"
+`)
+	state, err := runWorkflowFile(t, path, false)
+	if err != nil || state.Status != StatusCompleted {
+		t.Fatalf("run = %v, %v (%+v)", state.Status, err, state.Steps)
+	}
+
+	table, ok := state.Steps["bench"].ParsedData.([]interface{})
+	if !ok || len(table) != 4 {
+		t.Fatalf("table = %#v", state.Steps["bench"].ParsedData)
+	}
+	for i, want := range []string{"cc-terse", "cc-verbose", "cod-terse", "cod-verbose"} {
+		row := table[i].(map[string]interface{})
+		if row["output"] != want || row["status"] != string(StatusCompleted) || row["step_id"] != fmt.Sprintf("bench_iter%d_cell", i) {
+			t.Errorf("row %d = %+v, want output %q", i, row, want)
+		}
+		if cell := state.Steps[fmt.Sprintf("bench_iter%d_cell", i)]; cell.Output != want {
+			t.Errorf("scoped result %d = %+v", i, cell)
+		}
+	}
+	if out := state.Steps["report"].Output; out != "cod-verbose cc-terse" {
+		t.Fatalf("downstream output = %q", out)
+	}
+	if !strings.HasPrefix(state.Steps["bench"].Output, "Matrix completed: 4/4 dispatched") {
+		t.Fatalf("summary = %q", state.Steps["bench"].Output)
+	}
+}
+
+func TestMatrixFailedCellKeepsTableWithOnErrorContinue(t *testing.T) {
+	dir := t.TempDir()
+	path := writeWorkflowFile(t, dir, "bench.yaml", `
+schema_version: "2.0"
+name: bench
+steps:
+  - id: bench
+    command: "test ${matrix.n} != 2 && echo ok-${matrix.n}"
+    on_error: continue
+    output_var: results
+    matrix:
+      n: [1, 2, 3]
+`)
+	state, _ := runWorkflowFile(t, path, false)
+	rows, _ := state.Steps["bench"].ParsedData.([]interface{})
+	if len(rows) != 3 {
+		t.Fatalf("rows = %#v", state.Steps["bench"].ParsedData)
+	}
+	var statuses []string
+	for _, r := range rows {
+		statuses = append(statuses, r.(map[string]interface{})["status"].(string))
+	}
+	if got := strings.Join(statuses, ","); got != "completed,failed,completed" {
+		t.Fatalf("statuses = %s", got)
+	}
+	if _, ok := state.Variables["results"].([]interface{}); !ok {
+		t.Fatalf("results var = %#v", state.Variables["results"])
+	}
+}
+
+type constPressureProvider struct{ cpu float64 }
+
+func (p constPressureProvider) Name() string { return "const" }
+
+func (p constPressureProvider) Read(context.Context) ([]pressure.Reading, error) {
+	return []pressure.Reading{{Source: pressure.SourceCPU, Value: p.cpu, Unit: "ratio"}}, nil
+}
+
+func TestMatrixFanoutGatedByPressure(t *testing.T) {
+	dir := t.TempDir()
+	path := writeWorkflowFile(t, dir, "bench.yaml", `
+schema_version: "2.0"
+name: bench
+steps:
+  - id: bench
+    command: "echo ${matrix.agent}"
+    matrix:
+      agent: [cc, cod, agy]
+      parallel: true
+`)
+	run := func(cpu float64) *ExecutionState {
+		t.Helper()
+		workflow, _, err := LoadAndValidate(path)
+		if err != nil {
+			t.Fatalf("load: %v", err)
+		}
+		cfg := DefaultExecutorConfig("matrix-session")
+		cfg.ProjectDir = filepath.Dir(path)
+		cfg.DefaultTimeout = 5 * time.Second
+		cfg.Pressure = pressure.New(pressure.Config{
+			Mode:      pressure.ModeEnforce,
+			Providers: []pressure.Provider{constPressureProvider{cpu: cpu}},
+		})
+		state, _ := NewExecutor(cfg).Run(context.Background(), workflow, nil, nil)
+		return state
+	}
+
+	// High pressure serializes the fan-out but still runs every cell.
+	if state := run(0.85); state.Status != StatusCompleted || len(state.Steps["bench"].ParsedData.([]interface{})) != 3 {
+		t.Fatalf("high pressure run = %s %+v", state.Status, state.Steps["bench"])
+	}
+
+	state := run(0.97)
+	bench := state.Steps["bench"]
+	if state.Status != StatusFailed || bench.Error == nil || bench.Error.Type != "pressure" || !strings.Contains(bench.Error.Message, "reduce parallelism: cpu") {
+		t.Fatalf("critical pressure run = %s %+v", state.Status, bench.Error)
+	}
+}
+
+func TestValidateMatrix(t *testing.T) {
+	for name, tc := range map[string]struct {
+		step string
+		want string
+	}{
+		"no body":         {`matrix: {a: [1]}`, "matrix step must have prompt"},
+		"empty axis":      {"command: 'true'\n    matrix: {a: []}", `matrix axis "a" has no values`},
+		"unknown exclude": {"command: 'true'\n    matrix: {a: [1], exclude: [{b: 1}]}", `unknown matrix axis "b"`},
+		"all excluded":    {"command: 'true'\n    matrix: {a: [1], exclude: [{a: 1}]}", "expands to no combinations"},
+		"with loop":       {"command: 'true'\n    loop: {times: 2}\n    matrix: {a: [1]}", "cannot combine matrix"},
+	} {
+		wf, err := ParseString("schema_version: \"2.0\"\nname: v\nsteps:\n  - id: s\n    "+tc.step+"\n", "yaml")
+		if err != nil {
+			t.Fatalf("%s: parse: %v", name, err)
+		}
+		result := Validate(wf)
+		found := false
+		for _, e := range result.Errors {
+			found = found || strings.Contains(e.Message, tc.want)
+		}
+		if result.Valid || !found {
+			t.Errorf("%s: errors = %+v, want %q", name, result.Errors, tc.want)
+		}
+	}
+
+	if _, err := ParseString("schema_version: \"2.0\"\nname: v\nsteps:\n  - id: s\n    command: 'true'\n    matrix: {a: 1}\n", "yaml"); err == nil || !strings.Contains(err.Error(), `axis "a" must be a list`) {
+		t.Fatalf("scalar axis parse error = %v", err)
+	}
+}
+
+func TestMatrixParsesFromTOML(t *testing.T) {
+	wf, err := ParseString(`
+schema_version = "2.0"
+name = "m"
+
+[[steps]]
+id = "bench"
+prompt = "Solve it as ${matrix.agent}"
+
+[steps.matrix]
+agent = ["cc", "cod"]
+max_concurrent = 2
+exclude = [{agent = "cod"}]
+`, "toml")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	m := wf.Steps[0].Matrix
+	if m == nil || m.MaxConcurrent != 2 || len(m.Combinations()) != 1 {
+		t.Fatalf("matrix = %+v", m)
+	}
+	if result := Validate(wf); !result.Valid || len(result.Warnings) != 0 {
+		t.Fatalf("validate = %+v", result)
+	}
+}
+
+func TestMatrixRetryRerunsOnlyUnfinishedCells(t *testing.T) {
+	dir := t.TempDir()
+	path := writeWorkflowFile(t, dir, "bench.yaml", `
+schema_version: "2.0"
+name: bench
+steps:
+  - id: bench
+    command: "echo ${matrix.n} >> runs.log && { test ${matrix.n} != 2 || test -f flaked || { touch flaked; exit 1; }; }"
+    on_error: retry
+    retry_count: 1
+    retry_delay: 10ms
+    matrix:
+      n: [1, 2, 3]
+`)
+	state, err := runWorkflowFile(t, path, false)
+	if err != nil || state.Status != StatusCompleted {
+		t.Fatalf("run = %v, %v", state.Status, err)
+	}
+	runs, err := os.ReadFile(filepath.Join(dir, "runs.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(strings.Fields(string(runs)), ","); got != "1,2,2,3" {
+		t.Fatalf("cell runs = %s, want 1,2,2,3", got)
+	}
+}
@@ -165,7 +165,7 @@ func formatUndecodedTOMLKeys(keys []toml.Key) string {
 func filterUndecodedTOMLKeys(keys []toml.Key) []toml.Key {
 	filtered := make([]toml.Key, 0, len(keys))
 	for _, key := range keys {
-		if isKnownParallelInlineStepKey(key) {
+		if isKnownParallelInlineStepKey(key) || isMatrixTOMLKey(key) {
 			continue
 		}
 		filtered = append(filtered, key)
@@ -191,6 +191,19 @@ func isKnownParallelInlineStepKey(key toml.Key) bool {
 	return knownStepTOMLFields[key[3]]
 }
 
+// isMatrixTOMLKey reports whether an undecoded key lies inside a step's
+// matrix table. MatrixConfig.UnmarshalTOML consumes the whole table (axes
+// are free-form keys), but the decoder still lists nested include/exclude
+// entries as undecoded.
+func isMatrixTOMLKey(key toml.Key) bool {
+	for i := 1; i < len(key)-1; i++ {
+		if key[i] == "matrix" && key[i-1] == "steps" {
+			return true
+		}
+	}
+	return false
+}
+
 var knownStepTOMLFields = map[string]bool{
 	"id":                       true,
 	"name":                     true,
@@ -219,13 +232,20 @@ var knownStepTOMLFields = map[string]bool{
 	"branch":                   true,
 	"branches":                 true,
 	"bead_query":               true,
+	"workflow":                 true,
+	"call":                     true,
+	"approval":                 true,
+	"gate":                     true,
 	"output_var":               true,
 	"output_parse":             true,
+	"artifacts":                true,
 	"parallel":                 true,
 	"loop":                     true,
 	"loop_control":             true,
 	"foreach":                  true,
 	"foreach_pane":             true,
+	"matrix":                   true,
+	"cache":                    true,
 	"mail_send":                true,
 	"file_reservation_paths":   true,
 	"mail_inbox_check":         true,
@@ -363,6 +383,7 @@ func Validate(w *Workflow) ValidationResult {
 
 	// Validate variable references
 	validateVariableRefs(w, &result)
+	validateTypedExpressions(w, &result)
 
 	return result
 }
@@ -447,6 +468,8 @@ func validateStep(step *Step, stepField string, stepIDs map[string]bool, result
 	hasBeadQuery := step.BeadQuery != nil
 	mailStepKinds := step.mailStepKindNames()
 	hasMailStep := len(mailStepKinds) > 0
+	hasWorkflow := step.Workflow != nil || step.Call != nil
+	hasApproval := step.Approval != nil || step.Gate != nil
 
 	if hasPrompt && hasParallel {
 		result.addError(ParseError{
@@ -505,6 +528,60 @@ func validateStep(step *Step, stepField string, stepIDs map[string]bool, result
 		})
 	}
 
+	if hasWorkflow && (hasPrompt || hasParallel || hasCommand || hasTemplate || hasForeach || hasBranch || hasMailStep || hasBeadQuery || step.Loop != nil) {
+		result.addError(ParseError{
+			Field:   stepField,
+			Message: "step cannot combine workflow with prompt, command, template, parallel, loop, foreach, branch, bead_query, or Agent Mail step kinds",
+			Hint:    "workflow runs another workflow file as the step body; wrap other work in the callee",
+		})
+	}
+	if step.Workflow != nil && step.Call != nil {
+		result.addError(ParseError{
+			Field:   stepField,
+			Message: "step cannot have both workflow and call",
+			Hint:    "call is an alias for workflow; use one",
+		})
+	}
+	if hasWorkflow {
+		sub := step.Workflow
+		if sub == nil {
+			sub = step.Call
+		}
+		if strings.TrimSpace(sub.File) == "" {
+			result.addError(ParseError{
+				Field:   stepField + ".workflow.file",
+				Message: "workflow step requires a file",
+				Hint:    "Set workflow: path/to/callee.yaml or workflow: {file: ..., inputs: {...}}",
+			})
+		}
+	}
+
+	if hasApproval && (hasPrompt || hasParallel || hasCommand || hasTemplate || hasForeach || hasBranch || hasMailStep || hasBeadQuery || hasWorkflow || step.Loop != nil) {
+		result.addError(ParseError{
+			Field:   stepField,
+			Message: "step cannot combine approval with other step kinds",
+			Hint:    "put the approval gate in its own step and make the gated work depend on it",
+		})
+	}
+	if step.Matrix != nil {
+		if hasParallel || hasForeach || hasBranch || hasMailStep || hasBeadQuery || hasWorkflow || hasApproval || step.Loop != nil {
+			result.addError(ParseError{
+				Field:   stepField,
+				Message: "step cannot combine matrix with parallel, loop, foreach, branch, workflow, approval, bead_query, or Agent Mail step kinds",
+				Hint:    "matrix fans out a single prompt, command, or template step; use foreach for multi-step bodies",
+			})
+		}
+		validateMatrix(step, stepField, result)
+	}
+	validateStepCache(step, stepField, result)
+	if step.Approval != nil && step.Gate != nil {
+		result.addError(ParseError{
+			Field:   stepField,
+			Message: "step cannot have both approval and gate",
+			Hint:    "gate is an alias for approval; use one",
+		})
+	}
+
 	// bd-nz63w: branch steps must have both the selector predicate and a
 	// branches map. The executor only dispatches when step.Branch is set, so
 	// a step with branches: but no branch: would silently fall through to
@@ -532,11 +609,11 @@ func validateStep(step *Step, stepField string, stepIDs map[string]bool, result
 	hasLoopControlOnly := step.LoopControl == LoopControlBreak || step.LoopControl == LoopControlContinue
 
 	if !hasPrompt && !hasParallel && !hasCommand && !hasTemplate &&
-		!hasForeach && !hasBranch && !hasBeadQuery && !hasMailStep &&
+		!hasForeach && !hasBranch && !hasBeadQuery && !hasMailStep && !hasWorkflow && !hasApproval &&
 		!hasLoopControlOnly && step.Loop == nil {
 		result.addError(ParseError{
 			Field:   stepField,
-			Message: "step must have prompt, prompt_file, command, template, parallel, loop, foreach, branch, bead_query, or loop_control",
+			Message: "step must have prompt, prompt_file, command, template, parallel, loop, foreach, branch, bead_query, workflow, approval, or loop_control",
 			Hint:    "Pick the step kind that matches the work you want done.",
 		})
 	}
@@ -613,6 +690,7 @@ func validateStep(step *Step, stepField string, stepIDs map[string]bool, result
 	}
 
 	validateOutputVarCollisions(step, stepField, result)
+	validateArtifactSpecs(step, stepField, result)
 
 	// Validate parallel sub-steps
 	for j, pStep := range step.Parallel.Steps {
@@ -754,7 +832,8 @@ func detectCycles(steps []Step) [][]string {
 // validateVariableRefs checks that variable references are valid
 func validateVariableRefs(w *Workflow, result *ValidationResult) {
 	// Uses package-level varPattern from variables.go
-	checkString := func(s, field string) {
+	// inMatrix allows ${matrix.*}, which a matrix step binds per cell.
+	checkString := func(s, field string, inMatrix bool) {
 		matches := varPattern.FindAllStringSubmatch(s, -1)
 		for _, match := range matches {
 			ref := match[1]
@@ -781,13 +860,29 @@ func validateVariableRefs(w *Workflow, result *ValidationResult) {
 						Hint:    "Use ${steps.step_id.output}",
 					})
 				}
+			case "artifacts":
+				if len(parts) < 3 {
+					result.addWarning(ParseError{
+						Field:   field,
+						Message: fmt.Sprintf("incomplete artifact reference: ${%s}", ref),
+						Hint:    "Use ${artifacts.step_id.name}",
+					})
+				}
 			case "env", "session", "timestamp", "run_id", "workflow", "loop":
 				// Valid built-in references
+			case "matrix":
+				if !inMatrix {
+					result.addWarning(ParseError{
+						Field:   field,
+						Message: fmt.Sprintf("matrix reference outside a matrix step: ${%s}", ref),
+						Hint:    "${matrix.<axis>} is only bound in the prompt of a step with a matrix block",
+					})
+				}
 			default:
 				result.addWarning(ParseError{
 					Field:   field,
 					Message: fmt.Sprintf("unknown reference type: ${%s}", ref),
-					Hint:    "Valid types: vars, steps, env, session, timestamp, run_id, workflow",
+					Hint:    "Valid types: vars, steps, artifacts, env, session, timestamp, run_id, workflow",
 				})
 			}
 		}
@@ -799,10 +894,10 @@ func validateVariableRefs(w *Workflow, result *ValidationResult) {
 		for i, step := range steps {
 			stepField := fmt.Sprintf("%s[%d]", prefix, i)
 			if step.Prompt != "" {
-				checkString(step.Prompt, stepField+".prompt")
+				checkString(step.Prompt, stepField+".prompt", step.Matrix != nil)
 			}
-			if step.When != "" {
-				checkString(step.When, stepField+".when")
+			if step.When != "" && !IsTypedExpression(step.When) {
+				checkString(step.When, stepField+".when", false)
 			}
 			// Check parallel sub-steps
 			if len(step.Parallel.Steps) > 0 {
@@ -878,6 +973,7 @@ func LoadAndValidate(path string) (*Workflow, ValidationResult, error) {
 	}
 
 	result := Validate(workflow)
+	validateSubWorkflowRefs(path, workflow, &result)
 	return workflow, result, nil
 }
 
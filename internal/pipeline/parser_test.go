@@ -449,7 +449,7 @@ func TestValidate_StepKindMutualExclusivityAndRequiredWork(t *testing.T) {
 		{
 			name:          "empty step has no work",
 			step:          Step{ID: "s1"},
-			wantErrSubstr: "must have prompt, prompt_file, command, template, parallel, loop, foreach, branch, bead_query, or loop_control",
+			wantErrSubstr: "must have prompt, prompt_file, command, template, parallel, loop, foreach, branch, bead_query, workflow, approval, or loop_control",
 		},
 		{
 			// bd-oqv4c: loop_control-only steps are valid; the runtime
@@ -106,6 +106,7 @@ type PipelineProgress struct {
 	Total          int              `json:"total"`
 	Percent        float64          `json:"percent"`
 	SkipKindCounts map[SkipKind]int `json:"skip_kind_counts,omitempty"`
+	CacheHits      int              `json:"cache_hits,omitempty"`
 }
 
 // PipelineStep represents step status in pipeline output
@@ -121,6 +122,8 @@ type PipelineStep struct {
 	Error       string   `json:"error,omitempty"`
 	SkipKind    SkipKind `json:"skip_kind,omitempty"`
 	SkipReason  string   `json:"skip_reason,omitempty"`
+	// Cache reports the result cache key and whether the step was reused.
+	Cache *StepCacheInfo `json:"cache,omitempty"`
 }
 
 // PipelineRunOptions configures a pipeline run
@@ -134,6 +137,8 @@ type PipelineRunOptions struct {
 	StartFromStep  string                 // Optional top-level step ID to start from
 	FromState      string                 // Optional prior run ID to copy skipped outputs from
 	StartFromState *ExecutionState        // Optional preloaded prior state
+	RunID          string                 // Optional: pre-assigned run ID (generated when empty)
+	NoCache        bool                   // Ignore cached step results (fresh results are still stored)
 }
 
 // PipelineRunOutput is the response for --robot-pipeline-run
@@ -331,12 +336,14 @@ func PrintPipelineRun(opts PipelineRunOptions) int {
 	// Create executor
 	execCfg := DefaultExecutorConfig(opts.Session)
 	execCfg.DryRun = opts.DryRun
+	execCfg.NoCache = opts.NoCache
 	if strings.TrimSpace(opts.ProjectDir) != "" {
 		execCfg.ProjectDir = opts.ProjectDir
 	} else if projectDir, err := os.Getwd(); err == nil {
 		execCfg.ProjectDir = projectDir
 	}
 	execCfg.WorkflowFile = workflowPath
+	execCfg.RunID = opts.RunID
 	execCfg.StartFromStep = opts.StartFromStep
 	execCfg.StartFromState = opts.StartFromState
 	if opts.FromState != "" {
@@ -482,7 +489,7 @@ func PrintPipelineStatus(runID string) int {
 			exec.WorkflowID, exec.Status, output.Progress.Percent),
 	}
 
-	if exec.Status == "running" {
+	if ExecutionStatus(exec.Status).IsActive() {
 		output.AgentHints.CancelCmd = fmt.Sprintf("ntm --robot-pipeline-cancel=%s", runID)
 		output.AgentHints.Suggestions = append(output.AgentHints.Suggestions, "Wait for completion or cancel")
 	}
@@ -519,7 +526,7 @@ func PrintPipelineList() int {
 	failed := 0
 	for _, p := range output.Pipelines {
 		switch p.Status {
-		case "running":
+		case "running", "waiting_approval":
 			running++
 		case "completed":
 			completed++
@@ -568,7 +575,7 @@ func PrintPipelineCancel(runID string) int {
 	}
 
 	// Check if already finished
-	if exec.Status != "running" {
+	if !ExecutionStatus(exec.Status).IsActive() {
 		output.RobotResponse = NewRobotResponse(true)
 		output.RunID = runID
 		output.Status = exec.Status
@@ -615,6 +622,9 @@ func calculateProgress(state *ExecutionState) PipelineProgress {
 		switch result.Status {
 		case StatusCompleted:
 			progress.Completed++
+			if result.Cache.IsHit() {
+				progress.CacheHits++
+			}
 		case StatusRunning:
 			progress.Running++
 		case StatusFailed:
@@ -653,6 +663,7 @@ func convertSteps(state *ExecutionState) map[string]PipelineStep {
 			PaneUsed:   result.PaneUsed,
 			SkipKind:   result.SkipKind,
 			SkipReason: result.SkipReason,
+			Cache:      result.Cache,
 		}
 
 		if !result.StartedAt.IsZero() {
@@ -1001,6 +1012,19 @@ func CancelPipeline(runID string) bool {
 	return true
 }
 
+// IsPipelineActive reports whether runID is still executing in THIS process.
+// Unlike GetPipelineSnapshot it ignores persisted state, so a run left
+// "running" on disk by a process that died is not reported as active.
+func IsPipelineActive(runID string) bool {
+	pipelineMu.RLock()
+	snapshot := snapshotPipeline(pipelineRegistry[runID])
+	pipelineMu.RUnlock()
+	if snapshot == nil {
+		return false
+	}
+	return ExecutionStatus(snapshot.Status).IsActive()
+}
+
 func updatePipelineFromState(runID string, state *ExecutionState) {
 	if state == nil {
 		return
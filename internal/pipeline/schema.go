@@ -59,6 +59,11 @@ type OutputDecl struct {
 	Name        string `yaml:"name,omitempty" toml:"name,omitempty" json:"name,omitempty"`
 	Description string `yaml:"description,omitempty" toml:"description,omitempty" json:"description,omitempty"`
 	Path        string `yaml:"path,omitempty" toml:"path,omitempty" json:"path,omitempty"`
+	// Value is an expression (e.g. ${steps.fix.output}) evaluated against the
+	// final state when this workflow is invoked through a workflow: step;
+	// Type, when set, is enforced on the result like a typed var.
+	Value string  `yaml:"value,omitempty" toml:"value,omitempty" json:"value,omitempty"`
+	Type  VarType `yaml:"type,omitempty" toml:"type,omitempty" json:"type,omitempty"`
 }
 
 // UnmarshalYAML accepts three input forms:
@@ -82,7 +87,7 @@ func (o *OutputDecl) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	// mapping with no extra keys takes precedence).
 	type rawDecl OutputDecl
 	var raw rawDecl
-	if err := unmarshal(&raw); err == nil && (raw.Name != "" || raw.Path != "" || raw.Description != "") {
+	if err := unmarshal(&raw); err == nil && (raw.Name != "" || raw.Path != "" || raw.Description != "" || raw.Value != "") {
 		*o = OutputDecl(raw)
 		return nil
 	}
@@ -124,7 +129,7 @@ func (o *OutputDecl) UnmarshalJSON(data []byte) error {
 
 	type rawDecl OutputDecl
 	var raw rawDecl
-	if err := json.Unmarshal(data, &raw); err == nil && (raw.Name != "" || raw.Path != "" || raw.Description != "") {
+	if err := json.Unmarshal(data, &raw); err == nil && (raw.Name != "" || raw.Path != "" || raw.Description != "" || raw.Value != "") {
 		*o = OutputDecl(raw)
 		return nil
 	}
@@ -162,7 +167,7 @@ func (o *OutputDecl) UnmarshalTOML(data any) error {
 
 	type rawDecl OutputDecl
 	var raw rawDecl
-	if err := decodeTOMLValue(m, &raw); err == nil && (raw.Name != "" || raw.Path != "" || raw.Description != "") {
+	if err := decodeTOMLValue(m, &raw); err == nil && (raw.Name != "" || raw.Path != "" || raw.Description != "" || raw.Value != "") {
 		*o = OutputDecl(raw)
 		return nil
 	}
@@ -422,9 +427,8 @@ type Step struct {
 	// Branch is a shell command (or template expression) whose stdout selects
 	// which entry of Branches to execute. Equivalent to a switch statement
 	// over the command's output. When Branch is set, Branches must be a
-	// map keyed by the possible output values. Currently parsed but not yet
-	// executed by the canonical executor — orchestration-style pipelines
-	// declare this for structural hint and the operator dispatches manually.
+	// map keyed by the possible output values. A ${{ ... }} typed expression
+	// selects the key by value instead of running a command.
 	Branch   string                 `yaml:"branch,omitempty" toml:"branch,omitempty" json:"branch,omitempty"`
 	Branches map[string]interface{} `yaml:"branches,omitempty" toml:"branches,omitempty" json:"branches,omitempty"`
 
@@ -432,10 +436,27 @@ type Step struct {
 	// JSON output, avoiding shell-piped br|jq steps in pipeline definitions.
 	BeadQuery *BeadQueryStep `yaml:"bead_query,omitempty" toml:"bead_query,omitempty" json:"bead_query,omitempty"`
 
+	// Workflow runs another workflow file as this step's body, binding its
+	// inputs from the current variables and returning its declared outputs
+	// (see SubWorkflowStep). Call is an alias normalized into Workflow.
+	Workflow *SubWorkflowStep `yaml:"workflow,omitempty" toml:"workflow,omitempty" json:"workflow,omitempty"`
+	Call     *SubWorkflowStep `yaml:"call,omitempty" toml:"call,omitempty" json:"call,omitempty"`
+
+	// Approval pauses the run until a human approves or denies the request
+	// it files with the approval engine (see ApprovalGateStep). Gate is an
+	// alias normalized into Approval.
+	Approval *ApprovalGateStep `yaml:"approval,omitempty" toml:"approval,omitempty" json:"approval,omitempty"`
+	Gate     *ApprovalGateStep `yaml:"gate,omitempty" toml:"gate,omitempty" json:"gate,omitempty"`
+
 	// Output handling
 	OutputVar     string        `yaml:"output_var,omitempty" toml:"output_var,omitempty" json:"output_var,omitempty"`                // Store output in variable
 	OutputVarMode OutputVarMode `yaml:"output_var_mode,omitempty" toml:"output_var_mode,omitempty" json:"output_var_mode,omitempty"` // aggregate, last, collect
-	OutputParse   OutputParse   `yaml:"output_parse,omitempty" toml:"output_parse,omitempty" json:"output_parse,omitempty"`          // none, json, yaml, lines, first_line, regex
+	OutputParse   OutputParse   `yaml:"output_parse,omitempty" toml:"output_parse,omitempty" json:"output_parse,omitempty"`          // none, json, yaml, lines, first_line, regex, turns
+
+	// Artifacts are files the step leaves in the working directory. They are
+	// copied into the run's artifact store when the step completes and can
+	// be referenced downstream as ${artifacts.<step>.<name>} (see ArtifactSpec).
+	Artifacts []ArtifactSpec `yaml:"artifacts,omitempty" toml:"artifacts,omitempty" json:"artifacts,omitempty"`
 
 	// Parallel execution. Two forms accepted:
 	//   - parallel: [<step>, <step>, ...]  — explicit inline sub-steps
@@ -458,12 +479,26 @@ type Step struct {
 	Foreach     *ForeachConfig `yaml:"foreach,omitempty" toml:"foreach,omitempty" json:"foreach,omitempty"`
 	ForeachPane *ForeachConfig `yaml:"foreach_pane,omitempty" toml:"foreach_pane,omitempty" json:"foreach_pane,omitempty"`
 
+	// Matrix runs this step's prompt/command/template once per combination
+	// of several axes (agent × model × variant, ...) and collects the cells
+	// into a result table (see MatrixConfig).
+	Matrix *MatrixConfig `yaml:"matrix,omitempty" toml:"matrix,omitempty" json:"matrix,omitempty"`
+
+	// Cache reuses a completed command or bead_query result across runs
+	// while its rendered inputs are unchanged (see StepCacheConfig).
+	Cache *StepCacheConfig `yaml:"cache,omitempty" toml:"cache,omitempty" json:"cache,omitempty"`
+
 	// Agent Mail step kinds. These execute through MCP Agent Mail rather than
 	// tmux pane dispatch and are mutually exclusive with prompt/command/etc.
 	MailSend               *MailSendStep               `yaml:"mail_send,omitempty" toml:"mail_send,omitempty" json:"mail_send,omitempty"`
 	FileReservationPaths   *FileReservationPathsStep   `yaml:"file_reservation_paths,omitempty" toml:"file_reservation_paths,omitempty" json:"file_reservation_paths,omitempty"`
 	MailInboxCheck         *MailInboxCheckStep         `yaml:"mail_inbox_check,omitempty" toml:"mail_inbox_check,omitempty" json:"mail_inbox_check,omitempty"`
 	FileReservationRelease *FileReservationReleaseStep `yaml:"file_reservation_release,omitempty" toml:"file_reservation_release,omitempty" json:"file_reservation_release,omitempty"`
+
+	// exprLocals holds the foreach bindings captured when this step was
+	// materialized, for ${{ ... }} conditions that cannot be rewritten as
+	// text (see bindTypedExprLocals).
+	exprLocals map[string]interface{}
 }
 
 // StringOrList accepts either a single string or a list of strings. Used for
@@ -1167,8 +1202,18 @@ const (
 
 // OutputParse defines how to parse step output
 type OutputParse struct {
-	Type    string `yaml:"type,omitempty" toml:"type,omitempty" json:"type,omitempty"`          // none, json, yaml, lines, first_line, regex
+	Type    string `yaml:"type,omitempty" toml:"type,omitempty" json:"type,omitempty"`          // none, json, yaml, lines, first_line, regex, turns
 	Pattern string `yaml:"pattern,omitempty" toml:"pattern,omitempty" json:"pattern,omitempty"` // For regex type
+	Agent   string `yaml:"agent,omitempty" toml:"agent,omitempty" json:"agent,omitempty"`       // For turns type; defaults to the agent that produced the output
+}
+
+// forAgent fills in Agent for turns parsing when the step's pane agent is
+// known.
+func (o OutputParse) forAgent(agentType string) OutputParse {
+	if o.Agent == "" {
+		o.Agent = agentType
+	}
+	return o
 }
 
 // UnmarshalText allows OutputParse to be specified as a simple string
@@ -1188,6 +1233,7 @@ func (o *OutputParse) UnmarshalJSON(data []byte) error {
 	if err := json.Unmarshal(data, &s); err == nil {
 		o.Type = s
 		o.Pattern = ""
+		o.Agent = ""
 		return nil
 	}
 	type raw OutputParse
@@ -1204,6 +1250,7 @@ func (o *OutputParse) UnmarshalTOML(data any) error {
 	if s, ok := data.(string); ok {
 		o.Type = s
 		o.Pattern = ""
+		o.Agent = ""
 		return nil
 	}
 	type raw OutputParse
@@ -1292,8 +1339,17 @@ const (
 	StatusFailed    ExecutionStatus = "failed"
 	StatusCancelled ExecutionStatus = "cancelled"
 	StatusSkipped   ExecutionStatus = "skipped"
+	// StatusWaitingApproval marks a run blocked on an approval gate. It is
+	// persisted like running, so a restarted server or `ntm pipeline resume`
+	// picks the run up and keeps waiting on the same approval request.
+	StatusWaitingApproval ExecutionStatus = "waiting_approval"
 )
 
+// IsActive reports whether a run in this status has not finished yet.
+func (s ExecutionStatus) IsActive() bool {
+	return s == StatusRunning || s == StatusPending || s == StatusWaitingApproval
+}
+
 // SkipKind classifies why a step was skipped or cancelled.
 type SkipKind string
 
@@ -1332,6 +1388,11 @@ type StepResult struct {
 	SkipReason string          `json:"skip_reason,omitempty"` // If skipped due to 'when' condition
 	SkipKind   SkipKind        `json:"skip_kind,omitempty"`   // Structured classifier for SkipReason
 	Attempts   int             `json:"attempts,omitempty"`    // Number of retry attempts
+	// Artifacts lists the files archived for the step's declared artifacts.
+	Artifacts []ArtifactRecord `json:"artifacts,omitempty"`
+	// Cache is set for cache-enabled steps and reports whether the result
+	// was reused from an earlier run.
+	Cache *StepCacheInfo `json:"cache,omitempty"`
 	// RerunOnResume marks a step for re-execution on resume even when its
 	// persisted Status is Completed. WaitNone (fire-and-forget) commands
 	// whose background process was killed by cancellation cleanup after the
@@ -1375,6 +1436,7 @@ type ExecutionState struct {
 	ParallelState    map[string]ParallelGroupState    `json:"parallel_state,omitempty"`
 	ScopeStack       []ScopeFrame                     `json:"scope_stack,omitempty"`
 	InFlightSteps    map[string]InFlightStepState     `json:"in_flight_steps,omitempty"`
+	ApprovalGates    map[string]ApprovalGateState     `json:"approval_gates,omitempty"`
 
 	// OutputValidation records the post-run check of Workflow.Outputs (bd-3uqce).
 	// nil when the workflow declared no outputs or validation was skipped (e.g.
@@ -1514,6 +1576,16 @@ func normalizeSteps(steps []Step) {
 			// Non-enum values (e.g. "fallback_to_ntm_inbox") and structured
 			// fallbacks stay in OnFailure for the executor to interpret.
 		}
+		// Call → Workflow (alias).
+		if s.Call != nil && s.Workflow == nil {
+			s.Workflow = s.Call
+			s.Call = nil
+		}
+		// Gate → Approval (alias).
+		if s.Gate != nil && s.Approval == nil {
+			s.Approval = s.Gate
+			s.Gate = nil
+		}
 		// TemplateParams → Params merge (Params wins on conflict).
 		if len(s.TemplateParams) > 0 {
 			if s.Params == nil {
@@ -20,6 +20,8 @@ const (
 	sideEffectKindAgentMailRelease     = "agent_mail_release"
 	sideEffectKindAgentMailInboxCheck  = "agent_mail_inbox_check"
 	sideEffectKindBeadQuery            = "bead_query"
+	sideEffectKindSubWorkflow          = "sub_workflow"
+	sideEffectKindApprovalRequest      = "approval_request"
 	sideEffectKindFilesystemWrite      = "filesystem_write"
 )
 
@@ -155,6 +157,12 @@ func (m *SideEffectManifest) collectStep(step Step, ctx sideEffectContext) {
 		}))
 	case step.BeadQuery != nil:
 		m.add(stepSideEffect(step, ctx, sideEffectKindBeadQuery, "Run structured br query", nil))
+	case step.Workflow != nil:
+		m.add(stepSideEffect(step, ctx, sideEffectKindSubWorkflow, "Run nested workflow (its own effects follow from the callee)", func(entry *SideEffectEntry) {
+			entry.Target = step.Workflow.File
+		}))
+	case step.Approval != nil:
+		m.add(stepSideEffect(step, ctx, sideEffectKindApprovalRequest, "File approval request and wait for a human decision", nil))
 	case step.MailSend != nil:
 		send := step.MailSend
 		m.add(stepSideEffect(step, ctx, sideEffectKindAgentMailSend, "Send Agent Mail message", func(entry *SideEffectEntry) {
@@ -170,6 +170,11 @@ func CleanupStates(projectDir string, olderThan time.Duration) (int, error) {
 			if err := os.Remove(path); err != nil {
 				return deleted, fmt.Errorf("remove pipeline state: %w", err)
 			}
+			// Archived artifacts live beside the state file and go with it.
+			runDir := filepath.Join(dir, strings.TrimSuffix(entry.Name(), ".json"))
+			if err := os.RemoveAll(runDir); err != nil {
+				return deleted, fmt.Errorf("remove pipeline artifacts: %w", err)
+			}
 			deleted++
 		}
 	}
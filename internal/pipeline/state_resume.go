@@ -235,6 +235,7 @@ func (e *Executor) resetResumeState(workflow *Workflow) {
 	e.state.ParallelState = nil
 	e.state.ScopeStack = nil
 	e.state.InFlightSteps = nil
+	e.state.ApprovalGates = nil
 	e.state.CurrentStep = ""
 	e.state.Errors = nil
 	e.stateMu.Unlock()
@@ -0,0 +1,443 @@
+package pipeline
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/util"
+)
+
+// StepCacheConfig opts a command or bead_query step into result caching.
+// The cache key covers the rendered command (or br query), stdin, args,
+// the declared artifacts, the contents of every file matching Inputs and
+// the rendered Key expression. A completed result stored under that key
+// is reused by later runs, artifacts included, until TTL expires.
+//
+//	cache: true
+//
+//	cache:
+//	  key: "${vars.model}"
+//	  inputs: [go.sum, internal/pipeline]
+//	  ttl: 24h
+type StepCacheConfig struct {
+	Enabled bool `yaml:"enabled" toml:"enabled" json:"enabled"`
+	// Key is an extra expression folded into the cache key, rendered with
+	// the usual ${...} substitution.
+	Key string `yaml:"key,omitempty" toml:"key,omitempty" json:"key,omitempty"`
+	// Inputs are workdir-relative paths or globs whose file contents are
+	// hashed into the key; matched directories are walked.
+	Inputs []string `yaml:"inputs,omitempty" toml:"inputs,omitempty" json:"inputs,omitempty"`
+	// TTL bounds how long a stored result stays reusable; zero never expires.
+	TTL Duration `yaml:"ttl,omitempty" toml:"ttl,omitempty" json:"ttl,omitempty"`
+}
+
+// StepCacheInfo reports how a cache-enabled step used the result cache.
+type StepCacheInfo struct {
+	Key string `json:"key"`
+	Hit bool   `json:"hit"`
+	// SourceRunID and StoredAt identify the run that produced a reused result.
+	SourceRunID string    `json:"source_run_id,omitempty"`
+	StoredAt    time.Time `json:"stored_at,omitempty"`
+	// Bypassed is set when --no-cache skipped the lookup; the fresh result
+	// is still stored.
+	Bypassed bool `json:"bypassed,omitempty"`
+}
+
+// IsHit reports whether the step result was served from the cache.
+func (c *StepCacheInfo) IsHit() bool {
+	return c != nil && c.Hit
+}
+
+const (
+	stepCacheDirName = "cache/steps"
+	// stepCacheKeyVersion is folded into every key so a change to what the
+	// key covers invalidates old entries instead of misreading them.
+	stepCacheKeyVersion = "v1"
+)
+
+func isStepCacheField(key string) bool {
+	switch key {
+	case "enabled", "key", "inputs", "ttl":
+		return true
+	default:
+		return false
+	}
+}
+
+// UnmarshalYAML accepts either a bool or the object form; the object form
+// enables caching unless it sets enabled: false.
+func (c *StepCacheConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var b bool
+	if err := unmarshal(&b); err == nil {
+		*c = StepCacheConfig{Enabled: b}
+		return nil
+	}
+	type raw StepCacheConfig
+	obj := raw{Enabled: true}
+	if err := unmarshal(&obj); err != nil {
+		return fmt.Errorf("cache: must be bool or {key, inputs, ttl}: %w", err)
+	}
+	*c = StepCacheConfig(obj)
+	return nil
+}
+
+// UnmarshalJSON accepts either a bool or the object form.
+func (c *StepCacheConfig) UnmarshalJSON(data []byte) error {
+	var b bool
+	if err := json.Unmarshal(data, &b); err == nil {
+		*c = StepCacheConfig{Enabled: b}
+		return nil
+	}
+	type raw StepCacheConfig
+	obj := raw{Enabled: true}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return fmt.Errorf("cache: must be bool or {key, inputs, ttl}: %w", err)
+	}
+	*c = StepCacheConfig(obj)
+	return nil
+}
+
+// UnmarshalTOML accepts either a bool or a table. Unknown table keys are
+// rejected here because the decoder cannot flag them once this consumes
+// the table.
+func (c *StepCacheConfig) UnmarshalTOML(data any) error {
+	if b, ok := data.(bool); ok {
+		*c = StepCacheConfig{Enabled: b}
+		return nil
+	}
+	m, ok := tomlMap(data)
+	if !ok {
+		return fmt.Errorf("cache: must be bool or a table")
+	}
+	for key := range m {
+		if !isStepCacheField(key) {
+			return fmt.Errorf("cache: unknown field %q", key)
+		}
+	}
+	type raw StepCacheConfig
+	obj := raw{Enabled: true}
+	if err := decodeTOMLValue(m, &obj); err != nil {
+		return fmt.Errorf("cache: %w", err)
+	}
+	*c = StepCacheConfig(obj)
+	return nil
+}
+
+func (c *StepCacheConfig) enabled() bool {
+	return c != nil && c.Enabled
+}
+
+// validateStepCache checks a step's cache: block.
+func validateStepCache(step *Step, stepField string, result *ValidationResult) {
+	if !step.Cache.enabled() {
+		return
+	}
+	field := stepField + ".cache"
+	if step.Command == "" && step.BeadQuery == nil {
+		result.addError(ParseError{
+			Field:   field,
+			Message: "cache is only supported on command and bead_query steps",
+			Hint:    "Only deterministic steps can reuse a stored result; agent prompts always run",
+		})
+	}
+	if step.Wait == WaitNone {
+		result.addError(ParseError{
+			Field:   field,
+			Message: "cache cannot be combined with wait: none",
+			Hint:    "Fire-and-forget commands have no result to reuse",
+		})
+	}
+	for i, pattern := range step.Cache.Inputs {
+		if err := checkArtifactPattern(pattern); err != nil {
+			result.addError(ParseError{
+				Field:   fmt.Sprintf("%s.inputs[%d]", field, i),
+				Message: err.Error(),
+				Hint:    "Cache inputs are paths or globs relative to the working directory",
+			})
+		}
+	}
+	if step.Cache.TTL.Duration < 0 {
+		result.addError(ParseError{
+			Field:   field + ".ttl",
+			Message: "cache ttl must not be negative",
+			Hint:    "Omit ttl to keep entries until their inputs change",
+		})
+	}
+}
+
+// stepCacheMaterial is everything a cached result depends on. It is
+// hashed as JSON, which sorts map keys, so the key is deterministic.
+type stepCacheMaterial struct {
+	Version   string            `json:"version"`
+	Kind      string            `json:"kind"`
+	Command   string            `json:"command,omitempty"`
+	Stdin     string            `json:"stdin,omitempty"`
+	Args      interface{}       `json:"args,omitempty"`
+	Key       string            `json:"key,omitempty"`
+	Inputs    map[string]string `json:"inputs,omitempty"`
+	Artifacts []ArtifactSpec    `json:"artifacts,omitempty"`
+}
+
+// stepCacheEntry is the on-disk record of a cached result.
+type stepCacheEntry struct {
+	Key       string           `json:"key"`
+	StepID    string           `json:"step_id"`
+	RunID     string           `json:"run_id"`
+	Output    string           `json:"output"`
+	Artifacts []ArtifactRecord `json:"artifacts,omitempty"`
+	StoredAt  time.Time        `json:"stored_at"`
+	ExpiresAt time.Time        `json:"expires_at,omitempty"`
+}
+
+// StepCacheDir returns the directory holding cached step results.
+func StepCacheDir(projectDir string) string {
+	return filepath.Join(projectDir, ".ntm", filepath.FromSlash(stepCacheDirName))
+}
+
+// stepCacheKey renders the step's cache key expression, hashes its
+// declared inputs and returns the hex key for the given material.
+func (e *Executor) stepCacheKey(step *Step, material stepCacheMaterial) (string, error) {
+	workDir, err := e.workDir()
+	if err != nil {
+		return "", fmt.Errorf("resolve working directory: %w", err)
+	}
+	if step.Cache.Key != "" {
+		key, err := e.substituteVariablesStrict(step.Cache.Key)
+		if err != nil {
+			return "", fmt.Errorf("cache key: %w", err)
+		}
+		material.Key = key
+	}
+	if len(step.Cache.Inputs) > 0 {
+		material.Inputs = make(map[string]string)
+	}
+	for _, spec := range step.Cache.Inputs {
+		pattern, err := e.substituteVariablesStrict(spec)
+		if err != nil {
+			return "", fmt.Errorf("cache input %s: %w", spec, err)
+		}
+		if err := checkArtifactPattern(pattern); err != nil {
+			return "", fmt.Errorf("cache input %s: %w", pattern, err)
+		}
+		files, err := matchArtifactFiles(workDir, pattern)
+		if err != nil {
+			return "", fmt.Errorf("cache input %s: %w", pattern, err)
+		}
+		// Record the pattern itself so a glob that starts matching a new
+		// file, or stops matching anything, changes the key.
+		material.Inputs["pattern:"+pattern] = strings.Join(files, "\x00")
+		for _, rel := range files {
+			sum, err := hashFile(filepath.Join(workDir, rel))
+			if err != nil {
+				return "", fmt.Errorf("cache input %s: %w", rel, err)
+			}
+			material.Inputs[filepath.ToSlash(rel)] = sum
+		}
+	}
+	material.Version = stepCacheKeyVersion
+	material.Artifacts = step.Artifacts
+
+	data, err := json.Marshal(material)
+	if err != nil {
+		return "", fmt.Errorf("encode cache key: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// lookupStepCache computes the step's cache key and, unless NoCache is
+// set, loads a live entry for it. On a hit the cached artifacts are
+// restored into this run's artifact store and the returned entry's
+// records point at the restored copies. A nil entry with a nil error is
+// a miss. The returned info is always set so the caller can report the key.
+func (e *Executor) lookupStepCache(step *Step, material stepCacheMaterial) (*StepCacheInfo, *stepCacheEntry, error) {
+	key, err := e.stepCacheKey(step, material)
+	if err != nil {
+		return nil, nil, err
+	}
+	info := &StepCacheInfo{Key: key}
+	if e.config.NoCache {
+		info.Bypassed = true
+		return info, nil, nil
+	}
+	workDir, err := e.workDir()
+	if err != nil {
+		return nil, nil, fmt.Errorf("resolve working directory: %w", err)
+	}
+
+	entry, err := loadStepCacheEntry(workDir, key)
+	if err != nil {
+		slog.Warn("pipeline.cache.unreadable", "step_id", step.ID, "key", key, "error", err)
+		return info, nil, nil
+	}
+	if entry == nil {
+		return info, nil, nil
+	}
+	if !entry.ExpiresAt.IsZero() && time.Now().After(entry.ExpiresAt) {
+		_ = os.RemoveAll(filepath.Join(StepCacheDir(workDir), key))
+		return info, nil, nil
+	}
+
+	records, err := e.restoreCachedArtifacts(workDir, step, entry.Artifacts)
+	if err != nil {
+		// A damaged entry is a miss; the fresh result will replace it.
+		slog.Warn("pipeline.cache.restore_failed", "step_id", step.ID, "key", key, "error", err)
+		return info, nil, nil
+	}
+	entry.Artifacts = records
+
+	info.Hit = true
+	info.SourceRunID = entry.RunID
+	info.StoredAt = entry.StoredAt
+	slog.Info("pipeline.cache.hit",
+		"run_id", e.runIDForLog(),
+		"step_id", step.ID,
+		"key", key,
+		"source_run_id", entry.RunID,
+	)
+	return info, entry, nil
+}
+
+func loadStepCacheEntry(workDir, key string) (*stepCacheEntry, error) {
+	data, err := os.ReadFile(filepath.Join(StepCacheDir(workDir), key, "entry.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read cache entry: %w", err)
+	}
+	var entry stepCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("parse cache entry: %w", err)
+	}
+	if entry.Key != key {
+		return nil, fmt.Errorf("cache entry key mismatch")
+	}
+	return &entry, nil
+}
+
+// restoreCachedArtifacts copies cached artifact files into this run's
+// artifact store for step, verifying each file's recorded hash.
+func (e *Executor) restoreCachedArtifacts(workDir string, step *Step, cached []ArtifactRecord) ([]ArtifactRecord, error) {
+	if len(cached) == 0 {
+		return nil, nil
+	}
+	store := ArtifactStoreDir(workDir, e.runIDForLog())
+	records := make([]ArtifactRecord, 0, len(cached))
+	for _, src := range cached {
+		rec := ArtifactRecord{Name: src.Name, Dir: filepath.Join(store, step.ID, src.Name)}
+		if err := os.RemoveAll(rec.Dir); err != nil {
+			return nil, fmt.Errorf("artifact %s: clear previous copy: %w", src.Name, err)
+		}
+		for _, f := range src.Files {
+			rel := filepath.FromSlash(f.Path)
+			if !filepath.IsLocal(rel) {
+				return nil, fmt.Errorf("artifact %s: cached path %q escapes the store", src.Name, f.Path)
+			}
+			got, err := archiveArtifactFile(filepath.Join(src.Dir, rel), filepath.Join(rec.Dir, rel))
+			if err != nil {
+				return nil, fmt.Errorf("artifact %s: %w", src.Name, err)
+			}
+			if got.SHA256 != f.SHA256 {
+				return nil, fmt.Errorf("artifact %s: cached %s is corrupt", src.Name, f.Path)
+			}
+			got.Path = f.Path
+			rec.Files = append(rec.Files, got)
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+// storeStepCache saves a freshly completed result under its cache key,
+// copying its archived artifacts alongside. Failures are logged rather
+// than failing the step: the cache only ever saves work.
+func (e *Executor) storeStepCache(step *Step, result StepResult) {
+	if result.Cache == nil || result.Cache.Hit || result.Status != StatusCompleted || e.config.DryRun {
+		return
+	}
+	if err := e.writeStepCacheEntry(step, result); err != nil {
+		slog.Warn("pipeline.cache.store_failed",
+			"run_id", e.runIDForLog(),
+			"step_id", step.ID,
+			"key", result.Cache.Key,
+			"error", err,
+		)
+	}
+}
+
+func (e *Executor) writeStepCacheEntry(step *Step, result StepResult) error {
+	workDir, err := e.workDir()
+	if err != nil {
+		return fmt.Errorf("resolve working directory: %w", err)
+	}
+	key := result.Cache.Key
+	dir := filepath.Join(StepCacheDir(workDir), key)
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("clear cache entry: %w", err)
+	}
+
+	entry := stepCacheEntry{
+		Key:      key,
+		StepID:   step.ID,
+		RunID:    e.runIDForLog(),
+		Output:   result.Output,
+		StoredAt: time.Now().UTC(),
+	}
+	if ttl := step.Cache.TTL.Duration; ttl > 0 {
+		entry.ExpiresAt = entry.StoredAt.Add(ttl)
+	}
+	for _, src := range result.Artifacts {
+		rec := ArtifactRecord{Name: src.Name, Dir: filepath.Join(dir, "artifacts", src.Name)}
+		for _, f := range src.Files {
+			rel := filepath.FromSlash(f.Path)
+			got, err := archiveArtifactFile(filepath.Join(src.Dir, rel), filepath.Join(rec.Dir, rel))
+			if err != nil {
+				return fmt.Errorf("artifact %s: %w", src.Name, err)
+			}
+			got.Path = f.Path
+			rec.Files = append(rec.Files, got)
+		}
+		entry.Artifacts = append(entry.Artifacts, rec)
+	}
+
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode cache entry: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("create cache entry dir: %w", err)
+	}
+	if err := util.AtomicWriteFile(filepath.Join(dir, "entry.json"), data, 0644); err != nil {
+		return fmt.Errorf("write cache entry: %w", err)
+	}
+	slog.Info("pipeline.cache.stored",
+		"run_id", entry.RunID,
+		"step_id", step.ID,
+		"key", key,
+		"artifacts", len(entry.Artifacts),
+	)
+	return nil
+}
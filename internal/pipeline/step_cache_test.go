@@ -0,0 +1,234 @@
+package pipeline
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func countLinesIn(t *testing.T, path string) int {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(strings.Fields(string(data)))
+}
+
+func TestStepCacheReusesOutputAndArtifactsAcrossRuns(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "input.txt"), []byte("alpha"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	path := writeWorkflowFile(t, dir, "build.yaml", `
+schema_version: "2.0"
+name: build
+steps:
+  - id: build
+    command: "echo run >> runs.log && tr a-z A-Z < input.txt | tee report.txt"
+    cache:
+      inputs: [input.txt]
+    artifacts:
+      - {name: report, path: report.txt}
+  - id: use
+    depends_on: [build]
+    command: "cat ${artifacts.build.report}"
+`)
+
+	first, err := runWorkflowFile(t, path, false)
+	if err != nil || first.Status != StatusCompleted {
+		t.Fatalf("first run = %v, %v", first.Status, err)
+	}
+	if c := first.Steps["build"].Cache; c == nil || c.Hit || c.Key == "" {
+		t.Fatalf("first run cache = %+v, want miss with key", c)
+	}
+
+	second, err := runWorkflowFile(t, path, false)
+	if err != nil || second.Status != StatusCompleted {
+		t.Fatalf("second run = %v, %v", second.Status, err)
+	}
+	build := second.Steps["build"]
+	if !build.Cache.IsHit() || build.Cache.SourceRunID != first.RunID || build.Cache.Key != first.Steps["build"].Cache.Key {
+		t.Fatalf("second run cache = %+v, want hit from %s", build.Cache, first.RunID)
+	}
+	if build.Output != "ALPHA" || second.Steps["use"].Output != "ALPHA" {
+		t.Fatalf("cached output = %q, downstream = %q", build.Output, second.Steps["use"].Output)
+	}
+	if len(build.Artifacts) != 1 || !strings.HasPrefix(build.Artifacts[0].Dir, ArtifactStoreDir(dir, second.RunID)) {
+		t.Fatalf("restored artifacts = %+v", build.Artifacts)
+	}
+	if n := countLinesIn(t, filepath.Join(dir, "runs.log")); n != 1 {
+		t.Fatalf("command ran %d times, want 1", n)
+	}
+	if p := calculateProgress(second); p.CacheHits != 1 {
+		t.Fatalf("progress cache hits = %d", p.CacheHits)
+	}
+
+	// Changing a declared input changes the key.
+	if err := os.WriteFile(filepath.Join(dir, "input.txt"), []byte("beta"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	third, _ := runWorkflowFile(t, path, false)
+	if third.Steps["build"].Cache.IsHit() || third.Steps["build"].Output != "BETA" {
+		t.Fatalf("third run = %+v", third.Steps["build"])
+	}
+	if n := countLinesIn(t, filepath.Join(dir, "runs.log")); n != 2 {
+		t.Fatalf("command ran %d times, want 2", n)
+	}
+}
+
+func TestStepCacheNoCacheAndTTL(t *testing.T) {
+	dir := t.TempDir()
+	path := writeWorkflowFile(t, dir, "w.yaml", `
+schema_version: "2.0"
+name: w
+steps:
+  - id: stamp
+    command: "echo run >> runs.log && echo ${vars.model}"
+    cache:
+      key: "${vars.model}"
+      ttl: 200ms
+`)
+	workflow, _, err := LoadAndValidate(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := func(model string, noCache bool) StepResult {
+		t.Helper()
+		cfg := DefaultExecutorConfig("cache-session")
+		cfg.ProjectDir = dir
+		cfg.DefaultTimeout = 5 * time.Second
+		cfg.NoCache = noCache
+		state, err := NewExecutor(cfg).Run(context.Background(), workflow, map[string]interface{}{"model": model}, nil)
+		if err != nil || state.Status != StatusCompleted {
+			t.Fatalf("run = %v, %v", state.Status, err)
+		}
+		return state.Steps["stamp"]
+	}
+
+	run("fast", false)
+	if r := run("fast", false); !r.Cache.IsHit() {
+		t.Fatalf("same key = %+v, want hit", r.Cache)
+	}
+	if r := run("smart", false); r.Cache.IsHit() || r.Output != "smart" {
+		t.Fatalf("different key expression = %+v", r)
+	}
+	if r := run("fast", true); r.Cache.IsHit() || !r.Cache.Bypassed {
+		t.Fatalf("--no-cache = %+v", r.Cache)
+	}
+	time.Sleep(250 * time.Millisecond)
+	if r := run("fast", false); r.Cache.IsHit() {
+		t.Fatalf("expired entry = %+v, want miss", r.Cache)
+	}
+	if n := countLinesIn(t, filepath.Join(dir, "runs.log")); n != 4 {
+		t.Fatalf("command ran %d times, want 4", n)
+	}
+}
+
+func TestStepCacheBeadQuery(t *testing.T) {
+	dir := t.TempDir()
+	workflow := &Workflow{
+		SchemaVersion: SchemaVersion,
+		Name:          "beads",
+		Steps: []Step{{
+			ID:        "collect",
+			BeadQuery: &BeadQueryStep{Label: StringOrList{"hypothesis"}},
+			Cache:     &StepCacheConfig{Enabled: true},
+		}},
+	}
+	calls := 0
+	run := func() StepResult {
+		t.Helper()
+		cfg := DefaultExecutorConfig("session")
+		cfg.ProjectDir = dir
+		cfg.BeadQueryRunBr = func(ctx context.Context, args []string) ([]byte, error) {
+			calls++
+			return []byte(`{"issues":[{"id":"bd-1","title":"One","labels":["hypothesis"],"status":"open"}]}`), nil
+		}
+		state, err := NewExecutor(cfg).Run(context.Background(), workflow, nil, nil)
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		return state.Steps["collect"]
+	}
+
+	run()
+	second := run()
+	records, ok := second.ParsedData.([]BeadRecord)
+	if !second.Cache.IsHit() || !ok || len(records) != 1 || records[0].ID != "bd-1" {
+		t.Fatalf("cached bead query = %+v (%T)", second, second.ParsedData)
+	}
+	if calls != 1 {
+		t.Fatalf("br called %d times, want 1", calls)
+	}
+}
+
+func TestValidateStepCache(t *testing.T) {
+	for name, tc := range map[string]struct {
+		step string
+		want string
+	}{
+		"prompt step":    {"prompt: hi\n    cache: true", "only supported on command and bead_query"},
+		"wait none":      {"command: 'true'\n    wait: none\n    cache: true", "wait: none"},
+		"absolute input": {"command: 'true'\n    cache: {inputs: [/etc/passwd]}", "must be relative"},
+		"parent input":   {"command: 'true'\n    cache: {inputs: [../x]}", "must not contain .."},
+	} {
+		wf, err := ParseString("schema_version: \"2.0\"\nname: v\nsteps:\n  - id: s\n    "+tc.step+"\n", "yaml")
+		if err != nil {
+			t.Fatalf("%s: parse: %v", name, err)
+		}
+		result := Validate(wf)
+		found := false
+		for _, e := range result.Errors {
+			found = found || strings.Contains(e.Message, tc.want)
+		}
+		if result.Valid || !found {
+			t.Errorf("%s: errors = %+v, want %q", name, result.Errors, tc.want)
+		}
+	}
+
+	// cache: false is accepted anywhere and disables caching.
+	wf, err := ParseString("schema_version: \"2.0\"\nname: v\nsteps:\n  - id: s\n    prompt: hi\n    cache: false\n", "yaml")
+	if err != nil || !Validate(wf).Valid || wf.Steps[0].Cache.enabled() {
+		t.Fatalf("cache: false = %+v, %v", wf.Steps[0].Cache, err)
+	}
+}
+
+func TestStepCacheParsesFromTOML(t *testing.T) {
+	wf, err := ParseString(`
+schema_version = "2.0"
+name = "c"
+
+[[steps]]
+id = "a"
+command = "true"
+cache = true
+
+[[steps]]
+id = "b"
+command = "true"
+
+[steps.cache]
+key = "${vars.x}"
+inputs = ["go.sum"]
+ttl = "1h"
+`, "toml")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if !wf.Steps[0].Cache.enabled() {
+		t.Fatalf("bool form = %+v", wf.Steps[0].Cache)
+	}
+	c := wf.Steps[1].Cache
+	if !c.enabled() || c.Key != "${vars.x}" || len(c.Inputs) != 1 || c.TTL.Duration != time.Hour {
+		t.Fatalf("table form = %+v", c)
+	}
+
+	_, err = ParseString("schema_version = \"2.0\"\nname = \"c\"\n\n[[steps]]\nid = \"a\"\ncommand = \"true\"\n\n[steps.cache]\nttl = \"1h\"\nbogus = 1\n", "toml")
+	if err == nil || !strings.Contains(err.Error(), "bogus") {
+		t.Fatalf("unknown cache field error = %v", err)
+	}
+}
@@ -0,0 +1,499 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SubWorkflowStep invokes another workflow file as the body of a step
+// (`workflow:` or its alias `call:`). Inputs bind the callee's declared vars
+// from the caller's variables; the callee's named Outputs come back as the
+// step's parsed data, so `output_var: review` exposes them as
+// ${vars.review_parsed.<name>} and ${steps.<id>.data.<name>}.
+//
+// The short form `workflow: shared/review.yaml` is accepted for callees that
+// take no inputs.
+type SubWorkflowStep struct {
+	File   string                 `yaml:"file" toml:"file" json:"file"`
+	Inputs map[string]interface{} `yaml:"inputs,omitempty" toml:"inputs,omitempty" json:"inputs,omitempty"`
+}
+
+// UnmarshalYAML accepts a bare file path or the structured form.
+func (s *SubWorkflowStep) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var file string
+	if err := unmarshal(&file); err == nil {
+		*s = SubWorkflowStep{File: file}
+		return nil
+	}
+	type raw SubWorkflowStep
+	var obj raw
+	if err := unmarshal(&obj); err != nil {
+		return fmt.Errorf("workflow: must be a file path or {file, inputs}: %w", err)
+	}
+	*s = SubWorkflowStep(obj)
+	return nil
+}
+
+// UnmarshalJSON accepts a bare file path or the structured form.
+func (s *SubWorkflowStep) UnmarshalJSON(data []byte) error {
+	if isJSONNull(data) {
+		*s = SubWorkflowStep{}
+		return nil
+	}
+	var file string
+	if err := json.Unmarshal(data, &file); err == nil {
+		*s = SubWorkflowStep{File: file}
+		return nil
+	}
+	type raw SubWorkflowStep
+	var obj raw
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return fmt.Errorf("workflow: must be a file path or {file, inputs}: %w", err)
+	}
+	*s = SubWorkflowStep(obj)
+	return nil
+}
+
+// UnmarshalTOML accepts a bare file path or the structured table.
+func (s *SubWorkflowStep) UnmarshalTOML(data any) error {
+	if file, ok := data.(string); ok {
+		*s = SubWorkflowStep{File: file}
+		return nil
+	}
+	type raw SubWorkflowStep
+	var obj raw
+	if err := decodeTOMLValue(data, &obj); err != nil {
+		return fmt.Errorf("workflow: must be a file path or {file, inputs}: %w", err)
+	}
+	*s = SubWorkflowStep(obj)
+	return nil
+}
+
+// maxSubWorkflowDepth caps nested workflow calls. Cycles are rejected
+// outright; the depth cap bounds legitimate-but-runaway nesting.
+const maxSubWorkflowDepth = 8
+
+type subWorkflowStackKey struct{}
+
+// subWorkflowStack returns the chain of workflow files currently executing,
+// outermost first.
+func subWorkflowStack(ctx context.Context) []string {
+	if ctx == nil {
+		return nil
+	}
+	stack, _ := ctx.Value(subWorkflowStackKey{}).([]string)
+	return stack
+}
+
+func withSubWorkflowStack(ctx context.Context, stack []string) context.Context {
+	return context.WithValue(ctx, subWorkflowStackKey{}, stack)
+}
+
+// subWorkflowRunID derives the callee's run ID from the caller's so a resumed
+// caller finds the callee's persisted ExecutionState again.
+func subWorkflowRunID(parentRunID, stepID string) string {
+	return parentRunID + "--" + sanitizeDispatchLogStepID(stepID)
+}
+
+// checkSubWorkflowCall rejects a call to path that would recurse into a
+// workflow already on the stack or exceed maxSubWorkflowDepth.
+func checkSubWorkflowCall(stack []string, path string) error {
+	for i, active := range stack {
+		if active == path {
+			chain := append(append([]string{}, stack[i:]...), path)
+			for j := range chain {
+				chain[j] = filepath.Base(chain[j])
+			}
+			return fmt.Errorf("workflow call cycle: %s", strings.Join(chain, " → "))
+		}
+	}
+	if len(stack) >= maxSubWorkflowDepth {
+		return fmt.Errorf("workflow call depth exceeds %d", maxSubWorkflowDepth)
+	}
+	return nil
+}
+
+// resolveSubWorkflowPath finds the callee relative to the calling workflow
+// file, then the project dir — the same lookup order as templates.
+func (e *Executor) resolveSubWorkflowPath(file string) (string, error) {
+	resolved := e.resolveTemplatePath(file)
+	if resolved == "" {
+		return "", fmt.Errorf("workflow file %q not found", file)
+	}
+	abs, err := filepath.Abs(resolved)
+	if err != nil {
+		return "", fmt.Errorf("resolve workflow file %q: %w", file, err)
+	}
+	return abs, nil
+}
+
+func (e *Executor) executeSubWorkflow(ctx context.Context, step *Step, workflow *Workflow) StepResult {
+	result := StepResult{
+		StepID:    step.ID,
+		Status:    StatusRunning,
+		StartedAt: time.Now(),
+		AgentType: "workflow",
+	}
+	fail := func(reason, hint string, err error) StepResult {
+		details := ""
+		if err != nil {
+			details = err.Error()
+		}
+		result.Status = StatusFailed
+		result.Error = stepRuntimeError(step, "workflow", "workflow", reason, hint, details)
+		result.FinishedAt = time.Now()
+		return result
+	}
+
+	file, err := e.substituteVariablesStrictCtx(ctx, step.Workflow.File)
+	if err != nil {
+		return fail(fmt.Sprintf("variable substitution failed in workflow file: %v", err),
+			"reference declared workflow vars or provide a | fallback for optional values", err)
+	}
+	path, err := e.resolveSubWorkflowPath(file)
+	if err != nil {
+		return fail(err.Error(), "paths resolve relative to the calling workflow file, then the project dir", err)
+	}
+
+	stack := subWorkflowStack(ctx)
+	if len(stack) == 0 && e.config.WorkflowFile != "" {
+		if self, absErr := filepath.Abs(e.config.WorkflowFile); absErr == nil {
+			stack = []string{self}
+		}
+	}
+	if err := checkSubWorkflowCall(stack, path); err != nil {
+		return fail(err.Error(), "break the cycle or flatten the nested calls", err)
+	}
+
+	callee, validation, err := LoadAndValidate(path)
+	if err != nil {
+		return fail(fmt.Sprintf("failed to load workflow %s: %v", file, err), "fix the callee's syntax", err)
+	}
+	if !validation.Valid {
+		msg := "invalid"
+		if len(validation.Errors) > 0 {
+			msg = validation.Errors[0].Error()
+		}
+		return fail(fmt.Sprintf("workflow %s is invalid: %s", file, msg), "run `ntm pipeline validate` on the callee", nil)
+	}
+
+	inputs, err := e.resolveSubWorkflowInputs(ctx, step.Workflow.Inputs)
+	if err != nil {
+		return fail(fmt.Sprintf("failed to bind inputs: %v", err),
+			"inputs may reference the caller's vars, defaults and step outputs", err)
+	}
+
+	e.stateMu.RLock()
+	parentRunID := e.state.RunID
+	e.stateMu.RUnlock()
+
+	cfg := e.config
+	cfg.WorkflowFile = path
+	cfg.RunID = subWorkflowRunID(parentRunID, step.ID)
+	cfg.StartFromStep = ""
+	cfg.StartFromState = nil
+	cfg.ResumeOptions = ResumeOptions{}
+	if step.Timeout.Duration > 0 {
+		cfg.GlobalTimeout = step.Timeout.Duration
+	}
+	child := NewExecutor(cfg)
+	child.SetTmuxClient(e.tmuxClient())
+
+	childCtx := withSubWorkflowStack(ctx, append(append([]string{}, stack...), path))
+
+	slog.Info("workflow step starting",
+		"run_id", parentRunID,
+		"workflow", workflow.Name,
+		"step_id", step.ID,
+		"callee", callee.Name,
+		"callee_run_id", cfg.RunID,
+	)
+
+	var state *ExecutionState
+	if prior := e.priorSubWorkflowState(cfg.RunID, callee); prior != nil {
+		state, err = child.Resume(childCtx, callee, prior, nil)
+	} else {
+		state, err = child.Run(childCtx, callee, inputs, nil)
+	}
+	if state == nil {
+		return fail(fmt.Sprintf("workflow %s did not run: %v", file, err), "check the callee's inputs", err)
+	}
+
+	switch state.Status {
+	case StatusCompleted:
+	case StatusCancelled:
+		result.Status = StatusCancelled
+		result.SkipReason = fmt.Sprintf("workflow %s was cancelled", file)
+		result.SkipKind = SkipKindCancelled
+		result.FinishedAt = time.Now()
+		return result
+	default:
+		msg := string(state.Status)
+		if err != nil {
+			msg = err.Error()
+		} else if n := len(state.Errors); n > 0 {
+			msg = state.Errors[n-1].Message
+		}
+		return fail(fmt.Sprintf("workflow %s %s: %s", file, state.Status, msg),
+			fmt.Sprintf("inspect the callee run with `ntm pipeline status %s`", cfg.RunID), err)
+	}
+
+	if e.config.DryRun {
+		result.Status = StatusCompleted
+		result.Output = dryRunOutput(step, fmt.Sprintf("Would run workflow %s (run %s)", SanitizeDescriptionForTerminal(file), cfg.RunID)) +
+			subWorkflowDryRunLines(callee, state)
+		result.FinishedAt = time.Now()
+		return result
+	}
+
+	outputs, err := child.collectSubWorkflowOutputs(callee)
+	if err != nil {
+		return fail(fmt.Sprintf("workflow %s outputs: %v", file, err), "fix the callee's outputs declarations", err)
+	}
+	data, err := json.Marshal(outputs)
+	if err != nil {
+		return fail(fmt.Sprintf("failed to encode outputs: %v", err), "outputs must be JSON-encodable", err)
+	}
+
+	result.Output = string(data)
+	result.ParsedData = outputs
+	result.Status = StatusCompleted
+	result.FinishedAt = time.Now()
+
+	slog.Info("workflow step completed",
+		"run_id", parentRunID,
+		"workflow", workflow.Name,
+		"step_id", step.ID,
+		"callee_run_id", cfg.RunID,
+		"outputs", len(outputs),
+	)
+	return result
+}
+
+// priorSubWorkflowState returns the callee's persisted state when the caller
+// is resuming and the callee run had started but not completed; the callee is
+// then resumed rather than restarted so its completed steps are not repeated.
+func (e *Executor) priorSubWorkflowState(runID string, callee *Workflow) *ExecutionState {
+	if !e.resuming || e.config.DryRun || e.config.ResumeOptions.Reset {
+		return nil
+	}
+	projectDir := e.config.ProjectDir
+	if projectDir == "" {
+		projectDir, _ = os.Getwd()
+	}
+	prior, err := LoadState(projectDir, runID)
+	if err != nil || prior == nil {
+		return nil
+	}
+	if prior.WorkflowID != "" && prior.WorkflowID != callee.Name {
+		return nil
+	}
+	// A completed callee (the caller crashed before recording the step)
+	// resumes as a no-op that just re-derives the outputs.
+	return prior
+}
+
+// resolveSubWorkflowInputs substitutes the caller's variables into each
+// input. A string that is exactly one ${ref} keeps the referenced value's
+// type, so arrays and numbers reach typed callee vars intact.
+func (e *Executor) resolveSubWorkflowInputs(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+	resolved := make(map[string]interface{}, len(inputs))
+	for name, value := range inputs {
+		s, ok := value.(string)
+		if !ok {
+			resolved[name] = value
+			continue
+		}
+		v, err := e.resolveTypedValueCtx(ctx, s)
+		if err != nil {
+			return nil, fmt.Errorf("input %s: %w", name, err)
+		}
+		resolved[name] = v
+	}
+	return resolved, nil
+}
+
+func (e *Executor) resolveTypedValueCtx(ctx context.Context, s string) (interface{}, error) {
+	trimmed := strings.TrimSpace(s)
+	if match := varPattern.FindStringSubmatch(trimmed); len(match) == 2 && match[0] == trimmed {
+		e.stateMu.RLock()
+		e.varMu.RLock()
+		sub := NewSubstitutor(e.state, e.config.Session, e.state.WorkflowID)
+		sub.SetDefaults(e.defaults)
+		if overrides := roundOverridesFromCtx(ctx); overrides != nil {
+			sub.SetLocalOverrides(overrides)
+		}
+		value, err := sub.resolveVar(match[1])
+		e.varMu.RUnlock()
+		e.stateMu.RUnlock()
+		if err == nil {
+			return value, nil
+		}
+	}
+	return e.substituteVariablesStrictCtx(ctx, s)
+}
+
+// collectSubWorkflowOutputs evaluates the callee's named output declarations
+// against its final state. An output's Value expression wins; otherwise a
+// variable of the same name, then the substituted Path. A declared Type is
+// enforced with the same rules as typed vars.
+func (e *Executor) collectSubWorkflowOutputs(callee *Workflow) (map[string]interface{}, error) {
+	outputs := make(map[string]interface{})
+	for _, decl := range callee.Outputs {
+		if decl.Name == "" {
+			continue
+		}
+		var (
+			value interface{}
+			found bool
+		)
+		switch {
+		case decl.Value != "":
+			v, err := e.resolveTypedValueCtx(context.Background(), decl.Value)
+			if err != nil {
+				return nil, fmt.Errorf("output %s: %w", decl.Name, err)
+			}
+			value, found = v, true
+		default:
+			e.varMu.RLock()
+			v, ok := e.state.Variables[decl.Name]
+			e.varMu.RUnlock()
+			if ok {
+				value, found = v, true
+			} else if decl.Path != "" {
+				value, found = e.substituteVariables(decl.Path), true
+			}
+		}
+		if !found {
+			if decl.Type != "" {
+				return nil, fmt.Errorf("output %s: declared %s but produced no value", decl.Name, decl.Type)
+			}
+			continue
+		}
+		typed, err := normalizeWorkflowVar(decl.Name, decl.Type, value)
+		if err != nil {
+			return nil, fmt.Errorf("output %w", err)
+		}
+		outputs[decl.Name] = typed
+	}
+	return outputs, nil
+}
+
+// subWorkflowDryRunLines renders the callee's dry-run results beneath the
+// calling step, indented, in declaration order.
+func subWorkflowDryRunLines(callee *Workflow, state *ExecutionState) string {
+	var b strings.Builder
+	for _, s := range callee.Steps {
+		res, ok := state.Steps[s.ID]
+		if !ok {
+			continue
+		}
+		text := res.Output
+		if text == "" {
+			text = fmt.Sprintf("▶ [%s] %s", s.ID, res.Status)
+		}
+		for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+			b.WriteString("\n    ")
+			b.WriteString(line)
+		}
+	}
+	return b.String()
+}
+
+// validateSubWorkflowRefs statically follows literal workflow: references
+// from the file at path, reporting missing callees, call cycles and nesting
+// beyond maxSubWorkflowDepth. References containing ${...} are resolved at
+// runtime and skipped here.
+func validateSubWorkflowRefs(path string, w *Workflow, result *ValidationResult) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return
+	}
+	seen := make(map[string]bool)
+	var walk func(file string, wf *Workflow, stack []string)
+	walk = func(file string, wf *Workflow, stack []string) {
+		stack = append(stack, file)
+		for _, ref := range subWorkflowRefs(wf) {
+			if strings.Contains(ref.file, "${") {
+				continue
+			}
+			target := ref.file
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(filepath.Dir(file), target)
+			}
+			field := fmt.Sprintf("steps[%s].workflow", ref.stepID)
+			if err := checkSubWorkflowCall(stack, target); err != nil {
+				result.addError(ParseError{
+					Field:   field,
+					Message: err.Error(),
+					Hint:    "A workflow must not call itself directly or through other workflows",
+				})
+				continue
+			}
+			if seen[target] {
+				continue
+			}
+			callee, err := ParseFile(target)
+			if err != nil {
+				if _, statErr := os.Stat(target); statErr != nil {
+					// May still resolve against the project dir at runtime.
+					result.addWarning(ParseError{
+						Field:   field,
+						Message: fmt.Sprintf("workflow file %q not found next to %s", ref.file, filepath.Base(file)),
+						Hint:    "Paths resolve relative to the calling workflow file, then the project dir",
+					})
+					continue
+				}
+				result.addError(ParseError{
+					Field:   field,
+					Message: fmt.Sprintf("workflow %q failed to parse: %v", ref.file, err),
+				})
+				continue
+			}
+			seen[target] = true
+			walk(target, callee, stack)
+		}
+	}
+	walk(abs, w, nil)
+}
+
+type subWorkflowRef struct {
+	stepID string
+	file   string
+}
+
+// subWorkflowRefs lists every workflow: reference in w, including those
+// nested in parallel, loop, foreach and on_success bodies.
+func subWorkflowRefs(w *Workflow) []subWorkflowRef {
+	var refs []subWorkflowRef
+	var visit func(steps []Step)
+	visit = func(steps []Step) {
+		for i := range steps {
+			s := &steps[i]
+			if s.Workflow != nil && s.Workflow.File != "" {
+				refs = append(refs, subWorkflowRef{stepID: s.ID, file: s.Workflow.File})
+			}
+			visit(s.Parallel.Steps)
+			visit(s.OnSuccess)
+			if s.Loop != nil {
+				visit(s.Loop.Steps)
+			}
+			for _, fc := range []*ForeachConfig{s.Foreach, s.ForeachPane} {
+				if fc != nil {
+					visit(fc.Steps)
+				}
+			}
+		}
+	}
+	visit(w.Steps)
+	visit(w.PostPipelineSteps)
+	sort.SliceStable(refs, func(i, j int) bool { return refs[i].stepID < refs[j].stepID })
+	return refs
+}
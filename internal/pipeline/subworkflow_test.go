@@ -0,0 +1,280 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeWorkflowFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	return path
+}
+
+func runWorkflowFile(t *testing.T, path string, dryRun bool) (*ExecutionState, error) {
+	t.Helper()
+	workflow, validation, err := LoadAndValidate(path)
+	if err != nil {
+		t.Fatalf("LoadAndValidate(%s): %v", path, err)
+	}
+	if !validation.Valid {
+		t.Fatalf("LoadAndValidate(%s) invalid: %+v", path, validation.Errors)
+	}
+	cfg := DefaultExecutorConfig("subwf-session")
+	cfg.ProjectDir = filepath.Dir(path)
+	cfg.WorkflowFile = path
+	cfg.DefaultTimeout = 5 * time.Second
+	cfg.DryRun = dryRun
+	return NewExecutor(cfg).Run(context.Background(), workflow, nil, nil)
+}
+
+func TestSubWorkflowStepBindsTypedInputsAndOutputs(t *testing.T) {
+	dir := t.TempDir()
+	marker := filepath.Join(dir, "marker.txt")
+	writeWorkflowFile(t, dir, "review.yaml", `
+schema_version: "2.0"
+name: review
+vars:
+  target: {type: string, required: true}
+  rounds: {type: number, required: true}
+outputs:
+  - {name: summary, value: "${steps.check.output}"}
+  - {name: rounds, value: "${vars.rounds}", type: number}
+steps:
+  - id: check
+    command: printf 'reviewed %s' "${vars.target}"
+`)
+	parent := writeWorkflowFile(t, dir, "main.yaml", `
+schema_version: "2.0"
+name: main
+vars:
+  module: {default: parser}
+steps:
+  - id: review
+    call:
+      file: review.yaml
+      inputs:
+        target: ${vars.module}
+        rounds: 2
+    output_var: review
+  - id: report
+    depends_on: [review]
+    command: printf '%s/%s' "${vars.review_parsed.summary}" "${steps.review.data.rounds}" > `+strconv.Quote(marker)+`
+`)
+
+	state, err := runWorkflowFile(t, parent, false)
+	if err != nil || state.Status != StatusCompleted {
+		t.Fatalf("Run = %s, %v (%+v)", state.Status, err, state.Steps)
+	}
+	got, err := os.ReadFile(marker)
+	if err != nil || string(got) != "reviewed parser/2" {
+		t.Fatalf("marker = %q, %v", got, err)
+	}
+	data, ok := state.Steps["review"].ParsedData.(map[string]interface{})
+	_, roundsIsString := data["rounds"].(string)
+	if !ok || roundsIsString || fmt.Sprint(data["rounds"]) != "2" || data["summary"] != "reviewed parser" {
+		t.Fatalf("review outputs = %#v, want summary and typed rounds", state.Steps["review"].ParsedData)
+	}
+
+	child, err := LoadState(dir, subWorkflowRunID(state.RunID, "review"))
+	if err != nil || child.WorkflowID != "review" || child.Status != StatusCompleted {
+		t.Fatalf("callee state = %+v, %v", child, err)
+	}
+}
+
+func TestSubWorkflowStepRejectsBadInputType(t *testing.T) {
+	dir := t.TempDir()
+	writeWorkflowFile(t, dir, "callee.yaml", `
+schema_version: "2.0"
+name: callee
+vars:
+  n: {type: number, required: true}
+steps:
+  - id: noop
+    command: "true"
+`)
+	parent := writeWorkflowFile(t, dir, "main.yaml", `
+schema_version: "2.0"
+name: main
+steps:
+  - id: call
+    workflow: {file: callee.yaml, inputs: {n: "not-a-number"}}
+`)
+	state, _ := runWorkflowFile(t, parent, false)
+	res := state.Steps["call"]
+	if res.Status != StatusFailed || res.Error == nil || !strings.Contains(res.Error.Message, "expected number") {
+		t.Fatalf("call step = %+v, want typed input failure", res)
+	}
+}
+
+func TestSubWorkflowCycleIsRejected(t *testing.T) {
+	dir := t.TempDir()
+	a := writeWorkflowFile(t, dir, "a.yaml", `
+schema_version: "2.0"
+name: a
+steps:
+  - id: to_b
+    workflow: b.yaml
+`)
+	writeWorkflowFile(t, dir, "b.yaml", `
+schema_version: "2.0"
+name: b
+steps:
+  - id: to_a
+    workflow: a.yaml
+`)
+
+	_, validation, err := LoadAndValidate(a)
+	if err != nil {
+		t.Fatalf("LoadAndValidate: %v", err)
+	}
+	if validation.Valid || len(validation.Errors) == 0 || !strings.Contains(validation.Errors[0].Message, "a.yaml → b.yaml → a.yaml") {
+		t.Fatalf("validation = %+v, want static cycle error", validation.Errors)
+	}
+
+	// The runtime guard holds even when static validation is bypassed.
+	workflow, err := ParseFile(a)
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+	cfg := DefaultExecutorConfig("subwf-session")
+	cfg.ProjectDir = dir
+	cfg.WorkflowFile = a
+	state, _ := NewExecutor(cfg).Run(context.Background(), workflow, nil, nil)
+	res := state.Steps["to_b"]
+	if res.Status != StatusFailed || res.Error == nil || !strings.Contains(res.Error.Message, "workflow call cycle") {
+		t.Fatalf("to_b = %+v, want cycle failure", res)
+	}
+}
+
+func TestSubWorkflowDryRunRendersCalleeSteps(t *testing.T) {
+	dir := t.TempDir()
+	writeWorkflowFile(t, dir, "callee.yaml", `
+schema_version: "2.0"
+name: callee
+steps:
+  - id: inner
+    command: echo should-not-run > `+strconv.Quote(filepath.Join(dir, "ran"))+`
+`)
+	parent := writeWorkflowFile(t, dir, "main.yaml", `
+schema_version: "2.0"
+name: main
+steps:
+  - id: outer
+    workflow: callee.yaml
+`)
+	state, err := runWorkflowFile(t, parent, true)
+	if err != nil || state.Status != StatusCompleted {
+		t.Fatalf("dry run = %s, %v", state.Status, err)
+	}
+	out := state.Steps["outer"].Output
+	if !strings.Contains(out, "Would run workflow callee.yaml") || !strings.Contains(out, "    ▶ [inner]") {
+		t.Fatalf("dry-run output = %q", out)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ran")); err == nil {
+		t.Fatal("dry run executed the callee's command")
+	}
+}
+
+// TestSubWorkflowResumeContinuesCallee proves a caller resumed mid-call
+// resumes the callee's own persisted run: the callee step that already
+// completed is not repeated.
+func TestSubWorkflowResumeContinuesCallee(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "calls.log")
+	writeWorkflowFile(t, dir, "callee.yaml", `
+schema_version: "2.0"
+name: callee
+steps:
+  - id: first
+    command: printf first >> `+strconv.Quote(logPath)+`
+  - id: second
+    depends_on: [first]
+    command: printf second >> `+strconv.Quote(logPath)+`
+`)
+	parentPath := writeWorkflowFile(t, dir, "main.yaml", `
+schema_version: "2.0"
+name: main
+steps:
+  - id: call
+    workflow: callee.yaml
+`)
+	workflow, err := ParseFile(parentPath)
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+
+	now := time.Now()
+	prior := &ExecutionState{
+		RunID:         "run-parent",
+		WorkflowID:    "main",
+		Session:       "subwf-session",
+		Status:        StatusRunning,
+		StartedAt:     now,
+		UpdatedAt:     now,
+		Steps:         map[string]StepResult{},
+		Variables:     map[string]interface{}{},
+		InFlightSteps: map[string]InFlightStepState{"call": {StepID: "call", Kind: StepKindWorkflow}},
+	}
+	if err := SaveState(dir, &ExecutionState{
+		RunID:      subWorkflowRunID(prior.RunID, "call"),
+		WorkflowID: "callee",
+		Session:    "subwf-session",
+		Status:     StatusRunning,
+		StartedAt:  now,
+		UpdatedAt:  now,
+		Steps:      map[string]StepResult{"first": {StepID: "first", Status: StatusCompleted}},
+		Variables:  map[string]interface{}{},
+	}); err != nil {
+		t.Fatalf("save callee state: %v", err)
+	}
+
+	cfg := DefaultExecutorConfig("subwf-session")
+	cfg.ProjectDir = dir
+	cfg.WorkflowFile = parentPath
+	final, err := NewExecutor(cfg).Resume(context.Background(), workflow, prior, nil)
+	if err != nil || final.Status != StatusCompleted {
+		t.Fatalf("Resume = %s, %v (%+v)", final.Status, err, final.Steps)
+	}
+	got, _ := os.ReadFile(logPath)
+	if string(got) != "second" {
+		t.Fatalf("callee log = %q, want only the unfinished step", got)
+	}
+}
+
+func TestValidateWorkflowStepShape(t *testing.T) {
+	w, err := ParseString(`
+schema_version: "2.0"
+name: shape
+steps:
+  - id: both
+    workflow: a.yaml
+    command: echo hi
+  - id: empty
+    call: {inputs: {x: 1}}
+`, "yaml")
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+	if w.Steps[1].Workflow == nil || w.Steps[1].Call != nil {
+		t.Fatalf("call alias not normalized: %+v", w.Steps[1])
+	}
+	result := Validate(w)
+	var msgs []string
+	for _, e := range result.Errors {
+		msgs = append(msgs, e.Message)
+	}
+	joined := strings.Join(msgs, "\n")
+	if !strings.Contains(joined, "cannot combine workflow") || !strings.Contains(joined, "requires a file") {
+		t.Fatalf("errors = %s", joined)
+	}
+}
@@ -0,0 +1,29 @@
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/Dicklesworthstone/ntm/tests/testutil"
+)
+
+// TestMain runs the suite from a private working directory: executors
+// without a ProjectDir persist run state under ./.ntm/pipelines, which must
+// never land in the package source tree.
+func TestMain(m *testing.M) {
+	cleanupCwd, err := testutil.IsolateWorkingDirProcess()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "isolate pipeline working dir: %v\n", err)
+		os.Exit(1)
+	}
+
+	code := m.Run()
+
+	if err := cleanupCwd(); err != nil {
+		fmt.Fprintf(os.Stderr, "clean up isolated pipeline working dir: %v\n", err)
+		code = 1
+	}
+
+	os.Exit(code)
+}
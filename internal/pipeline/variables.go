@@ -12,6 +12,8 @@ import (
 	"time"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/Dicklesworthstone/ntm/internal/turns"
 )
 
 // PrepareWorkflowVariables applies runtime overrides and workflow defaults,
@@ -524,7 +526,7 @@ func isTerminalSubstitutionNamespace(varPath string) bool {
 		root = path[:idx]
 	}
 	switch root {
-	case "steps", "env", "bead_query", "agent":
+	case "steps", "env", "bead_query", "agent", "artifacts":
 		return true
 	}
 	return false
@@ -597,6 +599,7 @@ func parseDefault(expr string) (varPath, defaultVal string, hasDefault bool) {
 //   - vars.name, vars.name.nested.field
 //   - steps.id.output, steps.id.data.field
 //   - steps.id.pane, steps.id.duration, steps.id.status, steps.id.agent
+//   - artifacts.step.name, artifacts.step.name.dir, artifacts.step.name.files
 //   - env.NAME
 //   - pane.role, pane.model, pane.domain, pane.index
 //   - session, timestamp, run_id, workflow
@@ -630,6 +633,8 @@ func (s *Substitutor) resolveVar(path string) (interface{}, error) {
 		return s.resolveVars(parts[1:])
 	case "steps":
 		return s.resolveSteps(parts[1:])
+	case "artifacts":
+		return s.resolveArtifacts(parts[1:])
 	case "env":
 		return s.resolveEnv(parts[1:])
 	case "loop":
@@ -695,7 +700,8 @@ func (s *Substitutor) resolveVars(parts []string) (interface{}, error) {
 	return value, nil
 }
 
-// resolveSteps handles steps.X.output, steps.X.data.field, steps.X.status, etc.
+// resolveSteps handles steps.X.output, steps.X.data.field, steps.X.json.field,
+// steps.X.status, etc.
 func (s *Substitutor) resolveSteps(parts []string) (interface{}, error) {
 	if len(parts) < 2 {
 		return nil, fmt.Errorf("steps requires step ID and field")
@@ -757,6 +763,21 @@ func (s *Substitutor) resolveSteps(parts []string) (interface{}, error) {
 			return navigateNested(result.ParsedData, rest)
 		}
 		return result.ParsedData, nil
+	case "json":
+		// json is the step's parsed data, or its output decoded as JSON
+		// when no output_parse ran.
+		data := result.ParsedData
+		if data == nil {
+			decoded, err := NewOutputParser().parseJSON(result.Output)
+			if err != nil {
+				return nil, fmt.Errorf("step %s output is not JSON: %w", stepID, err)
+			}
+			data = decoded
+		}
+		if len(rest) > 0 {
+			return navigateNested(data, rest)
+		}
+		return data, nil
 	case "pane":
 		return result.PaneUsed, nil
 	case "duration":
@@ -1030,11 +1051,42 @@ func (p *OutputParser) Parse(output string, config OutputParse) (interface{}, er
 	case "regex":
 		return p.parseRegex(output, config.Pattern)
 
+	case "turns":
+		return p.parseTurns(output, config.Agent)
+
 	default:
 		return nil, fmt.Errorf("unknown parse type: %s", config.Type)
 	}
 }
 
+// parseTurns segments agent output into turns. The result is decoded from
+// JSON so expressions navigate it like parsed json output, e.g.
+// ${vars.result.last.diff.text} or ${vars.result.turns[0].prompt}.
+func (p *OutputParser) parseTurns(output, agentType string) (map[string]interface{}, error) {
+	parsed := turns.Parse(agentType, output)
+	last := make(map[string]turns.Segment)
+	for kind, seg := range turns.LastByKind(parsed) {
+		last[string(kind)] = seg
+	}
+	if parsed == nil {
+		parsed = []turns.Turn{}
+	}
+	data, err := json.Marshal(map[string]interface{}{
+		"agent":  agentType,
+		"parser": turns.Dialect(agentType, output),
+		"turns":  parsed,
+		"last":   last,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
 // parseFirstLine extracts the first non-empty line from output.
 func (p *OutputParser) parseFirstLine(output string) (string, error) {
 	lines := strings.Split(output, "\n")
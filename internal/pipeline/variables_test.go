@@ -1438,6 +1438,29 @@ func TestOutputParser_ParseUnknownType(t *testing.T) {
 	}
 }
 
+func TestOutputParser_ParseTurns(t *testing.T) {
+	output := "⏺ Update(main.go)\n  ⎿  Updated main.go with 1 addition and 1 removal\n       3 -  a := 1\n       3 +  a := 2\n\n⏺ Done."
+	got, err := NewOutputParser().Parse(output, OutputParse{Type: "turns"}.forAgent("cc"))
+	if err != nil {
+		t.Fatalf("Parse(turns) error = %v", err)
+	}
+	m, ok := got.(map[string]interface{})
+	if !ok || m["parser"] != "cc" || m["agent"] != "cc" {
+		t.Fatalf("Parse(turns) = %#v", got)
+	}
+
+	state := &ExecutionState{Variables: map[string]interface{}{"edit": got}}
+	sub := NewSubstitutor(state, "sess", "wf")
+	file, err := sub.Substitute("${vars.edit.last.diff.file}")
+	if err != nil || file != "main.go" {
+		t.Errorf("last diff file = %q, %v", file, err)
+	}
+	text, err := sub.Substitute("${vars.edit.turns[0].segments[2].text}")
+	if err != nil || text != "Done." {
+		t.Errorf("segment text = %q, %v", text, err)
+	}
+}
+
 func TestOutputParser_ParseNone(t *testing.T) {
 
 	parser := NewOutputParser()
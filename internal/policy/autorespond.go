@@ -0,0 +1,150 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ResponseAction is what the gate auto-responder does with a blocking
+// prompt.
+type ResponseAction string
+
+const (
+	RespondApprove  ResponseAction = "approve"  // send the prompt's one-time accept keys
+	RespondDeny     ResponseAction = "deny"     // send the prompt's decline keys
+	RespondEscalate ResponseAction = "escalate" // file an approval request and wait for a human
+)
+
+// AutoRespondRule decides blocking prompts raised by agents (permission
+// prompts, trust dialogs, ...). Every non-empty selector must match; the
+// first matching rule in file order wins.
+type AutoRespondRule struct {
+	// Agents selects agent types by canonical name (cc, cod, gmi, ...).
+	Agents []string `yaml:"agents,omitempty"`
+	// Gates selects gate kinds: permission, trust, auth, onboarding.
+	Gates []string `yaml:"gates,omitempty"`
+	// Tools selects permission prompt tool categories: read, edit, bash,
+	// fetch, mcp, other.
+	Tools []string `yaml:"tools,omitempty"`
+	// Pattern is a regexp matched against the requested command or path.
+	Pattern string         `yaml:"pattern,omitempty"`
+	Action  ResponseAction `yaml:"action"`
+	Reason  string         `yaml:"reason,omitempty"`
+	// SLB requires two-person approval when the rule escalates.
+	SLB   bool `yaml:"slb,omitempty"`
+	regex *regexp.Regexp
+}
+
+// GateRequest describes one blocking prompt for DecideGate.
+type GateRequest struct {
+	AgentType string
+	Gate      string
+	Tool      string
+	Command   string
+	// Context is where the command would run. Its AgentType defaults to
+	// the request's.
+	Context Context
+}
+
+// GateDecision is the auto-responder's verdict on one prompt.
+type GateDecision struct {
+	Action ResponseAction `json:"action"`
+	// Rule is the index of the deciding auto_respond rule, or -1 when none
+	// matched.
+	Rule   int    `json:"rule"`
+	Reason string `json:"reason"`
+	SLB    bool   `json:"slb,omitempty"`
+	// Policy is the command policy match that overrode or confirmed the
+	// rule, if the command matched one.
+	Policy *Match `json:"policy,omitempty"`
+}
+
+// DecideGate applies the auto_respond rules to a prompt. A prompt no rule
+// covers escalates. An approve verdict is re-checked against the command
+// policy: a blocked command is denied and an approval_required command
+// escalates, so auto-approval can never run what ntm itself would refuse.
+func (p *Policy) DecideGate(req GateRequest) GateDecision {
+	d := GateDecision{Action: RespondEscalate, Rule: -1, Reason: "no auto_respond rule covers this prompt"}
+	for i := range p.AutoRespond {
+		r := &p.AutoRespond[i]
+		if !r.matches(req) {
+			continue
+		}
+		d = GateDecision{Action: r.Action, Rule: i, Reason: r.Reason, SLB: r.SLB}
+		if d.Reason == "" {
+			d.Reason = fmt.Sprintf("auto_respond rule %d", i+1)
+		}
+		break
+	}
+	if d.Action != RespondApprove || strings.TrimSpace(req.Command) == "" {
+		return d
+	}
+	ctx := req.Context
+	if ctx.AgentType == "" {
+		ctx.AgentType = req.AgentType
+	}
+	m := p.CheckContext(req.Command, ctx)
+	if m == nil {
+		return d
+	}
+	d.Policy = m
+	switch m.Action {
+	case ActionBlock:
+		d.Action = RespondDeny
+		d.Reason = "blocked by policy: " + m.Reason
+	case ActionApprove:
+		d.Action = RespondEscalate
+		d.Reason = "policy requires approval: " + m.Reason
+		d.SLB = m.SLB
+	}
+	return d
+}
+
+func (r *AutoRespondRule) matches(req GateRequest) bool {
+	if !selects(r.Agents, req.AgentType) || !selects(r.Gates, req.Gate) || !selects(r.Tools, req.Tool) {
+		return false
+	}
+	if r.Pattern == "" {
+		return true
+	}
+	// A rule whose pattern failed to compile never matches.
+	return r.regex != nil && r.regex.MatchString(strings.TrimSpace(req.Command))
+}
+
+func selects(list []string, value string) bool {
+	if len(list) == 0 {
+		return true
+	}
+	for _, v := range list {
+		if v == "*" || strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// compileAutoRespond compiles and validates the auto_respond rules.
+func (p *Policy) compileAutoRespond() error {
+	var errs error
+	for i := range p.AutoRespond {
+		r := &p.AutoRespond[i]
+		r.regex = nil
+		switch r.Action {
+		case RespondApprove, RespondDeny, RespondEscalate:
+		default:
+			errs = errors.Join(errs, fmt.Errorf("auto_respond rule %d: invalid action %q (must be approve, deny, or escalate)", i+1, r.Action))
+		}
+		if r.Pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			errs = errors.Join(errs, fmt.Errorf("invalid auto_respond pattern %q: %w", r.Pattern, err))
+			continue
+		}
+		r.regex = re
+	}
+	return errs
+}
@@ -0,0 +1,80 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+)
+
+const autoRespondYAML = `version: 1
+blocked:
+  - pattern: 'git\s+reset\s+--hard'
+    reason: "Hard reset loses uncommitted changes"
+approval_required:
+  - pattern: 'git\s+push'
+    reason: "Pushes leave the machine"
+auto_respond:
+  - gates: [permission]
+    pattern: '(^|\s)rm\s'
+    action: escalate
+    reason: "never auto-approve rm"
+  - agents: [cc]
+    gates: [permission]
+    tools: [read]
+    action: approve
+    reason: "read-only tools are safe"
+  - agents: [cod]
+    tools: [bash]
+    action: approve
+  - gates: [trust]
+    action: deny
+`
+
+func TestDecideGate(t *testing.T) {
+	p, err := DecodeYAML([]byte(autoRespondYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name   string
+		req    GateRequest
+		action ResponseAction
+		rule   int
+		reason string
+	}{
+		{"read-only cc", GateRequest{AgentType: "cc", Gate: "permission", Tool: "read", Command: "/etc/hosts"}, RespondApprove, 1, "read-only tools are safe"},
+		{"rm never auto-approved", GateRequest{AgentType: "cc", Gate: "permission", Tool: "bash", Command: "rm build.log"}, RespondEscalate, 0, "never auto-approve rm"},
+		{"read in another agent is uncovered", GateRequest{AgentType: "gmi", Gate: "permission", Tool: "read"}, RespondEscalate, -1, "no auto_respond rule"},
+		{"approved command blocked by policy", GateRequest{AgentType: "cod", Gate: "permission", Tool: "bash", Command: "git reset --hard HEAD~3"}, RespondDeny, 2, "blocked by policy"},
+		{"approved command needing approval", GateRequest{AgentType: "cod", Gate: "permission", Tool: "bash", Command: "git push origin main"}, RespondEscalate, 2, "policy requires approval"},
+		{"approved command", GateRequest{AgentType: "cod", Gate: "permission", Tool: "bash", Command: "go test ./..."}, RespondApprove, 2, "auto_respond rule 3"},
+		{"trust gate", GateRequest{AgentType: "cc", Gate: "trust"}, RespondDeny, 3, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := p.DecideGate(tc.req)
+			if d.Action != tc.action || d.Rule != tc.rule || !strings.Contains(d.Reason, tc.reason) {
+				t.Errorf("decision = %+v, want %s by rule %d (%q)", d, tc.action, tc.rule, tc.reason)
+			}
+		})
+	}
+}
+
+func TestAutoRespondValidation(t *testing.T) {
+	p, err := DecodeYAML([]byte("auto_respond:\n  - action: maybe\n  - pattern: '('\n    action: deny\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = p.Validate()
+	if err == nil {
+		t.Fatal("invalid auto_respond rules validated")
+	}
+	for _, want := range []string{`invalid action "maybe"`, "invalid auto_respond pattern"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q missing %q", err, want)
+		}
+	}
+}
@@ -0,0 +1,493 @@
+package policy
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/config"
+)
+
+// Context describes where a checked command would run. Callers fill in what
+// they know; rule conditions (Rule.When) are evaluated against it and an
+// empty field is treated as unknown.
+type Context struct {
+	// AgentType is the canonical agent type of the issuing pane (cc, cod,
+	// gmi, ...), or "user" for a human.
+	AgentType string `json:"agent_type,omitempty"`
+	Session   string `json:"session,omitempty"`
+	// Project and Label split Session at the label separator
+	// ("myproject--frontend"); they are derived from Session when empty.
+	Project string `json:"project,omitempty"`
+	Label   string `json:"label,omitempty"`
+	// Dir is the working directory the command runs in.
+	Dir string `json:"dir,omitempty"`
+	// Branch is the git branch checked out in Dir. For a `git push`
+	// sub-command, branches conditions see each branch the push updates
+	// instead (see pushDestinations).
+	Branch string `json:"branch,omitempty"`
+	// Time is when the command runs; zero means now.
+	Time time.Time `json:"time,omitempty"`
+}
+
+// DirContext returns a Context for commands run in dir, with the git branch
+// checked out there. A detached HEAD or a directory outside git leaves
+// Branch unknown. HEAD is read directly rather than through git, which the
+// safety wrapper may itself be intercepting.
+func DirContext(dir string) Context {
+	if dir == "" {
+		return Context{}
+	}
+	return Context{Dir: dir, Branch: gitBranch(dir)}
+}
+
+func gitBranch(dir string) string {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for d := abs; ; {
+		gitPath := filepath.Join(d, ".git")
+		if info, err := os.Stat(gitPath); err == nil {
+			gitDir := gitPath
+			if !info.IsDir() {
+				// A worktree or submodule: ".git" is a "gitdir: <path>" file.
+				data, err := os.ReadFile(gitPath)
+				if err != nil {
+					return ""
+				}
+				target, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir:")
+				if !ok {
+					return ""
+				}
+				gitDir = strings.TrimSpace(target)
+				if !filepath.IsAbs(gitDir) {
+					gitDir = filepath.Join(d, gitDir)
+				}
+			}
+			head, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+			if err != nil {
+				return ""
+			}
+			branch, _ := strings.CutPrefix(strings.TrimSpace(string(head)), "ref: refs/heads/")
+			if branch == strings.TrimSpace(string(head)) {
+				return "" // detached
+			}
+			return branch
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return ""
+		}
+		d = parent
+	}
+}
+
+// subCommandContexts returns the contexts conditions see for sub, or nil
+// when that is ctx itself. A `git push` is evaluated once per branch it
+// updates, so a branches condition sees where the push goes rather than
+// what is checked out.
+func subCommandContexts(sub ShellCommand, ctx Context) []Context {
+	dests, ok := pushDestinations(sub, ctx.Branch)
+	if !ok {
+		return nil
+	}
+	ctxs := make([]Context, 0, len(dests))
+	for _, dest := range dests {
+		c := ctx
+		c.Branch = dest
+		ctxs = append(ctxs, c)
+	}
+	return ctxs
+}
+
+// gitGlobalOptsWithValue are the git options before the subcommand that
+// take their value as the next word.
+var gitGlobalOptsWithValue = map[string]bool{
+	"-C": true, "-c": true, "--git-dir": true, "--work-tree": true,
+	"--namespace": true, "--exec-path": true, "--config-env": true,
+}
+
+// gitPushOptsWithValue are the `git push` options that take their value as
+// the next word.
+var gitPushOptsWithValue = map[string]bool{
+	"--repo": true, "-o": true, "--push-option": true,
+	"--receive-pack": true, "--exec": true,
+}
+
+// pushDestinations returns the branches a `git push` sub-command would
+// update, with ok false for any other command. A refspec's destination is
+// the part after ":", or the source itself; HEAD and a push with no
+// refspec stand for current, the checked-out branch. --all, --mirror, or a
+// destination that cannot be read make the destination unknown ("").
+func pushDestinations(sub ShellCommand, current string) (dests []string, ok bool) {
+	if sub.Name != "git" {
+		return nil, false
+	}
+	args := sub.argvTail()
+	i := 0
+	for ; i < len(args) && strings.HasPrefix(args[i], "-"); i++ {
+		if gitGlobalOptsWithValue[args[i]] {
+			i++
+		}
+	}
+	if i >= len(args) || args[i] != "push" {
+		return nil, false
+	}
+	var positional []string
+	for i++; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--all" || arg == "--mirror" || arg == "--branches":
+			return []string{""}, true
+		case arg == "--":
+			positional = append(positional, args[i+1:]...)
+			i = len(args)
+		case strings.HasPrefix(arg, "-"):
+			if gitPushOptsWithValue[arg] {
+				i++
+			}
+		default:
+			positional = append(positional, arg)
+		}
+	}
+	if len(positional) < 2 {
+		return []string{current}, true
+	}
+	for _, refspec := range positional[1:] {
+		refspec = strings.TrimPrefix(refspec, "+")
+		dst := refspec
+		if _, after, found := strings.Cut(refspec, ":"); found {
+			dst = after
+		}
+		dst = strings.TrimPrefix(dst, "refs/heads/")
+		if dst == "HEAD" || dst == "@" {
+			dst = current
+		}
+		if strings.ContainsAny(dst, "$`*") {
+			dst = ""
+		}
+		dests = append(dests, dst)
+	}
+	return dests, true
+}
+
+// resolved fills derived fields so conditions see one consistent view.
+func (c Context) resolved() Context {
+	if c.Session != "" && c.Project == "" && c.Label == "" {
+		c.Project, c.Label = config.ParseSessionLabel(c.Session)
+	}
+	if c.Dir != "" {
+		c.Dir = filepath.Clean(c.Dir)
+	}
+	if c.Time.IsZero() {
+		c.Time = time.Now()
+	}
+	return c
+}
+
+// Conditions scope a rule to the contexts it applies in. Every condition
+// that is set must hold. List entries are globs; an entry starting with "!"
+// excludes instead, so branches: ["!agent/*"] holds on any branch outside
+// agent/.
+type Conditions struct {
+	// Agents selects agent types by canonical name (cc, cod, gmi, user).
+	Agents   StringList `yaml:"agents,omitempty"`
+	Sessions StringList `yaml:"sessions,omitempty"`
+	Projects StringList `yaml:"projects,omitempty"`
+	Labels   StringList `yaml:"labels,omitempty"`
+	// Paths are working directory globs. A glob also covers every
+	// directory below the ones it matches, and "~/" is the home directory.
+	Paths StringList `yaml:"paths,omitempty"`
+	// Branches match the checked-out branch, except for `git push`, where
+	// they match every branch the push would update: `git push origin
+	// HEAD:main` from agent/x is a push to main.
+	Branches StringList `yaml:"branches,omitempty"`
+	// Hours are "HH:MM-HH:MM" windows; a window ending before it starts
+	// wraps past midnight.
+	Hours StringList `yaml:"hours,omitempty"`
+	// Days are weekday names or ranges: "mon-fri", "sat".
+	Days StringList `yaml:"days,omitempty"`
+	// Timezone is the IANA zone Hours and Days are read in; default local.
+	Timezone string `yaml:"timezone,omitempty"`
+}
+
+// Condition outcomes reported by Explain.
+const (
+	ConditionHolds   = "holds"
+	ConditionFails   = "fails"
+	ConditionUnknown = "unknown"
+)
+
+// ConditionResult is the outcome of one condition against a Context.
+type ConditionResult struct {
+	Condition string `json:"condition"`
+	Want      string `json:"want"`
+	Got       string `json:"got,omitempty"`
+	Outcome   string `json:"outcome"`
+}
+
+func (c *Conditions) empty() bool {
+	return c == nil || (len(c.Agents) == 0 && len(c.Sessions) == 0 && len(c.Projects) == 0 &&
+		len(c.Labels) == 0 && len(c.Paths) == 0 && len(c.Branches) == 0 &&
+		len(c.Hours) == 0 && len(c.Days) == 0)
+}
+
+// evaluate checks every condition against ctx, which must be resolved.
+func (c *Conditions) evaluate(ctx Context) []ConditionResult {
+	if c.empty() {
+		return nil
+	}
+	var results []ConditionResult
+	list := func(name string, globs StringList, got string, match func(glob, value string) bool) {
+		if len(globs) == 0 {
+			return
+		}
+		r := ConditionResult{Condition: name, Want: strings.Join(globs, ","), Got: got}
+		switch {
+		case got == "":
+			r.Outcome = ConditionUnknown
+		case selectsGlobs(globs, got, match):
+			r.Outcome = ConditionHolds
+		default:
+			r.Outcome = ConditionFails
+		}
+		results = append(results, r)
+	}
+	list("agents", c.Agents, ctx.AgentType, func(glob, value string) bool {
+		return strings.EqualFold(glob, value) || globMatch(glob, value)
+	})
+	list("sessions", c.Sessions, ctx.Session, globMatch)
+	list("projects", c.Projects, ctx.Project, globMatch)
+	list("labels", c.Labels, ctx.Label, globMatch)
+	list("paths", c.Paths, ctx.Dir, pathGlobMatch)
+	list("branches", c.Branches, ctx.Branch, globMatch)
+
+	if len(c.Hours) == 0 && len(c.Days) == 0 {
+		return results
+	}
+	loc := time.Local
+	if c.Timezone != "" {
+		if l, err := time.LoadLocation(c.Timezone); err == nil {
+			loc = l
+		}
+	}
+	now := ctx.Time.In(loc)
+	if len(c.Hours) > 0 {
+		r := ConditionResult{Condition: "hours", Want: strings.Join(c.Hours, ","), Got: now.Format("15:04 MST"), Outcome: ConditionFails}
+		minute := now.Hour()*60 + now.Minute()
+		for _, window := range c.Hours {
+			if start, end, err := parseHours(window); err == nil && inWindow(minute, start, end) {
+				r.Outcome = ConditionHolds
+				break
+			}
+		}
+		results = append(results, r)
+	}
+	if len(c.Days) > 0 {
+		r := ConditionResult{Condition: "days", Want: strings.Join(c.Days, ","), Got: strings.ToLower(now.Weekday().String()[:3]), Outcome: ConditionFails}
+		for _, spec := range c.Days {
+			if first, last, err := parseDays(spec); err == nil && inDayRange(now.Weekday(), first, last) {
+				r.Outcome = ConditionHolds
+				break
+			}
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// inScope reports whether conditions let a rule apply. An unknown context
+// value counts as holding for restrictive rules and as failing for allowed
+// ones, so a caller that knows less never gets a more permissive verdict.
+func inScope(results []ConditionResult, restrictive bool) bool {
+	for _, r := range results {
+		switch r.Outcome {
+		case ConditionFails:
+			return false
+		case ConditionUnknown:
+			if !restrictive {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// selectsGlobs applies a condition list: value must match a plain entry (if
+// any are given) and no "!" entry.
+func selectsGlobs(globs []string, value string, match func(glob, value string) bool) bool {
+	included, hasIncludes := false, false
+	for _, glob := range globs {
+		if exclude, ok := strings.CutPrefix(glob, "!"); ok {
+			if match(exclude, value) {
+				return false
+			}
+			continue
+		}
+		hasIncludes = true
+		if match(glob, value) {
+			included = true
+		}
+	}
+	return included || !hasIncludes
+}
+
+// pathGlobMatch matches dir or any of its parents against glob.
+func pathGlobMatch(glob, dir string) bool {
+	glob = strings.TrimSuffix(expandHome(glob), "/**")
+	if glob == "" {
+		return false
+	}
+	glob = filepath.ToSlash(filepath.Clean(glob))
+	for d := filepath.ToSlash(dir); ; {
+		if globMatch(glob, d) {
+			return true
+		}
+		parent := path.Dir(d)
+		if parent == d {
+			return false
+		}
+		d = parent
+	}
+}
+
+func expandHome(p string) string {
+	if p != "~" && !strings.HasPrefix(p, "~/") {
+		return p
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return p
+	}
+	return filepath.Join(home, strings.TrimPrefix(p, "~"))
+}
+
+func parseHours(window string) (start, end int, err error) {
+	from, to, ok := strings.Cut(strings.TrimSpace(window), "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("hours %q must be HH:MM-HH:MM", window)
+	}
+	if start, err = parseClock(from); err != nil {
+		return 0, 0, fmt.Errorf("hours %q: %w", window, err)
+	}
+	if end, err = parseClock(to); err != nil {
+		return 0, 0, fmt.Errorf("hours %q: %w", window, err)
+	}
+	if start == end {
+		return 0, 0, fmt.Errorf("hours %q is an empty window", window)
+	}
+	return start, end, nil
+}
+
+func parseClock(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if s == "24:00" {
+		return 24 * 60, nil
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func inWindow(minute, start, end int) bool {
+	if start < end {
+		return minute >= start && minute < end
+	}
+	return minute >= start || minute < end
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+func parseWeekday(s string) (time.Weekday, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if len(s) >= 3 {
+		if d, ok := weekdays[s[:3]]; ok && strings.HasPrefix(strings.ToLower(d.String()), s) {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid day %q", s)
+}
+
+func parseDays(spec string) (first, last time.Weekday, err error) {
+	from, to, isRange := strings.Cut(spec, "-")
+	if first, err = parseWeekday(from); err != nil {
+		return 0, 0, err
+	}
+	last = first
+	if isRange {
+		if last, err = parseWeekday(to); err != nil {
+			return 0, 0, err
+		}
+	}
+	return first, last, nil
+}
+
+func inDayRange(day, first, last time.Weekday) bool {
+	if first <= last {
+		return day >= first && day <= last
+	}
+	return day >= first || day <= last
+}
+
+func (c *Conditions) validate() error {
+	if c == nil {
+		return nil
+	}
+	for _, globs := range []StringList{c.Agents, c.Sessions, c.Projects, c.Labels, c.Paths, c.Branches} {
+		for _, glob := range globs {
+			if _, err := path.Match(strings.TrimPrefix(glob, "!"), ""); err != nil {
+				return fmt.Errorf("glob %q: %w", glob, err)
+			}
+		}
+	}
+	for _, window := range c.Hours {
+		if _, _, err := parseHours(window); err != nil {
+			return err
+		}
+	}
+	for _, spec := range c.Days {
+		if _, _, err := parseDays(spec); err != nil {
+			return err
+		}
+	}
+	if c.Timezone != "" {
+		if _, err := time.LoadLocation(c.Timezone); err != nil {
+			return fmt.Errorf("timezone %q: %w", c.Timezone, err)
+		}
+	}
+	return nil
+}
+
+// describe renders the conditions as "agents=cod branches=!agent/*".
+func (c *Conditions) describe() string {
+	if c.empty() {
+		return ""
+	}
+	var parts []string
+	add := func(name string, values StringList) {
+		if len(values) > 0 {
+			parts = append(parts, name+"="+strings.Join(values, ","))
+		}
+	}
+	add("agents", c.Agents)
+	add("sessions", c.Sessions)
+	add("projects", c.Projects)
+	add("labels", c.Labels)
+	add("paths", c.Paths)
+	add("branches", c.Branches)
+	add("hours", c.Hours)
+	add("days", c.Days)
+	if c.Timezone != "" && (len(c.Hours) > 0 || len(c.Days) > 0) {
+		parts = append(parts, "timezone="+c.Timezone)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,237 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const scopedPolicyYAML = `version: 1
+allowed:
+  - pattern: '^make deploy'
+    when:
+      labels: release
+blocked:
+  - command: git
+    arg: push
+    when:
+      agents: cod
+      branches: "!agent/*"
+    reason: codex pushes only agent branches
+  - command: npm
+    arg: publish
+    when:
+      sessions: ["!*--release"]
+    reason: publish from the release session
+  - pattern: '^make deploy'
+    reason: deploys need the release session
+approval_required:
+  - arg: ["*DROP TABLE*", "*drop table*"]
+    when:
+      hours: "09:00-18:00"
+      days: mon-fri
+      timezone: UTC
+    reason: schema changes during business hours
+`
+
+func loadScopedPolicy(t *testing.T) *Policy {
+	t.Helper()
+	p, err := DecodeYAML([]byte(scopedPolicyYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestCheckContextAppliesScopedRules(t *testing.T) {
+	p := loadScopedPolicy(t)
+	monday10 := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	saturday10 := time.Date(2026, 3, 7, 10, 0, 0, 0, time.UTC)
+	monday20 := time.Date(2026, 3, 2, 20, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name    string
+		command string
+		ctx     Context
+		want    Action // "" for no match
+	}{
+		{"codex push to main", "git push origin main", Context{AgentType: "cod", Branch: "main"}, ActionBlock},
+		{"codex push from agent branch", "git push", Context{AgentType: "cod", Branch: "agent/fix-1"}, ""},
+		{"claude push to main", "git push", Context{AgentType: "cc", Branch: "main"}, ""},
+		{"codex push to main from agent branch", "git push origin HEAD:main", Context{AgentType: "cod", Branch: "agent/x"}, ActionBlock},
+		{"codex push to full ref", "git push origin +agent/x:refs/heads/main", Context{AgentType: "cod", Branch: "agent/x"}, ActionBlock},
+		{"codex push of agent branch from main", "git push origin agent/x", Context{AgentType: "cod", Branch: "main"}, ""},
+		{"codex push with global options", "git -C repo push -u origin HEAD", Context{AgentType: "cod", Branch: "agent/x"}, ""},
+		{"codex push to several branches", "git push origin agent/x main", Context{AgentType: "cod", Branch: "agent/x"}, ActionBlock},
+		{"codex push of every branch", "git push --all origin", Context{AgentType: "cod", Branch: "agent/x"}, ActionBlock},
+		{"unknown agent is assumed", "git push", Context{Branch: "main"}, ActionBlock},
+		{"publish outside release", "npm publish", Context{Session: "proj--dev"}, ActionBlock},
+		{"publish in release", "npm publish", Context{Session: "proj--release"}, ""},
+		{"deploy in release label", "make deploy", Context{Session: "proj--release"}, ActionAllow},
+		{"deploy elsewhere", "make deploy", Context{Session: "proj"}, ActionBlock},
+		{"allowed rule needs a known label", "make deploy", Context{}, ActionBlock},
+		{"drop table in hours", "psql -c 'DROP TABLE users'", Context{Time: monday10}, ActionApprove},
+		{"drop table at weekend", "psql -c 'DROP TABLE users'", Context{Time: saturday10}, ""},
+		{"drop table after hours", "psql -c 'DROP TABLE users'", Context{Time: monday20}, ""},
+	}
+	for _, tc := range cases {
+		m := p.CheckContext(tc.command, tc.ctx)
+		got := Action("")
+		if m != nil {
+			got = m.Action
+		}
+		if got != tc.want {
+			t.Errorf("%s: CheckContext(%q) = %+v, want %q", tc.name, tc.command, m, tc.want)
+		}
+	}
+
+	if m := p.CheckContext("git push", Context{AgentType: "cod", Branch: "main"}); m.Scope != "agents=cod branches=!agent/*" {
+		t.Errorf("Scope = %q", m.Scope)
+	}
+}
+
+func TestConditionsPathsAndWindows(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	for _, tc := range []struct {
+		glob, dir string
+		want      bool
+	}{
+		{"/srv/prod", "/srv/prod/api", true},
+		{"/srv/*/deploy", "/srv/eu/deploy/bin", true},
+		{"/srv/prod/**", "/srv/prod", true},
+		{"/srv/prod", "/srv/production", false},
+		{"~/work", filepath.Join(home, "work", "x"), true},
+	} {
+		if got := pathGlobMatch(tc.glob, tc.dir); got != tc.want {
+			t.Errorf("pathGlobMatch(%q, %q) = %v, want %v", tc.glob, tc.dir, got, tc.want)
+		}
+	}
+
+	start, end, err := parseHours("22:00-06:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !inWindow(23*60, start, end) || !inWindow(5*60, start, end) || inWindow(12*60, start, end) {
+		t.Error("overnight window does not wrap midnight")
+	}
+	first, last, err := parseDays("fri-mon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !inDayRange(time.Sunday, first, last) || inDayRange(time.Wednesday, first, last) {
+		t.Error("day range does not wrap the week")
+	}
+}
+
+func TestValidateRejectsMalformedConditions(t *testing.T) {
+	for _, when := range []string{
+		`hours: "9-5"`,
+		`hours: "10:00-10:00"`,
+		`days: funday`,
+		`timezone: Mars/Olympus`,
+		`branches: "[agent"`,
+	} {
+		src := "blocked:\n  - pattern: x\n    when:\n      " + when + "\n"
+		p, err := DecodeYAML([]byte(src))
+		if err != nil {
+			t.Fatalf("decode %q: %v", when, err)
+		}
+		if err := p.Validate(); err == nil || !strings.Contains(err.Error(), "conditions") {
+			t.Errorf("Validate(%s) = %v, want a conditions error", when, err)
+		}
+	}
+	if _, err := DecodeYAML([]byte("blocked:\n  - pattern: x\n    when:\n      weekday: mon\n")); err == nil {
+		t.Error("unknown condition field was accepted")
+	}
+}
+
+func TestDirContextReadsBranchWithoutGit(t *testing.T) {
+	repo := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(repo, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, ".git", "HEAD"), []byte("ref: refs/heads/agent/fix-1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sub := filepath.Join(repo, "pkg", "x")
+	if err := os.MkdirAll(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if got := DirContext(sub).Branch; got != "agent/fix-1" {
+		t.Errorf("Branch = %q, want agent/fix-1", got)
+	}
+
+	worktree := t.TempDir()
+	if err := os.WriteFile(filepath.Join(worktree, ".git"), []byte("gitdir: "+filepath.Join(repo, ".git")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := DirContext(worktree).Branch; got != "agent/fix-1" {
+		t.Errorf("worktree Branch = %q, want agent/fix-1", got)
+	}
+
+	if err := os.WriteFile(filepath.Join(repo, ".git", "HEAD"), []byte("0123456789abcdef0123456789abcdef01234567\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := DirContext(repo).Branch; got != "" {
+		t.Errorf("detached Branch = %q, want unknown", got)
+	}
+}
+
+func TestExplainReportsWhyRulesApplied(t *testing.T) {
+	p := loadScopedPolicy(t)
+	exp := p.Explain("git push origin main", Context{AgentType: "cc", Branch: "main"})
+	if exp.Verdict != nil {
+		t.Fatalf("Verdict = %+v, want implicit allow", exp.Verdict)
+	}
+	push := findExplanation(t, exp, ListBlocked, 0)
+	if push.InScope || !push.Matched || !strings.Contains(push.Why, "agents cc does not match cod") {
+		t.Errorf("push rule = %+v", push)
+	}
+
+	exp = p.Explain("make deploy", Context{Session: "proj"})
+	allow := findExplanation(t, exp, ListAllowed, 0)
+	if allow.InScope || !strings.Contains(allow.Why, "labels") {
+		t.Errorf("allowed rule = %+v", allow)
+	}
+	deploy := findExplanation(t, exp, ListBlocked, 2)
+	if !deploy.Decisive || !deploy.InScope || !strings.Contains(deploy.Why, "decided the verdict") {
+		t.Errorf("deploy rule = %+v", deploy)
+	}
+
+	exp = p.Explain("sudo git push", Context{Branch: "main"})
+	push = findExplanation(t, exp, ListBlocked, 0)
+	if !push.Decisive || push.SubCommand != "git push" || !strings.Contains(push.Why, "agents unknown") {
+		t.Errorf("push rule with unknown agent = %+v", push)
+	}
+
+	exp = p.Explain("git push origin agent/x HEAD:main", Context{AgentType: "cod", Branch: "agent/x"})
+	push = findExplanation(t, exp, ListBlocked, 0)
+	var branch ConditionResult
+	for _, c := range push.Conditions {
+		if c.Condition == "branches" {
+			branch = c
+		}
+	}
+	if !push.Decisive || branch.Got != "main" || branch.Outcome != ConditionHolds {
+		t.Errorf("push rule for a push to main = %+v", push)
+	}
+}
+
+func findExplanation(t *testing.T, exp Explanation, list string, index int) RuleExplanation {
+	t.Helper()
+	for _, re := range exp.Rules {
+		if re.List == list && re.Index == index {
+			return re
+		}
+	}
+	t.Fatalf("no explanation for %s[%d]", list, index)
+	return RuleExplanation{}
+}
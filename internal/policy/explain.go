@@ -0,0 +1,146 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Rule list names, as written in the policy file.
+const (
+	ListAllowed          = "allowed"
+	ListBlocked          = "blocked"
+	ListApprovalRequired = "approval_required"
+)
+
+// Explanation reports how every rule fared for one command in one context.
+type Explanation struct {
+	Command string  `json:"command"`
+	Context Context `json:"context"`
+	// Verdict is what CheckContext returns; nil means implicitly allowed.
+	Verdict *Match            `json:"verdict,omitempty"`
+	Rules   []RuleExplanation `json:"rules"`
+}
+
+// RuleExplanation says whether a rule applied and why.
+type RuleExplanation struct {
+	List  string `json:"list"`
+	Index int    `json:"index"`
+	Rule  string `json:"rule"`
+	Scope string `json:"scope,omitempty"`
+	// Source is the policy file and layer the rule came from.
+	Source Source `json:"source"`
+	// Conditions are the rule's conditions evaluated against the context.
+	Conditions []ConditionResult `json:"conditions,omitempty"`
+	// InScope is true when the conditions let the rule apply.
+	InScope bool `json:"in_scope"`
+	// Matched is true when the rule matches the command (or one of its
+	// sub-commands), regardless of scope.
+	Matched    bool   `json:"matched"`
+	SubCommand string `json:"sub_command,omitempty"`
+	// Decisive marks the rule that produced the verdict.
+	Decisive bool   `json:"decisive,omitempty"`
+	Why      string `json:"why"`
+}
+
+// Explain evaluates command in ctx and reports, for every rule, whether its
+// conditions held, whether it matched, and which rule decided the verdict.
+func (p *Policy) Explain(command string, ctx Context) Explanation {
+	cmd := strings.TrimSpace(command)
+	resolved := ctx.resolved()
+	exp := Explanation{
+		Command: cmd,
+		Context: resolved,
+		Verdict: p.CheckContext(cmd, resolved),
+		Rules:   []RuleExplanation{},
+	}
+	subs := checkedSubCommands(cmd)
+	for _, list := range []struct {
+		name        string
+		rules       []Rule
+		restrictive bool
+	}{
+		{ListAllowed, p.Allowed, false},
+		{ListBlocked, p.Blocked, true},
+		{ListApprovalRequired, p.ApprovalRequired, true},
+	} {
+		for i := range list.rules {
+			r := &list.rules[i]
+			re := RuleExplanation{
+				List:       list.name,
+				Index:      i,
+				Rule:       r.Describe(),
+				Scope:      r.Scope(),
+				Source:     r.source,
+				Conditions: r.When.evaluate(resolved),
+			}
+			re.InScope = inScope(re.Conditions, list.restrictive)
+			for _, sub := range subs {
+				if r.matches(sub) {
+					re.Matched = true
+					if sub.raw == "" {
+						re.SubCommand = sub.String()
+					}
+					explainPushScope(&re, r, sub, resolved, list.restrictive)
+					break
+				}
+			}
+			re.Decisive = exp.Verdict != nil && exp.Verdict.list == list.name && exp.Verdict.index == i
+			re.Why = explainRule(re, list.restrictive, exp.Verdict)
+			exp.Rules = append(exp.Rules, re)
+		}
+	}
+	return exp
+}
+
+// explainPushScope re-evaluates re's conditions against the branches a
+// matched `git push` updates, reporting the destination that decides the
+// rule: one it applies to for restrictive rules, one it does not apply to
+// for allowed rules.
+func explainPushScope(re *RuleExplanation, r *Rule, sub ShellCommand, ctx Context, restrictive bool) {
+	ctxs := subCommandContexts(sub, ctx)
+	for i, c := range ctxs {
+		conditions := r.When.evaluate(c)
+		in := inScope(conditions, restrictive)
+		if i == 0 || in == restrictive {
+			re.Conditions, re.InScope = conditions, in
+		}
+		if in == restrictive {
+			return
+		}
+	}
+}
+
+func explainRule(re RuleExplanation, restrictive bool, verdict *Match) string {
+	if !re.InScope {
+		for _, c := range re.Conditions {
+			switch {
+			case c.Outcome == ConditionFails:
+				return fmt.Sprintf("not applied: %s %s does not match %s", c.Condition, c.Got, c.Want)
+			case c.Outcome == ConditionUnknown && !restrictive:
+				return fmt.Sprintf("not applied: %s is unknown, and allowed rules need every condition confirmed", c.Condition)
+			}
+		}
+		return "not applied: conditions do not hold"
+	}
+	scope := "applies everywhere"
+	if len(re.Conditions) > 0 {
+		scope = "conditions hold"
+		for _, c := range re.Conditions {
+			if c.Outcome == ConditionUnknown {
+				scope = fmt.Sprintf("conditions hold (%s unknown, assumed for a restrictive rule)", c.Condition)
+				break
+			}
+		}
+	}
+	switch {
+	case !re.Matched:
+		return scope + "; command does not match"
+	case re.Decisive:
+		return scope + "; matched and decided the verdict"
+	case verdict != nil && re.List == ListAllowed && verdict.list != ListAllowed && verdict.Source.Layer != re.Source.Layer:
+		return fmt.Sprintf("%s; matched, but cannot lift %s[%d] from the %s layer", scope, verdict.list, verdict.index, verdict.Source.Layer)
+	case verdict != nil:
+		return fmt.Sprintf("%s; matched, but %s[%d] takes precedence", scope, verdict.list, verdict.index)
+	}
+	return scope + "; matched"
+}
@@ -0,0 +1,278 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Policy layers, most general first. LoadForSession merges every layer that
+// has a policy file: restrictive rules from all layers apply, and a more
+// specific layer can tighten but not loosen a more general one.
+const (
+	LayerSystem  = "system"  // org baseline in SystemPolicyDir, optionally signed
+	LayerUser    = "user"    // ~/.ntm/policy.yaml
+	LayerProject = "project" // .ntm/policy.yaml in the working directory
+	LayerSession = "session" // .ntm/policies/<session>.yaml in the working directory
+)
+
+// layerOrder ranks the layers; a lower rank is more general.
+var layerOrder = []string{LayerSystem, LayerUser, LayerProject, LayerSession}
+
+// SystemPolicyDir holds the org-wide baseline (policy.yaml), its detached
+// signature (policy.yaml.sig), and the keys trusted to sign it
+// (policy.pub). When policy.pub lists a key, the baseline must exist and
+// carry a valid signature, or loading fails.
+var SystemPolicyDir = "/etc/ntm"
+
+// TrustedKeysFile names the baseline's trusted key file in SystemPolicyDir.
+const TrustedKeysFile = "policy.pub"
+
+// Layer is one policy file merged into the effective policy.
+type Layer struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	// Signed is true for a baseline whose signature verified.
+	Signed bool `json:"signed,omitempty"`
+}
+
+// Source records where a rule came from.
+type Source struct {
+	// Layer is empty for a rule read by Load or built into DefaultPolicy.
+	Layer string `json:"layer,omitempty"`
+	// Path is empty for a built-in default rule.
+	Path string `json:"path,omitempty"`
+	// Index is the rule's position in its list within the file.
+	Index int `json:"index"`
+}
+
+// String renders the source for display ("system /etc/ntm/policy.yaml #2").
+func (s Source) String() string {
+	if s.Path == "" {
+		return "built-in default"
+	}
+	if s.Layer == "" {
+		return fmt.Sprintf("%s #%d", s.Path, s.Index+1)
+	}
+	return fmt.Sprintf("%s %s #%d", s.Layer, s.Path, s.Index+1)
+}
+
+// LayerPaths lists the policy file each layer reads for session, most
+// general first, whether or not the file exists. An empty session, or one
+// that is not a plain name, has no session layer.
+func LayerPaths(session string) ([]Layer, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("resolve policy home directory: %w", err)
+	}
+	layers := []Layer{
+		{Name: LayerSystem, Path: filepath.Join(SystemPolicyDir, "policy.yaml")},
+		{Name: LayerUser, Path: filepath.Join(home, DefaultPolicyPath)},
+		{Name: LayerProject, Path: DefaultPolicyPath},
+	}
+	if session != "" && session == filepath.Base(session) && session != "." && session != ".." {
+		layers = append(layers, Layer{Name: LayerSession, Path: filepath.Join(".ntm", "policies", session+".yaml")})
+	}
+	return layers, nil
+}
+
+// LoadForSession loads the effective policy for commands run in session:
+// the merge of every layer's policy file (see LayerPaths), or DefaultPolicy
+// when none exists.
+//
+// Blocked and approval_required rules are additive. An allowed rule
+// overrides restrictive rules from its own layer; it overrides one from a
+// more general layer only when that rule is marked overridable. Automation
+// settings take the strictest value any layer sets, and auto_respond rules
+// keep the more general layers' rules first.
+func LoadForSession(session string) (*Policy, error) {
+	paths, err := LayerPaths(session)
+	if err != nil {
+		return nil, err
+	}
+	var layers []loadedLayer
+	seen := make(map[string]bool)
+	for _, layer := range paths {
+		abs, err := filepath.Abs(layer.Path)
+		if err != nil {
+			return nil, fmt.Errorf("resolve %s policy path: %w", layer.Name, err)
+		}
+		if seen[abs] {
+			// The project layer is the user layer when run from $HOME.
+			continue
+		}
+		seen[abs] = true
+		loaded, err := loadLayer(layer)
+		if err != nil {
+			return nil, err
+		}
+		if loaded != nil {
+			layers = append(layers, *loaded)
+		}
+	}
+	if len(layers) == 0 {
+		return DefaultPolicy(), nil
+	}
+	return mergeLayers(layers)
+}
+
+type loadedLayer struct {
+	Layer
+	rank       int
+	policy     *Policy
+	automation automationSettings
+}
+
+// automationSettings records which automation keys a layer sets, which
+// AutomationConfig's plain fields cannot tell apart from false.
+type automationSettings struct {
+	Automation struct {
+		AutoPush     *bool  `yaml:"auto_push"`
+		AutoCommit   *bool  `yaml:"auto_commit"`
+		ForceRelease string `yaml:"force_release"`
+	} `yaml:"automation"`
+}
+
+// loadLayer reads one layer's policy file, or returns nil when it does not
+// exist. The system layer is checked against its trusted keys.
+func loadLayer(layer Layer) (*loadedLayer, error) {
+	data, err := os.ReadFile(layer.Path)
+	missing := errors.Is(err, os.ErrNotExist)
+	if err != nil && !missing {
+		return nil, fmt.Errorf("reading %s policy %s: %w", layer.Name, layer.Path, err)
+	}
+	if layer.Name == LayerSystem {
+		signed, err := verifyBaseline(layer.Path, data, missing)
+		if err != nil {
+			return nil, err
+		}
+		layer.Signed = signed
+	}
+	if missing {
+		return nil, nil
+	}
+
+	p, err := DecodeYAML(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s policy %s: %w", layer.Name, layer.Path, err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, fmt.Errorf("%s policy %s: %w", layer.Name, layer.Path, err)
+	}
+	var automation automationSettings
+	if err := yaml.Unmarshal(data, &automation); err != nil {
+		return nil, fmt.Errorf("%s policy %s: %w", layer.Name, layer.Path, err)
+	}
+	rank := 0
+	for i, name := range layerOrder {
+		if name == layer.Name {
+			rank = i
+		}
+	}
+	return &loadedLayer{Layer: layer, rank: rank, policy: p, automation: automation}, nil
+}
+
+// verifyBaseline checks the org baseline's signature when SystemPolicyDir
+// pins signing keys. With keys pinned, a missing or unsigned baseline is an
+// error, so deleting it cannot silently drop the org's rules.
+func verifyBaseline(path string, data []byte, missing bool) (bool, error) {
+	keysPath := filepath.Join(SystemPolicyDir, TrustedKeysFile)
+	keys, err := LoadTrustedKeys(keysPath)
+	if err != nil {
+		return false, err
+	}
+	if len(keys) == 0 {
+		return false, nil
+	}
+	if missing {
+		return false, fmt.Errorf("org policy baseline %s is missing, but %s pins a signing key", path, keysPath)
+	}
+	sig, err := os.ReadFile(path + SignatureSuffix)
+	if err != nil {
+		return false, fmt.Errorf("org policy baseline %s is not signed: %w", path, err)
+	}
+	if !Verify(data, string(sig), keys) {
+		return false, fmt.Errorf("org policy baseline %s: signature does not match any key in %s", path, keysPath)
+	}
+	return true, nil
+}
+
+func mergeLayers(layers []loadedLayer) (*Policy, error) {
+	merged := &Policy{}
+	var autoPush, autoCommit *bool
+	for _, l := range layers {
+		p := l.policy
+		if p.Version > merged.Version {
+			merged.Version = p.Version
+		}
+		merged.Blocked = appendLayerRules(merged.Blocked, p.Blocked, l)
+		merged.ApprovalRequired = appendLayerRules(merged.ApprovalRequired, p.ApprovalRequired, l)
+		merged.Allowed = appendLayerRules(merged.Allowed, p.Allowed, l)
+		merged.AutoRespond = append(merged.AutoRespond, p.AutoRespond...)
+
+		// A layer may switch automation off but never back on.
+		autoPush = andSetting(autoPush, l.automation.Automation.AutoPush)
+		autoCommit = andSetting(autoCommit, l.automation.Automation.AutoCommit)
+		if forceReleaseStrictness(p.Automation.ForceRelease) > forceReleaseStrictness(merged.Automation.ForceRelease) {
+			merged.Automation.ForceRelease = p.Automation.ForceRelease
+		}
+		merged.layers = append(merged.layers, l.Layer)
+	}
+	merged.Automation.AutoPush = autoPush != nil && *autoPush
+	merged.Automation.AutoCommit = autoCommit != nil && *autoCommit
+	if err := merged.compile(); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+func appendLayerRules(dst, rules []Rule, l loadedLayer) []Rule {
+	for i, r := range rules {
+		r.rank = l.rank
+		r.source = Source{Layer: l.Name, Path: l.Path, Index: i}
+		dst = append(dst, r)
+	}
+	return dst
+}
+
+func andSetting(acc, set *bool) *bool {
+	if set == nil {
+		return acc
+	}
+	v := *set && (acc == nil || *acc)
+	return &v
+}
+
+// forceReleaseStrictness orders force_release values; "" is unset.
+func forceReleaseStrictness(v string) int {
+	switch v {
+	case "auto":
+		return 1
+	case "approval":
+		return 2
+	case "never":
+		return 3
+	}
+	return 0
+}
+
+// Layers lists the policy files merged into p, most general first. It is
+// empty for DefaultPolicy and for a policy read by Load.
+func (p *Policy) Layers() []Layer {
+	return append([]Layer(nil), p.layers...)
+}
+
+// Source reports where the rule came from.
+func (r Rule) Source() Source {
+	return r.source
+}
+
+// allowOverrides reports whether an allowed rule lifts restrictive rule r:
+// within one layer, or across layers when the more general rule is
+// overridable. A more specific layer's restrictive rule always stands.
+func allowOverrides(allow, r *Rule) bool {
+	return allow.rank == r.rank || (r.rank < allow.rank && r.Overridable)
+}
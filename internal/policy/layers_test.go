@@ -0,0 +1,192 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// layeredEnv isolates every policy layer and returns a writer for each.
+func layeredEnv(t *testing.T) (write func(layer, content string)) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	chdir(t, t.TempDir())
+	prev := SystemPolicyDir
+	SystemPolicyDir = t.TempDir()
+	t.Cleanup(func() { SystemPolicyDir = prev })
+
+	return func(layer, content string) {
+		t.Helper()
+		paths, err := LayerPaths("proj--dev")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, l := range paths {
+			if l.Name != layer {
+				continue
+			}
+			if err := os.MkdirAll(filepath.Dir(l.Path), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(l.Path, []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			return
+		}
+		t.Fatalf("no %s layer", layer)
+	}
+}
+
+func TestLoadForSessionMergesLayers(t *testing.T) {
+	write := layeredEnv(t)
+	write(LayerSystem, `blocked:
+  - command: git
+    arg: push
+    reason: org forbids direct pushes
+  - pattern: 'npm publish'
+    overridable: true
+    reason: publish from CI
+allowed:
+  - pattern: 'terraform plan'
+automation:
+  auto_push: false
+  force_release: approval
+`)
+	write(LayerUser, `allowed:
+  - pattern: 'git push'
+  - pattern: 'npm publish'
+automation:
+  auto_push: true
+  auto_commit: true
+  force_release: auto
+`)
+	write(LayerProject, `blocked:
+  - pattern: 'terraform'
+    reason: no terraform in this repo
+`)
+	write(LayerSession, `approval_required:
+  - pattern: 'make release'
+`)
+
+	p, err := LoadForSession("proj--dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(p.Layers()); got != 4 {
+		t.Fatalf("Layers() = %+v, want all four", p.Layers())
+	}
+
+	for _, tc := range []struct {
+		command string
+		want    Action
+		layer   string
+	}{
+		{"git push origin main", ActionBlock, LayerSystem}, // a user allow cannot lift an org block
+		{"npm publish", ActionAllow, LayerUser},            // unless the org marks it overridable
+		{"terraform plan", ActionBlock, LayerProject},      // projects tighten org allows
+		{"make release", ActionApprove, LayerSession},      // session rules apply
+	} {
+		m := p.Check(tc.command)
+		if m == nil || m.Action != tc.want || m.Source.Layer != tc.layer {
+			t.Errorf("Check(%q) = %+v, want %s from the %s layer", tc.command, m, tc.want, tc.layer)
+		}
+	}
+
+	if p.Automation.AutoPush || !p.Automation.AutoCommit || p.ForceReleasePolicy() != "approval" {
+		t.Errorf("Automation = %+v, want auto_push off, auto_commit on, force_release approval", p.Automation)
+	}
+
+	// Without the session the session layer is not read.
+	p, err = LoadForSession("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := p.Check("make release"); m != nil {
+		t.Errorf("session rule applied outside the session: %+v", m)
+	}
+
+	exp := p.Explain("git push", Context{})
+	allow := findExplanation(t, exp, ListAllowed, 1)
+	if allow.Source.Layer != LayerUser || !strings.Contains(allow.Why, "cannot lift blocked[0] from the system layer") {
+		t.Errorf("user allow explanation = %+v", allow)
+	}
+}
+
+func TestLoadForSessionDefaultsAndSingleLayer(t *testing.T) {
+	write := layeredEnv(t)
+	p, err := LoadForSession("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Layers()) != 0 || p.Check("git reset --hard") == nil {
+		t.Fatal("no policy files should yield the default policy")
+	}
+
+	// A single layer keeps the allowed-first precedence within the file.
+	write(LayerUser, "allowed:\n  - pattern: 'git push --force-with-lease'\nblocked:\n  - pattern: 'git push'\n")
+	p, err = LoadForSession("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := p.Check("git push --force-with-lease"); m == nil || m.Action != ActionAllow {
+		t.Errorf("same-layer allow = %+v, want allow", m)
+	}
+
+	bad, err := DecodeYAML([]byte("allowed:\n  - pattern: x\n    overridable: true\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bad.Validate(); err == nil || !strings.Contains(err.Error(), "overridable") {
+		t.Errorf("Validate() = %v, want an overridable error", err)
+	}
+}
+
+func TestSignedBaseline(t *testing.T) {
+	write := layeredEnv(t)
+	baseline := "blocked:\n  - pattern: 'curl .* \\| sh'\n"
+	write(LayerSystem, baseline)
+	basePath := filepath.Join(SystemPolicyDir, "policy.yaml")
+
+	pub, priv, err := GenerateSigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Unsigned baselines load until a key is pinned.
+	if p, err := LoadForSession(""); err != nil || p.Layers()[0].Signed {
+		t.Fatalf("unpinned baseline: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(SystemPolicyDir, TrustedKeysFile), []byte("# org key\n"+pub+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadForSession(""); err == nil || !strings.Contains(err.Error(), "not signed") {
+		t.Errorf("unsigned pinned baseline: err = %v", err)
+	}
+
+	sig, err := Sign([]byte(baseline), priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(basePath+SignatureSuffix, []byte(sig+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p, err := LoadForSession("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if layers := p.Layers(); len(layers) != 1 || !layers[0].Signed {
+		t.Errorf("Layers() = %+v, want one signed baseline", layers)
+	}
+
+	write(LayerSystem, baseline+"allowed:\n  - pattern: '.*'\n")
+	if _, err := LoadForSession(""); err == nil || !strings.Contains(err.Error(), "signature does not match") {
+		t.Errorf("tampered baseline: err = %v", err)
+	}
+	if err := os.Remove(basePath); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadForSession(""); err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("deleted baseline: err = %v", err)
+	}
+}
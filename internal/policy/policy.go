@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
 	"strings"
@@ -26,12 +27,53 @@ const (
 	ActionAllow   Action = "allow"
 )
 
-// Rule represents a single policy rule.
+// Rule represents a single policy rule. Rules are matched against each
+// sub-command ParseShell resolves from a command line. Pattern is a regexp
+// over the sub-command's rendered argv; Command, Flag, and Arg match its
+// argv directly. Every matcher a rule sets must match, and When limits the
+// contexts the rule applies in.
 type Rule struct {
-	Pattern string `yaml:"pattern"`
-	Reason  string `yaml:"reason,omitempty"`
-	SLB     bool   `yaml:"slb,omitempty"` // Requires SLB two-person approval
-	regex   *regexp.Regexp
+	Pattern string `yaml:"pattern,omitempty"`
+	// Command is a glob matched against the command's base name ("rm",
+	// "git"), or against argv[0] when it contains a slash.
+	Command string `yaml:"command,omitempty"`
+	// Flag lists flags that must all be present. An entry may name
+	// alternatives, as in "-r|-R|--recursive". Short flags also match inside
+	// combined clusters, so "-r" matches "-rf".
+	Flag StringList `yaml:"flag,omitempty"`
+	// Arg lists globs of which at least one must match a non-flag argument.
+	Arg StringList `yaml:"arg,omitempty"`
+	// When scopes the rule to agent types, sessions, paths, branches, and
+	// time windows; see Conditions.
+	When   *Conditions `yaml:"when,omitempty"`
+	Reason string      `yaml:"reason,omitempty"`
+	SLB    bool        `yaml:"slb,omitempty"` // Requires SLB two-person approval
+	// Overridable lets allowed rules in more specific policy layers lift
+	// this blocked or approval_required rule; see LoadForSession.
+	Overridable bool `yaml:"overridable,omitempty"`
+	regex       *regexp.Regexp
+	rank        int
+	source      Source
+}
+
+// StringList is a rule matcher list written as a YAML scalar or sequence.
+type StringList []string
+
+// UnmarshalYAML accepts "arg: /" as well as "arg: [/, ~]".
+func (l *StringList) UnmarshalYAML(value *yaml.Node) error {
+	switch value.Kind {
+	case yaml.ScalarNode:
+		*l = StringList{value.Value}
+		return nil
+	case yaml.SequenceNode:
+		var items []string
+		if err := value.Decode(&items); err != nil {
+			return err
+		}
+		*l = items
+		return nil
+	}
+	return fmt.Errorf("line %d: expected a string or a list of strings", value.Line)
 }
 
 // AutomationConfig controls automatic operations.
@@ -48,6 +90,10 @@ type Policy struct {
 	ApprovalRequired []Rule           `yaml:"approval_required"`
 	Allowed          []Rule           `yaml:"allowed"`
 	Automation       AutomationConfig `yaml:"automation"`
+	// AutoRespond decides agent permission prompts and other blocking
+	// gates; see DecideGate.
+	AutoRespond []AutoRespondRule `yaml:"auto_respond,omitempty"`
+	layers      []Layer
 }
 
 // Match represents a matched policy rule.
@@ -56,7 +102,16 @@ type Match struct {
 	Pattern string
 	Reason  string
 	Command string
-	SLB     bool // Whether this match requires SLB approval
+	// SubCommand is the resolved sub-command that matched, when the command
+	// line parsed as shell ("rm -rf /" for "sudo sh -c 'rm -rf /'").
+	SubCommand string
+	// Scope describes the matched rule's conditions, if it has any.
+	Scope string
+	// Source is the policy file and layer the matched rule came from.
+	Source Source
+	SLB    bool // Whether this match requires SLB approval
+	list   string
+	index  int
 }
 
 // DecodeYAML decodes policy YAML strictly, rejecting unknown fields.
@@ -93,16 +148,22 @@ func Load(path string) (*Policy, error) {
 	if err := p.Validate(); err != nil {
 		return nil, err
 	}
+	for _, rules := range [][]Rule{p.Blocked, p.ApprovalRequired, p.Allowed} {
+		for i := range rules {
+			rules[i].source = Source{Path: path, Index: i}
+		}
+	}
 
 	return p, nil
 }
 
-// ResolveEffectivePath reports which policy file LoadOrDefault would actually
-// read, and whether such a file exists. Writers MUST use it so an update
-// lands on the file that is being enforced: targeting the home path
-// unconditionally would shadow an existing project-local policy with a
-// freshly written one, silently dropping its blocked/approval_required rules
-// (bd-fresh-eyes-audit .30).
+// ResolveEffectivePath reports which policy file writers should update, and
+// whether such a file exists: the user policy when it exists, otherwise the
+// project-local one. Writers MUST use it so an update lands on a file that
+// is being enforced: creating a defaults-only home policy beside an existing
+// project-local one used to shadow it, silently dropping its
+// blocked/approval_required rules (bd-fresh-eyes-audit .30). LoadOrDefault
+// now merges both, along with the system and session layers.
 //
 // When no policy file exists, the returned path is where a new one should be
 // created and exists is false.
@@ -125,20 +186,16 @@ func ResolveEffectivePath() (path string, exists bool, err error) {
 	return homePath, false, nil
 }
 
-// LoadOrDefault loads the policy from the default path, or returns an empty policy if not found.
+// LoadOrDefault loads the effective policy outside any session: the merged
+// system, user, and project layers, or DefaultPolicy when none exists.
 func LoadOrDefault() (*Policy, error) {
-	path, exists, err := ResolveEffectivePath()
-	if err != nil {
-		return nil, err
-	}
-	if !exists {
-		// Return default policy with common dangerous patterns
-		return DefaultPolicy(), nil
-	}
-
-	return Load(path)
+	return LoadForSession("")
 }
 
+// rmRecursiveForce matches rm -rf in any spelling: -fr, -r -f, -R,
+// --recursive --force.
+var rmRecursiveForce = StringList{"-r|-R|--recursive", "-f|--force"}
+
 // DefaultPolicy returns a sensible default policy for destructive command protection.
 func DefaultPolicy() *Policy {
 	p := &Policy{
@@ -151,25 +208,26 @@ func DefaultPolicy() *Policy {
 		},
 		// Allowed patterns checked FIRST - explicitly safe commands
 		Allowed: []Rule{
-			{Pattern: `git\s+push\s+.*--force-with-lease`, Reason: "Safe force push (prevents overwriting others' work)"},
+			{Command: "git", Arg: StringList{"push"}, Flag: StringList{"--force-with-lease"}, Reason: "Safe force push (prevents overwriting others' work)"},
 			{Pattern: `git\s+reset\s+--soft`, Reason: "Soft reset preserves changes"},
 			{Pattern: `git\s+reset\s+HEAD~?\d*$`, Reason: "Mixed reset preserves working directory"},
 		},
-		// Blocked patterns - dangerous commands (checked after allowed)
+		// Blocked rules - dangerous commands (checked after allowed). Flags
+		// are matched on argv, so order, clustering, and long forms agree.
 		Blocked: []Rule{
-			{Pattern: `git\s+reset\s+--hard`, Reason: "Hard reset loses uncommitted changes"},
-			{Pattern: `git\s+clean(\s+.*?)?\s(-[\w]*f[\w]*|--force)(\s|$)`, Reason: "Removes untracked files permanently"},
-			{Pattern: `git\s+push(\s+.*?)?\s(-[\w]*f[\w]*|--force)(\s|$)`, Reason: "Force push can overwrite remote history"},
-			{Pattern: `git\s+push(\s+.*?)?\s(\+|:\+)`, Reason: "Force push via +refspec can overwrite remote history"},
-			{Pattern: `rm\s+-rf\s+(/|~|\*|\.|\.\.)(/|\s|$)`, Reason: "Critical recursive deletion"},
-			{Pattern: `git\s+branch\s+-D`, Reason: "Force delete branch loses unmerged work"},
-			{Pattern: `git\s+stash\s+(drop|clear)`, Reason: "Losing stashed work"},
+			{Command: "git", Arg: StringList{"reset"}, Flag: StringList{"--hard"}, Reason: "Hard reset loses uncommitted changes"},
+			{Command: "git", Arg: StringList{"clean"}, Flag: StringList{"-f|--force"}, Reason: "Removes untracked files permanently"},
+			{Command: "git", Arg: StringList{"push"}, Flag: StringList{"-f|--force"}, Reason: "Force push can overwrite remote history"},
+			{Command: "git", Pattern: `(^|\s)push(\s.*)?\s:?\+\S`, Reason: "Force push via +refspec can overwrite remote history"},
+			{Command: "rm", Flag: rmRecursiveForce, Arg: StringList{"/", `/\*`, "~", "~/", `\*`, ".", "./", "..", "../"}, Reason: "Critical recursive deletion"},
+			{Command: "git", Arg: StringList{"branch"}, Flag: StringList{"-D|-d|--delete", "-D|-f|--force"}, Reason: "Force delete branch loses unmerged work"},
+			{Command: "git", Pattern: `(^|\s)stash(\s.*)?\s(drop|clear)(\s|$)`, Reason: "Losing stashed work"},
 		},
 		// Approval required - potentially dangerous commands
 		ApprovalRequired: []Rule{
-			{Pattern: `git\s+rebase(\s+.*?)?\s(-i|--interactive)`, Reason: "Interactive rebase rewrites history"},
-			{Pattern: `git\s+commit\s+--amend`, Reason: "Amending rewrites history"},
-			{Pattern: `rm\s+-rf\s+\S`, Reason: "Recursive force delete"},
+			{Command: "git", Arg: StringList{"rebase"}, Flag: StringList{"-i|--interactive"}, Reason: "Interactive rebase rewrites history"},
+			{Command: "git", Arg: StringList{"commit"}, Flag: StringList{"--amend"}, Reason: "Amending rewrites history"},
+			{Command: "rm", Flag: rmRecursiveForce, Reason: "Recursive force delete"},
 			{Pattern: `force_release`, Reason: "Force release another agent's reservation", SLB: true},
 		},
 	}
@@ -197,87 +255,312 @@ func (p *Policy) ToYAML() (string, error) {
 // non-matching). Returning all failures at once means a typo in
 // Blocked[0] does not silently disable Blocked[1..N] for the caller.
 func (p *Policy) compile() error {
-	var errs error
+	errs := errors.Join(
+		compileRules("blocked", p.Blocked),
+		compileRules("approval_required", p.ApprovalRequired),
+		compileRules("allowed", p.Allowed),
+	)
+	return errors.Join(errs, p.compileAutoRespond())
+}
 
-	for i := range p.Blocked {
+func compileRules(kind string, rules []Rule) error {
+	var errs error
+	for i := range rules {
+		r := &rules[i]
 		// Always reset compiled state first so a recompile after policy edits
 		// cannot keep matching an old regex when the new pattern is invalid.
-		p.Blocked[i].regex = nil
-		re, err := regexp.Compile(p.Blocked[i].Pattern)
+		r.regex = nil
+		if err := r.validateMatchers(); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("invalid %s rule %q: %w", kind, r.Describe(), err))
+		}
+		if err := r.When.validate(); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("invalid %s rule %q conditions: %w", kind, r.Describe(), err))
+		}
+		if r.Overridable && kind == ListAllowed {
+			errs = errors.Join(errs, fmt.Errorf("invalid %s rule %q: overridable applies only to blocked and approval_required rules", kind, r.Describe()))
+		}
+		if r.Pattern == "" && r.structured() {
+			continue
+		}
+		re, err := regexp.Compile(r.Pattern)
 		if err != nil {
-			errs = errors.Join(errs, fmt.Errorf("invalid blocked pattern %q: %w", p.Blocked[i].Pattern, err))
+			errs = errors.Join(errs, fmt.Errorf("invalid %s pattern %q: %w", kind, r.Pattern, err))
 			continue
 		}
-		p.Blocked[i].regex = re
+		r.regex = re
 	}
+	return errs
+}
 
-	for i := range p.ApprovalRequired {
-		p.ApprovalRequired[i].regex = nil
-		re, err := regexp.Compile(p.ApprovalRequired[i].Pattern)
-		if err != nil {
-			errs = errors.Join(errs, fmt.Errorf("invalid approval_required pattern %q: %w", p.ApprovalRequired[i].Pattern, err))
-			continue
+// structured reports whether the rule uses argv matchers.
+func (r *Rule) structured() bool {
+	return r.Command != "" || len(r.Flag) > 0 || len(r.Arg) > 0
+}
+
+func (r *Rule) validateMatchers() error {
+	if _, err := path.Match(r.Command, ""); err != nil {
+		return fmt.Errorf("command %q: %w", r.Command, err)
+	}
+	for _, arg := range r.Arg {
+		if _, err := path.Match(arg, ""); err != nil {
+			return fmt.Errorf("arg %q: %w", arg, err)
 		}
-		p.ApprovalRequired[i].regex = re
 	}
+	for _, entry := range r.Flag {
+		for _, flag := range strings.Split(entry, "|") {
+			if len(flag) < 2 || flag[0] != '-' || flag == "--" {
+				return fmt.Errorf("flag %q must start with - or --", flag)
+			}
+		}
+	}
+	return nil
+}
 
-	for i := range p.Allowed {
-		p.Allowed[i].regex = nil
-		re, err := regexp.Compile(p.Allowed[i].Pattern)
-		if err != nil {
-			errs = errors.Join(errs, fmt.Errorf("invalid allowed pattern %q: %w", p.Allowed[i].Pattern, err))
-			continue
+// Describe renders the rule's matchers for display: the pattern of a legacy
+// rule, or "command=rm flag=-r,-f arg=/|~" for an argv rule.
+func (r Rule) Describe() string {
+	if !r.structured() {
+		return r.Pattern
+	}
+	var parts []string
+	if r.Command != "" {
+		parts = append(parts, "command="+r.Command)
+	}
+	if len(r.Flag) > 0 {
+		parts = append(parts, "flag="+strings.Join(r.Flag, ","))
+	}
+	if len(r.Arg) > 0 {
+		parts = append(parts, "arg="+strings.Join(r.Arg, "|"))
+	}
+	if r.Pattern != "" {
+		parts = append(parts, "pattern="+r.Pattern)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Scope renders the rule's conditions for display ("agents=cod
+// branches=!agent/*"), or "" for a rule that applies everywhere.
+func (r Rule) Scope() string {
+	return r.When.describe()
+}
+
+// matches reports whether every matcher the rule sets matches sub.
+func (r *Rule) matches(sub ShellCommand) bool {
+	if r.Command != "" {
+		target := sub.Name
+		if strings.Contains(r.Command, "/") && len(sub.Argv) > 0 {
+			target = sub.Argv[0]
+		}
+		if !globMatch(r.Command, target) {
+			return false
+		}
+	}
+	for _, entry := range r.Flag {
+		if !anyFlag(sub, strings.Split(entry, "|")) {
+			return false
+		}
+	}
+	if len(r.Arg) > 0 && !anyGlobMatch(r.Arg, sub.operands()) {
+		return false
+	}
+	if r.Pattern == "" && r.structured() {
+		return true
+	}
+	if r.regex == nil {
+		return false
+	}
+	return r.regex.MatchString(sub.matchText()) || (sub.pipeline != "" && r.regex.MatchString(sub.pipeline))
+}
+
+func anyFlag(sub ShellCommand, flags []string) bool {
+	for _, flag := range flags {
+		if sub.hasFlag(flag) {
+			return true
 		}
-		p.Allowed[i].regex = re
 	}
+	return false
+}
 
-	return errs
+func anyGlobMatch(globs, values []string) bool {
+	for _, value := range values {
+		for _, glob := range globs {
+			if globMatch(glob, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// actionSeverity orders verdicts across sub-commands.
+func actionSeverity(a Action) int {
+	switch a {
+	case ActionBlock:
+		return 3
+	case ActionApprove:
+		return 2
+	case ActionAllow:
+		return 1
+	}
+	return 0
 }
 
 // Check evaluates a command against the policy and returns a match if found.
 // Returns nil if the command is not matched by any rule (implicitly allowed).
-// Order of precedence: allowed > blocked > approval_required
+// It is CheckContext with nothing known about where the command runs.
 func (p *Policy) Check(command string) *Match {
+	return p.CheckContext(command, Context{})
+}
+
+// CheckContext evaluates a command run in ctx against the policy.
+//
+// The command is parsed as shell and every sub-command it would run is
+// checked on its own (see ParseShell), so quoting, substitutions, and
+// wrappers such as sudo, xargs, or sh -c cannot hide a command, and a
+// dangerous string inside an argument does not trip a rule. Within one
+// sub-command the order of precedence is allowed > blocked >
+// approval_required; across sub-commands the most severe verdict wins.
+// Input that does not parse as shell is matched as one string.
+//
+// Rules with conditions apply only when ctx satisfies them. A condition on
+// something ctx leaves unknown holds for blocked and approval_required rules
+// but not for allowed ones.
+func (p *Policy) CheckContext(command string, ctx Context) *Match {
 	// Normalize command for matching
 	cmd := strings.TrimSpace(command)
+	subs := checkedSubCommands(cmd)
+	resolved := ctx.resolved()
+	scopes := p.scopes(resolved)
+
+	var verdict *Match
+	verdictDepth := 0
+	for _, sub := range subs {
+		for _, subScopes := range p.subCommandScopes(sub, resolved, scopes) {
+			m := p.checkSubCommand(cmd, sub, subScopes)
+			if m == nil {
+				continue
+			}
+			// On a tie, report the most deeply resolved sub-command: "rm -rf /"
+			// explains "sudo rm -rf /" better than the sudo line itself.
+			if verdict == nil || actionSeverity(m.Action) > actionSeverity(verdict.Action) ||
+				(m.Action == verdict.Action && len(sub.Via) > verdictDepth) {
+				verdict, verdictDepth = m, len(sub.Via)
+			}
+		}
+	}
+	return verdict
+}
+
+// subCommandScopes returns the rule scopes sub is checked under: scopes
+// itself, or for a `git push` one set per destination branch (see
+// subCommandContexts).
+func (p *Policy) subCommandScopes(sub ShellCommand, ctx Context, scopes ruleScopes) []ruleScopes {
+	ctxs := subCommandContexts(sub, ctx)
+	if ctxs == nil {
+		return []ruleScopes{scopes}
+	}
+	out := make([]ruleScopes, 0, len(ctxs))
+	for _, c := range ctxs {
+		out = append(out, p.scopes(c))
+	}
+	return out
+}
+
+// checkedSubCommands parses cmd for Check, falling back to the whole string
+// when it is not shell. Without command boundaries every word may start a
+// command, so the fallback offers argv rules each word onward.
+func checkedSubCommands(cmd string) []ShellCommand {
+	subs, err := ParseShell(cmd)
+	if err == nil && len(subs) > 0 {
+		return subs
+	}
+	words := strings.Fields(cmd)
+	if len(words) == 0 {
+		return []ShellCommand{{raw: cmd}}
+	}
+	n := min(len(words), maxShellCommands)
+	fallback := make([]ShellCommand, n)
+	for i := range fallback {
+		fallback[i] = ShellCommand{Name: path.Base(words[i]), Argv: words[i:], raw: cmd}
+	}
+	return fallback
+}
+
+// ruleScopes records, per rule list, which rules apply in a context.
+type ruleScopes struct {
+	allowed, blocked, approval []bool
+}
+
+func (p *Policy) scopes(ctx Context) ruleScopes {
+	eval := func(rules []Rule, restrictive bool) []bool {
+		in := make([]bool, len(rules))
+		for i := range rules {
+			in[i] = inScope(rules[i].When.evaluate(ctx), restrictive)
+		}
+		return in
+	}
+	return ruleScopes{
+		allowed:  eval(p.Allowed, false),
+		blocked:  eval(p.Blocked, true),
+		approval: eval(p.ApprovalRequired, true),
+	}
+}
 
-	// Check allowed first (explicit allowlist takes precedence)
-	for _, rule := range p.Allowed {
-		if rule.regex != nil && rule.regex.MatchString(cmd) {
-			return &Match{
-				Action:  ActionAllow,
-				Pattern: rule.Pattern,
-				Reason:  rule.Reason,
-				Command: cmd,
+func (p *Policy) checkSubCommand(cmd string, sub ShellCommand, scopes ruleScopes) *Match {
+	match := func(action Action, list string, index int, rule *Rule) *Match {
+		m := &Match{
+			Action:  action,
+			Pattern: rule.Describe(),
+			Reason:  rule.Reason,
+			Command: cmd,
+			Scope:   rule.Scope(),
+			Source:  rule.source,
+			list:    list,
+			index:   index,
+		}
+		if sub.raw == "" {
+			m.SubCommand = sub.String()
+		}
+		return m
+	}
+
+	// Allowed rules take precedence over the restrictive rules they
+	// override: all of them within one policy layer (see allowOverrides).
+	var allowed []int
+	for i := range p.Allowed {
+		if scopes.allowed[i] && p.Allowed[i].matches(sub) {
+			allowed = append(allowed, i)
+		}
+	}
+	stands := func(r *Rule) bool {
+		for _, i := range allowed {
+			if allowOverrides(&p.Allowed[i], r) {
+				return false
 			}
 		}
+		return true
 	}
 
 	// Check blocked patterns
-	for _, rule := range p.Blocked {
-		if rule.regex != nil && rule.regex.MatchString(cmd) {
-			return &Match{
-				Action:  ActionBlock,
-				Pattern: rule.Pattern,
-				Reason:  rule.Reason,
-				Command: cmd,
-			}
+	for i := range p.Blocked {
+		if scopes.blocked[i] && p.Blocked[i].matches(sub) && stands(&p.Blocked[i]) {
+			return match(ActionBlock, ListBlocked, i, &p.Blocked[i])
 		}
 	}
 
 	// Check approval required patterns
-	for _, rule := range p.ApprovalRequired {
-		if rule.regex != nil && rule.regex.MatchString(cmd) {
-			return &Match{
-				Action:  ActionApprove,
-				Pattern: rule.Pattern,
-				Reason:  rule.Reason,
-				Command: cmd,
-				SLB:     rule.SLB,
-			}
+	for i := range p.ApprovalRequired {
+		if scopes.approval[i] && p.ApprovalRequired[i].matches(sub) && stands(&p.ApprovalRequired[i]) {
+			m := match(ActionApprove, ListApprovalRequired, i, &p.ApprovalRequired[i])
+			m.SLB = p.ApprovalRequired[i].SLB
+			return m
 		}
 	}
 
+	if len(allowed) > 0 {
+		return match(ActionAllow, ListAllowed, allowed[0], &p.Allowed[allowed[0]])
+	}
+
 	// No match - implicitly allowed
 	return nil
 }
@@ -44,12 +44,31 @@ func TestCheck_Blocked(t *testing.T) {
 		{"git stash drop", "git stash drop", true},
 		{"git stash clear", "git stash clear", true},
 
+		// Reordered, split, and long-form flags
+		{"rm -fr /", "rm -fr /", true},
+		{"rm -r -f /", "rm -r -f /", true},
+		{"rm --recursive --force /", "rm --recursive --force /", true},
+		{"rm -f -R ~", "rm -f -R ~", true},
+		{"rm -rf ../", "rm -rf ../", true},
+		{"git reset -q --hard", "git reset -q --hard HEAD~1", true},
+		{"git -C reset --hard", "git -C repo reset --hard", true},
+		{"git clean -d -f", "git clean -d -f", true},
+		{"git clean --force", "git clean --force -x", true},
+		{"git push -uf", "git push -uf origin main", true},
+		{"git push +refspec", "git push origin +main", true},
+		{"git branch --delete --force", "git branch --delete --force feature", true},
+		{"git branch -d -f", "git branch -d -f feature", true},
+		{"git stash -q drop", "git stash -q drop", true},
+
 		// Not blocked
 		{"git status", "git status", false},
 		{"git add", "git add .", false},
 		{"git commit", "git commit -m 'test'", false},
 		{"git push", "git push origin main", false},
 		{"rm file", "rm file.txt", false},
+		{"rm -rf build", "rm --recursive --force build", false},
+		{"git branch -d", "git branch -d feature", false},
+		{"git clean -n", "git clean -n", false},
 		{"git reset --soft", "git reset --soft HEAD~1", false},
 		// force-with-lease is explicitly allowed (takes precedence)
 		{"git push --force-with-lease", "git push --force-with-lease", false},
@@ -75,6 +94,10 @@ func TestCheck_ApprovalRequired(t *testing.T) {
 		{"git rebase -i", "git rebase -i HEAD~3", true},
 		{"git commit --amend", "git commit --amend", true},
 		{"rm -rf (general)", "rm -rf node_modules", true},
+		{"rm -r -f (general)", "rm -r -f node_modules", true},
+		{"rm --recursive --force", "rm --recursive --force node_modules", true},
+		{"git rebase --interactive", "git rebase --interactive main", true},
+		{"git commit -a --amend", "git commit -a --amend", true},
 
 		// Not requiring approval
 		{"git status", "git status", false},
@@ -142,6 +165,115 @@ func TestCheck_NoMatch(t *testing.T) {
 	}
 }
 
+func TestCheck_ResolvesShellSubCommands(t *testing.T) {
+	p := DefaultPolicy()
+
+	cases := []struct {
+		command string
+		action  Action // "" means no match
+		sub     string
+	}{
+		{`r''m -rf /`, ActionBlock, "rm -rf /"},
+		{`$(printf rm) -rf /`, ActionBlock, "rm -rf /"},
+		{`echo / | xargs rm -rf`, ActionBlock, "rm -rf /"},
+		{`xargs rm -rf /`, ActionBlock, "rm -rf /"},
+		{`sudo rm -rf /`, ActionBlock, "rm -rf /"},
+		{`sh -c 'git status; git reset --hard'`, ActionBlock, "git reset --hard"},
+		{`git push --force-with-lease && git branch -D old`, ActionBlock, "git branch -D old"},
+		{`cd build && rm -rf node_modules`, ActionApprove, "rm -rf node_modules"},
+		// A dangerous string in an argument is data, not a command.
+		{`echo "rm -rf /"`, "", ""},
+		{`git commit -m "stop running git reset --hard"`, "", ""},
+	}
+	for _, tc := range cases {
+		match := p.Check(tc.command)
+		if tc.action == "" {
+			if match != nil {
+				t.Errorf("Check(%q) = %+v, want nil", tc.command, match)
+			}
+			continue
+		}
+		if match == nil || match.Action != tc.action || match.SubCommand != tc.sub {
+			t.Errorf("Check(%q) = %+v, want %s on %q", tc.command, match, tc.action, tc.sub)
+		}
+		if match != nil && match.Command != tc.command {
+			t.Errorf("Check(%q).Command = %q, want the full command", tc.command, match.Command)
+		}
+	}
+}
+
+func TestCheck_UnparseableFallsBackToWholeString(t *testing.T) {
+	for _, cmd := range []string{"don't rm -rf /", "don't rm --recursive --force /"} {
+		match := DefaultPolicy().Check(cmd)
+		if match == nil || match.Action != ActionBlock || match.SubCommand != "" {
+			t.Fatalf("Check(%q) on unparseable input = %+v, want whole-string block", cmd, match)
+		}
+	}
+}
+
+func TestCheck_ArgvRules(t *testing.T) {
+	p, err := DecodeYAML([]byte(`version: 1
+allowed:
+  - command: git
+    arg: push
+    flag: --force-with-lease
+blocked:
+  - command: rm
+    flag: [-r, -f]
+    arg: ["/", "~", "/*"]
+    reason: critical delete
+  - command: git
+    arg: push
+    flag: --force
+approval_required:
+  - command: "my*"
+    pattern: DROP
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		command string
+		action  Action
+	}{
+		{"rm -rf /", ActionBlock},
+		{"rm -f -r ~", ActionBlock},
+		{"sudo rm -Rfr /*", ActionBlock},
+		{"rm -rf ./build", ""},
+		{"rm -r /", ""},
+		{"git push --force origin main", ActionBlock},
+		{"git push --force --force-with-lease", ActionAllow},
+		{"psql -c 'DROP TABLE users'", ""},
+		{"mysql DROP", ActionApprove},
+	}
+	for _, tc := range cases {
+		match := p.Check(tc.command)
+		var got Action
+		if match != nil {
+			got = match.Action
+		}
+		if got != tc.action {
+			t.Errorf("Check(%q) = %q, want %q", tc.command, got, tc.action)
+		}
+	}
+	if got := p.Check("rm -rf /").Pattern; got != "command=rm flag=-r,-f arg=/|~|/*" {
+		t.Errorf("Pattern = %q, want the rule description", got)
+	}
+}
+
+func TestValidate_RejectsMalformedMatchers(t *testing.T) {
+	for _, rule := range []Rule{{Command: "[rm"}, {Arg: StringList{"[/"}}, {Flag: StringList{"force"}}, {Flag: StringList{"-r|recursive"}}} {
+		p := &Policy{Blocked: []Rule{rule}}
+		if err := p.Validate(); err == nil || !strings.Contains(err.Error(), "invalid blocked rule") {
+			t.Errorf("Validate(%+v) = %v, want invalid blocked rule", rule, err)
+		}
+	}
+}
+
 func TestAutomationConfig(t *testing.T) {
 	p := DefaultPolicy()
 
@@ -777,7 +909,7 @@ func TestResolveEffectivePath_PrefersProjectPolicyWhenNoHomePolicy(t *testing.T)
 		t.Fatalf("resolved path = %q, want the project-local policy", path)
 	}
 
-	// A home policy takes precedence once it exists, matching LoadOrDefault.
+	// Writers target the home policy once it exists; LoadOrDefault merges both.
 	homePolicy := filepath.Join(home, DefaultPolicyPath)
 	if err := os.MkdirAll(filepath.Dir(homePolicy), 0o755); err != nil {
 		t.Fatalf("mkdir home policy: %v", err)
@@ -0,0 +1,1497 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// ShellCommand is one simple command a shell command line would run, after
+// quote removal, static expansion, and wrapper resolution.
+type ShellCommand struct {
+	// Name is the base name of Argv[0].
+	Name string
+	// Argv holds the command words. Assignments and redirections are
+	// dropped; expansions whose value cannot be known statically keep their
+	// source text ("$HOME", "$(date)").
+	Argv []string
+	// Via lists the wrappers this command was resolved through, outermost
+	// first ("sudo", "sh -c", "xargs", ...). Empty for commands written
+	// directly on the line.
+	Via []string
+
+	// pipeline is the rendered text of the multi-stage pipeline the command
+	// belongs to, so legacy patterns that span a pipe still match.
+	pipeline string
+	// raw, when set, replaces the rendered argv for pattern matching. It
+	// carries the whole input when the line did not parse as shell.
+	raw string
+}
+
+const (
+	// maxShellDepth bounds nested sh -c / eval / env -S scripts.
+	maxShellDepth = 8
+	// maxShellCommands bounds the commands one line may expand to.
+	maxShellCommands = 512
+)
+
+// ParseShell parses a POSIX/bash command line into every simple command it
+// would run: pipeline stages, && / || / ; list members, subshell and group
+// bodies, command and process substitutions, and the commands behind
+// wrappers such as env, sudo, xargs, find -exec, eval, and sh -c. A wrapper
+// is reported itself and again as the command it runs. Nothing is executed;
+// only echo/printf output and assignments made earlier on the same line are
+// resolved statically.
+func ParseShell(line string) ([]ShellCommand, error) {
+	var out []ShellCommand
+	p := &shellParser{src: []rune(line), vars: map[string]string{}, out: &out}
+	if err := p.parseScript(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// String renders the command's argv with shell quoting where needed.
+func (c ShellCommand) String() string {
+	parts := make([]string, len(c.Argv))
+	for i, arg := range c.Argv {
+		parts[i] = shellQuote(arg)
+	}
+	return strings.Join(parts, " ")
+}
+
+// matchText renders argv for legacy regex patterns. Whitespace inside a
+// single word becomes a no-break space, which \s does not match, so a
+// quoted argument such as echo "rm -rf /" cannot read as a command.
+func (c ShellCommand) matchText() string {
+	if c.raw != "" {
+		return c.raw
+	}
+	return renderMatchText(c.Argv)
+}
+
+func renderMatchText(argv []string) string {
+	parts := make([]string, len(argv))
+	for i, arg := range argv {
+		parts[i] = strings.Map(func(r rune) rune {
+			switch r {
+			case ' ', '\t', '\n', '\r', '\f', '\v':
+				return '\u00a0'
+			}
+			return r
+		}, arg)
+	}
+	return strings.Join(parts, " ")
+}
+
+// operands returns the words after the command name that are not options.
+// Everything after "--" is an operand.
+func (c ShellCommand) operands() []string {
+	var ops []string
+	endOfOptions := false
+	for _, arg := range c.argvTail() {
+		switch {
+		case endOfOptions:
+			ops = append(ops, arg)
+		case arg == "--":
+			endOfOptions = true
+		case len(arg) > 1 && arg[0] == '-':
+		default:
+			ops = append(ops, arg)
+		}
+	}
+	return ops
+}
+
+// hasFlag reports whether the command was given flag. Long flags match
+// "--name" and "--name=value"; short flags also match inside a combined
+// cluster, so -r and -f both match "-rf". A multi-letter short flag such as
+// "-rf" requires each of its letters unless it appears verbatim.
+func (c ShellCommand) hasFlag(flag string) bool {
+	var clusters []string
+	for _, arg := range c.argvTail() {
+		if arg == "--" {
+			break
+		}
+		if arg == flag || (strings.HasPrefix(flag, "--") && strings.HasPrefix(arg, flag+"=")) {
+			return true
+		}
+		if len(arg) > 1 && arg[0] == '-' && !strings.HasPrefix(arg, "--") {
+			clusters = append(clusters, arg[1:])
+		}
+	}
+	if strings.HasPrefix(flag, "--") || len(flag) < 2 || flag[0] != '-' {
+		return false
+	}
+	for _, letter := range flag[1:] {
+		found := false
+		for _, cluster := range clusters {
+			if strings.ContainsRune(cluster, letter) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (c ShellCommand) argvTail() []string {
+	if len(c.Argv) == 0 {
+		return nil
+	}
+	return c.Argv[1:]
+}
+
+// globMatch matches a rule glob against a value; a malformed glob only
+// matches itself.
+func globMatch(pattern, value string) bool {
+	if pattern == value {
+		return true
+	}
+	ok, err := path.Match(pattern, value)
+	return err == nil && ok
+}
+
+// shellQuote single-quotes s when it holds whitespace, quotes, or shell
+// operators. Expansion and glob characters stay bare so unresolved
+// expansions read as written.
+func shellQuote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	if !strings.ContainsAny(s, " \t\n\r'\"\\`;&|<>()#") {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+type shellParser struct {
+	src      []rune
+	pos      int
+	vars     map[string]string
+	via      []string
+	depth    int
+	out      *[]ShellCommand
+	heredocs []pendingHeredoc
+}
+
+// shellWord is one word after quote removal.
+type shellWord struct {
+	value string
+	// dynamic: the word holds an expansion whose value is unknown.
+	dynamic bool
+	// split: the word holds an unquoted expansion subject to field
+	// splitting.
+	split  bool
+	quoted bool
+	// assignLen is the length of NAME in a NAME=value word, or -1.
+	assignLen int
+	comment   bool
+}
+
+// pendingCommand is a simple command whose heredoc bodies may still be
+// unread. Compound commands (subshells, arithmetic) leave words empty.
+type pendingCommand struct {
+	words  []shellWord
+	stdin  *string
+	assign map[string]*shellWord
+}
+
+type pendingHeredoc struct {
+	delim string
+	strip bool
+	cmd   *pendingCommand
+}
+
+func (p *shellParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("shell syntax: "+format+" at offset %d", append(args, p.pos)...)
+}
+
+func (p *shellParser) atEnd() bool { return p.pos >= len(p.src) }
+
+func (p *shellParser) hasPrefix(s string) bool {
+	i := p.pos
+	for _, r := range s {
+		if i >= len(p.src) || p.src[i] != r {
+			return false
+		}
+		i++
+	}
+	return true
+}
+
+func (p *shellParser) parseScript() error {
+	if p.depth > maxShellDepth {
+		return errors.New("shell syntax: nesting too deep")
+	}
+	list, err := p.parseList(nil)
+	if err != nil {
+		return err
+	}
+	if !p.atEnd() {
+		return p.errorf("unexpected %q", p.src[p.pos])
+	}
+	return p.finish(list)
+}
+
+// parseNested parses script with a child parser that shares this parser's
+// output, as the commands run by a wrapper.
+func (p *shellParser) parseNested(script string, via []string) error {
+	vars := make(map[string]string, len(p.vars))
+	for k, v := range p.vars {
+		vars[k] = v
+	}
+	child := &shellParser{src: []rune(script), vars: vars, via: via, depth: p.depth + 1, out: p.out}
+	return child.parseScript()
+}
+
+// skipBlanks skips spaces, tabs, and backslash-newline continuations.
+func (p *shellParser) skipBlanks() {
+	for !p.atEnd() {
+		switch {
+		case p.src[p.pos] == ' ' || p.src[p.pos] == '\t' || p.src[p.pos] == '\r':
+			p.pos++
+		case p.hasPrefix("\\\n"):
+			p.pos += 2
+		default:
+			return
+		}
+	}
+}
+
+// consumeNewline consumes a newline and the bodies of heredocs opened on
+// the line it ends.
+func (p *shellParser) consumeNewline() {
+	p.pos++
+	pending := p.heredocs
+	p.heredocs = nil
+	for _, h := range pending {
+		var body strings.Builder
+		for !p.atEnd() {
+			end := p.pos
+			for end < len(p.src) && p.src[end] != '\n' {
+				end++
+			}
+			line := string(p.src[p.pos:end])
+			p.pos = end
+			if !p.atEnd() {
+				p.pos++
+			}
+			if h.strip {
+				line = strings.TrimLeft(line, "\t")
+			}
+			if line == h.delim {
+				break
+			}
+			body.WriteString(line)
+			body.WriteByte('\n')
+		}
+		text := body.String()
+		h.cmd.stdin = &text
+	}
+}
+
+func (p *shellParser) skipBlanksAndNewlines() {
+	for {
+		p.skipBlanks()
+		if p.atEnd() || p.src[p.pos] != '\n' {
+			return
+		}
+		p.consumeNewline()
+	}
+}
+
+// atTerminator reports whether the input continues with one of terms:
+// operators such as ")" or ";;", or keywords such as "esac".
+func (p *shellParser) atTerminator(terms []string) bool {
+	for _, term := range terms {
+		if isShellKeyword(term) {
+			if p.peekKeyword(term) {
+				return true
+			}
+		} else if p.hasPrefix(term) {
+			return true
+		}
+	}
+	return false
+}
+
+func isShellKeyword(s string) bool {
+	return s != "" && s[0] >= 'a' && s[0] <= 'z'
+}
+
+// peekKeyword reports whether the next word is exactly the unquoted word kw.
+func (p *shellParser) peekKeyword(kw string) bool {
+	if !p.hasPrefix(kw) {
+		return false
+	}
+	next := p.pos + len([]rune(kw))
+	return next >= len(p.src) || isWordBreak(p.src[next])
+}
+
+func isWordBreak(r rune) bool {
+	switch r {
+	case ' ', '\t', '\r', '\n', '|', '&', ';', '(', ')', '<', '>':
+		return true
+	}
+	return false
+}
+
+// parseList parses pipelines separated by ;, &, &&, ||, and newlines until
+// the input ends or one of terms is next.
+func (p *shellParser) parseList(terms []string) ([][]*pendingCommand, error) {
+	var list [][]*pendingCommand
+	for {
+		for {
+			p.skipBlanks()
+			if p.atEnd() || p.atTerminator(terms) {
+				return list, nil
+			}
+			switch {
+			case p.src[p.pos] == '\n':
+				p.consumeNewline()
+				continue
+			case p.hasPrefix("&&"), p.hasPrefix("||"):
+				p.pos += 2
+				continue
+			case p.src[p.pos] == ';', p.src[p.pos] == '&' && !p.hasPrefix("&>"):
+				p.pos++
+				continue
+			}
+			break
+		}
+		if p.src[p.pos] == ')' {
+			return nil, p.errorf("unexpected %q", ')')
+		}
+		stages, err := p.parsePipeline()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, stages)
+	}
+}
+
+func (p *shellParser) parsePipeline() ([]*pendingCommand, error) {
+	var stages []*pendingCommand
+	for {
+		cmd, err := p.parseCommand()
+		if err != nil {
+			return nil, err
+		}
+		stages = append(stages, cmd)
+		p.skipBlanks()
+		if p.hasPrefix("||") || !p.hasPrefix("|") {
+			return stages, nil
+		}
+		p.pos++
+		if p.hasPrefix("&") {
+			p.pos++
+		}
+		p.skipBlanksAndNewlines()
+	}
+}
+
+// shellReserved are words skipped in command position; the commands they
+// introduce or close are parsed as ordinary commands.
+var shellReserved = map[string]bool{
+	"if": true, "then": true, "else": true, "elif": true, "fi": true,
+	"do": true, "done": true, "while": true, "until": true,
+	"!": true, "{": true, "}": true, "coproc": true,
+}
+
+func (p *shellParser) parseCommand() (*pendingCommand, error) {
+	cmd := &pendingCommand{}
+	p.skipBlanks()
+	switch {
+	case p.hasPrefix("(("):
+		if _, err := p.readBalanced('(', ')'); err != nil {
+			return nil, err
+		}
+		return cmd, p.parseRedirects(cmd)
+	case p.hasPrefix("("):
+		p.pos++
+		if err := p.parseGroup(")"); err != nil {
+			return nil, err
+		}
+		return cmd, p.parseRedirects(cmd)
+	}
+
+	for {
+		p.skipBlanks()
+		if p.atEnd() {
+			break
+		}
+		c := p.src[p.pos]
+		if c == '\n' || c == ';' || c == '|' || c == ')' || (c == '&' && !p.hasPrefix("&>")) {
+			break
+		}
+		if c == '(' {
+			// "name()" defines a function; its body parses as ordinary
+			// commands.
+			if len(cmd.words) == 1 && p.skipFunctionParens() {
+				return &pendingCommand{}, nil
+			}
+			return nil, p.errorf("unexpected %q", '(')
+		}
+		if (c == '<' || c == '>' || p.hasPrefix("&>")) && !p.hasPrefix("<(") && !p.hasPrefix(">(") {
+			if err := p.parseRedirect(cmd); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		w, err := p.readWord()
+		if err != nil {
+			return nil, err
+		}
+		if w.comment {
+			break
+		}
+		if !w.quoted && isDigits(w.value) && !p.atEnd() && (p.src[p.pos] == '<' || p.src[p.pos] == '>') {
+			if err := p.parseRedirect(cmd); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if len(cmd.words) == 0 && !w.quoted {
+			switch {
+			case shellReserved[w.value]:
+				continue
+			case w.value == "for" || w.value == "select":
+				return cmd, p.skipLoopHeader()
+			case w.value == "case":
+				return cmd, p.parseCase()
+			case w.value == "function":
+				if _, err := p.readWord(); err != nil {
+					return nil, err
+				}
+				p.skipBlanks()
+				p.skipFunctionParens()
+				return cmd, nil
+			case w.assignLen >= 0:
+				if cmd.assign == nil {
+					cmd.assign = map[string]*shellWord{}
+				}
+				word := w
+				cmd.assign[w.value[:w.assignLen]] = &word
+				continue
+			}
+		}
+		cmd.words = append(cmd.words, w)
+	}
+
+	if len(cmd.words) == 0 {
+		// A bare assignment sets a shell variable for the rest of the line.
+		for name, w := range cmd.assign {
+			name = strings.TrimSuffix(name, "+")
+			if w.dynamic {
+				delete(p.vars, name)
+				continue
+			}
+			p.vars[name] = w.value[w.assignLen+1:]
+		}
+	}
+	return cmd, nil
+}
+
+// parseGroup parses a subshell body through its closing delimiter.
+func (p *shellParser) parseGroup(closer string) error {
+	list, err := p.parseList([]string{closer})
+	if err != nil {
+		return err
+	}
+	if !p.hasPrefix(closer) {
+		return p.errorf("unterminated %q", "(")
+	}
+	p.pos += len([]rune(closer))
+	return p.finish(list)
+}
+
+func (p *shellParser) parseRedirects(cmd *pendingCommand) error {
+	for {
+		p.skipBlanks()
+		if p.atEnd() {
+			return nil
+		}
+		if p.src[p.pos] != '<' && p.src[p.pos] != '>' && !p.hasPrefix("&>") {
+			return nil
+		}
+		if err := p.parseRedirect(cmd); err != nil {
+			return err
+		}
+	}
+}
+
+// parseRedirect consumes one redirection operator and its target word.
+// Heredoc and here-string bodies become the command's stdin.
+func (p *shellParser) parseRedirect(cmd *pendingCommand) error {
+	var op string
+	for _, candidate := range []string{"<<<", "<<-", "<<", "<&", "<>", "&>>", "&>", ">>", ">&", ">|", "<", ">"} {
+		if p.hasPrefix(candidate) {
+			op = candidate
+			break
+		}
+	}
+	p.pos += len(op)
+	p.skipBlanks()
+	target, err := p.readWord()
+	if err != nil {
+		return err
+	}
+	if target.comment || (target.value == "" && !target.quoted) {
+		return p.errorf("missing redirection target after %q", op)
+	}
+	switch op {
+	case "<<", "<<-":
+		p.heredocs = append(p.heredocs, pendingHeredoc{delim: target.value, strip: op == "<<-", cmd: cmd})
+	case "<<<":
+		if !target.dynamic {
+			text := target.value + "\n"
+			cmd.stdin = &text
+		}
+	}
+	return nil
+}
+
+// skipFunctionParens consumes the "()" of a function definition.
+func (p *shellParser) skipFunctionParens() bool {
+	save := p.pos
+	if !p.hasPrefix("(") {
+		return false
+	}
+	p.pos++
+	p.skipBlanks()
+	if !p.hasPrefix(")") {
+		p.pos = save
+		return false
+	}
+	p.pos++
+	return true
+}
+
+// skipLoopHeader consumes the rest of a for/select header up to its
+// separator; the loop body then parses as ordinary commands.
+func (p *shellParser) skipLoopHeader() error {
+	for {
+		p.skipBlanks()
+		if p.atEnd() || p.src[p.pos] == '\n' || p.src[p.pos] == ';' {
+			return nil
+		}
+		if p.hasPrefix("((") {
+			if _, err := p.readBalanced('(', ')'); err != nil {
+				return err
+			}
+			continue
+		}
+		w, err := p.readWord()
+		if err != nil {
+			return err
+		}
+		if w.comment {
+			return nil
+		}
+		if w.value == "" && !w.quoted {
+			return p.errorf("unexpected %q", p.src[p.pos])
+		}
+	}
+}
+
+// parseCase parses "case WORD in PATTERN) LIST ;; ... esac", reporting the
+// commands of every branch.
+func (p *shellParser) parseCase() error {
+	for {
+		p.skipBlanksAndNewlines()
+		if p.atEnd() {
+			return p.errorf("unterminated %q", "case")
+		}
+		w, err := p.readWord()
+		if err != nil {
+			return err
+		}
+		if w.value == "in" && !w.quoted {
+			break
+		}
+		if w.value == "" && !w.quoted {
+			return p.errorf("unexpected %q", p.src[p.pos])
+		}
+	}
+	for {
+		p.skipBlanksAndNewlines()
+		if p.atEnd() {
+			return p.errorf("unterminated %q", "case")
+		}
+		if p.peekKeyword("esac") {
+			p.pos += len("esac")
+			return nil
+		}
+		if p.hasPrefix("(") {
+			p.pos++
+		}
+		for {
+			p.skipBlanks()
+			if p.atEnd() {
+				return p.errorf("unterminated %q", "case")
+			}
+			if p.src[p.pos] == ')' {
+				p.pos++
+				break
+			}
+			if p.src[p.pos] == '|' {
+				p.pos++
+				continue
+			}
+			w, err := p.readWord()
+			if err != nil {
+				return err
+			}
+			if w.value == "" && !w.quoted {
+				return p.errorf("unexpected %q", p.src[p.pos])
+			}
+		}
+		list, err := p.parseList([]string{";;&", ";;", ";&", "esac"})
+		if err != nil {
+			return err
+		}
+		if err := p.finish(list); err != nil {
+			return err
+		}
+		for _, term := range []string{";;&", ";;", ";&"} {
+			if p.hasPrefix(term) {
+				p.pos += len(term)
+				break
+			}
+		}
+	}
+}
+
+// readBalanced consumes an open..close delimited region starting at the
+// current open rune and returns it verbatim.
+func (p *shellParser) readBalanced(open, close rune) (string, error) {
+	start := p.pos
+	depth := 0
+	for !p.atEnd() {
+		switch p.src[p.pos] {
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				p.pos++
+				return string(p.src[start:p.pos]), nil
+			}
+		}
+		p.pos++
+	}
+	return "", p.errorf("unterminated %q", open)
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isNameRune(r rune, first bool) bool {
+	return r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (!first && r >= '0' && r <= '9')
+}
+
+func isAssignName(s string) bool {
+	s = strings.TrimSuffix(s, "+")
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if !isNameRune(r, i == 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// readWord reads one word, removing quotes and resolving expansions it can
+// know statically.
+func (p *shellParser) readWord() (shellWord, error) {
+	w := shellWord{assignLen: -1}
+	var b strings.Builder
+	start := p.pos
+	literal := true // only unquoted literal runes so far
+	for !p.atEnd() {
+		c := p.src[p.pos]
+		switch {
+		case c == '#' && p.pos == start:
+			for !p.atEnd() && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+			w.comment = true
+			return w, nil
+		case (c == '<' || c == '>') && p.pos == start && p.hasPrefix(string(c)+"("):
+			// Process substitution: the inner list runs; the word is a
+			// /dev/fd path.
+			p.pos += 2
+			if err := p.parseGroup(")"); err != nil {
+				return w, err
+			}
+			b.WriteString("/dev/fd/63")
+			w.dynamic = true
+			literal = false
+		case isWordBreak(c):
+			w.value = b.String()
+			return w, nil
+		case c == '\\':
+			if p.hasPrefix("\\\n") {
+				p.pos += 2
+				continue
+			}
+			p.pos++
+			if !p.atEnd() {
+				b.WriteRune(p.src[p.pos])
+				p.pos++
+			} else {
+				b.WriteRune('\\')
+			}
+			w.quoted = true
+			literal = false
+		case c == '\'':
+			end := p.pos + 1
+			for end < len(p.src) && p.src[end] != '\'' {
+				end++
+			}
+			if end >= len(p.src) {
+				return w, p.errorf("unterminated single quote")
+			}
+			b.WriteString(string(p.src[p.pos+1 : end]))
+			p.pos = end + 1
+			w.quoted = true
+			literal = false
+		case c == '"':
+			p.pos++
+			if err := p.readDoubleQuoted(&b, &w); err != nil {
+				return w, err
+			}
+			w.quoted = true
+			literal = false
+		case c == '$':
+			if err := p.readDollar(&b, &w, false); err != nil {
+				return w, err
+			}
+			literal = false
+		case c == '`':
+			if err := p.readBackquote(&b, &w, false); err != nil {
+				return w, err
+			}
+			literal = false
+		case c == '=' && literal && w.assignLen < 0 && isAssignName(b.String()):
+			w.assignLen = len(b.String())
+			b.WriteRune(c)
+			p.pos++
+			if p.hasPrefix("(") {
+				// Array assignment: NAME=(a b c).
+				raw, err := p.readBalanced('(', ')')
+				if err != nil {
+					return w, err
+				}
+				b.WriteString(raw)
+				w.dynamic = true
+			}
+		default:
+			b.WriteRune(c)
+			p.pos++
+		}
+	}
+	w.value = b.String()
+	return w, nil
+}
+
+func (p *shellParser) readDoubleQuoted(b *strings.Builder, w *shellWord) error {
+	for !p.atEnd() {
+		c := p.src[p.pos]
+		switch c {
+		case '"':
+			p.pos++
+			return nil
+		case '\\':
+			if p.pos+1 < len(p.src) {
+				next := p.src[p.pos+1]
+				switch next {
+				case '\n':
+				case '$', '`', '"', '\\':
+					b.WriteRune(next)
+				default:
+					b.WriteRune('\\')
+					b.WriteRune(next)
+				}
+				p.pos += 2
+				continue
+			}
+			b.WriteRune(c)
+			p.pos++
+		case '$':
+			if err := p.readDollar(b, w, true); err != nil {
+				return err
+			}
+		case '`':
+			if err := p.readBackquote(b, w, true); err != nil {
+				return err
+			}
+		default:
+			b.WriteRune(c)
+			p.pos++
+		}
+	}
+	return p.errorf("unterminated double quote")
+}
+
+// readDollar reads an expansion starting at '$'.
+func (p *shellParser) readDollar(b *strings.Builder, w *shellWord, inDouble bool) error {
+	start := p.pos
+	switch {
+	case !inDouble && p.hasPrefix("$'"):
+		p.pos += 2
+		var raw strings.Builder
+		for {
+			if p.atEnd() {
+				return p.errorf("unterminated single quote")
+			}
+			c := p.src[p.pos]
+			if c == '\'' {
+				p.pos++
+				break
+			}
+			if c == '\\' && p.pos+1 < len(p.src) {
+				raw.WriteRune(c)
+				raw.WriteRune(p.src[p.pos+1])
+				p.pos += 2
+				continue
+			}
+			raw.WriteRune(c)
+			p.pos++
+		}
+		b.WriteString(unescapeBackslashes(raw.String()))
+		w.quoted = true
+		return nil
+	case !inDouble && p.hasPrefix("$\""):
+		p.pos += 2
+		w.quoted = true
+		return p.readDoubleQuoted(b, w)
+	case p.hasPrefix("$(("):
+		p.pos++
+		raw, err := p.readBalanced('(', ')')
+		if err != nil {
+			return err
+		}
+		b.WriteString("$" + raw)
+		w.dynamic = true
+		return nil
+	case p.hasPrefix("$("):
+		p.pos += 2
+		list, err := p.parseList([]string{")"})
+		if err != nil {
+			return err
+		}
+		if !p.hasPrefix(")") {
+			return p.errorf("unterminated command substitution")
+		}
+		p.pos++
+		if err := p.finish(list); err != nil {
+			return err
+		}
+		p.writeSubstitution(b, w, list, string(p.src[start:p.pos]), inDouble)
+		return nil
+	case p.hasPrefix("${"):
+		p.pos++
+		raw, err := p.readBalanced('{', '}')
+		if err != nil {
+			return err
+		}
+		name := raw[1 : len(raw)-1]
+		if value, ok := p.vars[name]; ok && isAssignName(name) {
+			b.WriteString(value)
+			w.split = w.split || !inDouble
+			return nil
+		}
+		b.WriteString("$" + raw)
+		w.dynamic = true
+		return nil
+	}
+	p.pos++
+	if p.atEnd() {
+		b.WriteRune('$')
+		return nil
+	}
+	c := p.src[p.pos]
+	if isNameRune(c, true) {
+		end := p.pos
+		for end < len(p.src) && isNameRune(p.src[end], false) {
+			end++
+		}
+		name := string(p.src[p.pos:end])
+		p.pos = end
+		if value, ok := p.vars[name]; ok {
+			b.WriteString(value)
+			w.split = w.split || !inDouble
+			return nil
+		}
+		b.WriteString("$" + name)
+		w.dynamic = true
+		return nil
+	}
+	if strings.ContainsRune("0123456789@*#?$!-", c) {
+		p.pos++
+		b.WriteString("$" + string(c))
+		w.dynamic = true
+		return nil
+	}
+	b.WriteRune('$')
+	return nil
+}
+
+// readBackquote reads a `...` command substitution.
+func (p *shellParser) readBackquote(b *strings.Builder, w *shellWord, inDouble bool) error {
+	start := p.pos
+	p.pos++
+	var body strings.Builder
+	for {
+		if p.atEnd() {
+			return p.errorf("unterminated command substitution")
+		}
+		c := p.src[p.pos]
+		if c == '`' {
+			p.pos++
+			break
+		}
+		if c == '\\' && p.pos+1 < len(p.src) && strings.ContainsRune("`\\$", p.src[p.pos+1]) {
+			body.WriteRune(p.src[p.pos+1])
+			p.pos += 2
+			continue
+		}
+		body.WriteRune(c)
+		p.pos++
+	}
+	child := &shellParser{src: []rune(body.String()), vars: p.vars, via: p.via, depth: p.depth + 1, out: p.out}
+	if child.depth > maxShellDepth {
+		return errors.New("shell syntax: nesting too deep")
+	}
+	list, err := child.parseList(nil)
+	if err != nil {
+		return err
+	}
+	if !child.atEnd() {
+		return child.errorf("unexpected %q", child.src[child.pos])
+	}
+	if err := child.finish(list); err != nil {
+		return err
+	}
+	p.writeSubstitution(b, w, list, string(p.src[start:p.pos]), inDouble)
+	return nil
+}
+
+// writeSubstitution appends a command substitution's value: the output of
+// a lone static echo/printf/cat, otherwise the source text as a dynamic
+// value.
+func (p *shellParser) writeSubstitution(b *strings.Builder, w *shellWord, list [][]*pendingCommand, raw string, inDouble bool) {
+	if len(list) == 1 && len(list[0]) == 1 {
+		if out, ok := list[0][0].staticOutput(); ok {
+			b.WriteString(strings.TrimRight(out, "\n"))
+			w.split = w.split || !inDouble
+			return
+		}
+	}
+	b.WriteString(raw)
+	w.dynamic = true
+}
+
+// argv returns the command's words after field splitting.
+func (c *pendingCommand) argv() []string {
+	var argv []string
+	for _, w := range c.words {
+		if w.split && !w.quoted {
+			argv = append(argv, strings.Fields(w.value)...)
+			continue
+		}
+		argv = append(argv, w.value)
+	}
+	return argv
+}
+
+// staticOutput returns what the command prints when that is knowable
+// without running anything: echo, printf, and cat of a known stdin.
+func (c *pendingCommand) staticOutput() (string, bool) {
+	for _, w := range c.words {
+		if w.dynamic {
+			return "", false
+		}
+	}
+	argv := c.argv()
+	if len(argv) == 0 {
+		return "", false
+	}
+	switch path.Base(argv[0]) {
+	case "echo":
+		args := argv[1:]
+		newline, escapes := true, false
+		for len(args) > 0 && len(args[0]) > 1 && args[0][0] == '-' && strings.Trim(args[0][1:], "neE") == "" {
+			newline = newline && !strings.ContainsRune(args[0], 'n')
+			escapes = strings.ContainsRune(args[0], 'e')
+			args = args[1:]
+		}
+		out := strings.Join(args, " ")
+		if escapes {
+			out = unescapeBackslashes(out)
+		}
+		if newline {
+			out += "\n"
+		}
+		return out, true
+	case "printf":
+		if len(argv) < 2 {
+			return "", false
+		}
+		return formatPrintf(argv[1], argv[2:])
+	case "cat":
+		if len(argv) == 1 && c.stdin != nil {
+			return *c.stdin, true
+		}
+	}
+	return "", false
+}
+
+// formatPrintf evaluates printf formats that use only %s, %b, and %%.
+func formatPrintf(format string, args []string) (string, bool) {
+	var out strings.Builder
+	for pass := 0; pass < 64; pass++ {
+		consumed := 0
+		runes := []rune(format)
+		var lit strings.Builder
+		for i := 0; i < len(runes); i++ {
+			if runes[i] != '%' {
+				lit.WriteRune(runes[i])
+				continue
+			}
+			if i+1 >= len(runes) {
+				return "", false
+			}
+			i++
+			switch runes[i] {
+			case '%':
+				lit.WriteRune('%')
+			case 's', 'b':
+				out.WriteString(unescapeBackslashes(lit.String()))
+				lit.Reset()
+				arg := ""
+				if len(args) > 0 {
+					arg, args = args[0], args[1:]
+					consumed++
+				}
+				if runes[i] == 'b' {
+					arg = unescapeBackslashes(arg)
+				}
+				out.WriteString(arg)
+			default:
+				return "", false
+			}
+		}
+		out.WriteString(unescapeBackslashes(lit.String()))
+		if consumed == 0 || len(args) == 0 {
+			return out.String(), true
+		}
+	}
+	return "", false
+}
+
+// unescapeBackslashes interprets the common backslash escapes of echo -e,
+// printf, and $'...'.
+func unescapeBackslashes(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '\\' || i+1 >= len(runes) {
+			b.WriteRune(runes[i])
+			continue
+		}
+		i++
+		switch runes[i] {
+		case 'n':
+			b.WriteRune('\n')
+		case 't':
+			b.WriteRune('\t')
+		case 'r':
+			b.WriteRune('\r')
+		case 'a':
+			b.WriteRune('\a')
+		case 'e', 'E':
+			b.WriteRune('\x1b')
+		case 'x':
+			value, n := 0, 0
+			for n < 2 && i+1 < len(runes) && strings.ContainsRune("0123456789abcdefABCDEF", runes[i+1]) {
+				i++
+				value = value*16 + hexValue(runes[i])
+				n++
+			}
+			if n == 0 {
+				b.WriteString(`\x`)
+				continue
+			}
+			b.WriteRune(rune(value))
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			value := int(runes[i] - '0')
+			for n := 0; n < 2 && i+1 < len(runes) && runes[i+1] >= '0' && runes[i+1] <= '7'; n++ {
+				i++
+				value = value*8 + int(runes[i]-'0')
+			}
+			b.WriteRune(rune(value))
+		case '\\', '\'', '"':
+			b.WriteRune(runes[i])
+		default:
+			b.WriteRune('\\')
+			b.WriteRune(runes[i])
+		}
+	}
+	return b.String()
+}
+
+func hexValue(r rune) int {
+	switch {
+	case r >= '0' && r <= '9':
+		return int(r - '0')
+	case r >= 'a' && r <= 'f':
+		return int(r-'a') + 10
+	default:
+		return int(r-'A') + 10
+	}
+}
+
+// finish emits the commands of parsed pipelines. A stage whose input comes
+// from a static echo/printf/cat stage gets that text as stdin, so
+// "echo 'rm -rf /' | sh" and "echo / | xargs rm -rf" resolve.
+func (p *shellParser) finish(list [][]*pendingCommand) error {
+	for _, stages := range list {
+		start := len(*p.out)
+		var piped *string
+		texts := make([]string, 0, len(stages))
+		for i, stage := range stages {
+			if stage.stdin == nil && i > 0 {
+				stage.stdin = piped
+			}
+			argv := stage.argv()
+			if err := p.emit(argv, stage.stdin, p.via); err != nil {
+				return err
+			}
+			piped = nil
+			if out, ok := stage.staticOutput(); ok {
+				piped = &out
+			}
+			if len(argv) > 0 {
+				texts = append(texts, renderMatchText(argv))
+			}
+		}
+		if len(stages) > 1 {
+			text := strings.Join(texts, " | ")
+			for i := start; i < len(*p.out); i++ {
+				if (*p.out)[i].pipeline == "" {
+					(*p.out)[i].pipeline = text
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// emit records a command and, if it is a wrapper, the commands it runs.
+func (p *shellParser) emit(argv []string, stdin *string, via []string) error {
+	if len(argv) == 0 {
+		return nil
+	}
+	if len(*p.out) >= maxShellCommands {
+		return errors.New("shell syntax: too many commands")
+	}
+	name := path.Base(argv[0])
+	if argv[0] == "" {
+		name = ""
+	}
+	*p.out = append(*p.out, ShellCommand{Name: name, Argv: argv, Via: via})
+
+	w := resolveWrapper(name, argv, stdin)
+	if w.label == "" {
+		return nil
+	}
+	next := make([]string, len(via), len(via)+1)
+	copy(next, via)
+	next = append(next, w.label)
+	for _, inner := range w.commands {
+		if err := p.emit(inner, w.stdin, next); err != nil {
+			return err
+		}
+	}
+	if w.script != nil {
+		return p.parseNested(*w.script, next)
+	}
+	return nil
+}
+
+// wrapped is what a wrapper command runs: argv lists, a shell script, or
+// both. An empty label means the command is not a wrapper.
+type wrapped struct {
+	label    string
+	commands [][]string
+	script   *string
+	stdin    *string
+}
+
+// skipOptions returns the index of the first operand of argv, skipping
+// options. Short options in withArg take a value (attached or the next
+// word); so do the long options in longWithArg when written without "=".
+func skipOptions(argv []string, withArg string, longWithArg ...string) int {
+	i := 1
+	for i < len(argv) {
+		a := argv[i]
+		if a == "--" {
+			return i + 1
+		}
+		if len(a) < 2 || a[0] != '-' {
+			return i
+		}
+		if strings.HasPrefix(a, "--") {
+			for _, long := range longWithArg {
+				if a == long {
+					i++
+					break
+				}
+			}
+			i++
+			continue
+		}
+		for j := 1; j < len(a); j++ {
+			if strings.IndexByte(withArg, a[j]) >= 0 {
+				if j == len(a)-1 {
+					i++
+				}
+				break
+			}
+		}
+		i++
+	}
+	return i
+}
+
+func tailFrom(argv []string, i int) [][]string {
+	if i >= len(argv) {
+		return nil
+	}
+	return [][]string{argv[i:]}
+}
+
+var shellNames = map[string]bool{
+	"sh": true, "bash": true, "zsh": true, "dash": true, "ksh": true, "mksh": true, "ash": true,
+}
+
+// resolveWrapper unwraps commands that run another command or a script.
+func resolveWrapper(name string, argv []string, stdin *string) wrapped {
+	switch name {
+	case "sudo":
+		i := skipOptions(argv, "CDgprRtTUu", "--user", "--group", "--prompt", "--close-from",
+			"--chdir", "--role", "--type", "--other-user", "--command-timeout")
+		return wrapped{label: name, commands: tailFrom(argv, i), stdin: stdin}
+	case "doas":
+		return wrapped{label: name, commands: tailFrom(argv, skipOptions(argv, "uC")), stdin: stdin}
+	case "nohup", "builtin", "setsid", "unbuffer", "busybox":
+		return wrapped{label: name, commands: tailFrom(argv, skipOptions(argv, "")), stdin: stdin}
+	case "nice":
+		return wrapped{label: name, commands: tailFrom(argv, skipOptions(argv, "n", "--adjustment")), stdin: stdin}
+	case "ionice":
+		return wrapped{label: name, commands: tailFrom(argv, skipOptions(argv, "cn", "--class", "--classdata")), stdin: stdin}
+	case "time":
+		return wrapped{label: name, commands: tailFrom(argv, skipOptions(argv, "fo", "--format", "--output")), stdin: stdin}
+	case "exec":
+		return wrapped{label: name, commands: tailFrom(argv, skipOptions(argv, "a")), stdin: stdin}
+	case "stdbuf":
+		return wrapped{label: name, commands: tailFrom(argv, skipOptions(argv, "ioe", "--input", "--output", "--error")), stdin: stdin}
+	case "command":
+		i := skipOptions(argv, "")
+		for _, a := range argv[1:i] {
+			if strings.ContainsAny(a, "vV") {
+				return wrapped{} // lookup only
+			}
+		}
+		return wrapped{label: name, commands: tailFrom(argv, i), stdin: stdin}
+	case "timeout":
+		i := skipOptions(argv, "sk", "--signal", "--kill-after") + 1 // the duration
+		return wrapped{label: name, commands: tailFrom(argv, i), stdin: stdin}
+	case "env":
+		return resolveEnv(argv, stdin)
+	case "xargs":
+		return resolveXargs(argv, stdin)
+	case "find":
+		return resolveFind(argv)
+	case "eval":
+		script := strings.Join(argv[1:], " ")
+		return wrapped{label: name, script: &script}
+	case "watch":
+		i := skipOptions(argv, "n", "--interval")
+		for _, a := range argv[1:i] {
+			if a == "-x" || a == "--exec" {
+				return wrapped{label: name, commands: tailFrom(argv, i)}
+			}
+		}
+		if i >= len(argv) {
+			return wrapped{}
+		}
+		script := strings.Join(argv[i:], " ")
+		return wrapped{label: name, script: &script}
+	}
+	if shellNames[name] {
+		return resolveShell(name, argv, stdin)
+	}
+	return wrapped{}
+}
+
+// resolveShell finds the script a shell runs: the -c string, or stdin when
+// no script file is named.
+func resolveShell(name string, argv []string, stdin *string) wrapped {
+	command := false
+	operand := len(argv)
+options:
+	for i := 1; i < len(argv); i++ {
+		a := argv[i]
+		switch {
+		case a == "--" || a == "-":
+			operand = i + 1
+			break options
+		case a == "-o" || a == "+o" || a == "-O" || a == "+O" || a == "--rcfile" || a == "--init-file":
+			i++
+		case strings.HasPrefix(a, "--"):
+		case len(a) > 1 && (a[0] == '-' || a[0] == '+'):
+			if a[0] == '-' && strings.ContainsRune(a[1:], 'c') {
+				command = true
+			}
+		default:
+			operand = i
+			break options
+		}
+	}
+	switch {
+	case command && operand < len(argv):
+		return wrapped{label: name + " -c", script: &argv[operand]}
+	case command, operand < len(argv):
+		// No -c string, or a script file we cannot see.
+		return wrapped{}
+	case stdin != nil:
+		return wrapped{label: name, script: stdin}
+	}
+	return wrapped{}
+}
+
+// resolveEnv skips env's options and NAME=value words; -S splits its value
+// into a command line of its own.
+func resolveEnv(argv []string, stdin *string) wrapped {
+	i := 1
+	for i < len(argv) {
+		a := argv[i]
+		var split string
+		switch {
+		case a == "--":
+			i++
+		case a == "-S" || a == "--split-string":
+			if i+1 >= len(argv) {
+				return wrapped{}
+			}
+			split = argv[i+1]
+			i += 2
+		case strings.HasPrefix(a, "--split-string="):
+			split = strings.TrimPrefix(a, "--split-string=")
+			i++
+		case strings.HasPrefix(a, "-S"):
+			split = a[2:]
+			i++
+		case a == "-u" || a == "-C" || a == "--unset" || a == "--chdir":
+			i += 2
+			continue
+		case len(a) > 1 && a[0] == '-':
+			i++
+			continue
+		case strings.Contains(a, "=") && isAssignName(a[:strings.Index(a, "=")]):
+			i++
+			continue
+		}
+		if split != "" {
+			script := split
+			for _, rest := range argv[i:] {
+				script += " " + shellQuote(rest)
+			}
+			return wrapped{label: "env -S", script: &script}
+		}
+		break
+	}
+	return wrapped{label: "env", commands: tailFrom(argv, i), stdin: stdin}
+}
+
+// resolveXargs returns the command xargs runs. When its input is static
+// the input words are appended (or substituted for the -I string).
+func resolveXargs(argv []string, stdin *string) wrapped {
+	replace := ""
+	for i := 1; i < len(argv); i++ {
+		a := argv[i]
+		switch {
+		case a == "-I" && i+1 < len(argv):
+			replace = argv[i+1]
+		case strings.HasPrefix(a, "-I") && len(a) > 2:
+			replace = a[2:]
+		case a == "-i" || a == "--replace":
+			replace = "{}"
+		case strings.HasPrefix(a, "-i") && len(a) > 2:
+			replace = a[2:]
+		case strings.HasPrefix(a, "--replace="):
+			replace = strings.TrimPrefix(a, "--replace=")
+		}
+	}
+	i := skipOptions(argv, "adEILnPs", "--arg-file", "--delimiter", "--max-args", "--max-procs",
+		"--max-chars", "--process-slot-var")
+	inner := []string{"echo"}
+	if i < len(argv) {
+		inner = append([]string(nil), argv[i:]...)
+	}
+	if stdin != nil {
+		input := strings.Fields(*stdin)
+		if replace != "" && len(input) > 0 {
+			for j := range inner {
+				inner[j] = strings.ReplaceAll(inner[j], replace, strings.Join(input, " "))
+			}
+		} else {
+			inner = append(inner, input...)
+		}
+	}
+	return wrapped{label: "xargs", commands: [][]string{inner}}
+}
+
+// resolveFind returns the commands of find's -exec family of actions.
+func resolveFind(argv []string) wrapped {
+	var commands [][]string
+	for i := 1; i < len(argv); i++ {
+		switch argv[i] {
+		case "-exec", "-execdir", "-ok", "-okdir":
+			j := i + 1
+			for j < len(argv) && argv[j] != ";" && argv[j] != "+" {
+				j++
+			}
+			if j > i+1 {
+				commands = append(commands, argv[i+1:j])
+			}
+			i = j
+		}
+	}
+	if len(commands) == 0 {
+		return wrapped{}
+	}
+	return wrapped{label: "find -exec", commands: commands}
+}
@@ -0,0 +1,87 @@
+package policy
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseShellResolvesSubCommands(t *testing.T) {
+	cases := []struct {
+		line string
+		want []string // String() of every command, in emission order
+	}{
+		{`git status && git push; ls | wc -l`, []string{"git status", "git push", "ls", "wc -l"}},
+		{`r''m -rf "/"`, []string{"rm -rf /"}},
+		{`$(printf rm) -rf /`, []string{"printf rm", "rm -rf /"}},
+		{"X=rm; $X -rf /", []string{"rm -rf /"}},
+		{`sudo -u root rm -rf /`, []string{"sudo -u root rm -rf /", "rm -rf /"}},
+		{`env -i A=1 nice -n 5 rm x`, []string{"env -i A=1 nice -n 5 rm x", "nice -n 5 rm x", "rm x"}},
+		{`bash -lc 'cd /tmp && rm -rf ~'`, []string{"bash -lc 'cd /tmp && rm -rf ~'", "cd /tmp", "rm -rf ~"}},
+		{`echo / | xargs rm -rf`, []string{"echo /", "xargs rm -rf", "rm -rf /"}},
+		{`find . -exec rm {} \;`, []string{"find . -exec rm {} ';'", "rm {}"}},
+		{"sh <<EOF\nrm -rf /\nEOF", []string{"sh", "rm -rf /"}},
+		{`(cd / && rm -rf .) > /dev/null 2>&1`, []string{"cd /", "rm -rf ."}},
+		{`for f in a b; do rm "$f"; done`, []string{"rm $f"}},
+		{`case $x in a|b) rm -rf /;; *) true;; esac`, []string{"rm -rf /", "true"}},
+		{`echo "rm -rf /" # trailing comment`, []string{"echo 'rm -rf /'"}},
+		{`command -v rm`, []string{"command -v rm"}},
+	}
+	for _, tc := range cases {
+		cmds, err := ParseShell(tc.line)
+		if err != nil {
+			t.Errorf("ParseShell(%q): %v", tc.line, err)
+			continue
+		}
+		var got []string
+		for _, c := range cmds {
+			got = append(got, c.String())
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("ParseShell(%q) = %q, want %q", tc.line, got, tc.want)
+		}
+	}
+}
+
+func TestParseShellRecordsWrappers(t *testing.T) {
+	cmds, err := ParseShell(`timeout 5 sudo sh -c "rm -rf /"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := cmds[len(cmds)-1]
+	if last.Name != "rm" || !reflect.DeepEqual(last.Via, []string{"timeout", "sudo", "sh -c"}) {
+		t.Fatalf("innermost = %+v, want rm via timeout, sudo, sh -c", last)
+	}
+}
+
+func TestParseShellRejectsMalformedInput(t *testing.T) {
+	for _, line := range []string{`echo 'unterminated`, `echo "unterminated`, `echo $(date`, `echo )`} {
+		if _, err := ParseShell(line); err == nil || !strings.Contains(err.Error(), "shell syntax") {
+			t.Errorf("ParseShell(%q) error = %v, want a shell syntax error", line, err)
+		}
+	}
+	deep := "rm -rf /"
+	for i := 0; i < maxShellDepth+2; i++ {
+		deep = "sh -c " + shellQuote(deep)
+	}
+	if _, err := ParseShell(deep); err == nil {
+		t.Error("expected nesting limit error")
+	}
+}
+
+func TestShellCommandFlagsAndOperands(t *testing.T) {
+	c := ShellCommand{Argv: []string{"rm", "-rf", "--no-preserve-root", "--", "-weird", "/"}}
+	for _, flag := range []string{"-r", "-f", "-rf", "-fr", "--no-preserve-root"} {
+		if !c.hasFlag(flag) {
+			t.Errorf("hasFlag(%q) = false", flag)
+		}
+	}
+	for _, flag := range []string{"-w", "--force"} {
+		if c.hasFlag(flag) {
+			t.Errorf("hasFlag(%q) = true; flags after -- are operands", flag)
+		}
+	}
+	if got := c.operands(); !reflect.DeepEqual(got, []string{"-weird", "/"}) {
+		t.Errorf("operands = %q", got)
+	}
+}
@@ -0,0 +1,78 @@
+package policy
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// SignatureSuffix is appended to a policy file's path to name its detached
+// ed25519 signature.
+const SignatureSuffix = ".sig"
+
+// GenerateSigningKey returns a new ed25519 key pair for signing policy
+// baselines, each base64-encoded. The public key goes in the baseline's
+// trusted key file; the private key stays with whoever signs the baseline.
+func GenerateSigningKey() (publicKey, privateKey string, err error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", fmt.Errorf("generating signing key: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(pub), base64.StdEncoding.EncodeToString(priv), nil
+}
+
+// Sign signs policy file content with a base64-encoded ed25519 private key
+// and returns the base64 signature to store beside the file.
+func Sign(data []byte, privateKey string) (string, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(privateKey))
+	if err != nil || len(raw) != ed25519.PrivateKeySize {
+		return "", errors.New("invalid signing key: expected a base64 ed25519 private key")
+	}
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(ed25519.PrivateKey(raw), data)), nil
+}
+
+// Verify reports whether signature (base64) signs data under one of keys.
+func Verify(data []byte, signature string, keys []ed25519.PublicKey) bool {
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(signature))
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return false
+	}
+	for _, key := range keys {
+		if ed25519.Verify(key, data, sig) {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadTrustedKeys reads base64 ed25519 public keys, one per line; blank
+// lines and # comments are ignored. A missing file yields no keys.
+func LoadTrustedKeys(path string) ([]ed25519.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading trusted policy keys: %w", err)
+	}
+	var keys []ed25519.PublicKey
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		raw, err := base64.StdEncoding.DecodeString(text)
+		if err != nil || len(raw) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("%s:%d: expected a base64 ed25519 public key", path, line)
+		}
+		keys = append(keys, ed25519.PublicKey(raw))
+	}
+	return keys, nil
+}
@@ -36,12 +36,14 @@ type SimulationStep struct {
 	PolicyProvenance  []string         `json:"policy_provenance,omitempty"`
 }
 
-// SimulationMatch records the policy rule that matched the command.
+// SimulationMatch records the policy rule that matched the command and the
+// resolved sub-command it matched.
 type SimulationMatch struct {
-	Action  string `json:"action"`
-	Pattern string `json:"pattern,omitempty"`
-	Reason  string `json:"reason,omitempty"`
-	SLB     bool   `json:"slb,omitempty"`
+	Action     string `json:"action"`
+	Pattern    string `json:"pattern,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+	SubCommand string `json:"sub_command,omitempty"`
+	SLB        bool   `json:"slb,omitempty"`
 }
 
 // SimulationSummary aggregates simulated verdicts.
@@ -123,10 +125,11 @@ func simulateStep(p *Policy, index int, raw string) SimulationStep {
 
 	step.Action = string(match.Action)
 	step.Policy = &SimulationMatch{
-		Action:  string(match.Action),
-		Pattern: match.Pattern,
-		Reason:  match.Reason,
-		SLB:     match.SLB,
+		Action:     string(match.Action),
+		Pattern:    match.Pattern,
+		Reason:     match.Reason,
+		SubCommand: match.SubCommand,
+		SLB:        match.SLB,
 	}
 	step.PolicyProvenance = []string{string(match.Action) + ":" + match.Pattern}
 	step.SaferAlternatives = saferAlternatives(command, match)
@@ -161,3 +161,14 @@ func TestSimulatePlanCompilesConstructedPolicy(t *testing.T) {
 		t.Fatalf("expected matched constructed policy provenance: %+v", report.Steps[0])
 	}
 }
+
+func TestSimulatePlanReportsMatchedSubCommand(t *testing.T) {
+	report := SimulatePlan(DefaultPolicy(), []string{`sudo sh -c "cd / && rm -rf ."`})
+	step := report.Steps[0]
+	if step.Decision != SimulationDecisionBlock {
+		t.Fatalf("decision = %q, want %q", step.Decision, SimulationDecisionBlock)
+	}
+	if step.Policy == nil || step.Policy.SubCommand != "rm -rf ." {
+		t.Fatalf("policy = %+v, want sub_command %q", step.Policy, "rm -rf .")
+	}
+}
@@ -0,0 +1,85 @@
+package pressure
+
+import "strconv"
+
+// FanoutAdmission is the robot-stable outcome of a pipeline fan-out
+// check. Allowed is the number of branches the caller may run at once;
+// it is only binding when Enforcing is true.
+type FanoutAdmission struct {
+	Decision      Decision `json:"decision"`
+	Requested     int      `json:"requested"`
+	Allowed       int      `json:"allowed"`
+	Reason        string   `json:"reason"`
+	Hint          string   `json:"hint,omitempty"`
+	PressureLevel string   `json:"pressure_level"`
+	Limiting      []string `json:"limiting,omitempty"`
+	Enforcing     bool     `json:"enforcing"`
+}
+
+// EvaluateFanout applies a budget's ActionPipelineFanout rules to a
+// request for `requested` concurrent branches. Under DeferAtLevel the
+// fan-out is serialized to one branch at a time; under DenyAtLevel it
+// is refused. Otherwise the request is capped at MaxPipelineFanout.
+// Like EvaluateSpawnAdmission it is pure so tests and callers without
+// a Governor share the same thresholds.
+func EvaluateFanout(budget Budget, requested int, snap Snapshot) FanoutAdmission {
+	requested = maxInt(requested, 0)
+	out := FanoutAdmission{
+		Decision:      DecisionAllow,
+		Requested:     requested,
+		Allowed:       requested,
+		Reason:        "headroom_available",
+		PressureLevel: snap.Overall.String(),
+		Limiting:      limitingStrings(snap.Limiting),
+	}
+	switch {
+	case budget.DenyAtLevel > LevelLow && snap.Overall >= budget.DenyAtLevel:
+		out.Decision = DecisionDeny
+		out.Allowed = 0
+		out.Reason = "pressure_" + snap.Overall.String()
+		out.Hint = recommendation(ActionPipelineFanout, snap.Limiting)
+	case budget.DeferAtLevel > LevelLow && snap.Overall >= budget.DeferAtLevel:
+		out.Decision = DecisionDefer
+		out.Allowed = min(requested, 1)
+		out.Reason = "pressure_" + snap.Overall.String()
+		out.Hint = recommendation(ActionPipelineFanout, snap.Limiting)
+	case budget.MaxPipelineFanout > 0 && requested > budget.MaxPipelineFanout:
+		out.Allowed = budget.MaxPipelineFanout
+		out.Reason = "fanout_budget"
+		out.Hint = "requested " + strconv.Itoa(requested) +
+			" concurrent branches exceeds max_pipeline_fanout " +
+			strconv.Itoa(budget.MaxPipelineFanout)
+	}
+	return out
+}
+
+// AdmitFanout evaluates a fan-out of `requested` branches for session
+// against the latest snapshot and the session's budget (falling back to
+// the global budget). In observe mode the decision is reported but
+// Allowed always equals Requested.
+func (g *Governor) AdmitFanout(session string, requested int) FanoutAdmission {
+	g.mu.RLock()
+	budget := g.global
+	if b, ok := g.sessions[session]; ok && b != (Budget{}) {
+		budget = b
+	}
+	mode := g.mode
+	g.mu.RUnlock()
+
+	out := EvaluateFanout(budget, requested, g.Latest())
+	out.Enforcing = mode == ModeEnforce
+	if !out.Enforcing {
+		out.Allowed = out.Requested
+	}
+	if out.Decision != DecisionAllow {
+		g.logger.Info("pressure fanout gated",
+			"session", session,
+			"decision", string(out.Decision),
+			"requested", out.Requested,
+			"allowed", out.Allowed,
+			"level", out.PressureLevel,
+			"enforcing", out.Enforcing,
+		)
+	}
+	return out
+}
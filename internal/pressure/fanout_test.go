@@ -0,0 +1,59 @@
+package pressure
+
+import (
+	"context"
+	"testing"
+)
+
+func TestEvaluateFanout(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		name      string
+		cpu       float64
+		requested int
+		decision  Decision
+		allowed   int
+		reason    string
+	}{
+		{"headroom", 0.20, 8, DecisionAllow, 8, "headroom_available"},
+		{"capped by budget", 0.20, 40, DecisionAllow, 16, "fanout_budget"},
+		{"high pressure serializes", 0.85, 8, DecisionDefer, 1, "pressure_high"},
+		{"critical pressure refuses", 0.95, 8, DecisionDeny, 0, "pressure_critical"},
+	}
+	for _, tc := range cases {
+		snap := buildSnapshot(fixedClock()(), []Reading{{Source: SourceCPU, Value: tc.cpu, Unit: "ratio"}}, DefaultThresholds())
+		got := EvaluateFanout(DefaultBudget(), tc.requested, snap)
+		if got.Decision != tc.decision || got.Allowed != tc.allowed || got.Reason != tc.reason {
+			t.Errorf("%s: got %+v", tc.name, got)
+		}
+		if tc.decision != DecisionAllow && got.Hint != "reduce parallelism: cpu" {
+			t.Errorf("%s: hint = %q", tc.name, got.Hint)
+		}
+	}
+}
+
+func TestGovernor_AdmitFanoutObserveModeDoesNotThrottle(t *testing.T) {
+	t.Parallel()
+	cpu := NewFakeProvider("cpu", Reading{Source: SourceCPU, Value: 0.95, Unit: "ratio"})
+
+	observe := newTestGovernor(t, ModeObserve, cpu)
+	observe.Refresh(context.Background())
+	if got := observe.AdmitFanout("s", 4); got.Decision != DecisionDeny || got.Allowed != 4 || got.Enforcing {
+		t.Fatalf("observe = %+v", got)
+	}
+
+	enforce := New(Config{
+		Mode:          ModeEnforce,
+		Providers:     []Provider{cpu},
+		SessionBudget: map[string]Budget{"small": {MaxPipelineFanout: 2}},
+		Now:           fixedClock(),
+	})
+	enforce.Refresh(context.Background())
+	if got := enforce.AdmitFanout("s", 4); got.Decision != DecisionDeny || got.Allowed != 0 || !got.Enforcing {
+		t.Fatalf("enforce = %+v", got)
+	}
+	// A session budget without levels only caps the width.
+	if got := enforce.AdmitFanout("small", 4); got.Decision != DecisionAllow || got.Allowed != 2 {
+		t.Fatalf("session budget = %+v", got)
+	}
+}
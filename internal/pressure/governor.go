@@ -77,6 +77,15 @@ func New(cfg Config) *Governor {
 	}
 }
 
+// NewSystemGovernor returns a Governor in mode that samples host pressure
+// with the default SystemProvider.
+func NewSystemGovernor(mode Mode) *Governor {
+	return New(Config{
+		Mode:      mode,
+		Providers: []Provider{NewSystemProvider()},
+	})
+}
+
 // Mode returns the governor's mode. Read under RLock so concurrent
 // writers observe the Go memory-model happens-before edge established
 // by the matching write under g.mu.Lock (bd-qjt3s).
@@ -0,0 +1,358 @@
+package ptyd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"time"
+
+	"golang.org/x/term"
+
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+)
+
+// DetachKey is the byte that ends an Attach: Ctrl-].
+const DetachKey = 0x1d
+
+// daemonStartTimeout bounds how long Client waits for an autostarted daemon
+// to answer.
+const daemonStartTimeout = 5 * time.Second
+
+// errDaemonReply marks an error reported by the daemon rather than the
+// connection to it.
+var errDaemonReply = errors.New("pty daemon error")
+
+// Client drives the PTY daemon. It implements tmux.PaneBackend and
+// tmux.PaneAttacher.
+type Client struct {
+	// Socket is the daemon's unix socket.
+	Socket string
+	// Autostart launches `ntm pty daemon` when creating a session finds no
+	// daemon running.
+	Autostart bool
+}
+
+var (
+	_ tmux.PaneBackend  = (*Client)(nil)
+	_ tmux.PaneAttacher = (*Client)(nil)
+)
+
+// NewClient returns a client for the daemon on socket, or the default socket
+// when socket is empty.
+func NewClient(socket string) (*Client, error) {
+	if socket == "" {
+		var err error
+		if socket, err = SocketPath(); err != nil {
+			return nil, err
+		}
+	}
+	return &Client{Socket: socket, Autostart: true}, nil
+}
+
+// Name implements tmux.PaneBackend.
+func (c *Client) Name() string { return BackendName }
+
+// noServerError mirrors tmux's wording so callers that treat "no server
+// running" as "no sessions" keep doing so.
+func (c *Client) noServerError(err error) error {
+	return fmt.Errorf("no server running on %s: %w", c.Socket, err)
+}
+
+func (c *Client) dial(ctx context.Context) (net.Conn, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", c.Socket)
+	if err != nil {
+		return nil, c.noServerError(err)
+	}
+	return conn, nil
+}
+
+// call sends one request and waits for the reply.
+func (c *Client) call(ctx context.Context, req request) (response, error) {
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return response{}, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() { _ = conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return response{}, c.contextErr(ctx, err)
+	}
+	var resp response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return response{}, c.contextErr(ctx, err)
+	}
+	if resp.Error != "" {
+		args := []string{req.Op}
+		if req.Session != "" {
+			args = append(args, req.Session)
+		}
+		if req.Target != "" {
+			args = append(args, req.Target)
+		}
+		return resp, &tmux.CommandError{Command: "ntm pty", Args: args, Stderr: resp.Error, Err: errDaemonReply}
+	}
+	return resp, nil
+}
+
+func (c *Client) contextErr(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return fmt.Errorf("pty daemon: %w", err)
+}
+
+// Running reports whether a daemon answers on the socket.
+func (c *Client) Running(ctx context.Context) bool {
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// ensureDaemon starts a daemon when none answers and waits for it.
+func (c *Client) ensureDaemon(ctx context.Context) error {
+	if c.Running(ctx) {
+		return nil
+	}
+	if !c.Autostart {
+		return c.noServerError(os.ErrNotExist)
+	}
+	// Under `go test` os.Executable is the test binary, which would re-run
+	// the suite instead of serving panes.
+	if flag.Lookup("test.v") != nil {
+		return errors.New("refusing to start the pty daemon from a test binary")
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("locate ntm to start the pty daemon: %w", err)
+	}
+	cmd := exec.Command(exe, "pty", "daemon", "--socket", c.Socket)
+	detach(cmd)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("start pty daemon: %w", err)
+	}
+	_ = cmd.Process.Release()
+
+	deadline := time.Now().Add(daemonStartTimeout)
+	for time.Now().Before(deadline) {
+		if c.Running(ctx) {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+	return fmt.Errorf("pty daemon did not start on %s within %s", c.Socket, daemonStartTimeout)
+}
+
+// HasSession implements tmux.PaneBackend. No daemon means no sessions.
+func (c *Client) HasSession(ctx context.Context, session string) (bool, error) {
+	if !c.Running(ctx) {
+		return false, nil
+	}
+	resp, err := c.call(ctx, request{Op: opHasSession, Session: session})
+	return resp.Exists, err
+}
+
+// NewSession implements tmux.PaneBackend, starting the daemon if needed.
+func (c *Client) NewSession(ctx context.Context, session, directory string) error {
+	if err := c.ensureDaemon(ctx); err != nil {
+		return err
+	}
+	_, err := c.call(ctx, request{Op: opNewSession, Session: session, Dir: directory})
+	return err
+}
+
+// KillSession implements tmux.PaneBackend.
+func (c *Client) KillSession(ctx context.Context, session string) error {
+	_, err := c.call(ctx, request{Op: opKillSession, Session: session})
+	return err
+}
+
+// ListSessions implements tmux.PaneBackend. No daemon means no sessions.
+func (c *Client) ListSessions(ctx context.Context) ([]tmux.Session, error) {
+	if !c.Running(ctx) {
+		return nil, nil
+	}
+	resp, err := c.call(ctx, request{Op: opListSessions})
+	if err != nil {
+		return nil, err
+	}
+	var sessions []tmux.Session
+	for _, s := range resp.Sessions {
+		sessions = append(sessions, tmux.Session{
+			Name:     s.Name,
+			Windows:  1,
+			Attached: s.Attached,
+			Created:  s.Created.Format(time.ANSIC),
+		})
+	}
+	return sessions, nil
+}
+
+// Sessions returns the daemon's sessions as it reports them.
+func (c *Client) Sessions(ctx context.Context) ([]SessionInfo, error) {
+	resp, err := c.call(ctx, request{Op: opListSessions})
+	return resp.Sessions, err
+}
+
+// Panes returns a session's panes as the daemon reports them.
+func (c *Client) Panes(ctx context.Context, session string) ([]PaneInfo, error) {
+	resp, err := c.call(ctx, request{Op: opListPanes, Session: session})
+	return resp.Panes, err
+}
+
+// ListPanes implements tmux.PaneBackend.
+func (c *Client) ListPanes(ctx context.Context, session string) ([]tmux.PaneActivity, error) {
+	panes, err := c.Panes(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]tmux.PaneActivity, 0, len(panes))
+	for _, p := range panes {
+		out = append(out, tmux.PaneActivity{
+			Pane: tmux.Pane{
+				ID:      p.ID,
+				Index:   p.Index,
+				Title:   p.Title,
+				Command: p.Command,
+				Width:   p.Width,
+				Height:  p.Height,
+				Active:  p.Active,
+				PID:     p.PID,
+			},
+			LastActivity: p.LastActivity,
+		})
+	}
+	return out, nil
+}
+
+// SplitPane implements tmux.PaneBackend.
+func (c *Client) SplitPane(ctx context.Context, session, directory string) (string, error) {
+	resp, err := c.call(ctx, request{Op: opSplit, Session: session, Dir: directory})
+	return resp.Pane, err
+}
+
+// KillPane implements tmux.PaneBackend.
+func (c *Client) KillPane(ctx context.Context, target string) error {
+	_, err := c.call(ctx, request{Op: opKillPane, Target: target})
+	return err
+}
+
+// SetPaneTitle implements tmux.PaneBackend.
+func (c *Client) SetPaneTitle(ctx context.Context, target, title string) error {
+	_, err := c.call(ctx, request{Op: opSetTitle, Target: target, Title: title})
+	return err
+}
+
+// SendKeys implements tmux.PaneBackend.
+func (c *Client) SendKeys(ctx context.Context, target, keys string, literal bool) error {
+	_, err := c.call(ctx, request{Op: opSendKeys, Target: target, Keys: keys, Literal: literal})
+	return err
+}
+
+// Paste implements tmux.PaneBackend.
+func (c *Client) Paste(ctx context.Context, target, content string) error {
+	_, err := c.call(ctx, request{Op: opPaste, Target: target, Keys: content})
+	return err
+}
+
+// Capture implements tmux.PaneBackend.
+func (c *Client) Capture(ctx context.Context, target string, lines int, visible bool) (string, error) {
+	resp, err := c.call(ctx, request{Op: opCapture, Target: target, Lines: lines, Visible: visible})
+	return resp.Output, err
+}
+
+// Shutdown stops the daemon and every pane it owns.
+func (c *Client) Shutdown(ctx context.Context) error {
+	_, err := c.call(ctx, request{Op: opKillServer})
+	return err
+}
+
+// Attach implements tmux.PaneAttacher: it connects the terminal to target (a
+// session's active pane, session:0.N or a pane ID) until the pane exits or
+// the user presses Ctrl-].
+func (c *Client) Attach(target string) error {
+	conn, err := c.dial(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	req := request{Op: opAttach, Target: target}
+	fd := int(os.Stdin.Fd())
+	if cols, rows, err := term.GetSize(int(os.Stdout.Fd())); err == nil {
+		req.Cols, req.Rows = cols, rows
+	}
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return err
+	}
+	r := bufio.NewReader(conn)
+	line, err := r.ReadBytes('\n')
+	if err != nil {
+		return fmt.Errorf("attach %s: %w", target, err)
+	}
+	var resp response
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return fmt.Errorf("attach %s: %w", target, err)
+	}
+	if resp.Error != "" {
+		return fmt.Errorf("attach %s: %s", target, resp.Error)
+	}
+
+	if term.IsTerminal(fd) {
+		state, err := term.MakeRaw(fd)
+		if err != nil {
+			return err
+		}
+		defer term.Restore(fd, state)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(os.Stdout, r)
+		close(done)
+	}()
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			n, err := os.Stdin.Read(buf)
+			for i := 0; i < n; i++ {
+				if buf[i] == DetachKey {
+					n = i
+					err = io.EOF
+					break
+				}
+			}
+			if n > 0 {
+				if _, werr := conn.Write(buf[:n]); werr != nil {
+					return
+				}
+			}
+			if err != nil {
+				conn.Close()
+				return
+			}
+		}
+	}()
+	<-done
+	fmt.Fprintf(os.Stdout, "\r\n[detached from %s]\r\n", resp.Pane)
+	return nil
+}
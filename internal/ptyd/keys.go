@@ -0,0 +1,60 @@
+package ptyd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// namedKeys maps the tmux key names ntm sends to the bytes a terminal would
+// produce for them.
+var namedKeys = map[string]string{
+	"Enter":    "\r",
+	"Escape":   "\x1b",
+	"Tab":      "\t",
+	"BTab":     "\x1b[Z",
+	"BSpace":   "\x7f",
+	"Space":    " ",
+	"Up":       "\x1b[A",
+	"Down":     "\x1b[B",
+	"Right":    "\x1b[C",
+	"Left":     "\x1b[D",
+	"Home":     "\x1b[H",
+	"End":      "\x1b[F",
+	"IC":       "\x1b[2~",
+	"Insert":   "\x1b[2~",
+	"DC":       "\x1b[3~",
+	"Delete":   "\x1b[3~",
+	"PPage":    "\x1b[5~",
+	"PageUp":   "\x1b[5~",
+	"NPage":    "\x1b[6~",
+	"PageDown": "\x1b[6~",
+}
+
+// keyBytes translates one tmux key name (Enter, C-c, M-b, Up, ...) into the
+// bytes to write to the pane. A single character stands for itself.
+func keyBytes(name string) (string, error) {
+	if b, ok := namedKeys[name]; ok {
+		return b, nil
+	}
+	switch {
+	case strings.HasPrefix(name, "C-") && len(name) == 3:
+		c := name[2]
+		switch {
+		case c >= 'a' && c <= 'z':
+			return string(rune(c - 'a' + 1)), nil
+		case c >= '@' && c <= '_':
+			return string(rune(c - '@')), nil
+		case c == '?':
+			return "\x7f", nil
+		}
+	case strings.HasPrefix(name, "M-") && len(name) > 2:
+		rest, err := keyBytes(name[2:])
+		if err != nil {
+			return "", err
+		}
+		return "\x1b" + rest, nil
+	case len([]rune(name)) == 1:
+		return name, nil
+	}
+	return "", fmt.Errorf("unknown key: %s", name)
+}
@@ -0,0 +1,22 @@
+//go:build !unix
+
+package ptyd
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+var errNoPTY = errors.New("the pty backend needs a unix pseudo-terminal")
+
+func startPTY(*exec.Cmd, int, int) (*os.File, error) { return nil, errNoPTY }
+
+func resizePTY(*os.File, int, int) error { return errNoPTY }
+
+func foregroundCommand(_ *os.File, _ int, shell string) string { return filepath.Base(shell) }
+
+func hangup(proc *os.Process) { _ = proc.Kill() }
+
+func detach(*exec.Cmd) {}
@@ -0,0 +1,51 @@
+//go:build unix
+
+package ptyd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/creack/pty"
+	"golang.org/x/sys/unix"
+
+	"github.com/Dicklesworthstone/ntm/internal/process"
+)
+
+// startPTY runs cmd on a new pseudo-terminal of the given size and returns
+// the terminal's master side.
+func startPTY(cmd *exec.Cmd, cols, rows int) (*os.File, error) {
+	return pty.StartWithSize(cmd, &pty.Winsize{Cols: uint16(cols), Rows: uint16(rows)})
+}
+
+// resizePTY changes the terminal size, which signals SIGWINCH to the pane.
+func resizePTY(f *os.File, cols, rows int) error {
+	return pty.Setsize(f, &pty.Winsize{Cols: uint16(cols), Rows: uint16(rows)})
+}
+
+// foregroundCommand names the terminal's foreground process, the equivalent
+// of tmux's pane_current_command, falling back to the pane's shell.
+func foregroundCommand(f *os.File, shellPID int, shell string) string {
+	pid := shellPID
+	if pgrp, err := unix.IoctlGetInt(int(f.Fd()), unix.TIOCGPGRP); err == nil && pgrp > 0 {
+		pid = pgrp
+	}
+	if argv := process.GetCmdline(pid); len(argv) > 0 && argv[0] != "" {
+		return strings.TrimPrefix(filepath.Base(argv[0]), "-")
+	}
+	return filepath.Base(shell)
+}
+
+// hangup sends SIGHUP to the pane's whole process group.
+func hangup(proc *os.Process) {
+	_ = syscall.Kill(-proc.Pid, syscall.SIGHUP)
+}
+
+// detach starts cmd in its own session so the daemon outlives the ntm
+// invocation that launched it.
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
@@ -0,0 +1,108 @@
+// Package ptyd runs agent panes without a tmux server. A small daemon owns one
+// pseudo-terminal per pane, keeps a plain-text scrollback for each, and hands
+// out tmux-style pane IDs (%0, %1, ...) and titles so the rest of ntm cannot
+// tell the difference. Client implements tmux.PaneBackend against the daemon
+// over a unix socket, and Attach connects a terminal to a running pane.
+package ptyd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/util"
+)
+
+// BackendName is how the PTY backend is selected (ntm spawn --backend=pty,
+// [tmux] backend, NTM_BACKEND) and reported.
+const BackendName = "pty"
+
+// SocketEnv overrides the daemon socket path.
+const SocketEnv = "NTM_PTY_SOCKET"
+
+// SocketPath returns the daemon's unix socket: $NTM_PTY_SOCKET, else
+// ~/.ntm/ptyd.sock.
+func SocketPath() (string, error) {
+	if p := strings.TrimSpace(os.Getenv(SocketEnv)); p != "" {
+		return p, nil
+	}
+	dir, err := util.NTMDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "ptyd.sock"), nil
+}
+
+// Default pane geometry. Detached panes have no client to size them, so they
+// get a screen wide enough that agent TUIs do not wrap their footers.
+const (
+	DefaultCols = 200
+	DefaultRows = 50
+)
+
+// Daemon operations.
+const (
+	opHasSession   = "has-session"
+	opNewSession   = "new-session"
+	opKillSession  = "kill-session"
+	opListSessions = "list-sessions"
+	opListPanes    = "list-panes"
+	opSplit        = "split"
+	opKillPane     = "kill-pane"
+	opSetTitle     = "set-title"
+	opSendKeys     = "send-keys"
+	opPaste        = "paste"
+	opCapture      = "capture"
+	opAttach       = "attach"
+	opKillServer   = "kill-server"
+)
+
+// request is one JSON line sent to the daemon.
+type request struct {
+	Op      string `json:"op"`
+	Session string `json:"session,omitempty"`
+	Target  string `json:"target,omitempty"`
+	Dir     string `json:"dir,omitempty"`
+	Title   string `json:"title,omitempty"`
+	Keys    string `json:"keys,omitempty"`
+	Literal bool   `json:"literal,omitempty"`
+	Lines   int    `json:"lines,omitempty"`
+	Visible bool   `json:"visible,omitempty"`
+	Cols    int    `json:"cols,omitempty"`
+	Rows    int    `json:"rows,omitempty"`
+}
+
+// response is the daemon's JSON line reply. Error carries tmux-style messages
+// ("can't find pane: %3") so tmux.ClassifyCommandError reads them the same way.
+type response struct {
+	Error    string        `json:"error,omitempty"`
+	Exists   bool          `json:"exists,omitempty"`
+	Pane     string        `json:"pane,omitempty"`
+	Output   string        `json:"output,omitempty"`
+	Sessions []SessionInfo `json:"sessions,omitempty"`
+	Panes    []PaneInfo    `json:"panes,omitempty"`
+}
+
+// SessionInfo describes a daemon session.
+type SessionInfo struct {
+	Name     string    `json:"name"`
+	Panes    int       `json:"panes"`
+	Attached bool      `json:"attached"`
+	Created  time.Time `json:"created"`
+}
+
+// PaneInfo describes a daemon pane. Every pane lives in window 0.
+type PaneInfo struct {
+	ID           string    `json:"id"`
+	Session      string    `json:"session"`
+	Index        int       `json:"index"`
+	Title        string    `json:"title"`
+	Command      string    `json:"command"`
+	Width        int       `json:"width"`
+	Height       int       `json:"height"`
+	Active       bool      `json:"active"`
+	PID          int       `json:"pid"`
+	Attached     bool      `json:"attached"`
+	LastActivity time.Time `json:"last_activity"`
+}
@@ -0,0 +1,202 @@
+package ptyd
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultScrollback matches tmux.DefaultHistoryLimit so captures reach as far
+// back as they would in a spawned tmux session.
+const DefaultScrollback = 50000
+
+// escape parser states.
+const (
+	stateGround = iota
+	stateEscape
+	stateCSI
+	stateOSC
+	stateOSCEscape
+)
+
+// scrollback turns a pane's raw terminal output into the plain text lines
+// capture-pane -p would print. It is not a terminal emulator: escape sequences
+// are dropped, and only carriage return, backspace, horizontal cursor moves
+// and erase-in-line edit the current line. That is enough for shells, line
+// oriented tools and the status footers agent detection keys on.
+type scrollback struct {
+	limit int
+	lines []string // committed lines, oldest first
+	cur   []rune   // the line the cursor is on
+	col   int
+
+	state   int
+	params  []byte
+	pending []byte // incomplete UTF-8 sequence from the previous write
+
+	// bracketedPaste tracks DEC mode 2004 so pastes are wrapped only when the
+	// program asked for it, as tmux's paste-buffer -p does.
+	bracketedPaste bool
+}
+
+func newScrollback(limit int) *scrollback {
+	if limit <= 0 {
+		limit = DefaultScrollback
+	}
+	return &scrollback{limit: limit}
+}
+
+// Write feeds raw pane output into the buffer.
+func (s *scrollback) Write(p []byte) (int, error) {
+	n := len(p)
+	if len(s.pending) > 0 {
+		p = append(s.pending, p...)
+		s.pending = nil
+	}
+	for len(p) > 0 {
+		b := p[0]
+		if s.state != stateGround || b < utf8.RuneSelf {
+			s.byte(b)
+			p = p[1:]
+			continue
+		}
+		if !utf8.FullRune(p) {
+			s.pending = append([]byte(nil), p...)
+			break
+		}
+		r, size := utf8.DecodeRune(p)
+		s.put(r)
+		p = p[size:]
+	}
+	return n, nil
+}
+
+func (s *scrollback) byte(b byte) {
+	switch s.state {
+	case stateEscape:
+		switch b {
+		case '[':
+			s.state = stateCSI
+			s.params = s.params[:0]
+		case ']':
+			s.state = stateOSC
+		default:
+			s.state = stateGround
+		}
+		return
+	case stateCSI:
+		if b >= 0x40 && b <= 0x7e {
+			s.csi(b)
+			s.state = stateGround
+			return
+		}
+		s.params = append(s.params, b)
+		return
+	case stateOSC:
+		switch b {
+		case 0x07:
+			s.state = stateGround
+		case 0x1b:
+			s.state = stateOSCEscape
+		}
+		return
+	case stateOSCEscape:
+		s.state = stateGround
+		return
+	}
+
+	switch b {
+	case 0x1b:
+		s.state = stateEscape
+	case '\n':
+		s.newline()
+	case '\r':
+		s.col = 0
+	case '\b':
+		if s.col > 0 {
+			s.col--
+		}
+	case '\t':
+		next := (s.col/8 + 1) * 8
+		for s.col < next {
+			s.put(' ')
+		}
+	default:
+		if b >= 0x20 && b != 0x7f {
+			s.put(rune(b))
+		}
+	}
+}
+
+// csi applies the control sequences that edit the current line and ignores
+// the rest.
+func (s *scrollback) csi(final byte) {
+	params := string(s.params)
+	if strings.HasPrefix(params, "?") {
+		if params == "?2004" && (final == 'h' || final == 'l') {
+			s.bracketedPaste = final == 'h'
+		}
+		return
+	}
+	n, err := strconv.Atoi(params)
+	if err != nil {
+		n = 0
+	}
+	switch final {
+	case 'K':
+		switch n {
+		case 0:
+			if s.col < len(s.cur) {
+				s.cur = s.cur[:s.col]
+			}
+		case 1:
+			for i := 0; i < s.col && i < len(s.cur); i++ {
+				s.cur[i] = ' '
+			}
+		case 2:
+			s.cur = s.cur[:0]
+		}
+	case 'G':
+		s.col = max(n, 1) - 1
+	case 'C':
+		s.col += max(n, 1)
+	case 'D':
+		s.col = max(s.col-max(n, 1), 0)
+	}
+}
+
+func (s *scrollback) put(r rune) {
+	for len(s.cur) < s.col {
+		s.cur = append(s.cur, ' ')
+	}
+	if s.col < len(s.cur) {
+		s.cur[s.col] = r
+	} else {
+		s.cur = append(s.cur, r)
+	}
+	s.col++
+}
+
+func (s *scrollback) newline() {
+	s.lines = append(s.lines, strings.TrimRight(string(s.cur), " "))
+	s.cur = s.cur[:0]
+	s.col = 0
+	// Trim in batches so a busy pane does not copy the whole history on
+	// every line.
+	if len(s.lines) > s.limit+s.limit/8 {
+		s.lines = append([]string(nil), s.lines[len(s.lines)-s.limit:]...)
+	}
+}
+
+// Tail returns the last n lines, including the cursor line, without trailing
+// blank lines. n <= 0 returns everything.
+func (s *scrollback) Tail(n int) string {
+	all := s.lines
+	if len(s.cur) > 0 {
+		all = append(all[:len(all):len(all)], strings.TrimRight(string(s.cur), " "))
+	}
+	if n > 0 && len(all) > n {
+		all = all[len(all)-n:]
+	}
+	return strings.TrimRight(strings.Join(all, "\n"), "\n")
+}
@@ -0,0 +1,81 @@
+package ptyd
+
+import "testing"
+
+func TestScrollbackPlainText(t *testing.T) {
+	tests := []struct {
+		name   string
+		writes []string
+		want   string
+	}{
+		{"lines", []string{"one\r\ntwo\r\n"}, "one\ntwo"},
+		{"colors dropped", []string{"\x1b[1;32mok\x1b[0m done\n"}, "ok done"},
+		{"carriage return overwrites", []string{"50%\r100%\n"}, "100%"},
+		{"erase to end of line", []string{"working...\r\x1b[Kidle\n"}, "idle"},
+		{"backspace", []string{"ab\bc\n"}, "ac"},
+		{"title OSC dropped", []string{"\x1b]0;my title\x07$ \n"}, "$"},
+		{"utf8 split across writes", []string{"caf\xc3", "\xa9 \xe2\x9c", "\x93\n"}, "café ✓"},
+		{"escape split across writes", []string{"a\x1b[3", "1mb\n"}, "ab"},
+		{"cursor line included", []string{"done\n$ "}, "done\n$"},
+		{"tabs", []string{"a\tb\n"}, "a       b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newScrollback(0)
+			for _, w := range tt.writes {
+				s.Write([]byte(w))
+			}
+			if got := s.Tail(0); got != tt.want {
+				t.Errorf("Tail = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScrollbackTailAndLimit(t *testing.T) {
+	s := newScrollback(10)
+	for i := 0; i < 100; i++ {
+		s.Write([]byte{byte('a' + i%26), '\n'})
+	}
+	if len(s.lines) > 10+10/8 {
+		t.Errorf("kept %d lines with limit 10", len(s.lines))
+	}
+	if got := s.Tail(3); got != "t\nu\nv" {
+		t.Errorf("Tail(3) = %q", got)
+	}
+}
+
+func TestScrollbackBracketedPasteMode(t *testing.T) {
+	s := newScrollback(0)
+	s.Write([]byte("\x1b[?2004h"))
+	if !s.bracketedPaste {
+		t.Fatal("mode 2004 set not tracked")
+	}
+	s.Write([]byte("\x1b[?2004l"))
+	if s.bracketedPaste {
+		t.Fatal("mode 2004 reset not tracked")
+	}
+}
+
+func TestKeyBytes(t *testing.T) {
+	tests := map[string]string{
+		"Enter":  "\r",
+		"C-c":    "\x03",
+		"C-u":    "\x15",
+		"C-[":    "\x1b",
+		"Escape": "\x1b",
+		"BSpace": "\x7f",
+		"Up":     "\x1b[A",
+		"M-b":    "\x1bb",
+		"y":      "y",
+	}
+	for name, want := range tests {
+		got, err := keyBytes(name)
+		if err != nil || got != want {
+			t.Errorf("keyBytes(%q) = %q, %v; want %q", name, got, err, want)
+		}
+	}
+	if _, err := keyBytes("Hyper-x"); err == nil {
+		t.Error("unknown key name accepted")
+	}
+}
@@ -0,0 +1,632 @@
+package ptyd
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+)
+
+// Server is the PTY daemon: it owns every pane's pseudo-terminal and serves
+// the JSON line protocol Client speaks.
+type Server struct {
+	// Socket is the unix socket to listen on.
+	Socket string
+	// Shell runs in new panes; $SHELL or /bin/sh when empty.
+	Shell string
+	// Scrollback is the per-pane line limit; DefaultScrollback when zero.
+	Scrollback int
+	// ExitWhenEmpty stops the daemon once its last session ends, the way a
+	// tmux server exits with its last session.
+	ExitWhenEmpty bool
+
+	mu       sync.Mutex
+	sessions map[string]*session
+	panes    map[string]*pane
+	nextID   int
+	listener net.Listener
+	closed   bool
+	done     chan struct{}
+}
+
+type session struct {
+	name    string
+	created time.Time
+	panes   []*pane
+	active  *pane
+}
+
+type pane struct {
+	id      string
+	session *session
+	shell   string
+	cmd     *exec.Cmd
+	tty     *os.File
+	exited  chan struct{}
+	writeMu sync.Mutex
+
+	// mu also guards tty's descriptor: it is closed under mu, so ioctls
+	// made while holding mu never see a closed or reused fd.
+	mu       sync.Mutex
+	closed   bool
+	title    string
+	cols     int
+	rows     int
+	buf      *scrollback
+	last     time.Time
+	watchers map[chan []byte]struct{}
+}
+
+// NewServer returns a daemon listening on socket.
+func NewServer(socket string) *Server {
+	return &Server{
+		Socket:   socket,
+		sessions: make(map[string]*session),
+		panes:    make(map[string]*pane),
+		done:     make(chan struct{}),
+	}
+}
+
+// ErrDaemonRunning is returned by Serve when another daemon already answers
+// on the socket.
+var ErrDaemonRunning = errors.New("pty daemon already running")
+
+// Serve listens on the socket and handles clients until Close. A stale
+// socket left by a crashed daemon is replaced.
+func (s *Server) Serve() error {
+	if err := os.MkdirAll(filepath.Dir(s.Socket), 0o700); err != nil {
+		return fmt.Errorf("create socket directory: %w", err)
+	}
+	if conn, err := net.DialTimeout("unix", s.Socket, time.Second); err == nil {
+		conn.Close()
+		return fmt.Errorf("%w on %s", ErrDaemonRunning, s.Socket)
+	}
+	_ = os.Remove(s.Socket)
+	ln, err := net.Listen("unix", s.Socket)
+	if err != nil {
+		return fmt.Errorf("listen on %s: %w", s.Socket, err)
+	}
+	if err := os.Chmod(s.Socket, 0o600); err != nil {
+		ln.Close()
+		return fmt.Errorf("restrict %s: %w", s.Socket, err)
+	}
+	s.mu.Lock()
+	s.listener = ln
+	s.mu.Unlock()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			select {
+			case <-s.done:
+				return nil
+			default:
+			}
+			return err
+		}
+		go s.handle(conn)
+	}
+}
+
+// Close stops the daemon and kills every pane.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	close(s.done)
+	ln := s.listener
+	var panes []*pane
+	for _, p := range s.panes {
+		panes = append(panes, p)
+	}
+	s.mu.Unlock()
+
+	for _, p := range panes {
+		p.kill()
+	}
+	if ln != nil {
+		ln.Close()
+		_ = os.Remove(s.Socket)
+	}
+	return nil
+}
+
+// Done is closed when the daemon stops.
+func (s *Server) Done() <-chan struct{} { return s.done }
+
+func (s *Server) handle(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	enc := json.NewEncoder(conn)
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(line) == 0 && err != nil {
+			conn.Close()
+			return
+		}
+		var req request
+		if err := json.Unmarshal(line, &req); err != nil {
+			_ = enc.Encode(response{Error: fmt.Sprintf("malformed request: %v", err)})
+			conn.Close()
+			return
+		}
+		if req.Op == opAttach {
+			s.attach(conn, r, req)
+			return
+		}
+		resp := s.dispatch(req)
+		if err := enc.Encode(resp); err != nil {
+			conn.Close()
+			return
+		}
+		if req.Op == opKillServer {
+			conn.Close()
+			s.Close()
+			return
+		}
+	}
+}
+
+func (s *Server) dispatch(req request) response {
+	var resp response
+	var err error
+	switch req.Op {
+	case opHasSession:
+		s.mu.Lock()
+		_, resp.Exists = s.sessions[strings.TrimPrefix(req.Session, "=")]
+		s.mu.Unlock()
+	case opNewSession:
+		resp.Pane, err = s.newSession(req.Session, req.Dir)
+	case opKillSession:
+		err = s.killSession(req.Session)
+	case opListSessions:
+		resp.Sessions = s.listSessions()
+	case opListPanes:
+		resp.Panes, err = s.listPanes(req.Session)
+	case opSplit:
+		resp.Pane, err = s.split(req.Session, req.Dir)
+	case opKillPane:
+		var p *pane
+		if p, err = s.findPane(req.Target); err == nil {
+			p.kill()
+			s.removePane(p)
+		}
+	case opSetTitle:
+		var p *pane
+		if p, err = s.findPane(req.Target); err == nil {
+			p.mu.Lock()
+			p.title = req.Title
+			p.mu.Unlock()
+		}
+	case opSendKeys:
+		var p *pane
+		if p, err = s.findPane(req.Target); err == nil {
+			data := req.Keys
+			if !req.Literal {
+				data, err = keyBytes(req.Keys)
+			}
+			if err == nil {
+				err = p.write([]byte(data))
+			}
+		}
+	case opPaste:
+		var p *pane
+		if p, err = s.findPane(req.Target); err == nil {
+			err = p.paste(req.Keys)
+		}
+	case opCapture:
+		var p *pane
+		if p, err = s.findPane(req.Target); err == nil {
+			resp.Output = p.capture(req.Lines, req.Visible)
+		}
+	case opKillServer:
+	default:
+		err = fmt.Errorf("unknown command: %s", req.Op)
+	}
+	if err != nil {
+		resp.Error = err.Error()
+	}
+	return resp
+}
+
+func (s *Server) newSession(name, dir string) (string, error) {
+	name = strings.TrimPrefix(name, "=")
+	if err := tmux.ValidateSessionName(name); err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	if _, ok := s.sessions[name]; ok {
+		s.mu.Unlock()
+		return "", fmt.Errorf("duplicate session: %s", name)
+	}
+	sess := &session{name: name, created: time.Now()}
+	s.sessions[name] = sess
+	s.mu.Unlock()
+
+	p, err := s.startPane(sess, dir)
+	if err != nil {
+		s.mu.Lock()
+		delete(s.sessions, name)
+		s.mu.Unlock()
+		return "", err
+	}
+	return p.id, nil
+}
+
+func (s *Server) split(name, dir string) (string, error) {
+	s.mu.Lock()
+	sess, ok := s.sessions[strings.TrimPrefix(name, "=")]
+	s.mu.Unlock()
+	if !ok {
+		return "", fmt.Errorf("can't find session: %s", name)
+	}
+	p, err := s.startPane(sess, dir)
+	if err != nil {
+		return "", err
+	}
+	return p.id, nil
+}
+
+// startPane runs a shell on a new pseudo-terminal and adds it to sess.
+func (s *Server) startPane(sess *session, dir string) (*pane, error) {
+	shell := s.Shell
+	if shell == "" {
+		shell = os.Getenv("SHELL")
+	}
+	if shell == "" {
+		shell = "/bin/sh"
+	}
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+	// Like tmux, run the shell as a login shell.
+	cmd := exec.Command(shell)
+	cmd.Args = []string{"-" + filepath.Base(shell)}
+	cmd.Dir = dir
+	cmd.Env = paneEnv()
+
+	tty, err := startPTY(cmd, DefaultCols, DefaultRows)
+	if err != nil {
+		return nil, fmt.Errorf("start pane shell: %w", err)
+	}
+	host, _ := os.Hostname()
+
+	s.mu.Lock()
+	p := &pane{
+		id:       "%" + strconv.Itoa(s.nextID),
+		session:  sess,
+		shell:    shell,
+		cmd:      cmd,
+		tty:      tty,
+		exited:   make(chan struct{}),
+		title:    host,
+		cols:     DefaultCols,
+		rows:     DefaultRows,
+		buf:      newScrollback(s.Scrollback),
+		last:     time.Now(),
+		watchers: make(map[chan []byte]struct{}),
+	}
+	s.nextID++
+	s.panes[p.id] = p
+	sess.panes = append(sess.panes, p)
+	sess.active = p
+	s.mu.Unlock()
+
+	go s.pump(p)
+	return p, nil
+}
+
+// paneEnv is the daemon's environment minus tmux's variables, so agents in a
+// PTY pane do not believe they run inside tmux.
+func paneEnv() []string {
+	var env []string
+	for _, kv := range os.Environ() {
+		key, _, _ := strings.Cut(kv, "=")
+		switch key {
+		case "TMUX", "TMUX_PANE", "TERM":
+			continue
+		}
+		env = append(env, kv)
+	}
+	return append(env, "TERM=xterm-256color")
+}
+
+// pump copies pane output into its scrollback and attached clients until the
+// process exits, then removes the pane.
+func (s *Server) pump(p *pane) {
+	chunk := make([]byte, 32*1024)
+	for {
+		n, err := p.tty.Read(chunk)
+		if n > 0 {
+			data := append([]byte(nil), chunk[:n]...)
+			p.mu.Lock()
+			p.buf.Write(data)
+			p.last = time.Now()
+			for ch := range p.watchers {
+				select {
+				case ch <- data:
+				default: // a stalled client loses output rather than the pane
+				}
+			}
+			p.mu.Unlock()
+		}
+		if err != nil {
+			break
+		}
+	}
+	_ = p.cmd.Wait()
+	p.mu.Lock()
+	p.tty.Close()
+	p.closed = true
+	close(p.exited)
+	for ch := range p.watchers {
+		close(ch)
+		delete(p.watchers, ch)
+	}
+	p.mu.Unlock()
+	s.removePane(p)
+}
+
+// removePane drops p from the daemon, renumbers its session's panes and
+// ends the session with its last pane.
+func (s *Server) removePane(p *pane) {
+	s.mu.Lock()
+	if _, ok := s.panes[p.id]; !ok {
+		s.mu.Unlock()
+		return
+	}
+	delete(s.panes, p.id)
+	sess := p.session
+	for i, q := range sess.panes {
+		if q == p {
+			sess.panes = append(sess.panes[:i], sess.panes[i+1:]...)
+			break
+		}
+	}
+	if sess.active == p && len(sess.panes) > 0 {
+		sess.active = sess.panes[len(sess.panes)-1]
+	}
+	if len(sess.panes) == 0 && s.sessions[sess.name] == sess {
+		delete(s.sessions, sess.name)
+	}
+	empty := len(s.sessions) == 0
+	s.mu.Unlock()
+	if empty && s.ExitWhenEmpty {
+		s.Close()
+	}
+}
+
+func (s *Server) killSession(name string) error {
+	s.mu.Lock()
+	sess, ok := s.sessions[strings.TrimPrefix(name, "=")]
+	if !ok {
+		s.mu.Unlock()
+		return fmt.Errorf("can't find session: %s", name)
+	}
+	panes := append([]*pane(nil), sess.panes...)
+	s.mu.Unlock()
+	for _, p := range panes {
+		p.kill()
+		s.removePane(p)
+	}
+	return nil
+}
+
+func (s *Server) listSessions() []SessionInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]SessionInfo, 0, len(s.sessions))
+	for _, sess := range s.sessions {
+		info := SessionInfo{Name: sess.name, Panes: len(sess.panes), Created: sess.created}
+		for _, p := range sess.panes {
+			if p.attached() {
+				info.Attached = true
+			}
+		}
+		out = append(out, info)
+	}
+	sortSessions(out)
+	return out
+}
+
+func sortSessions(sessions []SessionInfo) {
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].Name < sessions[j].Name })
+}
+
+func (s *Server) listPanes(name string) ([]PaneInfo, error) {
+	s.mu.Lock()
+	sess, ok := s.sessions[strings.TrimPrefix(name, "=")]
+	if !ok {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("can't find session: %s", name)
+	}
+	panes := append([]*pane(nil), sess.panes...)
+	active := sess.active
+	s.mu.Unlock()
+
+	out := make([]PaneInfo, 0, len(panes))
+	for i, p := range panes {
+		p.mu.Lock()
+		info := PaneInfo{
+			ID:           p.id,
+			Session:      sess.name,
+			Index:        i,
+			Title:        p.title,
+			Width:        p.cols,
+			Height:       p.rows,
+			Active:       p == active,
+			Attached:     len(p.watchers) > 0,
+			LastActivity: p.last,
+		}
+		if p.cmd.Process != nil {
+			info.PID = p.cmd.Process.Pid
+		}
+		info.Command = filepath.Base(p.shell)
+		if !p.closed {
+			info.Command = foregroundCommand(p.tty, info.PID, p.shell)
+		}
+		p.mu.Unlock()
+		out = append(out, info)
+	}
+	return out, nil
+}
+
+// findPane resolves a tmux-style target: a pane ID (%3), a session (the
+// active pane), or session:window.pane with window 0.
+func (s *Server) findPane(target string) (*pane, error) {
+	t := strings.TrimPrefix(target, "=")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if strings.HasPrefix(t, "%") {
+		if p, ok := s.panes[t]; ok {
+			return p, nil
+		}
+		return nil, fmt.Errorf("can't find pane: %s", target)
+	}
+	name, rest, _ := strings.Cut(t, ":")
+	sess, ok := s.sessions[name]
+	if !ok {
+		return nil, fmt.Errorf("can't find session: %s", name)
+	}
+	win, idx, hasPane := strings.Cut(rest, ".")
+	if win != "" && win != "0" {
+		return nil, fmt.Errorf("can't find window: %s", win)
+	}
+	if !hasPane || idx == "" {
+		return sess.active, nil
+	}
+	i, err := strconv.Atoi(idx)
+	if err != nil || i < 0 || i >= len(sess.panes) {
+		return nil, fmt.Errorf("can't find pane: %s", target)
+	}
+	return sess.panes[i], nil
+}
+
+func (p *pane) write(data []byte) error {
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+	_, err := p.tty.Write(data)
+	return err
+}
+
+// paste delivers content the way tmux paste-buffer -p does: line feeds
+// become carriage returns, and the text is bracketed when the program
+// enabled bracketed paste.
+func (p *pane) paste(content string) error {
+	data := strings.ReplaceAll(content, "\n", "\r")
+	p.mu.Lock()
+	bracketed := p.buf.bracketedPaste
+	p.mu.Unlock()
+	if bracketed {
+		data = "\x1b[200~" + data + "\x1b[201~"
+	}
+	return p.write([]byte(data))
+}
+
+// capture returns the visible screen plus lines of scrollback above it.
+func (p *pane) capture(lines int, visible bool) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if lines < 0 {
+		lines = -lines
+	}
+	if visible {
+		lines = 0
+	}
+	return p.buf.Tail(p.rows + lines)
+}
+
+func (p *pane) attached() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.watchers) > 0
+}
+
+// kill hangs up the pane's process group, as tmux does when it destroys a
+// pane.
+func (p *pane) kill() {
+	if p.cmd.Process != nil {
+		hangup(p.cmd.Process)
+	}
+	select {
+	case <-p.exited:
+	case <-time.After(2 * time.Second):
+		if p.cmd.Process != nil {
+			_ = p.cmd.Process.Kill()
+		}
+	}
+}
+
+// attach turns conn into a raw terminal stream for one pane: the current
+// screen, then live output, with everything the client sends written to the
+// pane.
+func (s *Server) attach(conn net.Conn, r *bufio.Reader, req request) {
+	defer conn.Close()
+	p, err := s.findPane(req.Target)
+	if err != nil {
+		_ = json.NewEncoder(conn).Encode(response{Error: err.Error()})
+		return
+	}
+	if req.Cols > 0 && req.Rows > 0 {
+		p.mu.Lock()
+		if !p.closed && resizePTY(p.tty, req.Cols, req.Rows) == nil {
+			p.cols, p.rows = req.Cols, req.Rows
+		}
+		p.mu.Unlock()
+	}
+	ch := make(chan []byte, 256)
+	p.mu.Lock()
+	screen := p.buf.Tail(p.rows)
+	p.watchers[ch] = struct{}{}
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		if _, ok := p.watchers[ch]; ok {
+			delete(p.watchers, ch)
+			close(ch)
+		}
+		p.mu.Unlock()
+	}()
+
+	if err := json.NewEncoder(conn).Encode(response{Pane: p.id}); err != nil {
+		return
+	}
+	if _, err := io.WriteString(conn, "\x1b[H\x1b[2J"+strings.ReplaceAll(screen, "\n", "\r\n")); err != nil {
+		return
+	}
+
+	go func() {
+		for data := range ch {
+			if _, err := conn.Write(data); err != nil {
+				return
+			}
+		}
+		conn.Close()
+	}()
+	buf := make([]byte, 4096)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if werr := p.write(buf[:n]); werr != nil {
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
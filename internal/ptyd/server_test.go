@@ -0,0 +1,188 @@
+//go:build unix
+
+package ptyd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+)
+
+// startTestDaemon serves a daemon on a private socket and returns a tmux
+// client whose panes live in it.
+func startTestDaemon(t *testing.T) (*tmux.Client, *Client) {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "ptyd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	socket := filepath.Join(dir, "d.sock")
+
+	srv := NewServer(socket)
+	srv.Shell = "/bin/sh"
+	errc := make(chan error, 1)
+	go func() { errc <- srv.Serve() }()
+	t.Cleanup(func() {
+		srv.Close()
+		if err := <-errc; err != nil {
+			t.Errorf("Serve: %v", err)
+		}
+	})
+
+	pc := &Client{Socket: socket}
+	deadline := time.Now().Add(5 * time.Second)
+	for !pc.Running(context.Background()) {
+		if time.Now().After(deadline) {
+			t.Fatal("daemon did not start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	c := tmux.NewClient("")
+	c.SetBackend(pc)
+	return c, pc
+}
+
+func waitForCapture(t *testing.T, c *tmux.Client, target, want string) string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	var out string
+	for time.Now().Before(deadline) {
+		var err error
+		out, err = c.CapturePaneOutput(target, 100)
+		if err != nil {
+			t.Fatalf("capture %s: %v", target, err)
+		}
+		if strings.Contains(out, want) {
+			return out
+		}
+		time.Sleep(25 * time.Millisecond)
+	}
+	t.Fatalf("capture of %s never showed %q:\n%s", target, want, out)
+	return ""
+}
+
+func TestClientDrivesPanesThroughDaemon(t *testing.T) {
+	c, _ := startTestDaemon(t)
+	if !c.IsInstalled() {
+		t.Fatal("client with a backend reported tmux missing")
+	}
+	if c.SessionExists("proj") {
+		t.Fatal("session exists before creation")
+	}
+	work := t.TempDir()
+	if err := c.CreateSession("proj", work); err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	if err := c.CreateSession("proj", work); err == nil {
+		t.Fatal("duplicate session accepted")
+	}
+	paneID, err := c.SplitWindow("proj", work)
+	if err != nil {
+		t.Fatalf("SplitWindow: %v", err)
+	}
+	if err := c.SetPaneTitle(paneID, "proj__cc_1"); err != nil {
+		t.Fatalf("SetPaneTitle: %v", err)
+	}
+
+	panes, err := c.GetPanes("proj")
+	if err != nil {
+		t.Fatalf("GetPanes: %v", err)
+	}
+	if len(panes) != 2 || panes[0].ID != "%0" || panes[1].ID != paneID || panes[1].Index != 1 {
+		t.Fatalf("panes = %+v", panes)
+	}
+	if panes[1].Type != tmux.AgentClaude || panes[1].NTMIndex != 1 {
+		t.Errorf("title not classified: %+v", panes[1])
+	}
+	if panes[1].PID == 0 || panes[1].Command == "" {
+		t.Errorf("pane process not reported: %+v", panes[1])
+	}
+
+	if err := c.SendKeys(paneID, "echo hello-$((6*7)); pwd", true); err != nil {
+		t.Fatalf("SendKeys: %v", err)
+	}
+	out := waitForCapture(t, c, paneID, "hello-42")
+	if !strings.Contains(out, filepath.Base(work)) {
+		t.Errorf("pane did not start in %s:\n%s", work, out)
+	}
+
+	if err := c.SendBuffer("proj:0.1", "echo first\necho second", true); err != nil {
+		t.Fatalf("SendBuffer: %v", err)
+	}
+	waitForCapture(t, c, paneID, "second")
+
+	if err := c.SendKeys(paneID, "sleep 30", true); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if err := c.SendInterrupt(paneID); err != nil {
+		t.Fatalf("SendInterrupt: %v", err)
+	}
+	if err := c.SendKeys(paneID, "echo after-interrupt", true); err != nil {
+		t.Fatal(err)
+	}
+	waitForCapture(t, c, paneID, "after-interrupt")
+
+	if _, err := c.RunContext(context.Background(), "select-layout", "tiled"); !errors.Is(err, tmux.ErrBackendUnsupported) {
+		t.Errorf("raw tmux command = %v, want unsupported", err)
+	}
+
+	if err := c.KillPane(paneID); err != nil {
+		t.Fatalf("KillPane: %v", err)
+	}
+	if _, err := c.CapturePaneOutput(paneID, 10); tmux.ClassifyCommandError(err).Kind != tmux.CommandErrorPaneNotFound {
+		t.Errorf("capture of killed pane = %v, want pane not found", err)
+	}
+	sessions, err := c.ListSessions()
+	if err != nil || len(sessions) != 1 || sessions[0].Name != "proj" {
+		t.Fatalf("ListSessions = %+v, %v", sessions, err)
+	}
+	if err := c.KillSession("proj"); err != nil {
+		t.Fatalf("KillSession: %v", err)
+	}
+	if c.SessionExists("proj") {
+		t.Error("session survived KillSession")
+	}
+}
+
+func TestPaneExitEndsSession(t *testing.T) {
+	c, pc := startTestDaemon(t)
+	if err := c.CreateSession("short", t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SendKeys("short", "exit", true); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for c.SessionExists("short") {
+		if time.Now().After(deadline) {
+			t.Fatal("session outlived its only pane")
+		}
+		time.Sleep(25 * time.Millisecond)
+	}
+	if sessions, err := pc.Sessions(context.Background()); err != nil || len(sessions) != 0 {
+		t.Errorf("Sessions = %+v, %v", sessions, err)
+	}
+}
+
+func TestClientWithoutDaemonHasNoSessions(t *testing.T) {
+	pc := &Client{Socket: filepath.Join(t.TempDir(), "none.sock")}
+	c := tmux.NewClient("")
+	c.SetBackend(pc)
+	if c.SessionExists("x") {
+		t.Error("session reported without a daemon")
+	}
+	if sessions, err := c.ListSessions(); err != nil || len(sessions) != 0 {
+		t.Errorf("ListSessions = %+v, %v", sessions, err)
+	}
+	if err := c.CreateSession("x", t.TempDir()); err == nil {
+		t.Error("CreateSession without a daemon and autostart succeeded")
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/BurntSushi/toml"
 
 	agentpkg "github.com/Dicklesworthstone/ntm/internal/agent"
+	"github.com/Dicklesworthstone/ntm/internal/layout"
 )
 
 // Recipe defines a reusable session configuration preset.
@@ -20,7 +21,10 @@ type Recipe struct {
 	Name        string      `toml:"name"`
 	Description string      `toml:"description"`
 	Agents      []AgentSpec `toml:"agents"`
-	Source      string      `toml:"-"` // "builtin", "user", "project" - set at load time
+	// Layout arranges the spawned panes: a layout name (layout = "by-agent")
+	// or an inline table with strategy, per_window, main and main_size.
+	Layout *layout.Spec `toml:"layout,omitempty"`
+	Source string       `toml:"-"` // "builtin", "user", "project" - set at load time
 }
 
 // AgentSpec defines an agent configuration within a recipe.
@@ -349,5 +353,31 @@ func (r *Recipe) Validate() error {
 	if r.TotalAgents() > 50 {
 		return fmt.Errorf("recipe %q has too many agents: %d (max 50)", r.Name, r.TotalAgents())
 	}
+	if r.Layout != nil && !r.Layout.IsReference() {
+		l := *r.Layout
+		if l.Name == "" {
+			l.Name = r.Name
+		}
+		if err := l.Validate(); err != nil {
+			return fmt.Errorf("in recipe %q: %w", r.Name, err)
+		}
+	}
 	return nil
 }
+
+// ResolveLayout returns the recipe's layout, looking a named reference up in
+// custom layouts and the builtins. ok is false when the recipe has none.
+func (r *Recipe) ResolveLayout(custom []layout.Spec) (spec layout.Spec, ok bool, err error) {
+	if r.Layout == nil {
+		return layout.Spec{}, false, nil
+	}
+	if r.Layout.IsReference() {
+		spec, err = layout.Lookup(r.Layout.Name, custom)
+		return spec, err == nil, err
+	}
+	spec = *r.Layout
+	if spec.Name == "" {
+		spec.Name = r.Name
+	}
+	return spec, true, spec.Validate()
+}
@@ -5,6 +5,8 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/Dicklesworthstone/ntm/internal/layout"
 )
 
 func TestValidateAgentSpec(t *testing.T) {
@@ -230,6 +232,56 @@ count = 2
 	}
 }
 
+func TestLoadFromFile_Layouts(t *testing.T) {
+	t.Parallel()
+	tmpDir := t.TempDir()
+	recipesPath := filepath.Join(tmpDir, "recipes.toml")
+	content := `
+[[recipes]]
+name = "named"
+layout = "wide"
+[[recipes.agents]]
+type = "cc"
+count = 2
+
+[[recipes]]
+name = "inline"
+layout = { strategy = "by-agent", per_window = 4 }
+[[recipes.agents]]
+type = "cod"
+count = 6
+`
+	if err := os.WriteFile(recipesPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	recipes, err := loadFromFile(recipesPath, "test")
+	if err != nil {
+		t.Fatalf("loadFromFile() error: %v", err)
+	}
+	custom := []layout.Spec{{Name: "wide", Strategy: layout.StrategyMainVertical, MainSize: "60%"}}
+
+	spec, ok, err := recipes[0].ResolveLayout(custom)
+	if err != nil || !ok || spec.MainSize != "60%" {
+		t.Errorf("named layout = %+v, %v, %v", spec, ok, err)
+	}
+	spec, ok, err = recipes[1].ResolveLayout(nil)
+	if err != nil || !ok || spec.Name != "inline" || spec.Strategy != layout.StrategyByAgent || spec.PerWindow != 4 {
+		t.Errorf("inline layout = %+v, %v, %v", spec, ok, err)
+	}
+	if _, _, err := recipes[0].ResolveLayout(nil); err == nil {
+		t.Error("unknown layout name resolved")
+	}
+	if _, ok, _ := (&Recipe{Name: "plain"}).ResolveLayout(nil); ok {
+		t.Error("recipe without layout reported one")
+	}
+
+	bad := Recipe{Name: "bad", Agents: []AgentSpec{{Type: "cc", Count: 1}}, Layout: &layout.Spec{Strategy: "spiral"}}
+	if err := bad.Validate(); err == nil {
+		t.Error("invalid inline layout validated")
+	}
+}
+
 func TestLoadFromFile_RejectsUnknownFields(t *testing.T) {
 	t.Parallel()
 	tmpDir := t.TempDir()
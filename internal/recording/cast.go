@@ -0,0 +1,164 @@
+// Package recording captures pane output into asciicast v2 files and plays
+// them back. A recorder follows every pane of one session through the pane
+// streamer, writes one cast per pane with ANSI sequences and timing intact,
+// redacts secrets before anything reaches disk and rotates files by size.
+// Seek markers are taken from the persisted agent timeline and prompt
+// history at playback time.
+package recording
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"time"
+)
+
+// CastVersion is the asciicast format version written and accepted.
+const CastVersion = 2
+
+// Asciicast v2 event codes.
+const (
+	EventOutput = "o"
+	EventMarker = "m"
+	EventResize = "r"
+)
+
+// Header is the first line of an asciicast v2 file. NTM carries the pane
+// the cast was recorded from; players ignore keys they do not know.
+type Header struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp,omitempty"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+	NTM       *Meta             `json:"ntm,omitempty"`
+}
+
+// Meta identifies the pane behind a cast.
+type Meta struct {
+	Session   string `json:"session"`
+	PaneID    string `json:"pane_id"`
+	Window    int    `json:"window"`
+	Pane      int    `json:"pane"`
+	AgentID   string `json:"agent_id,omitempty"` // timeline agent ID, e.g. cc_1
+	AgentType string `json:"agent_type,omitempty"`
+	Part      int    `json:"part"` // 1 for the first file, incremented on rotation
+}
+
+// Start returns the wall-clock time the cast starts at.
+func (h Header) Start() time.Time {
+	return time.Unix(h.Timestamp, 0)
+}
+
+// Event is one asciicast event line: [time, code, data].
+type Event struct {
+	Time float64 // seconds since the header timestamp
+	Code string
+	Data string
+}
+
+// MarshalJSON encodes the event as the asciicast three-element array.
+func (e Event) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{math.Round(e.Time*1e6) / 1e6, e.Code, e.Data})
+}
+
+// UnmarshalJSON decodes the asciicast three-element array.
+func (e *Event) UnmarshalJSON(b []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	if len(raw) != 3 {
+		return fmt.Errorf("asciicast event has %d fields, want 3", len(raw))
+	}
+	if err := json.Unmarshal(raw[0], &e.Time); err != nil {
+		return fmt.Errorf("asciicast event time: %w", err)
+	}
+	if err := json.Unmarshal(raw[1], &e.Code); err != nil {
+		return fmt.Errorf("asciicast event code: %w", err)
+	}
+	if err := json.Unmarshal(raw[2], &e.Data); err != nil {
+		return fmt.Errorf("asciicast event data: %w", err)
+	}
+	return nil
+}
+
+// Cast is a parsed asciicast v2 recording.
+type Cast struct {
+	Header Header
+	Events []Event
+}
+
+// Duration returns the time of the last event in seconds.
+func (c *Cast) Duration() float64 {
+	if len(c.Events) == 0 {
+		return 0
+	}
+	return c.Events[len(c.Events)-1].Time
+}
+
+// Read parses an asciicast v2 stream.
+func Read(r io.Reader) (*Cast, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	c := &Cast{}
+	line := 0
+	for sc.Scan() {
+		line++
+		b := bytes.TrimSpace(sc.Bytes())
+		if len(b) == 0 {
+			continue
+		}
+		if line == 1 {
+			if err := json.Unmarshal(b, &c.Header); err != nil {
+				return nil, fmt.Errorf("asciicast header: %w", err)
+			}
+			if c.Header.Version != CastVersion {
+				return nil, fmt.Errorf("unsupported asciicast version %d", c.Header.Version)
+			}
+			continue
+		}
+		var ev Event
+		if err := json.Unmarshal(b, &ev); err != nil {
+			return nil, fmt.Errorf("asciicast line %d: %w", line, err)
+		}
+		c.Events = append(c.Events, ev)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if line == 0 {
+		return nil, fmt.Errorf("asciicast is empty")
+	}
+	return c, nil
+}
+
+// ReadFile parses the asciicast file at path.
+func ReadFile(path string) (*Cast, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// Write encodes c as asciicast v2.
+func (c *Cast) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(c.Header); err != nil {
+		return err
+	}
+	for _, ev := range c.Events {
+		if err := enc.Encode(ev); err != nil {
+			return err
+		}
+	}
+	return nil
+}
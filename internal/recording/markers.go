@@ -0,0 +1,145 @@
+package recording
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/history"
+	"github.com/Dicklesworthstone/ntm/internal/state"
+)
+
+// Marker is a named seek point in a recording.
+type Marker struct {
+	Time  float64 `json:"time"` // seconds since the cast start
+	Label string  `json:"label"`
+}
+
+// maxMarkerPrompt bounds the prompt text carried in a marker label.
+const maxMarkerPrompt = 60
+
+// Markers returns the cast's marker events in time order.
+func (c *Cast) Markers() []Marker {
+	markers := []Marker{}
+	for _, ev := range c.Events {
+		if ev.Code == EventMarker {
+			markers = append(markers, Marker{Time: ev.Time, Label: ev.Data})
+		}
+	}
+	return markers
+}
+
+// WithMarkers returns a copy of c with markers merged into its events.
+// Markers outside the cast are dropped.
+func (c *Cast) WithMarkers(markers []Marker) *Cast {
+	out := &Cast{Header: c.Header, Events: append([]Event(nil), c.Events...)}
+	end := c.Duration()
+	for _, m := range markers {
+		if m.Time < 0 || m.Time > end {
+			continue
+		}
+		out.Events = append(out.Events, Event{Time: m.Time, Code: EventMarker, Data: m.Label})
+	}
+	sort.SliceStable(out.Events, func(i, j int) bool { return out.Events[i].Time < out.Events[j].Time })
+	return out
+}
+
+// TimelineMarkers collects seek markers for a cast recorded by ntm: state
+// transitions of the pane's agent from the persisted session timeline, and
+// prompts sent to the pane from the prompt history. Sources that cannot be
+// read contribute nothing.
+func TimelineMarkers(c *Cast) []Marker {
+	if c.Header.NTM == nil {
+		return []Marker{}
+	}
+	session := c.Header.NTM.Session
+	var events []state.AgentEvent
+	if p, err := state.GetDefaultTimelinePersister(); err == nil {
+		events, _ = p.LoadTimeline(session)
+	}
+	entries, _ := history.ReadForSession(session)
+	return markersFor(c, events, entries)
+}
+
+func markersFor(c *Cast, events []state.AgentEvent, entries []history.HistoryEntry) []Marker {
+	meta := c.Header.NTM
+	start := c.Header.Start()
+	end := start.Add(time.Duration(c.Duration() * float64(time.Second)))
+	offset := func(t time.Time) (float64, bool) {
+		if t.Before(start) || t.After(end) {
+			return 0, false
+		}
+		return t.Sub(start).Seconds(), true
+	}
+
+	markers := []Marker{}
+	if meta.AgentID != "" {
+		for _, e := range events {
+			if e.AgentID != meta.AgentID {
+				continue
+			}
+			if t, ok := offset(e.Timestamp); ok {
+				markers = append(markers, Marker{Time: t, Label: fmt.Sprintf("%s %s", e.AgentID, e.State)})
+			}
+		}
+	}
+	for _, e := range entries {
+		if !e.Success || !targetsPane(e.Targets, meta) {
+			continue
+		}
+		if t, ok := offset(e.Timestamp); ok {
+			markers = append(markers, Marker{Time: t, Label: "prompt: " + promptSummary(e.Prompt)})
+		}
+	}
+	sort.SliceStable(markers, func(i, j int) bool { return markers[i].Time < markers[j].Time })
+	return markers
+}
+
+// targetsPane reports whether a history target list names the pane. History
+// stores the bare pane index for single-window sessions and window.pane
+// otherwise.
+func targetsPane(targets []string, m *Meta) bool {
+	for _, t := range targets {
+		if t == m.PaneID || t == fmt.Sprintf("%d.%d", m.Window, m.Pane) || t == strconv.Itoa(m.Pane) {
+			return true
+		}
+	}
+	return false
+}
+
+func promptSummary(prompt string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(prompt), "\n")
+	if r := []rune(line); len(r) > maxMarkerPrompt {
+		return string(r[:maxMarkerPrompt-1]) + "…"
+	}
+	return line
+}
+
+// Seek resolves a seek reference to a time in c. ref is a 1-based marker
+// number, a duration into the recording ("90s", "2m"), or text matched
+// case-insensitively against marker labels (first match wins).
+func Seek(c *Cast, ref string) (float64, error) {
+	ref = strings.TrimSpace(ref)
+	markers := c.Markers()
+	if n, err := strconv.Atoi(ref); err == nil {
+		if n < 1 || n > len(markers) {
+			return 0, fmt.Errorf("marker %d out of range: recording has %d markers", n, len(markers))
+		}
+		return markers[n-1].Time, nil
+	}
+	if d, err := time.ParseDuration(ref); err == nil {
+		if d < 0 {
+			return 0, fmt.Errorf("seek offset %s is negative", ref)
+		}
+		return d.Seconds(), nil
+	}
+	needle := strings.ToLower(ref)
+	for _, m := range markers {
+		if strings.Contains(strings.ToLower(m.Label), needle) {
+			return m.Time, nil
+		}
+	}
+	return 0, fmt.Errorf("no marker matches %q", ref)
+}
@@ -0,0 +1,67 @@
+package recording
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// PlayOptions controls terminal playback.
+type PlayOptions struct {
+	// Speed is the playback rate; 2 plays twice as fast. Zero means 1.
+	Speed float64
+
+	// IdleLimit caps any pause between events. Zero keeps pauses as
+	// recorded.
+	IdleLimit time.Duration
+
+	// From skips to this many seconds into the recording. Output before it
+	// is written at once so the screen is current when timed playback
+	// starts.
+	From float64
+}
+
+// Play writes the cast's output to w with its original timing.
+func Play(ctx context.Context, w io.Writer, c *Cast, opts PlayOptions) error {
+	return play(ctx, w, c, opts, sleepContext)
+}
+
+func play(ctx context.Context, w io.Writer, c *Cast, opts PlayOptions, sleep func(context.Context, time.Duration) error) error {
+	speed := opts.Speed
+	if speed <= 0 {
+		speed = 1
+	}
+	prev := opts.From
+	for _, ev := range c.Events {
+		if ev.Code != EventOutput {
+			continue
+		}
+		if ev.Time > opts.From {
+			gap := time.Duration((ev.Time - prev) * float64(time.Second))
+			if opts.IdleLimit > 0 && gap > opts.IdleLimit {
+				gap = opts.IdleLimit
+			}
+			if gap = time.Duration(float64(gap) / speed); gap > 0 {
+				if err := sleep(ctx, gap); err != nil {
+					return err
+				}
+			}
+			prev = ev.Time
+		}
+		if _, err := io.WriteString(w, ev.Data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
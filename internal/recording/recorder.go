@@ -0,0 +1,419 @@
+package recording
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/redaction"
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+)
+
+// DefaultMaxBytes is the size at which a pane's cast is rotated.
+const DefaultMaxBytes int64 = 50 << 20
+
+// DefaultPaneRefresh is how often a recorder looks for added or removed panes.
+const DefaultPaneRefresh = 2 * time.Second
+
+// clearScreen homes the cursor and clears the screen before a full repaint.
+const clearScreen = "\x1b[H\x1b[2J"
+
+// Options configures a Recorder.
+type Options struct {
+	// Dir is the recordings root; casts go under Dir/<session>/. Empty
+	// means DefaultDir().
+	Dir string
+
+	// MaxBytes rotates a pane's cast into a new part once it reaches this
+	// size. Zero means DefaultMaxBytes.
+	MaxBytes int64
+
+	// Redaction scrubs secrets from output before it is written. Nil or
+	// mode off records output verbatim; warn and block redact, since a
+	// recording is persistence.
+	Redaction *redaction.Config
+
+	// Client is the tmux server to record from. Nil means
+	// tmux.DefaultClient.
+	Client *tmux.Client
+
+	// Stream configures the pane streamers. The zero value uses
+	// tmux.DefaultPaneStreamerConfig().
+	Stream tmux.PaneStreamerConfig
+
+	// PaneRefresh is how often new panes are picked up. Zero means
+	// DefaultPaneRefresh.
+	PaneRefresh time.Duration
+}
+
+// DefaultDir returns the recordings root: $XDG_DATA_HOME/ntm/recordings, or
+// ~/.local/share/ntm/recordings.
+func DefaultDir() string {
+	if xdg := os.Getenv("XDG_DATA_HOME"); xdg != "" {
+		return filepath.Join(xdg, "ntm", "recordings")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return filepath.Join(os.TempDir(), fmt.Sprintf("ntm_recordings_%d", os.Getuid()))
+	}
+	return filepath.Join(home, ".local", "share", "ntm", "recordings")
+}
+
+// Recorder records every pane of one session.
+type Recorder struct {
+	session string
+	opts    Options
+	client  *tmux.Client
+	stamp   string // recording start, shared by all file names of this run
+
+	mu      sync.Mutex
+	panes   map[string]*paneRecorder // by pane ID
+	manager *tmux.StreamManager
+	closed  bool
+}
+
+// NewRecorder prepares a recorder for session. Nothing is written until Run.
+func NewRecorder(session string, opts Options) *Recorder {
+	if opts.Dir == "" {
+		opts.Dir = DefaultDir()
+	}
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = DefaultMaxBytes
+	}
+	if opts.PaneRefresh <= 0 {
+		opts.PaneRefresh = DefaultPaneRefresh
+	}
+	if opts.Stream == (tmux.PaneStreamerConfig{}) {
+		opts.Stream = tmux.DefaultPaneStreamerConfig()
+	}
+	if opts.Redaction != nil {
+		cfg := opts.Redaction.DeepCopy()
+		if cfg.Mode == redaction.ModeWarn || cfg.Mode == redaction.ModeBlock {
+			cfg.Mode = redaction.ModeRedact
+		}
+		opts.Redaction = &cfg
+	}
+	client := opts.Client
+	if client == nil {
+		client = tmux.DefaultClient
+	}
+	r := &Recorder{
+		session: session,
+		opts:    opts,
+		client:  client,
+		stamp:   time.Now().UTC().Format("20060102T150405Z"),
+		panes:   make(map[string]*paneRecorder),
+	}
+	r.manager = tmux.NewStreamManager(client, r.onStream, opts.Stream)
+	return r
+}
+
+// Run records until ctx is done or the session ends. Panes added to the
+// session while recording are picked up; panes that go away are closed.
+func (r *Recorder) Run(ctx context.Context) error {
+	if err := r.sync(ctx); err != nil {
+		r.Close()
+		return err
+	}
+	defer r.Close()
+
+	ticker := time.NewTicker(r.opts.PaneRefresh)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			exists, err := r.client.SessionExistsContext(ctx, r.session)
+			if ctx.Err() != nil {
+				return nil
+			}
+			if err == nil && !exists {
+				return nil
+			}
+			if err := r.sync(ctx); err != nil && ctx.Err() == nil {
+				return err
+			}
+		}
+	}
+}
+
+// Close stops streaming and closes every cast. It is safe to call twice.
+func (r *Recorder) Close() {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return
+	}
+	r.closed = true
+	panes := make([]*paneRecorder, 0, len(r.panes))
+	for _, p := range r.panes {
+		panes = append(panes, p)
+	}
+	r.mu.Unlock()
+
+	r.manager.StopAll()
+	for _, p := range panes {
+		p.close()
+	}
+}
+
+// Files returns every cast written so far, in pane then part order.
+func (r *Recorder) Files() []string {
+	r.mu.Lock()
+	panes := make([]*paneRecorder, 0, len(r.panes))
+	for _, p := range r.panes {
+		panes = append(panes, p)
+	}
+	r.mu.Unlock()
+	sort.Slice(panes, func(i, j int) bool {
+		if panes[i].meta.Window != panes[j].meta.Window {
+			return panes[i].meta.Window < panes[j].meta.Window
+		}
+		return panes[i].meta.Pane < panes[j].meta.Pane
+	})
+	var files []string
+	for _, p := range panes {
+		p.mu.Lock()
+		files = append(files, p.paths...)
+		p.mu.Unlock()
+	}
+	return files
+}
+
+// sync starts recording new panes and stops recording panes that are gone.
+func (r *Recorder) sync(ctx context.Context) error {
+	panes, err := r.client.GetPanesContext(ctx, r.session)
+	if err != nil {
+		return fmt.Errorf("list panes of %s: %w", r.session, err)
+	}
+	seen := make(map[string]bool, len(panes))
+	for _, pane := range panes {
+		seen[pane.ID] = true
+		r.mu.Lock()
+		_, known := r.panes[pane.ID]
+		closed := r.closed
+		r.mu.Unlock()
+		if known || closed {
+			continue
+		}
+
+		p := r.newPaneRecorder(pane)
+		r.mu.Lock()
+		r.panes[pane.ID] = p
+		r.mu.Unlock()
+
+		// Seed the cast with what the pane shows now, so playback starts
+		// from the screen the first streamed output lands on.
+		screen, err := r.client.RunContext(ctx, "capture-pane", "-p", "-e", "-t", pane.ID)
+		if err == nil {
+			if err := p.write(time.Now(), clearScreen+strings.ReplaceAll(screen, "\n", "\r\n")); err != nil {
+				return err
+			}
+		}
+		if err := r.manager.StartStream(pane.ID); err != nil {
+			return fmt.Errorf("stream %s: %w", pane.ID, err)
+		}
+	}
+
+	r.mu.Lock()
+	var gone []*paneRecorder
+	for id, p := range r.panes {
+		if !seen[id] && !p.isClosed() {
+			gone = append(gone, p)
+		}
+	}
+	r.mu.Unlock()
+	for _, p := range gone {
+		r.manager.StopStream(p.meta.PaneID)
+		p.close()
+	}
+	return nil
+}
+
+func (r *Recorder) onStream(ev tmux.StreamEvent) {
+	r.mu.Lock()
+	p := r.panes[ev.Target]
+	r.mu.Unlock()
+	if p == nil || len(ev.Lines) == 0 {
+		return
+	}
+	_ = p.write(ev.Timestamp, streamData(ev))
+}
+
+// streamData turns a stream event back into terminal output. Polled
+// captures repaint the whole screen; streamed lines are re-terminated, with
+// a carriage return added where the pane emitted a bare newline.
+func streamData(ev tmux.StreamEvent) string {
+	if ev.IsFull {
+		return clearScreen + strings.Join(ev.Lines, "\r\n")
+	}
+	var b strings.Builder
+	for _, line := range ev.Lines {
+		b.WriteString(line)
+		if !strings.HasSuffix(line, "\r") {
+			b.WriteByte('\r')
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func (r *Recorder) newPaneRecorder(pane tmux.Pane) *paneRecorder {
+	meta := Meta{
+		Session: r.session,
+		PaneID:  pane.ID,
+		Window:  pane.WindowIndex,
+		Pane:    pane.Index,
+	}
+	if pane.Type != tmux.AgentUser && pane.Type != tmux.AgentUnknown {
+		meta.AgentType = string(pane.Type)
+		if pane.NTMIndex > 0 {
+			meta.AgentID = fmt.Sprintf("%s_%d", pane.Type, pane.NTMIndex)
+		}
+	}
+	return &paneRecorder{
+		dir:      filepath.Join(r.opts.Dir, r.session),
+		base:     fmt.Sprintf("%s-%d.%d", r.stamp, pane.WindowIndex, pane.Index),
+		meta:     meta,
+		width:    pane.Width,
+		height:   pane.Height,
+		maxBytes: r.opts.MaxBytes,
+		redact:   r.opts.Redaction,
+	}
+}
+
+// paneRecorder writes one pane's casts.
+type paneRecorder struct {
+	dir      string
+	base     string
+	meta     Meta
+	width    int
+	height   int
+	maxBytes int64
+	redact   *redaction.Config
+
+	mu     sync.Mutex
+	file   *os.File
+	start  time.Time
+	size   int64
+	paths  []string
+	closed bool
+}
+
+// write appends an output event, opening or rotating the cast as needed.
+func (p *paneRecorder) write(at time.Time, data string) error {
+	if data == "" {
+		return nil
+	}
+	if p.redact != nil && p.redact.Mode != redaction.ModeOff {
+		data = redaction.ScanAndRedact(data, *p.redact).Output
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil
+	}
+	if p.file != nil && p.size >= p.maxBytes {
+		p.closeFile()
+	}
+	if p.file == nil {
+		if err := p.open(at); err != nil {
+			return err
+		}
+	}
+	t := at.Sub(p.start).Seconds()
+	if t < 0 {
+		t = 0
+	}
+	line, err := encodeLine(Event{Time: t, Code: EventOutput, Data: data})
+	if err != nil {
+		return err
+	}
+	n, err := p.file.Write(line)
+	p.size += int64(n)
+	return err
+}
+
+// open starts the next part. The header timestamp has whole-second
+// precision, so event times are measured from the truncated start.
+func (p *paneRecorder) open(at time.Time) error {
+	if err := os.MkdirAll(p.dir, 0o700); err != nil {
+		return fmt.Errorf("create recordings dir: %w", err)
+	}
+	p.meta.Part++
+	name := p.base
+	if p.meta.Part > 1 {
+		name = fmt.Sprintf("%s-p%d", p.base, p.meta.Part)
+	}
+	path := filepath.Join(p.dir, name+".cast")
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("create recording: %w", err)
+	}
+	p.start = at.Truncate(time.Second)
+	meta := p.meta
+	title := fmt.Sprintf("%s %d.%d", meta.Session, meta.Window, meta.Pane)
+	if meta.AgentID != "" {
+		title += " (" + meta.AgentID + ")"
+	}
+	header, err := encodeLine(Header{
+		Version:   CastVersion,
+		Width:     p.width,
+		Height:    p.height,
+		Timestamp: p.start.Unix(),
+		Title:     title,
+		NTM:       &meta,
+	})
+	if err == nil {
+		_, err = f.Write(header)
+	}
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("write recording header: %w", err)
+	}
+	p.file = f
+	p.size = int64(len(header))
+	p.paths = append(p.paths, path)
+	return nil
+}
+
+func (p *paneRecorder) closeFile() {
+	if p.file != nil {
+		_ = p.file.Close()
+		p.file = nil
+	}
+}
+
+func (p *paneRecorder) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	p.closeFile()
+}
+
+func (p *paneRecorder) isClosed() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.closed
+}
+
+// encodeLine renders v as one JSON line without HTML escaping, which would
+// otherwise bloat terminal output full of '<' and '&'.
+func encodeLine(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,289 @@
+package recording
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/history"
+	"github.com/Dicklesworthstone/ntm/internal/redaction"
+	"github.com/Dicklesworthstone/ntm/internal/state"
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+)
+
+func TestCastRoundTrip(t *testing.T) {
+	c := &Cast{
+		Header: Header{Version: CastVersion, Width: 80, Height: 24, Timestamp: 1700000000, NTM: &Meta{Session: "s", PaneID: "%1", Part: 1}},
+		Events: []Event{
+			{Time: 0, Code: EventOutput, Data: "\x1b[31mred\x1b[0m <tag> & more\r\n"},
+			{Time: 1.2345678, Code: EventMarker, Data: "cc_1 working"},
+		},
+	}
+	var buf bytes.Buffer
+	if err := c.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), `<`) {
+		t.Errorf("output is HTML-escaped: %s", buf.String())
+	}
+	if !strings.Contains(buf.String(), `[1.234568,"m","cc_1 working"]`) {
+		t.Errorf("event not encoded as asciicast array: %s", buf.String())
+	}
+	got, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Header.NTM == nil || got.Header.NTM.PaneID != "%1" || got.Events[0].Data != c.Events[0].Data {
+		t.Fatalf("round trip = %+v", got)
+	}
+	if len(got.Markers()) != 1 || got.Duration() != 1.234568 {
+		t.Errorf("markers = %v, duration = %v", got.Markers(), got.Duration())
+	}
+
+	if _, err := Read(strings.NewReader(`{"version":1,"width":80,"height":24}`)); err == nil {
+		t.Error("Read accepted asciicast v1")
+	}
+}
+
+func TestPaneRecorderRotatesAndRedacts(t *testing.T) {
+	root := t.TempDir()
+	cfg := redaction.Config{Mode: redaction.ModeRedact}
+	p := &paneRecorder{
+		dir:      filepath.Join(root, "proj"),
+		base:     "20261017T021400Z-0.1",
+		meta:     Meta{Session: "proj", PaneID: "%3", Pane: 1, AgentID: "cc_1"},
+		width:    100,
+		height:   30,
+		maxBytes: 300,
+		redact:   &cfg,
+	}
+	start := time.Unix(1700000000, 0)
+	secret := "sk-ant-api03-" + strings.Repeat("a", 90)
+	for i := 0; i < 6; i++ {
+		if err := p.write(start.Add(time.Duration(i)*time.Second), fmt.Sprintf("line %d %s\r\n", i, secret)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p.close()
+
+	if len(p.paths) < 2 {
+		t.Fatalf("expected rotation, got %v", p.paths)
+	}
+	if filepath.Base(p.paths[1]) != "20261017T021400Z-0.1-p2.cast" {
+		t.Errorf("second part = %s", p.paths[1])
+	}
+	for i, path := range p.paths {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(raw), secret) {
+			t.Errorf("%s contains the unredacted secret", path)
+		}
+		c, err := Read(bytes.NewReader(raw))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Header.NTM.Part != i+1 || c.Header.Width != 100 {
+			t.Errorf("%s header = %+v", path, c.Header)
+		}
+		if len(c.Events) == 0 {
+			t.Errorf("%s has no events", path)
+		}
+	}
+
+	infos, err := List(root, "")
+	if err != nil || len(infos) != len(p.paths) {
+		t.Fatalf("List = %v, %v", infos, err)
+	}
+	if other, err := List(root, "other"); err != nil || len(other) != 0 {
+		t.Errorf("List(other) = %v, %v", other, err)
+	}
+	if infos[0].AgentID != "cc_1" || infos[0].Pane != "0.1" {
+		t.Errorf("info = %+v", infos[0])
+	}
+}
+
+func TestStreamData(t *testing.T) {
+	if got := streamData(tmux.StreamEvent{Lines: []string{"a", "b\r"}}); got != "a\r\nb\r\n" {
+		t.Errorf("incremental = %q", got)
+	}
+	if got := streamData(tmux.StreamEvent{Lines: []string{"a", "b"}, IsFull: true}); got != clearScreen+"a\r\nb" {
+		t.Errorf("full = %q", got)
+	}
+}
+
+func TestMarkersForAndSeek(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	c := &Cast{
+		Header: Header{Version: CastVersion, Timestamp: start.Unix(), NTM: &Meta{Session: "proj", PaneID: "%7", Window: 0, Pane: 2, AgentID: "cc_2"}},
+		Events: []Event{{Time: 0, Code: EventOutput, Data: "a"}, {Time: 100, Code: EventOutput, Data: "b"}},
+	}
+	events := []state.AgentEvent{
+		{AgentID: "cc_2", State: state.TimelineWorking, Timestamp: start.Add(10 * time.Second)},
+		{AgentID: "cc_1", State: state.TimelineWorking, Timestamp: start.Add(11 * time.Second)},
+		{AgentID: "cc_2", State: state.TimelineIdle, Timestamp: start.Add(90 * time.Second)},
+		{AgentID: "cc_2", State: state.TimelineError, Timestamp: start.Add(500 * time.Second)},
+	}
+	entries := []history.HistoryEntry{
+		{Targets: []string{"2"}, Prompt: "run the database migration\nthen report", Success: true, Timestamp: start.Add(5 * time.Second)},
+		{Targets: []string{"1"}, Prompt: "other pane", Success: true, Timestamp: start.Add(6 * time.Second)},
+		{Targets: []string{"0.2"}, Prompt: "failed send", Success: false, Timestamp: start.Add(7 * time.Second)},
+	}
+	markers := markersFor(c, events, entries)
+	want := []string{"prompt: run the database migration", "cc_2 working", "cc_2 idle"}
+	if len(markers) != len(want) {
+		t.Fatalf("markers = %+v", markers)
+	}
+	for i, m := range markers {
+		if m.Label != want[i] {
+			t.Errorf("marker %d = %q, want %q", i, m.Label, want[i])
+		}
+	}
+
+	merged := c.WithMarkers(markers)
+	if len(merged.Events) != 5 || len(c.Events) != 2 {
+		t.Fatalf("merged events = %+v", merged.Events)
+	}
+	for _, tt := range []struct {
+		ref  string
+		want float64
+	}{
+		{"2", 10},
+		{"migration", 5},
+		{"IDLE", 90},
+		{"30s", 30},
+	} {
+		got, err := Seek(merged, tt.ref)
+		if err != nil || got != tt.want {
+			t.Errorf("Seek(%q) = %v, %v; want %v", tt.ref, got, err, tt.want)
+		}
+	}
+	for _, bad := range []string{"0", "9", "nothing like it"} {
+		if _, err := Seek(merged, bad); err == nil {
+			t.Errorf("Seek(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestPlayTimingAndSeek(t *testing.T) {
+	c := &Cast{Events: []Event{
+		{Time: 0, Code: EventOutput, Data: "a"},
+		{Time: 2, Code: EventOutput, Data: "b"},
+		{Time: 3, Code: EventMarker, Data: "m"},
+		{Time: 10, Code: EventOutput, Data: "c"},
+		{Time: 11, Code: EventOutput, Data: "d"},
+	}}
+	var sleeps []time.Duration
+	sleep := func(_ context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		return nil
+	}
+
+	var out bytes.Buffer
+	if err := play(context.Background(), &out, c, PlayOptions{Speed: 2, IdleLimit: 4 * time.Second}, sleep); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "abcd" {
+		t.Errorf("output = %q", out.String())
+	}
+	want := []time.Duration{time.Second, 2 * time.Second, 500 * time.Millisecond}
+	if fmt.Sprint(sleeps) != fmt.Sprint(want) {
+		t.Errorf("sleeps = %v, want %v", sleeps, want)
+	}
+
+	out.Reset()
+	sleeps = nil
+	if err := play(context.Background(), &out, c, PlayOptions{From: 10}, sleep); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "abcd" || fmt.Sprint(sleeps) != fmt.Sprint([]time.Duration{time.Second}) {
+		t.Errorf("seek output = %q, sleeps = %v", out.String(), sleeps)
+	}
+}
+
+func TestPathRejectsTraversal(t *testing.T) {
+	if p, err := Path("/r", "proj/20261017T021400Z-0.1"); err != nil || p != "/r/proj/20261017T021400Z-0.1.cast" {
+		t.Errorf("Path = %q, %v", p, err)
+	}
+	for _, bad := range []string{"", "proj", "../x", "proj/..", "proj/a/b", `proj/a\b`} {
+		if _, err := Path("/r", bad); err == nil {
+			t.Errorf("Path(%q) succeeded", bad)
+		}
+	}
+	if _, err := Load(t.TempDir(), "proj/missing"); err == nil || !strings.Contains(err.Error(), ErrNotFound.Error()) {
+		t.Errorf("Load missing = %v", err)
+	}
+}
+
+func TestRecorderRecordsSession(t *testing.T) {
+	if !tmux.IsInstalled() {
+		t.Skip("tmux not installed")
+	}
+	client := tmux.NewClient("")
+	client.Socket = fmt.Sprintf("ntm-rec-%d", os.Getpid())
+	t.Cleanup(func() { _ = client.RunSilent("kill-server") })
+	if err := client.CreateSession("rec", t.TempDir()); err != nil {
+		t.Skipf("create session: %v", err)
+	}
+	panes, err := client.GetPanes("rec")
+	if err != nil || len(panes) != 1 {
+		t.Fatalf("panes = %v, %v", panes, err)
+	}
+	if err := client.RunSilent("respawn-pane", "-k", "-t", panes[0].ID, "cat"); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	stream := tmux.DefaultPaneStreamerConfig()
+	stream.FIFODir = t.TempDir()
+	rec := NewRecorder("rec", Options{Dir: dir, Client: client, Stream: stream, PaneRefresh: 100 * time.Millisecond})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- rec.Run(ctx) }()
+
+	// The first event is the screen seeded at start; the marker must arrive
+	// through the pane stream after it.
+	streamed := func(c *Cast) bool {
+		for i, ev := range c.Events {
+			if i > 0 && strings.Contains(ev.Data, "recorded-marker") {
+				return true
+			}
+		}
+		return false
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	var casts []Info
+	var c *Cast
+	for time.Now().Before(deadline) {
+		if casts, _ = List(dir, "rec"); len(casts) == 1 {
+			_ = client.SendKeys(panes[0].ID, "recorded-marker", true)
+			time.Sleep(300 * time.Millisecond)
+			if c, _ = ReadFile(casts[0].Path); c != nil && streamed(c) {
+				break
+			}
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(casts) != 1 || c == nil {
+		t.Fatalf("casts = %+v", casts)
+	}
+	if !streamed(c) {
+		t.Fatalf("streamed output not recorded: %+v", c.Events)
+	}
+	if c.Header.NTM.PaneID != panes[0].ID || c.Header.Width == 0 {
+		t.Errorf("header = %+v", c.Header)
+	}
+	if files := rec.Files(); len(files) != 1 || files[0] != casts[0].Path {
+		t.Errorf("Files() = %v", files)
+	}
+}
@@ -0,0 +1,134 @@
+package recording
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Info describes one cast on disk.
+type Info struct {
+	ID        string    `json:"id"` // <session>/<name>, the handle used by play, export and the API
+	Session   string    `json:"session"`
+	PaneID    string    `json:"pane_id,omitempty"`
+	Pane      string    `json:"pane"` // window.pane
+	AgentID   string    `json:"agent_id,omitempty"`
+	AgentType string    `json:"agent_type,omitempty"`
+	Part      int       `json:"part"`
+	Started   time.Time `json:"started"`
+	Duration  float64   `json:"duration_sec"`
+	Width     int       `json:"width"`
+	Height    int       `json:"height"`
+	Size      int64     `json:"size_bytes"`
+	Path      string    `json:"path"`
+}
+
+// ErrNotFound is returned when a recording ID does not name a cast.
+var ErrNotFound = errors.New("recording not found")
+
+// Path resolves a recording ID ("<session>/<name>") to its file under dir.
+func Path(dir, id string) (string, error) {
+	session, name, ok := strings.Cut(strings.TrimSuffix(id, ".cast"), "/")
+	if !ok || !validComponent(session) || !validComponent(name) {
+		return "", fmt.Errorf("invalid recording id %q: want <session>/<name>", id)
+	}
+	return filepath.Join(dir, session, name+".cast"), nil
+}
+
+func validComponent(s string) bool {
+	return s != "" && s != "." && s != ".." && !strings.ContainsAny(s, `/\`)
+}
+
+// Load reads the cast with the given ID.
+func Load(dir, id string) (*Cast, error) {
+	path, err := Path(dir, id)
+	if err != nil {
+		return nil, err
+	}
+	c, err := ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	return c, err
+}
+
+// List returns the casts under dir, newest first. A non-empty session limits
+// the listing to that session. Unreadable files are skipped.
+func List(dir, session string) ([]Info, error) {
+	sessions := []string{session}
+	if session == "" {
+		entries, err := os.ReadDir(dir)
+		if errors.Is(err, os.ErrNotExist) {
+			return []Info{}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		sessions = sessions[:0]
+		for _, e := range entries {
+			if e.IsDir() {
+				sessions = append(sessions, e.Name())
+			}
+		}
+	} else if !validComponent(session) {
+		return nil, fmt.Errorf("invalid session %q", session)
+	}
+
+	infos := []Info{}
+	for _, s := range sessions {
+		paths, err := filepath.Glob(filepath.Join(dir, s, "*.cast"))
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range paths {
+			info, err := stat(s, path)
+			if err != nil {
+				continue
+			}
+			infos = append(infos, info)
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		if !infos[i].Started.Equal(infos[j].Started) {
+			return infos[i].Started.After(infos[j].Started)
+		}
+		return infos[i].ID < infos[j].ID
+	})
+	return infos, nil
+}
+
+func stat(session, path string) (Info, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return Info{}, err
+	}
+	c, err := ReadFile(path)
+	if err != nil {
+		return Info{}, err
+	}
+	info := Info{
+		ID:       session + "/" + strings.TrimSuffix(filepath.Base(path), ".cast"),
+		Session:  session,
+		Part:     1,
+		Started:  c.Header.Start().UTC(),
+		Duration: c.Duration(),
+		Width:    c.Header.Width,
+		Height:   c.Header.Height,
+		Size:     fi.Size(),
+		Path:     path,
+	}
+	if m := c.Header.NTM; m != nil {
+		info.PaneID = m.PaneID
+		info.Pane = fmt.Sprintf("%d.%d", m.Window, m.Pane)
+		info.AgentID = m.AgentID
+		info.AgentType = m.AgentType
+		if m.Part > 0 {
+			info.Part = m.Part
+		}
+	}
+	return info, nil
+}
@@ -0,0 +1,29 @@
+package resilience
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/Dicklesworthstone/ntm/tests/testutil"
+)
+
+// TestMain runs the suite from a private working directory: crash reports go
+// to the notifier's default human inbox, ./.ntm/human_inbox, which must never
+// land in the package source tree.
+func TestMain(m *testing.M) {
+	cleanupCwd, err := testutil.IsolateWorkingDirProcess()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "isolate resilience working dir: %v\n", err)
+		os.Exit(1)
+	}
+
+	code := m.Run()
+
+	if err := cleanupCwd(); err != nil {
+		fmt.Fprintf(os.Stderr, "clean up isolated resilience working dir: %v\n", err)
+		code = 1
+	}
+
+	os.Exit(code)
+}
@@ -470,6 +470,24 @@ func buildCommandRegistry() []RobotCommandInfo {
 				"ntm --robot-tail=myproject --panes=2 --fresh",
 			},
 		},
+		{
+			Name:        "turns",
+			Flag:        "--robot-turns",
+			Category:    "state",
+			Description: "Segment pane scrollback into turns: user prompts, assistant text, tool calls and results, code blocks, diffs and errors, with the last segment of each kind per pane. Parsers cover cc, cod and agy/gmi; other panes get a generic parse.",
+			Parameters: []RobotParameter{
+				{Name: "session", Flag: "--robot-turns", Type: "string", Required: true, Description: "Session name"},
+				{Name: "panes", Flag: "--panes", Type: "string", Required: false, Description: "Comma-separated N, W.P, or %N pane selectors"},
+				{Name: "lines", Flag: "--lines", Type: "int", Required: false, Default: "500", Description: "Scrollback lines to segment per pane"},
+				{Name: "turn_kinds", Flag: "--turn-kinds", Type: "string", Required: false, Description: "Comma-separated kinds to keep: user_prompt, assistant, tool_call, tool_result, code, diff, error"},
+				{Name: "turn_limit", Flag: "--turn-limit", Type: "int", Required: false, Default: "0", Description: "Keep only the last N turns per pane (0 = all)"},
+			},
+			Examples: []string{
+				"ntm --robot-turns=myproject",
+				"ntm --robot-turns=myproject --panes=2 --turn-kinds=diff",
+				"ntm --robot-turns=myproject --turn-limit=1 --lines=2000",
+			},
+		},
 		{
 			Name:        "is-working",
 			Flag:        "--robot-is-working",
@@ -187,6 +187,7 @@ func getCommandsContent() *DocsContent {
 				Body: `--robot-status: Cheap summary surface (session headers, counts, health)
 --robot-snapshot: Unified state query (sessions + beads + alerts + mail)
 --robot-tail=SESSION: Capture recent pane output
+--robot-turns=SESSION: Segment pane output into prompts, assistant text, tool calls/results, code blocks, diffs and errors (--turn-kinds, --turn-limit)
 --robot-watch-bead=SESSION: Capture bead mentions + current bead status
 --robot-context=SESSION: Get context window usage
 --robot-activity=SESSION: Classify pane work state (idle/busy/error) with optional pane/type filters
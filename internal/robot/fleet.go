@@ -0,0 +1,90 @@
+package robot
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/config"
+	"github.com/Dicklesworthstone/ntm/internal/fleet"
+	"github.com/Dicklesworthstone/ntm/internal/status"
+)
+
+// SnapshotHost is one remote host from [[hosts]] in a snapshot. The local
+// server's sessions stay in the top-level sessions array; each host carries
+// its own, named host:session.
+type SnapshotHost struct {
+	Name      string            `json:"name"`
+	SSH       string            `json:"ssh,omitempty"`
+	Socket    string            `json:"socket,omitempty"`
+	Labels    []string          `json:"labels,omitempty"`
+	Reachable bool              `json:"reachable"`
+	Error     string            `json:"error,omitempty"`
+	Sessions  []SnapshotSession `json:"sessions"`
+}
+
+// snapshotFleetTimeout bounds the whole fleet section, including per-pane
+// captures on every host.
+const snapshotFleetTimeout = 20 * time.Second
+
+// attachSnapshotFleet adds the remote hosts declared in cfg to output. It is
+// a no-op without [[hosts]].
+func attachSnapshotFleet(output *SnapshotOutput, cfg *config.Config) {
+	if cfg == nil || len(cfg.Hosts) == 0 {
+		return
+	}
+	f, err := fleet.FromConfig(cfg)
+	if err != nil {
+		output.Alerts = append(output.Alerts, "fleet: "+err.Error())
+		return
+	}
+	defer f.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), snapshotFleetTimeout)
+	defer cancel()
+	for _, o := range f.Overview(ctx) {
+		if o.Host.IsLocal() {
+			continue
+		}
+		host := SnapshotHost{
+			Name:      o.Host.Name,
+			SSH:       o.Host.SSH,
+			Socket:    o.Host.Socket,
+			Labels:    o.Host.Labels,
+			Reachable: o.Err == nil,
+			Sessions:  []SnapshotSession{},
+		}
+		if o.Err != nil {
+			host.Error = fleet.ErrorSummary(o.Err)
+			output.Alerts = append(output.Alerts, fmt.Sprintf("fleet host %s unreachable: %s", o.Host.Name, host.Error))
+		}
+		for _, s := range o.Sessions {
+			snapSession := SnapshotSession{
+				Name:     s.Ref.String(),
+				Host:     o.Host.Name,
+				Attached: s.Session.Attached,
+				Agents:   []SnapshotAgent{},
+			}
+			for _, pane := range s.Session.Panes {
+				captured, capErr := o.Host.Client.CapturePaneOutputContext(ctx, pane.ID, 50)
+				detection := DetectAgentTypeEnhanced(pane, captured)
+				agent := SnapshotAgent{
+					Pane:             fmt.Sprintf("%d.%d", pane.WindowIndex, pane.Index),
+					Type:             detection.Type,
+					Variant:          pane.Variant,
+					TypeConfidence:   detection.Confidence,
+					TypeMethod:       string(detection.Method),
+					State:            "unknown",
+					LastOutputAgeSec: -1,
+				}
+				if capErr == nil {
+					agent.OutputTailLines = len(splitLines(status.StripANSI(captured)))
+					agent.State = determineState(captured, agent.Type)
+				}
+				snapSession.Agents = append(snapSession.Agents, agent)
+			}
+			host.Sessions = append(host.Sessions, snapSession)
+		}
+		output.Hosts = append(output.Hosts, host)
+	}
+}
@@ -10,11 +10,13 @@ package robot
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/Dicklesworthstone/ntm/internal/config"
+	"github.com/Dicklesworthstone/ntm/internal/layout"
 	"github.com/Dicklesworthstone/ntm/internal/process"
 	"github.com/Dicklesworthstone/ntm/internal/tmux"
 )
@@ -612,6 +614,13 @@ func GetKillPane(ctx context.Context, opts KillPaneOptions) (*KillPaneOutput, er
 		}
 		output.Removed = append(output.Removed, identity)
 	}
+	if len(output.Removed) > 0 {
+		// Best-effort: the kill already succeeded, and a failed re-tile only
+		// leaves the survivors in tmux's own arrangement.
+		if err := layout.Retile(ctx, session); err != nil {
+			slog.Warn("re-tile after pane removal failed", "session", session, "error", err)
+		}
+	}
 	if remaining, err := tmux.GetPanesContext(ctx, session); err == nil {
 		output.RemainingPanes = len(remaining)
 	}
@@ -31,6 +31,7 @@ import (
 	"github.com/Dicklesworthstone/ntm/internal/git"
 	"github.com/Dicklesworthstone/ntm/internal/health"
 	"github.com/Dicklesworthstone/ntm/internal/models"
+	"github.com/Dicklesworthstone/ntm/internal/policy"
 	"github.com/Dicklesworthstone/ntm/internal/pressure"
 	"github.com/Dicklesworthstone/ntm/internal/recipe"
 	"github.com/Dicklesworthstone/ntm/internal/redaction"
@@ -4571,6 +4572,7 @@ type SnapshotOutput struct {
 	ResourcePressure         *pressure.RobotPressure       `json:"resource_pressure,omitempty"` // Host pressure projection for large-swarm operators
 	Disk                     *DiskSection                  `json:"disk,omitempty"`              // Disk usage trajectory for the working dir's filesystem (ntm-1k9g)
 	Swarm                    *SwarmSnapshot                `json:"swarm,omitempty"`             // Active swarm orchestration state (optional)
+	Hosts                    []SnapshotHost                `json:"hosts,omitempty"`             // Remote fleet hosts from [[hosts]] (optional)
 	Alerts                   []string                      `json:"alerts"`                      // Legacy: simple string alerts
 	AlertsDetailed           []AlertInfo                   `json:"alerts_detailed,omitempty"`   // Rich alert objects
 	AlertSummary             *AlertSummaryInfo             `json:"alert_summary,omitempty"`
@@ -4648,6 +4650,7 @@ type SnapshotIncident struct {
 // SnapshotSession represents a session in the snapshot
 type SnapshotSession struct {
 	Name     string          `json:"name"`
+	Host     string          `json:"host,omitempty"` // Fleet host; set on host-qualified (host:session) names
 	Attached bool            `json:"attached"`
 	Agents   []SnapshotAgent `json:"agents"`
 }
@@ -4758,6 +4761,15 @@ func GetSnapshotWithOptions(cfg *config.Config, opts PaginationOptions) (*Snapsh
 	if cfg == nil {
 		cfg = config.Default()
 	}
+	output, err := getLocalSnapshot(cfg, opts)
+	if err == nil && output != nil {
+		attachSnapshotFleet(output, cfg)
+	}
+	return output, err
+}
+
+// getLocalSnapshot builds the snapshot of the local tmux server.
+func getLocalSnapshot(cfg *config.Config, opts PaginationOptions) (*SnapshotOutput, error) {
 	output := newSnapshotOutput(cfg)
 	attachSnapshotResourcePressure(output)
 	// Disk trajectory must attach before either snapshot path (projection or
@@ -6117,6 +6129,7 @@ type SendOutput struct {
 	MemoryInjection *CMInjectionInfo     `json:"memory_injection,omitempty"`
 	AgentHints      *SendAgentHints      `json:"_agent_hints,omitempty"`
 	RenderEvidence  []SendRenderEvidence `json:"render_evidence,omitempty"`
+	Verification    []SendVerification   `json:"verification,omitempty"`
 
 	// Operation is the durable idempotent-operation receipt (#245), present
 	// when the caller supplied an operation ID (--op-id / Idempotency-Key).
@@ -6136,6 +6149,14 @@ type SendRenderEvidence struct {
 	CaptureError         string `json:"capture_error,omitempty"`
 }
 
+// SendVerification is the keystroke-level delivery check for one target:
+// the composer region diffed before and after typing, and the retry
+// strategies used when the prompt was lost, partial, or left unsubmitted.
+type SendVerification struct {
+	Pane string `json:"pane"`
+	dispatchsvc.Verification
+}
+
 // RedactionSummary is a safe-to-print summary of redaction findings.
 // It intentionally does NOT include the matched secret values.
 type RedactionSummary struct {
@@ -6880,12 +6901,19 @@ func (robotDispatchProtocolPlanner) PlanDelivery(_ context.Context, target dispa
 }
 
 func newRobotDispatchService(redactCfg redaction.Config, deliverer dispatchsvc.Deliverer, pacer dispatchsvc.Pacer) (*dispatchsvc.Service, *robotFinalMessageRedactor, error) {
+	return newGuardedRobotDispatchService(redactCfg, deliverer, pacer, nil)
+}
+
+// newGuardedRobotDispatchService is newRobotDispatchService with a final
+// message guard, used for operator-authored sends.
+func newGuardedRobotDispatchService(redactCfg redaction.Config, deliverer dispatchsvc.Deliverer, pacer dispatchsvc.Pacer, guard dispatchsvc.FinalMessageGuard) (*dispatchsvc.Service, *robotFinalMessageRedactor, error) {
 	if deliverer == nil {
 		deliverer = dispatchsvc.TMUXDeliverer{}
 	}
 	redactor := &robotFinalMessageRedactor{config: redactCfg}
 	service, err := dispatchsvc.NewService(dispatchsvc.Ports{
 		Redactor:  redactor,
+		Guard:     guard,
 		Protocols: robotDispatchProtocolPlanner{},
 		Deliverer: deliverer,
 		Pacer:     pacer,
@@ -6893,6 +6921,21 @@ func newRobotDispatchService(redactCfg redaction.Config, deliverer dispatchsvc.D
 	return service, redactor, err
 }
 
+// robotSendPolicyGuard checks sent messages against the command policy in
+// each target's context. Sends proceed unguarded when the policy cannot be
+// loaded, matching the serve endpoints.
+func robotSendPolicyGuard(session string) dispatchsvc.FinalMessageGuard {
+	p, err := policy.LoadForSession(session)
+	if err != nil {
+		return nil
+	}
+	dir := ""
+	if cfg, cfgErr := config.Load(config.DefaultPath()); cfgErr == nil && cfg != nil {
+		dir = cfg.GetProjectDir(session)
+	}
+	return dispatchsvc.PolicyGuard{Policy: p, Base: policy.DirContext(dir)}
+}
+
 func robotDispatchPrepareErrorResponse(err error) RobotResponse {
 	errorCode := ErrCodeInternalError
 	hint := "Inspect the dispatch target and message policy"
@@ -6908,6 +6951,9 @@ func robotDispatchPrepareErrorResponse(err error) RobotResponse {
 	case dispatchsvc.ErrInvalidRequest, dispatchsvc.ErrInvalidSelector, dispatchsvc.ErrNoTargets:
 		errorCode = ErrCodeInvalidFlag
 		hint = "Check pane selectors, agent filters, delay, and message options"
+	case dispatchsvc.ErrPolicyBlocked:
+		errorCode = ErrCodePermissionDenied
+		hint = "Run 'ntm policy explain' with the message and --session/--agent to see which rule blocked it"
 	}
 	return NewErrorResponse(err, errorCode, hint)
 }
@@ -6953,6 +6999,9 @@ func validateRobotPromptDeliveryTargets(allPanes, targetPanes []tmux.Pane, opts
 
 func applyRobotDispatchResult(output *SendOutput, result dispatchsvc.Result) {
 	for _, receipt := range result.Receipts {
+		if receipt.Verification != nil {
+			output.Verification = append(output.Verification, SendVerification{Pane: receipt.Target.Address, Verification: *receipt.Verification})
+		}
 		switch receipt.Status {
 		case dispatchsvc.ReceiptDelivered:
 			output.Successful = append(output.Successful, receipt.Target.Address)
@@ -7290,7 +7339,7 @@ func GetSend(opts SendOptions) (*SendOutput, error) {
 		}()
 	}
 
-	service, finalRedactor, err := newRobotDispatchService(redactCfg, nil, nil)
+	service, finalRedactor, err := newGuardedRobotDispatchService(redactCfg, nil, nil, robotSendPolicyGuard(opts.Session))
 	if err != nil {
 		output.RobotResponse = NewErrorResponse(err, ErrCodeInternalError, "Dispatch service initialization failed")
 		return finalizeTerminalSendActuation(trace, opts, &output), nil
@@ -125,6 +125,7 @@ var SchemaCommand = map[string]interface{}{
 	"kill_pane":            KillPaneOutput{},
 	"dialogs":              DialogsOutput{},
 	"answer_dialog":        AnswerDialogOutput{},
+	"turns":                TurnsOutput{},
 
 	// Ensemble
 	"ensemble":         EnsembleOutput{},
@@ -151,6 +151,7 @@ var SchemaPagination = map[string]SchemaPaginationFlag{
 	"tail":            {Reason: "bounded: per-pane capture capped by the line limit"},
 	"terse":           {Reason: "bounded: extreme-compression summary"},
 	"tokens":          {Reason: "bounded: per-pane token usage rows"},
+	"turns":           {Reason: "bounded: per-pane segmentation of the --lines capture; --turn-limit trims turns"},
 	"tools":           {Reason: "bounded: fixed tool catalog"},
 	"triage":          {Reason: "bounded: top-K triage recommendations"},
 	"wait":            {Reason: "bounded: wake reasons for one wait"},
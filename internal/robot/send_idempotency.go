@@ -35,6 +35,7 @@ import (
 	"strings"
 	"time"
 
+	dispatchsvc "github.com/Dicklesworthstone/ntm/internal/dispatch"
 	"github.com/Dicklesworthstone/ntm/internal/state"
 )
 
@@ -77,6 +78,9 @@ type SendAdmission struct {
 	Target string `json:"target"`
 	State  string `json:"state"` // not_attempted | submitted | rejected | unknown
 	Error  string `json:"error,omitempty"`
+	// Verification is the keystroke-level delivery check for the target,
+	// present when the pane was typed into.
+	Verification *dispatchsvc.Verification `json:"verification,omitempty"`
 }
 
 // SendOperationInfo is the public view of a durable send operation attached
@@ -199,18 +203,22 @@ func admissionsFromSendOutput(output *SendOutput) []SendAdmission {
 	for _, failure := range output.Failed {
 		failures[failure.Pane] = failure.Error
 	}
+	verifications := make(map[string]*dispatchsvc.Verification, len(output.Verification))
+	for i := range output.Verification {
+		verifications[output.Verification[i].Pane] = &output.Verification[i].Verification
+	}
 
 	admissions := make([]SendAdmission, 0, len(output.Targets))
 	for _, target := range output.Targets {
 		if successful[target] {
-			admissions = append(admissions, SendAdmission{Target: target, State: AdmissionSubmitted})
+			admissions = append(admissions, SendAdmission{Target: target, State: AdmissionSubmitted, Verification: verifications[target]})
 			continue
 		}
 		// Presence check, not message check: a failure recorded with an
 		// empty error string is still a rejection, not "never attempted".
 		if msg, failed := failures[target]; failed {
 			admissions = append(admissions, SendAdmission{
-				Target: target, State: AdmissionRejected, Error: msg,
+				Target: target, State: AdmissionRejected, Error: msg, Verification: verifications[target],
 			})
 			continue
 		}
@@ -265,6 +273,11 @@ func applyReplayedOutcome(output *SendOutput, op *state.SendOperation) error {
 	output.Targets = outcome.Targets
 	output.Successful = outcome.Successful
 	output.Failed = outcome.Failed
+	for _, admission := range outcome.Admissions {
+		if admission.Verification != nil {
+			output.Verification = append(output.Verification, SendVerification{Pane: admission.Target, Verification: *admission.Verification})
+		}
+	}
 	if outcome.MessagePreview != "" {
 		output.MessagePreview = outcome.MessagePreview
 	}
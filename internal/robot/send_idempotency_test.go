@@ -5,7 +5,9 @@ import (
 	"testing"
 	"time"
 
+	dispatchsvc "github.com/Dicklesworthstone/ntm/internal/dispatch"
 	"github.com/Dicklesworthstone/ntm/internal/state"
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
 )
 
 func TestSendOperationBindingHashCanonicalizesSelectors(t *testing.T) {
@@ -112,6 +114,40 @@ func TestAdmissionsFromSendOutput(t *testing.T) {
 	}
 }
 
+func TestAdmissionsCarryDeliveryVerification(t *testing.T) {
+	recovered := dispatchsvc.Verification{Status: dispatchsvc.VerificationRecovered, Outcome: tmux.DeliverySubmitted, Retries: 2}
+	failed := dispatchsvc.Verification{Status: dispatchsvc.VerificationFailed, Outcome: tmux.DeliveryPartial, Retries: 3, PartialPaste: true}
+	output := &SendOutput{
+		Targets:    []string{"cc_1", "cc_2"},
+		Successful: []string{"cc_1"},
+		Failed:     []SendError{{Pane: "cc_2", Error: "DELIVERY_UNVERIFIED"}},
+		Verification: []SendVerification{
+			{Pane: "cc_1", Verification: recovered},
+			{Pane: "cc_2", Verification: failed},
+		},
+	}
+	admissions := admissionsFromSendOutput(output)
+	if v := admissions[0].Verification; v == nil || v.Status != dispatchsvc.VerificationRecovered || v.Retries != 2 {
+		t.Errorf("cc_1 verification = %+v", v)
+	}
+	if v := admissions[1].Verification; v == nil || !v.PartialPaste {
+		t.Errorf("cc_2 verification = %+v", v)
+	}
+
+	// The stored outcome round-trips verification through the admissions.
+	data, err := json.Marshal(sendOperationOutcome{Targets: output.Targets, Admissions: admissions})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var replayed SendOutput
+	if err := applyReplayedOutcome(&replayed, &state.SendOperation{OperationID: "op", OutcomeJSON: string(data)}); err != nil {
+		t.Fatal(err)
+	}
+	if len(replayed.Verification) != 2 || replayed.Verification[1].Pane != "cc_2" || replayed.Verification[1].Outcome != tmux.DeliveryPartial {
+		t.Errorf("replayed verification = %+v", replayed.Verification)
+	}
+}
+
 func TestApplyReplayedOutcomeRestoresOriginalResult(t *testing.T) {
 	sentAt := time.Now().UTC().Truncate(time.Second)
 	outcome := sendOperationOutcome{
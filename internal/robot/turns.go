@@ -0,0 +1,96 @@
+// Package robot provides machine-readable output for AI agents.
+// turns.go implements --robot-turns: pane scrollback segmented into
+// prompts, assistant text, tool calls and results, code blocks, diffs and
+// errors, so consumers can ask for "the last diff pane 2 produced" without
+// re-parsing capture-pane text.
+package robot
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+	"github.com/Dicklesworthstone/ntm/internal/turns"
+)
+
+// TurnsOptions configures --robot-turns.
+type TurnsOptions struct {
+	Session string
+	Panes   []string
+	Lines   int          // scrollback lines to segment per pane (default 500)
+	Kinds   []turns.Kind // keep only these segment kinds (empty = all)
+	Limit   int          // keep the last N turns per pane (0 = all)
+}
+
+// PaneTurns is the segmented output of one pane.
+type PaneTurns struct {
+	Pane      string                   `json:"pane"`
+	Target    string                   `json:"target"`
+	AgentType string                   `json:"agent_type"`
+	Parser    string                   `json:"parser"`
+	Turns     []turns.Turn             `json:"turns"`
+	Last      map[string]turns.Segment `json:"last"`
+	Error     string                   `json:"error,omitempty"`
+}
+
+// TurnsOutput is the structured output for --robot-turns.
+type TurnsOutput struct {
+	RobotResponse
+	Session string      `json:"session"`
+	Lines   int         `json:"lines"`
+	Panes   []PaneTurns `json:"panes"`
+}
+
+// GetTurns captures and segments every selected pane.
+func GetTurns(ctx context.Context, opts TurnsOptions) (*TurnsOutput, error) {
+	if opts.Lines <= 0 {
+		opts.Lines = 500
+	}
+	output := &TurnsOutput{
+		RobotResponse: NewRobotResponse(true),
+		Session:       opts.Session,
+		Lines:         opts.Lines,
+		Panes:         []PaneTurns{},
+	}
+	targets, multiWindow, failure := resolveLifecycleTargets(ctx, LifecycleOptions{Session: opts.Session, Panes: opts.Panes}, "turns")
+	if failure != nil {
+		output.RobotResponse = *failure
+		return output, nil
+	}
+	for _, pane := range targets {
+		agentType := restartPaneAgentType(pane)
+		result := PaneTurns{
+			Pane:      paneTargetKey(pane, multiWindow),
+			Target:    pane.ID,
+			AgentType: agentType,
+			Turns:     []turns.Turn{},
+			Last:      map[string]turns.Segment{},
+		}
+		capture, err := tmux.CapturePaneOutputContext(ctx, pane.ID, opts.Lines)
+		if err != nil {
+			result.Error = fmt.Sprintf("capture failed: %v", err)
+			output.Panes = append(output.Panes, result)
+			continue
+		}
+		result.Parser = turns.Dialect(agentType, capture)
+		parsed := turns.Filter(turns.Parse(agentType, capture), opts.Kinds...)
+		// last covers the whole capture even when --turn-limit trims turns.
+		for kind, seg := range turns.LastByKind(parsed) {
+			result.Last[string(kind)] = seg
+		}
+		if tail := turns.Tail(parsed, opts.Limit); tail != nil {
+			result.Turns = tail
+		}
+		output.Panes = append(output.Panes, result)
+	}
+	return output, nil
+}
+
+// PrintTurns prints the per-pane turn segmentation.
+func PrintTurns(ctx context.Context, opts TurnsOptions) error {
+	output, err := GetTurns(ctx, opts)
+	if err != nil {
+		return err
+	}
+	return encodeTerminalRobotOutput(output, output.RobotResponse, "robot turns failed")
+}
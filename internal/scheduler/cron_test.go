@@ -0,0 +1,74 @@
+package scheduler
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	base := time.Date(2026, 3, 14, 10, 17, 42, 0, time.UTC) // Saturday
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, 3, 14, 10, 18, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 3, 14, 10, 30, 0, 0, time.UTC)},
+		{"0 2 * * *", time.Date(2026, 3, 15, 2, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, 3, 14, 11, 0, 0, 0, time.UTC)},
+		{"30 9 * * mon-fri", time.Date(2026, 3, 16, 9, 30, 0, 0, time.UTC)},
+		{"0 0 1 jan *", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 12 * * 7", time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)},
+		{"5,10 10 * * *", time.Date(2026, 3, 15, 10, 5, 0, 0, time.UTC)},
+		// Both day fields restricted: either may match (the 20th or a Monday).
+		{"0 0 20 * 1", time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		spec, err := ParseCron(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseCron(%q): %v", tt.expr, err)
+		}
+		if got := spec.Next(base); !got.Equal(tt.want) {
+			t.Errorf("Next(%q) = %s, want %s", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestCronNextHonoursLocation(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("tzdata unavailable: %v", err)
+	}
+	spec, _ := ParseCron("0 2 * * *")
+	got := spec.Next(time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC).In(ny))
+	if got.Hour() != 2 || got.Location() != ny || got.UTC().Hour() != 6 {
+		t.Fatalf("Next in New York = %s (UTC %s)", got, got.UTC())
+	}
+}
+
+func TestParseCronRejectsInvalid(t *testing.T) {
+	for expr, want := range map[string]string{
+		"* * * *":      "expected 5 fields",
+		"60 * * * *":   "out of range",
+		"* * * * 8":    "out of range",
+		"*/0 * * * *":  "invalid step",
+		"5-1 * * * *":  "backwards",
+		"* * * foo *":  "invalid value",
+		"1,,2 * * * *": "empty list element",
+		"@fortnightly": "expected 5 fields",
+	} {
+		_, err := ParseCron(expr)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseCron(%q) = %v, want error containing %q", expr, err, want)
+		}
+	}
+
+	spec, err := ParseCron("0 0 30 2 *")
+	if err != nil {
+		t.Fatalf("ParseCron(Feb 30): %v", err)
+	}
+	if got := spec.Next(time.Now()); !got.IsZero() {
+		t.Fatalf("Feb 30 should never fire, got %s", got)
+	}
+}
@@ -0,0 +1,59 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/Dicklesworthstone/ntm/internal/pipeline"
+	"github.com/Dicklesworthstone/ntm/internal/state"
+)
+
+// PipelineRunner is the in-process Runner used by the standalone scheduler
+// daemon. Runs are registered with the pipeline registry, so they show up in
+// `ntm pipeline status` and can be cancelled like any background run.
+type PipelineRunner struct {
+	// ProjectDir is used for schedules that do not record their own.
+	ProjectDir string
+}
+
+// Start loads and validates the schedule's workflow and starts it in the
+// background.
+func (r PipelineRunner) Start(_ context.Context, sched state.PipelineSchedule, vars map[string]interface{}) (string, error) {
+	projectDir := sched.ProjectDir
+	if projectDir == "" {
+		projectDir = r.ProjectDir
+	}
+	path := sched.WorkflowFile
+	if !filepath.IsAbs(path) && projectDir != "" {
+		path = filepath.Join(projectDir, path)
+	}
+
+	workflow, result, err := pipeline.LoadAndValidate(path)
+	if err != nil {
+		return "", fmt.Errorf("load workflow: %w", err)
+	}
+	if !result.Valid {
+		msg := "workflow validation failed"
+		if len(result.Errors) > 0 {
+			msg = result.Errors[0].Message
+		}
+		return "", fmt.Errorf("invalid workflow %s: %s", sched.WorkflowFile, msg)
+	}
+	validated, varErr := pipeline.ValidateWorkflowVariables(workflow, vars)
+	if varErr != nil {
+		return "", fmt.Errorf("variables: %s", varErr.Message)
+	}
+
+	cfg := pipeline.DefaultExecutorConfig(sched.Session)
+	cfg.ProjectDir = projectDir
+	cfg.WorkflowFile = path
+	exec := pipeline.StartBackgroundPipeline(workflow, validated.Variables, cfg)
+	return exec.RunID, nil
+}
+
+// Active reports whether the run is still executing in this process.
+func (PipelineRunner) Active(runID string) bool { return pipeline.IsPipelineActive(runID) }
+
+// Cancel cancels a run started by this process.
+func (PipelineRunner) Cancel(runID string) bool { return pipeline.CancelPipeline(runID) }
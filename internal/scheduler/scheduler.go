@@ -0,0 +1,535 @@
+// Package scheduler starts pipeline workflows on cron expressions, fixed
+// intervals, or event-bus events. Schedules live in the state DB
+// (pipeline_schedules); the Scheduler re-reads them every tick, so changes
+// made by `ntm pipeline schedule` or the REST API apply without a restart.
+//
+// The Scheduler is hosted by `ntm serve` (which also feeds it events) or by
+// the standalone `ntm pipeline schedule daemon`.
+package scheduler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/events"
+	"github.com/Dicklesworthstone/ntm/internal/state"
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+	"github.com/Dicklesworthstone/ntm/internal/util"
+)
+
+// Trigger kinds.
+const (
+	TriggerCron     = "cron"
+	TriggerInterval = "interval"
+	TriggerEvent    = "event"
+)
+
+// Overlap policies decide what happens when a schedule fires while the run it
+// started last time is still active.
+const (
+	// OverlapSkip drops the new firing.
+	OverlapSkip = "skip"
+	// OverlapQueue defers the firing until the active run ends.
+	OverlapQueue = "queue"
+	// OverlapCancelPrevious cancels the active run and starts a new one.
+	OverlapCancelPrevious = "cancel_previous"
+)
+
+const (
+	// DefaultTick is how often the scheduler re-reads schedules. Cron has
+	// minute resolution, so firings land within one tick of their slot.
+	DefaultTick = 15 * time.Second
+
+	// MinInterval is the shortest accepted interval trigger.
+	MinInterval = time.Minute
+
+	// maxQueued caps deferred firings per schedule under OverlapQueue so a
+	// run that hangs cannot accumulate an unbounded backlog.
+	maxQueued = 16
+)
+
+// Runner starts and tracks the pipeline runs a schedule launches.
+type Runner interface {
+	// Start launches sched's workflow in the background with vars and
+	// returns the run ID.
+	Start(ctx context.Context, sched state.PipelineSchedule, vars map[string]interface{}) (string, error)
+	// Active reports whether runID is still executing.
+	Active(runID string) bool
+	// Cancel stops runID, reporting whether a live run was found.
+	Cancel(runID string) bool
+}
+
+// Config configures a Scheduler.
+type Config struct {
+	Store  *state.Store
+	Runner Runner
+	// Tick overrides DefaultTick.
+	Tick time.Duration
+	// Now overrides time.Now (tests).
+	Now func() time.Time
+	// Jitter overrides the random jitter source; it returns a duration in
+	// [0, max].
+	Jitter func(max time.Duration) time.Duration
+}
+
+// Scheduler evaluates persisted schedules and launches their runs.
+type Scheduler struct {
+	store  *state.Store
+	runner Runner
+	tick   time.Duration
+	now    func() time.Time
+	jitter func(time.Duration) time.Duration
+
+	// mu serializes evaluation so a tick and an event never fire the same
+	// schedule concurrently.
+	mu sync.Mutex
+
+	ctxMu  sync.Mutex
+	ctx    context.Context
+	timers map[*time.Timer]struct{}
+
+	// eventTypes caches the event types some schedule listens for, refreshed
+	// every tick, so HandleEvent can ignore unrelated bus traffic without a
+	// store query. Nil until the first tick.
+	eventTypes atomic.Pointer[map[string]struct{}]
+}
+
+// New returns a Scheduler. Call Run to start it.
+func New(cfg Config) *Scheduler {
+	s := &Scheduler{
+		store:  cfg.Store,
+		runner: cfg.Runner,
+		tick:   cfg.Tick,
+		now:    cfg.Now,
+		jitter: cfg.Jitter,
+		ctx:    context.Background(),
+		timers: make(map[*time.Timer]struct{}),
+	}
+	if s.tick <= 0 {
+		s.tick = DefaultTick
+	}
+	if s.now == nil {
+		s.now = time.Now
+	}
+	if s.jitter == nil {
+		s.jitter = randomJitter
+	}
+	return s
+}
+
+// Run evaluates schedules every tick until ctx is cancelled. Pending jittered
+// event firings are dropped on shutdown.
+func (s *Scheduler) Run(ctx context.Context) {
+	s.ctxMu.Lock()
+	s.ctx = ctx
+	s.ctxMu.Unlock()
+	defer s.stopTimers()
+
+	ticker := time.NewTicker(s.tick)
+	defer ticker.Stop()
+	for {
+		if err := s.Tick(ctx); err != nil {
+			slog.Warn("pipeline scheduler tick failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick evaluates every time-based schedule once: schedules without a next
+// run get one, due schedules fire, and queued firings whose previous run has
+// finished are released.
+func (s *Scheduler) Tick(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	schedules, err := s.store.ListPipelineSchedules()
+	if err != nil {
+		return err
+	}
+	now := s.now()
+	eventTypes := make(map[string]struct{})
+	for i := range schedules {
+		sched := schedules[i]
+		if sched.Paused {
+			continue
+		}
+		if sched.TriggerKind == TriggerEvent {
+			eventTypes[sched.EventType] = struct{}{}
+		}
+		rt := sched.Runtime()
+		changed := false
+
+		if rt.Queued > 0 && !s.runActive(rt.LastRunID) {
+			rt.Queued--
+			s.launch(ctx, &sched, &rt, now, map[string]interface{}{"trigger": "queued"})
+			changed = true
+		}
+
+		if sched.TriggerKind != TriggerEvent {
+			switch {
+			case rt.NextRunAt == nil:
+				next, err := s.nextRun(&sched, now)
+				if err != nil {
+					rt.LastError = err.Error()
+				}
+				rt.NextRunAt = next
+				changed = true
+			case !now.Before(*rt.NextRunAt):
+				s.fire(ctx, &sched, &rt, now, map[string]interface{}{"trigger": sched.TriggerKind})
+				next, err := s.nextRun(&sched, now)
+				if err != nil {
+					rt.LastError = err.Error()
+				}
+				rt.NextRunAt = next
+				changed = true
+			}
+		}
+
+		if changed {
+			if err := s.store.UpdatePipelineScheduleRuntime(sched.ID, rt, now); err != nil {
+				slog.Warn("pipeline scheduler: record runtime failed", "schedule", sched.Name, "error", err)
+			}
+		}
+	}
+	s.eventTypes.Store(&eventTypes)
+	return nil
+}
+
+// HandleEvent fires every active event schedule that matches ev. It is safe
+// to subscribe directly to an events.EventBus. Schedules created since the
+// last tick start matching from the next tick.
+func (s *Scheduler) HandleEvent(ev events.BusEvent) {
+	if ev == nil {
+		return
+	}
+	if types := s.eventTypes.Load(); types != nil {
+		if _, ok := (*types)[ev.EventType()]; !ok {
+			return
+		}
+	}
+	schedules, err := s.store.ListPipelineSchedules()
+	if err != nil {
+		slog.Warn("pipeline scheduler: list schedules for event failed", "event", ev.EventType(), "error", err)
+		return
+	}
+	info := map[string]interface{}{
+		"trigger": TriggerEvent,
+		"event": map[string]interface{}{
+			"type":      ev.EventType(),
+			"session":   ev.EventSession(),
+			"timestamp": ev.EventTimestamp().UTC().Format(time.RFC3339Nano),
+		},
+	}
+	for i := range schedules {
+		sched := schedules[i]
+		if sched.Paused || sched.TriggerKind != TriggerEvent || sched.EventType != ev.EventType() {
+			continue
+		}
+		if sched.EventSession != "" && sched.EventSession != ev.EventSession() {
+			continue
+		}
+		delay := time.Duration(0)
+		if sched.JitterSeconds > 0 {
+			delay = s.jitter(time.Duration(sched.JitterSeconds) * time.Second)
+		}
+		if delay <= 0 {
+			s.fireByID(sched.ID, info)
+			continue
+		}
+		s.after(delay, sched.ID, info)
+	}
+}
+
+// fireByID re-reads a schedule and fires it, so a schedule paused or removed
+// while a jittered firing was pending does not run.
+func (s *Scheduler) fireByID(id string, info map[string]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sched, err := s.store.GetPipelineSchedule(id)
+	if err != nil || sched == nil || sched.Paused {
+		return
+	}
+	now := s.now()
+	rt := sched.Runtime()
+	s.fire(s.baseContext(), sched, &rt, now, info)
+	if err := s.store.UpdatePipelineScheduleRuntime(sched.ID, rt, now); err != nil {
+		slog.Warn("pipeline scheduler: record runtime failed", "schedule", sched.Name, "error", err)
+	}
+}
+
+// fire applies the overlap policy and launches a run when it allows one.
+func (s *Scheduler) fire(ctx context.Context, sched *state.PipelineSchedule, rt *state.PipelineScheduleRuntime, now time.Time, info map[string]interface{}) {
+	if s.runActive(rt.LastRunID) {
+		switch sched.OverlapPolicy {
+		case OverlapQueue:
+			if rt.Queued < maxQueued {
+				rt.Queued++
+			}
+			slog.Info("pipeline schedule queued behind active run", "schedule", sched.Name, "active_run", rt.LastRunID, "queued", rt.Queued)
+			return
+		case OverlapCancelPrevious:
+			s.runner.Cancel(rt.LastRunID)
+			slog.Info("pipeline schedule cancelled previous run", "schedule", sched.Name, "cancelled_run", rt.LastRunID)
+		default:
+			rt.LastError = fmt.Sprintf("skipped at %s: run %s still active", now.UTC().Format(time.RFC3339), rt.LastRunID)
+			slog.Info("pipeline schedule skipped: previous run still active", "schedule", sched.Name, "active_run", rt.LastRunID)
+			return
+		}
+	}
+	s.launch(ctx, sched, rt, now, info)
+}
+
+func (s *Scheduler) launch(ctx context.Context, sched *state.PipelineSchedule, rt *state.PipelineScheduleRuntime, now time.Time, info map[string]interface{}) {
+	vars := make(map[string]interface{}, len(sched.Variables)+2)
+	for k, v := range sched.Variables {
+		vars[k] = v
+	}
+	if _, ok := vars["schedule"]; !ok {
+		meta := map[string]interface{}{
+			"id":       sched.ID,
+			"name":     sched.Name,
+			"trigger":  info["trigger"],
+			"fired_at": now.UTC().Format(time.RFC3339),
+		}
+		vars["schedule"] = meta
+	}
+	if ev, ok := info["event"]; ok {
+		if _, taken := vars["event"]; !taken {
+			vars["event"] = ev
+		}
+	}
+
+	fired := now
+	rt.LastFiredAt = &fired
+	runID, err := s.runner.Start(ctx, *sched, vars)
+	if err != nil {
+		rt.LastError = err.Error()
+		slog.Warn("pipeline schedule failed to start run", "schedule", sched.Name, "error", err)
+		return
+	}
+	rt.LastRunID = runID
+	rt.LastError = ""
+	slog.Info("pipeline schedule fired", "schedule", sched.Name, "trigger", info["trigger"], "run_id", runID)
+}
+
+func (s *Scheduler) runActive(runID string) bool {
+	return runID != "" && s.runner.Active(runID)
+}
+
+// nextRun computes the next firing after now, including jitter.
+func (s *Scheduler) nextRun(sched *state.PipelineSchedule, now time.Time) (*time.Time, error) {
+	next, err := NextFire(sched, now)
+	if err != nil || next.IsZero() {
+		return nil, err
+	}
+	if sched.JitterSeconds > 0 {
+		next = next.Add(s.jitter(time.Duration(sched.JitterSeconds) * time.Second))
+	}
+	return &next, nil
+}
+
+func (s *Scheduler) after(delay time.Duration, id string, info map[string]interface{}) {
+	s.ctxMu.Lock()
+	defer s.ctxMu.Unlock()
+	var timer *time.Timer
+	timer = time.AfterFunc(delay, func() {
+		s.ctxMu.Lock()
+		delete(s.timers, timer)
+		s.ctxMu.Unlock()
+		s.fireByID(id, info)
+	})
+	s.timers[timer] = struct{}{}
+}
+
+func (s *Scheduler) stopTimers() {
+	s.ctxMu.Lock()
+	defer s.ctxMu.Unlock()
+	for timer := range s.timers {
+		timer.Stop()
+		delete(s.timers, timer)
+	}
+}
+
+func (s *Scheduler) baseContext() context.Context {
+	s.ctxMu.Lock()
+	defer s.ctxMu.Unlock()
+	return s.ctx
+}
+
+// NextFire returns the first firing of a cron or interval schedule strictly
+// after t, without jitter. Event schedules have no next firing.
+func NextFire(sched *state.PipelineSchedule, t time.Time) (time.Time, error) {
+	switch sched.TriggerKind {
+	case TriggerCron:
+		spec, err := ParseCron(sched.CronExpr)
+		if err != nil {
+			return time.Time{}, err
+		}
+		loc, err := scheduleLocation(sched.Timezone)
+		if err != nil {
+			return time.Time{}, err
+		}
+		next := spec.Next(t.In(loc))
+		if next.IsZero() {
+			return time.Time{}, fmt.Errorf("cron expression %q never fires", sched.CronExpr)
+		}
+		return next, nil
+	case TriggerInterval:
+		if sched.IntervalSeconds <= 0 {
+			return time.Time{}, fmt.Errorf("interval must be positive")
+		}
+		return t.Add(time.Duration(sched.IntervalSeconds) * time.Second), nil
+	default:
+		return time.Time{}, nil
+	}
+}
+
+// Validate checks a schedule definition and fills defaults. It does not touch
+// the store.
+func Validate(sched *state.PipelineSchedule) error {
+	sched.Name = strings.TrimSpace(sched.Name)
+	if sched.Name == "" {
+		return fmt.Errorf("schedule name is required")
+	}
+	if strings.TrimSpace(sched.WorkflowFile) == "" {
+		return fmt.Errorf("workflow file is required")
+	}
+	if sched.Session == "" {
+		return fmt.Errorf("session is required")
+	}
+	if err := tmux.ValidateSessionName(sched.Session); err != nil {
+		return fmt.Errorf("invalid session name: %w", err)
+	}
+
+	switch sched.OverlapPolicy {
+	case "":
+		sched.OverlapPolicy = OverlapSkip
+	case OverlapSkip, OverlapQueue, OverlapCancelPrevious:
+	default:
+		return fmt.Errorf("unknown overlap policy %q (want %s, %s or %s)", sched.OverlapPolicy, OverlapSkip, OverlapQueue, OverlapCancelPrevious)
+	}
+	if sched.JitterSeconds < 0 {
+		return fmt.Errorf("jitter must not be negative")
+	}
+
+	set := 0
+	if sched.CronExpr != "" {
+		set++
+		sched.TriggerKind = TriggerCron
+	}
+	if sched.IntervalSeconds != 0 {
+		set++
+		sched.TriggerKind = TriggerInterval
+	}
+	if sched.EventType != "" {
+		set++
+		sched.TriggerKind = TriggerEvent
+	}
+	if set != 1 {
+		return fmt.Errorf("schedule needs exactly one trigger: cron, interval or event")
+	}
+
+	switch sched.TriggerKind {
+	case TriggerCron:
+		if _, err := ParseCron(sched.CronExpr); err != nil {
+			return err
+		}
+		if _, err := scheduleLocation(sched.Timezone); err != nil {
+			return err
+		}
+	case TriggerInterval:
+		if time.Duration(sched.IntervalSeconds)*time.Second < MinInterval {
+			return fmt.Errorf("interval must be at least %s", MinInterval)
+		}
+	case TriggerEvent:
+		if sched.Timezone != "" {
+			return fmt.Errorf("timezone only applies to cron schedules")
+		}
+	}
+	if sched.EventSession != "" && sched.TriggerKind != TriggerEvent {
+		return fmt.Errorf("event session filter only applies to event schedules")
+	}
+	return nil
+}
+
+// Create validates sched, assigns it an ID and stores it. Runtime fields are
+// reset; Paused is kept so a schedule can be created disabled.
+func Create(store *state.Store, sched *state.PipelineSchedule) error {
+	if store == nil {
+		return fmt.Errorf("pipeline schedules require the state store")
+	}
+	if err := Validate(sched); err != nil {
+		return err
+	}
+	if existing, err := store.GetPipelineSchedule(sched.Name); err != nil {
+		return err
+	} else if existing != nil {
+		return fmt.Errorf("schedule %q already exists", sched.Name)
+	}
+	idBytes := make([]byte, 6)
+	if _, err := rand.Read(idBytes); err != nil {
+		return fmt.Errorf("generate schedule id: %w", err)
+	}
+	sched.ID = "sched_" + hex.EncodeToString(idBytes)
+	sched.NextRunAt = nil
+	sched.LastFiredAt = nil
+	sched.LastRunID = ""
+	sched.LastError = ""
+	sched.Queued = 0
+	sched.CreatedAt = time.Now().UTC()
+	return store.CreatePipelineSchedule(sched)
+}
+
+// DurationSeconds parses an interval or jitter flag such as "30m", "6h" or
+// "1d" into whole seconds. Empty input is zero. A positive duration under one
+// second rounds up so validation reports it instead of treating it as unset.
+func DurationSeconds(raw string) (int64, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0, nil
+	}
+	d, err := util.ParseDuration(raw)
+	if err != nil {
+		return 0, err
+	}
+	secs := int64(d / time.Second)
+	if secs == 0 && d > 0 {
+		secs = 1
+	}
+	return secs, nil
+}
+
+func scheduleLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q: %w", name, err)
+	}
+	return loc, nil
+}
+
+func randomJitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(max)+1))
+	if err != nil {
+		return 0
+	}
+	return time.Duration(n.Int64())
+}
@@ -0,0 +1,270 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/events"
+	"github.com/Dicklesworthstone/ntm/internal/state"
+)
+
+type fakeRunner struct {
+	mu        sync.Mutex
+	started   []map[string]interface{}
+	active    map[string]bool
+	cancelled []string
+	fail      error
+}
+
+func (f *fakeRunner) Start(_ context.Context, _ state.PipelineSchedule, vars map[string]interface{}) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.fail != nil {
+		return "", f.fail
+	}
+	f.started = append(f.started, vars)
+	runID := fmt.Sprintf("run-%d", len(f.started))
+	f.active[runID] = true
+	return runID, nil
+}
+
+func (f *fakeRunner) Active(runID string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.active[runID]
+}
+
+func (f *fakeRunner) Cancel(runID string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.cancelled = append(f.cancelled, runID)
+	f.active[runID] = false
+	return true
+}
+
+func (f *fakeRunner) finish(runID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.active[runID] = false
+}
+
+func (f *fakeRunner) starts() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.started)
+}
+
+type schedulerHarness struct {
+	store  *state.Store
+	runner *fakeRunner
+	sched  *Scheduler
+	now    time.Time
+}
+
+func newSchedulerHarness(t *testing.T) *schedulerHarness {
+	t.Helper()
+	store, err := state.Open(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	if err := store.Migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	h := &schedulerHarness{
+		store:  store,
+		runner: &fakeRunner{active: map[string]bool{}},
+		now:    time.Date(2026, 3, 14, 10, 0, 0, 0, time.UTC),
+	}
+	h.sched = New(Config{
+		Store:  store,
+		Runner: h.runner,
+		Now:    func() time.Time { return h.now },
+		Jitter: func(max time.Duration) time.Duration { return max },
+	})
+	return h
+}
+
+func (h *schedulerHarness) add(t *testing.T, sched state.PipelineSchedule) *state.PipelineSchedule {
+	t.Helper()
+	if sched.WorkflowFile == "" {
+		sched.WorkflowFile = "audit.yaml"
+	}
+	if sched.Session == "" {
+		sched.Session = "proj"
+	}
+	if err := Create(h.store, &sched); err != nil {
+		t.Fatalf("Create(%s): %v", sched.Name, err)
+	}
+	return &sched
+}
+
+func (h *schedulerHarness) tick(t *testing.T, advance time.Duration) {
+	t.Helper()
+	h.now = h.now.Add(advance)
+	if err := h.sched.Tick(context.Background()); err != nil {
+		t.Fatalf("Tick: %v", err)
+	}
+}
+
+func (h *schedulerHarness) get(t *testing.T, ref string) *state.PipelineSchedule {
+	t.Helper()
+	sched, err := h.store.GetPipelineSchedule(ref)
+	if err != nil || sched == nil {
+		t.Fatalf("GetPipelineSchedule(%s) = %v, %v", ref, sched, err)
+	}
+	return sched
+}
+
+func TestSchedulerIntervalAndJitter(t *testing.T) {
+	h := newSchedulerHarness(t)
+	h.add(t, state.PipelineSchedule{Name: "triage", IntervalSeconds: 3600, JitterSeconds: 60})
+
+	h.tick(t, 0)
+	got := h.get(t, "triage")
+	if h.runner.starts() != 0 || got.NextRunAt == nil || !got.NextRunAt.Equal(h.now.Add(61*time.Minute)) {
+		t.Fatalf("first tick must only plan the next run (+jitter): next=%v starts=%d", got.NextRunAt, h.runner.starts())
+	}
+
+	h.tick(t, 60*time.Minute)
+	if h.runner.starts() != 0 {
+		t.Fatal("fired before the jittered slot")
+	}
+	h.tick(t, time.Minute)
+	if h.runner.starts() != 1 {
+		t.Fatalf("starts = %d, want 1", h.runner.starts())
+	}
+	got = h.get(t, "triage")
+	if got.LastRunID != "run-1" || got.LastFiredAt == nil || !got.NextRunAt.After(h.now) {
+		t.Fatalf("runtime after fire = %+v", got)
+	}
+	vars := h.runner.started[0]
+	meta, _ := vars["schedule"].(map[string]interface{})
+	if meta["name"] != "triage" || meta["trigger"] != TriggerInterval {
+		t.Fatalf("schedule vars = %#v", vars)
+	}
+}
+
+func TestSchedulerOverlapPolicies(t *testing.T) {
+	h := newSchedulerHarness(t)
+	h.add(t, state.PipelineSchedule{Name: "skip", IntervalSeconds: 60})
+	h.add(t, state.PipelineSchedule{Name: "queue", IntervalSeconds: 60, OverlapPolicy: OverlapQueue})
+	h.add(t, state.PipelineSchedule{Name: "cancel", IntervalSeconds: 60, OverlapPolicy: OverlapCancelPrevious})
+
+	h.tick(t, 0)           // plan
+	h.tick(t, time.Minute) // first firing of all three; runs stay active
+	firstRun := map[string]string{}
+	for _, name := range []string{"skip", "queue", "cancel"} {
+		firstRun[name] = h.get(t, name).LastRunID
+	}
+	h.tick(t, time.Minute) // second firing while the first runs are active
+
+	if got := h.get(t, "skip"); got.LastRunID != firstRun["skip"] || !strings.Contains(got.LastError, "still active") {
+		t.Fatalf("skip = %+v", got)
+	}
+	if got := h.get(t, "queue"); got.LastRunID != firstRun["queue"] || got.Queued != 1 {
+		t.Fatalf("queue = %+v", got)
+	}
+	if got := h.get(t, "cancel"); got.LastRunID == firstRun["cancel"] || len(h.runner.cancelled) != 1 || h.runner.cancelled[0] != firstRun["cancel"] {
+		t.Fatalf("cancel_previous = %+v, cancelled %v", got, h.runner.cancelled)
+	}
+
+	// Once the queued schedule's run ends, the deferred firing is released
+	// on the next tick, before the schedule is due again.
+	h.runner.finish(firstRun["queue"])
+	h.tick(t, time.Second)
+	if got := h.get(t, "queue"); got.Queued != 0 || got.LastRunID == firstRun["queue"] {
+		t.Fatalf("queue after release = %+v", got)
+	}
+}
+
+func TestSchedulerEventsAndPause(t *testing.T) {
+	h := newSchedulerHarness(t)
+	h.add(t, state.PipelineSchedule{Name: "on-error", EventType: "agent_error", EventSession: "proj"})
+	paused := h.add(t, state.PipelineSchedule{Name: "nightly", CronExpr: "0 2 * * *", Paused: true})
+	h.tick(t, 0)
+
+	if got := h.get(t, "nightly"); got.NextRunAt != nil {
+		t.Fatalf("paused schedule was planned: %+v", got)
+	}
+
+	h.sched.HandleEvent(events.NewAgentErrorEvent("other", "cc_1", "crash", "boom"))
+	h.sched.HandleEvent(events.NewContextWarningEvent("proj", "cc_1", 90, 1000))
+	if h.runner.starts() != 0 {
+		t.Fatalf("unmatched events started %d runs", h.runner.starts())
+	}
+	h.sched.HandleEvent(events.NewAgentErrorEvent("proj", "cc_1", "crash", "boom"))
+	if h.runner.starts() != 1 {
+		t.Fatalf("matching event started %d runs, want 1", h.runner.starts())
+	}
+	ev, _ := h.runner.started[0]["event"].(map[string]interface{})
+	if ev["type"] != "agent_error" || ev["session"] != "proj" {
+		t.Fatalf("event vars = %#v", h.runner.started[0])
+	}
+
+	if err := h.store.SetPipelineSchedulePaused(h.get(t, "on-error").ID, true, h.now); err != nil {
+		t.Fatalf("pause: %v", err)
+	}
+	h.sched.HandleEvent(events.NewAgentErrorEvent("proj", "cc_1", "crash", "boom"))
+	if h.runner.starts() != 1 {
+		t.Fatal("paused event schedule still fired")
+	}
+
+	if err := h.store.SetPipelineSchedulePaused(paused.ID, false, h.now); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	h.tick(t, 0)
+	if got := h.get(t, "nightly"); got.NextRunAt == nil || !got.NextRunAt.Equal(time.Date(2026, 3, 15, 2, 0, 0, 0, time.Local).In(got.NextRunAt.Location())) {
+		t.Fatalf("resumed schedule next run = %v", got.NextRunAt)
+	}
+}
+
+func TestSchedulerRecordsStartFailure(t *testing.T) {
+	h := newSchedulerHarness(t)
+	h.runner.fail = fmt.Errorf("workflow_file must be inside the project directory")
+	h.add(t, state.PipelineSchedule{Name: "broken", IntervalSeconds: 60})
+	h.tick(t, 0)
+	h.tick(t, time.Minute)
+	got := h.get(t, "broken")
+	if got.LastRunID != "" || !strings.Contains(got.LastError, "inside the project directory") || got.LastFiredAt == nil {
+		t.Fatalf("failed start runtime = %+v", got)
+	}
+}
+
+func TestValidateSchedule(t *testing.T) {
+	for name, tc := range map[string]struct {
+		sched state.PipelineSchedule
+		want  string
+	}{
+		"no trigger":   {state.PipelineSchedule{Name: "a"}, "exactly one trigger"},
+		"two triggers": {state.PipelineSchedule{Name: "a", CronExpr: "@daily", EventType: "x"}, "exactly one trigger"},
+		"bad cron":     {state.PipelineSchedule{Name: "a", CronExpr: "61 * * * *"}, "out of range"},
+		"short":        {state.PipelineSchedule{Name: "a", IntervalSeconds: 30}, "at least 1m"},
+		"bad overlap":  {state.PipelineSchedule{Name: "a", IntervalSeconds: 60, OverlapPolicy: "stack"}, "unknown overlap policy"},
+		"bad tz":       {state.PipelineSchedule{Name: "a", CronExpr: "@daily", Timezone: "Mars/Olympus"}, "unknown timezone"},
+		"event filter": {state.PipelineSchedule{Name: "a", IntervalSeconds: 60, EventSession: "proj"}, "only applies to event"},
+		"bad session":  {state.PipelineSchedule{Name: "a", IntervalSeconds: 60, Session: "a:b"}, "invalid session"},
+	} {
+		sched := tc.sched
+		sched.WorkflowFile = "w.yaml"
+		if sched.Session == "" {
+			sched.Session = "proj"
+		}
+		if err := Validate(&sched); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: Validate = %v, want %q", name, err, tc.want)
+		}
+	}
+
+	ok := state.PipelineSchedule{Name: " nightly ", WorkflowFile: "w.yaml", Session: "proj", CronExpr: "@daily"}
+	if err := Validate(&ok); err != nil {
+		t.Fatalf("Validate(valid) = %v", err)
+	}
+	if ok.Name != "nightly" || ok.TriggerKind != TriggerCron || ok.OverlapPolicy != OverlapSkip {
+		t.Fatalf("defaults not applied: %+v", ok)
+	}
+}
@@ -0,0 +1,421 @@
+// Package serve provides scoped API keys for the NTM HTTP server.
+// apikeys.go implements the key registry behind `ntm serve keys` and the
+// api_key authentication path that resolves a presented key to a role and
+// scope.
+package serve
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/Dicklesworthstone/ntm/internal/state"
+)
+
+// apiKeySecretPrefix marks registry-issued secrets so they are recognisable
+// in logs and secret scanners.
+const apiKeySecretPrefix = "ntm_"
+
+// apiKeyDisplayPrefixLen is how much of a secret is kept for display.
+const apiKeyDisplayPrefixLen = 12
+
+// apiKeyTouchInterval throttles last-used writes: a busy dashboard must not
+// turn every request into a state DB write.
+const apiKeyTouchInterval = time.Minute
+
+// Claim keys carrying a registry key's identity and scope from
+// authenticateRequest to rbacMiddleware.
+const (
+	claimKeyID       = "ntm_key_id"
+	claimKeySessions = "ntm_key_sessions"
+	claimKeyProjects = "ntm_key_projects"
+)
+
+// KeyScope restricts what a registry API key may touch. Empty slices leave
+// that dimension unrestricted.
+type KeyScope struct {
+	KeyID    string   `json:"key_id"`
+	Sessions []string `json:"sessions,omitempty"`
+	Projects []string `json:"projects,omitempty"`
+}
+
+// IssueAPIKeyOptions describes a new registry key.
+type IssueAPIKeyOptions struct {
+	Name     string
+	Role     Role
+	Sessions []string
+	Projects []string
+	// TTL is the key's lifetime; zero means the key never expires.
+	TTL time.Duration
+}
+
+// IssuedAPIKey is a freshly created key. Secret is shown exactly once; only
+// its hash is stored.
+type IssuedAPIKey struct {
+	state.ServeAPIKey
+	Secret string `json:"secret"`
+}
+
+// ValidateRole rejects anything other than the three serve roles. ParseRole
+// is deliberately lenient for claims; key management must not silently turn
+// a typo into a viewer key.
+func ValidateRole(role string) (Role, error) {
+	switch r := Role(strings.ToLower(strings.TrimSpace(role))); r {
+	case RoleViewer, RoleOperator, RoleAdmin:
+		return r, nil
+	default:
+		return "", fmt.Errorf("invalid role %q (valid: viewer, operator, admin)", role)
+	}
+}
+
+// HashAPIKey returns the hex SHA-256 under which a secret is stored.
+func HashAPIKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// IssueAPIKey creates and stores a new registry key.
+func IssueAPIKey(store *state.Store, opts IssueAPIKeyOptions) (*IssuedAPIKey, error) {
+	if store == nil {
+		return nil, errors.New("api key registry requires a state store")
+	}
+	issued, err := newAPIKey(opts, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	if err := store.CreateServeAPIKey(&issued.ServeAPIKey); err != nil {
+		return nil, err
+	}
+	return issued, nil
+}
+
+// RotateAPIKey replaces key id with a new secret carrying the same name, role
+// and scope, and revokes the old key in the same transaction. A zero ttl keeps
+// the old key's remaining lifetime policy: never-expiring keys stay that way,
+// expiring ones get their original lifetime again.
+func RotateAPIKey(store *state.Store, id string, ttl time.Duration) (*IssuedAPIKey, error) {
+	if store == nil {
+		return nil, errors.New("api key registry requires a state store")
+	}
+	old, err := store.GetServeAPIKey(id)
+	if err != nil {
+		return nil, err
+	}
+	if old == nil {
+		return nil, fmt.Errorf("api key %q not found", id)
+	}
+	if old.RevokedAt != nil {
+		return nil, fmt.Errorf("api key %q is revoked", id)
+	}
+	if ttl == 0 && old.ExpiresAt != nil {
+		ttl = old.ExpiresAt.Sub(old.CreatedAt)
+	}
+	now := time.Now().UTC()
+	issued, err := newAPIKey(IssueAPIKeyOptions{
+		Name:     old.Name,
+		Role:     Role(old.Role),
+		Sessions: old.Sessions,
+		Projects: old.Projects,
+		TTL:      ttl,
+	}, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := store.RotateServeAPIKey(id, &issued.ServeAPIKey, now); err != nil {
+		return nil, err
+	}
+	return issued, nil
+}
+
+// RevokeAPIKey revokes a registry key immediately.
+func RevokeAPIKey(store *state.Store, id string) error {
+	if store == nil {
+		return errors.New("api key registry requires a state store")
+	}
+	return store.RevokeServeAPIKey(id, time.Now().UTC())
+}
+
+func newAPIKey(opts IssueAPIKeyOptions, now time.Time) (*IssuedAPIKey, error) {
+	role, err := ValidateRole(string(opts.Role))
+	if err != nil {
+		return nil, err
+	}
+	if opts.TTL < 0 {
+		return nil, fmt.Errorf("api key ttl must not be negative")
+	}
+	secretBytes := make([]byte, 32)
+	if _, err := rand.Read(secretBytes); err != nil {
+		return nil, fmt.Errorf("generate api key: %w", err)
+	}
+	idBytes := make([]byte, 6)
+	if _, err := rand.Read(idBytes); err != nil {
+		return nil, fmt.Errorf("generate api key id: %w", err)
+	}
+	secret := apiKeySecretPrefix + hex.EncodeToString(secretBytes)
+
+	key := state.ServeAPIKey{
+		ID:        "key_" + hex.EncodeToString(idBytes),
+		Name:      strings.TrimSpace(opts.Name),
+		KeyHash:   HashAPIKey(secret),
+		KeyPrefix: secret[:apiKeyDisplayPrefixLen],
+		Role:      string(role),
+		Sessions:  trimNonEmpty(opts.Sessions),
+		Projects:  trimNonEmpty(opts.Projects),
+		CreatedAt: now,
+	}
+	if opts.TTL > 0 {
+		expires := now.Add(opts.TTL)
+		key.ExpiresAt = &expires
+	}
+	return &IssuedAPIKey{ServeAPIKey: key, Secret: secret}, nil
+}
+
+func trimNonEmpty(values []string) []string {
+	var out []string
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// authenticateRegistryKey resolves a presented secret against the key
+// registry and returns the claims for rbacMiddleware. Revoked, expired and
+// unknown keys are all reported as authentication failures.
+func (s *Server) authenticateRegistryKey(secret string) (map[string]interface{}, error) {
+	if s.stateStore == nil {
+		return nil, errors.New("invalid api key")
+	}
+	key, err := s.stateStore.GetServeAPIKeyByHash(HashAPIKey(secret))
+	if err != nil {
+		return nil, fmt.Errorf("api key lookup: %w", err)
+	}
+	now := time.Now().UTC()
+	if key == nil {
+		return nil, errors.New("invalid api key")
+	}
+	if key.RevokedAt != nil {
+		return nil, fmt.Errorf("api key %s is revoked", key.ID)
+	}
+	if !key.Active(now) {
+		return nil, fmt.Errorf("api key %s expired", key.ID)
+	}
+	s.touchAPIKey(key.ID, now)
+
+	claims := map[string]interface{}{
+		"role":      key.Role,
+		"sub":       "api-key:" + key.ID,
+		claimKeyID:  key.ID,
+		"key_label": key.Name,
+	}
+	if len(key.Sessions) > 0 {
+		claims[claimKeySessions] = key.Sessions
+	}
+	if len(key.Projects) > 0 {
+		claims[claimKeyProjects] = key.Projects
+	}
+	return claims, nil
+}
+
+// touchAPIKey records last use, at most once per apiKeyTouchInterval per key.
+func (s *Server) touchAPIKey(id string, now time.Time) {
+	if prev, ok := s.apiKeyTouched.Load(id); ok {
+		if now.Sub(prev.(time.Time)) < apiKeyTouchInterval {
+			return
+		}
+	}
+	s.apiKeyTouched.Store(id, now)
+	if err := s.stateStore.TouchServeAPIKey(id, now); err != nil {
+		s.apiKeyTouched.Delete(id)
+	}
+}
+
+// keyScopeFromClaims rebuilds a registry key's scope, or nil for callers that
+// did not authenticate with a registry key.
+func keyScopeFromClaims(claims map[string]interface{}) *KeyScope {
+	id, _ := claims[claimKeyID].(string)
+	if id == "" {
+		return nil
+	}
+	return &KeyScope{
+		KeyID:    id,
+		Sessions: claimStrings(claims[claimKeySessions]),
+		Projects: claimStrings(claims[claimKeyProjects]),
+	}
+}
+
+func claimStrings(v interface{}) []string {
+	switch vals := v.(type) {
+	case []string:
+		return vals
+	case []interface{}:
+		out := make([]string, 0, len(vals))
+		for _, item := range vals {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// allowsSession reports whether the scope admits session.
+func (k *KeyScope) allowsSession(session string) bool {
+	if k == nil || len(k.Sessions) == 0 {
+		return true
+	}
+	for _, allowed := range k.Sessions {
+		if allowed == session {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsProject reports whether the scope admits the server's project
+// directory, matched by full path or by directory name.
+func (k *KeyScope) allowsProject(projectDir string) bool {
+	if k == nil || len(k.Projects) == 0 {
+		return true
+	}
+	projectDir = strings.TrimSpace(projectDir)
+	if projectDir == "" {
+		return false
+	}
+	clean := filepath.Clean(projectDir)
+	for _, allowed := range k.Projects {
+		if allowed == clean || allowed == filepath.Base(clean) {
+			return true
+		}
+	}
+	return false
+}
+
+// sessionAgnosticReads are the routes a session-scoped key may read without
+// naming a session: they expose no per-session data, or (the WebSocket) scope
+// each subscription themselves. Every other read that names no session is a
+// collection spanning all sessions and is refused.
+var sessionAgnosticReads = map[string]bool{
+	"/api/kernel/commands": true,
+	"/api/v1/health":       true,
+	"/api/v1/version":      true,
+	"/api/v1/capabilities": true,
+	"/api/v1/openapi.json": true,
+	"/api/v1/ws":           true,
+}
+
+// sessionQueryRoutes are the routes whose handlers filter by ?session=. On
+// any other route the query parameter is ignored by the handler, so it must
+// not count as naming a session either.
+var sessionQueryRoutes = map[string]bool{
+	"/api/v1/robot/activity":   true,
+	"/api/v1/attention/stream": true,
+	"/api/v1/attention/events": true,
+	"/api/v1/attention/digest": true,
+	"/api/v1/recordings/":      true,
+}
+
+// checkKeyScope enforces a registry key's project and session scope for one
+// request. Requests that name a session — in the path, a ?session= filter
+// the route honours, or a top-level "session" body field — must name an
+// in-scope one. A session-scoped key may not use endpoints that name no
+// session, except the sessionAgnosticReads: a collection read would list
+// other sessions' data, and a write whose target cannot be determined is
+// refused rather than guessed.
+func (s *Server) checkKeyScope(r *http.Request, scope *KeyScope) error {
+	if scope == nil {
+		return nil
+	}
+	if !scope.allowsProject(s.projectDirSnapshot()) {
+		return fmt.Errorf("api key %s is not scoped to this project", scope.KeyID)
+	}
+	if len(scope.Sessions) == 0 {
+		return nil
+	}
+	sessions := requestSessions(r)
+	if len(sessions) == 0 {
+		if (r.Method == http.MethodGet || r.Method == http.MethodHead) && sessionAgnosticReads[routePattern(r)] {
+			return nil
+		}
+		return fmt.Errorf("api key %s is session-scoped and this request names no session", scope.KeyID)
+	}
+	for _, session := range sessions {
+		if !scope.allowsSession(session) {
+			return fmt.Errorf("api key %s is not scoped to session %q", scope.KeyID, session)
+		}
+	}
+	return nil
+}
+
+// routePattern returns the matched chi route pattern, or "" outside a router.
+func routePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		return rctx.RoutePattern()
+	}
+	return ""
+}
+
+// requestSessions collects every session a request addresses.
+func requestSessions(r *http.Request) []string {
+	var sessions []string
+	add := func(v string) {
+		if v = strings.TrimSpace(v); v != "" {
+			sessions = append(sessions, v)
+		}
+	}
+	pattern := routePattern(r)
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		add(rctx.URLParam("sessionId"))
+		add(rctx.URLParam("sessionName"))
+		add(rctx.URLParam("session"))
+		if strings.Contains(pattern, "/sessions/{id}") {
+			add(rctx.URLParam("id"))
+		}
+	}
+	if sessionQueryRoutes[pattern] {
+		add(r.URL.Query().Get("session"))
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		add(requestBodySession(r))
+	}
+	return sessions
+}
+
+// requestBodySession peeks at a JSON body for a top-level "session" field or
+// a "params.session" field (the Jobs API shape), restoring r.Body afterwards.
+func requestBodySession(r *http.Request) string {
+	if r.Body == nil || r.Body == http.NoBody {
+		return ""
+	}
+	data, err := io.ReadAll(io.LimitReader(r.Body, idempotencyFingerprintLimit))
+	r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(data), r.Body))
+	if err != nil || len(data) == 0 {
+		return ""
+	}
+	var body struct {
+		Session string `json:"session"`
+		Params  struct {
+			Session string `json:"session"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return ""
+	}
+	if body.Session != "" {
+		return body.Session
+	}
+	return body.Params.Session
+}
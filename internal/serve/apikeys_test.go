@@ -0,0 +1,191 @@
+package serve
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/events"
+)
+
+func newAPIKeyServer(t *testing.T) (*Server, func(method, path, key, body string) int) {
+	t.Helper()
+	store := openJobStateStore(t, filepath.Join(t.TempDir(), "state.db"))
+	srv := New(Config{
+		EventBus:   events.NewEventBus(16),
+		StateStore: store,
+		Auth:       AuthConfig{Mode: AuthModeAPIKey},
+	})
+	t.Cleanup(srv.Stop)
+	do := func(method, path, key, body string) int {
+		t.Helper()
+		var reader *bytes.Buffer
+		if body != "" {
+			reader = bytes.NewBufferString(body)
+		} else {
+			reader = &bytes.Buffer{}
+		}
+		req := httptest.NewRequest(method, path, reader)
+		req.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		rec := httptest.NewRecorder()
+		srv.Router().ServeHTTP(rec, req)
+		return rec.Code
+	}
+	return srv, do
+}
+
+func TestValidateConfig_APIKeyRegistryNeedsNoSharedKey(t *testing.T) {
+	store := openJobStateStore(t, filepath.Join(t.TempDir(), "state.db"))
+	cfg := Config{Host: "0.0.0.0", Auth: AuthConfig{Mode: AuthModeAPIKey}, StateStore: store}
+	if err := ValidateConfig(cfg); err != nil {
+		t.Fatalf("api_key mode with a key registry must validate, got %v", err)
+	}
+}
+
+func TestRegistryKeyRoleExpiryAndRevocation(t *testing.T) {
+	srv, do := newAPIKeyServer(t)
+
+	viewer, err := IssueAPIKey(srv.stateStore, IssueAPIKeyOptions{Name: "dash", Role: RoleViewer})
+	if err != nil {
+		t.Fatalf("issue viewer: %v", err)
+	}
+	if _, err := IssueAPIKey(srv.stateStore, IssueAPIKeyOptions{Role: "superuser"}); err == nil {
+		t.Fatal("unknown role must be rejected, not downgraded to viewer")
+	}
+
+	if code := do(http.MethodGet, "/api/v1/jobs", "", ""); code != http.StatusUnauthorized {
+		t.Fatalf("no key = %d, want 401", code)
+	}
+	if code := do(http.MethodGet, "/api/v1/jobs", "ntm_bogus", ""); code != http.StatusUnauthorized {
+		t.Fatalf("unknown key = %d, want 401", code)
+	}
+	if code := do(http.MethodGet, "/api/v1/jobs", viewer.Secret, ""); code != http.StatusOK {
+		t.Fatalf("viewer read = %d, want 200", code)
+	}
+	if code := do(http.MethodPost, "/api/v1/jobs", viewer.Secret, `{"type":"swarm_spawn"}`); code != http.StatusForbidden {
+		t.Fatalf("viewer write = %d, want 403", code)
+	}
+
+	stored, _ := srv.stateStore.GetServeAPIKey(viewer.ID)
+	if stored == nil || stored.LastUsedAt == nil {
+		t.Fatalf("last_used_at not recorded: %+v", stored)
+	}
+
+	rotated, err := RotateAPIKey(srv.stateStore, viewer.ID, 0)
+	if err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	if rotated.Role != string(RoleViewer) || rotated.Name != "dash" || rotated.ExpiresAt != nil {
+		t.Fatalf("rotated key lost settings: %+v", rotated.ServeAPIKey)
+	}
+	if code := do(http.MethodGet, "/api/v1/jobs", viewer.Secret, ""); code != http.StatusUnauthorized {
+		t.Fatalf("rotated-away key = %d, want 401", code)
+	}
+	if code := do(http.MethodGet, "/api/v1/jobs", rotated.Secret, ""); code != http.StatusOK {
+		t.Fatalf("replacement key = %d, want 200", code)
+	}
+	if err := RevokeAPIKey(srv.stateStore, rotated.ID); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if code := do(http.MethodGet, "/api/v1/jobs", rotated.Secret, ""); code != http.StatusUnauthorized {
+		t.Fatalf("revoked key = %d, want 401", code)
+	}
+
+	expired, err := IssueAPIKey(srv.stateStore, IssueAPIKeyOptions{Role: RoleAdmin, TTL: time.Millisecond})
+	if err != nil {
+		t.Fatalf("issue expiring: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if code := do(http.MethodGet, "/api/v1/jobs", expired.Secret, ""); code != http.StatusUnauthorized {
+		t.Fatalf("expired key = %d, want 401", code)
+	}
+}
+
+func TestRegistryKeySessionScope(t *testing.T) {
+	srv, do := newAPIKeyServer(t)
+	op, err := IssueAPIKey(srv.stateStore, IssueAPIKeyOptions{Role: RoleOperator, Sessions: []string{"proj"}})
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+
+	if code := do(http.MethodGet, "/api/v1/sessions/other", op.Secret, ""); code != http.StatusForbidden {
+		t.Fatalf("out-of-scope session read = %d, want 403", code)
+	}
+	if code := do(http.MethodGet, "/api/v1/sessions/proj", op.Secret, ""); code == http.StatusForbidden || code == http.StatusUnauthorized {
+		t.Fatalf("in-scope session read = %d, want it past auth", code)
+	}
+	// Collection reads would list every session's data.
+	for _, path := range []string{"/api/v1/sessions", "/api/v1/jobs", "/api/v1/jobs?session=proj", "/api/v1/attention/events"} {
+		if code := do(http.MethodGet, path, op.Secret, ""); code != http.StatusForbidden {
+			t.Fatalf("scoped collection read %s = %d, want 403", path, code)
+		}
+	}
+	if code := do(http.MethodGet, "/api/v1/attention/events?session=other", op.Secret, ""); code != http.StatusForbidden {
+		t.Fatalf("out-of-scope session filter = %d, want 403", code)
+	}
+	for _, path := range []string{"/api/v1/attention/events?session=proj", "/api/v1/version"} {
+		if code := do(http.MethodGet, path, op.Secret, ""); code == http.StatusForbidden || code == http.StatusUnauthorized {
+			t.Fatalf("scoped read %s = %d, want it past auth", path, code)
+		}
+	}
+	if code := do(http.MethodPost, "/api/v1/sessions/other/zoom", op.Secret, `{}`); code != http.StatusForbidden {
+		t.Fatalf("out-of-scope session write = %d, want 403", code)
+	}
+	if code := do(http.MethodPost, "/api/v1/jobs", op.Secret, `{"type":"swarm_spawn","params":{"session":"other"}}`); code != http.StatusForbidden {
+		t.Fatalf("job for out-of-scope session = %d, want 403", code)
+	}
+	if code := do(http.MethodPost, "/api/v1/jobs", op.Secret, `{"type":"swarm_spawn"}`); code != http.StatusForbidden {
+		t.Fatalf("sessionless write by scoped key = %d, want 403", code)
+	}
+	if code := do(http.MethodPost, "/api/v1/jobs", op.Secret, `{"type":"swarm_spawn","params":{"session":"proj"}}`); code != http.StatusAccepted {
+		t.Fatalf("job for in-scope session = %d, want 202", code)
+	}
+
+	projectKey, err := IssueAPIKey(srv.stateStore, IssueAPIKeyOptions{Role: RoleViewer, Projects: []string{"elsewhere"}})
+	if err != nil {
+		t.Fatalf("issue project key: %v", err)
+	}
+	srv.mu.Lock()
+	srv.projectDir = filepath.Join(t.TempDir(), "here")
+	srv.mu.Unlock()
+	if code := do(http.MethodGet, "/api/v1/jobs", projectKey.Secret, ""); code != http.StatusForbidden {
+		t.Fatalf("out-of-scope project = %d, want 403", code)
+	}
+
+	client := &WSClient{authClaims: map[string]interface{}{claimKeyID: op.ID, claimKeySessions: []string{"proj"}}}
+	for topic, want := range map[string]bool{
+		"sessions:proj":    true,
+		"panes:proj:1":     true,
+		"sessions:other":   false,
+		"panes:other:0":    false,
+		"sessions:*":       false,
+		"global":           false,
+		"attention:events": false,
+	} {
+		if got := client.canSubscribe(topic); got != want {
+			t.Errorf("canSubscribe(%q) = %v, want %v", topic, got, want)
+		}
+	}
+}
+
+func TestIssuedAPIKeySecretIsNotStored(t *testing.T) {
+	srv, _ := newAPIKeyServer(t)
+	issued, err := IssueAPIKey(srv.stateStore, IssueAPIKeyOptions{Role: RoleViewer})
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	if !strings.HasPrefix(issued.Secret, apiKeySecretPrefix) || !strings.HasPrefix(issued.Secret, issued.KeyPrefix) {
+		t.Fatalf("secret %q / prefix %q", issued.Secret, issued.KeyPrefix)
+	}
+	stored, _ := srv.stateStore.GetServeAPIKey(issued.ID)
+	if stored.KeyHash != HashAPIKey(issued.Secret) || stored.KeyHash == issued.Secret {
+		t.Fatalf("stored hash %q does not match secret", stored.KeyHash)
+	}
+}
@@ -434,6 +434,7 @@ func (s *Server) handleCloseBead(w http.ResponseWriter, r *http.Request) {
 			"bead": bead,
 		})
 	}
+	s.notifyPipelineScheduler("bead.closed", "")
 
 	writeSuccessResponse(w, http.StatusOK, map[string]interface{}{
 		"bead":   bead,
@@ -465,11 +466,14 @@ func (s *Server) executeBeadCloseJob(jobID, beadID string) {
 	}
 	if alreadyClosed {
 		result["already_closed"] = true
-	} else if s.wsHub != nil {
-		s.wsHub.Publish("beads:*", "bead.closed", map[string]interface{}{
-			"id":   beadID,
-			"bead": bead,
-		})
+	} else {
+		if s.wsHub != nil {
+			s.wsHub.Publish("beads:*", "bead.closed", map[string]interface{}{
+				"id":   beadID,
+				"bead": bead,
+			})
+		}
+		s.notifyPipelineScheduler("bead.closed", "")
 	}
 	s.jobStore.Update(jobID, JobStatusCompleted, 100, result, "")
 	s.publishAsyncBeadCloseAttention(jobID, beadID, alreadyClosed, nil)
@@ -33,7 +33,9 @@ import (
 	"github.com/Dicklesworthstone/ntm/internal/bv"
 	"github.com/Dicklesworthstone/ntm/internal/cass"
 	"github.com/Dicklesworthstone/ntm/internal/checkpoint"
+	"github.com/Dicklesworthstone/ntm/internal/config"
 	"github.com/Dicklesworthstone/ntm/internal/events"
+	"github.com/Dicklesworthstone/ntm/internal/fleet"
 	"github.com/Dicklesworthstone/ntm/internal/kernel"
 	"github.com/Dicklesworthstone/ntm/internal/pipeline"
 	"github.com/Dicklesworthstone/ntm/internal/process"
@@ -556,6 +558,59 @@ func TestHandleSessionsV1_EmptyDB(t *testing.T) {
 	}
 }
 
+// handleSessionsV1 — fleet listing and ?host= filter
+func TestHandleSessionsV1_Fleet(t *testing.T) {
+	srv, _ := setupTestServer(t)
+	hosts, err := fleet.New(tmux.NewClient(""), "", []config.HostConfig{
+		{Name: "alpha", Socket: fmt.Sprintf("ntm-serve-fleet-%d", os.Getpid())},
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.fleet = hosts
+
+	get := func(url string) (int, map[string]interface{}) {
+		rec := httptest.NewRecorder()
+		srv.handleSessionsV1(rec, httptest.NewRequest(http.MethodGet, url, nil))
+		var resp map[string]interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		return rec.Code, resp
+	}
+	hostNames := func(resp map[string]interface{}) []string {
+		fleetResp, ok := resp["fleet"].(map[string]interface{})
+		if !ok {
+			t.Fatalf("fleet missing: %v", resp)
+		}
+		var names []string
+		for _, h := range fleetResp["hosts"].([]interface{}) {
+			names = append(names, h.(map[string]interface{})["name"].(string))
+		}
+		return names
+	}
+
+	code, resp := get("/api/v1/sessions")
+	if code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", code)
+	}
+	if names := strings.Join(hostNames(resp), ","); names != "local,alpha" {
+		t.Errorf("hosts = %s, want local,alpha", names)
+	}
+
+	code, resp = get("/api/v1/sessions?host=alpha")
+	if code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", code)
+	}
+	if names := strings.Join(hostNames(resp), ","); names != "alpha" {
+		t.Errorf("filtered hosts = %s, want alpha", names)
+	}
+
+	if code, _ := get("/api/v1/sessions?host=nope"); code != http.StatusNotFound {
+		t.Errorf("unknown host status = %d, want 404", code)
+	}
+}
+
 // =============================================================================
 // handleCreateSessionV1 — kernel error path (no tmux)
 // =============================================================================
@@ -7501,6 +7556,50 @@ func TestHandleSafetyCheckV1_ApprovalCommand(t *testing.T) {
 	}
 }
 
+// --- Safety check with rules scoped by context ---
+
+func TestHandleSafetyCheckV1_ScopedRuleUsesRequestContext(t *testing.T) {
+	srv, _ := setupTestServer(t)
+
+	tmpHome := t.TempDir()
+	t.Setenv("HOME", tmpHome)
+	policyDir := filepath.Join(tmpHome, ".ntm")
+	os.MkdirAll(policyDir, 0755)
+	os.WriteFile(filepath.Join(policyDir, "policy.yaml"), []byte("version: 1\nblocked:\n  - command: git\n    arg: push\n    when:\n      agents: cod\n      branches: main\n    reason: codex pushes only agent branches\n"), 0644)
+
+	check := func(body string) map[string]interface{} {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/safety/check", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		srv.handleSafetyCheckV1(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+		}
+		var resp map[string]interface{}
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		return resp
+	}
+
+	resp := check(`{"command":"git push","agent_type":"cod","branch":"main"}`)
+	if resp["action"] != "block" || resp["scope"] != "agents=cod branches=main" {
+		t.Errorf("codex on main: action=%v scope=%v, want block", resp["action"], resp["scope"])
+	}
+
+	resp = check(`{"command":"git push","agent_type":"cc","branch":"main","explain":true}`)
+	if resp["action"] != "allow" {
+		t.Errorf("claude on main: action=%v, want allow", resp["action"])
+	}
+	exp, _ := resp["explanation"].(map[string]interface{})
+	rules, _ := exp["rules"].([]interface{})
+	if len(rules) != 1 {
+		t.Fatalf("explanation rules = %v, want 1", exp["rules"])
+	}
+	rule, _ := rules[0].(map[string]interface{})
+	if rule["in_scope"] != false || !strings.Contains(fmt.Sprint(rule["why"]), "agents cc does not match cod") {
+		t.Errorf("explained rule = %v", rule)
+	}
+}
+
 // --- redactingResponseWriter Write branch ---
 
 func TestRedactingResponseWriter_WriteWithoutHeader(t *testing.T) {
@@ -11,6 +11,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/Dicklesworthstone/ntm/internal/checkpoint"
@@ -70,7 +71,7 @@ func (s *Server) dispatchJob(jobID string, req CreateJobRequest) {
 	)
 	switch req.Type {
 	case JobTypePipelineRun:
-		result, err = s.jobPipelineRun(ctx, req.Params)
+		result, err = s.jobPipelineRun(ctx, jobID, req.Params)
 	case JobTypeSwarmSpawn:
 		result, err = s.jobSwarmSpawn(ctx, req.Params)
 	case JobTypeCheckpointRestore:
@@ -102,8 +103,10 @@ func decodeJobParams(params map[string]interface{}, into interface{}) error {
 }
 
 // jobPipelineRun executes a workflow through the same path as
-// POST /api/v1/pipelines/run.
-func (s *Server) jobPipelineRun(ctx context.Context, params map[string]interface{}) (map[string]interface{}, error) {
+// POST /api/v1/pipelines/run. The run ID is assigned up front and recorded on
+// the job before execution starts, so a server restart mid-run can find the
+// run's persisted state and resume it (see reconcileJobs).
+func (s *Server) jobPipelineRun(ctx context.Context, jobID string, params map[string]interface{}) (map[string]interface{}, error) {
 	var req PipelineRunRequest
 	if err := decodeJobParams(params, &req); err != nil {
 		return nil, err
@@ -118,6 +121,11 @@ func (s *Server) jobPipelineRun(ctx context.Context, params map[string]interface
 		return nil, fmt.Errorf("invalid session name: %w", err)
 	}
 
+	runID := pipeline.GenerateRunID()
+	if !req.DryRun {
+		s.jobStore.Update(jobID, JobStatusRunning, 0, map[string]interface{}{"run_id": runID}, "")
+	}
+
 	result := s.runPipelineWithResult(ctx, pipeline.PipelineRunOptions{
 		WorkflowFile: req.WorkflowFile,
 		Session:      req.Session,
@@ -125,6 +133,7 @@ func (s *Server) jobPipelineRun(ctx context.Context, params map[string]interface
 		Variables:    req.Variables,
 		DryRun:       req.DryRun,
 		Background:   req.Background,
+		RunID:        runID,
 	})
 	if !result.Success {
 		return nil, fmt.Errorf("pipeline run failed [%s]: %s", result.ErrorCode, result.Error)
@@ -239,3 +248,107 @@ func (s *Server) jobCheckpointRestore(_ context.Context, params map[string]inter
 		"warnings":         result.Warnings,
 	}, nil
 }
+
+// errJobInterrupted is recorded on jobs that were in flight when the server
+// stopped and could not be resumed on the next start.
+const errJobInterrupted = "server restarted before the job finished"
+
+// restoreJobs attaches the job store to the state store and reconciles the
+// jobs a previous server left pending or running. It runs once from Start.
+func (s *Server) restoreJobs() {
+	if s.stateStore == nil {
+		return
+	}
+	orphans, err := s.jobStore.Attach(s.stateStore)
+	if err != nil {
+		slog.Warn("serve jobs: restore from state store failed", "error", err)
+		return
+	}
+	s.reconcileJobs(orphans)
+}
+
+// reconcileJobs settles jobs that were in flight when the previous server
+// stopped. A pipeline_run whose run state is still on disk is resumed through
+// the same path as POST /api/v1/pipelines/{id}/resume, or takes the recorded
+// terminal outcome when the run finished after its job was last written.
+// Everything else — swarm spawns and checkpoint restores are not safely
+// repeatable — is marked interrupted.
+func (s *Server) reconcileJobs(orphans []*Job) {
+	for _, job := range orphans {
+		if job.Type == JobTypePipelineRun {
+			if s.reconcilePipelineJob(job) {
+				continue
+			}
+		}
+		slog.Info("serve jobs: marking interrupted job", "job_id", job.ID, "type", job.Type)
+		s.jobStore.Update(job.ID, JobStatusInterrupted, job.Progress, job.Result, errJobInterrupted)
+	}
+}
+
+// reconcilePipelineJob resumes or settles an orphaned pipeline_run job and
+// reports whether it did. It returns false when the run left no resumable
+// state, leaving the caller to mark the job interrupted.
+func (s *Server) reconcilePipelineJob(job *Job) bool {
+	runID, _ := job.Result["run_id"].(string)
+	if runID == "" {
+		return false
+	}
+	prior, err := pipeline.LoadState(s.pipelineProjectDir(), runID)
+	if err != nil || prior == nil {
+		return false
+	}
+
+	result := map[string]interface{}{
+		"run_id":      runID,
+		"workflow_id": prior.WorkflowID,
+		"session":     prior.Session,
+		"status":      string(prior.Status),
+	}
+	switch prior.Status {
+	case pipeline.StatusCompleted:
+		s.jobStore.Update(job.ID, JobStatusCompleted, 100, result, "")
+		return true
+	case pipeline.StatusFailed, pipeline.StatusCancelled:
+		s.jobStore.Update(job.ID, JobStatusFailed, job.Progress, result,
+			fmt.Sprintf("pipeline run %s finished %s while the server was down", runID, prior.Status))
+		return true
+	}
+	if prior.WorkflowFile == "" || prior.Session == "" {
+		return false
+	}
+
+	slog.Info("serve jobs: resuming interrupted pipeline job", "job_id", job.ID, "run_id", runID)
+	go s.resumePipelineJob(job.ID, runID, prior)
+	return true
+}
+
+// resumePipelineJob drives a resumed pipeline run to its terminal state and
+// records the outcome on the job, mirroring dispatchJob.
+func (s *Server) resumePipelineJob(jobID, runID string, prior *pipeline.ExecutionState) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.jobStore.Update(jobID, JobStatusFailed, 0, nil, fmt.Sprintf("panic: %v", r))
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), jobExecutionTimeout)
+	defer cancel()
+	s.jobStore.SetCancel(jobID, cancel)
+	defer s.jobStore.ClearCancel(jobID)
+
+	s.jobStore.Update(jobID, JobStatusRunning, 0, map[string]interface{}{"run_id": runID, "resumed": true}, "")
+
+	result := s.resumePipelineWithResult(ctx, runID, prior.Session, nil, prior)
+	if !result.Success {
+		s.jobStore.Update(jobID, JobStatusFailed, 0, map[string]interface{}{"run_id": runID, "resumed": true},
+			fmt.Sprintf("pipeline resume failed [%s]: %s", result.ErrorCode, result.Error))
+		return
+	}
+	s.jobStore.Update(jobID, JobStatusCompleted, 100, map[string]interface{}{
+		"run_id":      result.RunID,
+		"workflow_id": result.WorkflowID,
+		"session":     result.Session,
+		"status":      result.Status,
+		"resumed":     true,
+	}, "")
+}
@@ -73,7 +73,7 @@ func pollJobTerminal(t *testing.T, srv *Server, jobID string) jobEnvelope {
 			t.Fatalf("decode job envelope: %v (body=%s)", err, rec.Body.String())
 		}
 		switch JobStatus(env.Job.Status) {
-		case JobStatusCompleted, JobStatusFailed, JobStatusCancelled:
+		case JobStatusCompleted, JobStatusFailed, JobStatusCancelled, JobStatusInterrupted:
 			return env
 		}
 		if time.Now().After(deadline) {
@@ -0,0 +1,255 @@
+package serve
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/events"
+	"github.com/Dicklesworthstone/ntm/internal/pipeline"
+	"github.com/Dicklesworthstone/ntm/internal/state"
+)
+
+func openJobStateStore(t *testing.T, path string) *state.Store {
+	t.Helper()
+	store, err := state.Open(path)
+	if err != nil {
+		t.Fatalf("open state store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	if err := store.Migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return store
+}
+
+// TestJobStorePersistsAcrossRestart proves a job written through one JobStore
+// is readable — status, progress and result intact — from a fresh JobStore
+// attached to the same state DB, and that in-flight jobs come back as orphans.
+func TestJobStorePersistsAcrossRestart(t *testing.T) {
+	store := openJobStateStore(t, filepath.Join(t.TempDir(), "state.db"))
+
+	first := NewJobStore()
+	if orphans, err := first.Attach(store); err != nil || len(orphans) != 0 {
+		t.Fatalf("Attach(empty) = %v, %v", orphans, err)
+	}
+	done := first.CreateFromRequest(CreateJobRequest{Type: JobTypeSwarmSpawn, Params: map[string]interface{}{"session": "proj"}}, "key-1")
+	first.Update(done.ID, JobStatusCompleted, 100, map[string]interface{}{"panes": float64(3)}, "")
+	running := first.Create(JobTypeCheckpointRestore)
+	first.Update(running.ID, JobStatusRunning, 40, nil, "")
+
+	second := NewJobStore()
+	orphans, err := second.Attach(store)
+	if err != nil {
+		t.Fatalf("Attach: %v", err)
+	}
+	if len(orphans) != 1 || orphans[0].ID != running.ID || orphans[0].Progress != 40 {
+		t.Fatalf("orphans = %+v, want only %s", orphans, running.ID)
+	}
+
+	got := second.Get(done.ID)
+	if got == nil || got.Status != JobStatusCompleted || got.Session != "proj" || got.Result["panes"] != float64(3) {
+		t.Fatalf("restored job = %+v", got)
+	}
+	if byKey := second.FindByIdempotencyKey("key-1", time.Hour); byKey == nil || byKey.ID != done.ID {
+		t.Fatalf("FindByIdempotencyKey = %+v", byKey)
+	}
+}
+
+// TestReconcileJobsMarksUnresumableInterrupted covers the restart path for
+// job types that cannot be resumed: they settle as interrupted, a terminal
+// state that later dispatch updates cannot overwrite.
+func TestReconcileJobsMarksUnresumableInterrupted(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "state.db")
+	store := openJobStateStore(t, dbPath)
+
+	before := NewJobStore()
+	if _, err := before.Attach(store); err != nil {
+		t.Fatalf("Attach: %v", err)
+	}
+	spawn := before.Create(JobTypeSwarmSpawn)
+	before.Update(spawn.ID, JobStatusRunning, 10, nil, "")
+	// A pipeline job with no recorded run state is not resumable either.
+	run := before.Create(JobTypePipelineRun)
+	before.Update(run.ID, JobStatusRunning, 0, map[string]interface{}{"run_id": "run-missing"}, "")
+
+	srv := New(Config{EventBus: events.NewEventBus(16), StateStore: store})
+	defer srv.Stop()
+	srv.mu.Lock()
+	srv.projectDir = t.TempDir()
+	srv.mu.Unlock()
+	srv.restoreJobs()
+
+	for _, id := range []string{spawn.ID, run.ID} {
+		job := srv.jobStore.Get(id)
+		if job == nil || job.Status != JobStatusInterrupted || job.Error != errJobInterrupted {
+			t.Fatalf("job %s after reconcile = %+v, want interrupted", id, job)
+		}
+		srv.jobStore.Update(id, JobStatusCompleted, 100, nil, "")
+		if job := srv.jobStore.Get(id); job.Status != JobStatusInterrupted {
+			t.Fatalf("interrupted job %s must stay terminal, got %s", id, job.Status)
+		}
+	}
+}
+
+// TestReconcileJobsResumesPipelineRun proves an orphaned pipeline_run whose
+// run state survived on disk is resumed rather than abandoned: the step that
+// had not run yet executes after the restart and the job completes.
+func TestReconcileJobsResumesPipelineRun(t *testing.T) {
+	store := openJobStateStore(t, filepath.Join(t.TempDir(), "state.db"))
+	projectDir := t.TempDir()
+
+	marker := filepath.Join(projectDir, "resumed.txt")
+	workflowPath := filepath.Join(projectDir, "resume.yaml")
+	workflow := fmt.Sprintf(`
+schema_version: "2.0"
+name: job-resume
+steps:
+  - id: mark
+    command: 'echo resumed > %s'
+`, marker)
+	if err := os.WriteFile(workflowPath, []byte(workflow), 0o644); err != nil {
+		t.Fatalf("write workflow: %v", err)
+	}
+
+	runID := pipeline.GenerateRunID()
+	if err := pipeline.SaveState(projectDir, &pipeline.ExecutionState{
+		RunID:        runID,
+		WorkflowID:   "job-resume",
+		WorkflowFile: workflowPath,
+		Session:      "jobresume1",
+		Status:       pipeline.StatusRunning,
+		StartedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+		Steps:        map[string]pipeline.StepResult{},
+		Variables:    map[string]interface{}{},
+	}); err != nil {
+		t.Fatalf("save run state: %v", err)
+	}
+
+	before := NewJobStore()
+	if _, err := before.Attach(store); err != nil {
+		t.Fatalf("Attach: %v", err)
+	}
+	job := before.Create(JobTypePipelineRun)
+	before.Update(job.ID, JobStatusRunning, 0, map[string]interface{}{"run_id": runID}, "")
+
+	srv := New(Config{EventBus: events.NewEventBus(16), StateStore: store})
+	defer srv.Stop()
+	srv.mu.Lock()
+	srv.projectDir = projectDir
+	srv.mu.Unlock()
+	srv.restoreJobs()
+
+	final := pollJobTerminal(t, srv, job.ID)
+	if JobStatus(final.Job.Status) != JobStatusCompleted || final.Job.Result["resumed"] != true {
+		t.Fatalf("resumed job = %+v, want completed+resumed", final.Job)
+	}
+	data, err := os.ReadFile(marker)
+	if err != nil || !strings.Contains(string(data), "resumed") {
+		t.Fatalf("resumed step did not run: %q, %v", data, err)
+	}
+}
+
+func TestJobHistoryEndpointFiltersAndPaginates(t *testing.T) {
+	store := openJobStateStore(t, filepath.Join(t.TempDir(), "state.db"))
+	srv := New(Config{EventBus: events.NewEventBus(16), StateStore: store})
+	defer srv.Stop()
+	srv.restoreJobs()
+
+	for i := 0; i < 3; i++ {
+		job := srv.jobStore.Create(JobTypePipelineRun)
+		srv.jobStore.Update(job.ID, JobStatusCompleted, 100, nil, "")
+	}
+	failed := srv.jobStore.Create(JobTypeSwarmSpawn)
+	srv.jobStore.Update(failed.ID, JobStatusFailed, 0, nil, "boom")
+
+	get := func(query string) map[string]interface{} {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		srv.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/jobs/history"+query, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET history%s = %d (%s)", query, rec.Code, rec.Body.String())
+		}
+		var body map[string]interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return body
+	}
+
+	page := get("?type=pipeline_run&limit=2")
+	if page["total"] != float64(3) || page["count"] != float64(2) || page["has_more"] != true {
+		t.Fatalf("pipeline_run page = %v", page)
+	}
+	last := get("?type=pipeline_run&limit=2&offset=2")
+	if last["count"] != float64(1) || last["has_more"] != false {
+		t.Fatalf("pipeline_run last page = %v", last)
+	}
+	byStatus := get("?status=failed,interrupted")
+	jobs, _ := byStatus["jobs"].([]interface{})
+	if byStatus["total"] != float64(1) || len(jobs) != 1 || jobs[0].(map[string]interface{})["id"] != failed.ID {
+		t.Fatalf("status filter = %v", byStatus)
+	}
+}
+
+// TestCreateJobIdempotencyKeySurvivesRestart proves a POST retried with the
+// same Idempotency-Key after a restart maps back onto the persisted job
+// instead of starting a duplicate run.
+func TestCreateJobIdempotencyKeySurvivesRestart(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "state.db")
+	store := openJobStateStore(t, dbPath)
+	body := `{"type":"checkpoint_restore","params":{"session":"idem","checkpoint_id":"missing"}}`
+
+	post := func(srv *Server) (int, jobEnvelope, string) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/jobs", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", "retry-me")
+		rec := httptest.NewRecorder()
+		srv.Router().ServeHTTP(rec, req)
+		var env jobEnvelope
+		_ = json.Unmarshal(rec.Body.Bytes(), &env)
+		return rec.Code, env, rec.Header().Get("X-Idempotent-Replay")
+	}
+
+	first := New(Config{EventBus: events.NewEventBus(16), StateStore: store})
+	first.restoreJobs()
+	code, created, _ := post(first)
+	if code != http.StatusAccepted || created.Job.ID == "" {
+		t.Fatalf("first POST = %d %+v", code, created)
+	}
+	pollJobTerminal(t, first, created.Job.ID)
+	first.Stop()
+
+	second := New(Config{EventBus: events.NewEventBus(16), StateStore: store})
+	second.restoreJobs()
+	code, replayed, replayHeader := post(second)
+	if code != http.StatusAccepted || replayed.Job.ID != created.Job.ID || replayHeader != "true" {
+		t.Fatalf("retried POST = %d id=%s replay=%q, want job %s", code, replayed.Job.ID, replayHeader, created.Job.ID)
+	}
+	pollJobTerminal(t, second, replayed.Job.ID)
+	second.Stop()
+
+	// Past the idempotency TTL the persisted key expires like the in-memory
+	// cache does, and the same key starts a new job.
+	if _, err := store.DB().Exec(`UPDATE serve_jobs SET created_at = ? WHERE id = ?`,
+		time.Now().Add(-25*time.Hour).UTC(), created.Job.ID); err != nil {
+		t.Fatalf("age job: %v", err)
+	}
+	third := New(Config{EventBus: events.NewEventBus(16), StateStore: store})
+	defer third.Stop()
+	third.restoreJobs()
+	code, fresh, replayHeader := post(third)
+	if code != http.StatusAccepted || fresh.Job.ID == "" || fresh.Job.ID == created.Job.ID || replayHeader != "" {
+		t.Fatalf("POST with an expired key = %d id=%s replay=%q, want a new job", code, fresh.Job.ID, replayHeader)
+	}
+	pollJobTerminal(t, third, fresh.Job.ID)
+}
@@ -0,0 +1,107 @@
+package serve
+
+// pipeline_artifacts.go implements the /api/v1/pipelines/{id}/artifacts
+// endpoints for listing and downloading files archived by pipeline steps.
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/Dicklesworthstone/ntm/internal/pipeline"
+)
+
+// ErrCodeArtifactNotFound is returned when a run has no matching artifact.
+const ErrCodeArtifactNotFound = "ARTIFACT_NOT_FOUND"
+
+func (s *Server) registerPipelineArtifactRoutes(r chi.Router) {
+	r.With(s.RequirePermission(PermReadPipelines)).Get("/artifacts", s.handleListPipelineArtifacts)
+	r.With(s.RequirePermission(PermReadPipelines)).Get("/artifacts/{step}/{name}", s.handleDownloadPipelineArtifact)
+}
+
+// handleListPipelineArtifacts handles GET /api/v1/pipelines/{id}/artifacts
+func (s *Server) handleListPipelineArtifacts(w http.ResponseWriter, r *http.Request) {
+	reqID := requestIDFromContext(r.Context())
+	runID := chi.URLParam(r, "id")
+
+	artifacts, err := pipeline.ListRunArtifacts(s.pipelineProjectDir(), runID)
+	if err != nil {
+		writePipelineStateError(w, runID, err, reqID)
+		return
+	}
+	if artifacts == nil {
+		artifacts = []pipeline.RunArtifact{}
+	}
+	writeSuccessResponse(w, http.StatusOK, map[string]interface{}{
+		"run_id":    runID,
+		"artifacts": artifacts,
+		"count":     len(artifacts),
+	}, reqID)
+}
+
+// handleDownloadPipelineArtifact handles
+// GET /api/v1/pipelines/{id}/artifacts/{step}/{name}?file=<path>. The file
+// query parameter is required when the artifact archived more than one file.
+func (s *Server) handleDownloadPipelineArtifact(w http.ResponseWriter, r *http.Request) {
+	reqID := requestIDFromContext(r.Context())
+	runID := chi.URLParam(r, "id")
+	stepID := chi.URLParam(r, "step")
+	name := chi.URLParam(r, "name")
+	file := r.URL.Query().Get("file")
+
+	artifact, localPath, err := pipeline.ResolveRunArtifact(s.pipelineProjectDir(), runID, stepID, name, file)
+	if err != nil {
+		if errors.Is(err, pipeline.ErrArtifactNotFound) {
+			writeErrorResponse(w, http.StatusNotFound, ErrCodeArtifactNotFound, "artifact not found", map[string]interface{}{
+				"run_id": runID,
+				"step":   stepID,
+				"name":   name,
+				"file":   file,
+			}, reqID)
+			return
+		}
+		writePipelineStateError(w, runID, err, reqID)
+		return
+	}
+
+	f, err := os.Open(localPath)
+	if err != nil {
+		slog.Warn("pipeline artifact open failed", "request_id", reqID, "run_id", runID, "path", localPath, "error", err)
+		writeErrorResponse(w, http.StatusNotFound, ErrCodeArtifactNotFound, "archived artifact file is missing", map[string]interface{}{
+			"run_id": runID,
+			"file":   artifact.File,
+		}, reqID)
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, ErrCodeInternalError, "failed to stat artifact", nil, reqID)
+		return
+	}
+
+	slog.Info("pipeline artifact download", "request_id", reqID, "run_id", runID, "step", stepID, "name", name, "file", artifact.File)
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", path.Base(artifact.File)))
+	w.Header().Set("X-Artifact-SHA256", artifact.SHA256)
+	http.ServeContent(w, r, "", info.ModTime(), f)
+}
+
+// writePipelineStateError maps a failure to load a run's persisted state to
+// an HTTP error.
+func writePipelineStateError(w http.ResponseWriter, runID string, err error, reqID string) {
+	if errors.Is(err, os.ErrNotExist) {
+		writeErrorResponse(w, http.StatusNotFound, ErrCodePipelineNotFound, "pipeline not found", map[string]interface{}{
+			"run_id": runID,
+		}, reqID)
+		return
+	}
+	writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, err.Error(), map[string]interface{}{
+		"run_id": runID,
+	}, reqID)
+}
@@ -0,0 +1,77 @@
+package serve
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/pipeline"
+)
+
+func TestPipelineArtifactEndpoints(t *testing.T) {
+	srv := New(Config{})
+	projectDir := t.TempDir()
+	srv.mu.Lock()
+	srv.projectDir = projectDir
+	srv.mu.Unlock()
+
+	const runID = "run-20260314-100000-abcd"
+	dir := filepath.Join(pipeline.ArtifactStoreDir(projectDir, runID), "review", "patches")
+	for name, body := range map[string]string{"a.patch": "--- a\n", "b.patch": "--- b\n"} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := pipeline.SaveState(projectDir, &pipeline.ExecutionState{
+		RunID:     runID,
+		Status:    pipeline.StatusCompleted,
+		StartedAt: time.Now(),
+		Steps: map[string]pipeline.StepResult{
+			"review": {StepID: "review", Status: pipeline.StatusCompleted, Artifacts: []pipeline.ArtifactRecord{{
+				Name:  "patches",
+				Dir:   dir,
+				Files: []pipeline.ArtifactFile{{Path: "a.patch", Size: 6}, {Path: "b.patch", Size: 6}},
+			}}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("SaveState: %v", err)
+	}
+
+	get := func(path string) *httptest.ResponseRecorder {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		srv.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	rec := get("/api/v1/pipelines/" + runID + "/artifacts")
+	var list struct {
+		Artifacts []pipeline.RunArtifact `json:"artifacts"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); rec.Code != http.StatusOK || err != nil || len(list.Artifacts) != 2 {
+		t.Fatalf("list = %d %s", rec.Code, rec.Body)
+	}
+
+	rec = get("/api/v1/pipelines/" + runID + "/artifacts/review/patches?file=b.patch")
+	if rec.Code != http.StatusOK || rec.Body.String() != "--- b\n" || rec.Header().Get("Content-Disposition") != `attachment; filename="b.patch"` {
+		t.Fatalf("download = %d %q %v", rec.Code, rec.Body, rec.Header())
+	}
+
+	for path, want := range map[string]int{
+		"/api/v1/pipelines/" + runID + "/artifacts/review/patches":                       http.StatusBadRequest,
+		"/api/v1/pipelines/" + runID + "/artifacts/review/patches?file=../../state.json": http.StatusNotFound,
+		"/api/v1/pipelines/run-missing/artifacts":                                        http.StatusNotFound,
+	} {
+		if rec := get(path); rec.Code != want {
+			t.Errorf("GET %s = %d, want %d (%s)", path, rec.Code, want, rec.Body)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package serve
+
+// pipeline_graph.go implements GET /api/v1/pipelines/{id}/graph and the
+// pipeline.graph_updated WebSocket event that keeps a rendered run graph
+// live.
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/Dicklesworthstone/ntm/internal/pipeline"
+)
+
+func (s *Server) registerPipelineGraphRoutes(r chi.Router) {
+	r.With(s.RequirePermission(PermReadPipelines)).Get("/graph", s.handleGetPipelineGraph)
+}
+
+// handleGetPipelineGraph handles GET /api/v1/pipelines/{id}/graph?format=json|dot|mermaid.
+// JSON is wrapped in the standard success envelope; dot and mermaid are
+// returned as plain text for piping into renderers.
+func (s *Server) handleGetPipelineGraph(w http.ResponseWriter, r *http.Request) {
+	reqID := requestIDFromContext(r.Context())
+	runID := chi.URLParam(r, "id")
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	if format == "" {
+		format = pipeline.GraphFormatJSON
+	}
+	switch format {
+	case pipeline.GraphFormatJSON, pipeline.GraphFormatDOT, pipeline.GraphFormatMermaid:
+	default:
+		writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, "format must be json, dot or mermaid", map[string]interface{}{
+			"format": format,
+		}, reqID)
+		return
+	}
+
+	graph, err := pipeline.LoadRunGraph(s.pipelineProjectDir(), runID)
+	if err != nil {
+		writePipelineStateError(w, runID, err, reqID)
+		return
+	}
+
+	if format == pipeline.GraphFormatJSON {
+		writeSuccessResponse(w, http.StatusOK, map[string]interface{}{
+			"run_id": runID,
+			"graph":  graph,
+		}, reqID)
+		return
+	}
+	rendered, err := graph.Render(format)
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error(), nil, reqID)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(rendered))
+}
+
+// pipelineRunRef identifies the run a progress forwarder publishes for.
+type pipelineRunRef struct {
+	runID    string
+	session  string
+	workflow *pipeline.Workflow
+	executor *pipeline.Executor
+}
+
+// forwardPipelineProgress publishes one executor progress event on the
+// pipelines WebSocket topics. Step starts and finishes, and the workflow
+// start and end, are also followed by a pipeline.graph_updated event
+// carrying the run graph with current step statuses.
+func (s *Server) forwardPipelineProgress(ev pipeline.ProgressEvent, run pipelineRunRef) {
+	if eventType, ok := pipelineEventTypeFromProgressType(ev.Type); ok {
+		payload := map[string]interface{}{
+			"run_id":      run.runID,
+			"workflow_id": run.workflow.Name,
+			"session":     run.session,
+			"step_id":     ev.StepID,
+			"message":     ev.Message,
+			"progress":    ev.Progress,
+			"timestamp":   ev.Timestamp.UTC().Format(time.RFC3339Nano),
+		}
+		if ev.Type == "workflow_error" {
+			payload["success"] = false
+		}
+		if ev.Type == "workflow_complete" {
+			payload["success"] = true
+		}
+		s.publishPipelineEvent(run.session, eventType, payload)
+	}
+
+	switch ev.Type {
+	case "workflow_start", "step_start", "step_complete", "step_error", "workflow_complete", "workflow_error":
+		s.publishPipelineGraph(ev, run)
+	}
+}
+
+func (s *Server) publishPipelineGraph(ev pipeline.ProgressEvent, run pipelineRunRef) {
+	if s.wsHub == nil || run.executor == nil {
+		return
+	}
+	s.publishPipelineEvent(run.session, "pipeline.graph_updated", map[string]interface{}{
+		"run_id":    run.runID,
+		"step_id":   ev.StepID,
+		"graph":     pipeline.BuildRunGraph(run.workflow, run.executor.GetState()),
+		"timestamp": ev.Timestamp.UTC().Format(time.RFC3339Nano),
+	})
+}
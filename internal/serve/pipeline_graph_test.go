@@ -0,0 +1,118 @@
+package serve
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dicklesworthstone/ntm/internal/pipeline"
+)
+
+func TestPipelineGraphEndpoint(t *testing.T) {
+	srv := New(Config{})
+	projectDir := t.TempDir()
+	srv.mu.Lock()
+	srv.projectDir = projectDir
+	srv.mu.Unlock()
+
+	workflowFile := filepath.Join(projectDir, "graph.yaml")
+	if err := os.WriteFile(workflowFile, []byte(`
+schema_version: "2.0"
+name: graph
+steps:
+  - id: build
+    command: "echo build"
+  - id: test
+    depends_on: [build]
+    command: "exit 3"
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	workflow, _, err := pipeline.LoadAndValidate(workflowFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := pipeline.DefaultExecutorConfig("graph-session")
+	cfg.ProjectDir = projectDir
+	cfg.WorkflowFile = workflowFile
+	cfg.DefaultTimeout = 5 * time.Second
+	state, _ := pipeline.NewExecutor(cfg).Run(context.Background(), workflow, nil, nil)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		srv.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	rec := get("/api/v1/pipelines/" + state.RunID + "/graph")
+	var body struct {
+		Graph pipeline.RunGraph `json:"graph"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); rec.Code != http.StatusOK || err != nil {
+		t.Fatalf("graph = %d %s", rec.Code, rec.Body)
+	}
+	statuses := map[string]pipeline.ExecutionStatus{}
+	for _, n := range body.Graph.Nodes {
+		statuses[n.ID] = n.Status
+	}
+	if statuses["build"] != pipeline.StatusCompleted || statuses["test"] != pipeline.StatusFailed || len(body.Graph.Edges) != 1 {
+		t.Fatalf("graph = %+v", body.Graph)
+	}
+
+	rec = get("/api/v1/pipelines/" + state.RunID + "/graph?format=mermaid")
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Body.String(), "flowchart LR") || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain") {
+		t.Fatalf("mermaid = %d %q", rec.Code, rec.Body)
+	}
+
+	for path, want := range map[string]int{
+		"/api/v1/pipelines/" + state.RunID + "/graph?format=svg": http.StatusBadRequest,
+		"/api/v1/pipelines/run-missing/graph":                    http.StatusNotFound,
+	} {
+		if rec := get(path); rec.Code != want {
+			t.Errorf("GET %s = %d, want %d (%s)", path, rec.Code, want, rec.Body)
+		}
+	}
+}
+
+func TestForwardPipelineProgressPublishesGraphUpdates(t *testing.T) {
+	srv := New(Config{})
+	hub := NewWSHub() // not running: published events stay in the buffer
+	srv.wsHub = hub
+
+	workflow := &pipeline.Workflow{Name: "wf", Steps: []pipeline.Step{{ID: "a", Command: "true"}}}
+	run := pipelineRunRef{
+		runID:    "run-1",
+		session:  "proj",
+		workflow: workflow,
+		executor: pipeline.NewExecutor(pipeline.DefaultExecutorConfig("proj")),
+	}
+
+	srv.forwardPipelineProgress(pipeline.ProgressEvent{Type: "step_start", StepID: "a"}, run)
+	srv.forwardPipelineProgress(pipeline.ProgressEvent{Type: "step_complete", StepID: "a"}, run)
+	srv.forwardPipelineProgress(pipeline.ProgressEvent{Type: "loop_start", StepID: "a"}, run)
+
+	var got []string
+	for len(hub.broadcast) > 0 {
+		ev := <-hub.broadcast
+		if ev.Topic != "pipelines:proj" {
+			t.Errorf("topic = %s", ev.Topic)
+		}
+		got = append(got, ev.EventType)
+		if ev.EventType == "pipeline.graph_updated" {
+			payload := ev.Data.(map[string]interface{})
+			if g, ok := payload["graph"].(*pipeline.RunGraph); !ok || len(g.Nodes) != 1 || payload["run_id"] != "run-1" {
+				t.Errorf("graph payload = %+v", payload)
+			}
+		}
+	}
+	if strings.Join(got, ",") != "pipeline.graph_updated,pipeline.step_completed,pipeline.graph_updated" {
+		t.Fatalf("events = %v", got)
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/go-chi/chi/v5"
 
 	"github.com/Dicklesworthstone/ntm/internal/pipeline"
+	"github.com/Dicklesworthstone/ntm/internal/pressure"
 	"github.com/Dicklesworthstone/ntm/internal/tmux"
 )
 
@@ -99,6 +100,7 @@ type PipelineRunRequest struct {
 	Variables    map[string]interface{} `json:"variables,omitempty"`
 	DryRun       bool                   `json:"dry_run,omitempty"`
 	Background   bool                   `json:"background,omitempty"`
+	NoCache      bool                   `json:"no_cache,omitempty"`
 }
 
 // PipelineValidateRequest is the request body for POST /api/v1/pipelines/validate
@@ -147,12 +149,17 @@ func (s *Server) registerPipelineRoutes(r chi.Router) {
 		// Cleanup old pipeline state files (dangerous operation - admin only)
 		r.With(s.RequirePermission(PermDangerousOps)).Post("/cleanup", s.handleCleanupPipelines)
 
+		// Cron, interval and event schedules
+		s.registerScheduleRoutes(r)
+
 		// Single pipeline operations
 		r.Route("/{id}", func(r chi.Router) {
 			r.With(s.RequirePermission(PermReadPipelines)).Get("/", s.handleGetPipeline)
 			r.With(s.RequirePermission(PermWritePipelines)).Delete("/", s.handleCancelPipeline)
 			r.With(s.RequirePermission(PermWritePipelines)).Post("/cancel", s.handleCancelPipeline)
 			r.With(s.RequirePermission(PermWritePipelines)).Post("/resume", s.handleResumePipeline)
+			s.registerPipelineArtifactRoutes(r)
+			s.registerPipelineGraphRoutes(r)
 		})
 	})
 }
@@ -246,6 +253,7 @@ func (s *Server) handleRunPipeline(w http.ResponseWriter, r *http.Request) {
 		Variables:    req.Variables,
 		DryRun:       req.DryRun,
 		Background:   req.Background,
+		NoCache:      req.NoCache,
 	}
 
 	// Use the pipeline robot API which handles everything
@@ -385,7 +393,7 @@ func (s *Server) handleCancelPipeline(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check if pipeline can be cancelled
-	if exec.Status != "running" && exec.Status != "pending" {
+	if !pipeline.ExecutionStatus(exec.Status).IsActive() {
 		writeErrorResponse(w, http.StatusConflict, ErrCodeConflict, "pipeline cannot be cancelled", map[string]interface{}{
 			"run_id": runID,
 			"status": exec.Status,
@@ -670,12 +678,18 @@ func (s *Server) runPipelineWithResult(ctx context.Context, opts pipeline.Pipeli
 	// Create executor config
 	config := pipeline.DefaultExecutorConfig(opts.Session)
 	config.DryRun = opts.DryRun
+	config.NoCache = opts.NoCache
 	config.ProjectDir = opts.ProjectDir
 	if strings.TrimSpace(config.ProjectDir) == "" {
 		config.ProjectDir = s.pipelineProjectDir()
 	}
 	config.WorkflowFile = workflowPath
-	config.RunID = pipeline.GenerateRunID()
+	config.RunID = opts.RunID
+	if config.RunID == "" {
+		config.RunID = pipeline.GenerateRunID()
+	}
+	config.Approvals = s.approvalEngine()
+	config.Pressure = pressure.NewSystemGovernor(pressure.ModeEnforce)
 
 	executor := pipeline.NewExecutor(config)
 
@@ -723,6 +737,7 @@ func (s *Server) runPipelineWithResult(ctx context.Context, opts pipeline.Pipeli
 		Percent: 0,
 	}
 
+	run := pipelineRunRef{runID: config.RunID, session: opts.Session, workflow: workflow, executor: executor}
 	progress := make(chan pipeline.ProgressEvent, 256)
 	done := make(chan struct{})
 	go func() {
@@ -734,50 +749,12 @@ func (s *Server) runPipelineWithResult(ctx context.Context, opts pipeline.Pipeli
 		for {
 			select {
 			case ev := <-progress:
-				eventType, ok := pipelineEventTypeFromProgressType(ev.Type)
-				if !ok {
-					continue
-				}
-				payload := map[string]interface{}{
-					"run_id":      config.RunID,
-					"workflow_id": workflow.Name,
-					"session":     opts.Session,
-					"step_id":     ev.StepID,
-					"message":     ev.Message,
-					"progress":    ev.Progress,
-					"timestamp":   ev.Timestamp.UTC().Format(time.RFC3339Nano),
-				}
-				if ev.Type == "workflow_error" {
-					payload["success"] = false
-				}
-				if ev.Type == "workflow_complete" {
-					payload["success"] = true
-				}
-				s.publishPipelineEvent(opts.Session, eventType, payload)
+				s.forwardPipelineProgress(ev, run)
 			case <-done:
 				for {
 					select {
 					case ev := <-progress:
-						eventType, ok := pipelineEventTypeFromProgressType(ev.Type)
-						if !ok {
-							continue
-						}
-						payload := map[string]interface{}{
-							"run_id":      config.RunID,
-							"workflow_id": workflow.Name,
-							"session":     opts.Session,
-							"step_id":     ev.StepID,
-							"message":     ev.Message,
-							"progress":    ev.Progress,
-							"timestamp":   ev.Timestamp.UTC().Format(time.RFC3339Nano),
-						}
-						if ev.Type == "workflow_error" {
-							payload["success"] = false
-						}
-						if ev.Type == "workflow_complete" {
-							payload["success"] = true
-						}
-						s.publishPipelineEvent(opts.Session, eventType, payload)
+						s.forwardPipelineProgress(ev, run)
 					default:
 						return
 					}
@@ -827,6 +804,8 @@ func (s *Server) execPipelineInline(ctx context.Context, workflow *pipeline.Work
 	config := pipeline.DefaultExecutorConfig(session)
 	config.ProjectDir = s.pipelineProjectDir()
 	config.RunID = pipeline.GenerateRunID()
+	config.Approvals = s.approvalEngine()
+	config.Pressure = pressure.NewSystemGovernor(pressure.ModeEnforce)
 
 	executor := pipeline.NewExecutor(config)
 
@@ -857,6 +836,7 @@ func (s *Server) execPipelineInline(ctx context.Context, workflow *pipeline.Work
 		defer cancelSync()
 	}
 
+	run := pipelineRunRef{runID: config.RunID, session: session, workflow: workflow, executor: executor}
 	progress := make(chan pipeline.ProgressEvent, 256)
 	done := make(chan struct{})
 	go func() {
@@ -868,50 +848,12 @@ func (s *Server) execPipelineInline(ctx context.Context, workflow *pipeline.Work
 		for {
 			select {
 			case ev := <-progress:
-				eventType, ok := pipelineEventTypeFromProgressType(ev.Type)
-				if !ok {
-					continue
-				}
-				payload := map[string]interface{}{
-					"run_id":      config.RunID,
-					"workflow_id": workflow.Name,
-					"session":     session,
-					"step_id":     ev.StepID,
-					"message":     ev.Message,
-					"progress":    ev.Progress,
-					"timestamp":   ev.Timestamp.UTC().Format(time.RFC3339Nano),
-				}
-				if ev.Type == "workflow_error" {
-					payload["success"] = false
-				}
-				if ev.Type == "workflow_complete" {
-					payload["success"] = true
-				}
-				s.publishPipelineEvent(session, eventType, payload)
+				s.forwardPipelineProgress(ev, run)
 			case <-done:
 				for {
 					select {
 					case ev := <-progress:
-						eventType, ok := pipelineEventTypeFromProgressType(ev.Type)
-						if !ok {
-							continue
-						}
-						payload := map[string]interface{}{
-							"run_id":      config.RunID,
-							"workflow_id": workflow.Name,
-							"session":     session,
-							"step_id":     ev.StepID,
-							"message":     ev.Message,
-							"progress":    ev.Progress,
-							"timestamp":   ev.Timestamp.UTC().Format(time.RFC3339Nano),
-						}
-						if ev.Type == "workflow_error" {
-							payload["success"] = false
-						}
-						if ev.Type == "workflow_complete" {
-							payload["success"] = true
-						}
-						s.publishPipelineEvent(session, eventType, payload)
+						s.forwardPipelineProgress(ev, run)
 					default:
 						return
 					}
@@ -974,6 +916,8 @@ func (s *Server) resumePipelineWithResult(ctx context.Context, runID, session st
 	config.ProjectDir = s.pipelineProjectDir()
 	config.WorkflowFile = state.WorkflowFile
 	config.RunID = runID
+	config.Approvals = s.approvalEngine()
+	config.Pressure = pressure.NewSystemGovernor(pressure.ModeEnforce)
 
 	executor := pipeline.NewExecutor(config)
 
@@ -991,6 +935,7 @@ func (s *Server) resumePipelineWithResult(ctx context.Context, runID, session st
 		runCtx = context.Background()
 	}
 
+	run := pipelineRunRef{runID: config.RunID, session: session, workflow: workflow, executor: executor}
 	progress := make(chan pipeline.ProgressEvent, 256)
 	done := make(chan struct{})
 	go func() {
@@ -1002,50 +947,12 @@ func (s *Server) resumePipelineWithResult(ctx context.Context, runID, session st
 		for {
 			select {
 			case ev := <-progress:
-				eventType, ok := pipelineEventTypeFromProgressType(ev.Type)
-				if !ok {
-					continue
-				}
-				payload := map[string]interface{}{
-					"run_id":      config.RunID,
-					"workflow_id": workflow.Name,
-					"session":     session,
-					"step_id":     ev.StepID,
-					"message":     ev.Message,
-					"progress":    ev.Progress,
-					"timestamp":   ev.Timestamp.UTC().Format(time.RFC3339Nano),
-				}
-				if ev.Type == "workflow_error" {
-					payload["success"] = false
-				}
-				if ev.Type == "workflow_complete" {
-					payload["success"] = true
-				}
-				s.publishPipelineEvent(session, eventType, payload)
+				s.forwardPipelineProgress(ev, run)
 			case <-done:
 				for {
 					select {
 					case ev := <-progress:
-						eventType, ok := pipelineEventTypeFromProgressType(ev.Type)
-						if !ok {
-							continue
-						}
-						payload := map[string]interface{}{
-							"run_id":      config.RunID,
-							"workflow_id": workflow.Name,
-							"session":     session,
-							"step_id":     ev.StepID,
-							"message":     ev.Message,
-							"progress":    ev.Progress,
-							"timestamp":   ev.Timestamp.UTC().Format(time.RFC3339Nano),
-						}
-						if ev.Type == "workflow_error" {
-							payload["success"] = false
-						}
-						if ev.Type == "workflow_complete" {
-							payload["success"] = true
-						}
-						s.publishPipelineEvent(session, eventType, payload)
+						s.forwardPipelineProgress(ev, run)
 					default:
 						return
 					}
@@ -170,6 +170,9 @@ type RoleContext struct {
 	Role      Role
 	UserID    string
 	ClaimsRaw map[string]interface{}
+	// Scope is set for callers authenticated with a registry API key and
+	// narrows which sessions and projects the role applies to.
+	Scope *KeyScope
 }
 
 // ctxKeyRole is the context key for RBAC context.
@@ -207,6 +210,7 @@ func (s *Server) rbacMiddleware(next http.Handler) http.Handler {
 			Role:      role,
 			UserID:    userID,
 			ClaimsRaw: claims,
+			Scope:     keyScopeFromClaims(claims),
 		}
 
 		// Add RBAC context to request
@@ -320,6 +324,15 @@ func (s *Server) RequirePermission(perm Permission) func(http.Handler) http.Hand
 				return
 			}
 
+			if err := s.checkKeyScope(r, rc.Scope); err != nil {
+				reqID := requestIDFromContext(r.Context())
+				log.Printf("RBAC: scope denied perm=%s path=%s user=%s request_id=%s: %v",
+					perm, r.URL.Path, rc.UserID, reqID, err)
+				writeErrorResponse(w, http.StatusForbidden, ErrCodeForbidden,
+					"access denied: "+err.Error(), nil, reqID)
+				return
+			}
+
 			next.ServeHTTP(w, r)
 		})
 	}
@@ -0,0 +1,81 @@
+package serve
+
+// recordings.go implements GET /api/v1/recordings and
+// GET /api/v1/recordings/{session}/{name}, which serve the pane casts written
+// by `ntm recording start` so the web UI can embed asciinema-player.
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/Dicklesworthstone/ntm/internal/recording"
+)
+
+func (s *Server) registerRecordingRoutes(r chi.Router) {
+	r.Route("/recordings", func(r chi.Router) {
+		r.With(s.RequirePermission(PermReadSessions)).Get("/", s.handleListRecordingsV1)
+		r.With(s.RequirePermission(PermReadSessions)).Get("/{session}/{name}", s.handleGetRecordingV1)
+	})
+}
+
+func (s *Server) recordingDir() string {
+	if s.recordingsDir != "" {
+		return s.recordingsDir
+	}
+	return recording.DefaultDir()
+}
+
+// handleListRecordingsV1 handles GET /api/v1/recordings?session=.
+func (s *Server) handleListRecordingsV1(w http.ResponseWriter, r *http.Request) {
+	reqID := requestIDFromContext(r.Context())
+	infos, err := recording.List(s.recordingDir(), r.URL.Query().Get("session"))
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, err.Error(), nil, reqID)
+		return
+	}
+	writeSuccessResponse(w, http.StatusOK, map[string]interface{}{
+		"recordings": infos,
+		"count":      len(infos),
+	}, reqID)
+}
+
+// handleGetRecordingV1 handles GET /api/v1/recordings/{session}/{name}. The
+// body is the asciicast v2 file itself, with timeline markers merged in
+// unless markers=false, so it can be handed straight to a player.
+func (s *Server) handleGetRecordingV1(w http.ResponseWriter, r *http.Request) {
+	reqID := requestIDFromContext(r.Context())
+	id := chi.URLParam(r, "session") + "/" + chi.URLParam(r, "name")
+	withMarkers := true
+	if v := r.URL.Query().Get("markers"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, "markers must be a boolean", map[string]interface{}{
+				"markers": v,
+			}, reqID)
+			return
+		}
+		withMarkers = b
+	}
+
+	c, err := recording.Load(s.recordingDir(), id)
+	if errors.Is(err, recording.ErrNotFound) {
+		writeErrorResponse(w, http.StatusNotFound, ErrCodeNotFound, "recording not found", map[string]interface{}{
+			"id": id,
+		}, reqID)
+		return
+	}
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, err.Error(), nil, reqID)
+		return
+	}
+	if withMarkers {
+		c = c.WithMarkers(recording.TimelineMarkers(c))
+	}
+
+	w.Header().Set("Content-Type", "application/x-asciicast")
+	w.WriteHeader(http.StatusOK)
+	_ = c.Write(w)
+}
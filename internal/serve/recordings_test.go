@@ -0,0 +1,75 @@
+package serve
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Dicklesworthstone/ntm/internal/recording"
+)
+
+func TestRecordingEndpoints(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "proj"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	cast := &recording.Cast{
+		Header: recording.Header{Version: recording.CastVersion, Width: 80, Height: 24, Timestamp: 1700000000,
+			NTM: &recording.Meta{Session: "proj", PaneID: "%1", Pane: 1, AgentID: "cc_1", Part: 1}},
+		Events: []recording.Event{{Time: 0.5, Code: recording.EventOutput, Data: "hello\r\n"}},
+	}
+	f, err := os.Create(filepath.Join(dir, "proj", "20261017T021400Z-0.1.cast"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cast.Write(f); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	srv := New(Config{RecordingsDir: dir})
+	get := func(path string) *httptest.ResponseRecorder {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		srv.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	rec := get("/api/v1/recordings?session=proj")
+	var list struct {
+		Recordings []recording.Info `json:"recordings"`
+		Count      int              `json:"count"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); rec.Code != http.StatusOK || err != nil || list.Count != 1 {
+		t.Fatalf("list = %d %s", rec.Code, rec.Body)
+	}
+	if list.Recordings[0].ID != "proj/20261017T021400Z-0.1" || list.Recordings[0].AgentID != "cc_1" {
+		t.Errorf("recording = %+v", list.Recordings[0])
+	}
+
+	rec = get("/api/v1/recordings/proj/20261017T021400Z-0.1?markers=false")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/x-asciicast" {
+		t.Fatalf("get = %d %s %s", rec.Code, rec.Header().Get("Content-Type"), rec.Body)
+	}
+	got, err := recording.Read(rec.Body)
+	if err != nil || len(got.Events) != 1 || got.Events[0].Data != "hello\r\n" {
+		t.Fatalf("cast = %+v, %v", got, err)
+	}
+
+	if rec := get("/api/v1/recordings/proj/20261017T021400Z-0.1"); rec.Code != http.StatusOK {
+		t.Errorf("get with markers = %d %s", rec.Code, rec.Body)
+	}
+	if rec := get("/api/v1/recordings/proj/missing"); rec.Code != http.StatusNotFound {
+		t.Errorf("missing = %d", rec.Code)
+	}
+	if rec := get("/api/v1/recordings/proj/x?markers=maybe"); rec.Code != http.StatusBadRequest {
+		t.Errorf("bad markers = %d", rec.Code)
+	}
+	if rec := get("/api/v1/recordings?session=.."); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "invalid session") {
+		t.Errorf("traversal = %d %s", rec.Code, rec.Body)
+	}
+}
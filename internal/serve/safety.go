@@ -2,6 +2,7 @@
 package serve
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -190,17 +191,54 @@ func (s *Server) handleSafetyBlockedV1(w http.ResponseWriter, r *http.Request) {
 }
 
 // SafetyCheckRequest is the request to check a command against policy.
+// The context fields feed rules scoped with when: conditions; omitted ones
+// are unknown, except that Branch is read from Dir when Dir is given.
 type SafetyCheckRequest struct {
-	Command string `json:"command"`
+	Command   string    `json:"command"`
+	AgentType string    `json:"agent_type,omitempty"`
+	Session   string    `json:"session,omitempty"`
+	Dir       string    `json:"dir,omitempty"`
+	Branch    string    `json:"branch,omitempty"`
+	Time      time.Time `json:"time,omitempty"`
+	// Explain adds a rule-by-rule explanation to the response.
+	Explain bool `json:"explain,omitempty"`
 }
 
 // SafetyCheckResponse is the REST response for safety check.
 type SafetyCheckResponse struct {
-	Command string `json:"command"`
-	Action  string `json:"action"` // allow, block, approve
-	Pattern string `json:"pattern,omitempty"`
-	Reason  string `json:"reason,omitempty"`
-	SLB     bool   `json:"slb,omitempty"` // Requires SLB two-person approval
+	Command     string              `json:"command"`
+	Action      string              `json:"action"` // allow, block, approve
+	Pattern     string              `json:"pattern,omitempty"`
+	Reason      string              `json:"reason,omitempty"`
+	SubCommand  string              `json:"sub_command,omitempty"` // resolved sub-command that matched
+	Scope       string              `json:"scope,omitempty"`       // matched rule's when: conditions
+	SLB         bool                `json:"slb,omitempty"`         // Requires SLB two-person approval
+	Explanation *policy.Explanation `json:"explanation,omitempty"`
+}
+
+// policyContext is the context the request describes.
+func (req SafetyCheckRequest) policyContext() policy.Context {
+	pctx := policy.Context{Dir: req.Dir}
+	if req.Dir != "" && req.Branch == "" {
+		pctx = policy.DirContext(req.Dir)
+	}
+	if req.Branch != "" {
+		pctx.Branch = req.Branch
+	}
+	pctx.AgentType = req.AgentType
+	pctx.Session = req.Session
+	pctx.Time = req.Time
+	return pctx
+}
+
+// sessionPolicyContext is the policy context for input sent to a session:
+// the server's project directory and its branch, the session, and the
+// target's agent type when known.
+func (s *Server) sessionPolicyContext(session, agentType string) policy.Context {
+	pctx := policy.DirContext(s.projectDirSnapshot())
+	pctx.Session = session
+	pctx.AgentType = agentType
+	return pctx
 }
 
 func (s *Server) handleSafetyCheckV1(w http.ResponseWriter, r *http.Request) {
@@ -219,14 +257,15 @@ func (s *Server) handleSafetyCheckV1(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	p, err := policy.LoadOrDefault()
+	p, err := policy.LoadForSession(req.Session)
 	if err != nil {
 		writeErrorResponse(w, http.StatusInternalServerError, ErrCodeInternalError,
 			"failed to load policy", nil, reqID)
 		return
 	}
 
-	match := p.Check(req.Command)
+	pctx := req.policyContext()
+	match := p.CheckContext(req.Command, pctx)
 
 	resp := SafetyCheckResponse{
 		Command: req.Command,
@@ -237,8 +276,14 @@ func (s *Server) handleSafetyCheckV1(w http.ResponseWriter, r *http.Request) {
 		resp.Action = string(match.Action)
 		resp.Pattern = match.Pattern
 		resp.Reason = match.Reason
+		resp.SubCommand = match.SubCommand
+		resp.Scope = match.Scope
 		resp.SLB = match.SLB
 	}
+	if req.Explain {
+		exp := p.Explain(req.Command, pctx)
+		resp.Explanation = &exp
+	}
 
 	data, err := toJSONMap(resp)
 	if err != nil {
@@ -410,6 +455,7 @@ type PolicyGetResponse struct {
 	PolicyPath string                  `json:"policy_path,omitempty"`
 	Content    string                  `json:"content,omitempty"`
 	IsDefault  bool                    `json:"is_default"`
+	Layers     []policy.Layer          `json:"layers,omitempty"` // merged policy files, most general first
 	Stats      PolicyStatsResponse     `json:"stats"`
 	Automation policy.AutomationConfig `json:"automation"`
 	Rules      *PolicyRulesResponse    `json:"rules,omitempty"`
@@ -432,9 +478,12 @@ type PolicyRulesResponse struct {
 
 // PolicyRuleSummary is a simplified rule representation.
 type PolicyRuleSummary struct {
-	Pattern string `json:"pattern"`
-	Reason  string `json:"reason,omitempty"`
-	SLB     bool   `json:"slb,omitempty"`
+	Pattern     string        `json:"pattern"`
+	When        string        `json:"when,omitempty"`
+	Reason      string        `json:"reason,omitempty"`
+	SLB         bool          `json:"slb,omitempty"`
+	Overridable bool          `json:"overridable,omitempty"`
+	Source      policy.Source `json:"source"` // layer and file the rule came from
 }
 
 func (s *Server) handlePolicyGetV1(w http.ResponseWriter, r *http.Request) {
@@ -444,11 +493,12 @@ func (s *Server) handlePolicyGetV1(w http.ResponseWriter, r *http.Request) {
 	includeRules := r.URL.Query().Get("rules") == "true"
 	includeContent := r.URL.Query().Get("content") == "true"
 
-	// Describe the policy LoadOrDefault actually reads, which prefers the home
+	// Report the file policy updates are written to, which prefers the home
 	// file but otherwise uses the project-local .ntm/policy.yaml that
 	// `ntm setup` creates. Hardcoding the home path made this endpoint report
 	// is_default:true for a project policy that was actively blocking
 	// commands — and report it alongside stats computed from that same file.
+	// The stats and rules cover every merged layer.
 	policyPath, policyExists, resolveErr := policy.ResolveEffectivePath()
 	if resolveErr != nil {
 		writeErrorResponse(w, http.StatusInternalServerError, ErrCodeInternalError,
@@ -463,7 +513,7 @@ func (s *Server) handlePolicyGetV1(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	isDefault := !policyExists
+	isDefault := len(p.Layers()) == 0
 	blocked, approval, allowed := p.Stats()
 
 	// Count SLB rules
@@ -477,6 +527,7 @@ func (s *Server) handlePolicyGetV1(w http.ResponseWriter, r *http.Request) {
 	resp := PolicyGetResponse{
 		Version:    p.Version,
 		IsDefault:  isDefault,
+		Layers:     p.Layers(),
 		Automation: p.Automation,
 		Stats: PolicyStatsResponse{
 			Blocked:  blocked,
@@ -486,7 +537,7 @@ func (s *Server) handlePolicyGetV1(w http.ResponseWriter, r *http.Request) {
 		},
 	}
 
-	if !isDefault {
+	if policyExists {
 		resp.PolicyPath = policyPath
 	}
 
@@ -499,7 +550,7 @@ func (s *Server) handlePolicyGetV1(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if includeContent {
-		if isDefault {
+		if !policyExists {
 			resp.Content = generatePolicyYAMLFromPolicy(p)
 		} else {
 			content, err := os.ReadFile(policyPath)
@@ -526,9 +577,13 @@ func toPolicyRuleSummaries(rules []policy.Rule) []PolicyRuleSummary {
 	result := make([]PolicyRuleSummary, len(rules))
 	for i, r := range rules {
 		result[i] = PolicyRuleSummary{
-			Pattern: r.Pattern,
+			Pattern: r.Describe(),
+			When:    r.Scope(),
 			Reason:  r.Reason,
 			SLB:     r.SLB,
+
+			Overridable: r.Overridable,
+			Source:      r.Source(),
 		}
 	}
 	return result
@@ -952,6 +1007,10 @@ type Approval struct {
 	ExpiresAt   time.Time `json:"expires_at"`
 	ApprovedBy  string    `json:"approved_by,omitempty"`
 	ApprovedAt  time.Time `json:"approved_at,omitempty"`
+	// Votes and Missing report quorum progress under an [approvals] rule;
+	// only the single-approval endpoint fills them.
+	Votes   []state.ApprovalVote `json:"votes,omitempty"`
+	Missing []string             `json:"missing,omitempty"`
 }
 
 // approvalFromState converts a durable approval record to its REST shape.
@@ -991,13 +1050,46 @@ func (s *Server) approvalEngine() *approvalpkg.Engine {
 		// The HTTP surface must not shell out to the external `slb` binary
 		// from a request handler (blocking, non-hermetic). The internal
 		// two-person rule is still enforced: RequiresSLB is recorded on the
-		// durable record and Engine.Approve rejects self-approval.
+		// durable record and Engine.Approve rejects self-approval, and
+		// [approvals] rules add native quorums on top.
 		cfg.EnableSLB = false
-		s.approvalEng = approvalpkg.New(s.stateStore, nil, s.eventBus, cfg)
+		cfg.Policy = s.approvalPolicy
+		s.approvalEng = approvalpkg.New(s.stateStore, s.approvalNotifier, s.eventBus, cfg)
 	})
 	return s.approvalEng
 }
 
+// approvalEscalationInterval is how often the server sweeps pending
+// approvals for due escalation steps and timeouts.
+const approvalEscalationInterval = 30 * time.Second
+
+// startApprovalEscalator sweeps pending approvals until ctx ends, so
+// escalation steps and timeouts fire even when no client reads the request.
+// It does nothing unless an [approvals] rule escalates or times out.
+func (s *Server) startApprovalEscalator(ctx context.Context) {
+	if !s.approvalPolicy.Escalates() {
+		return
+	}
+	eng := s.approvalEngine()
+	if eng == nil {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(approvalEscalationInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := eng.Escalate(ctx); err != nil && ctx.Err() == nil {
+					log.Printf("approval escalation sweep failed: %v", err)
+				}
+			}
+		}
+	}()
+}
+
 // requireApprovalEngine fetches the engine or writes a 503 and returns nil.
 func (s *Server) requireApprovalEngine(w http.ResponseWriter, reqID string) *approvalpkg.Engine {
 	eng := s.approvalEngine()
@@ -1032,9 +1124,31 @@ func approvalIdentity(r *http.Request) string {
 	return "unknown"
 }
 
+// approvalApprover is approvalIdentity plus the roles that place the caller
+// in [approvals] groups: the serve RBAC role and any "groups" or "roles"
+// claims carried by the OIDC token.
+func approvalApprover(r *http.Request, onBehalfOf string) approvalpkg.Approver {
+	approver := approvalpkg.Approver{ID: approvalIdentity(r), OnBehalfOf: onBehalfOf}
+	rc := RoleFromContext(r.Context())
+	if rc == nil {
+		return approver
+	}
+	approver.Roles = append(approver.Roles, string(rc.Role))
+	for _, key := range []string{"groups", "roles"} {
+		values, _ := rc.ClaimsRaw[key].([]interface{})
+		for _, v := range values {
+			if role, ok := v.(string); ok && role != "" {
+				approver.Roles = append(approver.Roles, role)
+			}
+		}
+	}
+	return approver
+}
+
 // writeApprovalActionError maps engine failures onto the HTTP statuses the
-// approval API has always used: unknown ID → 404, two-person-rule violation →
-// 403, already-decided/expired/raced → 409, anything else → 500.
+// approval API has always used: unknown ID → 404, two-person-rule violation
+// or a caller outside the rule's approver groups → 403,
+// already-decided/expired/raced/already-voted → 409, anything else → 500.
 func writeApprovalActionError(w http.ResponseWriter, err error, reqID string) {
 	switch {
 	case errors.Is(err, approvalpkg.ErrNotFound):
@@ -1042,9 +1156,13 @@ func writeApprovalActionError(w http.ResponseWriter, err error, reqID string) {
 	case errors.Is(err, approvalpkg.ErrSLBSelfApproval):
 		writeErrorResponse(w, http.StatusForbidden, ErrCodeForbidden,
 			"SLB two-person approval required: approver cannot be the requestor", nil, reqID)
+	case errors.Is(err, approvalpkg.ErrNotEligible),
+		errors.Is(err, approvalpkg.ErrNoDelegation):
+		writeErrorResponse(w, http.StatusForbidden, ErrCodeForbidden, err.Error(), nil, reqID)
 	case errors.Is(err, approvalpkg.ErrExpired),
 		errors.Is(err, approvalpkg.ErrNotPending),
-		errors.Is(err, approvalpkg.ErrConcurrentDecision):
+		errors.Is(err, approvalpkg.ErrConcurrentDecision),
+		errors.Is(err, approvalpkg.ErrDuplicateVote):
 		writeErrorResponse(w, http.StatusConflict, ErrCodeConflict, err.Error(), nil, reqID)
 	default:
 		writeErrorResponse(w, http.StatusInternalServerError, ErrCodeInternalError,
@@ -1184,9 +1302,10 @@ func (s *Server) handleApprovalGetV1(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check lazily transitions a pending record past its deadline to expired
-	// (durably) before returning it.
-	record, err := eng.Check(r.Context(), id)
+	// Tally (via Check) lazily fires due escalation steps and transitions a
+	// pending record past its deadline to expired (durably) before returning
+	// it with its quorum votes.
+	tally, err := eng.Tally(r.Context(), id)
 	if err != nil {
 		if errors.Is(err, approvalpkg.ErrNotFound) {
 			writeErrorResponse(w, http.StatusNotFound, ErrCodeNotFound,
@@ -1198,7 +1317,9 @@ func (s *Server) handleApprovalGetV1(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	data, err := toJSONMap(approvalFromState(record))
+	view := approvalFromState(tally.Approval)
+	view.Votes, view.Missing = tally.Votes, tally.Missing
+	data, err := toJSONMap(view)
 	if err != nil {
 		writeErrorResponse(w, http.StatusInternalServerError, ErrCodeInternalError,
 			"failed to serialize response", nil, reqID)
@@ -1212,7 +1333,17 @@ func (s *Server) handleApprovalGetV1(w http.ResponseWriter, r *http.Request) {
 type ApprovalDecisionResponse struct {
 	ID       string `json:"id"`
 	Status   string `json:"status"`
-	Decision string `json:"decision"`
+	Decision string `json:"decision"` // approved | voted | denied
+	// Missing lists the quorum requirements still unmet after a vote.
+	Missing []string `json:"missing,omitempty"`
+}
+
+// approvalDecisionRequest is the optional body of approve and deny.
+type approvalDecisionRequest struct {
+	Reason string `json:"reason"`
+	// OnBehalfOf casts the decision for an approver who has delegated to the
+	// caller in [approvals] delegations.
+	OnBehalfOf string `json:"on_behalf_of"`
 }
 
 func (s *Server) handleApprovalApproveV1(w http.ResponseWriter, r *http.Request) {
@@ -1230,29 +1361,48 @@ func (s *Server) handleApprovalApproveV1(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	approver := approvalIdentity(r)
+	// Optional body: {"on_behalf_of": "..."}. Absent or malformed bodies
+	// are tolerated for compatibility with the historical bodyless approve.
+	var approveReq approvalDecisionRequest
+	if r.Body != nil {
+		_ = json.NewDecoder(r.Body).Decode(&approveReq)
+	}
+	approver := approvalApprover(r, approveReq.OnBehalfOf)
 
 	// The engine enforces the guarded pending->approved transition (terminal
 	// state checked before expiry so a late retry cannot rewrite a resolved
-	// record), expiry, and the SLB two-person rule against the durable record.
-	if err := eng.Approve(r.Context(), id, approver); err != nil {
+	// record), expiry, the SLB two-person rule, and [approvals] quorums
+	// against the durable record.
+	vote, err := eng.ApproveAs(r.Context(), id, approver)
+	if err != nil {
 		writeApprovalActionError(w, err, reqID)
 		return
 	}
 
-	log.Printf("Approval %s approved by %s", id, approver)
-	// WS push sourced from the durable transition: published only after
-	// Engine.Approve has committed pending->approved to state.db.
-	s.publishApprovalEvent("approval.resolved", map[string]interface{}{
-		"approval_id": id,
-		"decision":    "approved",
-		"approved_by": approver,
-	})
-
 	resp := ApprovalDecisionResponse{
 		ID:       id,
-		Status:   string(state.ApprovalApproved),
+		Status:   string(vote.Approval.Status),
 		Decision: "approved",
+		Missing:  vote.Missing,
+	}
+	if vote.Approval.Status == state.ApprovalPending {
+		log.Printf("Approval %s: vote by %s recorded, still needs %s", id, approver.ID, strings.Join(vote.Missing, ", "))
+		resp.Decision = "voted"
+		s.publishApprovalEvent("approval.voted", map[string]interface{}{
+			"approval_id":  id,
+			"voter":        approver.ID,
+			"on_behalf_of": approver.OnBehalfOf,
+			"missing":      vote.Missing,
+		})
+	} else {
+		log.Printf("Approval %s approved by %s", id, vote.Approval.ApprovedBy)
+		// WS push sourced from the durable transition: published only after
+		// the engine has committed pending->approved to state.db.
+		s.publishApprovalEvent("approval.resolved", map[string]interface{}{
+			"approval_id": id,
+			"decision":    "approved",
+			"approved_by": vote.Approval.ApprovedBy,
+		})
 	}
 
 	data, err := toJSONMap(resp)
@@ -1280,22 +1430,20 @@ func (s *Server) handleApprovalDenyV1(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	denier := approvalIdentity(r)
-
-	// Optional body: {"reason": "..."} — recorded as the durable denial
-	// reason. Absent or malformed bodies are tolerated for compatibility with
-	// the historical bodyless deny.
-	var denyReq struct {
-		Reason string `json:"reason"`
-	}
+	// Optional body: {"reason": "...", "on_behalf_of": "..."} — the reason
+	// is recorded as the durable denial reason. Absent or malformed bodies
+	// are tolerated for compatibility with the historical bodyless deny.
+	var denyReq approvalDecisionRequest
 	if r.Body != nil {
 		_ = json.NewDecoder(r.Body).Decode(&denyReq)
 	}
+	approver := approvalApprover(r, denyReq.OnBehalfOf)
+	denier := approver.ID
 
 	// The engine enforces the guarded pending->denied transition (terminal
 	// state checked before expiry so a late retry cannot rewrite a resolved
-	// record) against the durable record.
-	if err := eng.Deny(r.Context(), id, denier, denyReq.Reason); err != nil {
+	// record) and [approvals] eligibility against the durable record.
+	if err := eng.DenyAs(r.Context(), id, approver, denyReq.Reason); err != nil {
 		writeApprovalActionError(w, err, reqID)
 		return
 	}
@@ -1562,7 +1710,7 @@ func generatePolicyYAMLFromPolicy(p *policy.Policy) string {
 	if len(p.Allowed) > 0 {
 		sb.WriteString("allowed:\n")
 		for _, r := range p.Allowed {
-			sb.WriteString(fmt.Sprintf("  - pattern: '%s'\n", safetyEscapeYAMLSingleQuote(r.Pattern)))
+			safetyWriteRuleMatchersYAML(&sb, r)
 			if r.Reason != "" {
 				sb.WriteString(fmt.Sprintf("    reason: \"%s\"\n", safetyEscapeYAMLDoubleQuote(r.Reason)))
 			}
@@ -1573,10 +1721,13 @@ func generatePolicyYAMLFromPolicy(p *policy.Policy) string {
 	if len(p.Blocked) > 0 {
 		sb.WriteString("blocked:\n")
 		for _, r := range p.Blocked {
-			sb.WriteString(fmt.Sprintf("  - pattern: '%s'\n", safetyEscapeYAMLSingleQuote(r.Pattern)))
+			safetyWriteRuleMatchersYAML(&sb, r)
 			if r.Reason != "" {
 				sb.WriteString(fmt.Sprintf("    reason: \"%s\"\n", safetyEscapeYAMLDoubleQuote(r.Reason)))
 			}
+			if r.Overridable {
+				sb.WriteString("    overridable: true\n")
+			}
 		}
 		sb.WriteString("\n")
 	}
@@ -1584,19 +1735,74 @@ func generatePolicyYAMLFromPolicy(p *policy.Policy) string {
 	if len(p.ApprovalRequired) > 0 {
 		sb.WriteString("approval_required:\n")
 		for _, r := range p.ApprovalRequired {
-			sb.WriteString(fmt.Sprintf("  - pattern: '%s'\n", safetyEscapeYAMLSingleQuote(r.Pattern)))
+			safetyWriteRuleMatchersYAML(&sb, r)
 			if r.Reason != "" {
 				sb.WriteString(fmt.Sprintf("    reason: \"%s\"\n", safetyEscapeYAMLDoubleQuote(r.Reason)))
 			}
 			if r.SLB {
 				sb.WriteString("    slb: true\n")
 			}
+			if r.Overridable {
+				sb.WriteString("    overridable: true\n")
+			}
 		}
 	}
 
 	return sb.String()
 }
 
+// safetyWriteRuleMatchersYAML writes the opening lines of a rule list item:
+// its pattern, any argv matchers, and its when: conditions.
+func safetyWriteRuleMatchersYAML(sb *strings.Builder, r policy.Rule) {
+	lead := "  - "
+	if r.Pattern != "" || (r.Command == "" && len(r.Flag) == 0 && len(r.Arg) == 0) {
+		sb.WriteString(fmt.Sprintf("%spattern: '%s'\n", lead, safetyEscapeYAMLSingleQuote(r.Pattern)))
+		lead = "    "
+	}
+	if r.Command != "" {
+		sb.WriteString(fmt.Sprintf("%scommand: '%s'\n", lead, safetyEscapeYAMLSingleQuote(r.Command)))
+		lead = "    "
+	}
+	for _, m := range []struct {
+		key    string
+		values []string
+	}{{"flag", r.Flag}, {"arg", r.Arg}} {
+		if len(m.values) == 0 {
+			continue
+		}
+		quoted := make([]string, len(m.values))
+		for i, v := range m.values {
+			quoted[i] = "'" + safetyEscapeYAMLSingleQuote(v) + "'"
+		}
+		sb.WriteString(fmt.Sprintf("%s%s: [%s]\n", lead, m.key, strings.Join(quoted, ", ")))
+		lead = "    "
+	}
+	if r.When == nil {
+		return
+	}
+	sb.WriteString("    when:\n")
+	for _, c := range []struct {
+		key    string
+		values []string
+	}{
+		{"agents", r.When.Agents}, {"sessions", r.When.Sessions}, {"projects", r.When.Projects},
+		{"labels", r.When.Labels}, {"paths", r.When.Paths}, {"branches", r.When.Branches},
+		{"hours", r.When.Hours}, {"days", r.When.Days},
+	} {
+		if len(c.values) == 0 {
+			continue
+		}
+		quoted := make([]string, len(c.values))
+		for i, v := range c.values {
+			quoted[i] = "'" + safetyEscapeYAMLSingleQuote(v) + "'"
+		}
+		sb.WriteString(fmt.Sprintf("      %s: [%s]\n", c.key, strings.Join(quoted, ", ")))
+	}
+	if r.When.Timezone != "" {
+		sb.WriteString(fmt.Sprintf("      timezone: '%s'\n", safetyEscapeYAMLSingleQuote(r.When.Timezone)))
+	}
+}
+
 func safetyEscapeYAMLSingleQuote(s string) string {
 	return strings.ReplaceAll(s, "'", "''")
 }
@@ -1701,9 +1907,11 @@ HOOK_INPUT="$(cat)"
 if [ -n "$HOOK_INPUT" ] && command -v jq >/dev/null 2>&1; then
     TOOL_NAME="$(printf '%s' "$HOOK_INPUT" | jq -r '.tool_name // empty' 2>/dev/null)"
     COMMAND="$(printf '%s' "$HOOK_INPUT" | jq -r '.tool_input.command // empty' 2>/dev/null)"
+    HOOK_CWD="$(printf '%s' "$HOOK_INPUT" | jq -r '.cwd // empty' 2>/dev/null)"
 else
     TOOL_NAME=""
     COMMAND=""
+    HOOK_CWD=""
 fi
 
 # Fall back to legacy env vars if a caller still provides them directly.
@@ -1723,8 +1931,12 @@ if [ -z "$COMMAND" ]; then
     exit 0
 fi
 
-# Check against policy
-check_result=$(ntm safety check "$COMMAND" --json 2>&1)
+# Check against policy as Claude Code, in the tool call's working directory
+context_args=(--agent cc)
+if [ -n "$HOOK_CWD" ]; then
+    context_args+=(--dir "$HOOK_CWD")
+fi
+check_result=$(ntm safety check "$COMMAND" "${context_args[@]}" --json 2>&1)
 exit_code=$?
 
 # ntm safety check exits 0 for allow/approve, 1 for block
@@ -351,6 +351,71 @@ func TestApprovalSLBRuleSurfacesThroughHTTP(t *testing.T) {
 	}
 }
 
+// An [approvals] quorum rule holds a request open across HTTP votes: the
+// first eligible vote reports what is still missing, an identity outside
+// the rule's groups is refused, and the vote completing the quorum approves.
+func TestApprovalQuorumVotesThroughHTTP(t *testing.T) {
+	s, store := setupTestServer(t)
+	s.approvalPolicy = approvalpkg.Policy{
+		Groups: []approvalpkg.Group{
+			{Name: "sre", Roles: []string{"sre"}},
+			{Name: "leads", Members: []string{"dana"}},
+		},
+		Rules: []approvalpkg.Rule{{
+			Action: "force_*",
+			Quorum: []approvalpkg.Requirement{{Group: "sre", Count: 1}, {Group: "leads", Count: 1}},
+		}},
+	}
+	if err := store.CreateApproval(&state.Approval{
+		ID:          "apr-quorum-http",
+		Action:      "force_push",
+		RequestedBy: "agent-1",
+		Status:      state.ApprovalPending,
+		CreatedAt:   time.Now(),
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}); err != nil {
+		t.Fatalf("seed approval: %v", err)
+	}
+
+	approveAs := func(rc *RoleContext) (*httptest.ResponseRecorder, ApprovalDecisionResponse) {
+		t.Helper()
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", "apr-quorum-http")
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/safety/approvals/apr-quorum-http/approve", nil)
+		req = req.WithContext(context.WithValue(withRoleContext(req.Context(), rc), chi.RouteCtxKey, rctx))
+		rec := httptest.NewRecorder()
+		s.handleApprovalApproveV1(rec, req)
+		var resp ApprovalDecisionResponse
+		if rec.Code == http.StatusOK {
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode decision: %v", err)
+			}
+		}
+		return rec, resp
+	}
+
+	if rec, _ := approveAs(&RoleContext{Role: RoleAdmin, UserID: "mallory"}); rec.Code != http.StatusForbidden {
+		t.Fatalf("ineligible approve: got %d, want 403: %s", rec.Code, rec.Body.String())
+	}
+
+	rec, resp := approveAs(&RoleContext{Role: RoleOperator, UserID: "erin", ClaimsRaw: map[string]interface{}{"groups": []interface{}{"sre"}}})
+	if rec.Code != http.StatusOK || resp.Decision != "voted" || resp.Status != "pending" || len(resp.Missing) != 1 {
+		t.Fatalf("first vote: %d %+v", rec.Code, resp)
+	}
+
+	rec, resp = approveAs(&RoleContext{Role: RoleOperator, UserID: "dana"})
+	if rec.Code != http.StatusOK || resp.Decision != "approved" || len(resp.Missing) != 0 {
+		t.Fatalf("quorum vote: %d %+v", rec.Code, resp)
+	}
+	a, err := store.GetApproval("apr-quorum-http")
+	if err != nil || a == nil {
+		t.Fatalf("reload approval: %v (record=%v)", err, a)
+	}
+	if a.Status != state.ApprovalApproved || a.ApprovedBy != "erin,dana" {
+		t.Fatalf("durable record = status %q approved_by %q, want approved by erin,dana", a.Status, a.ApprovedBy)
+	}
+}
+
 // Without a state store the approval endpoints fail closed (503) instead of
 // silently keeping decisions in process-local memory.
 func TestApprovalEndpointsFailClosedWithoutStateStore(t *testing.T) {
@@ -0,0 +1,261 @@
+package serve
+
+// schedules.go hosts the pipeline scheduler inside `ntm serve` and implements
+// the /api/v1/pipelines/schedules endpoints.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/Dicklesworthstone/ntm/internal/events"
+	"github.com/Dicklesworthstone/ntm/internal/pipeline"
+	"github.com/Dicklesworthstone/ntm/internal/scheduler"
+	"github.com/Dicklesworthstone/ntm/internal/state"
+)
+
+// ErrCodeScheduleNotFound is returned for unknown schedule IDs or names.
+const ErrCodeScheduleNotFound = "SCHEDULE_NOT_FOUND"
+
+// PipelineScheduleRequest is the request body for POST /api/v1/pipelines/schedules.
+// Exactly one of Cron, Interval or Event must be set.
+type PipelineScheduleRequest struct {
+	Name         string                 `json:"name"`
+	WorkflowFile string                 `json:"workflow_file"`
+	Session      string                 `json:"session"`
+	Variables    map[string]interface{} `json:"variables,omitempty"`
+	Cron         string                 `json:"cron,omitempty"`
+	Timezone     string                 `json:"timezone,omitempty"`
+	Interval     string                 `json:"interval,omitempty"` // e.g. "30m", "6h", "1d"
+	Event        string                 `json:"event,omitempty"`    // event-bus type, e.g. "agent_error"
+	EventSession string                 `json:"event_session,omitempty"`
+	Overlap      string                 `json:"overlap,omitempty"` // skip | queue | cancel_previous
+	Jitter       string                 `json:"jitter,omitempty"`
+	Paused       bool                   `json:"paused,omitempty"`
+}
+
+// serveScheduleRunner launches scheduled runs through the same path as
+// POST /pipelines/run, so they get workflow-path confinement and publish
+// progress on the pipelines:* WebSocket topics.
+type serveScheduleRunner struct {
+	s *Server
+}
+
+func (r serveScheduleRunner) Start(ctx context.Context, sched state.PipelineSchedule, vars map[string]interface{}) (string, error) {
+	result := r.s.runPipelineWithResult(ctx, pipeline.PipelineRunOptions{
+		WorkflowFile: sched.WorkflowFile,
+		Session:      sched.Session,
+		ProjectDir:   sched.ProjectDir,
+		Variables:    vars,
+		Background:   true,
+	})
+	if !result.Success {
+		return "", errors.New(result.Error)
+	}
+	return result.RunID, nil
+}
+
+func (serveScheduleRunner) Active(runID string) bool { return pipeline.IsPipelineActive(runID) }
+
+func (serveScheduleRunner) Cancel(runID string) bool { return pipeline.CancelPipeline(runID) }
+
+// startPipelineScheduler runs the scheduler until ctx ends and feeds it bus
+// events. Without a state store there are no schedules and it is a no-op.
+func (s *Server) startPipelineScheduler(ctx context.Context) func() {
+	if s.pipelineScheduler == nil {
+		return func() {}
+	}
+	go s.pipelineScheduler.Run(ctx)
+	if s.eventBus == nil {
+		return func() {}
+	}
+	return s.eventBus.SubscribeAll(s.pipelineScheduler.HandleEvent)
+}
+
+// notifyPipelineScheduler forwards events that are published only to the
+// WebSocket hub (such as bead.closed) to event-triggered schedules.
+func (s *Server) notifyPipelineScheduler(eventType, session string) {
+	if s.pipelineScheduler == nil {
+		return
+	}
+	go s.pipelineScheduler.HandleEvent(events.BaseEvent{
+		Type:      eventType,
+		Timestamp: time.Now().UTC(),
+		Session:   session,
+	})
+}
+
+// registerScheduleRoutes registers the schedule endpoints under /pipelines.
+func (s *Server) registerScheduleRoutes(r chi.Router) {
+	r.Route("/schedules", func(r chi.Router) {
+		r.With(s.RequirePermission(PermReadPipelines)).Get("/", s.handleListSchedules)
+		r.With(s.RequirePermission(PermWritePipelines)).Post("/", s.handleCreateSchedule)
+		r.With(s.RequirePermission(PermReadPipelines)).Get("/{scheduleId}", s.handleGetSchedule)
+		r.With(s.RequirePermission(PermWritePipelines)).Delete("/{scheduleId}", s.handleDeleteSchedule)
+		r.With(s.RequirePermission(PermWritePipelines)).Post("/{scheduleId}/pause", s.handlePauseSchedule)
+		r.With(s.RequirePermission(PermWritePipelines)).Post("/{scheduleId}/resume", s.handleResumeSchedule)
+	})
+}
+
+// handleListSchedules handles GET /api/v1/pipelines/schedules
+func (s *Server) handleListSchedules(w http.ResponseWriter, r *http.Request) {
+	reqID := requestIDFromContext(r.Context())
+	if s.stateStore == nil {
+		writeErrorResponse(w, http.StatusServiceUnavailable, ErrCodeServiceUnavail, "state store not available", nil, reqID)
+		return
+	}
+	schedules, err := s.stateStore.ListPipelineSchedules()
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error(), nil, reqID)
+		return
+	}
+	if schedules == nil {
+		schedules = []state.PipelineSchedule{}
+	}
+	writeSuccessResponse(w, http.StatusOK, map[string]interface{}{
+		"schedules": schedules,
+		"count":     len(schedules),
+	}, reqID)
+}
+
+// handleCreateSchedule handles POST /api/v1/pipelines/schedules
+func (s *Server) handleCreateSchedule(w http.ResponseWriter, r *http.Request) {
+	reqID := requestIDFromContext(r.Context())
+	if s.stateStore == nil {
+		writeErrorResponse(w, http.StatusServiceUnavailable, ErrCodeServiceUnavail, "state store not available", nil, reqID)
+		return
+	}
+
+	var req PipelineScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, "invalid request body", nil, reqID)
+		return
+	}
+	if _, err := s.resolveWorkflowPath(req.WorkflowFile); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, ErrCodeMissingWorkflow, err.Error(), nil, reqID)
+		return
+	}
+	sched, err := scheduleFromRequest(req)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, err.Error(), nil, reqID)
+		return
+	}
+	sched.ProjectDir = s.projectDirSnapshot()
+	sched.Paused = req.Paused
+
+	if err := scheduler.Create(s.stateStore, sched); err != nil {
+		status, code := http.StatusBadRequest, ErrCodeBadRequest
+		if strings.Contains(err.Error(), "already exists") {
+			status, code = http.StatusConflict, ErrCodeConflict
+		}
+		writeErrorResponse(w, status, code, err.Error(), nil, reqID)
+		return
+	}
+
+	slog.Info("pipeline schedule created", "request_id", reqID, "schedule", sched.Name, "trigger", sched.TriggerKind)
+	writeSuccessResponse(w, http.StatusCreated, map[string]interface{}{"schedule": sched}, reqID)
+}
+
+// handleGetSchedule handles GET /api/v1/pipelines/schedules/{scheduleId}
+func (s *Server) handleGetSchedule(w http.ResponseWriter, r *http.Request) {
+	reqID := requestIDFromContext(r.Context())
+	sched, ok := s.lookupSchedule(w, r, reqID)
+	if !ok {
+		return
+	}
+	writeSuccessResponse(w, http.StatusOK, map[string]interface{}{"schedule": sched}, reqID)
+}
+
+// handleDeleteSchedule handles DELETE /api/v1/pipelines/schedules/{scheduleId}
+func (s *Server) handleDeleteSchedule(w http.ResponseWriter, r *http.Request) {
+	reqID := requestIDFromContext(r.Context())
+	sched, ok := s.lookupSchedule(w, r, reqID)
+	if !ok {
+		return
+	}
+	if err := s.stateStore.DeletePipelineSchedule(sched.ID); err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error(), nil, reqID)
+		return
+	}
+	slog.Info("pipeline schedule deleted", "request_id", reqID, "schedule", sched.Name)
+	writeSuccessResponse(w, http.StatusOK, map[string]interface{}{"id": sched.ID, "deleted": true}, reqID)
+}
+
+// handlePauseSchedule handles POST /api/v1/pipelines/schedules/{scheduleId}/pause
+func (s *Server) handlePauseSchedule(w http.ResponseWriter, r *http.Request) {
+	s.setSchedulePaused(w, r, true)
+}
+
+// handleResumeSchedule handles POST /api/v1/pipelines/schedules/{scheduleId}/resume
+func (s *Server) handleResumeSchedule(w http.ResponseWriter, r *http.Request) {
+	s.setSchedulePaused(w, r, false)
+}
+
+func (s *Server) setSchedulePaused(w http.ResponseWriter, r *http.Request, paused bool) {
+	reqID := requestIDFromContext(r.Context())
+	sched, ok := s.lookupSchedule(w, r, reqID)
+	if !ok {
+		return
+	}
+	if err := s.stateStore.SetPipelineSchedulePaused(sched.ID, paused, time.Now().UTC()); err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error(), nil, reqID)
+		return
+	}
+	updated, err := s.stateStore.GetPipelineSchedule(sched.ID)
+	if err != nil || updated == nil {
+		writeErrorResponse(w, http.StatusInternalServerError, ErrCodeInternalError, "reload schedule failed", nil, reqID)
+		return
+	}
+	slog.Info("pipeline schedule updated", "request_id", reqID, "schedule", sched.Name, "paused", paused)
+	writeSuccessResponse(w, http.StatusOK, map[string]interface{}{"schedule": updated}, reqID)
+}
+
+func (s *Server) lookupSchedule(w http.ResponseWriter, r *http.Request, reqID string) (*state.PipelineSchedule, bool) {
+	if s.stateStore == nil {
+		writeErrorResponse(w, http.StatusServiceUnavailable, ErrCodeServiceUnavail, "state store not available", nil, reqID)
+		return nil, false
+	}
+	ref := chi.URLParam(r, "scheduleId")
+	sched, err := s.stateStore.GetPipelineSchedule(ref)
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error(), nil, reqID)
+		return nil, false
+	}
+	if sched == nil {
+		writeErrorResponse(w, http.StatusNotFound, ErrCodeScheduleNotFound, "schedule not found", map[string]interface{}{
+			"schedule": ref,
+		}, reqID)
+		return nil, false
+	}
+	return sched, true
+}
+
+// scheduleFromRequest converts the REST body into a schedule row. Trigger and
+// policy validation happens in scheduler.Create.
+func scheduleFromRequest(req PipelineScheduleRequest) (*state.PipelineSchedule, error) {
+	sched := &state.PipelineSchedule{
+		Name:          req.Name,
+		WorkflowFile:  req.WorkflowFile,
+		Session:       req.Session,
+		Variables:     req.Variables,
+		CronExpr:      strings.TrimSpace(req.Cron),
+		Timezone:      strings.TrimSpace(req.Timezone),
+		EventType:     strings.TrimSpace(req.Event),
+		EventSession:  strings.TrimSpace(req.EventSession),
+		OverlapPolicy: strings.TrimSpace(req.Overlap),
+	}
+	var err error
+	if sched.IntervalSeconds, err = scheduler.DurationSeconds(req.Interval); err != nil {
+		return nil, errors.New("invalid interval: " + err.Error())
+	}
+	if sched.JitterSeconds, err = scheduler.DurationSeconds(req.Jitter); err != nil {
+		return nil, errors.New("invalid jitter: " + err.Error())
+	}
+	return sched, nil
+}
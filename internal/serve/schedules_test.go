@@ -0,0 +1,74 @@
+package serve
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Dicklesworthstone/ntm/internal/events"
+)
+
+func TestPipelineScheduleEndpoints(t *testing.T) {
+	store := openJobStateStore(t, filepath.Join(t.TempDir(), "state.db"))
+	srv := New(Config{EventBus: events.NewEventBus(16), StateStore: store})
+	t.Cleanup(srv.Stop)
+
+	projectDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(projectDir, "audit.yaml"), []byte("schema_version: \"2.0\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	srv.mu.Lock()
+	srv.projectDir = projectDir
+	srv.mu.Unlock()
+
+	do := func(method, path, body string) (int, map[string]interface{}) {
+		t.Helper()
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		srv.Router().ServeHTTP(rec, req)
+		var resp map[string]interface{}
+		_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+		return rec.Code, resp
+	}
+
+	create := `{"name":"nightly","workflow_file":"audit.yaml","session":"proj","cron":"0 2 * * *","overlap":"queue"}`
+	code, resp := do(http.MethodPost, "/api/v1/pipelines/schedules", create)
+	if code != http.StatusCreated {
+		t.Fatalf("create = %d %v", code, resp)
+	}
+	sched, _ := resp["schedule"].(map[string]interface{})
+	if sched["trigger_kind"] != "cron" || sched["overlap_policy"] != "queue" || sched["project_dir"] != projectDir {
+		t.Fatalf("created schedule = %v", sched)
+	}
+
+	if code, _ := do(http.MethodPost, "/api/v1/pipelines/schedules", create); code != http.StatusConflict {
+		t.Fatalf("duplicate create = %d, want 409", code)
+	}
+	if code, _ := do(http.MethodPost, "/api/v1/pipelines/schedules", `{"name":"x","workflow_file":"audit.yaml","session":"proj"}`); code != http.StatusBadRequest {
+		t.Fatalf("triggerless create = %d, want 400", code)
+	}
+	if code, _ := do(http.MethodPost, "/api/v1/pipelines/schedules", `{"name":"x","workflow_file":"../escape.yaml","session":"proj","interval":"1h"}`); code != http.StatusBadRequest {
+		t.Fatalf("escaping workflow path = %d, want 400", code)
+	}
+
+	if code, resp := do(http.MethodGet, "/api/v1/pipelines/schedules", ""); code != http.StatusOK || resp["count"] != float64(1) {
+		t.Fatalf("list = %d %v", code, resp)
+	}
+	if code, resp := do(http.MethodPost, "/api/v1/pipelines/schedules/nightly/pause", ""); code != http.StatusOK || resp["schedule"].(map[string]interface{})["paused"] != true {
+		t.Fatalf("pause = %d %v", code, resp)
+	}
+	if code, resp := do(http.MethodPost, "/api/v1/pipelines/schedules/nightly/resume", ""); code != http.StatusOK || resp["schedule"].(map[string]interface{})["paused"] == true {
+		t.Fatalf("resume = %d %v", code, resp)
+	}
+	if code, _ := do(http.MethodDelete, "/api/v1/pipelines/schedules/nightly", ""); code != http.StatusOK {
+		t.Fatalf("delete = %d", code)
+	}
+	if code, resp := do(http.MethodGet, "/api/v1/pipelines/schedules/nightly", ""); code != http.StatusNotFound || resp["error_code"] != ErrCodeScheduleNotFound {
+		t.Fatalf("get deleted = %d %v", code, resp)
+	}
+}
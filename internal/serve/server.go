@@ -45,11 +45,14 @@ import (
 	"github.com/Dicklesworthstone/ntm/internal/agentmail"
 	approvalpkg "github.com/Dicklesworthstone/ntm/internal/approval"
 	"github.com/Dicklesworthstone/ntm/internal/events"
+	"github.com/Dicklesworthstone/ntm/internal/fleet"
 	"github.com/Dicklesworthstone/ntm/internal/kernel"
+	"github.com/Dicklesworthstone/ntm/internal/notify"
 	"github.com/Dicklesworthstone/ntm/internal/policy"
 	"github.com/Dicklesworthstone/ntm/internal/redaction"
 	"github.com/Dicklesworthstone/ntm/internal/robot"
 	"github.com/Dicklesworthstone/ntm/internal/robot/adapters"
+	"github.com/Dicklesworthstone/ntm/internal/scheduler"
 	"github.com/Dicklesworthstone/ntm/internal/state"
 	"github.com/Dicklesworthstone/ntm/internal/tmux"
 )
@@ -62,6 +65,8 @@ type Server struct {
 	version       string
 	eventBus      *events.EventBus
 	stateStore    *state.Store
+	fleet         *fleet.Fleet
+	recordingsDir string
 	server        *http.Server
 	auth          AuthConfig
 
@@ -73,6 +78,14 @@ type Server struct {
 	// memory.
 	approvalEng     *approvalpkg.Engine
 	approvalEngOnce sync.Once
+	// approvalPolicy and approvalNotifier configure approvalEng's quorum,
+	// escalation and delegation rules and where escalations are announced.
+	approvalPolicy   approvalpkg.Policy
+	approvalNotifier *notify.Notifier
+
+	// apiKeyTouched throttles last-used writes for registry API keys
+	// (key ID -> time.Time of the last recorded use).
+	apiKeyTouched sync.Map
 
 	// SSE clients
 	sseClients   map[chan events.BusEvent]struct{}
@@ -87,6 +100,10 @@ type Server struct {
 	// Job management
 	jobStore *JobStore
 
+	// pipelineScheduler fires persisted pipeline schedules while the server
+	// runs. Nil without a state store.
+	pipelineScheduler *scheduler.Scheduler
+
 	// Chi router for /api/v1
 	router chi.Router
 
@@ -102,6 +119,7 @@ type Server struct {
 
 	// Pane output streaming
 	streamManager   *tmux.StreamManager
+	turnStreams     *turnStreams
 	spawnAgents     func(context.Context, robot.SpawnOptions) (*robot.SpawnOutput, error)
 	sendAgents      func(robot.SendOptions) (*robot.SendOutput, error)
 	interruptAgents func(robot.InterruptOptions) (*robot.InterruptOutput, error)
@@ -227,6 +245,20 @@ type Config struct {
 	// no UI is mounted and non-API paths 404 as before (`ntm serve` default;
 	// `ntm web` and `ntm serve --web` set it).
 	WebUI fs.FS
+	// Fleet lists live sessions on the [[hosts]] machines alongside the local
+	// state store in /api/v1/sessions. Nil (or a fleet with no remote hosts)
+	// leaves the endpoint local-only. Ownership stays with the caller.
+	Fleet *fleet.Fleet
+	// RecordingsDir is where `ntm recording start` writes pane casts, served
+	// under /api/v1/recordings. Empty means recording.DefaultDir().
+	RecordingsDir string
+	// Approvals adds quorum, escalation and delegation rules to the approval
+	// endpoints (the [approvals] section of config.toml). The zero Policy
+	// keeps single-approver decisions.
+	Approvals approvalpkg.Policy
+	// ApprovalNotifier announces approval requests, decisions and escalation
+	// steps. Nil leaves them to the event bus and WebSocket clients.
+	ApprovalNotifier *notify.Notifier
 }
 
 const (
@@ -454,10 +486,18 @@ type Job struct {
 	Type      string                 `json:"type"`
 	Status    JobStatus              `json:"status"`
 	Progress  float64                `json:"progress,omitempty"`
+	Session   string                 `json:"session,omitempty"`
 	Result    map[string]interface{} `json:"result,omitempty"`
 	Error     string                 `json:"error,omitempty"`
 	CreatedAt string                 `json:"created_at"`
 	UpdatedAt string                 `json:"updated_at"`
+
+	// params are the original request params, kept so an interrupted job can
+	// be resumed after a restart. Never serialized: they may carry prompts.
+	params map[string]interface{}
+	// idempotencyKey is the SHA-256 of the caller-scoped Idempotency-Key the
+	// job was created under (empty when the request carried none).
+	idempotencyKey string
 }
 
 // JobStatus represents the state of a job.
@@ -469,13 +509,28 @@ const (
 	JobStatusCompleted JobStatus = "completed"
 	JobStatusFailed    JobStatus = "failed"
 	JobStatusCancelled JobStatus = "cancelled"
+	// JobStatusInterrupted marks a job that was pending or running when the
+	// server stopped and could not be resumed on the next start.
+	JobStatusInterrupted JobStatus = "interrupted"
 )
 
-// JobStore manages asynchronous jobs.
+// isTerminal reports whether no further transitions are allowed from status.
+func (st JobStatus) isTerminal() bool {
+	switch st {
+	case JobStatusCompleted, JobStatusFailed, JobStatusCancelled, JobStatusInterrupted:
+		return true
+	}
+	return false
+}
+
+// JobStore manages asynchronous jobs. It always keeps a bounded in-memory
+// copy; once attached to a state store (see Attach) every change is written
+// through to the serve_jobs table so jobs outlive the server process.
 type JobStore struct {
 	mu      sync.RWMutex
 	jobs    map[string]*Job
 	cancels map[string]context.CancelFunc
+	persist *state.Store
 }
 
 // NewJobStore creates a new job store.
@@ -486,6 +541,40 @@ func NewJobStore() *JobStore {
 	}
 }
 
+// Attach makes store the durable backing for the job store and loads the
+// persisted jobs into memory. It returns the jobs that were still pending or
+// running when the previous server stopped so the caller can reconcile them.
+func (s *JobStore) Attach(store *state.Store) ([]*Job, error) {
+	if store == nil {
+		return nil, nil
+	}
+	rows, _, err := store.ListServeJobs(state.ServeJobFilter{Limit: maxRetainedJobs})
+	if err != nil {
+		return nil, err
+	}
+	inFlight, _, err := store.ListServeJobs(state.ServeJobFilter{
+		Statuses: []string{string(JobStatusPending), string(JobStatusRunning)},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.persist = store
+	for _, row := range append(rows, inFlight...) {
+		if _, ok := s.jobs[row.ID]; ok {
+			continue
+		}
+		s.jobs[row.ID] = jobFromRow(row)
+	}
+	orphans := make([]*Job, 0, len(inFlight))
+	for _, row := range inFlight {
+		orphans = append(orphans, s.cloneJob(s.jobs[row.ID]))
+	}
+	return orphans, nil
+}
+
 // SetCancel registers the dispatch goroutine's cancel func so a user-facing
 // cancel actually stops the underlying work, not just the bookkeeping row.
 func (s *JobStore) SetCancel(id string, cancel context.CancelFunc) {
@@ -511,37 +600,87 @@ func (s *JobStore) Cancel(id string) {
 	}
 }
 
-// maxRetainedJobs bounds the job store over the server's lifetime: once the
-// map reaches this size, Create evicts the oldest terminal (completed /
-// failed / cancelled) jobs. Pending and running jobs are never evicted.
+// maxRetainedJobs bounds the in-memory job map over the server's lifetime:
+// once the map reaches this size, Create evicts the oldest terminal
+// (completed / failed / cancelled / interrupted) jobs. Pending and running
+// jobs are never evicted. Evicted jobs stay readable from the state store
+// when one is attached.
 const maxRetainedJobs = 1000
 
 // Create creates a new job.
 func (s *JobStore) Create(jobType string) *Job {
+	return s.CreateFromRequest(CreateJobRequest{Type: jobType}, "")
+}
+
+// CreateFromRequest creates a new job for req, remembering its params and the
+// hashed Idempotency-Key it arrived under.
+func (s *JobStore) CreateFromRequest(req CreateJobRequest, idempotencyKey string) *Job {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.evictTerminalLocked(maxRetainedJobs - 1)
 	id := generateRequestID()
 	now := time.Now().UTC().Format(time.RFC3339)
 	job := &Job{
-		ID:        id,
-		Type:      jobType,
-		Status:    JobStatusPending,
-		CreatedAt: now,
-		UpdatedAt: now,
+		ID:             id,
+		Type:           req.Type,
+		Status:         JobStatusPending,
+		Session:        jobSession(req),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		params:         req.Params,
+		idempotencyKey: idempotencyKey,
 	}
 	s.jobs[id] = job
+	s.persistLocked(job)
 	return s.cloneJob(job)
 }
 
-// Get retrieves a job by ID.
+// Get retrieves a job by ID, falling back to the state store for jobs that
+// were evicted from memory or predate this process.
 func (s *JobStore) Get(id string) *Job {
 	s.mu.RLock()
-	defer s.mu.RUnlock()
-	if job, ok := s.jobs[id]; ok {
+	job, ok := s.jobs[id]
+	persist := s.persist
+	if ok {
+		defer s.mu.RUnlock()
 		return s.cloneJob(job)
 	}
-	return nil
+	s.mu.RUnlock()
+	if persist == nil {
+		return nil
+	}
+	row, err := persist.GetServeJob(id)
+	if err != nil {
+		slog.Warn("serve jobs: load job failed", "job_id", id, "error", err)
+		return nil
+	}
+	if row == nil {
+		return nil
+	}
+	return jobFromRow(*row)
+}
+
+// FindByIdempotencyKey returns the job created under the hashed
+// Idempotency-Key no longer than maxAge ago, or nil. Only persisted jobs are
+// searched: the in-memory idempotency cache already covers replays within
+// one server lifetime, and maxAge should be its TTL so a key expires at the
+// same time across restarts.
+func (s *JobStore) FindByIdempotencyKey(key string, maxAge time.Duration) *Job {
+	s.mu.RLock()
+	persist := s.persist
+	s.mu.RUnlock()
+	if persist == nil || key == "" {
+		return nil
+	}
+	row, err := persist.FindServeJobByIdempotencyKey(key, time.Now().Add(-maxAge))
+	if err != nil {
+		slog.Warn("serve jobs: idempotency lookup failed", "error", err)
+		return nil
+	}
+	if row == nil {
+		return nil
+	}
+	return s.Get(row.ID)
 }
 
 // Update updates a job's status and progress.
@@ -554,8 +693,7 @@ func (s *JobStore) Update(id string, status JobStatus, progress float64, result
 	}
 	// Terminal states are final: a cancelled job must not later flip to
 	// completed/failed when its (now-cancelled) dispatch goroutine returns.
-	switch job.Status {
-	case JobStatusCompleted, JobStatusFailed, JobStatusCancelled:
+	if job.Status.isTerminal() {
 		return
 	}
 	job.Status = status
@@ -563,6 +701,23 @@ func (s *JobStore) Update(id string, status JobStatus, progress float64, result
 	job.Result = result
 	job.Error = errMsg
 	job.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	s.persistLocked(job)
+}
+
+// persistLocked writes job through to the state store. Persistence failures
+// are logged rather than surfaced: the in-memory copy stays authoritative for
+// this process. Callers must hold s.mu.
+func (s *JobStore) persistLocked(job *Job) {
+	if s.persist == nil {
+		return
+	}
+	row, err := jobToRow(job)
+	if err == nil {
+		err = s.persist.SaveServeJob(row)
+	}
+	if err != nil {
+		slog.Warn("serve jobs: persist job failed", "job_id", job.ID, "error", err)
+	}
 }
 
 // cloneJob creates a deep copy of a job
@@ -589,13 +744,82 @@ func (s *JobStore) List() []*Job {
 	for _, job := range s.jobs {
 		jobs = append(jobs, s.cloneJob(job))
 	}
+	sortJobsNewestFirst(jobs)
+	return jobs
+}
+
+// JobHistoryFilter narrows JobStore.History. Empty Types/Statuses match all.
+type JobHistoryFilter struct {
+	Types    []string
+	Statuses []JobStatus
+	Limit    int
+	Offset   int
+}
+
+// History returns one page of jobs matching filter, newest first, plus the
+// total number of matches. With a state store attached it covers every
+// persisted job; otherwise only the jobs retained in memory.
+func (s *JobStore) History(filter JobHistoryFilter) ([]*Job, int, error) {
+	s.mu.RLock()
+	persist := s.persist
+	s.mu.RUnlock()
+
+	if persist != nil {
+		statuses := make([]string, 0, len(filter.Statuses))
+		for _, st := range filter.Statuses {
+			statuses = append(statuses, string(st))
+		}
+		rows, total, err := persist.ListServeJobs(state.ServeJobFilter{
+			Types:    filter.Types,
+			Statuses: statuses,
+			Limit:    filter.Limit,
+			Offset:   filter.Offset,
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+		jobs := make([]*Job, 0, len(rows))
+		for _, row := range rows {
+			jobs = append(jobs, jobFromRow(row))
+		}
+		return jobs, total, nil
+	}
+
+	matched := make([]*Job, 0)
+	for _, job := range s.List() {
+		if len(filter.Types) > 0 && !slicesContains(filter.Types, job.Type) {
+			continue
+		}
+		if len(filter.Statuses) > 0 && !slicesContains(filter.Statuses, job.Status) {
+			continue
+		}
+		matched = append(matched, job)
+	}
+	total := len(matched)
+	start := min(max(filter.Offset, 0), total)
+	end := total
+	if filter.Limit > 0 {
+		end = min(start+filter.Limit, total)
+	}
+	return matched[start:end], total, nil
+}
+
+func slicesContains[T comparable](values []T, want T) bool {
+	for _, v := range values {
+		if v == want {
+			return true
+		}
+	}
+	return false
+}
+
+func sortJobsNewestFirst(jobs []*Job) {
 	sort.Slice(jobs, func(i, j int) bool {
 		if jobs[i].CreatedAt != jobs[j].CreatedAt {
 			return jobs[i].CreatedAt > jobs[j].CreatedAt
 		}
 		return jobs[i].ID < jobs[j].ID
 	})
-	return jobs
 }
 
 // evictTerminalLocked removes the oldest terminal jobs until at most limit
@@ -609,8 +833,7 @@ func (s *JobStore) evictTerminalLocked(limit int) {
 	type victim struct{ id, createdAt string }
 	terminal := make([]victim, 0, len(s.jobs))
 	for id, job := range s.jobs {
-		switch job.Status {
-		case JobStatusCompleted, JobStatusFailed, JobStatusCancelled:
+		if job.Status.isTerminal() {
 			terminal = append(terminal, victim{id: id, createdAt: job.CreatedAt})
 		}
 	}
@@ -629,6 +852,73 @@ func (s *JobStore) evictTerminalLocked(limit int) {
 	}
 }
 
+// jobSession extracts the target session from a job request, preferring the
+// top-level field and falling back to params["session"].
+func jobSession(req CreateJobRequest) string {
+	if req.Session != "" {
+		return req.Session
+	}
+	if session, ok := req.Params["session"].(string); ok {
+		return session
+	}
+	return ""
+}
+
+func jobToRow(job *Job) (*state.ServeJob, error) {
+	row := &state.ServeJob{
+		ID:             job.ID,
+		Type:           job.Type,
+		Status:         string(job.Status),
+		Progress:       job.Progress,
+		Session:        job.Session,
+		Error:          job.Error,
+		IdempotencyKey: job.idempotencyKey,
+	}
+	if job.params != nil {
+		raw, err := json.Marshal(job.params)
+		if err != nil {
+			return nil, fmt.Errorf("encode job params: %w", err)
+		}
+		row.ParamsJSON = string(raw)
+	}
+	if job.Result != nil {
+		raw, err := json.Marshal(job.Result)
+		if err != nil {
+			return nil, fmt.Errorf("encode job result: %w", err)
+		}
+		row.ResultJSON = string(raw)
+	}
+	var err error
+	if row.CreatedAt, err = time.Parse(time.RFC3339, job.CreatedAt); err != nil {
+		return nil, fmt.Errorf("parse job created_at: %w", err)
+	}
+	if row.UpdatedAt, err = time.Parse(time.RFC3339, job.UpdatedAt); err != nil {
+		return nil, fmt.Errorf("parse job updated_at: %w", err)
+	}
+	return row, nil
+}
+
+func jobFromRow(row state.ServeJob) *Job {
+	job := &Job{
+		ID:             row.ID,
+		Type:           row.Type,
+		Status:         JobStatus(row.Status),
+		Progress:       row.Progress,
+		Session:        row.Session,
+		Error:          row.Error,
+		CreatedAt:      row.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:      row.UpdatedAt.UTC().Format(time.RFC3339),
+		idempotencyKey: row.IdempotencyKey,
+	}
+	if row.ParamsJSON != "" {
+		_ = json.Unmarshal([]byte(row.ParamsJSON), &job.params)
+	}
+	if row.ResultJSON != "" {
+		_ = json.Unmarshal([]byte(row.ResultJSON), &job.Result)
+	}
+	return job
+}
+
 // ============================================================================
 // WebSocket Hub + Subscription Protocol
 // ============================================================================
@@ -1108,8 +1398,8 @@ func ValidateConfig(cfg Config) error {
 	}
 	cfg.Auth.Mode = mode
 
-	if mode == AuthModeAPIKey && cfg.Auth.APIKey == "" {
-		return fmt.Errorf("auth mode api_key requires --api-key")
+	if mode == AuthModeAPIKey && cfg.Auth.APIKey == "" && cfg.StateStore == nil {
+		return fmt.Errorf("auth mode api_key requires --api-key or keys issued with `ntm serve keys create`")
 	}
 	if mode == AuthModeOIDC {
 		if cfg.Auth.OIDC.Issuer == "" {
@@ -1134,6 +1424,9 @@ func ValidateConfig(cfg Config) error {
 			return fmt.Errorf("invalid public base URL %q", cfg.PublicBaseURL)
 		}
 	}
+	if err := cfg.Approvals.Validate(); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -1147,6 +1440,10 @@ func New(cfg Config) *Server {
 		version:            cfg.Version,
 		eventBus:           cfg.EventBus,
 		stateStore:         cfg.StateStore,
+		fleet:              cfg.Fleet,
+		recordingsDir:      cfg.RecordingsDir,
+		approvalPolicy:     cfg.Approvals,
+		approvalNotifier:   cfg.ApprovalNotifier,
 		auth:               cfg.Auth,
 		auditStore:         cfg.AuditStore,
 		webUI:              cfg.WebUI,
@@ -1156,11 +1453,18 @@ func New(cfg Config) *Server {
 		idempotencyStore:   NewIdempotencyStore(24 * time.Hour),
 		jobStore:           NewJobStore(),
 		wsHub:              NewWSHub(),
+		turnStreams:        newTurnStreams(),
 		spawnAgents: func(ctx context.Context, opts robot.SpawnOptions) (*robot.SpawnOutput, error) {
 			return robot.GetSpawn(ctx, opts, nil)
 		},
 		waitAgents: robot.GetWaitContext,
 	}
+	if s.stateStore != nil {
+		s.pipelineScheduler = scheduler.New(scheduler.Config{
+			Store:  s.stateStore,
+			Runner: serveScheduleRunner{s: s},
+		})
+	}
 
 	// Initialize pane output streaming
 	streamCfg := tmux.DefaultPaneStreamerConfig()
@@ -1173,6 +1477,7 @@ func New(cfg Config) *Server {
 			"ts":      event.Timestamp.UTC().Format(time.RFC3339Nano),
 			"is_full": event.IsFull,
 		})
+		s.publishTurnSegments(event)
 	}, streamCfg)
 
 	s.router = s.buildRouter()
@@ -1297,6 +1602,7 @@ func (s *Server) buildRouter() chi.Router {
 			r.With(s.RequirePermission(PermWriteSessions)).Post("/{paneIdx}/input", s.handlePaneInputV1)
 			r.With(s.RequirePermission(PermWriteSessions)).Post("/{paneIdx}/interrupt", s.handlePaneInterruptV1)
 			r.With(s.RequirePermission(PermReadSessions)).Get("/{paneIdx}/output", s.handlePaneOutputV1)
+			r.With(s.RequirePermission(PermReadSessions)).Get("/{paneIdx}/turns", s.handlePaneTurnsV1)
 			r.With(s.RequirePermission(PermReadSessions)).Get("/{paneIdx}/title", s.handleGetPaneTitleV1)
 			r.With(s.RequirePermission(PermWriteSessions)).Patch("/{paneIdx}/title", s.handleSetPaneTitleV1)
 			// Streaming endpoints
@@ -1321,6 +1627,7 @@ func (s *Server) buildRouter() chi.Router {
 		r.Route("/jobs", func(r chi.Router) {
 			r.With(s.RequirePermission(PermReadJobs)).Get("/", s.handleListJobs)
 			r.With(s.RequirePermission(PermWriteJobs)).Post("/", s.handleCreateJob)
+			r.With(s.RequirePermission(PermReadJobs)).Get("/history", s.handleJobHistory)
 			r.With(s.RequirePermission(PermReadJobs)).Get("/{id}", s.handleGetJob)
 			r.With(s.RequirePermission(PermWriteJobs)).Delete("/{id}", s.handleCancelJob)
 		})
@@ -1349,6 +1656,9 @@ func (s *Server) buildRouter() chi.Router {
 		// Accounts API - CAAM account management
 		s.registerAccountsRoutes(r)
 
+		// Pane recordings API - asciicast playback for the web UI
+		s.registerRecordingRoutes(r)
+
 		// Attention Feed API - normalized event streaming for operator agents
 		r.Route("/attention", func(r chi.Router) {
 			// SSE stream with cursor-based replay
@@ -1406,6 +1716,18 @@ func (s *Server) Start(ctx context.Context) error {
 		}
 	}
 
+	// Reload persisted async jobs and settle the ones a previous server left
+	// in flight, so clients polling /api/v1/jobs/{id} survive the restart.
+	s.restoreJobs()
+
+	// Fire cron, interval and event pipeline schedules for as long as the
+	// server runs.
+	stopScheduler := s.startPipelineScheduler(ctx)
+	defer stopScheduler()
+
+	// Escalate and time out pending approvals that nobody is looking at.
+	s.startApprovalEscalator(ctx)
+
 	// Wire WS event persistence/replay before the hub starts broadcasting so
 	// every published event is Store()d and carries a durable seq. Stopped
 	// after the hub (LIFO defers) so late drop-range flushes still land.
@@ -1951,13 +2273,19 @@ func (s *Server) authenticateRequest(r *http.Request) (map[string]interface{}, e
 	// authentication must not disable the write API.
 	switch s.auth.Mode {
 	case AuthModeAPIKey:
-		if err := s.authenticateAPIKey(r); err != nil {
-			return nil, err
+		// The shared --api-key keeps its configured role; anything else is
+		// looked up in the scoped key registry (`ntm serve keys`).
+		if err := s.authenticateAPIKey(r); err == nil {
+			return map[string]interface{}{
+				"role": string(s.auth.sharedCredentialRole()),
+				"sub":  "api-key",
+			}, nil
 		}
-		return map[string]interface{}{
-			"role": string(s.auth.sharedCredentialRole()),
-			"sub":  "api-key",
-		}, nil
+		key := extractAPIKey(r)
+		if key == "" {
+			return nil, errors.New("missing api key")
+		}
+		return s.authenticateRegistryKey(key)
 	case AuthModeOIDC:
 		return s.authenticateOIDC(r)
 	case AuthModeMTLS:
@@ -3084,6 +3412,18 @@ func (s *Server) handleSessionsV1(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	hostFilter := r.URL.Query().Get("host")
+	if hostFilter != "" {
+		if s.fleet == nil {
+			writeErrorResponse(w, http.StatusNotFound, ErrCodeNotFound, "no fleet hosts configured", nil, reqID)
+			return
+		}
+		if _, ok := s.fleet.Host(hostFilter); !ok {
+			writeErrorResponse(w, http.StatusNotFound, ErrCodeNotFound, "unknown host", map[string]interface{}{"host": hostFilter}, reqID)
+			return
+		}
+	}
+
 	sessions, err := s.stateStore.ListSessions("")
 	if err != nil {
 		writeErrorResponse(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error(), nil, reqID)
@@ -3095,10 +3435,16 @@ func (s *Server) handleSessionsV1(w http.ResponseWriter, r *http.Request) {
 		sessions = []state.Session{}
 	}
 
-	writeSuccessResponse(w, http.StatusOK, map[string]interface{}{
+	resp := map[string]interface{}{
 		"sessions": sessions,
 		"count":    len(sessions),
-	}, reqID)
+	}
+	// Live host-qualified (host:session) listing across the fleet; ?host=
+	// narrows it to one machine.
+	if s.fleet != nil && (s.fleet.HasRemoteHosts() || hostFilter != "") {
+		resp["fleet"] = s.fleet.Status(r.Context(), hostFilter)
+	}
+	writeSuccessResponse(w, http.StatusOK, resp, reqID)
 }
 
 // handleSessionV1 handles GET /api/v1/sessions/{id}.
@@ -3731,12 +4077,22 @@ func (s *Server) handlePaneInputV1(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Policy check: reject text that matches a blocked safety pattern.
-	// Graceful degradation: if policy cannot be loaded, allow through but log.
-	if p, policyErr := policy.LoadOrDefault(); policyErr != nil {
+	// Build pane target. Resolve via the pane's tmux ID (the `%N` form) so
+	// the target is base-index-independent — `<session>:<paneIdx>` looks
+	// like a pane index but tmux interprets it as a window index, which
+	// breaks on hosts with `base-index = 1` (see #141).
+	pane, ok := s.resolvePaneForRequest(w, r, sessionID, paneIdx, reqID)
+	if !ok {
+		return
+	}
+
+	// Policy check: reject text that matches a blocked safety pattern in
+	// the pane's context. Graceful degradation: if policy cannot be loaded,
+	// allow through but log.
+	if p, policyErr := policy.LoadForSession(sessionID); policyErr != nil {
 		slog.Warn("pane input policy check skipped: failed to load policy",
 			"error", policyErr, "request_id", reqID)
-	} else if match := p.Check(req.Text); match != nil && match.Action == policy.ActionBlock {
+	} else if match := p.CheckContext(req.Text, s.sessionPolicyContext(sessionID, string(pane.Type.Canonical()))); match != nil && match.Action == policy.ActionBlock {
 		slog.Warn("pane input blocked by policy",
 			"session", sessionID, "pane", paneIdx,
 			"pattern", match.Pattern, "request_id", reqID)
@@ -3744,15 +4100,6 @@ func (s *Server) handlePaneInputV1(w http.ResponseWriter, r *http.Request) {
 			fmt.Sprintf("blocked by safety policy: %s", match.Reason), nil, reqID)
 		return
 	}
-
-	// Build pane target. Resolve via the pane's tmux ID (the `%N` form) so
-	// the target is base-index-independent — `<session>:<paneIdx>` looks
-	// like a pane index but tmux interprets it as a window index, which
-	// breaks on hosts with `base-index = 1` (see #141).
-	pane, ok := s.resolvePaneForRequest(w, r, sessionID, paneIdx, reqID)
-	if !ok {
-		return
-	}
 	if err := pane.Type.ValidateAutomatedPromptDelivery(); err != nil {
 		writeErrorResponse(w, http.StatusNotImplemented, ErrCodeNotImplemented, err.Error(), map[string]interface{}{
 			"agent_type": pane.Type.Canonical().String(),
@@ -3961,16 +4308,18 @@ func (s *Server) handleStartPaneStreamV1(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	target, ok := s.resolvePaneTargetForRequest(w, r, sessionID, paneIdx, reqID)
+	pane, ok := s.resolvePaneForRequest(w, r, sessionID, paneIdx, reqID)
 	if !ok {
 		return
 	}
+	target := pane.ID
 	topic := streamTopicForTarget(target)
 
 	if err := s.streamManager.StartStream(target); err != nil {
 		writeErrorResponse(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error(), nil, reqID)
 		return
 	}
+	s.turnStreams.watch(target, sessionID, string(pane.Type))
 
 	writeSuccessResponse(w, http.StatusOK, map[string]interface{}{
 		"target":  target,
@@ -3999,6 +4348,7 @@ func (s *Server) handleStopPaneStreamV1(w http.ResponseWriter, r *http.Request)
 		return
 	}
 	s.streamManager.StopStream(target)
+	s.turnStreams.forget(target)
 
 	writeSuccessResponse(w, http.StatusOK, map[string]interface{}{
 		"target":  target,
@@ -4173,10 +4523,16 @@ func (s *Server) handleAgentSendV1(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Policy check: reject messages that match a blocked safety pattern.
-	if p, policyErr := policy.LoadOrDefault(); policyErr != nil {
+	// The agent type is known only when the request names exactly one;
+	// robot send re-checks every resolved pane in its own context.
+	agentType := ""
+	if len(req.AgentTypes) == 1 {
+		agentType = req.AgentTypes[0]
+	}
+	if p, policyErr := policy.LoadForSession(sessionID); policyErr != nil {
 		slog.Warn("agent send policy check skipped: failed to load policy",
 			"error", policyErr, "request_id", reqID)
-	} else if match := p.Check(req.Message); match != nil && match.Action == policy.ActionBlock {
+	} else if match := p.CheckContext(req.Message, s.sessionPolicyContext(sessionID, agentType)); match != nil && match.Action == policy.ActionBlock {
 		slog.Warn("agent send blocked by policy",
 			"session", sessionID, "pattern", match.Pattern, "request_id", reqID)
 		writeErrorResponse(w, http.StatusForbidden, ErrCodeForbidden,
@@ -4251,10 +4607,10 @@ func (s *Server) handleAgentInterruptV1(w http.ResponseWriter, r *http.Request)
 
 	// Policy check on the follow-up message (if any).
 	if req.Message != "" {
-		if p, policyErr := policy.LoadOrDefault(); policyErr != nil {
+		if p, policyErr := policy.LoadForSession(sessionID); policyErr != nil {
 			slog.Warn("agent interrupt policy check skipped: failed to load policy",
 				"error", policyErr, "request_id", reqID)
-		} else if match := p.Check(req.Message); match != nil && match.Action == policy.ActionBlock {
+		} else if match := p.CheckContext(req.Message, s.sessionPolicyContext(sessionID, "")); match != nil && match.Action == policy.ActionBlock {
 			slog.Warn("agent interrupt message blocked by policy",
 				"session", sessionID, "pattern", match.Pattern, "request_id", reqID)
 			writeErrorResponse(w, http.StatusForbidden, ErrCodeForbidden,
@@ -4459,7 +4815,19 @@ func (s *Server) handleCreateJob(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	job := s.jobStore.Create(req.Type)
+	// The in-memory idempotency cache does not survive a restart; the hashed
+	// key persisted with each job does, so a client retrying its POST after a
+	// restart gets the job it already created instead of a duplicate run.
+	idemKey := jobIdempotencyKey(r)
+	if existing := s.jobStore.FindByIdempotencyKey(idemKey, s.idempotencyStore.ttl); existing != nil {
+		w.Header().Set("X-Idempotent-Replay", "true")
+		writeSuccessResponse(w, http.StatusAccepted, map[string]interface{}{
+			"job": existing,
+		}, reqID)
+		return
+	}
+
+	job := s.jobStore.CreateFromRequest(req, idemKey)
 
 	// Start real job execution in background
 	go s.dispatchJob(job.ID, req)
@@ -4469,6 +4837,66 @@ func (s *Server) handleCreateJob(w http.ResponseWriter, r *http.Request) {
 	}, reqID)
 }
 
+// jobIdempotencyKey returns the SHA-256 of the caller-scoped Idempotency-Key,
+// or "" when the request carried none. Only the digest is persisted.
+func jobIdempotencyKey(r *http.Request) string {
+	key := scopedIdempotencyKey(r)
+	if key == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// handleJobHistory handles GET /api/v1/jobs/history. It pages through every
+// persisted job (not just those retained in memory), newest first, filtered by
+// comma-separated type and status query parameters.
+func (s *Server) handleJobHistory(w http.ResponseWriter, r *http.Request) {
+	reqID := requestIDFromContext(r.Context())
+	q := r.URL.Query()
+
+	limit := 50
+	offset := 0
+	if l := q.Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 {
+			limit = parsed
+			if limit > 1000 {
+				limit = 1000
+			}
+		}
+	}
+	if o := q.Get("offset"); o != "" {
+		if parsed, err := strconv.Atoi(o); err == nil && parsed >= 0 {
+			offset = parsed
+		}
+	}
+
+	filter := JobHistoryFilter{Limit: limit, Offset: offset, Types: parseCSVParam(q.Get("type"))}
+	for _, st := range parseCSVParam(q.Get("status")) {
+		filter.Statuses = append(filter.Statuses, JobStatus(st))
+	}
+
+	jobs, total, err := s.jobStore.History(filter)
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, ErrCodeInternalError, "failed to load job history", map[string]interface{}{
+			"error": err.Error(),
+		}, reqID)
+		return
+	}
+	if jobs == nil {
+		jobs = []*Job{}
+	}
+
+	writeSuccessResponse(w, http.StatusOK, map[string]interface{}{
+		"jobs":     jobs,
+		"count":    len(jobs),
+		"total":    total,
+		"offset":   offset,
+		"limit":    limit,
+		"has_more": offset+len(jobs) < total,
+	}, reqID)
+}
+
 // handleGetJob handles GET /api/v1/jobs/{id}.
 func (s *Server) handleGetJob(w http.ResponseWriter, r *http.Request) {
 	reqID := requestIDFromContext(r.Context())
@@ -4960,10 +5388,22 @@ func isValidTopic(topic string) bool {
 
 // canSubscribe checks if the client is authorized to subscribe to a topic.
 func (c *WSClient) canSubscribe(topic string) bool {
-	// Deliberate: every authenticated client may subscribe to any topic.
-	// ntm serve is a single-operator surface (auth gates the connection, not
-	// individual topics); per-topic RBAC is not part of the current design.
-	return true
+	// Auth gates the connection; the only per-topic restriction is the
+	// session scope of a registry API key. A session-scoped key may subscribe
+	// to its own sessions:/panes: topics and to nothing session-wide.
+	scope := keyScopeFromClaims(c.authClaims)
+	if scope == nil || len(scope.Sessions) == 0 {
+		return true
+	}
+	rest, ok := strings.CutPrefix(topic, "sessions:")
+	if !ok {
+		rest, ok = strings.CutPrefix(topic, "panes:")
+	}
+	if !ok {
+		return false
+	}
+	session, _, _ := strings.Cut(rest, ":")
+	return session != "*" && scope.allowsSession(session)
 }
 
 // partitionAttentionTopics separates attention topics from regular topics.
@@ -0,0 +1,183 @@
+package serve
+
+// turns.go implements GET /api/v1/sessions/{sessionId}/panes/{paneIdx}/turns,
+// which returns pane scrollback segmented into prompts, assistant text, tool
+// calls and results, code blocks, diffs and errors, and the "pane.turn" events
+// published for panes with an active output stream.
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/Dicklesworthstone/ntm/internal/events"
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+	"github.com/Dicklesworthstone/ntm/internal/turns"
+)
+
+// turnStreamLines bounds the rolling buffer segmented for incremental
+// (pipe-pane) stream events.
+const turnStreamLines = 2000
+
+// handlePaneTurnsV1 handles GET /api/v1/sessions/{sessionId}/panes/{paneIdx}/turns.
+// Query parameters: lines (1-10000, default 500), kinds (comma-separated
+// segment kinds) and limit (last N turns).
+func (s *Server) handlePaneTurnsV1(w http.ResponseWriter, r *http.Request) {
+	reqID := requestIDFromContext(r.Context())
+	sessionID := chi.URLParam(r, "sessionId")
+	paneIdxStr := chi.URLParam(r, "paneIdx")
+
+	if !validateSessionParam(w, sessionID, reqID) {
+		return
+	}
+
+	paneIdx := validatePaneIdx(w, paneIdxStr, reqID)
+	if paneIdx < 0 {
+		return
+	}
+
+	query := r.URL.Query()
+	lines := 500
+	if l := query.Get("lines"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, "invalid lines parameter", nil, reqID)
+			return
+		}
+		if n < 1 || n > 10000 {
+			writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, "lines must be 1-10000", nil, reqID)
+			return
+		}
+		lines = n
+	}
+	kinds, err := turns.ParseKinds(query.Get("kinds"))
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, err.Error(), nil, reqID)
+		return
+	}
+	limit := 0
+	if l := query.Get("limit"); l != "" {
+		if limit, err = strconv.Atoi(l); err != nil || limit < 0 {
+			writeErrorResponse(w, http.StatusBadRequest, ErrCodeBadRequest, "limit must be a non-negative integer", nil, reqID)
+			return
+		}
+	}
+
+	pane, ok := s.resolvePaneForRequest(w, r, sessionID, paneIdx, reqID)
+	if !ok {
+		return
+	}
+	output, err := tmux.CapturePaneOutputContext(r.Context(), pane.ID, lines)
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error(), nil, reqID)
+		return
+	}
+
+	agentType := string(pane.Type)
+	parsed := turns.Filter(turns.Parse(agentType, output), kinds...)
+	last := map[string]turns.Segment{}
+	for kind, seg := range turns.LastByKind(parsed) {
+		last[string(kind)] = seg
+	}
+	parsed = turns.Tail(parsed, limit)
+	if parsed == nil {
+		parsed = []turns.Turn{}
+	}
+	writeSuccessResponse(w, http.StatusOK, map[string]interface{}{
+		"pane":       pane.ID,
+		"agent_type": agentType,
+		"parser":     turns.Dialect(agentType, output),
+		"lines":      lines,
+		"turns":      parsed,
+		"last":       last,
+	}, reqID)
+}
+
+// turnStreams segments streamed pane output and reports new turn segments.
+type turnStreams struct {
+	mu      sync.Mutex
+	panes   map[string]*turnStream
+	tracker *turns.Tracker
+}
+
+// turnStream is the per-target state of a streamed pane.
+type turnStream struct {
+	session   string
+	agentType string
+	lines     []string
+}
+
+func newTurnStreams() *turnStreams {
+	return &turnStreams{panes: make(map[string]*turnStream), tracker: turns.NewTracker()}
+}
+
+// watch starts segmenting output streamed from target. The nil
+// *turnStreams of a bare Server ignores every call.
+func (t *turnStreams) watch(target, session, agentType string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.panes[target]; !ok {
+		t.panes[target] = &turnStream{session: session, agentType: agentType}
+	}
+}
+
+// forget stops segmenting target.
+func (t *turnStreams) forget(target string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	delete(t.panes, target)
+	t.mu.Unlock()
+	t.tracker.Forget(target)
+}
+
+// observe folds a stream event into the target's buffer and returns the
+// segments completed since the previous event.
+func (t *turnStreams) observe(event tmux.StreamEvent) (*turnStream, []turns.TurnSegment) {
+	if t == nil {
+		return nil, nil
+	}
+	t.mu.Lock()
+	ts, ok := t.panes[event.Target]
+	if !ok {
+		t.mu.Unlock()
+		return nil, nil
+	}
+	if event.IsFull {
+		ts.lines = append(ts.lines[:0], event.Lines...)
+	} else {
+		ts.lines = append(ts.lines, event.Lines...)
+	}
+	if n := len(ts.lines); n > turnStreamLines {
+		ts.lines = append(ts.lines[:0], ts.lines[n-turnStreamLines:]...)
+	}
+	output := strings.Join(ts.lines, "\n")
+	stream := *ts
+	t.mu.Unlock()
+	return &stream, t.tracker.Observe(event.Target, stream.agentType, output)
+}
+
+// publishTurnSegments emits a "pane.turn" WebSocket message and a
+// turn_segment bus event for each new segment of a streamed pane.
+func (s *Server) publishTurnSegments(event tmux.StreamEvent) {
+	stream, segs := s.turnStreams.observe(event)
+	for _, seg := range segs {
+		s.wsHub.Publish(streamTopicForTarget(event.Target), "pane.turn", map[string]interface{}{
+			"turn":    seg.Turn,
+			"segment": seg.Segment,
+			"ts":      event.Timestamp.UTC().Format(time.RFC3339Nano),
+		})
+		if s.eventBus != nil {
+			s.eventBus.Publish(events.NewTurnSegmentEvent(stream.session, event.Target, stream.agentType, seg.Turn,
+				string(seg.Kind), seg.Tool, seg.File, seg.Language, seg.Text))
+		}
+	}
+}
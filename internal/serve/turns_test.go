@@ -0,0 +1,65 @@
+package serve
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/Dicklesworthstone/ntm/internal/tmux"
+	"github.com/Dicklesworthstone/ntm/internal/turns"
+)
+
+func TestHandlePaneTurnsV1_BadParams(t *testing.T) {
+	srv, _ := setupTestServer(t)
+
+	for _, query := range []string{"lines=0", "lines=abc", "kinds=bogus", "limit=-1"} {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/sessions/s/panes/0/turns?"+query, nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("sessionId", "s")
+		rctx.URLParams.Add("paneIdx", "0")
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+		srv.handlePaneTurnsV1(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", query, rec.Code)
+		}
+	}
+}
+
+func TestTurnStreamsReportCompletedSegments(t *testing.T) {
+	ts := newTurnStreams()
+	event := func(full bool, lines ...string) tmux.StreamEvent {
+		return tmux.StreamEvent{Target: "%3", Lines: lines, IsFull: full}
+	}
+
+	// Unwatched targets are ignored.
+	if _, segs := ts.observe(event(true, "❯ hi")); segs != nil {
+		t.Fatalf("unwatched target reported %+v", segs)
+	}
+
+	ts.watch("%3", "proj", "cc")
+	ts.observe(event(true, "❯ run tests", "", "⏺ Bash(go test ./...)"))
+	stream, segs := ts.observe(event(false, "  ⎿  ok", "", "⏺ Done."))
+	if stream == nil || stream.session != "proj" {
+		t.Fatalf("stream = %+v", stream)
+	}
+	if len(segs) != 2 || segs[0].Kind != turns.KindToolCall || segs[1].Kind != turns.KindToolResult || segs[1].Tool != "Bash" {
+		t.Fatalf("segments = %+v", segs)
+	}
+
+	ts.forget("%3")
+	if _, segs := ts.observe(event(false, "⏺ More.")); segs != nil {
+		t.Errorf("forgotten target reported %+v", segs)
+	}
+
+	var bare *turnStreams
+	bare.watch("%1", "s", "cc")
+	if _, segs := bare.observe(event(true, "x")); segs != nil {
+		t.Error("nil turnStreams reported segments")
+	}
+}
@@ -0,0 +1,143 @@
+package state
+
+// approval_votes.go — votes and escalation steps for approvals governed by
+// quorum rules. The approval engine (internal/approval) owns the semantics;
+// these tables only make each vote and each escalation step durable and
+// claimable exactly once across processes.
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ApprovalVote is one approve vote cast toward an approval's quorum.
+type ApprovalVote struct {
+	ApprovalID string `json:"approval_id"`
+	// Principal is the identity the vote counts for: Voter, or OnBehalfOf
+	// when the voter acted under a delegation.
+	Principal  string    `json:"principal"`
+	Voter      string    `json:"voter"`
+	OnBehalfOf string    `json:"on_behalf_of,omitempty"`
+	Groups     []string  `json:"groups,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ApprovalEscalation is one escalation step that has fired.
+type ApprovalEscalation struct {
+	ApprovalID string    `json:"approval_id"`
+	Step       int       `json:"step"`
+	Notified   string    `json:"notified"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// AddApprovalVote records a vote, reporting whether it was new. A second vote
+// on the same approval by the same principal, or by the same voter acting
+// for someone else, is ignored and reports false.
+func (s *Store) AddApprovalVote(vote *ApprovalVote) (bool, error) {
+	if vote == nil || vote.ApprovalID == "" || vote.Principal == "" {
+		return false, fmt.Errorf("approval vote requires an approval id and principal")
+	}
+	if vote.CreatedAt.IsZero() {
+		vote.CreatedAt = time.Now().UTC()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, err := s.db.Exec(`
+		INSERT OR IGNORE INTO approval_votes (approval_id, principal, voter, on_behalf_of, groups, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		vote.ApprovalID, vote.Principal, vote.Voter, vote.OnBehalfOf, strings.Join(vote.Groups, ","), vote.CreatedAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("add approval vote: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("rows affected: %w", err)
+	}
+	return rows > 0, nil
+}
+
+// ListApprovalVotes returns the votes cast on an approval, oldest first.
+func (s *Store) ListApprovalVotes(approvalID string) ([]ApprovalVote, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(`
+		SELECT approval_id, principal, voter, on_behalf_of, groups, created_at
+		FROM approval_votes WHERE approval_id = ?
+		ORDER BY created_at, principal`, approvalID)
+	if err != nil {
+		return nil, fmt.Errorf("list approval votes: %w", err)
+	}
+	defer rows.Close()
+
+	var votes []ApprovalVote
+	for rows.Next() {
+		var v ApprovalVote
+		var groups string
+		if err := rows.Scan(&v.ApprovalID, &v.Principal, &v.Voter, &v.OnBehalfOf, &groups, &v.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan approval vote: %w", err)
+		}
+		if groups != "" {
+			v.Groups = strings.Split(groups, ",")
+		}
+		votes = append(votes, v)
+	}
+	return votes, rows.Err()
+}
+
+// ClaimApprovalEscalation records that an escalation step fired, reporting
+// whether this call claimed it. Only the claimant sends the step's
+// notifications.
+func (s *Store) ClaimApprovalEscalation(esc *ApprovalEscalation) (bool, error) {
+	if esc == nil || esc.ApprovalID == "" {
+		return false, fmt.Errorf("approval escalation requires an approval id")
+	}
+	if esc.CreatedAt.IsZero() {
+		esc.CreatedAt = time.Now().UTC()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, err := s.db.Exec(`
+		INSERT OR IGNORE INTO approval_escalations (approval_id, step, notified, created_at)
+		VALUES (?, ?, ?, ?)`,
+		esc.ApprovalID, esc.Step, esc.Notified, esc.CreatedAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("claim approval escalation: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("rows affected: %w", err)
+	}
+	return rows > 0, nil
+}
+
+// ListApprovalEscalations returns the escalation steps fired for an
+// approval, in step order.
+func (s *Store) ListApprovalEscalations(approvalID string) ([]ApprovalEscalation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(`
+		SELECT approval_id, step, notified, created_at
+		FROM approval_escalations WHERE approval_id = ?
+		ORDER BY step`, approvalID)
+	if err != nil {
+		return nil, fmt.Errorf("list approval escalations: %w", err)
+	}
+	defer rows.Close()
+
+	var steps []ApprovalEscalation
+	for rows.Next() {
+		var e ApprovalEscalation
+		if err := rows.Scan(&e.ApprovalID, &e.Step, &e.Notified, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan approval escalation: %w", err)
+		}
+		steps = append(steps, e)
+	}
+	return steps, rows.Err()
+}
@@ -0,0 +1,42 @@
+package state
+
+import (
+	"testing"
+	"time"
+)
+
+func TestApprovalVotesAndEscalations(t *testing.T) {
+	store := routingStateStore(t)
+	at := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	vote := &ApprovalVote{ApprovalID: "appr-1", Principal: "alice", Voter: "carol", OnBehalfOf: "alice", Groups: []string{"sre", "leads"}, CreatedAt: at}
+	if added, err := store.AddApprovalVote(vote); err != nil || !added {
+		t.Fatalf("AddApprovalVote = %v, %v; want added", added, err)
+	}
+	// The same principal cannot vote twice, even through another voter.
+	if added, err := store.AddApprovalVote(&ApprovalVote{ApprovalID: "appr-1", Principal: "alice", Voter: "alice", CreatedAt: at}); err != nil || added {
+		t.Fatalf("duplicate vote = %v, %v; want ignored", added, err)
+	}
+	if _, err := store.AddApprovalVote(&ApprovalVote{ApprovalID: "appr-1", Principal: "bob", Voter: "bob", CreatedAt: at.Add(time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+
+	votes, err := store.ListApprovalVotes("appr-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(votes) != 2 || votes[0].Voter != "carol" || len(votes[0].Groups) != 2 || votes[1].Principal != "bob" || votes[1].Groups != nil {
+		t.Fatalf("ListApprovalVotes = %+v", votes)
+	}
+
+	for i, want := range []bool{true, false} {
+		claimed, err := store.ClaimApprovalEscalation(&ApprovalEscalation{ApprovalID: "appr-1", Step: 0, Notified: "sre", CreatedAt: at})
+		if err != nil || claimed != want {
+			t.Fatalf("claim #%d = %v, %v; want %v", i, claimed, err, want)
+		}
+	}
+	steps, err := store.ListApprovalEscalations("appr-1")
+	if err != nil || len(steps) != 1 || steps[0].Notified != "sre" {
+		t.Fatalf("ListApprovalEscalations = %+v, %v", steps, err)
+	}
+}
@@ -0,0 +1,30 @@
+-- 022_serve_jobs.sql — durable async jobs for `ntm serve` (POST /api/v1/jobs).
+--
+-- The serve JobStore used to live only in process memory, so a restart lost
+-- every job together with its progress and result and pollers of
+-- /api/v1/jobs/{id} got 404s. One row per job; params_json keeps the original
+-- request params so a pipeline_run interrupted by a restart can be resumed.
+-- idempotency_key stores the SHA-256 of the caller-scoped Idempotency-Key so a
+-- retried POST after a restart maps back onto the same job.
+CREATE TABLE IF NOT EXISTS serve_jobs (
+    id              TEXT PRIMARY KEY,
+    job_type        TEXT NOT NULL,
+    status          TEXT NOT NULL,      -- pending | running | completed | failed | cancelled | interrupted
+    progress        REAL NOT NULL DEFAULT 0,
+    session_name    TEXT NOT NULL DEFAULT '',
+    params_json     TEXT NOT NULL DEFAULT '',
+    result_json     TEXT NOT NULL DEFAULT '',
+    error           TEXT NOT NULL DEFAULT '',
+    idempotency_key TEXT NOT NULL DEFAULT '',
+    created_at      TIMESTAMP NOT NULL,
+    updated_at      TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_serve_jobs_created
+    ON serve_jobs (created_at DESC, id);
+CREATE INDEX IF NOT EXISTS idx_serve_jobs_status
+    ON serve_jobs (status, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_serve_jobs_type
+    ON serve_jobs (job_type, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_serve_jobs_idempotency
+    ON serve_jobs (idempotency_key) WHERE idempotency_key != '';
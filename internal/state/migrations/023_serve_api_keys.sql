@@ -0,0 +1,24 @@
+-- 023_serve_api_keys.sql — scoped API key registry for `ntm serve`.
+--
+-- api_key auth used to accept exactly one shared secret, so every key holder
+-- got the same role. Each row here is an independently revocable key with its
+-- own serve role, optional session/project scope and expiry. Only the SHA-256
+-- of the secret is stored; key_prefix keeps the first characters so operators
+-- can tell keys apart in `ntm serve keys list`.
+CREATE TABLE IF NOT EXISTS serve_api_keys (
+    id            TEXT PRIMARY KEY,
+    name          TEXT NOT NULL DEFAULT '',
+    key_hash      TEXT NOT NULL UNIQUE,
+    key_prefix    TEXT NOT NULL DEFAULT '',
+    role          TEXT NOT NULL,              -- viewer | operator | admin
+    sessions      TEXT NOT NULL DEFAULT '',   -- comma-separated session scope ('' = any)
+    projects      TEXT NOT NULL DEFAULT '',   -- comma-separated project scope ('' = any)
+    created_at    TIMESTAMP NOT NULL,
+    expires_at    TIMESTAMP,
+    last_used_at  TIMESTAMP,
+    revoked_at    TIMESTAMP,
+    rotated_from  TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_serve_api_keys_created
+    ON serve_api_keys (created_at DESC);
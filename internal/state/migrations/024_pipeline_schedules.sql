@@ -0,0 +1,34 @@
+-- 024_pipeline_schedules.sql — persisted triggers for `ntm pipeline schedule`.
+--
+-- Each row starts a workflow file against a session on a cron expression, a
+-- fixed interval, or an event-bus event. The scheduler hosted by `ntm serve`
+-- (or `ntm pipeline schedule daemon`) re-reads this table every tick, so
+-- schedules added, paused or removed from the CLI or REST take effect without
+-- a restart. next_run_at/last_* are runtime bookkeeping owned by the scheduler.
+CREATE TABLE IF NOT EXISTS pipeline_schedules (
+    id                TEXT PRIMARY KEY,
+    name              TEXT NOT NULL UNIQUE,
+    workflow_file     TEXT NOT NULL,
+    session           TEXT NOT NULL,
+    project_dir       TEXT NOT NULL DEFAULT '',
+    variables         TEXT NOT NULL DEFAULT '',   -- JSON object ('' = none)
+    trigger_kind      TEXT NOT NULL,              -- cron | interval | event
+    cron_expr         TEXT NOT NULL DEFAULT '',
+    timezone          TEXT NOT NULL DEFAULT '',   -- IANA zone for cron ('' = local)
+    interval_seconds  INTEGER NOT NULL DEFAULT 0,
+    event_type        TEXT NOT NULL DEFAULT '',
+    event_session     TEXT NOT NULL DEFAULT '',   -- only events from this session ('' = any)
+    overlap_policy    TEXT NOT NULL DEFAULT 'skip', -- skip | queue | cancel_previous
+    jitter_seconds    INTEGER NOT NULL DEFAULT 0,
+    paused            INTEGER NOT NULL DEFAULT 0,
+    next_run_at       TIMESTAMP,
+    last_fired_at     TIMESTAMP,
+    last_run_id       TEXT NOT NULL DEFAULT '',
+    last_error        TEXT NOT NULL DEFAULT '',
+    queued            INTEGER NOT NULL DEFAULT 0,
+    created_at        TIMESTAMP NOT NULL,
+    updated_at        TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_pipeline_schedules_event
+    ON pipeline_schedules (event_type) WHERE trigger_kind = 'event';
@@ -0,0 +1,29 @@
+-- 025_approval_votes.sql — native quorum and escalation bookkeeping for approvals.
+--
+-- approval_votes records every approve vote cast on a request governed by an
+-- [approvals] rule. principal is the identity the vote counts for: the voter
+-- itself, or the away approver named in on_behalf_of when the voter holds a
+-- delegation. The primary key makes a second vote by the same principal a
+-- no-op across processes. groups lists the quorum groups the principal was
+-- eligible for when the vote was cast (comma-separated).
+--
+-- approval_escalations records each escalation step that has fired. The
+-- primary key lets exactly one process claim a step, so a group is notified
+-- once even when the CLI and `ntm serve` sweep the same request.
+CREATE TABLE IF NOT EXISTS approval_votes (
+    approval_id   TEXT NOT NULL,
+    principal     TEXT NOT NULL,
+    voter         TEXT NOT NULL,
+    on_behalf_of  TEXT NOT NULL DEFAULT '',
+    groups        TEXT NOT NULL DEFAULT '',
+    created_at    TIMESTAMP NOT NULL,
+    PRIMARY KEY (approval_id, principal)
+);
+
+CREATE TABLE IF NOT EXISTS approval_escalations (
+    approval_id   TEXT NOT NULL,
+    step          INTEGER NOT NULL,
+    notified      TEXT NOT NULL,               -- group notified by the step
+    created_at    TIMESTAMP NOT NULL,
+    PRIMARY KEY (approval_id, step)
+);
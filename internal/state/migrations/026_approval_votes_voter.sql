@@ -0,0 +1,13 @@
+-- 026_approval_votes_voter.sql — one vote per person on each approval.
+--
+-- 025 keyed votes by principal only, so a voter could approve as themselves
+-- and again on behalf of someone who delegated to them, meeting a
+-- two-person quorum alone. Keep each voter's first vote and make any further
+-- vote by the same voter on the same approval a no-op.
+DELETE FROM approval_votes
+WHERE rowid NOT IN (
+    SELECT MIN(rowid) FROM approval_votes GROUP BY approval_id, voter
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_approval_votes_voter
+    ON approval_votes (approval_id, voter);
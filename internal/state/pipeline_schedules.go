@@ -0,0 +1,223 @@
+package state
+
+// pipeline_schedules.go — persisted pipeline triggers. Rows are managed by
+// `ntm pipeline schedule` and /api/v1/pipelines/schedules, and consumed by the
+// scheduler hosted in `ntm serve`.
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// PipelineSchedule is one persisted trigger that starts WorkflowFile against
+// Session. Exactly one of CronExpr, IntervalSeconds or EventType is meaningful,
+// selected by TriggerKind. The fields after Paused are runtime bookkeeping the
+// scheduler updates through UpdatePipelineScheduleRuntime.
+type PipelineSchedule struct {
+	ID              string                 `json:"id"`
+	Name            string                 `json:"name"`
+	WorkflowFile    string                 `json:"workflow_file"`
+	Session         string                 `json:"session"`
+	ProjectDir      string                 `json:"project_dir,omitempty"`
+	Variables       map[string]interface{} `json:"variables,omitempty"`
+	TriggerKind     string                 `json:"trigger_kind"`
+	CronExpr        string                 `json:"cron,omitempty"`
+	Timezone        string                 `json:"timezone,omitempty"`
+	IntervalSeconds int64                  `json:"interval_seconds,omitempty"`
+	EventType       string                 `json:"event_type,omitempty"`
+	EventSession    string                 `json:"event_session,omitempty"`
+	OverlapPolicy   string                 `json:"overlap_policy"`
+	JitterSeconds   int64                  `json:"jitter_seconds,omitempty"`
+	Paused          bool                   `json:"paused"`
+	NextRunAt       *time.Time             `json:"next_run_at,omitempty"`
+	LastFiredAt     *time.Time             `json:"last_fired_at,omitempty"`
+	LastRunID       string                 `json:"last_run_id,omitempty"`
+	LastError       string                 `json:"last_error,omitempty"`
+	Queued          int                    `json:"queued,omitempty"`
+	CreatedAt       time.Time              `json:"created_at"`
+	UpdatedAt       time.Time              `json:"updated_at"`
+}
+
+// PipelineScheduleRuntime is the scheduler-owned part of a schedule row.
+type PipelineScheduleRuntime struct {
+	NextRunAt   *time.Time
+	LastFiredAt *time.Time
+	LastRunID   string
+	LastError   string
+	Queued      int
+}
+
+const pipelineScheduleColumns = `id, name, workflow_file, session, project_dir, variables,
+	trigger_kind, cron_expr, timezone, interval_seconds, event_type, event_session,
+	overlap_policy, jitter_seconds, paused, next_run_at, last_fired_at, last_run_id,
+	last_error, queued, created_at, updated_at`
+
+// CreatePipelineSchedule inserts a new schedule row.
+func (s *Store) CreatePipelineSchedule(sched *PipelineSchedule) error {
+	if sched == nil || sched.ID == "" || sched.Name == "" {
+		return fmt.Errorf("pipeline schedule requires an id and name")
+	}
+	if sched.WorkflowFile == "" || sched.Session == "" || sched.TriggerKind == "" {
+		return fmt.Errorf("pipeline schedule %s requires a workflow file, session and trigger", sched.Name)
+	}
+	vars, err := encodeScheduleVariables(sched.Variables)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	if sched.CreatedAt.IsZero() {
+		sched.CreatedAt = now
+	}
+	sched.UpdatedAt = sched.CreatedAt
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.db.Exec(`
+		INSERT INTO pipeline_schedules (`+pipelineScheduleColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		sched.ID, sched.Name, sched.WorkflowFile, sched.Session, sched.ProjectDir, vars,
+		sched.TriggerKind, sched.CronExpr, sched.Timezone, sched.IntervalSeconds, sched.EventType, sched.EventSession,
+		sched.OverlapPolicy, sched.JitterSeconds, sched.Paused, nullTimePtr(sched.NextRunAt), nullTimePtr(sched.LastFiredAt),
+		sched.LastRunID, sched.LastError, sched.Queued, sched.CreatedAt, sched.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("create pipeline schedule: %w", err)
+	}
+	return nil
+}
+
+// GetPipelineSchedule returns the schedule whose ID or name is ref, or nil if
+// none exists.
+func (s *Store) GetPipelineSchedule(ref string) (*PipelineSchedule, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	sched, err := scanPipelineSchedule(s.db.QueryRow(`
+		SELECT `+pipelineScheduleColumns+` FROM pipeline_schedules
+		WHERE id = ? OR name = ? ORDER BY id = ? DESC LIMIT 1`, ref, ref, ref))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get pipeline schedule: %w", err)
+	}
+	return sched, nil
+}
+
+// ListPipelineSchedules returns every schedule ordered by name.
+func (s *Store) ListPipelineSchedules() ([]PipelineSchedule, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	rows, err := s.db.Query(`SELECT ` + pipelineScheduleColumns + ` FROM pipeline_schedules ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("list pipeline schedules: %w", err)
+	}
+	defer rows.Close()
+
+	var out []PipelineSchedule
+	for rows.Next() {
+		sched, err := scanPipelineSchedule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan pipeline schedule: %w", err)
+		}
+		out = append(out, *sched)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate pipeline schedules: %w", err)
+	}
+	return out, nil
+}
+
+// SetPipelineSchedulePaused pauses or resumes a schedule. Resuming clears
+// next_run_at so the scheduler recomputes it from the resume time instead of
+// firing for every slot missed while paused.
+func (s *Store) SetPipelineSchedulePaused(id string, paused bool, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	query := `UPDATE pipeline_schedules SET paused = ?, updated_at = ? WHERE id = ?`
+	if !paused {
+		query = `UPDATE pipeline_schedules SET paused = ?, updated_at = ?, next_run_at = NULL WHERE id = ?`
+	}
+	res, err := s.db.Exec(query, paused, at, id)
+	if err != nil {
+		return fmt.Errorf("update pipeline schedule: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("pipeline schedule %q not found", id)
+	}
+	return nil
+}
+
+// UpdatePipelineScheduleRuntime records the scheduler's bookkeeping for id.
+func (s *Store) UpdatePipelineScheduleRuntime(id string, rt PipelineScheduleRuntime, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.db.Exec(`
+		UPDATE pipeline_schedules
+		SET next_run_at = ?, last_fired_at = ?, last_run_id = ?, last_error = ?, queued = ?, updated_at = ?
+		WHERE id = ?`,
+		nullTimePtr(rt.NextRunAt), nullTimePtr(rt.LastFiredAt), rt.LastRunID, rt.LastError, rt.Queued, at, id)
+	if err != nil {
+		return fmt.Errorf("update pipeline schedule runtime: %w", err)
+	}
+	return nil
+}
+
+// DeletePipelineSchedule removes a schedule. An unknown ID is an error.
+func (s *Store) DeletePipelineSchedule(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res, err := s.db.Exec(`DELETE FROM pipeline_schedules WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("delete pipeline schedule: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("pipeline schedule %q not found", id)
+	}
+	return nil
+}
+
+// Runtime returns the scheduler-owned fields of sched.
+func (sched *PipelineSchedule) Runtime() PipelineScheduleRuntime {
+	return PipelineScheduleRuntime{
+		NextRunAt:   sched.NextRunAt,
+		LastFiredAt: sched.LastFiredAt,
+		LastRunID:   sched.LastRunID,
+		LastError:   sched.LastError,
+		Queued:      sched.Queued,
+	}
+}
+
+func encodeScheduleVariables(vars map[string]interface{}) (string, error) {
+	if len(vars) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(vars)
+	if err != nil {
+		return "", fmt.Errorf("encode pipeline schedule variables: %w", err)
+	}
+	return string(data), nil
+}
+
+func scanPipelineSchedule(row rowScanner) (*PipelineSchedule, error) {
+	var (
+		sched              PipelineSchedule
+		vars               string
+		nextRun, lastFired sql.NullTime
+	)
+	if err := row.Scan(&sched.ID, &sched.Name, &sched.WorkflowFile, &sched.Session, &sched.ProjectDir, &vars,
+		&sched.TriggerKind, &sched.CronExpr, &sched.Timezone, &sched.IntervalSeconds, &sched.EventType, &sched.EventSession,
+		&sched.OverlapPolicy, &sched.JitterSeconds, &sched.Paused, &nextRun, &lastFired, &sched.LastRunID,
+		&sched.LastError, &sched.Queued, &sched.CreatedAt, &sched.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if vars != "" {
+		if err := json.Unmarshal([]byte(vars), &sched.Variables); err != nil {
+			return nil, fmt.Errorf("decode variables for schedule %s: %w", sched.ID, err)
+		}
+	}
+	sched.NextRunAt = timePtrFromNull(nextRun)
+	sched.LastFiredAt = timePtrFromNull(lastFired)
+	return &sched, nil
+}
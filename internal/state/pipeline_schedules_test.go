@@ -0,0 +1,81 @@
+package state
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPipelineSchedules_CRUD(t *testing.T) {
+	store := routingStateStore(t)
+
+	if got, err := store.GetPipelineSchedule("missing"); err != nil || got != nil {
+		t.Fatalf("GetPipelineSchedule(missing) = %+v, %v; want nil, nil", got, err)
+	}
+
+	created := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	sched := &PipelineSchedule{
+		ID:              "sched_1",
+		Name:            "nightly",
+		WorkflowFile:    "audit.yaml",
+		Session:         "proj",
+		Variables:       map[string]interface{}{"depth": "full"},
+		TriggerKind:     "interval",
+		IntervalSeconds: 3600,
+		OverlapPolicy:   "queue",
+		CreatedAt:       created,
+		UpdatedAt:       created,
+	}
+	if err := store.CreatePipelineSchedule(sched); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if err := store.CreatePipelineSchedule(&PipelineSchedule{ID: "sched_2", Name: "nightly", WorkflowFile: "x", TriggerKind: "interval"}); err == nil {
+		t.Fatal("duplicate name must be rejected")
+	}
+
+	byName, err := store.GetPipelineSchedule("nightly")
+	if err != nil || byName == nil || byName.ID != "sched_1" {
+		t.Fatalf("get by name = %+v, %v", byName, err)
+	}
+	if byName.Variables["depth"] != "full" || byName.IntervalSeconds != 3600 || byName.OverlapPolicy != "queue" {
+		t.Fatalf("round trip mismatch: %+v", byName)
+	}
+
+	next := created.Add(time.Hour)
+	if err := store.UpdatePipelineScheduleRuntime("sched_1", PipelineScheduleRuntime{
+		NextRunAt:   &next,
+		LastFiredAt: &created,
+		LastRunID:   "run-1",
+		Queued:      2,
+	}, created); err != nil {
+		t.Fatalf("update runtime: %v", err)
+	}
+	got, _ := store.GetPipelineSchedule("sched_1")
+	if got.NextRunAt == nil || !got.NextRunAt.Equal(next) || got.LastRunID != "run-1" || got.Queued != 2 {
+		t.Fatalf("runtime = %+v", got.Runtime())
+	}
+
+	if err := store.SetPipelineSchedulePaused("sched_1", true, created); err != nil {
+		t.Fatalf("pause: %v", err)
+	}
+	if err := store.SetPipelineSchedulePaused("sched_1", false, created); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	got, _ = store.GetPipelineSchedule("sched_1")
+	if got.Paused || got.NextRunAt != nil {
+		t.Fatalf("resume must clear next_run_at so the schedule is re-planned: %+v", got)
+	}
+	if err := store.SetPipelineSchedulePaused("nope", true, created); err == nil {
+		t.Fatal("pausing an unknown schedule must error")
+	}
+
+	list, err := store.ListPipelineSchedules()
+	if err != nil || len(list) != 1 {
+		t.Fatalf("list = %+v, %v", list, err)
+	}
+	if err := store.DeletePipelineSchedule("sched_1"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := store.DeletePipelineSchedule("sched_1"); err == nil {
+		t.Fatal("deleting twice must error")
+	}
+}
@@ -0,0 +1,250 @@
+package state
+
+// serve_api_keys.go — registry of scoped API keys for `ntm serve`. Rows are
+// created and managed by `ntm serve keys` and consulted by the server's
+// api_key authentication on every request.
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ServeAPIKey is one registered API key. KeyHash is the hex SHA-256 of the
+// secret; the secret itself is never stored. Empty Sessions/Projects mean the
+// key is not scoped along that dimension.
+type ServeAPIKey struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name,omitempty"`
+	KeyHash     string     `json:"-"`
+	KeyPrefix   string     `json:"key_prefix,omitempty"`
+	Role        string     `json:"role"`
+	Sessions    []string   `json:"sessions,omitempty"`
+	Projects    []string   `json:"projects,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+	RotatedFrom string     `json:"rotated_from,omitempty"`
+}
+
+// Active reports whether the key is neither revoked nor expired at now.
+func (k *ServeAPIKey) Active(now time.Time) bool {
+	if k == nil || k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
+
+const serveAPIKeyColumns = `id, name, key_hash, key_prefix, role, sessions, projects,
+	created_at, expires_at, last_used_at, revoked_at, rotated_from`
+
+// CreateServeAPIKey inserts a new key row.
+func (s *Store) CreateServeAPIKey(key *ServeAPIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return insertServeAPIKey(s.db, key)
+}
+
+// GetServeAPIKey returns the key with the given ID, or nil if none exists.
+func (s *Store) GetServeAPIKey(id string) (*ServeAPIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, err := scanServeAPIKey(s.db.QueryRow(`SELECT `+serveAPIKeyColumns+` FROM serve_api_keys WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get serve api key: %w", err)
+	}
+	return key, nil
+}
+
+// GetServeAPIKeyByHash returns the key whose secret hashes to keyHash, or nil.
+// Revoked and expired keys are returned too; callers decide via Active.
+func (s *Store) GetServeAPIKeyByHash(keyHash string) (*ServeAPIKey, error) {
+	if keyHash == "" {
+		return nil, nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, err := scanServeAPIKey(s.db.QueryRow(`SELECT `+serveAPIKeyColumns+` FROM serve_api_keys WHERE key_hash = ?`, keyHash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get serve api key by hash: %w", err)
+	}
+	return key, nil
+}
+
+// ListServeAPIKeys returns registered keys, newest first. Revoked keys are
+// included only when includeRevoked is set.
+func (s *Store) ListServeAPIKeys(includeRevoked bool) ([]ServeAPIKey, error) {
+	query := `SELECT ` + serveAPIKeyColumns + ` FROM serve_api_keys`
+	if !includeRevoked {
+		query += ` WHERE revoked_at IS NULL`
+	}
+	query += ` ORDER BY created_at DESC, id`
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("list serve api keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []ServeAPIKey
+	for rows.Next() {
+		key, err := scanServeAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan serve api key: %w", err)
+		}
+		keys = append(keys, *key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate serve api keys: %w", err)
+	}
+	return keys, nil
+}
+
+// CountActiveServeAPIKeys reports how many keys are neither revoked nor
+// expired at now.
+func (s *Store) CountActiveServeAPIKeys(now time.Time) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var n int
+	err := s.db.QueryRow(`
+		SELECT COUNT(*) FROM serve_api_keys
+		WHERE revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)`, now).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("count serve api keys: %w", err)
+	}
+	return n, nil
+}
+
+// RevokeServeAPIKey marks a key revoked. Revoking an already-revoked key is a
+// no-op; an unknown ID is an error.
+func (s *Store) RevokeServeAPIKey(id string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return revokeServeAPIKey(s.db, id, at)
+}
+
+// RotateServeAPIKey atomically revokes oldID and inserts replacement, which
+// records oldID in RotatedFrom.
+func (s *Store) RotateServeAPIKey(oldID string, replacement *ServeAPIKey, at time.Time) error {
+	if replacement == nil {
+		return fmt.Errorf("rotate serve api key: replacement required")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("rotate serve api key: begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := revokeServeAPIKey(tx, oldID, at); err != nil {
+		return err
+	}
+	replacement.RotatedFrom = oldID
+	if err := insertServeAPIKey(tx, replacement); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("rotate serve api key: commit: %w", err)
+	}
+	return nil
+}
+
+// TouchServeAPIKey records the key's last successful use.
+func (s *Store) TouchServeAPIKey(id string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.db.Exec(`UPDATE serve_api_keys SET last_used_at = ? WHERE id = ?`, at, id); err != nil {
+		return fmt.Errorf("touch serve api key: %w", err)
+	}
+	return nil
+}
+
+func insertServeAPIKey(db sqlExecer, key *ServeAPIKey) error {
+	if key == nil || key.ID == "" || key.KeyHash == "" {
+		return fmt.Errorf("serve api key requires an id and key hash")
+	}
+	if key.Role == "" {
+		return fmt.Errorf("serve api key %s requires a role", key.ID)
+	}
+	if key.CreatedAt.IsZero() {
+		key.CreatedAt = time.Now().UTC()
+	}
+	_, err := db.Exec(`
+		INSERT INTO serve_api_keys (`+serveAPIKeyColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		key.ID, key.Name, key.KeyHash, key.KeyPrefix, key.Role,
+		strings.Join(key.Sessions, ","), strings.Join(key.Projects, ","),
+		key.CreatedAt, nullTimePtr(key.ExpiresAt), nullTimePtr(key.LastUsedAt), nullTimePtr(key.RevokedAt),
+		key.RotatedFrom)
+	if err != nil {
+		return fmt.Errorf("create serve api key: %w", err)
+	}
+	return nil
+}
+
+func revokeServeAPIKey(db sqlExecer, id string, at time.Time) error {
+	res, err := db.Exec(`UPDATE serve_api_keys SET revoked_at = COALESCE(revoked_at, ?) WHERE id = ?`, at, id)
+	if err != nil {
+		return fmt.Errorf("revoke serve api key: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("serve api key %q not found", id)
+	}
+	return nil
+}
+
+func scanServeAPIKey(row rowScanner) (*ServeAPIKey, error) {
+	var (
+		key                          ServeAPIKey
+		sessions, projects           string
+		expiresAt, lastUsed, revoked sql.NullTime
+	)
+	if err := row.Scan(&key.ID, &key.Name, &key.KeyHash, &key.KeyPrefix, &key.Role, &sessions, &projects,
+		&key.CreatedAt, &expiresAt, &lastUsed, &revoked, &key.RotatedFrom); err != nil {
+		return nil, err
+	}
+	key.Sessions = splitNonEmpty(sessions)
+	key.Projects = splitNonEmpty(projects)
+	key.ExpiresAt = timePtrFromNull(expiresAt)
+	key.LastUsedAt = timePtrFromNull(lastUsed)
+	key.RevokedAt = timePtrFromNull(revoked)
+	return &key, nil
+}
+
+func nullTimePtr(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
+
+func timePtrFromNull(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	v := t.Time
+	return &v
+}
+
+func splitNonEmpty(raw string) []string {
+	var out []string
+	for _, part := range strings.Split(raw, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
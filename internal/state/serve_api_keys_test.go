@@ -0,0 +1,84 @@
+package state
+
+import (
+	"testing"
+	"time"
+)
+
+func TestServeAPIKeys_LifecycleAndRotation(t *testing.T) {
+	store := routingStateStore(t)
+
+	if got, err := store.GetServeAPIKeyByHash("nope"); err != nil || got != nil {
+		t.Fatalf("GetServeAPIKeyByHash(missing) = %+v, %v; want nil, nil", got, err)
+	}
+
+	created := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	expires := created.Add(time.Hour)
+	key := &ServeAPIKey{
+		ID:        "key_a",
+		Name:      "ci",
+		KeyHash:   "hash-a",
+		KeyPrefix: "ntm_aaaaaaaa",
+		Role:      "operator",
+		Sessions:  []string{"proj", "other"},
+		CreatedAt: created,
+		ExpiresAt: &expires,
+	}
+	if err := store.CreateServeAPIKey(key); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if err := store.CreateServeAPIKey(&ServeAPIKey{ID: "key_dup", KeyHash: "hash-a", Role: "viewer"}); err == nil {
+		t.Fatal("duplicate key hash must be rejected")
+	}
+
+	got, err := store.GetServeAPIKeyByHash("hash-a")
+	if err != nil || got == nil {
+		t.Fatalf("get by hash: %+v, %v", got, err)
+	}
+	if got.Role != "operator" || len(got.Sessions) != 2 || got.Sessions[1] != "other" || len(got.Projects) != 0 {
+		t.Fatalf("round trip mismatch: %+v", got)
+	}
+	if !got.Active(created.Add(time.Minute)) || got.Active(expires) {
+		t.Fatalf("Active must honour expiry at %v", expires)
+	}
+	if n, err := store.CountActiveServeAPIKeys(created); err != nil || n != 1 {
+		t.Fatalf("CountActiveServeAPIKeys = %d, %v", n, err)
+	}
+
+	used := created.Add(5 * time.Minute)
+	if err := store.TouchServeAPIKey("key_a", used); err != nil {
+		t.Fatalf("touch: %v", err)
+	}
+
+	rotatedAt := created.Add(10 * time.Minute)
+	replacement := &ServeAPIKey{ID: "key_b", KeyHash: "hash-b", Role: "operator", CreatedAt: rotatedAt}
+	if err := store.RotateServeAPIKey("key_a", replacement, rotatedAt); err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	old, _ := store.GetServeAPIKey("key_a")
+	if old == nil || old.RevokedAt == nil || !old.RevokedAt.Equal(rotatedAt) || old.LastUsedAt == nil || !old.LastUsedAt.Equal(used) {
+		t.Fatalf("old key after rotate = %+v", old)
+	}
+	if fresh, _ := store.GetServeAPIKey("key_b"); fresh == nil || fresh.RotatedFrom != "key_a" {
+		t.Fatalf("replacement = %+v", fresh)
+	}
+
+	active, err := store.ListServeAPIKeys(false)
+	if err != nil || len(active) != 1 || active[0].ID != "key_b" {
+		t.Fatalf("ListServeAPIKeys(active) = %+v, %v", active, err)
+	}
+	if all, _ := store.ListServeAPIKeys(true); len(all) != 2 {
+		t.Fatalf("ListServeAPIKeys(all) = %+v", all)
+	}
+
+	if err := store.RevokeServeAPIKey("missing", rotatedAt); err == nil {
+		t.Fatal("revoking an unknown key must error")
+	}
+	// Rotating a missing key must not leave the replacement behind.
+	if err := store.RotateServeAPIKey("missing", &ServeAPIKey{ID: "key_c", KeyHash: "hash-c", Role: "viewer"}, rotatedAt); err == nil {
+		t.Fatal("rotating an unknown key must error")
+	}
+	if orphan, _ := store.GetServeAPIKey("key_c"); orphan != nil {
+		t.Fatalf("failed rotation left replacement %+v", orphan)
+	}
+}
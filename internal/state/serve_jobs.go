@@ -0,0 +1,222 @@
+package state
+
+// serve_jobs.go — durable rows behind the `ntm serve` Jobs API. The serve
+// JobStore keeps a hot in-memory copy and writes through to this table so a
+// server restart neither loses jobs nor 404s clients polling /api/v1/jobs/{id}.
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ServeJob is the persisted form of one serve async job. ParamsJSON and
+// ResultJSON carry the JSON-encoded request params and result maps; the
+// serve package owns their shape.
+type ServeJob struct {
+	ID             string    `json:"id"`
+	Type           string    `json:"type"`
+	Status         string    `json:"status"`
+	Progress       float64   `json:"progress"`
+	Session        string    `json:"session,omitempty"`
+	ParamsJSON     string    `json:"params_json,omitempty"`
+	ResultJSON     string    `json:"result_json,omitempty"`
+	Error          string    `json:"error,omitempty"`
+	IdempotencyKey string    `json:"idempotency_key,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// ServeJobFilter narrows ListServeJobs. Empty Types/Statuses match every
+// value. Limit <= 0 means no limit.
+type ServeJobFilter struct {
+	Types    []string
+	Statuses []string
+	Limit    int
+	Offset   int
+}
+
+const serveJobColumns = `id, job_type, status, progress, session_name, params_json, result_json,
+	error, idempotency_key, created_at, updated_at`
+
+// SaveServeJob upserts a serve job row.
+func (s *Store) SaveServeJob(job *ServeJob) error {
+	if job == nil || job.ID == "" {
+		return fmt.Errorf("serve job requires an id")
+	}
+	if job.Type == "" || job.Status == "" {
+		return fmt.Errorf("serve job %s requires a type and status", job.ID)
+	}
+	now := time.Now().UTC()
+	if job.CreatedAt.IsZero() {
+		job.CreatedAt = now
+	}
+	if job.UpdatedAt.IsZero() {
+		job.UpdatedAt = now
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.db.Exec(`
+		INSERT INTO serve_jobs (`+serveJobColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			status = excluded.status,
+			progress = excluded.progress,
+			session_name = excluded.session_name,
+			params_json = excluded.params_json,
+			result_json = excluded.result_json,
+			error = excluded.error,
+			idempotency_key = excluded.idempotency_key,
+			updated_at = excluded.updated_at`,
+		job.ID, job.Type, job.Status, job.Progress, job.Session, job.ParamsJSON, job.ResultJSON,
+		job.Error, job.IdempotencyKey, job.CreatedAt, job.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("save serve job: %w", err)
+	}
+	return nil
+}
+
+// GetServeJob returns the job with the given ID, or nil if it does not exist.
+func (s *Store) GetServeJob(id string) (*ServeJob, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	job, err := scanServeJob(s.db.QueryRow(`SELECT `+serveJobColumns+` FROM serve_jobs WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get serve job: %w", err)
+	}
+	return job, nil
+}
+
+// FindServeJobByIdempotencyKey returns the newest job recorded under key at
+// or after since, or nil if none exists. An empty key never matches; a zero
+// since applies no age limit.
+func (s *Store) FindServeJobByIdempotencyKey(key string, since time.Time) (*ServeJob, error) {
+	if key == "" {
+		return nil, nil
+	}
+	query := `SELECT ` + serveJobColumns + ` FROM serve_jobs WHERE idempotency_key = ?`
+	args := []interface{}{key}
+	if !since.IsZero() {
+		query += ` AND created_at >= ?`
+		args = append(args, since.UTC())
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	job, err := scanServeJob(s.db.QueryRow(query+` ORDER BY created_at DESC, id LIMIT 1`, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("find serve job by idempotency key: %w", err)
+	}
+	return job, nil
+}
+
+// ListServeJobs returns jobs matching filter, newest first, together with the
+// total number of matching rows (ignoring Limit/Offset) for pagination.
+func (s *Store) ListServeJobs(filter ServeJobFilter) ([]ServeJob, int, error) {
+	var (
+		where []string
+		args  []interface{}
+	)
+	if len(filter.Types) > 0 {
+		where = append(where, "job_type IN ("+placeholders(len(filter.Types))+")")
+		for _, t := range filter.Types {
+			args = append(args, t)
+		}
+	}
+	if len(filter.Statuses) > 0 {
+		where = append(where, "status IN ("+placeholders(len(filter.Statuses))+")")
+		for _, st := range filter.Statuses {
+			args = append(args, st)
+		}
+	}
+	clause := ""
+	if len(where) > 0 {
+		clause = " WHERE " + strings.Join(where, " AND ")
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var total int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM serve_jobs`+clause, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count serve jobs: %w", err)
+	}
+
+	query := `SELECT ` + serveJobColumns + ` FROM serve_jobs` + clause + ` ORDER BY created_at DESC, id`
+	pageArgs := append([]interface{}{}, args...)
+	if filter.Limit > 0 {
+		query += ` LIMIT ? OFFSET ?`
+		pageArgs = append(pageArgs, filter.Limit, max(filter.Offset, 0))
+	} else if filter.Offset > 0 {
+		query += ` LIMIT -1 OFFSET ?`
+		pageArgs = append(pageArgs, filter.Offset)
+	}
+	rows, err := s.db.Query(query, pageArgs...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list serve jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []ServeJob
+	for rows.Next() {
+		job, err := scanServeJob(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("scan serve job: %w", err)
+		}
+		jobs = append(jobs, *job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("iterate serve jobs: %w", err)
+	}
+	return jobs, total, nil
+}
+
+// PruneServeJobs deletes terminal jobs last updated before the cutoff and
+// reports how many rows were removed. Pending, running and interrupted jobs
+// are kept so nothing a client may still be waiting on disappears.
+func (s *Store) PruneServeJobs(before time.Time) (int64, error) {
+	if before.IsZero() {
+		return 0, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res, err := s.db.Exec(`
+		DELETE FROM serve_jobs
+		WHERE updated_at < ? AND status IN ('completed', 'failed', 'cancelled')`, before)
+	if err != nil {
+		return 0, fmt.Errorf("prune serve jobs: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, nil
+	}
+	return n, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanServeJob(row rowScanner) (*ServeJob, error) {
+	var job ServeJob
+	if err := row.Scan(&job.ID, &job.Type, &job.Status, &job.Progress, &job.Session, &job.ParamsJSON,
+		&job.ResultJSON, &job.Error, &job.IdempotencyKey, &job.CreatedAt, &job.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func placeholders(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
@@ -0,0 +1,113 @@
+package state
+
+import (
+	"testing"
+	"time"
+)
+
+func TestServeJobs_RoundTripAndUpsert(t *testing.T) {
+	store := routingStateStore(t)
+
+	got, err := store.GetServeJob("missing")
+	if err != nil || got != nil {
+		t.Fatalf("GetServeJob(missing) = %+v, %v; want nil, nil", got, err)
+	}
+
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	job := &ServeJob{
+		ID:             "job-1",
+		Type:           "pipeline_run",
+		Status:         "running",
+		Session:        "proj",
+		ParamsJSON:     `{"workflow_file":"w.yaml"}`,
+		IdempotencyKey: "abc",
+		CreatedAt:      created,
+		UpdatedAt:      created,
+	}
+	if err := store.SaveServeJob(job); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	job.Status = "completed"
+	job.Progress = 100
+	job.ResultJSON = `{"run_id":"r1"}`
+	job.UpdatedAt = created.Add(time.Minute)
+	if err := store.SaveServeJob(job); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+
+	got, err = store.GetServeJob("job-1")
+	if err != nil || got == nil {
+		t.Fatalf("get: %+v, %v", got, err)
+	}
+	if got.Status != "completed" || got.Progress != 100 || got.ResultJSON != `{"run_id":"r1"}` {
+		t.Fatalf("upsert mismatch: %+v", got)
+	}
+	if !got.CreatedAt.Equal(created) || !got.UpdatedAt.Equal(created.Add(time.Minute)) {
+		t.Fatalf("timestamps = %v / %v", got.CreatedAt, got.UpdatedAt)
+	}
+
+	byKey, err := store.FindServeJobByIdempotencyKey("abc", created)
+	if err != nil || byKey == nil || byKey.ID != "job-1" {
+		t.Fatalf("FindServeJobByIdempotencyKey = %+v, %v", byKey, err)
+	}
+	if stale, err := store.FindServeJobByIdempotencyKey("abc", created.Add(time.Second)); err != nil || stale != nil {
+		t.Fatalf("key older than since must not match: %+v, %v", stale, err)
+	}
+	if none, err := store.FindServeJobByIdempotencyKey("", time.Time{}); err != nil || none != nil {
+		t.Fatalf("empty key must not match: %+v, %v", none, err)
+	}
+
+	if err := store.SaveServeJob(&ServeJob{ID: "x"}); err == nil {
+		t.Fatal("SaveServeJob without type/status must error")
+	}
+}
+
+func TestServeJobs_ListFiltersAndPaginates(t *testing.T) {
+	store := routingStateStore(t)
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	jobs := []ServeJob{
+		{ID: "a", Type: "pipeline_run", Status: "completed"},
+		{ID: "b", Type: "swarm_spawn", Status: "failed"},
+		{ID: "c", Type: "pipeline_run", Status: "interrupted"},
+		{ID: "d", Type: "pipeline_run", Status: "completed"},
+	}
+	for i := range jobs {
+		jobs[i].CreatedAt = base.Add(time.Duration(i) * time.Minute)
+		jobs[i].UpdatedAt = jobs[i].CreatedAt
+		if err := store.SaveServeJob(&jobs[i]); err != nil {
+			t.Fatalf("save %s: %v", jobs[i].ID, err)
+		}
+	}
+
+	all, total, err := store.ListServeJobs(ServeJobFilter{})
+	if err != nil || total != 4 || len(all) != 4 || all[0].ID != "d" {
+		t.Fatalf("list all = %d/%d first=%v, %v", len(all), total, all, err)
+	}
+
+	page, total, err := store.ListServeJobs(ServeJobFilter{Types: []string{"pipeline_run"}, Limit: 2, Offset: 1})
+	if err != nil {
+		t.Fatalf("list page: %v", err)
+	}
+	if total != 3 || len(page) != 2 || page[0].ID != "c" || page[1].ID != "a" {
+		t.Fatalf("page = %+v total=%d", page, total)
+	}
+
+	filtered, total, err := store.ListServeJobs(ServeJobFilter{Statuses: []string{"failed", "interrupted"}})
+	if err != nil || total != 2 || len(filtered) != 2 {
+		t.Fatalf("status filter = %+v total=%d, %v", filtered, total, err)
+	}
+
+	pruned, err := store.PruneServeJobs(base.Add(90 * time.Minute))
+	if err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+	// a, b, d are terminal and old enough; c (interrupted) is kept.
+	if pruned != 3 {
+		t.Fatalf("pruned = %d, want 3", pruned)
+	}
+	if left, _, _ := store.ListServeJobs(ServeJobFilter{}); len(left) != 1 || left[0].ID != "c" {
+		t.Fatalf("after prune = %+v", left)
+	}
+}
@@ -0,0 +1,85 @@
+package tmux
+
+import (
+	"context"
+	"errors"
+)
+
+// PaneBackend is the pane surface ntm drives through a Client: sessions of
+// panes that can be listed, split, titled, typed into, pasted into and
+// captured. The tmux server is the built-in implementation; SetBackend swaps
+// in another, such as the headless PTY daemon in internal/ptyd.
+type PaneBackend interface {
+	// Name identifies the backend in errors and status output.
+	Name() string
+	HasSession(ctx context.Context, session string) (bool, error)
+	// NewSession creates a session with one shell pane in directory.
+	NewSession(ctx context.Context, session, directory string) error
+	KillSession(ctx context.Context, session string) error
+	ListSessions(ctx context.Context) ([]Session, error)
+	// ListPanes returns the session's panes with the time each last
+	// produced output. Agent type detection is left to the Client.
+	ListPanes(ctx context.Context, session string) ([]PaneActivity, error)
+	// SplitPane adds a shell pane to the session and returns its pane ID.
+	SplitPane(ctx context.Context, session, directory string) (string, error)
+	KillPane(ctx context.Context, target string) error
+	SetPaneTitle(ctx context.Context, target, title string) error
+	// SendKeys types keys literally, or presses one tmux key name (Enter,
+	// C-c, Escape, ...) when literal is false.
+	SendKeys(ctx context.Context, target, keys string, literal bool) error
+	// Paste delivers content as paste-buffer -p would.
+	Paste(ctx context.Context, target, content string) error
+	// Capture returns the visible screen plus up to lines of scrollback
+	// above it, or only the visible screen when visible is set.
+	Capture(ctx context.Context, target string, lines int, visible bool) (string, error)
+}
+
+// PaneAttacher is implemented by backends that can connect the current
+// terminal to one of their panes.
+type PaneAttacher interface {
+	Attach(target string) error
+}
+
+// ErrBackendUnsupported is returned for tmux commands that the client's pane
+// backend has no equivalent for.
+var ErrBackendUnsupported = errors.New("not supported by the pane backend")
+
+// backendRef boxes a PaneBackend for atomic.Pointer.
+type backendRef struct{ b PaneBackend }
+
+// SetBackend routes the client's pane operations to b instead of the tmux
+// server. A nil b restores tmux.
+func (c *Client) SetBackend(b PaneBackend) {
+	if b == nil {
+		c.backend.Store(nil)
+		return
+	}
+	c.backend.Store(&backendRef{b: b})
+}
+
+// Backend returns the client's pane backend, or nil when it drives tmux.
+func (c *Client) Backend() PaneBackend {
+	if ref := c.backend.Load(); ref != nil {
+		return ref.b
+	}
+	return nil
+}
+
+// BackendName reports which backend the client drives: "tmux" or the
+// configured backend's name.
+func (c *Client) BackendName() string {
+	if b := c.Backend(); b != nil {
+		return b.Name()
+	}
+	return "tmux"
+}
+
+// backendPanes converts backend panes into fully classified Panes.
+func backendPanes(in []PaneActivity) []PaneActivity {
+	out := make([]PaneActivity, len(in))
+	for i, pa := range in {
+		classifyPane(&pa.Pane)
+		out[i] = pa
+	}
+	return out
+}
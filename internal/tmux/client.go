@@ -91,6 +91,7 @@ const (
 // the tmux server when it is consistently failing.
 type Client struct {
 	Remote string // "user@host" or empty for local
+	Socket string // tmux -L socket name, or empty for the default server
 
 	// captureBackpressure keeps the most recent capture attempt for each pane
 	// so runtime overload snapshots are based on live tmux activity.
@@ -100,14 +101,29 @@ type Client struct {
 	cbFailures  atomic.Int64 // consecutive failure count
 	cbOpenUntil atomic.Int64 // unix-nano timestamp when circuit closes (0 = closed)
 	cbProbing   atomic.Bool  // true when a half-open probe is in flight
+
+	// Control-mode transport (see control.go). When enabled, commands go
+	// over one persistent `tmux -C` connection and pane streams are fed by
+	// %output; the exec path below is used whenever that is not possible.
+	controlEnabled atomic.Bool
+	controlMu      sync.Mutex
+	control        *ControlConn
+	controlStreams map[string]*controlStream
+	controlRetryAt time.Time
+
+	// backend, when set, replaces the tmux server for pane operations
+	// (see backend.go).
+	backend atomic.Pointer[backendRef]
 }
 
 // NewClient creates a new tmux client
 func NewClient(remote string) *Client {
-	return &Client{
+	c := &Client{
 		Remote:              remote,
 		captureBackpressure: newCaptureBackpressureTracker(),
 	}
+	c.controlEnabled.Store(controlModeFromEnv())
+	return c
 }
 
 // DefaultClient is the default local client
@@ -280,6 +296,9 @@ func (c *Client) RunContext(ctx context.Context, args ...string) (string, error)
 	if ctx == nil {
 		ctx = context.Background()
 	}
+	if b := c.Backend(); b != nil {
+		return "", &CommandError{Command: b.Name(), Args: args, Err: ErrBackendUnsupported}
+	}
 
 	// Circuit breaker: reject early if tmux has been consistently failing.
 	if err := c.cbCheck(); err != nil {
@@ -288,13 +307,12 @@ func (c *Client) RunContext(ctx context.Context, args ...string) (string, error)
 
 	var out string
 	var err error
-	if c.Remote == "" {
-		out, err = runLocalContext(ctx, args...)
-	} else {
-		// Remote execution via ssh
-		remoteCmd := buildRemoteShellCommand("tmux", args...)
-		// Use "--" to prevent Remote from being parsed as an ssh option.
-		out, err = runSSHContext(ctx, "--", c.Remote, remoteCmd)
+	handled := false
+	if c.controlEnabled.Load() {
+		out, handled, err = c.runControlContext(ctx, args)
+	}
+	if !handled {
+		out, err = c.runExecContext(ctx, args...)
 	}
 
 	if err != nil && ClassifyCommandError(err).Infrastructure {
@@ -308,6 +326,28 @@ func (c *Client) RunContext(ctx context.Context, args ...string) (string, error)
 	return out, err
 }
 
+// runExecContext runs one tmux command in a fresh process, over ssh for a
+// remote client.
+func (c *Client) runExecContext(ctx context.Context, args ...string) (string, error) {
+	args = c.serverArgs(args)
+	if c.Remote == "" {
+		return runLocalContext(ctx, args...)
+	}
+	// Remote execution via ssh
+	remoteCmd := buildRemoteShellCommand("tmux", args...)
+	// Use "--" to prevent Remote from being parsed as an ssh option.
+	return runSSHContext(ctx, "--", c.Remote, remoteCmd)
+}
+
+// serverArgs prefixes args with the -L flag that selects the client's tmux
+// server when it does not use the default socket.
+func (c *Client) serverArgs(args []string) []string {
+	if c.Socket == "" {
+		return args
+	}
+	return append([]string{"-L", c.Socket}, args...)
+}
+
 // ClassifyCommandError returns the stable class for a tmux command failure.
 // It keeps caller decisions about retry and circuit-breaker accounting aligned.
 func ClassifyCommandError(err error) CommandErrorClass {
@@ -385,6 +425,14 @@ func ClassifyCommandError(err error) CommandErrorClass {
 		}
 	}
 
+	if errors.Is(err, ErrBackendUnsupported) {
+		return CommandErrorClass{Kind: CommandErrorCommandFailed}
+	}
+	if errors.Is(err, errControlCommandFailed) {
+		// A %error reply: tmux ran the command and rejected it, the control
+		// mode equivalent of a non-zero exit.
+		return CommandErrorClass{Kind: CommandErrorCommandFailed}
+	}
 	if exitCode, ok := commandExitCode(err); ok {
 		if exitCode == 255 {
 			return CommandErrorClass{Kind: CommandErrorRemoteUnavailable, Infrastructure: true, Retryable: true}
@@ -506,8 +554,12 @@ func (c *Client) RunSilentContext(ctx context.Context, args ...string) error {
 	return err
 }
 
-// IsInstalled checks if tmux is available on the target host
+// IsInstalled checks if tmux is available on the target host. A client with a
+// pane backend does not need tmux and always reports true.
 func (c *Client) IsInstalled() bool {
+	if c.Backend() != nil {
+		return true
+	}
 	if c.Remote == "" {
 		return binaryExists(BinaryPath())
 	}
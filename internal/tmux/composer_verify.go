@@ -0,0 +1,227 @@
+package tmux
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Dicklesworthstone/ntm/internal/agent"
+)
+
+// DeliveryOutcome classifies what a pane's composer shows after a prompt was
+// typed into it. It is derived by diffing the composer region of the visible
+// screen before and after the send, so "delivered" can mean "the prompt
+// landed and submitted" rather than "send-keys exited zero".
+type DeliveryOutcome string
+
+const (
+	// DeliverySubmitted: the composer is empty after a submitting send and
+	// the screen shows the prompt was taken (transcript grew or the agent is
+	// working).
+	DeliverySubmitted DeliveryOutcome = "submitted"
+	// DeliveryStaged: a stage-only send left the full payload in the composer.
+	DeliveryStaged DeliveryOutcome = "staged"
+	// DeliveryStranded: the full payload sits unsubmitted in the composer
+	// after a submitting send (the Enter was swallowed).
+	DeliveryStranded DeliveryOutcome = "stranded"
+	// DeliveryPartial: the composer holds only part of the payload — a
+	// paste that was cut short or a redraw that ate keystrokes.
+	DeliveryPartial DeliveryOutcome = "partial"
+	// DeliveryLost: nothing of the payload is visible and the screen did not
+	// change — the keystrokes landed in a menu, a redraw, or nowhere.
+	DeliveryLost DeliveryOutcome = "lost"
+	// DeliveryUnverifiable: the pane exposes no composer to diff (unknown
+	// TUI, dialog on screen, capture failure). Callers must not retry on it.
+	DeliveryUnverifiable DeliveryOutcome = "unverifiable"
+)
+
+// composerRegionBorders are the glyphs that close a composer box or rule the
+// line below it; continuation lines stop at the first of them.
+const composerRegionBorders = "─━╰╭└┌"
+
+// payloadProbeRunes bounds the head/tail snippets matched against composer
+// text. Short enough to survive soft-wrapping of the first and last visual
+// rows, long enough not to match unrelated text.
+const payloadProbeRunes = 24
+
+// HasComposerMarker reports whether the agent type renders a composer marker
+// that ComposerRegion and ClassifyDelivery can locate.
+func HasComposerMarker(agentType AgentType) bool {
+	return composerMarkerForAgent(agentType) != ""
+}
+
+// ComposerRegion returns the text of the live composer in a capture: the
+// bottom-most marker line plus its wrapped continuation lines, with box
+// borders and idle hint text removed. found=false means the agent
+// type has no known marker or none is visible.
+func ComposerRegion(capture string, agentType AgentType) (text string, found bool) {
+	marker := composerMarkerForAgent(agentType)
+	if marker == "" {
+		return "", false
+	}
+	lines := strings.Split(capture, "\n")
+	start := -1
+	for i := len(lines) - 1; i >= 0; i-- {
+		if strings.Contains(lines[i], marker) {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return "", false
+	}
+	first := lines[start]
+	first = first[strings.Index(first, marker)+len(marker):]
+	parts := []string{trimComposerBorder(first)}
+	for _, line := range lines[start+1:] {
+		body := strings.TrimSpace(line)
+		if body == "" || strings.ContainsAny(string([]rune(body)[0]), composerRegionBorders) {
+			break
+		}
+		// Continuation rows are indented under the marker; an unindented
+		// row is footer chrome ("? for shortcuts" sits indented too, but
+		// only ever after a rule, which ends the scan above).
+		if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "│") {
+			break
+		}
+		parts = append(parts, trimComposerBorder(line))
+	}
+	text = strings.TrimSpace(strings.Join(parts, " "))
+	for _, prefix := range composerPlaceholderPrefixes(agentType) {
+		if strings.HasPrefix(text, prefix) {
+			return "", true
+		}
+	}
+	return text, true
+}
+
+func trimComposerBorder(line string) string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "│")
+	line = strings.TrimSuffix(line, "│")
+	return strings.TrimSpace(line)
+}
+
+// normalizeForMatch collapses all whitespace runs to single spaces so text
+// re-wrapped by the TUI compares equal to the original payload.
+func normalizeForMatch(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// payloadProbes returns the normalized head and tail snippets of a payload.
+func payloadProbes(message string) (head, tail string) {
+	norm := normalizeForMatch(message)
+	if utf8.RuneCountInString(norm) <= payloadProbeRunes {
+		return norm, norm
+	}
+	runes := []rune(norm)
+	return string(runes[:payloadProbeRunes]), string(runes[len(runes)-payloadProbeRunes:])
+}
+
+// composerLooksWorking reports whether a capture shows the agent's in-flight
+// chrome, which is evidence that a submission was taken.
+func composerLooksWorking(capture string, agentType AgentType, paneWidth int) bool {
+	switch agentType.Canonical() {
+	case AgentClaude:
+		return agent.ClaudeActivelyWorking(capture, paneWidth)
+	case AgentCodex:
+		return codexLooksWorking(capture)
+	case AgentGrok:
+		return grokLooksWorking(capture)
+	}
+	return false
+}
+
+// ClassifyDelivery diffs the composer region of before and after captures of
+// a pane that was sent message and reports what happened to it. submit says
+// whether the send pressed Enter. The classification is conservative in the
+// direction that matters for retries: Lost is only reported when the whole
+// screen is unchanged, and Partial/Stranded only when the composer positively
+// shows payload text, so a retry can never duplicate a prompt the agent took.
+func ClassifyDelivery(before, after, message string, agentType AgentType, submit bool, paneWidth int) DeliveryOutcome {
+	afterText, found := ComposerRegion(after, agentType)
+	if !found {
+		if submit && composerLooksWorking(after, agentType, paneWidth) {
+			return DeliverySubmitted
+		}
+		return DeliveryUnverifiable
+	}
+	beforeText, _ := ComposerRegion(before, agentType)
+	head, tail := payloadProbes(message)
+	composer := normalizeForMatch(afterText)
+
+	if composer != "" && composer != normalizeForMatch(beforeText) {
+		switch {
+		case strings.Contains(composer, "[Pasted"), tail != "" && strings.Contains(composer, tail):
+			if submit {
+				return DeliveryStranded
+			}
+			return DeliveryStaged
+		case head != "" && strings.Contains(composer, head),
+			strings.Contains(normalizeForMatch(message), composer):
+			return DeliveryPartial
+		}
+		// Unrelated text (a picker entry, a history recall): the payload
+		// did not land where it was typed, unless the screen shows it was
+		// taken before the composer refilled.
+		if submit && payloadTaken(before, after, head, agentType, paneWidth) {
+			return DeliverySubmitted
+		}
+		return DeliveryLost
+	}
+
+	if !submit {
+		// A stage-only send that left the composer as it was lost the text.
+		return DeliveryLost
+	}
+	if payloadTaken(before, after, head, agentType, paneWidth) {
+		return DeliverySubmitted
+	}
+	if strings.TrimSpace(before) == strings.TrimSpace(after) {
+		return DeliveryLost
+	}
+	// The composer is clear and the screen moved on; a fast agent may have
+	// already scrolled the echoed prompt away. Nothing contradicts submission.
+	return DeliverySubmitted
+}
+
+// payloadTaken reports positive evidence that a submission was accepted: the
+// agent is working, or the payload head appears on screen more often than
+// before (the transcript echo of the prompt).
+func payloadTaken(before, after, head string, agentType AgentType, paneWidth int) bool {
+	if composerLooksWorking(after, agentType, paneWidth) {
+		return true
+	}
+	return head != "" && strings.Count(normalizeForMatch(after), head) > strings.Count(normalizeForMatch(before), head)
+}
+
+// SendKeysChunkedContext types content with send-keys in small literal
+// chunks separated by gap, for TUIs whose input loop drops bursts of
+// keystrokes during a redraw. It does not press Enter.
+func (c *Client) SendKeysChunkedContext(ctx context.Context, target, content string, chunkRunes int, gap time.Duration) error {
+	if chunkRunes <= 0 {
+		chunkRunes = 64
+	}
+	runes := []rune(content)
+	for start := 0; start < len(runes); start += chunkRunes {
+		if start > 0 {
+			if err := waitForSendDelay(ctx, gap); err != nil {
+				return err
+			}
+		}
+		end := start + chunkRunes
+		if end > len(runes) {
+			end = len(runes)
+		}
+		if err := c.SendKeysWithDelayContext(ctx, target, string(runes[start:end]), false, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SendKeysChunkedContext types content in chunks (default client).
+func SendKeysChunkedContext(ctx context.Context, target, content string, chunkRunes int, gap time.Duration) error {
+	return DefaultClient.SendKeysChunkedContext(ctx, target, content, chunkRunes, gap)
+}
@@ -0,0 +1,66 @@
+package tmux
+
+import "testing"
+
+const claudeIdleScreen = `⏺ Done earlier.
+
+────────────────────────────────────────
+❯ Try "refactor the parser"
+────────────────────────────────────────
+  ? for shortcuts`
+
+func claudeComposer(text string) string {
+	return "⏺ Done earlier.\n\n────────────────────────────────────────\n❯ " + text +
+		"\n────────────────────────────────────────\n  ? for shortcuts"
+}
+
+func TestComposerRegion(t *testing.T) {
+	if text, found := ComposerRegion(claudeIdleScreen, AgentClaude); !found || text != "" {
+		t.Errorf("idle placeholder = %q, %v", text, found)
+	}
+	wrapped := "› fix the parser so that it counts\n  runes instead of bytes\n\n  ⏎ send"
+	if text, _ := ComposerRegion(wrapped, AgentCodex); text != "fix the parser so that it counts runes instead of bytes" {
+		t.Errorf("wrapped codex composer = %q", text)
+	}
+	boxed := "│ ❯ run the tests           │\n╰───────────────────────────╯"
+	if text, _ := ComposerRegion(boxed, AgentGrok); text != "run the tests" {
+		t.Errorf("grok composer = %q", text)
+	}
+	if _, found := ComposerRegion(claudeIdleScreen, AgentGemini); found {
+		t.Error("gemini has no composer marker")
+	}
+}
+
+func TestClassifyDelivery(t *testing.T) {
+	msg := "please fix the failing parser test and rerun the suite"
+	submitted := "⏺ Done earlier.\n\n> " + msg + "\n\n────────────────────────────────────────\n❯ \n────────────────────────────────────────"
+
+	cases := []struct {
+		name   string
+		after  string
+		submit bool
+		want   DeliveryOutcome
+	}{
+		{"submitted echo", submitted, true, DeliverySubmitted},
+		{"stranded", claudeComposer(msg), true, DeliveryStranded},
+		{"staged", claudeComposer(msg), false, DeliveryStaged},
+		{"pasted stand-in", claudeComposer("[Pasted text #1 +12 lines]"), true, DeliveryStranded},
+		{"partial paste", claudeComposer("please fix the failing parser te"), true, DeliveryPartial},
+		{"eaten by redraw", claudeIdleScreen, true, DeliveryLost},
+		{"stage-only eaten", claudeIdleScreen, false, DeliveryLost},
+		{"landed in picker", claudeComposer("/compact"), true, DeliveryLost},
+		{"no composer", "Loading...", true, DeliveryUnverifiable},
+	}
+	for _, tc := range cases {
+		if got := ClassifyDelivery(claudeIdleScreen, tc.after, msg, AgentClaude, tc.submit, 0); got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+
+	// The screen moved on without an echo (fast agent scrolled it away):
+	// nothing contradicts submission, so no retry.
+	moved := "⏺ Something new.\n\n────────────────────────────────────────\n❯ \n────────────────────────────────────────"
+	if got := ClassifyDelivery(claudeIdleScreen, moved, msg, AgentClaude, true, 0); got != DeliverySubmitted {
+		t.Errorf("moved screen = %q", got)
+	}
+}
@@ -0,0 +1,687 @@
+package tmux
+
+// control.go implements the optional control-mode transport: one long-lived
+// `tmux -C` client per server (local, or over a single ssh channel) that
+// multiplexes commands and delivers %output notifications to pane streamers.
+// The per-command exec path in client.go remains the fallback whenever a
+// control connection cannot be used.
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ControlModeEnv enables the control-mode transport for clients created by
+// NewClient when set to a true value ("1", "true", "yes", "on").
+const ControlModeEnv = "NTM_TMUX_CONTROL_MODE"
+
+// ErrControlClosed is returned for commands that were in flight, or issued,
+// after a control connection exited.
+var ErrControlClosed = errors.New("tmux control connection closed")
+
+// errControlNotStarted is returned for commands the connection lost before
+// tmux opened their %begin block. tmux never ran them, so they are safe to
+// replay on the exec path.
+var errControlNotStarted = fmt.Errorf("%w before the command started", ErrControlClosed)
+
+// errControlCommandFailed is the underlying error of a CommandError built
+// from a %error block. ClassifyCommandError treats it like a non-zero exit.
+var errControlCommandFailed = errors.New("tmux command failed")
+
+// controlRetryDelay is how long a client waits before dialling again after a
+// control connection could not be established, so a server with no sessions
+// does not cost an extra fork per command.
+const controlRetryDelay = 5 * time.Second
+
+// controlHandshakeTimeout bounds how long dialling waits for the attach block.
+const controlHandshakeTimeout = 10 * time.Second
+
+// ControlNotification is an asynchronous control-mode line such as
+// %window-add or %session-changed.
+type ControlNotification struct {
+	// Name is the notification without its leading '%', e.g. "window-add".
+	Name string
+	// Args holds the first field (usually a pane, window or session ID)
+	// followed by the remainder of the line, if any.
+	Args []string
+	// Data is the unescaped payload of an %output notification.
+	Data []byte
+}
+
+type controlReply struct {
+	output string
+	failed bool
+	err    error
+}
+
+type controlWaiter struct {
+	reply   chan controlReply
+	started bool // tmux printed this command's %begin
+}
+
+// ControlConn is a single tmux control-mode client. Commands are written one
+// per line and tmux answers them in order, each inside a %begin/%end (or
+// %error) block, so replies are matched to a FIFO of waiters.
+type ControlConn struct {
+	w       io.WriteCloser
+	closeFn func() error
+	command string // tmux or ssh, for CommandError
+
+	writeMu sync.Mutex // keeps the waiter queue in the same order as writes
+
+	mu        sync.Mutex
+	pending   []*controlWaiter
+	handlers  map[uint64]func(ControlNotification)
+	outputs   map[string]map[uint64]func([]byte)
+	nextID    uint64
+	session   string
+	closed    bool
+	err       error
+	handshake chan controlReply
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// newControlConn starts reading control-mode output from r. Commands are
+// written to w; closeFn releases the underlying process.
+func newControlConn(r io.Reader, w io.WriteCloser, closeFn func() error, command string) *ControlConn {
+	cc := &ControlConn{
+		w:         w,
+		closeFn:   closeFn,
+		command:   command,
+		handlers:  make(map[uint64]func(ControlNotification)),
+		outputs:   make(map[string]map[uint64]func([]byte)),
+		handshake: make(chan controlReply, 1),
+		done:      make(chan struct{}),
+	}
+	go cc.readLoop(r)
+	return cc
+}
+
+// dialControl starts `tmux -C attach-session` on c's server, locally or over
+// ssh, and waits for tmux to acknowledge the attach. An empty session attaches
+// to the most recently used one.
+func dialControl(ctx context.Context, c *Client, session string, flags string) (*ControlConn, error) {
+	remote := c.Remote
+	args := c.serverArgs([]string{"-C", "attach-session"})
+	if session != "" {
+		args = append(args, "-t", TargetSession(session))
+	}
+	if flags != "" {
+		args = append(args, "-f", flags)
+	}
+
+	var cmd *exec.Cmd
+	if remote == "" {
+		cmd = exec.Command(BinaryPath(), args...)
+	} else {
+		remoteCmd := buildRemoteShellCommand("tmux", args...)
+		cmd = exec.Command("ssh", "--", remote, fmt.Sprintf("/bin/sh -c %s", ShellQuote(remoteCmd)))
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, &CommandError{Command: cmd.Path, Args: args, Err: err}
+	}
+
+	var waitOnce sync.Once
+	closeFn := func() error {
+		_ = stdin.Close()
+		waitOnce.Do(func() {
+			// tmux exits on stdin EOF; kill it if it does not.
+			timer := time.AfterFunc(2*time.Second, func() { _ = cmd.Process.Kill() })
+			_ = cmd.Wait()
+			timer.Stop()
+		})
+		return nil
+	}
+	cc := newControlConn(stdout, stdin, closeFn, cmd.Path)
+	cc.session = session
+
+	if err := cc.awaitHandshake(ctx); err != nil {
+		cc.Close() // waits for the process, so stderr is complete
+		var cmdErr *CommandError
+		if errors.As(err, &cmdErr) && cmdErr.Stderr == "" {
+			cmdErr.Stderr = stderr.String()
+		}
+		return nil, err
+	}
+	return cc, nil
+}
+
+func (cc *ControlConn) awaitHandshake(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	timer := time.NewTimer(controlHandshakeTimeout)
+	defer timer.Stop()
+	select {
+	case reply := <-cc.handshake:
+		if reply.err != nil {
+			return &CommandError{Command: cc.command, Args: []string{"-C", "attach-session"}, Err: reply.err}
+		}
+		if reply.failed {
+			return &CommandError{Command: cc.command, Args: []string{"-C", "attach-session"}, Stderr: reply.output, Err: errControlCommandFailed}
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return fmt.Errorf("tmux control mode: no attach response after %s", controlHandshakeTimeout)
+	}
+}
+
+// Run sends one command and waits for its reply. A %error reply is returned
+// as a *CommandError whose Stderr is the error text tmux printed.
+func (cc *ControlConn) Run(ctx context.Context, args ...string) (string, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if len(args) == 0 {
+		return "", errors.New("tmux control mode: empty command")
+	}
+	line := formatControlCommand(args)
+	ch := make(chan controlReply, 1)
+
+	cc.writeMu.Lock()
+	cc.mu.Lock()
+	if cc.closed {
+		cc.mu.Unlock()
+		cc.writeMu.Unlock()
+		return "", errControlNotStarted
+	}
+	cc.pending = append(cc.pending, &controlWaiter{reply: ch})
+	cc.mu.Unlock()
+	_, err := io.WriteString(cc.w, line+"\n")
+	cc.writeMu.Unlock()
+	if err != nil {
+		cc.shutdown(fmt.Errorf("%w: write: %v", ErrControlClosed, err))
+		return "", errControlNotStarted
+	}
+
+	select {
+	case reply := <-ch:
+		if reply.err != nil {
+			return "", reply.err
+		}
+		if reply.failed {
+			return "", &CommandError{
+				Command: cc.command,
+				Args:    append([]string(nil), args...),
+				Stderr:  reply.output,
+				Err:     errControlCommandFailed,
+			}
+		}
+		return strings.TrimSpace(reply.output), nil
+	case <-ctx.Done():
+		// The reply still arrives and is dropped into the buffered channel.
+		return "", ctx.Err()
+	}
+}
+
+// OnNotification registers fn for every notification other than %output.
+// fn runs on the connection's reader goroutine and must not block.
+func (cc *ControlConn) OnNotification(fn func(ControlNotification)) (cancel func()) {
+	cc.mu.Lock()
+	cc.nextID++
+	id := cc.nextID
+	cc.handlers[id] = fn
+	cc.mu.Unlock()
+	return func() {
+		cc.mu.Lock()
+		delete(cc.handlers, id)
+		cc.mu.Unlock()
+	}
+}
+
+// SubscribeOutput registers fn for %output data from paneID (e.g. "%3").
+// fn runs on the connection's reader goroutine and must not block.
+func (cc *ControlConn) SubscribeOutput(paneID string, fn func([]byte)) (cancel func()) {
+	cc.mu.Lock()
+	cc.nextID++
+	id := cc.nextID
+	if cc.outputs[paneID] == nil {
+		cc.outputs[paneID] = make(map[uint64]func([]byte))
+	}
+	cc.outputs[paneID][id] = fn
+	cc.mu.Unlock()
+	return func() {
+		cc.mu.Lock()
+		delete(cc.outputs[paneID], id)
+		if len(cc.outputs[paneID]) == 0 {
+			delete(cc.outputs, paneID)
+		}
+		cc.mu.Unlock()
+	}
+}
+
+// Done is closed when the connection exits.
+func (cc *ControlConn) Done() <-chan struct{} { return cc.done }
+
+// Err reports why the connection exited, or nil while it is open.
+func (cc *ControlConn) Err() error {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	if !cc.closed {
+		return nil
+	}
+	return cc.closedErrLocked()
+}
+
+// Close detaches the control client and fails any in-flight commands.
+func (cc *ControlConn) Close() {
+	cc.shutdown(ErrControlClosed)
+}
+
+func (cc *ControlConn) closedErrLocked() error {
+	if cc.err != nil {
+		return cc.err
+	}
+	return ErrControlClosed
+}
+
+func (cc *ControlConn) shutdown(reason error) {
+	cc.closeOnce.Do(func() {
+		cc.mu.Lock()
+		cc.closed = true
+		cc.err = reason
+		pending := cc.pending
+		cc.pending = nil
+		cc.mu.Unlock()
+
+		for _, w := range pending {
+			if w.started {
+				w.reply <- controlReply{err: reason}
+			} else {
+				w.reply <- controlReply{err: errControlNotStarted}
+			}
+		}
+		select {
+		case cc.handshake <- controlReply{err: reason}:
+		default:
+		}
+		if cc.closeFn != nil {
+			_ = cc.closeFn()
+		}
+		close(cc.done)
+	})
+}
+
+// readLoop parses control-mode output until EOF or %exit.
+func (cc *ControlConn) readLoop(r io.Reader) {
+	reader := bufio.NewReader(r)
+	var (
+		inBlock   bool
+		blockTag  string // "<time> <number>" of the open %begin
+		blockFlag string
+		body      []string
+		handshook bool
+	)
+	exitReason := error(ErrControlClosed)
+
+	for {
+		raw, err := reader.ReadString('\n')
+		line := strings.TrimSuffix(strings.TrimSuffix(raw, "\n"), "\r")
+		if raw != "" {
+			if inBlock {
+				kind, tag, flags, ok := parseControlGuard(line)
+				if ok && (kind == "end" || kind == "error") && tag == blockTag {
+					inBlock = false
+					reply := controlReply{output: strings.Join(body, "\n"), failed: kind == "error"}
+					body = nil
+					if !handshook && blockFlag != "1" {
+						handshook = true
+						select {
+						case cc.handshake <- reply:
+						default:
+						}
+					} else if flags == "1" {
+						cc.deliver(reply)
+					}
+				} else {
+					body = append(body, line)
+				}
+			} else if kind, tag, flags, ok := parseControlGuard(line); ok && kind == "begin" {
+				inBlock, blockTag, blockFlag = true, tag, flags
+				if flags == "1" {
+					cc.markStarted()
+				}
+			} else if strings.HasPrefix(line, "%exit") {
+				reason := strings.TrimSpace(strings.TrimPrefix(line, "%exit"))
+				if reason != "" {
+					exitReason = fmt.Errorf("%w: %s", ErrControlClosed, reason)
+				}
+				break
+			} else if strings.HasPrefix(line, "%") {
+				cc.notify(parseControlNotification(line))
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+	cc.shutdown(exitReason)
+}
+
+// markStarted records that tmux began executing the oldest pending command.
+func (cc *ControlConn) markStarted() {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	if len(cc.pending) > 0 {
+		cc.pending[0].started = true
+	}
+}
+
+func (cc *ControlConn) deliver(reply controlReply) {
+	cc.mu.Lock()
+	if len(cc.pending) == 0 {
+		cc.mu.Unlock()
+		return
+	}
+	w := cc.pending[0]
+	cc.pending = cc.pending[1:]
+	cc.mu.Unlock()
+	w.reply <- reply
+}
+
+func (cc *ControlConn) notify(n ControlNotification) {
+	cc.mu.Lock()
+	if n.Name == "session-changed" && len(n.Args) == 2 {
+		cc.session = n.Args[1]
+	}
+	var fns []func(ControlNotification)
+	var outs []func([]byte)
+	if n.Name == "output" && len(n.Args) > 0 {
+		for _, fn := range cc.outputs[n.Args[0]] {
+			outs = append(outs, fn)
+		}
+	} else {
+		for _, fn := range cc.handlers {
+			fns = append(fns, fn)
+		}
+	}
+	cc.mu.Unlock()
+
+	for _, fn := range outs {
+		fn(n.Data)
+	}
+	for _, fn := range fns {
+		fn(n)
+	}
+}
+
+// Session returns the session the control client is currently attached to.
+func (cc *ControlConn) Session() string {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	return cc.session
+}
+
+// parseControlGuard parses "%begin|%end|%error <time> <number> <flags>".
+func parseControlGuard(line string) (kind, tag, flags string, ok bool) {
+	fields := strings.Fields(line)
+	if len(fields) != 4 {
+		return "", "", "", false
+	}
+	switch fields[0] {
+	case "%begin", "%end", "%error":
+	default:
+		return "", "", "", false
+	}
+	return fields[0][1:], fields[1] + " " + fields[2], fields[3], true
+}
+
+// parseControlNotification splits a notification line into its name, first
+// field and remainder. %output payloads are unescaped into Data.
+func parseControlNotification(line string) ControlNotification {
+	name, rest, _ := strings.Cut(strings.TrimPrefix(line, "%"), " ")
+	n := ControlNotification{Name: name}
+	if rest == "" {
+		return n
+	}
+	first, remainder, hasRemainder := strings.Cut(rest, " ")
+	n.Args = []string{first}
+	if name == "output" {
+		n.Data = unescapeControlOutput(remainder)
+		return n
+	}
+	if hasRemainder {
+		n.Args = append(n.Args, remainder)
+	}
+	return n
+}
+
+// unescapeControlOutput reverses tmux's %output escaping, which writes bytes
+// below 0x20 and backslashes as three-digit octal escapes.
+func unescapeControlOutput(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) && isOctal(s[i+1]) && isOctal(s[i+2]) && isOctal(s[i+3]) {
+			v, _ := strconv.ParseUint(s[i+1:i+4], 8, 8)
+			out = append(out, byte(v))
+			i += 3
+			continue
+		}
+		out = append(out, s[i])
+	}
+	return out
+}
+
+func isOctal(b byte) bool { return b >= '0' && b <= '7' }
+
+// formatControlCommand renders argv as one line of tmux command syntax. Every
+// argument is double-quoted so that ';', '#', '~' and '$' stay literal, and
+// control characters (including newlines in send-keys payloads) are written
+// as octal escapes so the command never spans lines.
+func formatControlCommand(args []string) string {
+	var b strings.Builder
+	for i, arg := range args {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteByte('"')
+		for j := 0; j < len(arg); j++ {
+			c := arg[j]
+			switch {
+			case c == '"' || c == '\\' || c == '$':
+				b.WriteByte('\\')
+				b.WriteByte(c)
+			case c < 0x20 || c == 0x7f:
+				fmt.Fprintf(&b, "\\%03o", c)
+			default:
+				b.WriteByte(c)
+			}
+		}
+		b.WriteByte('"')
+	}
+	return b.String()
+}
+
+// controlModeFromEnv reports whether ControlModeEnv enables control mode.
+func controlModeFromEnv() bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(ControlModeEnv))) {
+	case "1", "true", "yes", "on":
+		return true
+	}
+	return false
+}
+
+// controlUnsafeCommands change which session the issuing client is attached
+// to, or read from the client's stdin, so they always take the exec path.
+var controlUnsafeCommands = map[string]bool{
+	"attach-session": true, "attach": true,
+	"switch-client": true, "switchc": true,
+	"detach-client": true, "detach": true,
+	"suspend-client": true, "suspendc": true,
+	"kill-server": true,
+}
+
+// controlEligible reports whether args can be sent over a control connection
+// with the same effect as running them in a fresh tmux process.
+func controlEligible(args []string) bool {
+	if len(args) == 0 || controlUnsafeCommands[args[0]] {
+		return false
+	}
+	switch args[0] {
+	case "new-session", "new":
+		// Without -d, new-session would attach the control client itself.
+		for _, a := range args[1:] {
+			if a == "-d" {
+				return true
+			}
+		}
+		return false
+	case "load-buffer", "loadb":
+		return args[len(args)-1] != "-"
+	}
+	return true
+}
+
+// SetControlMode enables or disables the control-mode transport. Disabling
+// it closes any open control connections.
+func (c *Client) SetControlMode(enabled bool) {
+	c.controlEnabled.Store(enabled)
+	if !enabled {
+		c.CloseControl()
+	}
+}
+
+// ControlModeEnabled reports whether commands try a control connection first.
+func (c *Client) ControlModeEnabled() bool {
+	return c.controlEnabled.Load()
+}
+
+// CloseControl closes the client's control connections. Streams fed by them
+// fall back to polling.
+func (c *Client) CloseControl() {
+	c.controlMu.Lock()
+	conns := make([]*ControlConn, 0, 1+len(c.controlStreams))
+	if c.control != nil {
+		conns = append(conns, c.control)
+		c.control = nil
+	}
+	for session, s := range c.controlStreams {
+		conns = append(conns, s.conn)
+		delete(c.controlStreams, session)
+	}
+	c.controlMu.Unlock()
+	for _, cc := range conns {
+		cc.Close()
+	}
+}
+
+// commandControl returns the client's command connection, dialling it when
+// needed. It returns nil when control mode is unavailable for now.
+func (c *Client) commandControl(ctx context.Context) *ControlConn {
+	c.controlMu.Lock()
+	defer c.controlMu.Unlock()
+	if c.control != nil {
+		if c.control.Err() == nil {
+			return c.control
+		}
+		c.control = nil
+	}
+	if time.Now().Before(c.controlRetryAt) {
+		return nil
+	}
+	// no-output: the command connection never needs pane output, and
+	// ignore-size keeps it from constraining window sizes.
+	cc, err := dialControl(ctx, c, "", "ignore-size,no-output")
+	if err != nil {
+		c.controlRetryAt = time.Now().Add(controlRetryDelay)
+		slog.Debug("tmux control mode unavailable, using exec", "remote", c.Remote, "error", err)
+		return nil
+	}
+	c.control = cc
+	return cc
+}
+
+// runControlContext runs args over the command connection. handled is false
+// when the caller should use the exec path instead.
+func (c *Client) runControlContext(ctx context.Context, args []string) (out string, handled bool, err error) {
+	if !controlEligible(args) {
+		return "", false, nil
+	}
+	cc := c.commandControl(ctx)
+	if cc == nil {
+		return "", false, nil
+	}
+	out, err = cc.Run(ctx, args...)
+	if errors.Is(err, errControlNotStarted) {
+		// Typically the attached session was just killed and tmux detached
+		// the control client. The command never ran, so exec it instead.
+		return "", false, nil
+	}
+	if errors.Is(err, ErrControlClosed) {
+		// The connection died under us. The command may or may not have
+		// run, so report the failure instead of replaying it on exec.
+		return "", true, &CommandError{Command: cc.command, Args: append([]string(nil), args...), Stderr: err.Error(), Err: err}
+	}
+	return out, true, err
+}
+
+type controlStream struct {
+	conn *ControlConn
+	refs int
+}
+
+// acquireControlStream returns a read-only control connection attached to
+// session, shared by every streamer of that session's panes. tmux only sends
+// %output for panes in the attached session, so output needs one connection
+// per streamed session.
+func (c *Client) acquireControlStream(ctx context.Context, session string) (*ControlConn, func(), error) {
+	c.controlMu.Lock()
+	defer c.controlMu.Unlock()
+	if c.controlStreams == nil {
+		c.controlStreams = make(map[string]*controlStream)
+	}
+	s := c.controlStreams[session]
+	if s != nil && s.conn.Err() != nil {
+		delete(c.controlStreams, session)
+		s = nil
+	}
+	if s == nil {
+		cc, err := dialControl(ctx, c, session, "ignore-size,read-only")
+		if err != nil {
+			return nil, nil, err
+		}
+		s = &controlStream{conn: cc}
+		c.controlStreams[session] = s
+	}
+	s.refs++
+
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			c.controlMu.Lock()
+			s.refs--
+			if s.refs > 0 || c.controlStreams[session] != s {
+				c.controlMu.Unlock()
+				return
+			}
+			delete(c.controlStreams, session)
+			c.controlMu.Unlock()
+			s.conn.Close()
+		})
+	}
+	return s.conn, release, nil
+}
@@ -0,0 +1,293 @@
+package tmux
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeControlServer plays the tmux side of a control-mode connection over
+// in-memory pipes.
+type fakeControlServer struct {
+	t        *testing.T
+	commands *bufio.Reader // what the client wrote
+	out      io.WriteCloser
+	serial   int
+}
+
+func newFakeControl(t *testing.T) (*ControlConn, *fakeControlServer) {
+	t.Helper()
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+	cc := newControlConn(clientR, clientW, func() error {
+		_ = clientW.Close()
+		return serverW.Close()
+	}, "tmux")
+	t.Cleanup(cc.Close)
+	return cc, &fakeControlServer{t: t, commands: bufio.NewReader(serverR), out: serverW}
+}
+
+func (f *fakeControlServer) send(lines ...string) {
+	f.t.Helper()
+	for _, line := range lines {
+		if _, err := io.WriteString(f.out, line+"\n"); err != nil {
+			f.t.Fatalf("write %q: %v", line, err)
+		}
+	}
+}
+
+// reply reads one command and answers it with body inside an %end or
+// %error block.
+func (f *fakeControlServer) reply(failed bool, body ...string) string {
+	f.t.Helper()
+	cmd, err := f.commands.ReadString('\n')
+	if err != nil {
+		f.t.Fatalf("read command: %v", err)
+	}
+	f.serial++
+	end := "%end"
+	if failed {
+		end = "%error"
+	}
+	f.send(fmt.Sprintf("%%begin 1700000000 %d 1", f.serial))
+	f.send(body...)
+	f.send(fmt.Sprintf("%s 1700000000 %d 1", end, f.serial))
+	return strings.TrimSuffix(cmd, "\n")
+}
+
+func TestControlConnRunMatchesRepliesInOrder(t *testing.T) {
+	cc, srv := newFakeControl(t)
+
+	// The attach block carries flags 0 and must not be taken as a reply.
+	go srv.send("%begin 1700000000 1 0", "%end 1700000000 1 0", "%session-changed $0 main")
+
+	type result struct {
+		out string
+		err error
+	}
+	first := make(chan result, 1)
+	go func() {
+		out, err := cc.Run(context.Background(), "list-sessions", "-F", "#{session_name}")
+		first <- result{out, err}
+	}()
+	if got := srv.reply(false, "main", "%end looks like a guard but is body"); got != `"list-sessions" "-F" "#{session_name}"` {
+		t.Fatalf("command line = %s", got)
+	}
+	if r := <-first; r.err != nil || r.out != "main\n%end looks like a guard but is body" {
+		t.Fatalf("first = %q, %v", r.out, r.err)
+	}
+
+	second := make(chan result, 1)
+	go func() {
+		out, err := cc.Run(context.Background(), "has-session", "-t", "missing")
+		second <- result{out, err}
+	}()
+	srv.reply(true, "can't find session: missing")
+	r := <-second
+	var cmdErr *CommandError
+	if !errors.As(r.err, &cmdErr) || cmdErr.Stderr != "can't find session: missing" {
+		t.Fatalf("second err = %v", r.err)
+	}
+	if kind := ClassifyCommandError(r.err).Kind; kind != CommandErrorSessionNotFound {
+		t.Errorf("classified %s, want %s", kind, CommandErrorSessionNotFound)
+	}
+	if cc.Session() != "main" {
+		t.Errorf("session = %q", cc.Session())
+	}
+}
+
+func TestControlConnConcurrentRuns(t *testing.T) {
+	cc, srv := newFakeControl(t)
+	const n = 20
+
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			out, err := cc.Run(context.Background(), "display-message", "-p", fmt.Sprint(i))
+			if err == nil && out != fmt.Sprint(i) {
+				err = fmt.Errorf("command %d got reply %q", i, out)
+			}
+			errs <- err
+		}(i)
+	}
+	// Echo each command's last argument back, in arrival order.
+	for i := 0; i < n; i++ {
+		cmd, err := srv.commands.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		fields := strings.Fields(cmd)
+		srv.serial++
+		srv.send(fmt.Sprintf("%%begin 1 %d 1", srv.serial), strings.Trim(fields[len(fields)-1], `"`), fmt.Sprintf("%%end 1 %d 1", srv.serial))
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestControlConnNotificationsAndOutput(t *testing.T) {
+	cc, srv := newFakeControl(t)
+
+	var mu sync.Mutex
+	var notes []ControlNotification
+	var pane1, pane2 []byte
+	gotExit := make(chan struct{})
+	cc.OnNotification(func(n ControlNotification) {
+		mu.Lock()
+		notes = append(notes, n)
+		mu.Unlock()
+	})
+	cc.SubscribeOutput("%1", func(b []byte) { mu.Lock(); pane1 = append(pane1, b...); mu.Unlock() })
+	cancel := cc.SubscribeOutput("%2", func(b []byte) { mu.Lock(); pane2 = append(pane2, b...); mu.Unlock() })
+	cancel()
+
+	go func() {
+		srv.send(
+			`%output %1 hello\015\012back\134slash`,
+			`%output %2 ignored`,
+			`%window-add @3`,
+			`%window-renamed @3 build logs`,
+			`%exit server exited`,
+		)
+		<-cc.Done()
+		close(gotExit)
+	}()
+	select {
+	case <-gotExit:
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection did not close on the exit notification")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if string(pane1) != "hello\r\nback\\slash" || len(pane2) != 0 {
+		t.Errorf("output pane1=%q pane2=%q", pane1, pane2)
+	}
+	if len(notes) != 2 || notes[0].Name != "window-add" || notes[0].Args[0] != "@3" ||
+		notes[1].Name != "window-renamed" || len(notes[1].Args) != 2 || notes[1].Args[1] != "build logs" {
+		t.Errorf("notifications = %+v", notes)
+	}
+	if err := cc.Err(); !errors.Is(err, ErrControlClosed) || !strings.Contains(err.Error(), "server exited") {
+		t.Errorf("Err() = %v", err)
+	}
+	if _, err := cc.Run(context.Background(), "list-sessions"); !errors.Is(err, ErrControlClosed) {
+		t.Errorf("Run after exit = %v", err)
+	}
+}
+
+func TestControlConnCloseFailsPending(t *testing.T) {
+	cc, srv := newFakeControl(t)
+	started := make(chan error, 1)
+	queued := make(chan error, 1)
+	go func() {
+		_, err := cc.Run(context.Background(), "list-sessions")
+		started <- err
+	}()
+	if _, err := srv.commands.ReadString('\n'); err != nil {
+		t.Fatal(err)
+	}
+	srv.send("%begin 1 1 1")
+	go func() {
+		_, err := cc.Run(context.Background(), "list-windows")
+		queued <- err
+	}()
+	if _, err := srv.commands.ReadString('\n'); err != nil {
+		t.Fatal(err)
+	}
+	_ = srv.out.Close() // tmux went away mid-command
+
+	for name, tc := range map[string]struct {
+		ch         chan error
+		notStarted bool
+	}{"started": {started, false}, "queued": {queued, true}} {
+		select {
+		case err := <-tc.ch:
+			if !errors.Is(err, ErrControlClosed) || errors.Is(err, errControlNotStarted) != tc.notStarted {
+				t.Errorf("%s command err = %v", name, err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s command was not failed", name)
+		}
+	}
+}
+
+func TestFormatControlCommand(t *testing.T) {
+	got := formatControlCommand([]string{"send-keys", "-t", "s:1.2", "-l", "echo \"$HOME\" \\ ; #{x}\nnext\ttab"})
+	want := `"send-keys" "-t" "s:1.2" "-l" "echo \"\$HOME\" \\ ; #{x}\012next\011tab"`
+	if got != want {
+		t.Fatalf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestUnescapeControlOutput(t *testing.T) {
+	for in, want := range map[string]string{
+		`plain`:              "plain",
+		`\033[31mred\033[0m`: "\x1b[31mred\x1b[0m",
+		`a\134b`:             `a\b`,
+		`trailing\01`:        `trailing\01`,
+		`not\89octal`:        `not\89octal`,
+		`\015\012`:           "\r\n",
+		"utf8 é \\134":       "utf8 é \\",
+	} {
+		if got := string(unescapeControlOutput(in)); got != want {
+			t.Errorf("unescape(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestControlEligible(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		want bool
+	}{
+		{[]string{"list-sessions"}, true},
+		{[]string{"new-session", "-d", "-s", "x"}, true},
+		{[]string{"new-session", "-s", "x"}, false},
+		{[]string{"attach", "-t", "x"}, false},
+		{[]string{"switch-client", "-t", "x"}, false},
+		{[]string{"kill-server"}, false},
+		{[]string{"load-buffer", "-b", "buf", "-"}, false},
+		{[]string{"load-buffer", "/tmp/file"}, true},
+		{nil, false},
+	} {
+		if got := controlEligible(tc.args); got != tc.want {
+			t.Errorf("controlEligible(%v) = %v, want %v", tc.args, got, tc.want)
+		}
+	}
+}
+
+func TestClassifyControlCommandFailure(t *testing.T) {
+	err := &CommandError{Command: "tmux", Args: []string{"send-keys", "permission denied"}, Stderr: "not a terminal", Err: errControlCommandFailed}
+	if class := ClassifyCommandError(err); class.Kind != CommandErrorCommandFailed || class.Infrastructure {
+		t.Fatalf("class = %+v", class)
+	}
+}
+
+func TestControlModeFromEnv(t *testing.T) {
+	t.Setenv(ControlModeEnv, "on")
+	if !NewClient("").ControlModeEnabled() {
+		t.Error("expected control mode enabled from env")
+	}
+	t.Setenv(ControlModeEnv, "")
+	c := NewClient("")
+	if c.ControlModeEnabled() {
+		t.Error("expected control mode disabled by default")
+	}
+	c.SetControlMode(true)
+	if !c.ControlModeEnabled() {
+		t.Error("SetControlMode(true) did not enable control mode")
+	}
+}
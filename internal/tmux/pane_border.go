@@ -30,6 +30,9 @@ func (c *Client) EnsurePaneBorderStatus(session string) error {
 //     inherited from the user's tmux.conf) already makes titles visible
 //     ("top" or "bottom"), nothing is changed.
 func (c *Client) EnsurePaneBorderStatusContext(ctx context.Context, session string) error {
+	if c.Backend() != nil {
+		return nil // pane backends draw no borders
+	}
 	// -A includes values inherited from the global/window defaults, so a user
 	// who globally chose "bottom" (or already enabled "top") is left alone.
 	if out, err := c.RunContext(ctx, "show-options", "-A", "-w", "-v", "-t", SessionOptionTarget(session), "pane-border-status"); err == nil {
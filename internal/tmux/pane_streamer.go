@@ -1,8 +1,10 @@
-// Package tmux provides pane output streaming using pipe-pane with polling fallback.
+// Package tmux provides pane output streaming using control-mode %output or
+// pipe-pane, with polling fallback.
 package tmux
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"fmt"
 	"hash/fnv"
@@ -90,6 +92,9 @@ type PaneStreamer struct {
 	useFallback  atomic.Bool
 	ownsPipePane bool
 
+	useControl     atomic.Bool
+	controlRelease func() // unsubscribes and releases the control connection
+
 	mu       sync.Mutex
 	running  bool
 	lastHash string // Hash of last captured output for deduplication
@@ -159,6 +164,7 @@ func (ps *PaneStreamer) Start(ctx context.Context) (err error) {
 	ps.ctx = ctx
 	ps.stopCh = make(chan struct{})
 	ps.useFallback.Store(false)
+	ps.useControl.Store(false)
 	ps.lastHash = ""
 	ps.fifoPath = ""
 	ps.mu.Unlock()
@@ -185,7 +191,16 @@ func (ps *PaneStreamer) Start(ctx context.Context) (err error) {
 		return fmt.Errorf("create fifo dir: %w", err)
 	}
 
-	// Try pipe-pane first
+	// Control mode delivers %output without a FIFO when it is enabled.
+	if ps.client.ControlModeEnabled() {
+		controlErr := ps.startControlStreaming()
+		if controlErr == nil {
+			return nil
+		}
+		log.Printf("control-mode: failed for %s, falling back to pipe-pane: %v", ps.target, controlErr)
+	}
+
+	// Try pipe-pane next
 	if err := ps.startPipePaneStreaming(); err != nil {
 		log.Printf("pipe-pane: failed for %s, falling back to polling: %v", ps.target, err)
 		ps.useFallback.Store(true)
@@ -227,6 +242,10 @@ func (ps *PaneStreamer) Stop() {
 		_ = os.Remove(ps.fifoPath)
 	}
 	ps.closeFIFO()
+	if ps.controlRelease != nil {
+		ps.controlRelease()
+		ps.controlRelease = nil
+	}
 
 	ps.wg.Wait()
 
@@ -241,6 +260,11 @@ func (ps *PaneStreamer) UsingFallback() bool {
 	return ps.useFallback.Load()
 }
 
+// UsingControlMode returns true if output arrives as control-mode %output.
+func (ps *PaneStreamer) UsingControlMode() bool {
+	return ps.useControl.Load() && !ps.useFallback.Load()
+}
+
 // nextSeq returns the next sequence number.
 func (ps *PaneStreamer) nextSeq() int64 {
 	return atomic.AddInt64(&ps.seq, 1)
@@ -248,7 +272,10 @@ func (ps *PaneStreamer) nextSeq() int64 {
 
 func pipePaneCatCommand(fifoPath string) string {
 	// pipe-pane runs the command via a shell, so the FIFO path must be quoted.
-	return fmt.Sprintf("cat >> %s", ShellQuote(fifoPath))
+	// tmux also expands formats and strftime sequences in the command first,
+	// which would mangle pane-ID targets ("%12") in the path.
+	escaped := strings.NewReplacer("#", "##", "%", "%%").Replace(fifoPath)
+	return fmt.Sprintf("cat >> %s", ShellQuote(escaped))
 }
 
 // startPipePaneStreaming sets up pipe-pane streaming via a FIFO.
@@ -297,6 +324,126 @@ func paneStreamerFIFOPath(dir, target string) string {
 	return filepath.Join(dir, fmt.Sprintf("pane_%s_%d_%d.fifo", safeTarget, os.Getpid(), fifoPathSequence.Add(1)))
 }
 
+// startControlStreaming subscribes to the pane's %output on a control-mode
+// connection attached to the pane's session.
+func (ps *PaneStreamer) startControlStreaming() error {
+	ctx, cancel := context.WithTimeout(ps.ctx, 5*time.Second)
+	defer cancel()
+	out, err := ps.client.RunContext(ctx, "display-message", "-p", "-t", ExactTarget(ps.target), "#{pane_id} #{session_name}")
+	if err != nil {
+		return fmt.Errorf("resolve pane: %w", err)
+	}
+	paneID, session, ok := strings.Cut(out, " ")
+	if !ok || !strings.HasPrefix(paneID, "%") {
+		return fmt.Errorf("resolve pane: malformed tmux output %q", out)
+	}
+	conn, release, err := ps.client.acquireControlStream(ctx, session)
+	if err != nil {
+		return err
+	}
+
+	// The subscriber runs on the connection's reader goroutine, so it only
+	// queues data; runControlReader does the line splitting and callbacks.
+	var pendingMu sync.Mutex
+	var pending []byte
+	wake := make(chan struct{}, 1)
+	unsubscribe := conn.SubscribeOutput(paneID, func(data []byte) {
+		pendingMu.Lock()
+		pending = append(pending, data...)
+		pendingMu.Unlock()
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	})
+	take := func() []byte {
+		pendingMu.Lock()
+		defer pendingMu.Unlock()
+		data := pending
+		pending = nil
+		return data
+	}
+	ps.controlRelease = func() {
+		unsubscribe()
+		release()
+	}
+	ps.useControl.Store(true)
+
+	log.Printf("control-mode: streaming %s (%s) from session %s", ps.target, paneID, session)
+
+	ps.wg.Add(1)
+	go ps.runControlReader(conn, wake, take)
+	return nil
+}
+
+// runControlReader turns queued %output data into line events.
+func (ps *PaneStreamer) runControlReader(conn *ControlConn, wake <-chan struct{}, take func() []byte) {
+	defer ps.wg.Done()
+
+	ctx := ps.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	stopCh := ps.stopCh
+
+	var lineBuf []string
+	var partial []byte
+	flushTicker := time.NewTicker(ps.config.FlushInterval)
+	defer flushTicker.Stop()
+
+	flushLines := func() {
+		if len(lineBuf) == 0 {
+			return
+		}
+		ps.callback(StreamEvent{
+			Target:    ps.target,
+			Lines:     lineBuf,
+			Seq:       ps.nextSeq(),
+			Timestamp: time.Now(),
+			IsFull:    false,
+		})
+		lineBuf = nil
+	}
+
+	for {
+		select {
+		case <-stopCh:
+			flushLines()
+			return
+		case <-ctx.Done():
+			flushLines()
+			return
+		case <-flushTicker.C:
+			flushLines()
+		case <-conn.Done():
+			flushLines()
+			select {
+			case <-stopCh:
+				return
+			default:
+			}
+			log.Printf("control-mode: connection ended for %s (%v), switching to fallback", ps.target, conn.Err())
+			ps.useFallback.Store(true)
+			ps.wg.Add(1)
+			go ps.runPollingLoop()
+			return
+		case <-wake:
+			partial = append(partial, take()...)
+			for {
+				i := bytes.IndexByte(partial, '\n')
+				if i < 0 {
+					break
+				}
+				lineBuf = append(lineBuf, string(partial[:i]))
+				partial = partial[i+1:]
+				if len(lineBuf) >= ps.config.MaxLinesPerEvent {
+					flushLines()
+				}
+			}
+		}
+	}
+}
+
 // runFIFOReader reads from the FIFO and emits events.
 func (ps *PaneStreamer) runFIFOReader() {
 	defer ps.wg.Done()
@@ -609,21 +756,26 @@ func (sm *StreamManager) Stats() map[string]interface{} {
 
 	active := len(sm.streamers)
 	pipePaneCount := 0
+	controlModeCount := 0
 	fallbackCount := 0
 
 	for _, s := range sm.streamers {
-		if s.UsingFallback() {
+		switch {
+		case s.UsingFallback():
 			fallbackCount++
-		} else {
+		case s.UsingControlMode():
+			controlModeCount++
+		default:
 			pipePaneCount++
 		}
 	}
 
 	return map[string]interface{}{
-		"active_streams":    active,
-		"pipe_pane_count":   pipePaneCount,
-		"fallback_count":    fallbackCount,
-		"fifo_dir":          sm.config.FIFODir,
-		"flush_interval_ms": sm.config.FlushInterval.Milliseconds(),
+		"active_streams":     active,
+		"pipe_pane_count":    pipePaneCount,
+		"control_mode_count": controlModeCount,
+		"fallback_count":     fallbackCount,
+		"fifo_dir":           sm.config.FIFODir,
+		"flush_interval_ms":  sm.config.FlushInterval.Milliseconds(),
 	}
 }
@@ -426,3 +426,64 @@ func TestIntegration_PaneStreamer_StopWhilePolling(t *testing.T) {
 		t.Error("Stop timed out - may be deadlocked")
 	}
 }
+
+func TestIntegration_PaneStreamer_ControlMode(t *testing.T) {
+	skipIfNoTmux(t)
+
+	name := uniqueSessionName("stream_control")
+	t.Cleanup(func() { cleanupSession(t, name) })
+	if err := CreateSession(name, t.TempDir()); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	panes, err := GetPanes(name)
+	if err != nil || len(panes) == 0 {
+		t.Fatalf("GetPanes = %v, %v", panes, err)
+	}
+
+	client := NewClient("")
+	client.SetControlMode(true)
+	t.Cleanup(client.CloseControl)
+
+	// Commands go over the control connection, including payloads that
+	// need quoting.
+	out, err := client.Run("display-message", "-p", "-t", panes[0].ID, "#{session_name};$HOME \"q\"")
+	if err != nil || out != name+";$HOME \"q\"" {
+		t.Fatalf("display-message = %q, %v", out, err)
+	}
+	if client.commandControl(context.Background()) == nil {
+		t.Fatal("expected an open control connection")
+	}
+	if _, err := client.Run("has-session", "-t", "="+name+"_missing"); ClassifyCommandError(err).Kind != CommandErrorSessionNotFound {
+		t.Errorf("missing session err = %v (%s)", err, ClassifyCommandError(err).Kind)
+	}
+
+	var mu sync.Mutex
+	var lines []string
+	ps := NewPaneStreamer(client, panes[0].ID, func(ev StreamEvent) {
+		mu.Lock()
+		lines = append(lines, ev.Lines...)
+		mu.Unlock()
+	}, PaneStreamerConfig{FIFODir: t.TempDir(), FlushInterval: 20 * time.Millisecond})
+	if err := ps.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer ps.Stop()
+	if !ps.UsingControlMode() {
+		t.Fatal("expected control-mode streaming")
+	}
+
+	if err := client.SendKeys(panes[0].ID, "echo control-marker-$((40+2))", true); err != nil {
+		t.Fatalf("SendKeys failed: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		mu.Lock()
+		found := strings.Contains(strings.Join(lines, "\n"), "control-marker-42")
+		mu.Unlock()
+		if found {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("marker not streamed; got %q", lines)
+}
@@ -321,6 +321,15 @@ func TestPipePaneCatCommand_QuotesPath(t *testing.T) {
 	}
 }
 
+func TestPipePaneCatCommand_EscapesTmuxFormats(t *testing.T) {
+	got := pipePaneCatCommand("/tmp/streams/pane_%12_#1.fifo")
+	want := "cat >> " + ShellQuote("/tmp/streams/pane_%%12_##1.fifo")
+
+	if got != want {
+		t.Fatalf("pipePaneCatCommand = %q, want %q", got, want)
+	}
+}
+
 func TestPaneStreamerFIFOPathIsUniqueForSameTarget(t *testing.T) {
 	dir := t.TempDir()
 	first := paneStreamerFIFOPath(dir, "project:0.1")
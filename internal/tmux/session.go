@@ -700,6 +700,9 @@ func (c *Client) SessionExists(name string) bool {
 
 // SessionExistsContext checks whether a session exists with caller cancellation.
 func (c *Client) SessionExistsContext(ctx context.Context, name string) (bool, error) {
+	if b := c.Backend(); b != nil {
+		return b.HasSession(ctx, name)
+	}
 	return classifySessionExistsResult(c.RunSilentContext(ctx, "has-session", "-t", TargetSession(name)))
 }
 
@@ -746,6 +749,9 @@ func (c *Client) ListSessions() ([]Session, error) {
 
 // ListSessionsContext returns all tmux sessions with caller cancellation.
 func (c *Client) ListSessionsContext(ctx context.Context) ([]Session, error) {
+	if b := c.Backend(); b != nil {
+		return b.ListSessions(ctx)
+	}
 	sep := FieldSeparator
 	format := fmt.Sprintf("#{session_name}%[1]s#{session_windows}%[1]s#{session_attached}%[1]s#{session_created_string}", sep)
 	output, err := c.RunContext(ctx, "list-sessions", "-F", format)
@@ -806,6 +812,9 @@ func (c *Client) GetSession(name string) (*Session, error) {
 	if !c.SessionExists(name) {
 		return nil, fmt.Errorf("session '%s' not found", name)
 	}
+	if c.Backend() != nil {
+		return c.getBackendSession(name)
+	}
 
 	// Get session info
 	sep := FieldSeparator
@@ -839,6 +848,25 @@ func (c *Client) GetSession(name string) (*Session, error) {
 	return session, nil
 }
 
+// getBackendSession is GetSession for a client with a pane backend.
+func (c *Client) getBackendSession(name string) (*Session, error) {
+	sessions, err := c.ListSessions()
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range sessions {
+		if s.Name != name {
+			continue
+		}
+		session := s
+		if session.Panes, err = c.GetPanes(name); err != nil {
+			return nil, err
+		}
+		return &session, nil
+	}
+	return nil, fmt.Errorf("session '%s' not found", name)
+}
+
 // GetSession returns detailed info about a session (default client)
 func GetSession(name string) (*Session, error) {
 	return DefaultClient.GetSession(name)
@@ -878,6 +906,10 @@ func (c *Client) CreateSessionWithHistoryLimitContext(ctx context.Context, name,
 	if err := ValidateSessionName(name); err != nil {
 		return fmt.Errorf("invalid session name: %w", err)
 	}
+	if b := c.Backend(); b != nil {
+		// Backends size their own scrollback.
+		return b.NewSession(ctx, name, directory)
+	}
 	if err := c.RunSilentContext(ctx, "new-session", "-d", "-s", name, "-c", directory); err != nil {
 		return err
 	}
@@ -927,6 +959,17 @@ func (c *Client) GetPanes(session string) ([]Pane, error) {
 
 // GetPanesContext returns all panes in a session with cancellation support.
 func (c *Client) GetPanesContext(ctx context.Context, session string) ([]Pane, error) {
+	if b := c.Backend(); b != nil {
+		activity, err := b.ListPanes(ctx, session)
+		if err != nil {
+			return nil, err
+		}
+		var panes []Pane
+		for _, pa := range backendPanes(activity) {
+			panes = append(panes, pa.Pane)
+		}
+		return panes, nil
+	}
 	sep := FieldSeparator
 	format := fmt.Sprintf("#{pane_id}%[1]s#{pane_index}%[1]s#{pane_title}%[1]s#{pane_current_command}%[1]s#{pane_width}%[1]s#{pane_height}%[1]s#{pane_active}%[1]s#{pane_pid}%[1]s#{window_index}", sep)
 	output, err := c.RunContext(ctx, "list-panes", "-s", "-t", TargetSession(session), "-F", format)
@@ -1147,6 +1190,21 @@ func waitForPaneProcessStartContext(
 
 // GetAllPanesContext returns all panes from all sessions, grouped by session name.
 func (c *Client) GetAllPanesContext(ctx context.Context) (map[string][]Pane, error) {
+	if c.Backend() != nil {
+		sessions, err := c.ListSessionsContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		panesBySession := make(map[string][]Pane, len(sessions))
+		for _, s := range sessions {
+			panes, err := c.GetPanesContext(ctx, s.Name)
+			if err != nil {
+				return nil, err
+			}
+			panesBySession[s.Name] = panes
+		}
+		return panesBySession, nil
+	}
 	sep := FieldSeparator
 	// Add session_name at the beginning
 	format := fmt.Sprintf("#{session_name}%[1]s#{pane_id}%[1]s#{pane_index}%[1]s#{pane_title}%[1]s#{pane_current_command}%[1]s#{pane_width}%[1]s#{pane_height}%[1]s#{pane_active}%[1]s#{pane_pid}%[1]s#{window_index}", sep)
@@ -1209,6 +1267,10 @@ func (c *Client) GetFirstWindow(session string) (int, error) {
 // GetFirstWindowContext returns the first window index for a session with
 // cancellation support.
 func (c *Client) GetFirstWindowContext(ctx context.Context, session string) (int, error) {
+	if c.Backend() != nil {
+		// Backend sessions are a single window.
+		return 0, nil
+	}
 	output, err := c.RunContext(ctx, "list-windows", "-t", TargetSession(session), "-F", "#{window_index}")
 	if err != nil {
 		return 0, err
@@ -1244,6 +1306,9 @@ func (c *Client) SplitWindowContext(ctx context.Context, session string, directo
 	if ctx == nil {
 		return "", errors.New("tmux split window context is required")
 	}
+	if b := c.Backend(); b != nil {
+		return b.SplitPane(ctx, session, directory)
+	}
 	firstWin, err := c.GetFirstWindowContext(ctx, session)
 	if err != nil {
 		return "", err
@@ -1302,6 +1367,9 @@ func (c *Client) SetPaneTitleContext(ctx context.Context, paneID, title string)
 			return fmt.Errorf("pane title contains disallowed control character 0x%02x", r)
 		}
 	}
+	if b := c.Backend(); b != nil {
+		return b.SetPaneTitle(ctx, paneID, title)
+	}
 	selectErr := c.RunSilentContext(ctx, "select-pane", "-t", ExactTarget(paneID), "-T", title)
 	if selectErr != nil && ClassifyCommandError(selectErr).Kind == CommandErrorPaneNotFound {
 		// On busy tmux servers, newly-created panes can transiently fail to resolve by ID.
@@ -1437,7 +1505,13 @@ func (c *Client) SendKeysWithDelayContext(ctx context.Context, target, keys stri
 	// Send large payloads in chunks to avoid ARG_MAX limits or tmux buffer issues
 	const chunkSize = 4096
 
-	if len(keys) <= chunkSize {
+	if b := c.Backend(); b != nil {
+		if keys != "" {
+			if err := b.SendKeys(ctx, target, keys, true); err != nil {
+				return err
+			}
+		}
+	} else if len(keys) <= chunkSize {
 		if err := c.RunSilentContext(ctx, "send-keys", "-t", ExactTarget(target), "-l", "--", escapeTrailingSemicolon(keys)); err != nil {
 			return err
 		}
@@ -1475,11 +1549,19 @@ func (c *Client) SendKeysWithDelayContext(ctx context.Context, target, keys stri
 		}
 		// Use "Enter" instead of "C-m" (Ctrl+M) because some TUIs (e.g., Codex)
 		// distinguish between the Enter key and the carriage return control character.
-		return c.RunSilentContext(ctx, "send-keys", "-t", ExactTarget(target), "Enter")
+		return c.pressKey(ctx, target, "Enter")
 	}
 	return nil
 }
 
+// pressKey sends one tmux key name to target.
+func (c *Client) pressKey(ctx context.Context, target, key string) error {
+	if b := c.Backend(); b != nil {
+		return b.SendKeys(ctx, target, key, false)
+	}
+	return c.RunSilentContext(ctx, "send-keys", "-t", ExactTarget(target), key)
+}
+
 func waitForSendDelay(ctx context.Context, delay time.Duration) error {
 	if err := ctx.Err(); err != nil {
 		return err
@@ -1631,6 +1713,18 @@ func (c *Client) SendBufferWithDelayContext(ctx context.Context, target, content
 	if enterDelay < 0 {
 		return errors.New("tmux Enter delay cannot be negative")
 	}
+	if b := c.Backend(); b != nil {
+		if err := b.Paste(ctx, target, content); err != nil {
+			return fmt.Errorf("paste buffer: %w", err)
+		}
+		if enter {
+			if err := waitForSendDelay(ctx, enterDelay); err != nil {
+				return err
+			}
+			return c.pressKey(ctx, target, "Enter")
+		}
+		return nil
+	}
 	bufferName := newSendBufferName()
 
 	// Load content into a tmux buffer
@@ -1662,7 +1756,7 @@ func (c *Client) SendBufferWithDelayContext(ctx context.Context, target, content
 		if err := waitForSendDelay(ctx, enterDelay); err != nil {
 			return err
 		}
-		return c.RunSilentContext(ctx, "send-keys", "-t", ExactTarget(target), "Enter")
+		return c.pressKey(ctx, target, "Enter")
 	}
 	return nil
 }
@@ -1690,7 +1784,7 @@ func (c *Client) loadBufferLocalContext(ctx context.Context, bufferName, content
 	binary := BinaryPath()
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
-	cmd := exec.CommandContext(ctx, binary, "load-buffer", "-b", bufferName, "-")
+	cmd := exec.CommandContext(ctx, binary, c.serverArgs([]string{"load-buffer", "-b", bufferName, "-"})...)
 	cmd.Stdin = strings.NewReader(content)
 	cmd.WaitDelay = 2 * time.Second
 	var stderr bytes.Buffer
@@ -1709,7 +1803,7 @@ func (c *Client) loadBufferLocalContext(ctx context.Context, bufferName, content
 func (c *Client) loadBufferRemoteContext(ctx context.Context, bufferName, content string) error {
 	// For remote, we need to pipe the content through ssh's stdin
 	// instead of passing it on the command line to avoid ARG_MAX limits.
-	remoteCmd := fmt.Sprintf("tmux load-buffer -b %s -", ShellQuote(bufferName))
+	remoteCmd := buildRemoteShellCommand("tmux", c.serverArgs([]string{"load-buffer", "-b", bufferName, "-"})...)
 	sshArgs := []string{"--", c.Remote, "/bin/sh", "-c", ShellQuote(remoteCmd)}
 
 	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
@@ -1734,6 +1828,12 @@ func SendBuffer(target, content string, enter bool) error {
 	return DefaultClient.SendBuffer(target, content, enter)
 }
 
+// SendBufferWithDelayContext sends content using the buffer mechanism with
+// cancellation (default client).
+func SendBufferWithDelayContext(ctx context.Context, target, content string, enter bool, enterDelay time.Duration) error {
+	return DefaultClient.SendBufferWithDelayContext(ctx, target, content, enter, enterDelay)
+}
+
 // SendKeysForAgent sends keys to a pane using the appropriate method for the agent type.
 // It uses buffer-based paste for agents/content combinations that misbehave with raw
 // send-keys (for example multiline Claude/Gemini prompts or large/multiline Codex prompts).
@@ -1869,14 +1969,14 @@ func (c *Client) SendKeysForAgentDoubleEnterContext(ctx context.Context, target,
 		return err
 	}
 	// First Enter
-	if err := c.RunSilentContext(ctx, "send-keys", "-t", ExactTarget(target), "Enter"); err != nil {
+	if err := c.pressKey(ctx, target, "Enter"); err != nil {
 		return err
 	}
 	if err := waitForSendDelay(ctx, DoubleEnterSecondDelay); err != nil {
 		return err
 	}
 	// Second Enter
-	if err := c.RunSilentContext(ctx, "send-keys", "-t", ExactTarget(target), "Enter"); err != nil {
+	if err := c.pressKey(ctx, target, "Enter"); err != nil {
 		return err
 	}
 	return nil
@@ -2113,7 +2213,7 @@ func (c *Client) ClearComposerContext(ctx context.Context, target string, agentT
 				return false, false, err
 			}
 		}
-		if err := c.RunSilentContext(ctx, "send-keys", "-t", ExactTarget(target), key); err != nil {
+		if err := c.pressKey(ctx, target, key); err != nil {
 			return false, false, fmt.Errorf("send composer clear key %q: %w", key, err)
 		}
 	}
@@ -2255,7 +2355,7 @@ func (c *Client) VerifyGrokSubmissionContext(ctx context.Context, target, messag
 	// The composer still holds the payload: finish the job with one bare
 	// Enter, then poll for the submission to take effect. An extra Enter into
 	// an already-submitted grok composer is a no-op (verified live).
-	if err := c.RunSilentContext(ctx, "send-keys", "-t", ExactTarget(target), "Enter"); err != nil {
+	if err := c.pressKey(ctx, target, "Enter"); err != nil {
 		return false, true, fmt.Errorf("send rescue Enter to grok pane: %w", err)
 	}
 	for poll := 0; poll < codexVerifyMaxPolls; poll++ {
@@ -2306,7 +2406,7 @@ func (c *Client) VerifyCodexSubmissionContext(ctx context.Context, target, messa
 
 	// The composer still holds the payload: finish the job with one bare
 	// Enter, then poll for the submission to take effect.
-	if err := c.RunSilentContext(ctx, "send-keys", "-t", ExactTarget(target), "Enter"); err != nil {
+	if err := c.pressKey(ctx, target, "Enter"); err != nil {
 		return false, true, fmt.Errorf("send rescue Enter to codex pane: %w", err)
 	}
 	for poll := 0; poll < codexVerifyMaxPolls; poll++ {
@@ -2408,13 +2508,13 @@ func (c *Client) VerifyClaudeSubmissionContext(ctx context.Context, target, mess
 
 	// Dismiss a possible picker, then finish the submission. A single Escape
 	// is deliberate: double-Escape opens Claude Code's message-history jump.
-	if err := c.RunSilentContext(ctx, "send-keys", "-t", ExactTarget(target), "Escape"); err != nil {
+	if err := c.pressKey(ctx, target, "Escape"); err != nil {
 		return false, true, fmt.Errorf("send rescue Escape to claude pane: %w", err)
 	}
 	if err := waitForSendDelay(ctx, 300*time.Millisecond); err != nil {
 		return false, true, err
 	}
-	if err := c.RunSilentContext(ctx, "send-keys", "-t", ExactTarget(target), "Enter"); err != nil {
+	if err := c.pressKey(ctx, target, "Enter"); err != nil {
 		return false, true, fmt.Errorf("send rescue Enter to claude pane: %w", err)
 	}
 	for poll := 0; poll < codexVerifyMaxPolls; poll++ {
@@ -2454,6 +2554,9 @@ func (c *Client) SendKeyName(target, keyName string) error {
 // The "--" stops a key name that begins with a dash from being parsed by tmux
 // as a flag instead of a key.
 func (c *Client) SendKeyNameContext(ctx context.Context, target, keyName string) error {
+	if b := c.Backend(); b != nil {
+		return b.SendKeys(ctx, target, keyName, false)
+	}
 	return c.RunSilentContext(ctx, "send-keys", "-t", ExactTarget(target), "--", keyName)
 }
 
@@ -2462,9 +2565,16 @@ func SendKeyName(target, keyName string) error {
 	return DefaultClient.SendKeyName(target, keyName)
 }
 
+// SendKeyNameContext sends a tmux key name with cancellation (default client).
+func SendKeyNameContext(ctx context.Context, target, keyName string) error {
+	return DefaultClient.SendKeyNameContext(ctx, target, keyName)
+}
+
 // SendInterrupt sends Ctrl+C to a pane
 func (c *Client) SendInterrupt(target string) error {
-	return c.RunSilent("send-keys", "-t", ExactTarget(target), "C-c")
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultCommandTimeout)
+	defer cancel()
+	return c.pressKey(ctx, target, "C-c")
 }
 
 // SendInterrupt sends Ctrl+C to a pane (default client)
@@ -2474,7 +2584,9 @@ func SendInterrupt(target string) error {
 
 // SendEOF sends Ctrl+D (EOF) to a pane
 func (c *Client) SendEOF(target string) error {
-	return c.RunSilent("send-keys", "-t", ExactTarget(target), "C-d")
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultCommandTimeout)
+	defer cancel()
+	return c.pressKey(ctx, target, "C-d")
 }
 
 // DisplayMessage shows a message in the tmux status line.
@@ -2515,12 +2627,19 @@ func BuildPaneCommand(projectDir, agentCommand string) (string, error) {
 
 // AttachOrSwitch attaches to a session or switches if already in tmux
 func (c *Client) AttachOrSwitch(session string) error {
+	if b := c.Backend(); b != nil {
+		a, ok := b.(PaneAttacher)
+		if !ok {
+			return fmt.Errorf("%s backend cannot attach to %s", b.Name(), session)
+		}
+		return a.Attach(session)
+	}
 	if c.Remote == "" {
 		if InTmux() {
 			return c.RunSilent("switch-client", "-t", TargetSession(session))
 		}
 		// Interactive attach needs stdin/stdout, so use exec directly for local
-		cmd := exec.Command(BinaryPath(), "attach", "-t", TargetSession(session))
+		cmd := exec.Command(BinaryPath(), c.serverArgs([]string{"attach", "-t", TargetSession(session)})...)
 		cmd.Stdin = os.Stdin
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
@@ -2529,7 +2648,7 @@ func (c *Client) AttachOrSwitch(session string) error {
 
 	// Remote attach
 	// ssh -t user@host tmux attach -t session
-	remoteCmd := buildRemoteShellCommand("tmux", "attach", "-t", TargetSession(session))
+	remoteCmd := buildRemoteShellCommand("tmux", c.serverArgs([]string{"attach", "-t", TargetSession(session)})...)
 	// Use "--" to prevent Remote from being parsed as an ssh option.
 	sshArgs := []string{"-t", "--", c.Remote, remoteCmd}
 	cmd := exec.Command("ssh", sshArgs...)
@@ -2546,6 +2665,11 @@ func AttachOrSwitch(session string) error {
 
 // KillSession kills a tmux session
 func (c *Client) KillSession(session string) error {
+	if b := c.Backend(); b != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultCommandTimeout)
+		defer cancel()
+		return b.KillSession(ctx, session)
+	}
 	return c.RunSilent("kill-session", "-t", TargetSession(session))
 }
 
@@ -2564,6 +2688,9 @@ func (c *Client) KillPaneContext(ctx context.Context, paneID string) error {
 	if ctx == nil {
 		return errors.New("tmux kill pane context is required")
 	}
+	if b := c.Backend(); b != nil {
+		return b.KillPane(ctx, paneID)
+	}
 	return c.RunSilentContext(ctx, "kill-pane", "-t", ExactTarget(paneID))
 }
 
@@ -2588,6 +2715,10 @@ func (c *Client) ApplyTiledLayoutContext(ctx context.Context, session string) er
 	if ctx == nil {
 		return errors.New("tmux layout context is required")
 	}
+	if c.Backend() != nil {
+		// Backend panes are not arranged on a shared screen.
+		return nil
+	}
 	output, err := c.RunContext(ctx, "list-windows", "-t", TargetSession(session), "-F", "#{window_index}")
 	if err != nil {
 		return err
@@ -2665,7 +2796,13 @@ func (c *Client) CapturePaneOutputContext(ctx context.Context, target string, li
 		lines = -lines
 	}
 	started := time.Now()
-	output, err := c.RunContext(ctx, "capture-pane", "-t", ExactTarget(target), "-p", "-S", fmt.Sprintf("-%d", lines))
+	var output string
+	var err error
+	if b := c.Backend(); b != nil {
+		output, err = b.Capture(ctx, target, lines, false)
+	} else {
+		output, err = c.RunContext(ctx, "capture-pane", "-t", ExactTarget(target), "-p", "-S", fmt.Sprintf("-%d", lines))
+	}
 	c.recordCaptureBackpressure(target, lines, time.Since(started), err)
 	return output, err
 }
@@ -2691,7 +2828,13 @@ func (c *Client) CapturePaneVisible(target string) (string, error) {
 // caller cancellation.
 func (c *Client) CapturePaneVisibleContext(ctx context.Context, target string) (string, error) {
 	started := time.Now()
-	output, err := c.RunContext(ctx, "capture-pane", "-t", ExactTarget(target), "-p", "-S", "0")
+	var output string
+	var err error
+	if b := c.Backend(); b != nil {
+		output, err = b.Capture(ctx, target, 0, true)
+	} else {
+		output, err = c.RunContext(ctx, "capture-pane", "-t", ExactTarget(target), "-p", "-S", "0")
+	}
 	c.recordCaptureBackpressure(target, 0, time.Since(started), err)
 	return output, err
 }
@@ -2816,6 +2959,13 @@ type PaneActivity struct {
 
 // GetPanesWithActivityContext returns all panes in a session with their activity times with cancellation support.
 func (c *Client) GetPanesWithActivityContext(ctx context.Context, session string) ([]PaneActivity, error) {
+	if b := c.Backend(); b != nil {
+		panes, err := b.ListPanes(ctx, session)
+		if err != nil {
+			return nil, err
+		}
+		return backendPanes(panes), nil
+	}
 	sep := FieldSeparator
 	format := fmt.Sprintf("#{pane_id}%[1]s#{pane_index}%[1]s#{pane_title}%[1]s#{pane_current_command}%[1]s#{pane_width}%[1]s#{pane_height}%[1]s#{pane_active}%[1]s#{window_activity}%[1]s#{pane_pid}%[1]s#{window_index}", sep)
 	output, err := c.RunContext(ctx, "list-panes", "-s", "-t", TargetSession(session), "-F", format)
@@ -2910,7 +3060,13 @@ func parsePaneFromParts(parts1, parts2 []string) (*Pane, error) {
 		Active:      active,
 		PID:         pid,
 	}
+	classifyPane(pane)
+	return pane, nil
+}
 
+// classifyPane fills in the agent type, NTM index, variant and tags of a pane
+// from its title, command and process tree.
+func classifyPane(pane *Pane) {
 	// Parse pane title using regex to extract type, index, variant, and tags
 	pane.Type, pane.NTMIndex, pane.Variant, pane.Tags = parseAgentFromTitle(pane.Title)
 
@@ -2929,8 +3085,6 @@ func parsePaneFromParts(parts1, parts2 []string) (*Pane, error) {
 			}
 		}
 	}
-
-	return pane, nil
 }
 
 func parsePaneActivityTimestamp(raw string, now time.Time) (time.Time, error) {
@@ -0,0 +1,319 @@
+package turns
+
+import (
+	"regexp"
+	"strings"
+)
+
+// lineKind is a lexer's classification of one output line.
+type lineKind int
+
+const (
+	// lineText continues the current segment, or starts assistant text.
+	lineText lineKind = iota
+	lineBlank
+	// lineChrome is TUI decoration (spinners, footers, box borders) that
+	// ends the current segment and is dropped.
+	lineChrome
+	linePrompt
+	lineAssistant
+	lineToolCall
+	lineToolResult
+	lineError
+)
+
+func (k lineKind) segmentKind() Kind {
+	switch k {
+	case linePrompt:
+		return KindPrompt
+	case lineToolCall:
+		return KindToolCall
+	case lineToolResult:
+		return KindToolResult
+	case lineError:
+		return KindError
+	}
+	return KindAssistant
+}
+
+// classified is a classified line. For marker lines text has the marker
+// stripped; for lineText it is the whole line.
+type classified struct {
+	kind lineKind
+	text string
+	tool string
+	args string
+	// cont marks text that continues the current segment regardless of
+	// indentation (e.g. the inside of a Gemini tool box).
+	cont bool
+	// busy marks chrome showing the agent at work (spinners, "esc to
+	// interrupt"), which means the prompt above it was submitted.
+	busy bool
+}
+
+// lexer classifies lines for one agent dialect. Lexers may keep state across
+// lines and are used for a single parse.
+type lexer interface {
+	classify(line string) classified
+}
+
+func newLexer(dialect string) lexer {
+	switch dialect {
+	case "cc":
+		return claudeLexer{}
+	case "cod":
+		return codexLexer{}
+	case "agy":
+		return &geminiLexer{}
+	}
+	return genericLexer{}
+}
+
+// detectDialect guesses the dialect from the markers each TUI uses.
+func detectDialect(output string) string {
+	switch {
+	case strings.Contains(output, "⏺") || strings.Contains(output, "⎿"):
+		return "cc"
+	case strings.Contains(output, "└") && strings.Contains(output, "• "):
+		return "cod"
+	case strings.Contains(output, "✦ ") || (strings.Contains(output, "╭─") && strings.Contains(output, "│ ✓")):
+		return "agy"
+	}
+	return "generic"
+}
+
+var (
+	ruleLineRe   = regexp.MustCompile(`^\s*[─━═-]{8,}\s*$`)
+	spinnerRe    = regexp.MustCompile(`^\s*[⠋⠙⠹⠸⠼⠴⠦⠧⠇⠏◐◑◒◓]`)
+	boxBorderRe  = regexp.MustCompile(`^\s*[╭╰┌└]─`)
+	genericErrRe = regexp.MustCompile(`(?i)^\s*(error|fatal|panic)(:|\s*\[)`)
+)
+
+// busyLine reports spinner and interrupt-hint chrome.
+func busyLine(line string, spinner ...*regexp.Regexp) bool {
+	for _, re := range append(spinner, spinnerRe) {
+		if re.MatchString(line) {
+			return true
+		}
+	}
+	return footerLine(line, []string{"esc to interrupt", "ctrl+c to interrupt", "esc to cancel"})
+}
+
+// footerLine reports status-bar text shared by the agent TUIs.
+func footerLine(line string, markers []string) bool {
+	lower := strings.ToLower(line)
+	for _, m := range markers {
+		if strings.Contains(lower, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// ---------------------------------------------------------------------------
+// Claude Code
+// ---------------------------------------------------------------------------
+
+var (
+	claudePromptRe    = regexp.MustCompile(`^[>❯][\s\x{00a0}]+(\S.*)$`)
+	claudeEmptyInput  = regexp.MustCompile(`^[>❯][\s\x{00a0}]*$`)
+	claudeMarkerRe    = regexp.MustCompile(`^[⏺●*][\s\x{00a0}]+(.*)$`)
+	claudeToolRe      = regexp.MustCompile(`^([\w.:-]+(?: \(MCP\))?)\((.*?)\)?$`)
+	claudeResultRe    = regexp.MustCompile(`^\s*⎿[\s\x{00a0}]*(.*)$`)
+	claudeSpinnerRe   = regexp.MustCompile(`^\s*[✻✶✳✢✽✦*·]\s+\S+…`)
+	claudeCompleteRe  = regexp.MustCompile(`^\s*[✻✶✳✢✽✦*]\s+\p{Lu}\p{Ll}+\s+for\s+(?:\d+\s*[hms]\s*)+$`)
+	claudeAPIErrorRe  = regexp.MustCompile(`^API Error\b`)
+	claudeFooterMarks = []string{"⏵⏵", "? for shortcuts", "bypass permissions", "esc to interrupt", "ctrl+c to interrupt", "auto-accept edits", "plan mode on", "context left until auto-compact", "shift+tab to cycle"}
+)
+
+// claudeLexer reads Claude Code: "❯ prompt", "⏺ text", "⏺ Tool(args)" calls
+// and "⎿ result" blocks.
+type claudeLexer struct{}
+
+func (claudeLexer) classify(line string) classified {
+	trimmed := strings.TrimSpace(line)
+	switch {
+	case trimmed == "":
+		return classified{kind: lineBlank}
+	case busyLine(line, claudeSpinnerRe):
+		return classified{kind: lineChrome, busy: true}
+	case ruleLineRe.MatchString(line), boxBorderRe.MatchString(line), strings.HasPrefix(trimmed, "│"),
+		claudeEmptyInput.MatchString(line), claudeCompleteRe.MatchString(line), footerLine(line, claudeFooterMarks):
+		return classified{kind: lineChrome}
+	}
+	if m := claudePromptRe.FindStringSubmatch(line); m != nil {
+		return classified{kind: linePrompt, text: m[1]}
+	}
+	if m := claudeResultRe.FindStringSubmatch(line); m != nil {
+		return classified{kind: lineToolResult, text: m[1]}
+	}
+	if m := claudeMarkerRe.FindStringSubmatch(line); m != nil {
+		rest := m[1]
+		if t := claudeToolRe.FindStringSubmatch(rest); t != nil {
+			return classified{kind: lineToolCall, text: rest, tool: t[1], args: t[2]}
+		}
+		if claudeAPIErrorRe.MatchString(rest) || errorTextRe.MatchString(rest) {
+			return classified{kind: lineError, text: rest}
+		}
+		return classified{kind: lineAssistant, text: rest}
+	}
+	return classified{kind: lineText, text: line}
+}
+
+// ---------------------------------------------------------------------------
+// Codex
+// ---------------------------------------------------------------------------
+
+var (
+	codexPromptRe    = regexp.MustCompile(`^[›▌>][\s\x{00a0}]+(\S.*)$`)
+	codexEmptyInput  = regexp.MustCompile(`^[›▌>][\s\x{00a0}]*$`)
+	codexMarkerRe    = regexp.MustCompile(`^[•●][\s\x{00a0}]+(.*)$`)
+	codexToolRe      = regexp.MustCompile(`^(Ran|Running|Explored|Exploring|Edited|Editing|Added|Deleted|Read|Reading|Searched|Listed|Called|Calling|Applied patch|Updated Plan|Waited|Search|List)\b\s*(.*)$`)
+	codexResultRe    = regexp.MustCompile(`^\s*[└⎿][\s\x{00a0}]*(.*)$`)
+	codexWorkingRe   = regexp.MustCompile(`(?i)^\s*[•·◐◑◒◓⬤]\s*(?:Working|Waiting\s+for\s+background|Thinking)\b`)
+	codexErrorRe     = regexp.MustCompile(`^(?:■|⚠)[\s\x{00a0}]*(.*)$`)
+	codexFooterMarks = []string{"context left", "? for shortcuts", "esc to interrupt", "token usage:", "worked for", "openai codex", "/status", "ctrl+j newline"}
+)
+
+// codexLexer reads Codex: "› prompt", "• text", "• Ran cmd" calls with
+// "└ output", and "• Edited path (+N -M)" followed by the patch.
+type codexLexer struct{}
+
+func (codexLexer) classify(line string) classified {
+	trimmed := strings.TrimSpace(line)
+	switch {
+	case trimmed == "":
+		return classified{kind: lineBlank}
+	case busyLine(line, codexWorkingRe):
+		return classified{kind: lineChrome, busy: true}
+	case ruleLineRe.MatchString(line), codexEmptyInput.MatchString(line),
+		boxBorderRe.MatchString(line) && !codexResultRe.MatchString(line), strings.HasPrefix(trimmed, "│"),
+		footerLine(line, codexFooterMarks):
+		return classified{kind: lineChrome}
+	}
+	if m := codexPromptRe.FindStringSubmatch(line); m != nil {
+		return classified{kind: linePrompt, text: m[1]}
+	}
+	if m := codexResultRe.FindStringSubmatch(line); m != nil {
+		return classified{kind: lineToolResult, text: m[1]}
+	}
+	if m := codexErrorRe.FindStringSubmatch(line); m != nil {
+		return classified{kind: lineError, text: m[1]}
+	}
+	if m := codexMarkerRe.FindStringSubmatch(line); m != nil {
+		rest := m[1]
+		if t := codexToolRe.FindStringSubmatch(rest); t != nil {
+			return classified{kind: lineToolCall, text: rest, tool: t[1], args: t[2]}
+		}
+		return classified{kind: lineAssistant, text: rest}
+	}
+	if genericErrRe.MatchString(line) && line == trimmed {
+		return classified{kind: lineError, text: trimmed}
+	}
+	return classified{kind: lineText, text: line}
+}
+
+// ---------------------------------------------------------------------------
+// Antigravity / Gemini
+// ---------------------------------------------------------------------------
+
+var (
+	geminiPromptRe    = regexp.MustCompile(`^>[\s\x{00a0}]+(\S.*)$`)
+	geminiMarkerRe    = regexp.MustCompile(`^✦[\s\x{00a0}]+(.*)$`)
+	geminiToolRe      = regexp.MustCompile(`^([✓✔✕✗x?⊷o●])\s+([A-Z][\w]*(?:\s[A-Z][\w]*)*)\s*(.*)$`)
+	geminiErrorRe     = regexp.MustCompile(`^✕[\s\x{00a0}]+(.*)$`)
+	geminiBoxTopRe    = regexp.MustCompile(`^\s*╭─`)
+	geminiBoxBottomRe = regexp.MustCompile(`^\s*╰─`)
+	geminiBoxLineRe   = regexp.MustCompile(`^\s*│ ?(.*?)\s*│?\s*$`)
+	geminiFooterMarks = []string{"esc to cancel", "no sandbox", "context left)", "accepting edits", "yolo mode", "type your message", "/model", "using:"}
+)
+
+// geminiLexer reads the Antigravity and Gemini TUIs: "> prompt", "✦ text"
+// and boxed tool calls whose header row carries a status glyph (✓ done,
+// ✕ failed) and whose body is the result.
+type geminiLexer struct {
+	inBox     bool
+	boxHeader bool // the next non-empty box row is the header
+	boxTool   bool // the box is a tool call
+	boxError  bool // the tool call failed
+	inResult  bool // the box body has started
+}
+
+func (g *geminiLexer) classify(line string) classified {
+	trimmed := strings.TrimSpace(line)
+	if g.inBox {
+		if geminiBoxBottomRe.MatchString(line) {
+			g.inBox = false
+			return classified{kind: lineChrome}
+		}
+		m := geminiBoxLineRe.FindStringSubmatch(line)
+		if m == nil {
+			// A box that was never closed in the capture.
+			g.inBox = false
+			return g.classify(line)
+		}
+		content := m[1]
+		if strings.TrimSpace(content) == "" {
+			return classified{kind: lineBlank}
+		}
+		if g.boxHeader {
+			g.boxHeader = false
+			if t := geminiToolRe.FindStringSubmatch(content); t != nil {
+				g.boxTool = true
+				g.boxError = t[1] == "✕" || t[1] == "✗" || t[1] == "x"
+				return classified{kind: lineToolCall, text: strings.TrimSpace(t[2] + " " + t[3]), tool: t[2], args: t[3]}
+			}
+			// The input box ("│ > Type your message │") and other framed
+			// chrome.
+			return classified{kind: lineChrome}
+		}
+		if g.boxTool && !g.inResult {
+			g.inResult = true
+			if g.boxError {
+				return classified{kind: lineError, text: content, cont: true}
+			}
+			return classified{kind: lineToolResult, text: content, cont: true}
+		}
+		return classified{kind: lineText, text: content, cont: true}
+	}
+
+	switch {
+	case trimmed == "":
+		return classified{kind: lineBlank}
+	case geminiBoxTopRe.MatchString(line):
+		*g = geminiLexer{inBox: true, boxHeader: true}
+		return classified{kind: lineChrome}
+	case busyLine(line):
+		return classified{kind: lineChrome, busy: true}
+	case ruleLineRe.MatchString(line), footerLine(line, geminiFooterMarks):
+		return classified{kind: lineChrome}
+	}
+	if m := geminiPromptRe.FindStringSubmatch(line); m != nil {
+		return classified{kind: linePrompt, text: m[1]}
+	}
+	if m := geminiMarkerRe.FindStringSubmatch(line); m != nil {
+		return classified{kind: lineAssistant, text: m[1]}
+	}
+	if m := geminiErrorRe.FindStringSubmatch(line); m != nil {
+		return classified{kind: lineError, text: m[1]}
+	}
+	return classified{kind: lineText, text: line}
+}
+
+// ---------------------------------------------------------------------------
+// Generic
+// ---------------------------------------------------------------------------
+
+// genericLexer treats output as assistant text, splitting out error lines;
+// code blocks and diffs are still recognized.
+type genericLexer struct{}
+
+func (genericLexer) classify(line string) classified {
+	switch {
+	case strings.TrimSpace(line) == "":
+		return classified{kind: lineBlank}
+	case genericErrRe.MatchString(line):
+		return classified{kind: lineError, text: strings.TrimSpace(line)}
+	}
+	return classified{kind: lineText, text: line}
+}
@@ -0,0 +1,75 @@
+package turns
+
+import (
+	"sync"
+)
+
+// Tracker reports the segments that appear in successive captures of panes,
+// for publishing turn events from a polling or streaming loop.
+type Tracker struct {
+	mu    sync.Mutex
+	panes map[string]map[segmentKey]int
+}
+
+// NewTracker creates an empty tracker.
+func NewTracker() *Tracker {
+	return &Tracker{panes: make(map[string]map[segmentKey]int)}
+}
+
+// segmentKey identifies a segment by content, since line numbers shift as
+// scrollback moves.
+type segmentKey struct {
+	kind Kind
+	tool string
+	text string
+}
+
+// Observe parses output for pane and returns segments not present in the
+// previous observation, each with the index of its turn. The first
+// observation of a pane only records a baseline, and the first segment of a
+// capture is never reported since it may be cut off. The final segment is held
+// back until a later one starts, so text that is still streaming is reported
+// once, complete.
+func (t *Tracker) Observe(pane, agentType, output string) []TurnSegment {
+	turns := Parse(agentType, output)
+	var all []TurnSegment
+	for _, turn := range turns {
+		for _, seg := range turn.Segments {
+			all = append(all, TurnSegment{Turn: turn.Index, Segment: seg})
+		}
+	}
+	if len(all) > 0 {
+		all = all[:len(all)-1]
+	}
+
+	seen := make(map[segmentKey]int, len(all))
+	var fresh []TurnSegment
+	t.mu.Lock()
+	prev, known := t.panes[pane]
+	for i, ts := range all {
+		key := segmentKey{kind: ts.Kind, tool: ts.Tool, text: ts.Text}
+		seen[key]++
+		// Identical segments (two "Ran go test" calls) are told apart by
+		// how often they occur. The first segment may be cut off by the
+		// top of the capture, so its text changes without being new.
+		if known && i > 0 && seen[key] > prev[key] {
+			fresh = append(fresh, ts)
+		}
+	}
+	t.panes[pane] = seen
+	t.mu.Unlock()
+	return fresh
+}
+
+// Forget drops the state kept for pane.
+func (t *Tracker) Forget(pane string) {
+	t.mu.Lock()
+	delete(t.panes, pane)
+	t.mu.Unlock()
+}
+
+// TurnSegment is a segment together with the index of its turn.
+type TurnSegment struct {
+	Turn int `json:"turn"`
+	Segment
+}
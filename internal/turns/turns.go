@@ -0,0 +1,458 @@
+// Package turns segments agent pane output into structured conversation
+// turns: user prompts, assistant text, tool calls and their results, code
+// blocks, diffs and errors. Robot consumers, the REST API and pipelines use it
+// instead of regex-scraping raw capture-pane scrollback.
+package turns
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Dicklesworthstone/ntm/internal/agent"
+	"github.com/Dicklesworthstone/ntm/internal/codeblock"
+)
+
+// Kind classifies a segment of pane output.
+type Kind string
+
+const (
+	KindPrompt     Kind = "user_prompt"
+	KindAssistant  Kind = "assistant"
+	KindToolCall   Kind = "tool_call"
+	KindToolResult Kind = "tool_result"
+	KindCode       Kind = "code"
+	KindDiff       Kind = "diff"
+	KindError      Kind = "error"
+)
+
+// Kinds lists every segment kind in display order.
+var Kinds = []Kind{KindPrompt, KindAssistant, KindToolCall, KindToolResult, KindCode, KindDiff, KindError}
+
+// ParseKind accepts a kind name or one of its short aliases (prompt, text,
+// tool, result).
+func ParseKind(s string) (Kind, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "user_prompt", "prompt", "user":
+		return KindPrompt, nil
+	case "assistant", "text":
+		return KindAssistant, nil
+	case "tool_call", "tool", "call":
+		return KindToolCall, nil
+	case "tool_result", "result":
+		return KindToolResult, nil
+	case "code":
+		return KindCode, nil
+	case "diff":
+		return KindDiff, nil
+	case "error":
+		return KindError, nil
+	}
+	return "", fmt.Errorf("unknown segment kind %q (want user_prompt, assistant, tool_call, tool_result, code, diff or error)", s)
+}
+
+// ParseKinds parses a comma-separated kind list. An empty list means all kinds.
+func ParseKinds(csv string) ([]Kind, error) {
+	var kinds []Kind
+	for _, part := range strings.Split(csv, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		k, err := ParseKind(part)
+		if err != nil {
+			return nil, err
+		}
+		kinds = append(kinds, k)
+	}
+	return kinds, nil
+}
+
+// Segment is one contiguous piece of a turn. StartLine and EndLine are
+// 1-based line numbers in the parsed output.
+type Segment struct {
+	Kind      Kind   `json:"kind"`
+	Text      string `json:"text"`
+	Tool      string `json:"tool,omitempty"`
+	Args      string `json:"args,omitempty"`
+	File      string `json:"file,omitempty"`
+	Language  string `json:"language,omitempty"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+}
+
+// Turn is a user prompt and everything the agent produced in response.
+// Output that precedes the first visible prompt forms a turn without one.
+type Turn struct {
+	Index    int       `json:"index"`
+	Prompt   string    `json:"prompt,omitempty"`
+	Segments []Segment `json:"segments"`
+}
+
+// Dialect names the parser used for an agent type: "cc", "cod", "agy"
+// (Antigravity and Gemini share a TUI) or "generic". Unknown agent types are
+// detected from the output itself.
+func Dialect(agentType, output string) string {
+	switch agent.AgentType(agentType).Canonical() {
+	case agent.AgentTypeClaudeCode:
+		return "cc"
+	case agent.AgentTypeCodex:
+		return "cod"
+	case agent.AgentTypeAntigravity, agent.AgentTypeGemini:
+		return "agy"
+	}
+	return detectDialect(output)
+}
+
+// Parse segments output captured from a pane running agentType.
+func Parse(agentType, output string) []Turn {
+	return group(segment(newLexer(Dialect(agentType, output)), output))
+}
+
+// Segments flattens turns into their segments in output order.
+func Segments(turns []Turn) []Segment {
+	var out []Segment
+	for _, t := range turns {
+		out = append(out, t.Segments...)
+	}
+	return out
+}
+
+// Last returns the most recent segment of kind.
+func Last(turns []Turn, kind Kind) (Segment, bool) {
+	for i := len(turns) - 1; i >= 0; i-- {
+		segs := turns[i].Segments
+		for j := len(segs) - 1; j >= 0; j-- {
+			if segs[j].Kind == kind {
+				return segs[j], true
+			}
+		}
+	}
+	return Segment{}, false
+}
+
+// LastByKind maps each kind present in turns to its most recent segment.
+func LastByKind(turns []Turn) map[Kind]Segment {
+	last := make(map[Kind]Segment)
+	for _, seg := range Segments(turns) {
+		last[seg.Kind] = seg
+	}
+	return last
+}
+
+// Filter keeps only segments of the given kinds, dropping turns left empty.
+// No kinds means no filtering.
+func Filter(turns []Turn, kinds ...Kind) []Turn {
+	if len(kinds) == 0 {
+		return turns
+	}
+	want := make(map[Kind]bool, len(kinds))
+	for _, k := range kinds {
+		want[k] = true
+	}
+	var out []Turn
+	for _, t := range turns {
+		var segs []Segment
+		for _, seg := range t.Segments {
+			if want[seg.Kind] {
+				segs = append(segs, seg)
+			}
+		}
+		if len(segs) > 0 {
+			t.Segments = segs
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// Tail keeps the last n turns (all when n <= 0).
+func Tail(turns []Turn, n int) []Turn {
+	if n <= 0 || len(turns) <= n {
+		return turns
+	}
+	return turns[len(turns)-n:]
+}
+
+// draft is a segment under construction; nums holds the output line number of
+// each entry in lines.
+type draft struct {
+	kind  Kind
+	tool  string
+	args  string
+	lines []string
+	nums  []int
+	// indentOnly limits continuation to indented lines.
+	indentOnly bool
+	// aligned marks lines that already share a margin (box contents), so
+	// dedent leaves them alone.
+	aligned bool
+}
+
+func (d *draft) add(text string, num int) {
+	d.lines = append(d.lines, text)
+	d.nums = append(d.nums, num)
+}
+
+// segment runs the lexer over output and post-processes the drafts into
+// segments.
+func segment(lx lexer, output string) []Segment {
+	raw := strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n")
+	var drafts []*draft
+	var cur *draft
+	var blanks []int
+	busyAt := -1
+	flush := func() {
+		if cur != nil {
+			drafts = append(drafts, cur)
+		}
+		cur, blanks = nil, nil
+	}
+	for i, line := range raw {
+		num := i + 1
+		line = strings.TrimRight(stripANSI(line), " \t\u00a0")
+		c := lx.classify(line)
+		switch c.kind {
+		case lineBlank:
+			if cur != nil {
+				blanks = append(blanks, num)
+			}
+			continue
+		case lineChrome:
+			flush()
+			if c.busy {
+				busyAt = len(drafts)
+			}
+			continue
+		case lineText:
+			indented := c.cont || strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")
+			if cur != nil && (indented || !cur.indentOnly) {
+				for _, b := range blanks {
+					cur.add("", b)
+				}
+				blanks = nil
+				cur.add(c.text, num)
+				continue
+			}
+			flush()
+			// Unmarked text has no marker column to dedent against.
+			cur = &draft{kind: KindAssistant, aligned: true}
+			cur.add(c.text, num)
+			continue
+		}
+		flush()
+		cur = &draft{kind: c.kind.segmentKind(), tool: c.tool, args: c.args, aligned: c.cont}
+		cur.indentOnly = c.kind != lineAssistant
+		cur.add(c.text, num)
+	}
+	flush()
+
+	// A prompt followed by nothing but idle chrome is the composer, not a
+	// submitted turn.
+	if n := len(drafts); n > 0 && drafts[n-1].kind == KindPrompt && busyAt != n {
+		drafts = drafts[:n-1]
+	}
+
+	var segs []Segment
+	var lastTool, lastArgs string
+	for _, d := range drafts {
+		dedent(d)
+		switch d.kind {
+		case KindToolCall:
+			lastTool, lastArgs = d.tool, d.args
+			segs = append(segs, splitToolCall(d)...)
+		case KindToolResult, KindError:
+			seg := finish(d)
+			seg.Tool = lastTool
+			if d.kind == KindToolResult {
+				if isDiff(seg.Text) {
+					seg.Kind = KindDiff
+					seg.File = diffFile(seg.Text, lastTool, lastArgs)
+				} else if errorTextRe.MatchString(seg.Text) {
+					seg.Kind = KindError
+				}
+			}
+			// A call has one result; later errors stand alone.
+			lastTool, lastArgs = "", ""
+			segs = append(segs, seg)
+		case KindAssistant:
+			lastTool, lastArgs = "", ""
+			segs = append(segs, splitCodeBlocks(d)...)
+		default:
+			lastTool, lastArgs = "", ""
+			segs = append(segs, finish(d))
+		}
+	}
+	return segs
+}
+
+// group splits segments into turns at each user prompt.
+func group(segs []Segment) []Turn {
+	var turns []Turn
+	for _, seg := range segs {
+		if seg.Kind == KindPrompt || len(turns) == 0 {
+			turns = append(turns, Turn{Index: len(turns)})
+		}
+		t := &turns[len(turns)-1]
+		if seg.Kind == KindPrompt {
+			t.Prompt = seg.Text
+		}
+		t.Segments = append(t.Segments, seg)
+	}
+	return turns
+}
+
+func finish(d *draft) Segment {
+	d.trim()
+	seg := Segment{Kind: d.kind, Text: strings.Join(d.lines, "\n"), Tool: d.tool, Args: d.args}
+	if len(d.nums) > 0 {
+		seg.StartLine, seg.EndLine = d.nums[0], d.nums[len(d.nums)-1]
+	}
+	return seg
+}
+
+// trim drops leading and trailing empty lines.
+func (d *draft) trim() {
+	for len(d.lines) > 0 && strings.TrimSpace(d.lines[len(d.lines)-1]) == "" {
+		d.lines, d.nums = d.lines[:len(d.lines)-1], d.nums[:len(d.nums)-1]
+	}
+	for len(d.lines) > 0 && strings.TrimSpace(d.lines[0]) == "" {
+		d.lines, d.nums = d.lines[1:], d.nums[1:]
+	}
+}
+
+// dedent removes the indentation shared by every continuation line so wrapped
+// text and code keep only their relative indentation.
+func dedent(d *draft) {
+	if d.aligned {
+		return
+	}
+	minIndent := -1
+	for _, l := range d.lines[1:] {
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+		n := len(l) - len(strings.TrimLeft(l, " "))
+		if minIndent < 0 || n < minIndent {
+			minIndent = n
+		}
+	}
+	if minIndent <= 0 {
+		return
+	}
+	for i := 1; i < len(d.lines); i++ {
+		if len(d.lines[i]) >= minIndent {
+			d.lines[i] = d.lines[i][minIndent:]
+		} else {
+			d.lines[i] = strings.TrimLeft(d.lines[i], " ")
+		}
+	}
+}
+
+// slice returns a draft of the same kind holding lines [from, to).
+func (d *draft) slice(kind Kind, from, to int) *draft {
+	return &draft{kind: kind, tool: d.tool, args: d.args, lines: d.lines[from:to], nums: d.nums[from:to]}
+}
+
+// splitToolCall separates a call header from an inline body, which agents
+// that render edits under the call (Codex "• Edited path (+1 -1)") use for
+// the patch.
+func splitToolCall(d *draft) []Segment {
+	call := finish(d.slice(KindToolCall, 0, 1))
+	if len(d.lines) == 1 {
+		return []Segment{call}
+	}
+	seg := finish(d.slice(KindToolResult, 1, len(d.lines)))
+	seg.Args = ""
+	if isDiff(seg.Text) {
+		seg.Kind = KindDiff
+		seg.File = diffFile(seg.Text, d.tool, d.args)
+	}
+	return []Segment{call, seg}
+}
+
+// splitCodeBlocks cuts fenced code blocks out of assistant text.
+func splitCodeBlocks(d *draft) []Segment {
+	blocks := codeblock.NewParser().Parse(strings.Join(d.lines, "\n"))
+	if len(blocks) == 0 {
+		return []Segment{finish(d)}
+	}
+	var segs []Segment
+	pos := 0
+	text := func(from, to int) {
+		if from < to {
+			if seg := finish(d.slice(KindAssistant, from, to)); seg.Text != "" {
+				segs = append(segs, seg)
+			}
+		}
+	}
+	for _, b := range blocks {
+		// codeblock lines are 1-based and include the fences.
+		start, end := b.StartLine-1, b.EndLine
+		if start < pos || end > len(d.lines) {
+			continue
+		}
+		text(pos, start)
+		seg := Segment{
+			Kind:      KindCode,
+			Text:      strings.TrimRight(b.Content, "\n"),
+			Language:  b.Language,
+			File:      b.FilePath,
+			StartLine: d.nums[start],
+			EndLine:   d.nums[end-1],
+		}
+		if b.Language == "diff" || b.Language == "patch" || isDiff(seg.Text) {
+			seg.Kind = KindDiff
+			if seg.File == "" {
+				seg.File = diffFile(seg.Text, "", "")
+			}
+		}
+		segs = append(segs, seg)
+		pos = end
+	}
+	text(pos, len(d.lines))
+	return segs
+}
+
+var (
+	// numberedDiffLineRe matches the line-numbered +/- rows agents print
+	// under edit tools ("  12 -  old", "  12 +  new").
+	numberedDiffLineRe = regexp.MustCompile(`(?m)^\s*\d+\s+[+-](\s|$)`)
+	unifiedHunkRe      = regexp.MustCompile(`(?m)^@@ -\d+(,\d+)? \+\d+(,\d+)? @@`)
+	unifiedHeaderRe    = regexp.MustCompile(`(?m)^(diff --git |\+\+\+ (b/)?\S)`)
+	diffPathRe         = regexp.MustCompile(`(?m)^(?:\+\+\+ b/|\+\+\+ |diff --git a/\S+ b/)(\S+)`)
+	updatedPathRe      = regexp.MustCompile(`(?m)^(?:Updated|Edited|Wrote|Added|Created)\s+(?:to\s+)?(\S+?)(?:\s+with\b|\s+\(|$)`)
+	errorTextRe        = regexp.MustCompile(`(?i)^(error|fatal|panic)\b`)
+)
+
+// isDiff reports whether text is a patch: a unified diff or the numbered
+// +/- rows agents print for edits.
+func isDiff(text string) bool {
+	return unifiedHunkRe.MatchString(text) || unifiedHeaderRe.MatchString(text) || numberedDiffLineRe.MatchString(text)
+}
+
+// diffFile names the file a diff touches, from the patch header, an
+// "Updated <path>" summary, or the edit tool's argument.
+func diffFile(text, tool, args string) string {
+	if m := diffPathRe.FindStringSubmatch(text); m != nil {
+		return m[1]
+	}
+	if m := updatedPathRe.FindStringSubmatch(text); m != nil {
+		return m[1]
+	}
+	if tool != "" && args != "" {
+		f := strings.Fields(args)[0]
+		if strings.ContainsAny(f, "./") {
+			return f
+		}
+	}
+	return ""
+}
+
+var ansiRe = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(\x07|\x1b\\)`)
+
+func stripANSI(s string) string {
+	if !strings.Contains(s, "\x1b") {
+		return s
+	}
+	return ansiRe.ReplaceAllString(s, "")
+}
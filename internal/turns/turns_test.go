@@ -0,0 +1,210 @@
+package turns
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func loadFixture(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// shape summarizes segments as "kind:tool" for compact comparison.
+func shape(turns []Turn) [][]string {
+	var out [][]string
+	for _, turn := range turns {
+		var row []string
+		for _, seg := range turn.Segments {
+			s := string(seg.Kind)
+			if seg.Tool != "" {
+				s += ":" + seg.Tool
+			}
+			row = append(row, s)
+		}
+		out = append(out, row)
+	}
+	return out
+}
+
+func assertShape(t *testing.T, got []Turn, want [][]string) {
+	t.Helper()
+	gotShape := shape(got)
+	if len(gotShape) != len(want) {
+		t.Fatalf("got %d turns %v, want %d %v", len(gotShape), gotShape, len(want), want)
+	}
+	for i := range want {
+		if strings.Join(gotShape[i], " ") != strings.Join(want[i], " ") {
+			t.Errorf("turn %d:\n got  %v\n want %v", i, gotShape[i], want[i])
+		}
+	}
+}
+
+func TestParseClaudeCode(t *testing.T) {
+	turns := Parse("claude", loadFixture(t, "cc.txt"))
+	assertShape(t, turns, [][]string{
+		{"user_prompt", "assistant", "tool_call:Bash", "error:Bash", "tool_call:Read", "tool_result:Read",
+			"tool_call:Update", "diff:Update", "assistant", "code", "assistant"},
+		{"user_prompt", "tool_call:Bash", "tool_result:Bash", "assistant"},
+	})
+	if turns[0].Prompt != "fix the failing parser test" || turns[1].Prompt != "now run the linter" {
+		t.Errorf("prompts = %q, %q", turns[0].Prompt, turns[1].Prompt)
+	}
+	call := turns[0].Segments[2]
+	if call.Args != "go test ./internal/parser/..." || call.StartLine != 9 {
+		t.Errorf("tool call = %+v", call)
+	}
+	if got := turns[0].Segments[3].Text; !strings.HasPrefix(got, "Error: Exit code 1\n--- FAIL: TestParse") {
+		t.Errorf("error text = %q", got)
+	}
+
+	diff, ok := Last(turns, KindDiff)
+	if !ok || diff.File != "internal/parser/parser.go" || !strings.Contains(diff.Text, "41 +    return len(s)") {
+		t.Errorf("last diff = %+v", diff)
+	}
+	code, _ := Last(turns, KindCode)
+	if code.Language != "go" || code.Text != "func count(s string) int {\n    return len(s)\n}" {
+		t.Errorf("code = %+v", code)
+	}
+	if last, _ := Last(turns, KindAssistant); last.Text != "Lint is clean." {
+		t.Errorf("last assistant = %q", last.Text)
+	}
+}
+
+func TestParseCodex(t *testing.T) {
+	turns := Parse("cod", loadFixture(t, "cod.txt"))
+	// The busy spinner keeps the submitted "explain the fix" prompt; the
+	// idle composer placeholder after it is dropped.
+	assertShape(t, turns, [][]string{
+		{"user_prompt", "assistant", "tool_call:Ran", "tool_result:Ran", "tool_call:Explored", "tool_result:Explored",
+			"tool_call:Edited", "diff:Edited", "assistant", "error"},
+		{"user_prompt"},
+	})
+	diff, _ := Last(turns, KindDiff)
+	if diff.File != "internal/parser/parser.go" || diff.StartLine != 18 || diff.EndLine != 21 {
+		t.Errorf("diff = %+v", diff)
+	}
+	if got := turns[0].Segments[3].Text; got != "--- FAIL: TestParse (0.00s)\n    parser_test.go:42: got 3, want 4\nFAIL" {
+		t.Errorf("result text = %q", got)
+	}
+}
+
+func TestParseAntigravity(t *testing.T) {
+	for _, agentType := range []string{"agy", "gmi"} {
+		turns := Parse(agentType, loadFixture(t, "agy.txt"))
+		assertShape(t, turns, [][]string{
+			{"user_prompt", "assistant", "tool_call:Shell", "tool_result:Shell", "tool_call:WriteFile", "error:WriteFile", "assistant", "diff"},
+		})
+		if got := turns[0].Segments[3].Text; got != "--- FAIL: TestParse (0.00s)\n    parser_test.go:42: got 3, want 4" {
+			t.Errorf("%s: box result = %q", agentType, got)
+		}
+		diff, _ := Last(turns, KindDiff)
+		if diff.Language != "diff" || diff.File != "internal/parser/parser.go" {
+			t.Errorf("%s: diff = %+v", agentType, diff)
+		}
+	}
+}
+
+func TestDialectDetection(t *testing.T) {
+	for file, want := range map[string]string{"cc.txt": "cc", "cod.txt": "cod", "agy.txt": "agy"} {
+		if got := Dialect("", loadFixture(t, file)); got != want {
+			t.Errorf("Dialect(%s) = %q, want %q", file, got, want)
+		}
+	}
+	if got := Dialect("ollama", "plain text"); got != "generic" {
+		t.Errorf("Dialect(plain) = %q", got)
+	}
+	if got := Dialect("codex", ""); got != "cod" {
+		t.Errorf("Dialect(codex alias) = %q", got)
+	}
+}
+
+func TestParseGeneric(t *testing.T) {
+	out := "Starting build\n  step one\n\nerror: build failed\n```diff\n--- a/x.go\n+++ b/x.go\n@@ -1 +1 @@\n-a\n+b\n```\ndone"
+	turns := Parse("", out)
+	assertShape(t, turns, [][]string{{"assistant", "error", "diff", "assistant"}})
+	if turns[0].Segments[0].Text != "Starting build\n  step one" {
+		t.Errorf("text = %q", turns[0].Segments[0].Text)
+	}
+	if turns[0].Segments[2].File != "x.go" {
+		t.Errorf("diff file = %q", turns[0].Segments[2].File)
+	}
+}
+
+func TestComposerPromptDropped(t *testing.T) {
+	out := "❯ first\n\n⏺ Done.\n\n────────────────\n❯ half-typed draft\n────────────────\n  ? for shortcuts\n"
+	assertShape(t, Parse("cc", out), [][]string{{"user_prompt", "assistant"}})
+}
+
+func TestFilterAndTail(t *testing.T) {
+	turns := Parse("cc", loadFixture(t, "cc.txt"))
+	assertShape(t, Filter(turns, KindDiff, KindError), [][]string{{"error:Bash", "diff:Update"}})
+	if got := Tail(turns, 1); len(got) != 1 || got[0].Index != 1 {
+		t.Errorf("Tail(1) = %+v", got)
+	}
+	if got := Filter(turns); len(got) != len(turns) {
+		t.Error("Filter without kinds dropped turns")
+	}
+	last := LastByKind(turns)
+	if last[KindToolCall].Tool != "Bash" || last[KindPrompt].Text != "now run the linter" {
+		t.Errorf("LastByKind = %+v", last)
+	}
+}
+
+func TestParseKinds(t *testing.T) {
+	kinds, err := ParseKinds("diff, tool ,prompt")
+	if err != nil || len(kinds) != 3 || kinds[0] != KindDiff || kinds[1] != KindToolCall || kinds[2] != KindPrompt {
+		t.Errorf("ParseKinds = %v, %v", kinds, err)
+	}
+	if kinds, err := ParseKinds(""); err != nil || kinds != nil {
+		t.Errorf("ParseKinds(\"\") = %v, %v", kinds, err)
+	}
+	if _, err := ParseKinds("diff,bogus"); err == nil {
+		t.Error("unknown kind accepted")
+	}
+}
+
+func TestTrackerReportsNewCompleteSegments(t *testing.T) {
+	tr := NewTracker()
+	base := "❯ run tests\n\n⏺ Bash(go test ./...)\n  ⎿  ok\n"
+	if got := tr.Observe("%1", "cc", base); len(got) != 0 {
+		t.Fatalf("baseline reported %+v", got)
+	}
+	// The streaming assistant line is held back until something follows it.
+	grown := base + "\n⏺ All tests pa"
+	if got := tr.Observe("%1", "cc", grown); len(got) != 1 || got[0].Kind != KindToolResult {
+		t.Fatalf("after result = %+v", got)
+	}
+	done := base + "\n⏺ All tests pass.\n\n⏺ Bash(go vet ./...)\n"
+	got := tr.Observe("%1", "cc", done)
+	if len(got) != 1 || got[0].Kind != KindAssistant || got[0].Text != "All tests pass." || got[0].Turn != 0 {
+		t.Fatalf("after completion = %+v", got)
+	}
+	if got := tr.Observe("%1", "cc", done); len(got) != 0 {
+		t.Errorf("unchanged capture reported %+v", got)
+	}
+	// A repeated identical call is still new.
+	again := done + "  ⎿  ok\n\n⏺ Bash(go test ./...)\n  ⎿  ok\n\n⏺ Done"
+	kinds := shape([]Turn{{Segments: segmentsOf(tr.Observe("%1", "cc", again))}})
+	if strings.Join(kinds[0], " ") != "tool_call:Bash tool_result:Bash tool_call:Bash tool_result:Bash" {
+		t.Errorf("repeat = %v", kinds)
+	}
+	tr.Forget("%1")
+	if got := tr.Observe("%1", "cc", again); len(got) != 0 {
+		t.Errorf("forgotten pane reported %+v", got)
+	}
+}
+
+func segmentsOf(ts []TurnSegment) []Segment {
+	var segs []Segment
+	for _, s := range ts {
+		segs = append(segs, s.Segment)
+	}
+	return segs
+}
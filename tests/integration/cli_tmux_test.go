@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -212,3 +213,83 @@ gemini = "/bin/true"
 	// Verify session no longer exists
 	testutil.AssertSessionNotExists(t, logger, sessionName)
 }
+
+// TestCLIAddAndKillPaneKeepActiveLayout verifies that ntm add and
+// ntm kill --pane re-tile with the session's named layout instead of leaving
+// the window tiled by the split or collapsed by the kill.
+func TestCLIAddAndKillPaneKeepActiveLayout(t *testing.T) {
+	testutil.RequireNTMBinary(t)
+	testutil.RequireTmuxThrottled(t)
+
+	logger := testutil.NewTestLogger(t, t.TempDir())
+
+	projectsBase := t.TempDir()
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	configContents := fmt.Sprintf(`projects_base = "%s"
+
+[agents]
+claude = "/bin/true"
+codex = "/bin/true"
+gemini = "/bin/true"
+`, projectsBase)
+	if err := os.WriteFile(configPath, []byte(configContents), 0644); err != nil {
+		t.Fatalf("failed to write temp config: %v", err)
+	}
+
+	t.Setenv("NTM_PROJECTS_BASE", projectsBase)
+
+	session := testutil.CreateTestSession(t, logger, testutil.SessionConfig{
+		Agents: testutil.AgentConfig{
+			Claude: 3,
+		},
+		WorkDir:   projectsBase,
+		ExtraArgs: []string{"--config", configPath},
+	})
+	testutil.AssertSessionExists(t, logger, session)
+
+	testutil.AssertCommandSuccess(t, logger, "ntm", "layout", "apply", "main-vertical", session, "--config", configPath)
+	assertMainVertical(t, session, 4)
+
+	logger.LogSection("Adding Claude Agent")
+	testutil.AssertCommandSuccess(t, logger, "ntm", "add", "--config", configPath, session, "--cc=1")
+	time.Sleep(500 * time.Millisecond)
+	assertMainVertical(t, session, 5)
+
+	logger.LogSection("Removing Claude Agent")
+	out, err := exec.Command(tmux.BinaryPath(), "list-panes", "-t", session, "-F", "#{pane_id}").Output()
+	if err != nil {
+		t.Fatalf("list panes: %v", err)
+	}
+	ids := strings.Fields(string(out))
+	testutil.AssertCommandSuccess(t, logger, "ntm", "kill", "--config", configPath, session, "--pane="+ids[len(ids)-1], "-f")
+	time.Sleep(300 * time.Millisecond)
+	assertMainVertical(t, session, 4)
+}
+
+// assertMainVertical checks the session is one window of want panes with the
+// user pane alone in the left column and every agent beside it.
+func assertMainVertical(t *testing.T, session string, want int) {
+	t.Helper()
+	out, err := exec.Command(tmux.BinaryPath(), "list-panes", "-s", "-t", session,
+		"-F", "#{window_id}\t#{pane_title}\t#{pane_left}").Output()
+	if err != nil {
+		t.Fatalf("list panes: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(lines) != want {
+		t.Fatalf("session has %d panes, want %d:\n%s", len(lines), want, out)
+	}
+	window := ""
+	for _, line := range lines {
+		f := strings.Split(line, "\t")
+		if window == "" {
+			window = f[0]
+		}
+		if f[0] != window {
+			t.Fatalf("panes span several windows:\n%s", out)
+		}
+		if user := strings.Contains(f[1], "__user"); user != (f[2] == "0") {
+			t.Fatalf("user pane is not alone in the main column:\n%s", out)
+		}
+	}
+}
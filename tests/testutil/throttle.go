@@ -131,6 +131,29 @@ func AcquireGlobalTmuxTestLockForTest(t *testing.T) {
 	acquireGlobalTmuxTestLock(t)
 }
 
+// IsolateWorkingDirProcess changes the test binary's working directory to a
+// process-private temp dir. Code that defaults its state root to the current
+// directory (pipeline run state under .ntm/pipelines, the human inbox under
+// .ntm/human_inbox) would otherwise write into the package source tree on
+// every `go test`. Call from TestMain before m.Run(); the returned cleanup
+// restores the original directory and removes the temp dir.
+func IsolateWorkingDirProcess() (func() error, error) {
+	orig, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("resolve working dir: %w", err)
+	}
+	dir, err := os.MkdirTemp("", "ntm-test-cwd-")
+	if err != nil {
+		return nil, fmt.Errorf("create private working dir: %w", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		return nil, errors.Join(fmt.Errorf("enter private working dir: %w", err), os.RemoveAll(dir))
+	}
+	return func() error {
+		return errors.Join(os.Chdir(orig), os.RemoveAll(dir))
+	}, nil
+}
+
 // IsolateTmuxTestProcess gives a package test binary its own tmux server and
 // IsolateGitConfigProcess points git's global and system configuration at
 // empty process-private locations so neither tests nor code under test that